@@ -8,10 +8,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/approval"
 	"github.com/felixgeelhaar/agent-go/domain/artifact"
+	"github.com/felixgeelhaar/agent-go/domain/event"
 	"github.com/felixgeelhaar/agent-go/domain/knowledge"
 	"github.com/felixgeelhaar/agent-go/domain/ledger"
 	"github.com/felixgeelhaar/agent-go/domain/middleware"
@@ -21,37 +24,77 @@ import (
 	inframw "github.com/felixgeelhaar/agent-go/infrastructure/middleware"
 	"github.com/felixgeelhaar/agent-go/infrastructure/planner"
 	"github.com/felixgeelhaar/agent-go/infrastructure/resilience"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
 	"github.com/felixgeelhaar/agent-go/infrastructure/statemachine"
 )
 
 // Engine is the main orchestration service for agent execution.
 type Engine struct {
-	registry     tool.Registry
-	planner      planner.Planner
-	executor     *resilience.Executor
-	artifacts    artifact.Store
-	knowledge    knowledge.Store
-	eligibility  *policy.ToolEligibility
-	transitions  *policy.StateTransitions
-	approver     policy.Approver
-	budgetLimits map[string]int
-	maxSteps     int
-	middleware   *middleware.Registry
+	registry      tool.Registry
+	planner       planner.Planner
+	executor      *resilience.Executor
+	artifacts     artifact.Store
+	knowledge     knowledge.Store
+	eligibility   *policy.ToolEligibility
+	transitions   *policy.StateTransitions
+	approver      policy.Approver
+	approvals     *policy.ApprovalPolicy
+	snapshot      *policy.ApprovalSnapshot
+	events        event.Store
+	budgetLimits  map[string]int
+	maxSteps      int
+	middleware    *middleware.Registry
+	defaultChain  bool
+	constraints   []policy.Constraint
+	rules         *policy.RuleSet
+	versions      policy.VersionStore
+	secretHandles *secrets.HandleStore
+	base          *runPolicy
+	active        atomic.Pointer[runPolicy]
+	unsubscribe   func()
+	evaluation    atomic.Pointer[evaluation]
 }
 
 // EngineConfig contains configuration for the engine.
 type EngineConfig struct {
-	Registry     tool.Registry
-	Planner      planner.Planner
-	Executor     *resilience.Executor
-	Artifacts    artifact.Store
-	Knowledge    knowledge.Store
-	Eligibility  *policy.ToolEligibility
-	Transitions  *policy.StateTransitions
-	Approver     policy.Approver
+	Registry    tool.Registry
+	Planner     planner.Planner
+	Executor    *resilience.Executor
+	Artifacts   artifact.Store
+	Knowledge   knowledge.Store
+	Eligibility *policy.ToolEligibility
+	Transitions *policy.StateTransitions
+	Approver    policy.Approver
+	// ApprovalPolicy decides which tools require approval in the default
+	// middleware chain. When nil, tool annotations decide.
+	ApprovalPolicy *policy.ApprovalPolicy
+	// ApprovalSnapshot lists additional tools requiring approval, typically
+	// the Approvals of the active PolicyVersion.
+	ApprovalSnapshot *policy.ApprovalSnapshot
+	// Events receives each run's history as events: the run ledger, and
+	// approval events from the default middleware chain.
+	Events       event.Store
 	BudgetLimits map[string]int
 	MaxSteps     int
 	Middleware   *middleware.Registry
+	// Constraints are evaluated before every tool call and every
+	// planner-initiated transition, regardless of the middleware chain.
+	Constraints []policy.Constraint
+	// Rules are declarative policy rules. Deny rules are evaluated as a
+	// constraint after Constraints; require_approval rules are honored by
+	// the default middleware chain.
+	Rules *policy.RuleSet
+	// PolicyVersions, when set, supplies the policy: the engine applies the
+	// store's current version and, if the store implements
+	// policy.VersionNotifier, each version saved afterwards. Runs keep the
+	// version they started with.
+	PolicyVersions policy.VersionStore
+	// SecretHandles, when set, lets the default middleware chain replace
+	// secret handles in tool input with their values right before the tool
+	// runs. The ledger, events and approvers keep seeing the handle. With a
+	// custom Middleware registry, the engine appends the SecretHandles
+	// middleware to a copy of it, innermost.
+	SecretHandles *secrets.HandleStore
 }
 
 // NewEngine creates a new engine with the given configuration.
@@ -64,17 +107,31 @@ func NewEngine(config EngineConfig) (*Engine, error) {
 	}
 
 	e := &Engine{
-		registry:     config.Registry,
-		planner:      config.Planner,
-		executor:     config.Executor,
-		artifacts:    config.Artifacts,
-		knowledge:    config.Knowledge,
-		eligibility:  config.Eligibility,
-		transitions:  config.Transitions,
-		approver:     config.Approver,
-		budgetLimits: config.BudgetLimits,
-		maxSteps:     config.MaxSteps,
-		middleware:   config.Middleware,
+		registry:      config.Registry,
+		planner:       config.Planner,
+		executor:      config.Executor,
+		artifacts:     config.Artifacts,
+		knowledge:     config.Knowledge,
+		eligibility:   config.Eligibility,
+		transitions:   config.Transitions,
+		approver:      config.Approver,
+		approvals:     config.ApprovalPolicy,
+		snapshot:      config.ApprovalSnapshot,
+		events:        config.Events,
+		budgetLimits:  config.BudgetLimits,
+		maxSteps:      config.MaxSteps,
+		middleware:    config.Middleware,
+		defaultChain:  config.Middleware == nil,
+		constraints:   config.Constraints,
+		rules:         config.Rules,
+		versions:      config.PolicyVersions,
+		secretHandles: config.SecretHandles,
+	}
+	// Custom chains get secret handle expansion too, innermost
+	if !e.defaultChain && e.secretHandles != nil {
+		e.middleware = config.Middleware.Clone().Use(inframw.SecretHandles(inframw.SecretHandlesConfig{
+			Handles: e.secretHandles,
+		}))
 	}
 
 	// Set defaults
@@ -90,16 +147,24 @@ func NewEngine(config EngineConfig) (*Engine, error) {
 	if e.maxSteps == 0 {
 		e.maxSteps = 100
 	}
-	if e.middleware == nil {
-		e.middleware = e.defaultMiddlewareChain()
+
+	base, err := e.buildPolicy(nil)
+	if err != nil {
+		return nil, err
+	}
+	e.base = base
+	e.middleware = base.middleware
+	e.active.Store(base)
+	if e.versions != nil {
+		e.watchPolicyVersions(context.Background())
 	}
 
 	return e, nil
 }
 
 // defaultMiddlewareChain creates the default middleware chain that replicates
-// the original inline policy enforcement behavior.
-func (e *Engine) defaultMiddlewareChain() *middleware.Registry {
+// the original inline policy enforcement behavior, for the given policy.
+func (e *Engine) defaultMiddlewareChain(p *runPolicy) *middleware.Registry {
 	registry := middleware.NewRegistry()
 
 	// Input validation (security: validate inputs before any processing)
@@ -107,20 +172,40 @@ func (e *Engine) defaultMiddlewareChain() *middleware.Registry {
 
 	// Eligibility check (tool allowed in current state)
 	registry.Use(inframw.Eligibility(inframw.EligibilityConfig{
-		Eligibility: e.eligibility,
+		Eligibility: p.eligibility,
 	}))
 
-	// Approval check (for destructive/high-risk tools)
+	// Approval check (per approval policy, policy version and rules);
+	// input modified by the approver is re-checked against the constraints
 	registry.Use(inframw.Approval(inframw.ApprovalConfig{
-		Approver: e.approver,
+		Approver:    e.approver,
+		Policy:      e.approvals,
+		Snapshot:    p.snapshot,
+		Rules:       p.rules,
+		Constraints: e.constraints,
+		Events:      e.events,
 	}))
 
+	// Per-tool rate limits, circuit breakers and timeouts (policy version)
+	if p.tools != nil {
+		registry.Use(inframw.ToolLimits(inframw.ToolLimitsConfig{
+			Settings: p.tools,
+		}))
+	}
+
 	// Logging (execution timing and results)
 	registry.Use(inframw.Logging(inframw.LoggingConfig{
 		LogInput:  false,
 		LogOutput: false,
 	}))
 
+	// Secret handle expansion (innermost, after the call is recorded and approved)
+	if e.secretHandles != nil {
+		registry.Use(inframw.SecretHandles(inframw.SecretHandlesConfig{
+			Handles: e.secretHandles,
+		}))
+	}
+
 	return registry
 }
 
@@ -134,20 +219,23 @@ func (e *Engine) RunWithVars(ctx context.Context, goal string, vars map[string]a
 	// Generate run ID
 	runID := generateRunID()
 
-	// Create run
+	// Create run under the active policy (or an evaluated candidate), kept
+	// for the whole run
+	pol := e.evaluatedPolicy()
 	run := agent.NewRun(runID, goal)
+	run.PolicyVersion = pol.version
 	for k, v := range vars {
 		run.SetVar(k, v)
 	}
 
 	// Create supporting components
-	budget := policy.NewBudget(e.budgetLimits)
-	runLedger := ledger.New(runID)
+	budget := policy.NewBudget(pol.budgetLimits)
+	runLedger := e.newRunLedger(ctx, run)
 
 	// Create state machine context
 	machineCtx := statemachine.NewContext(run, budget, runLedger)
-	machineCtx.Eligibility = e.eligibility
-	machineCtx.Transitions = e.transitions
+	machineCtx.Eligibility = pol.eligibility
+	machineCtx.Transitions = pol.transitions
 
 	// Create state machine
 	machine, err := statemachine.NewAgentMachine()
@@ -162,65 +250,14 @@ func (e *Engine) RunWithVars(ctx context.Context, goal string, vars map[string]a
 	logging.Info().
 		Add(logging.RunID(runID)).
 		Add(logging.Goal(goal)).
+		Add(logging.Int("policy_version", pol.version)).
 		Msg("run started")
 
 	// Start state machine
 	interp.Start()
 	runLedger.RecordRunStarted(goal)
 
-	// Execute until terminal state or max steps
-	steps := 0
-	for !interp.IsTerminal() && steps < e.maxSteps {
-		select {
-		case <-ctx.Done():
-			run.Fail("context cancelled")
-			runLedger.RecordRunFailed(run.CurrentState, "context cancelled")
-			return run, ctx.Err()
-		default:
-		}
-
-		if err := e.step(ctx, interp, machineCtx); err != nil {
-			// Handle human input request specially - not a failure
-			if errors.Is(err, agent.ErrAwaitingHumanInput) {
-				logging.Info().
-					Add(logging.RunID(runID)).
-					Add(logging.State(run.CurrentState)).
-					Msg("run paused for human input")
-				return run, err
-			}
-
-			run.Fail(err.Error())
-			runLedger.RecordRunFailed(run.CurrentState, err.Error())
-
-			logging.Error().
-				Add(logging.RunID(runID)).
-				Add(logging.State(run.CurrentState)).
-				Add(logging.ErrorField(err)).
-				Msg("run failed")
-
-			return run, err
-		}
-		steps++
-	}
-
-	if steps >= e.maxSteps && !interp.IsTerminal() {
-		run.Fail("max steps exceeded")
-		runLedger.RecordRunFailed(run.CurrentState, "max steps exceeded")
-		return run, errors.New("max steps exceeded")
-	}
-
-	// Log completion
-	logging.Info().
-		Add(logging.RunID(runID)).
-		Add(logging.State(run.CurrentState)).
-		Add(logging.Duration(run.Duration())).
-		Msg("run completed")
-
-	if run.Status == agent.RunStatusCompleted {
-		runLedger.RecordRunCompleted(run.Result)
-	}
-
-	return run, nil
+	return e.drive(ctx, interp, machineCtx, pol, "run completed")
 }
 
 // ResumeWithInput continues a paused run with human-provided input.
@@ -264,16 +301,17 @@ func (e *Engine) ResumeWithInput(ctx context.Context, run *agent.Run, input stri
 	run.Resume()
 
 	// Create supporting components (fresh for this segment)
-	budget := policy.NewBudget(e.budgetLimits)
-	runLedger := ledger.New(run.ID)
+	pol := e.policyFor(ctx, run)
+	budget := policy.NewBudget(pol.budgetLimits)
+	runLedger := e.newRunLedger(ctx, run)
 
 	// Record human input response in ledger
 	runLedger.RecordHumanInputResponse(run.CurrentState, question, input)
 
 	// Create state machine context
 	machineCtx := statemachine.NewContext(run, budget, runLedger)
-	machineCtx.Eligibility = e.eligibility
-	machineCtx.Transitions = e.transitions
+	machineCtx.Eligibility = pol.eligibility
+	machineCtx.Transitions = pol.transitions
 
 	// Create state machine
 	machine, err := statemachine.NewAgentMachine()
@@ -296,6 +334,89 @@ func (e *Engine) ResumeWithInput(ctx context.Context, run *agent.Run, input stri
 		Add(logging.Str("human_input", input)).
 		Msg("run resumed with human input")
 
+	return e.drive(ctx, interp, machineCtx, pol, "run completed after resume")
+}
+
+// ResumeWithApproval continues a run paused for asynchronous approval.
+// The pending tool call is replayed under its approval ticket, so a
+// queueing approver such as the approval inbox returns the recorded
+// decision: an approved call executes and the run continues, a denied or
+// expired one fails the run, and a still-pending one pauses the run again
+// with ErrAwaitingApproval.
+func (e *Engine) ResumeWithApproval(ctx context.Context, run *agent.Run) (*agent.Run, error) {
+	if run == nil {
+		return nil, errors.New("run is nil")
+	}
+	if !run.HasPendingApproval() {
+		return nil, agent.ErrNoPendingApproval
+	}
+
+	pending := *run.PendingApproval
+
+	// Replay the input recorded on the ticket, not the caller's copy of the
+	// run, so an approval only ever executes the call it was given for.
+	input := pending.Input
+	if tickets, ok := e.approver.(approval.TicketReader); ok {
+		ticket, err := tickets.Get(ctx, pending.TicketID)
+		switch {
+		case errors.Is(err, approval.ErrTicketNotFound):
+		case err != nil:
+			return nil, fmt.Errorf("failed to load approval ticket: %w", err)
+		case ticket.Request.RunID != run.ID || ticket.Request.ToolName != pending.ToolName:
+			return nil, fmt.Errorf("%w: ticket %s", approval.ErrTicketMismatch, ticket.ID)
+		default:
+			input = ticket.Request.Input
+		}
+	}
+
+	run.ClearPendingApproval()
+	run.Resume()
+
+	// Create supporting components (fresh for this segment)
+	pol := e.policyFor(ctx, run)
+	budget := policy.NewBudget(pol.budgetLimits)
+	runLedger := e.newRunLedger(ctx, run)
+
+	machineCtx := statemachine.NewContext(run, budget, runLedger)
+	machineCtx.Eligibility = pol.eligibility
+	machineCtx.Transitions = pol.transitions
+
+	machine, err := statemachine.NewAgentMachine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state machine: %w", err)
+	}
+	interp := statemachine.NewInterpreter(machine, machineCtx)
+	if err := interp.ResumeFrom(run.CurrentState); err != nil {
+		return nil, fmt.Errorf("failed to resume state machine: %w", err)
+	}
+
+	logging.Info().
+		Add(logging.RunID(run.ID)).
+		Add(logging.State(run.CurrentState)).
+		Add(logging.Str("ticket_id", pending.TicketID)).
+		Msg("run resumed after approval")
+
+	// Replay the pending call under its ticket
+	callCtx := approval.WithTicketID(ctx, pending.TicketID)
+	err = e.executeToolDecision(callCtx, interp, machineCtx, pol, &agent.CallToolDecision{
+		ToolName: pending.ToolName,
+		Input:    input,
+		Reason:   pending.Reason,
+	})
+	if err != nil {
+		return e.stop(run, runLedger, err)
+	}
+
+	return e.drive(ctx, interp, machineCtx, pol, "run completed after approval")
+}
+
+// drive steps the run until it reaches a terminal state, pauses, fails or
+// exceeds the step limit.
+func (e *Engine) drive(ctx context.Context, interp *statemachine.Interpreter, machineCtx *statemachine.Context, pol *runPolicy, completedMsg string) (*agent.Run, error) {
+	run := machineCtx.Run
+	runLedger := machineCtx.Ledger
+	defer func() { pol.recordRun(run) }()
+
 	// Execute until terminal state or max steps
 	steps := 0
 	for !interp.IsTerminal() && steps < e.maxSteps {
@@ -307,26 +428,8 @@ func (e *Engine) ResumeWithInput(ctx context.Context, run *agent.Run, input stri
 		default:
 		}
 
-		if err := e.step(ctx, interp, machineCtx); err != nil {
-			// Handle human input request specially - not a failure
-			if errors.Is(err, agent.ErrAwaitingHumanInput) {
-				logging.Info().
-					Add(logging.RunID(run.ID)).
-					Add(logging.State(run.CurrentState)).
-					Msg("run paused for human input")
-				return run, err
-			}
-
-			run.Fail(err.Error())
-			runLedger.RecordRunFailed(run.CurrentState, err.Error())
-
-			logging.Error().
-				Add(logging.RunID(run.ID)).
-				Add(logging.State(run.CurrentState)).
-				Add(logging.ErrorField(err)).
-				Msg("run failed")
-
-			return run, err
+		if err := e.step(ctx, interp, machineCtx, pol); err != nil {
+			return e.stop(run, runLedger, err)
 		}
 		steps++
 	}
@@ -342,7 +445,7 @@ func (e *Engine) ResumeWithInput(ctx context.Context, run *agent.Run, input stri
 		Add(logging.RunID(run.ID)).
 		Add(logging.State(run.CurrentState)).
 		Add(logging.Duration(run.Duration())).
-		Msg("run completed after resume")
+		Msg(completedMsg)
 
 	if run.Status == agent.RunStatusCompleted {
 		runLedger.RecordRunCompleted(run.Result)
@@ -351,8 +454,39 @@ func (e *Engine) ResumeWithInput(ctx context.Context, run *agent.Run, input stri
 	return run, nil
 }
 
+// stop ends a run segment on a step error: pauses for human input or
+// approval are returned as-is, anything else fails the run.
+func (e *Engine) stop(run *agent.Run, runLedger *ledger.Ledger, err error) (*agent.Run, error) {
+	// Handle human input and approval requests specially - not a failure
+	switch {
+	case errors.Is(err, agent.ErrAwaitingHumanInput):
+		logging.Info().
+			Add(logging.RunID(run.ID)).
+			Add(logging.State(run.CurrentState)).
+			Msg("run paused for human input")
+		return run, err
+	case errors.Is(err, agent.ErrAwaitingApproval):
+		logging.Info().
+			Add(logging.RunID(run.ID)).
+			Add(logging.State(run.CurrentState)).
+			Msg("run paused for approval")
+		return run, err
+	}
+
+	run.Fail(err.Error())
+	runLedger.RecordRunFailed(run.CurrentState, err.Error())
+
+	logging.Error().
+		Add(logging.RunID(run.ID)).
+		Add(logging.State(run.CurrentState)).
+		Add(logging.ErrorField(err)).
+		Msg("run failed")
+
+	return run, err
+}
+
 // step executes a single step of the agent.
-func (e *Engine) step(ctx context.Context, interp *statemachine.Interpreter, machineCtx *statemachine.Context) error {
+func (e *Engine) step(ctx context.Context, interp *statemachine.Interpreter, machineCtx *statemachine.Context, pol *runPolicy) error {
 	run := machineCtx.Run
 	runLedger := machineCtx.Ledger
 
@@ -386,13 +520,13 @@ func (e *Engine) step(ctx context.Context, interp *statemachine.Interpreter, mac
 	// Execute decision
 	switch decision.Type {
 	case agent.DecisionCallTool:
-		return e.executeToolDecision(ctx, interp, machineCtx, decision.CallTool)
+		return e.executeToolDecision(ctx, interp, machineCtx, pol, decision.CallTool)
 	case agent.DecisionTransition:
-		return e.executeTransition(ctx, interp, decision.Transition)
+		return e.executeTransition(ctx, interp, machineCtx, pol, decision.Transition)
 	case agent.DecisionAskHuman:
 		return e.executeAskHuman(ctx, interp, machineCtx, decision.AskHuman)
 	case agent.DecisionFinish:
-		return e.executeFinish(ctx, interp, machineCtx, decision.Finish)
+		return e.executeFinish(ctx, interp, machineCtx, pol, decision.Finish)
 	case agent.DecisionFail:
 		return e.executeFail(ctx, interp, machineCtx, decision.Fail)
 	default:
@@ -401,7 +535,7 @@ func (e *Engine) step(ctx context.Context, interp *statemachine.Interpreter, mac
 }
 
 // executeToolDecision executes a tool call decision using the middleware chain.
-func (e *Engine) executeToolDecision(ctx context.Context, _ *statemachine.Interpreter, machineCtx *statemachine.Context, decision *agent.CallToolDecision) error {
+func (e *Engine) executeToolDecision(ctx context.Context, _ *statemachine.Interpreter, machineCtx *statemachine.Context, pol *runPolicy, decision *agent.CallToolDecision) error {
 	run := machineCtx.Run
 	runLedger := machineCtx.Ledger
 	budget := machineCtx.Budget
@@ -420,27 +554,62 @@ func (e *Engine) executeToolDecision(ctx context.Context, _ *statemachine.Interp
 
 	// Build execution context for middleware
 	execCtx := &middleware.ExecutionContext{
-		RunID:        run.ID,
-		CurrentState: run.CurrentState,
-		Tool:         t,
-		Input:        decision.Input,
-		Reason:       decision.Reason,
-		Budget:       budget,
-		Vars:         run.Vars,
+		RunID:         run.ID,
+		CurrentState:  run.CurrentState,
+		Tool:          t,
+		Input:         decision.Input,
+		Reason:        decision.Reason,
+		Budget:        budget,
+		Vars:          run.Vars,
+		EvidenceCount: len(run.Evidence),
+		History:       toolHistory(run),
+		Ledger:        runLedger,
 	}
 
+	// Check the call against the policy under evaluation, if any
+	e.compareToolCall(pol, execCtx)
+
 	// Record tool call in ledger
 	runLedger.RecordToolCall(run.CurrentState, decision.ToolName, decision.Input)
 
 	// Core handler wraps the resilient executor
 	coreHandler := func(ctx context.Context, ec *middleware.ExecutionContext) (tool.Result, error) {
+		run.RecordToolCall(ec.Tool.Name(), ec.CurrentState)
 		return e.executor.Execute(ctx, ec.Tool, ec.Input)
 	}
 
-	// Execute through middleware chain
-	handler := e.middleware.Chain()(coreHandler)
+	// Execute through middleware chain, with constraints evaluated first.
+	// Tools can charge metered usage to the run's budget via the context.
+	ctx = policy.WithBudget(ctx, budget)
+	handler := pol.middleware.Chain()(coreHandler)
+	if len(pol.constraints) > 0 {
+		handler = inframw.Constraints(inframw.ConstraintConfig{
+			Constraints: pol.constraints,
+			OnViolation: func(ec *middleware.ExecutionContext, reason string) {
+				runLedger.RecordConstraintViolation(ec.CurrentState, ec.Tool.Name(), "", reason)
+			},
+		})(handler)
+	}
 	result, err := handler(ctx, execCtx)
 
+	// A queued approval pauses the run rather than failing the call
+	if errors.Is(err, agent.ErrAwaitingApproval) {
+		pending := agent.PendingApproval{
+			ToolName:    decision.ToolName,
+			Input:       decision.Input,
+			Reason:      decision.Reason,
+			RequestedAt: time.Now(),
+		}
+		var pendingErr *approval.PendingError
+		if errors.As(err, &pendingErr) {
+			pending.TicketID = pendingErr.Ticket.ID
+			pending.RequestedAt = pendingErr.Ticket.CreatedAt
+			pending.ExpiresAt = pendingErr.Ticket.ExpiresAt
+		}
+		run.AwaitApproval(pending)
+		return err
+	}
+
 	// Handle errors
 	if err != nil {
 		runLedger.RecordToolError(run.CurrentState, decision.ToolName, err)
@@ -459,10 +628,54 @@ func (e *Engine) executeToolDecision(ctx context.Context, _ *statemachine.Interp
 }
 
 // executeTransition executes a state transition decision.
-func (e *Engine) executeTransition(_ context.Context, interp *statemachine.Interpreter, decision *agent.TransitionDecision) error {
+func (e *Engine) executeTransition(_ context.Context, interp *statemachine.Interpreter, machineCtx *statemachine.Context, pol *runPolicy, decision *agent.TransitionDecision) error {
+	e.compareTransition(pol, machineCtx, decision.ToState)
+	if err := e.checkTransitionConstraints(machineCtx, pol, decision.ToState); err != nil {
+		return err
+	}
 	return interp.Transition(decision.ToState, decision.Reason)
 }
 
+// checkTransitionConstraints evaluates the policy's constraints for a
+// transition and records a denial in the ledger.
+func (e *Engine) checkTransitionConstraints(machineCtx *statemachine.Context, pol *runPolicy, to agent.State) error {
+	if len(pol.constraints) == 0 {
+		return nil
+	}
+	run := machineCtx.Run
+	ok, reason := policy.EvaluateConstraints(transitionContext(machineCtx, to), pol.constraints...)
+	if !ok {
+		machineCtx.Ledger.RecordConstraintViolation(run.CurrentState, "", to, reason)
+		return fmt.Errorf("%w: %s", policy.ErrConstraintViolation, reason)
+	}
+	return nil
+}
+
+// transitionContext builds the constraint context for a transition.
+func transitionContext(machineCtx *statemachine.Context, to agent.State) policy.ConstraintContext {
+	run := machineCtx.Run
+	return policy.ConstraintContext{
+		RunID:         run.ID,
+		CurrentState:  run.CurrentState,
+		ToState:       to,
+		Budget:        machineCtx.Budget,
+		Vars:          run.Vars,
+		EvidenceCount: len(run.Evidence),
+		History:       toolHistory(run),
+	}
+}
+
+// toolHistory lists the tool calls the run has executed. It is kept on
+// the run rather than the ledger, which starts afresh with every resumed
+// segment.
+func toolHistory(run *agent.Run) []policy.ToolCallRecord {
+	history := make([]policy.ToolCallRecord, len(run.ToolCalls))
+	for i, call := range run.ToolCalls {
+		history[i] = policy.ToolCallRecord{ToolName: call.ToolName, State: call.State}
+	}
+	return history
+}
+
 // executeAskHuman handles human input requests by pausing the run.
 func (e *Engine) executeAskHuman(_ context.Context, _ *statemachine.Interpreter, machineCtx *statemachine.Context, decision *agent.AskHumanDecision) error {
 	run := machineCtx.Run
@@ -485,8 +698,12 @@ func (e *Engine) executeAskHuman(_ context.Context, _ *statemachine.Interpreter,
 }
 
 // executeFinish completes the run successfully.
-func (e *Engine) executeFinish(_ context.Context, interp *statemachine.Interpreter, machineCtx *statemachine.Context, decision *agent.FinishDecision) error {
+func (e *Engine) executeFinish(_ context.Context, interp *statemachine.Interpreter, machineCtx *statemachine.Context, pol *runPolicy, decision *agent.FinishDecision) error {
 	run := machineCtx.Run
+	e.compareTransition(pol, machineCtx, agent.StateDone)
+	if err := e.checkTransitionConstraints(machineCtx, pol, agent.StateDone); err != nil {
+		return err
+	}
 	// Transition first, then mark complete (order matters - transition checks current state)
 	if err := interp.Transition(agent.StateDone, decision.Summary); err != nil {
 		return err
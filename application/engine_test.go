@@ -4,13 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/event"
+	"github.com/felixgeelhaar/agent-go/domain/ledger"
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
 	"github.com/felixgeelhaar/agent-go/domain/policy"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
+	approvalinbox "github.com/felixgeelhaar/agent-go/infrastructure/approval"
 	"github.com/felixgeelhaar/agent-go/infrastructure/planner"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+	"github.com/felixgeelhaar/agent-go/infrastructure/statemachine"
 	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
 )
 
@@ -882,6 +890,596 @@ func TestRun_EvidenceCollection(t *testing.T) {
 	}
 }
 
+// Constraint Tests
+
+func TestRun_Constraints(t *testing.T) {
+	registry := newTestRegistry(newTestTool("search", true))
+	eligibility := newTestEligibility(map[agent.State][]string{
+		agent.StateExplore: {"search"},
+	})
+	searchStep := planner.ScriptStep{
+		ExpectState: agent.StateExplore,
+		Decision:    agent.NewCallToolDecision("search", json.RawMessage(`{}`), "search"),
+	}
+	script := func(searches int) *planner.ScriptedPlanner {
+		steps := []planner.ScriptStep{{
+			ExpectState: agent.StateIntake,
+			Decision:    agent.NewTransitionDecision(agent.StateExplore, "start"),
+		}}
+		for range searches {
+			steps = append(steps, searchStep)
+		}
+		return planner.NewScriptedPlanner(append(steps,
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewTransitionDecision(agent.StateDecide, "decide")},
+			planner.ScriptStep{ExpectState: agent.StateDecide, Decision: agent.NewTransitionDecision(agent.StateAct, "act")},
+			planner.ScriptStep{ExpectState: agent.StateAct, Decision: agent.NewTransitionDecision(agent.StateValidate, "validate")},
+			planner.ScriptStep{ExpectState: agent.StateValidate, Decision: agent.NewFinishDecision("done", nil)},
+		)...)
+	}
+
+	tests := []struct {
+		name     string
+		searches int
+		wantErr  string
+	}{
+		{"satisfied", 2, ""},
+		{"too few explore calls", 1, "act requires 2 tool calls in explore first, have 1"},
+		{"too many tool calls", 3, "tool search may be called at most 2 times per run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := NewEngineWithOptions(
+				WithRegistry(registry),
+				WithPlanner(script(tt.searches)),
+				WithEligibility(eligibility),
+				WithConstraints(policy.MaxToolCalls{Tool: "search", Max: 2}),
+				WithConstraints(policy.RequireCallsBefore{State: agent.StateAct, After: agent.StateExplore, Calls: 2}),
+			)
+			if err != nil {
+				t.Fatalf("failed to create engine: %v", err)
+			}
+
+			run, err := engine.Run(context.Background(), "test constraints")
+			if tt.wantErr == "" {
+				if err != nil || run.Status != agent.RunStatusCompleted {
+					t.Fatalf("expected completion, got %s: %v", run.Status, err)
+				}
+				return
+			}
+			if !errors.Is(err, policy.ErrConstraintViolation) {
+				t.Fatalf("expected ErrConstraintViolation, got %v", err)
+			}
+			if run.Status != agent.RunStatusFailed || !strings.Contains(run.Error, tt.wantErr) {
+				t.Errorf("expected failure with %q, got %s: %s", tt.wantErr, run.Status, run.Error)
+			}
+		})
+	}
+}
+
+func TestRun_ToolCallLimitsSurvivePause(t *testing.T) {
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("search", true))),
+		WithPlanner(planner.NewScriptedPlanner(
+			planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("search", json.RawMessage(`{}`), "search")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.Decision{
+				Type:     agent.DecisionAskHuman,
+				AskHuman: &agent.AskHumanDecision{Question: "Search again?"},
+			}},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("search", json.RawMessage(`{}`), "search again")},
+		)),
+		WithEligibility(newTestEligibility(map[agent.State][]string{agent.StateExplore: {"search"}})),
+		WithConstraints(policy.MaxToolCalls{Tool: "search", Max: 1}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	run, err := engine.Run(context.Background(), "search once")
+	if !errors.Is(err, agent.ErrAwaitingHumanInput) {
+		t.Fatalf("expected the run to pause, got %v", err)
+	}
+	if len(run.ToolCalls) != 1 {
+		t.Fatalf("expected one recorded tool call, got %+v", run.ToolCalls)
+	}
+
+	// The resumed segment still sees the call made before the pause.
+	run, err = engine.ResumeWithInput(context.Background(), run, "yes")
+	if !errors.Is(err, policy.ErrConstraintViolation) || !strings.Contains(run.Error, "at most 1 times") {
+		t.Errorf("expected the call limit to hold after resuming, got %v (%s)", err, run.Error)
+	}
+}
+
+func TestRun_FailedToolCallsCount(t *testing.T) {
+	engine, err := NewEngine(EngineConfig{
+		Registry: newTestRegistry(newFailingTool("flaky", errors.New("unavailable"))),
+		Planner: planner.NewScriptedPlanner(
+			planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("flaky", json.RawMessage(`{}`), "try")},
+		),
+		Eligibility: newTestEligibility(map[agent.State][]string{agent.StateExplore: {"flaky"}}),
+	})
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	run, _ := engine.Run(context.Background(), "call a failing tool")
+	if len(run.ToolCalls) != 1 || run.ToolCalls[0].ToolName != "flaky" || run.ToolCalls[0].State != agent.StateExplore {
+		t.Errorf("expected the failed call to be recorded, got %+v", run.ToolCalls)
+	}
+}
+
+func TestRun_ApprovalSnapshotRecordsEvents(t *testing.T) {
+	snapshot := policy.NewApprovalSnapshot()
+	snapshot.RequireApproval("send_report")
+	events := memory.NewEventStore()
+
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("send_report", false))),
+		WithPlanner(planner.NewScriptedPlanner(
+			planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("send_report", json.RawMessage(`{}`), "report")},
+		)),
+		WithEligibility(newTestEligibility(map[agent.State][]string{agent.StateExplore: {"send_report"}})),
+		WithApprover(policy.NewDenyApprover("not today")),
+		WithApprovalSnapshot(snapshot),
+		WithEventStore(events),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	run, err := engine.Run(context.Background(), "send the report")
+	if !errors.Is(err, tool.ErrApprovalDenied) {
+		t.Fatalf("expected approval denial, got %v", err)
+	}
+
+	stored, err := events.LoadEvents(context.Background(), run.ID)
+	if err != nil {
+		t.Fatalf("failed to load events: %v", err)
+	}
+	// Approval events are stored in order with the run's history
+	want := []event.Type{
+		event.TypeRunStarted,
+		event.TypeDecisionMade, event.TypeStateTransitioned,
+		event.TypeDecisionMade, event.TypeToolCalled,
+		event.TypeApprovalRequested, event.TypeApprovalDenied,
+		event.TypeToolFailed, event.TypeRunFailed,
+	}
+	if len(stored) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), stored)
+	}
+	for i, evt := range stored {
+		if evt.Type != want[i] {
+			t.Errorf("event %d: expected %s, got %s", i, want[i], evt.Type)
+		}
+	}
+}
+
+func TestRun_ApprovalInbox(t *testing.T) {
+	// send_report echoes its input, so the evidence shows what ran.
+	sendReport, err := tool.NewBuilder("send_report").
+		WithHandler(func(_ context.Context, input json.RawMessage) (tool.Result, error) {
+			return tool.Result{Output: input}, nil
+		}).
+		Build()
+	if err != nil {
+		t.Fatalf("failed to build tool: %v", err)
+	}
+	newEngine := func(inbox *approvalinbox.Inbox) *Engine {
+		engine, err := NewEngineWithOptions(
+			WithRegistry(newTestRegistry(sendReport)),
+			WithPlanner(planner.NewScriptedPlanner(
+				planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+				planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("send_report", json.RawMessage(`{"to":"ops"}`), "report")},
+				planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewTransitionDecision(agent.StateDecide, "reported")},
+				planner.ScriptStep{ExpectState: agent.StateDecide, Decision: agent.NewFinishDecision("done", json.RawMessage(`{}`))},
+			)),
+			WithEligibility(newTestEligibility(map[agent.State][]string{agent.StateExplore: {"send_report"}})),
+			WithApprover(inbox),
+			WithApprovalPolicy(policy.ApprovalPolicy{RequireForTools: []string{"send_report"}}),
+		)
+		if err != nil {
+			t.Fatalf("failed to create engine: %v", err)
+		}
+		return engine
+	}
+	pause := func(t *testing.T, engine *Engine) *agent.Run {
+		t.Helper()
+		run, err := engine.Run(context.Background(), "send the report")
+		if !errors.Is(err, agent.ErrAwaitingApproval) {
+			t.Fatalf("expected the run to await approval, got %v", err)
+		}
+		if run.Status != agent.RunStatusPaused || !run.HasPendingApproval() {
+			t.Fatalf("expected a paused run with a pending approval, got %s", run.Status)
+		}
+		if run.PendingApproval.ToolName != "send_report" || string(run.PendingApproval.Input) != `{"to":"ops"}` {
+			t.Errorf("unexpected pending approval: %+v", run.PendingApproval)
+		}
+		return run
+	}
+
+	t.Run("approved", func(t *testing.T) {
+		inbox, err := approvalinbox.NewInbox(approvalinbox.InboxConfig{Store: memory.NewApprovalStore()})
+		if err != nil {
+			t.Fatalf("failed to create inbox: %v", err)
+		}
+		engine := newEngine(inbox)
+		run := pause(t, engine)
+		ticketID := run.PendingApproval.TicketID
+
+		// Resuming before a decision pauses again on the same ticket.
+		run, err = engine.ResumeWithApproval(context.Background(), run)
+		if !errors.Is(err, agent.ErrAwaitingApproval) || run.PendingApproval.TicketID != ticketID {
+			t.Fatalf("expected to keep waiting on %s, got %v", ticketID, err)
+		}
+
+		if _, err := inbox.Decide(context.Background(), ticketID, policy.ApprovalResponse{Approved: true, Approver: "alice"}); err != nil {
+			t.Fatalf("failed to decide: %v", err)
+		}
+		run, err = engine.ResumeWithApproval(context.Background(), run)
+		if err != nil {
+			t.Fatalf("failed to resume: %v", err)
+		}
+		if run.Status != agent.RunStatusCompleted || run.HasPendingApproval() {
+			t.Errorf("expected a completed run, got %s", run.Status)
+		}
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		inbox, err := approvalinbox.NewInbox(approvalinbox.InboxConfig{Store: memory.NewApprovalStore()})
+		if err != nil {
+			t.Fatalf("failed to create inbox: %v", err)
+		}
+		engine := newEngine(inbox)
+		run := pause(t, engine)
+
+		if _, err := inbox.Decide(context.Background(), run.PendingApproval.TicketID, policy.ApprovalResponse{Approved: false, Approver: "bob", Reason: "not now"}); err != nil {
+			t.Fatalf("failed to decide: %v", err)
+		}
+		run, err = engine.ResumeWithApproval(context.Background(), run)
+		if !errors.Is(err, tool.ErrApprovalDenied) || run.Status != agent.RunStatusFailed {
+			t.Errorf("expected a failed run, got %v (%s)", err, run.Status)
+		}
+	})
+
+	t.Run("replays the ticket once", func(t *testing.T) {
+		inbox, err := approvalinbox.NewInbox(approvalinbox.InboxConfig{Store: memory.NewApprovalStore()})
+		if err != nil {
+			t.Fatalf("failed to create inbox: %v", err)
+		}
+		engine := newEngine(inbox)
+		run := pause(t, engine)
+		if _, err := inbox.Decide(context.Background(), run.PendingApproval.TicketID, policy.ApprovalResponse{Approved: true, Approver: "alice"}); err != nil {
+			t.Fatalf("failed to decide: %v", err)
+		}
+
+		// The caller's copy of the run is not trusted for the input.
+		pending := *run.PendingApproval
+		pending.Input = json.RawMessage(`{"to":"everyone"}`)
+		stale := *run
+		stalePending := pending
+		stale.PendingApproval = &stalePending
+		run.PendingApproval = &pending
+
+		run, err = engine.ResumeWithApproval(context.Background(), run)
+		if err != nil {
+			t.Fatalf("failed to resume: %v", err)
+		}
+		if got := string(run.Evidence[len(run.Evidence)-1].Content); got != `{"to":"ops"}` {
+			t.Errorf("expected the approved input to run, got %s", got)
+		}
+
+		// A second resume of a stale copy cannot run the call again.
+		if _, err := engine.ResumeWithApproval(context.Background(), &stale); !errors.Is(err, approval.ErrTicketConsumed) {
+			t.Errorf("expected ErrTicketConsumed, got %v", err)
+		}
+	})
+
+	t.Run("no pending approval", func(t *testing.T) {
+		inbox, err := approvalinbox.NewInbox(approvalinbox.InboxConfig{Store: memory.NewApprovalStore()})
+		if err != nil {
+			t.Fatalf("failed to create inbox: %v", err)
+		}
+		if _, err := newEngine(inbox).ResumeWithApproval(context.Background(), agent.NewRun("run-1", "goal")); !errors.Is(err, agent.ErrNoPendingApproval) {
+			t.Errorf("expected ErrNoPendingApproval, got %v", err)
+		}
+	})
+}
+
+func TestRun_PolicyVersions(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewPolicyVersionStore()
+	versionWith := func(version int, tools ...string) *policy.PolicyVersion {
+		eligibility := policy.NewEligibilitySnapshot()
+		for _, name := range tools {
+			eligibility.AddTool(agent.StateExplore, name)
+		}
+		return &policy.PolicyVersion{Version: version, Eligibility: eligibility, Approvals: policy.NewApprovalSnapshot()}
+	}
+	v1 := versionWith(1, "read_file")
+	if err := store.Save(ctx, v1); err != nil {
+		t.Fatalf("failed to save version: %v", err)
+	}
+
+	completing := func(condition func(planner.PlanRequest) bool) []planner.ScriptStep {
+		return []planner.ScriptStep{
+			{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("read_file", json.RawMessage(`{}`), "read"), Condition: condition},
+			{ExpectState: agent.StateExplore, Decision: agent.NewTransitionDecision(agent.StateDecide, "done exploring")},
+			{ExpectState: agent.StateDecide, Decision: agent.NewFinishDecision("done", json.RawMessage(`{}`))},
+		}
+	}
+	// The first run saves version 2, which revokes read_file, while in flight.
+	var steps []planner.ScriptStep
+	steps = append(steps, completing(func(planner.PlanRequest) bool {
+		return store.Save(ctx, versionWith(2, "write_file")) == nil
+	})...)
+	steps = append(steps, completing(nil)[:2]...)
+	steps = append(steps, completing(nil)...)
+
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("read_file", true), newTestTool("write_file", false))),
+		WithPlanner(planner.NewScriptedPlanner(steps...)),
+		WithPolicyVersions(store),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	defer engine.Close()
+	if engine.PolicyVersion() != 1 {
+		t.Fatalf("expected version 1 to be applied, got %d", engine.PolicyVersion())
+	}
+
+	run, err := engine.Run(ctx, "in flight")
+	if err != nil {
+		t.Fatalf("in-flight run should keep version 1: %v", err)
+	}
+	if run.PolicyVersion != 1 || engine.PolicyVersion() != 2 {
+		t.Errorf("run version %d, engine version %d; want 1 and 2", run.PolicyVersion, engine.PolicyVersion())
+	}
+
+	run, err = engine.Run(ctx, "under version 2")
+	if !errors.Is(err, tool.ErrToolNotAllowed) || run.PolicyVersion != 2 {
+		t.Fatalf("expected version 2 to deny read_file, got %v (version %d)", err, run.PolicyVersion)
+	}
+
+	// Rolling back takes effect for the next run.
+	if err := engine.ApplyPolicyVersion(v1); err != nil {
+		t.Fatalf("failed to roll back: %v", err)
+	}
+	run, err = engine.Run(ctx, "after rollback")
+	if err != nil || run.PolicyVersion != 1 {
+		t.Errorf("expected the rollback to allow read_file, got %v (version %d)", err, run.PolicyVersion)
+	}
+}
+
+// pollingVersionStore hides the memory store's notifications.
+type pollingVersionStore struct {
+	policy.VersionStore
+}
+
+func TestEngine_RefreshPolicy(t *testing.T) {
+	ctx := context.Background()
+	store := pollingVersionStore{memory.NewPolicyVersionStore()}
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry()),
+		WithPlanner(planner.NewScriptedPlanner()),
+		WithPolicyVersions(store),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if engine.PolicyVersion() != 0 {
+		t.Fatalf("expected no policy version, got %d", engine.PolicyVersion())
+	}
+
+	if err := store.Save(ctx, &policy.PolicyVersion{Version: 1, Rules: []policy.RuleDefinition{{Name: "bad", Rule: "=>"}}}); err != nil {
+		t.Fatalf("failed to save version: %v", err)
+	}
+	if err := engine.RefreshPolicy(ctx); !errors.Is(err, policy.ErrInvalidRule) || engine.PolicyVersion() != 0 {
+		t.Errorf("expected the invalid version to be rejected, got %v (version %d)", err, engine.PolicyVersion())
+	}
+
+	if err := store.Save(ctx, &policy.PolicyVersion{Version: 2}); err != nil {
+		t.Fatalf("failed to save version: %v", err)
+	}
+	if err := engine.RefreshPolicy(ctx); err != nil || engine.PolicyVersion() != 2 {
+		t.Errorf("expected version 2, got %v (version %d)", err, engine.PolicyVersion())
+	}
+}
+
+func TestRun_PolicyVersionToolLimits(t *testing.T) {
+	ctx := context.Background()
+	guarded := tool.NewBuilder("read_file").
+		WithRiskLevel(tool.RiskHigh).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			return tool.Result{Output: json.RawMessage(`{}`)}, nil
+		}).
+		MustBuild()
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(guarded)),
+		WithPlanner(planner.NewScriptedPlanner(append(readFileRun(), readFileRun()...)...)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	// No approver is configured: only the exemption lets read_file run.
+	v := exploreVersion(1, "read_file")
+	v.Approvals.Exempt("read_file")
+	v.Tools.SetRateLimit("read_file", 1)
+	if err := engine.ApplyPolicyVersion(v); err != nil {
+		t.Fatalf("failed to apply version: %v", err)
+	}
+
+	if _, err := engine.Run(ctx, "exempt"); err != nil {
+		t.Fatalf("expected the exemption to skip approval: %v", err)
+	}
+	if _, err := engine.Run(ctx, "rate limited"); !errors.Is(err, policy.ErrRateLimitExceeded) {
+		t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+	}
+}
+
+func TestRun_SecretHandles(t *testing.T) {
+	ctx := context.Background()
+	handles := secrets.NewHandleStore(secrets.NewMemoryManager(secrets.WithInitialSecrets(map[string]string{"api/token": "s3cr3t"})))
+	h, err := handles.Issue(ctx, "api/token", 0)
+	if err != nil {
+		t.Fatalf("failed to issue handle: %v", err)
+	}
+
+	var received string
+	call := tool.NewBuilder("call_api").
+		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Token string `json:"token"`
+			}
+			_ = json.Unmarshal(input, &in)
+			received = in.Token
+			// Echo the token, as a careless API client might
+			output, _ := json.Marshal(map[string]string{"status": "ok", "token": in.Token})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+	script := func() *planner.ScriptedPlanner {
+		return planner.NewScriptedPlanner(
+			planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("call_api", json.RawMessage(`{"token":"`+h.ID+`"}`), "call")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewTransitionDecision(agent.StateDecide, "done")},
+			planner.ScriptStep{ExpectState: agent.StateDecide, Decision: agent.NewFinishDecision("done", json.RawMessage(`{}`))},
+		)
+	}
+
+	events := memory.NewEventStore()
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(call)),
+		WithPlanner(script()),
+		WithEligibility(newTestEligibility(map[agent.State][]string{agent.StateExplore: {"call_api"}})),
+		WithApprover(policy.NewAutoApprover("test")),
+		WithEventStore(events),
+		WithSecretHandles(handles),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	run, err := engine.Run(ctx, "call the api")
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if received != "s3cr3t" {
+		t.Errorf("tool received %q, want the secret value", received)
+	}
+
+	// The run history records the handle, never the value
+	stored, err := events.LoadEvents(ctx, run.ID)
+	if err != nil {
+		t.Fatalf("failed to load events: %v", err)
+	}
+	var called, requested bool
+	for _, evt := range stored {
+		if strings.Contains(string(evt.Payload), "s3cr3t") {
+			t.Errorf("%s event contains the secret value: %s", evt.Type, evt.Payload)
+		}
+		switch evt.Type {
+		case event.TypeToolCalled:
+			called = strings.Contains(string(evt.Payload), h.ID)
+		case event.TypeApprovalRequested:
+			requested = strings.Contains(string(evt.Payload), h.ID)
+		}
+	}
+	if !called || !requested {
+		t.Errorf("expected the tool call and approval request to record the handle (called=%v, requested=%v)", called, requested)
+	}
+	for _, evidence := range run.Evidence {
+		if strings.Contains(string(evidence.Content), "s3cr3t") {
+			t.Errorf("evidence contains the secret value: %s", evidence.Content)
+		}
+	}
+
+	// A custom middleware chain resolves handles too
+	received = ""
+	engine, err = NewEngineWithOptions(
+		WithRegistry(newTestRegistry(call)),
+		WithPlanner(script()),
+		WithEligibility(newTestEligibility(map[agent.State][]string{agent.StateExplore: {"call_api"}})),
+		WithMiddleware(middleware.NewRegistry()),
+		WithSecretHandles(handles),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if _, err := engine.Run(ctx, "call the api"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if received != "s3cr3t" {
+		t.Errorf("tool received %q with a custom chain, want the secret value", received)
+	}
+}
+
+func TestRun_Rules(t *testing.T) {
+	rules := policy.MustCompileRules(policy.RuleDefinition{
+		Name:   "explore-first",
+		Rule:   `to == "decide" && run.evidence < 1 => deny`,
+		Reason: "gather evidence first",
+	})
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry()),
+		WithPlanner(planner.NewScriptedPlanner(
+			planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewTransitionDecision(agent.StateDecide, "decide")},
+		)),
+		WithRules(rules),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	run, err := engine.Run(context.Background(), "test rules")
+	if !errors.Is(err, policy.ErrConstraintViolation) || !strings.Contains(run.Error, "gather evidence first") {
+		t.Errorf("expected the rule to deny the transition, got %v (%s)", err, run.Error)
+	}
+}
+
+func TestCheckTransitionConstraints_RecordsViolation(t *testing.T) {
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry()),
+		WithPlanner(planner.NewScriptedPlanner()),
+		WithConstraints(policy.ConstraintFunc(func(ctx policy.ConstraintContext) (bool, string) {
+			return ctx.EvidenceCount > 0, "no evidence gathered"
+		})),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	run := agent.NewRun("run-1", "goal")
+	runLedger := ledger.New(run.ID)
+	machineCtx := statemachine.NewContext(run, policy.NewBudget(nil), runLedger)
+
+	if err := engine.checkTransitionConstraints(machineCtx, engine.active.Load(), agent.StateDone); !errors.Is(err, policy.ErrConstraintViolation) {
+		t.Fatalf("expected ErrConstraintViolation, got %v", err)
+	}
+	entries := runLedger.EntriesByType(ledger.EntryConstraintViolation)
+	if len(entries) != 1 {
+		t.Fatalf("expected one violation entry, got %d", len(entries))
+	}
+	var details ledger.ConstraintViolationDetails
+	if err := json.Unmarshal(entries[0].Details, &details); err != nil {
+		t.Fatal(err)
+	}
+	if details.ToState != agent.StateDone || details.Reason != "no evidence gathered" {
+		t.Errorf("unexpected violation details: %+v", details)
+	}
+
+	run.AddEvidence(agent.NewToolEvidence("search", json.RawMessage(`{}`)))
+	if err := engine.checkTransitionConstraints(machineCtx, engine.active.Load(), agent.StateDone); err != nil {
+		t.Errorf("expected constraint to pass with evidence, got %v", err)
+	}
+}
+
 // Run ID Generation Tests
 
 func TestGenerateRunID_Format(t *testing.T) {
@@ -893,3 +1491,45 @@ func TestGenerateRunID_Format(t *testing.T) {
 		t.Error("expected run ID to have reasonable length")
 	}
 }
+
+func TestRun_ToolChargesRunBudget(t *testing.T) {
+	ctx := context.Background()
+
+	meter := tool.NewBuilder("metered").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, _ json.RawMessage) (tool.Result, error) {
+			budget, ok := policy.BudgetFromContext(ctx)
+			if !ok {
+				return tool.Result{}, errors.New("no run budget in context")
+			}
+			if err := budget.Consume("tokens", 40); err != nil {
+				return tool.Result{}, err
+			}
+			return tool.Result{Output: json.RawMessage(`{"status":"ok"}`)}, nil
+		}).
+		MustBuild()
+
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(meter)),
+		WithPlanner(planner.NewScriptedPlanner(
+			planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("metered", json.RawMessage(`{}`), "spend")},
+			planner.ScriptStep{
+				ExpectState: agent.StateExplore,
+				Decision:    agent.NewTransitionDecision(agent.StateDecide, "done"),
+				// The tool's spend shows up in the budget the planner sees.
+				Condition: func(req planner.PlanRequest) bool { return req.Budgets.Remaining["tokens"] == 60 },
+			},
+			planner.ScriptStep{ExpectState: agent.StateDecide, Decision: agent.NewFinishDecision("done", json.RawMessage(`{}`))},
+		)),
+		WithEligibility(newTestEligibility(map[agent.State][]string{agent.StateExplore: {"metered"}})),
+		WithBudgets(map[string]int{"tokens": 100}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+
+	if _, err := engine.Run(ctx, "spend tokens"); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+}
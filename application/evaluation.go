@@ -0,0 +1,289 @@
+package application
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/infrastructure/logging"
+	inframw "github.com/felixgeelhaar/agent-go/infrastructure/middleware"
+	"github.com/felixgeelhaar/agent-go/infrastructure/statemachine"
+)
+
+// Evaluation errors.
+var (
+	ErrEvaluationInProgress = errors.New("policy evaluation already in progress")
+	ErrNoEvaluation         = errors.New("no policy evaluation in progress")
+)
+
+// defaultMaxSamples is the number of disagreements kept in a report.
+const defaultMaxSamples = 100
+
+// EvaluationConfig configures a shadow or canary evaluation.
+type EvaluationConfig struct {
+	// Mode selects shadow or canary evaluation.
+	Mode policy.EvaluationMode
+
+	// CanaryPercent is the percentage of runs, 1 to 100, that run under the
+	// candidate in canary mode.
+	CanaryPercent int
+
+	// MaxSamples bounds the disagreements kept in the report. Defaults to 100.
+	MaxSamples int
+}
+
+// evaluation compares the active policy with a candidate on live runs.
+type evaluation struct {
+	mode       policy.EvaluationMode
+	candidate  *runPolicy
+	percent    int
+	maxSamples int
+
+	mu     sync.Mutex
+	report policy.EvaluationReport
+}
+
+// StartEvaluation evaluates candidate against runs started from now on.
+// In shadow mode every run executes under the active policy and each
+// decision is also checked against the candidate; disagreements are
+// recorded, never enforced. In canary mode the candidate is enforced on
+// CanaryPercent of runs and checked against the active policy.
+func (e *Engine) StartEvaluation(candidate *policy.PolicyVersion, cfg EvaluationConfig) error {
+	if candidate == nil {
+		return fmt.Errorf("policy version is nil")
+	}
+	switch cfg.Mode {
+	case policy.EvaluationShadow:
+	case policy.EvaluationCanary:
+		if cfg.CanaryPercent < 1 || cfg.CanaryPercent > 100 {
+			return fmt.Errorf("canary percent must be between 1 and 100, got %d", cfg.CanaryPercent)
+		}
+	default:
+		return fmt.Errorf("unknown evaluation mode %q", cfg.Mode)
+	}
+
+	p, err := e.buildPolicy(candidate)
+	if err != nil {
+		return err
+	}
+	if cfg.MaxSamples == 0 {
+		cfg.MaxSamples = defaultMaxSamples
+	}
+	ev := &evaluation{
+		mode:       cfg.Mode,
+		candidate:  p,
+		percent:    cfg.CanaryPercent,
+		maxSamples: cfg.MaxSamples,
+		report: policy.EvaluationReport{
+			Mode:             cfg.Mode,
+			CandidateVersion: candidate.Version,
+			ActiveVersion:    e.PolicyVersion(),
+			StartedAt:        time.Now(),
+		},
+	}
+	if cfg.Mode == policy.EvaluationCanary {
+		ev.report.CanaryPercent = cfg.CanaryPercent
+	}
+	if !e.evaluation.CompareAndSwap(nil, ev) {
+		return ErrEvaluationInProgress
+	}
+
+	logging.Info().
+		Add(logging.Str("mode", string(cfg.Mode))).
+		Add(logging.Int("policy_version", candidate.Version)).
+		Msg("policy evaluation started")
+	return nil
+}
+
+// StopEvaluation ends the evaluation and returns its final report. Runs in
+// flight finish under the policy they started with.
+func (e *Engine) StopEvaluation() (policy.EvaluationReport, error) {
+	ev := e.evaluation.Swap(nil)
+	if ev == nil {
+		return policy.EvaluationReport{}, ErrNoEvaluation
+	}
+	ev.mu.Lock()
+	ev.report.EndedAt = time.Now()
+	ev.mu.Unlock()
+
+	report := ev.snapshot()
+	logging.Info().
+		Add(logging.Str("mode", string(report.Mode))).
+		Add(logging.Int("policy_version", report.CandidateVersion)).
+		Add(logging.Int("decisions", report.Decisions)).
+		Add(logging.Int("disagreements", report.Disagreements)).
+		Msg("policy evaluation stopped")
+	return report, nil
+}
+
+// EvaluationReport returns the report of the evaluation in progress.
+func (e *Engine) EvaluationReport() (policy.EvaluationReport, bool) {
+	ev := e.evaluation.Load()
+	if ev == nil {
+		return policy.EvaluationReport{}, false
+	}
+	return ev.snapshot(), true
+}
+
+// evaluatedPolicy returns the policy a new run starts under: the active
+// one, or the candidate for runs sampled into a canary. While an evaluation
+// is in progress the returned policy carries the policy it is compared to.
+func (e *Engine) evaluatedPolicy() *runPolicy {
+	active := e.active.Load()
+	ev := e.evaluation.Load()
+	if ev == nil {
+		return active
+	}
+	if ev.mode == policy.EvaluationCanary && rand.IntN(100) < ev.percent { // #nosec G404 -- sampling, not security
+		return ev.compare(ev.candidate, active, true)
+	}
+	return ev.compare(active, ev.candidate, false)
+}
+
+// compare returns a copy of p, enforced and compared against shadow.
+// Canary policies enforce the candidate.
+func (ev *evaluation) compare(p, shadow *runPolicy, canary bool) *runPolicy {
+	c := *p
+	c.evaluation, c.shadow, c.canary = ev, shadow, canary
+	return &c
+}
+
+// compareToolCall checks a tool call against both policies of an
+// evaluation and records whether they agree.
+func (e *Engine) compareToolCall(pol *runPolicy, execCtx *middleware.ExecutionContext) {
+	if pol.evaluation == nil {
+		return
+	}
+	enforced, enforcedReason := e.toolVerdict(pol, execCtx)
+	shadow, shadowReason := e.toolVerdict(pol.shadow, execCtx)
+	pol.record(policy.Disagreement{
+		RunID:    execCtx.RunID,
+		State:    execCtx.CurrentState,
+		ToolName: execCtx.Tool.Name(),
+	}, enforced, enforcedReason, shadow, shadowReason)
+}
+
+// compareTransition checks a transition against both policies of an
+// evaluation and records whether they agree.
+func (e *Engine) compareTransition(pol *runPolicy, machineCtx *statemachine.Context, to agent.State) {
+	if pol.evaluation == nil {
+		return
+	}
+	enforced, enforcedReason := transitionVerdict(pol, machineCtx, to)
+	shadow, shadowReason := transitionVerdict(pol.shadow, machineCtx, to)
+	pol.record(policy.Disagreement{
+		RunID:   machineCtx.Run.ID,
+		State:   machineCtx.Run.CurrentState,
+		ToState: to,
+	}, enforced, enforcedReason, shadow, shadowReason)
+}
+
+// toolVerdict decides how the policy treats a tool call: eligibility and
+// constraints deny it; the approval policy, snapshot and rules may require
+// approval.
+func (e *Engine) toolVerdict(p *runPolicy, execCtx *middleware.ExecutionContext) (policy.Verdict, string) {
+	name := execCtx.Tool.Name()
+	if !p.eligibility.IsAllowed(execCtx.CurrentState, name) {
+		return policy.VerdictDeny, fmt.Sprintf("tool %s not allowed in state %s", name, execCtx.CurrentState)
+	}
+	if ok, reason := policy.EvaluateConstraints(inframw.ConstraintContext(execCtx), p.constraints...); !ok {
+		return policy.VerdictDeny, reason
+	}
+	if inframw.ApprovalRequired(inframw.ApprovalConfig{
+		Policy:   e.approvals,
+		Snapshot: p.snapshot,
+		Rules:    p.rules,
+	}, execCtx) {
+		return policy.VerdictRequireApproval, fmt.Sprintf("tool %s requires approval", name)
+	}
+	return policy.VerdictAllow, ""
+}
+
+// transitionVerdict decides whether the policy allows a transition.
+func transitionVerdict(p *runPolicy, machineCtx *statemachine.Context, to agent.State) (policy.Verdict, string) {
+	from := machineCtx.Run.CurrentState
+	if !p.transitions.CanTransition(from, to) {
+		return policy.VerdictDeny, fmt.Sprintf("transition from %s to %s not allowed", from, to)
+	}
+	if ok, reason := policy.EvaluateConstraints(transitionContext(machineCtx, to), p.constraints...); !ok {
+		return policy.VerdictDeny, reason
+	}
+	return policy.VerdictAllow, ""
+}
+
+// record counts a decision checked against both policies of an evaluation
+// and logs a disagreement.
+func (p *runPolicy) record(d policy.Disagreement, enforced policy.Verdict, enforcedReason string, shadow policy.Verdict, shadowReason string) {
+	activeReason, candidateReason := enforcedReason, shadowReason
+	d.Active, d.Candidate = enforced, shadow
+	if p.canary {
+		activeReason, candidateReason = shadowReason, enforcedReason
+		d.Active, d.Candidate = shadow, enforced
+	}
+	d.Reason = candidateReason
+	if d.Reason == "" {
+		d.Reason = activeReason
+	}
+
+	ev := p.evaluation
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	ev.report.Decisions++
+	if d.Active == d.Candidate {
+		return
+	}
+	ev.report.Disagreements++
+	d.Timestamp = time.Now()
+	if len(ev.report.Samples) < ev.maxSamples {
+		ev.report.Samples = append(ev.report.Samples, d)
+	}
+
+	logging.Warn().
+		Add(logging.RunID(d.RunID)).
+		Add(logging.State(d.State)).
+		Add(logging.Str("tool", d.ToolName)).
+		Add(logging.Str("to_state", string(d.ToState))).
+		Add(logging.Str("active", string(d.Active))).
+		Add(logging.Str("candidate", string(d.Candidate))).
+		Add(logging.Str("reason", d.Reason)).
+		Add(logging.Int("policy_version", ev.report.CandidateVersion)).
+		Msg("policy evaluation disagreement")
+}
+
+// recordRun counts the outcome of a finished run in its evaluation arm.
+// Paused runs are counted once they finish, if the evaluation is still in
+// progress when they resume.
+func (p *runPolicy) recordRun(run *agent.Run) {
+	if p.evaluation == nil {
+		return
+	}
+	ev := p.evaluation
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	metrics := &ev.report.Active
+	if p.canary {
+		metrics = &ev.report.Canary
+	}
+	switch run.Status {
+	case agent.RunStatusCompleted:
+		metrics.Runs++
+		metrics.Completed++
+	case agent.RunStatusFailed:
+		metrics.Runs++
+		metrics.Failed++
+	}
+}
+
+// snapshot returns a copy of the report.
+func (ev *evaluation) snapshot() policy.EvaluationReport {
+	ev.mu.Lock()
+	defer ev.mu.Unlock()
+	report := ev.report
+	report.Samples = append([]policy.Disagreement(nil), ev.report.Samples...)
+	return report
+}
@@ -0,0 +1,256 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/proposal"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/planner"
+	infraProposal "github.com/felixgeelhaar/agent-go/infrastructure/proposal"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
+)
+
+// readFileRun scripts a run that calls read_file and finishes.
+func readFileRun() []planner.ScriptStep {
+	return []planner.ScriptStep{
+		{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+		{ExpectState: agent.StateExplore, Decision: agent.NewCallToolDecision("read_file", json.RawMessage(`{}`), "read")},
+		{ExpectState: agent.StateExplore, Decision: agent.NewTransitionDecision(agent.StateDecide, "done exploring")},
+		{ExpectState: agent.StateDecide, Decision: agent.NewFinishDecision("done", json.RawMessage(`{}`))},
+	}
+}
+
+func exploreVersion(version int, tools ...string) *policy.PolicyVersion {
+	eligibility := policy.NewEligibilitySnapshot()
+	for _, name := range tools {
+		eligibility.AddTool(agent.StateExplore, name)
+	}
+	return &policy.PolicyVersion{
+		Version:     version,
+		Eligibility: eligibility,
+		Transitions: policy.NewTransitionSnapshot(),
+		Budgets:     policy.NewBudgetLimitsSnapshot(),
+		Approvals:   policy.NewApprovalSnapshot(),
+	}
+}
+
+func TestEngine_ShadowEvaluation(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("read_file", true), newTestTool("write_file", false))),
+		WithPlanner(planner.NewScriptedPlanner(readFileRun()...)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if err := engine.ApplyPolicyVersion(exploreVersion(1, "read_file")); err != nil {
+		t.Fatalf("failed to apply version: %v", err)
+	}
+
+	if _, err := engine.StopEvaluation(); !errors.Is(err, ErrNoEvaluation) {
+		t.Errorf("expected ErrNoEvaluation, got %v", err)
+	}
+	if err := engine.StartEvaluation(exploreVersion(2, "read_file"), EvaluationConfig{Mode: policy.EvaluationCanary}); err == nil {
+		t.Error("expected an error for a canary without a percentage")
+	}
+
+	// The candidate revokes read_file.
+	if err := engine.StartEvaluation(exploreVersion(2, "write_file"), EvaluationConfig{Mode: policy.EvaluationShadow}); err != nil {
+		t.Fatalf("failed to start evaluation: %v", err)
+	}
+	if err := engine.StartEvaluation(exploreVersion(3), EvaluationConfig{Mode: policy.EvaluationShadow}); !errors.Is(err, ErrEvaluationInProgress) {
+		t.Errorf("expected ErrEvaluationInProgress, got %v", err)
+	}
+
+	run, err := engine.Run(ctx, "shadowed")
+	if err != nil {
+		t.Fatalf("shadow evaluation must not be enforced: %v", err)
+	}
+	if run.PolicyVersion != 1 {
+		t.Errorf("expected the run under version 1, got %d", run.PolicyVersion)
+	}
+
+	report, err := engine.StopEvaluation()
+	if err != nil {
+		t.Fatalf("failed to stop evaluation: %v", err)
+	}
+	if report.ActiveVersion != 1 || report.CandidateVersion != 2 {
+		t.Errorf("unexpected versions: active %d, candidate %d", report.ActiveVersion, report.CandidateVersion)
+	}
+	if report.Decisions != 4 || report.Disagreements != 1 {
+		t.Errorf("expected 1 of 4 decisions to disagree, got %d of %d", report.Disagreements, report.Decisions)
+	}
+	if len(report.Samples) != 1 {
+		t.Fatalf("expected 1 sample, got %d", len(report.Samples))
+	}
+	d := report.Samples[0]
+	if d.ToolName != "read_file" || d.Active != policy.VerdictAllow || d.Candidate != policy.VerdictDeny || d.RunID != run.ID {
+		t.Errorf("unexpected disagreement: %+v", d)
+	}
+	if report.Active.Runs != 1 || report.Active.Completed != 1 || report.Canary.Runs != 0 {
+		t.Errorf("unexpected run metrics: active %+v, canary %+v", report.Active, report.Canary)
+	}
+	if report.EndedAt.IsZero() {
+		t.Error("expected the report to be closed")
+	}
+}
+
+func TestEngine_CanaryEvaluation(t *testing.T) {
+	ctx := context.Background()
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("read_file", true), newTestTool("write_file", false))),
+		WithPlanner(planner.NewScriptedPlanner(readFileRun()[:2]...)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	if err := engine.ApplyPolicyVersion(exploreVersion(1, "read_file")); err != nil {
+		t.Fatalf("failed to apply version: %v", err)
+	}
+	if err := engine.StartEvaluation(exploreVersion(2, "write_file"), EvaluationConfig{
+		Mode:          policy.EvaluationCanary,
+		CanaryPercent: 100,
+	}); err != nil {
+		t.Fatalf("failed to start evaluation: %v", err)
+	}
+
+	run, err := engine.Run(ctx, "canary")
+	if !errors.Is(err, tool.ErrToolNotAllowed) {
+		t.Fatalf("expected the canary to enforce the candidate, got %v", err)
+	}
+	if run.PolicyVersion != 2 {
+		t.Errorf("expected the run under version 2, got %d", run.PolicyVersion)
+	}
+
+	report, ok := engine.EvaluationReport()
+	if !ok {
+		t.Fatal("expected an evaluation in progress")
+	}
+	if report.Canary.Runs != 1 || report.Canary.Failed != 1 || report.Active.Runs != 0 {
+		t.Errorf("unexpected run metrics: active %+v, canary %+v", report.Active, report.Canary)
+	}
+	if report.Disagreements != 1 || report.Samples[0].Active != policy.VerdictAllow || report.Samples[0].Candidate != policy.VerdictDeny {
+		t.Errorf("unexpected disagreements: %+v", report.Samples)
+	}
+	if engine.PolicyVersion() != 1 {
+		t.Errorf("a canary must not change the active version, got %d", engine.PolicyVersion())
+	}
+}
+
+func TestProposalEvaluator(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowed   bool
+		rolledOut bool
+		version   int
+	}{
+		{name: "rolls out when the criteria hold", allowed: true, rolledOut: true, version: 2},
+		{name: "keeps the active version on disagreement", allowed: false, rolledOut: false, version: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			versions := memory.NewPolicyVersionStore()
+			if err := versions.Save(ctx, exploreVersion(1, "read_file", "write_file")); err != nil {
+				t.Fatalf("failed to save version: %v", err)
+			}
+			proposals := memory.NewProposalStore()
+			workflow := infraProposal.NewWorkflowService(proposals, versions, infraProposal.NewPolicyApplier())
+
+			prop, err := workflow.CreateProposal(ctx, "read_file access", "", "tester")
+			if err != nil {
+				t.Fatalf("failed to create proposal: %v", err)
+			}
+			change, err := infraProposal.CreateEligibilityChange(agent.StateExplore, "read_file", tt.allowed, "")
+			if err != nil {
+				t.Fatalf("failed to create change: %v", err)
+			}
+			if err := workflow.AddChange(ctx, prop.ID, *change); err != nil {
+				t.Fatalf("failed to add change: %v", err)
+			}
+			if err := workflow.Submit(ctx, prop.ID, "tester"); err != nil {
+				t.Fatalf("failed to submit: %v", err)
+			}
+			if err := workflow.Approve(ctx, prop.ID, "reviewer", "ok"); err != nil {
+				t.Fatalf("failed to approve: %v", err)
+			}
+
+			engine, err := NewEngineWithOptions(
+				WithRegistry(newTestRegistry(newTestTool("read_file", true), newTestTool("write_file", false))),
+				WithPlanner(planner.NewScriptedPlanner(readFileRun()...)),
+				WithPolicyVersions(versions),
+			)
+			if err != nil {
+				t.Fatalf("failed to create engine: %v", err)
+			}
+			defer engine.Close()
+
+			evaluator := NewProposalEvaluator(engine, workflow)
+			if err := evaluator.Start(ctx, prop.ID, EvaluationConfig{Mode: policy.EvaluationShadow},
+				policy.RolloutCriteria{MinRuns: 1}); err != nil {
+				t.Fatalf("failed to start: %v", err)
+			}
+			if outcome, err := evaluator.Check(ctx); err != nil || outcome != nil {
+				t.Fatalf("expected the evaluation to wait for runs, got %+v, %v", outcome, err)
+			}
+			if _, err := engine.Run(ctx, "evaluated"); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+
+			outcome, err := evaluator.Check(ctx)
+			if err != nil {
+				t.Fatalf("check failed: %v", err)
+			}
+			if outcome == nil || outcome.RolledOut != tt.rolledOut || outcome.Holds != tt.rolledOut {
+				t.Fatalf("unexpected outcome: %+v", outcome)
+			}
+			if engine.PolicyVersion() != tt.version {
+				t.Errorf("expected active version %d, got %d", tt.version, engine.PolicyVersion())
+			}
+
+			stored, err := proposals.Get(ctx, prop.ID)
+			if err != nil {
+				t.Fatalf("failed to get proposal: %v", err)
+			}
+			if len(stored.Evidence) != 1 || stored.Evidence[0].Type != "shadow_evaluation" {
+				t.Fatalf("expected shadow evaluation evidence, got %+v", stored.Evidence)
+			}
+			var report policy.EvaluationReport
+			if err := json.Unmarshal(stored.Evidence[0].Data, &report); err != nil || report.Active.Runs != 1 {
+				t.Errorf("unexpected evidence data: %s (%v)", stored.Evidence[0].Data, err)
+			}
+			wantStatus := proposal.ProposalStatusApproved
+			if tt.rolledOut {
+				wantStatus = proposal.ProposalStatusApplied
+			}
+			if stored.Status != wantStatus {
+				t.Errorf("expected status %s, got %s", wantStatus, stored.Status)
+			}
+		})
+	}
+}
+
+func TestProposalEvaluator_RequiresRuns(t *testing.T) {
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("read_file", true))),
+		WithPlanner(planner.NewScriptedPlanner(readFileRun()...)),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	defer engine.Close()
+
+	evaluator := NewProposalEvaluator(engine, nil)
+	err = evaluator.Start(context.Background(), "proposal-1", EvaluationConfig{Mode: policy.EvaluationShadow}, policy.RolloutCriteria{})
+	if !errors.Is(err, policy.ErrInvalidRolloutCriteria) {
+		t.Fatalf("expected ErrInvalidRolloutCriteria, got %v", err)
+	}
+	if _, err := evaluator.Check(context.Background()); !errors.Is(err, ErrNoEvaluation) {
+		t.Errorf("rejected criteria started an evaluation: %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/event"
+	"github.com/felixgeelhaar/agent-go/domain/ledger"
+	"github.com/felixgeelhaar/agent-go/infrastructure/logging"
+)
+
+// newRunLedger creates the ledger for a run segment. With an event store
+// configured, each entry is also stored as a run event, so the store holds
+// the run's history for replay and policy simulation.
+func (e *Engine) newRunLedger(ctx context.Context, run *agent.Run) *ledger.Ledger {
+	runLedger := ledger.New(run.ID)
+	if e.events == nil {
+		return runLedger
+	}
+
+	// Events recording a cancellation must still be stored
+	ctx = context.WithoutCancel(ctx)
+	runLedger.Observe(func(entry ledger.Entry) {
+		eventType, payload, ok := runEvent(run, entry)
+		if !ok {
+			return
+		}
+		evt, err := event.NewEvent(run.ID, eventType, payload)
+		if err == nil {
+			evt.Timestamp = entry.Timestamp
+			err = e.events.Append(ctx, evt)
+		}
+		if err != nil {
+			logging.Warn().
+				Add(logging.RunID(run.ID)).
+				Add(logging.Str("event_type", string(eventType))).
+				Add(logging.ErrorField(err)).
+				Msg("failed to store run event")
+		}
+	})
+	return runLedger
+}
+
+// runEvent maps a ledger entry to the event it is stored as. Approval
+// entries are not mapped: the approval middleware stores those events.
+// Human input responses are stored as evidence.added events, since the
+// engine adds them to the run's evidence; tool evidence follows from
+// tool.succeeded events.
+func runEvent(run *agent.Run, entry ledger.Entry) (event.Type, any, bool) {
+	switch entry.Type {
+	case ledger.EntryRunStarted:
+		vars := make(map[string]any, len(run.Vars))
+		for k, v := range run.Vars {
+			vars[k] = v
+		}
+		return event.TypeRunStarted, event.RunStartedPayload{
+			Goal:          run.Goal,
+			Vars:          vars,
+			PolicyVersion: run.PolicyVersion,
+		}, true
+
+	case ledger.EntryRunCompleted:
+		return event.TypeRunCompleted, event.RunCompletedPayload{
+			Result:   run.Result,
+			Duration: run.Duration(),
+		}, true
+
+	case ledger.EntryRunFailed:
+		var details map[string]string
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeRunFailed, event.RunFailedPayload{
+			Error:    details["reason"],
+			State:    entry.State,
+			Duration: run.Duration(),
+		}, true
+
+	case ledger.EntryStateTransition:
+		var details ledger.TransitionDetails
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeStateTransitioned, event.StateTransitionedPayload(details), true
+
+	case ledger.EntryDecision:
+		var details ledger.DecisionDetails
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeDecisionMade, event.DecisionMadePayload(details), true
+
+	case ledger.EntryToolCall:
+		var details ledger.ToolCallDetails
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeToolCalled, event.ToolCalledPayload{
+			ToolName: details.ToolName,
+			Input:    details.Input,
+			State:    entry.State,
+		}, true
+
+	case ledger.EntryToolResult:
+		var details ledger.ToolResultDetails
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeToolSucceeded, event.ToolSucceededPayload{
+			ToolName: details.ToolName,
+			Output:   details.Output,
+			Duration: details.Duration,
+			Cached:   details.Cached,
+		}, true
+
+	case ledger.EntryToolError:
+		var details ledger.ToolErrorDetails
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeToolFailed, event.ToolFailedPayload{
+			ToolName: details.ToolName,
+			Error:    details.Error,
+		}, true
+
+	case ledger.EntryHumanInputResponse:
+		return event.TypeEvidenceAdded, event.EvidenceAddedPayload{
+			Type:    string(agent.EvidenceHumanInput),
+			Source:  "human",
+			Content: entry.Details,
+		}, true
+
+	case ledger.EntryBudgetConsumed:
+		var details ledger.BudgetDetails
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeBudgetConsumed, event.BudgetConsumedPayload(details), true
+
+	case ledger.EntryBudgetExhausted:
+		var details ledger.BudgetDetails
+		if json.Unmarshal(entry.Details, &details) != nil {
+			return "", nil, false
+		}
+		return event.TypeBudgetExhausted, event.BudgetExhaustedPayload{BudgetName: details.BudgetName}, true
+	}
+	return "", nil, false
+}
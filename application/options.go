@@ -2,11 +2,13 @@ package application
 
 import (
 	"github.com/felixgeelhaar/agent-go/domain/artifact"
+	"github.com/felixgeelhaar/agent-go/domain/event"
 	"github.com/felixgeelhaar/agent-go/domain/middleware"
 	"github.com/felixgeelhaar/agent-go/domain/policy"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 	"github.com/felixgeelhaar/agent-go/infrastructure/planner"
 	"github.com/felixgeelhaar/agent-go/infrastructure/resilience"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
 )
 
 // Option configures the engine.
@@ -61,6 +63,49 @@ func WithApprover(a policy.Approver) Option {
 	}
 }
 
+// WithApprovalPolicy sets which tools require approval in the default
+// middleware chain.
+func WithApprovalPolicy(p policy.ApprovalPolicy) Option {
+	return func(c *EngineConfig) {
+		c.ApprovalPolicy = &p
+	}
+}
+
+// WithApprovalSnapshot requires approval for the tools in the snapshot,
+// typically the Approvals of the active policy version.
+func WithApprovalSnapshot(s policy.ApprovalSnapshot) Option {
+	return func(c *EngineConfig) {
+		c.ApprovalSnapshot = &s
+	}
+}
+
+// WithEventStore sets the event store that receives run and approval events.
+func WithEventStore(s event.Store) Option {
+	return func(c *EngineConfig) {
+		c.Events = s
+	}
+}
+
+// WithPolicyVersions sets the policy version store the engine takes its
+// policy from. The current version is applied at construction and, for
+// stores implementing policy.VersionNotifier, every version saved later is
+// applied to runs started afterwards.
+func WithPolicyVersions(store policy.VersionStore) Option {
+	return func(c *EngineConfig) {
+		c.PolicyVersions = store
+	}
+}
+
+// WithSecretHandles sets the handle store the default middleware chain
+// resolves secret handles in tool input from, after the call is recorded.
+// A custom registry set with WithMiddleware gets the SecretHandles
+// middleware appended, innermost.
+func WithSecretHandles(handles *secrets.HandleStore) Option {
+	return func(c *EngineConfig) {
+		c.SecretHandles = handles
+	}
+}
+
 // WithBudgets sets budget limits.
 func WithBudgets(limits map[string]int) Option {
 	return func(c *EngineConfig) {
@@ -86,6 +131,25 @@ func WithMiddleware(m *middleware.Registry) Option {
 	}
 }
 
+// WithConstraints registers policy constraints. They are evaluated before
+// every tool call and every planner-initiated transition, in the order
+// registered; the first one that is not satisfied denies the action.
+// Can be called multiple times.
+func WithConstraints(constraints ...policy.Constraint) Option {
+	return func(c *EngineConfig) {
+		c.Constraints = append(c.Constraints, constraints...)
+	}
+}
+
+// WithRules sets declarative policy rules. Deny rules are evaluated like
+// constraints; require_approval rules are honored by the default
+// middleware chain.
+func WithRules(rules *policy.RuleSet) Option {
+	return func(c *EngineConfig) {
+		c.Rules = rules
+	}
+}
+
 // NewEngineWithOptions creates an engine with functional options.
 func NewEngineWithOptions(opts ...Option) (*Engine, error) {
 	config := EngineConfig{}
@@ -0,0 +1,220 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/infrastructure/logging"
+)
+
+// runPolicy is the policy a run executes under. It is immutable once
+// built: applying a policy version builds a new one and swaps it in, so
+// runs already in flight keep the policy they started with.
+type runPolicy struct {
+	version      int
+	eligibility  *policy.ToolEligibility
+	transitions  *policy.StateTransitions
+	budgetLimits map[string]int
+	snapshot     *policy.ApprovalSnapshot
+	tools        *policy.ToolSettingsSnapshot
+	rules        *policy.RuleSet
+	constraints  []policy.Constraint
+	middleware   *middleware.Registry
+
+	// During a shadow or canary evaluation, decisions are also checked
+	// against shadow and reported to the evaluation. canary marks a run
+	// enforcing the candidate.
+	evaluation *evaluation
+	shadow     *runPolicy
+	canary     bool
+}
+
+// buildPolicy builds the run policy for a policy version on top of the
+// engine's configuration. Sections the version leaves empty (eligibility,
+// transitions, budgets, rules) keep the configured values; the version's
+// approval snapshot always replaces the configured one. Per-tool limits
+// exist only in policy versions. A nil version yields the configured policy.
+func (e *Engine) buildPolicy(v *policy.PolicyVersion) (*runPolicy, error) {
+	p := &runPolicy{
+		eligibility:  e.eligibility,
+		transitions:  e.transitions,
+		budgetLimits: e.budgetLimits,
+		snapshot:     e.snapshot,
+		rules:        e.rules,
+	}
+
+	if v != nil {
+		p.version = v.Version
+		if len(v.Eligibility.StateTools) > 0 {
+			p.eligibility = policy.NewToolEligibilityWith(policy.EligibilityRules(v.Eligibility.StateTools))
+		}
+		if len(v.Transitions.Transitions) > 0 {
+			p.transitions = policy.NewStateTransitionsWith(policy.TransitionRules(v.Transitions.Transitions))
+		}
+		if len(v.Budgets.Limits) > 0 {
+			p.budgetLimits = make(map[string]int, len(v.Budgets.Limits))
+			for name, limit := range v.Budgets.Limits {
+				p.budgetLimits[name] = limit
+			}
+		}
+		approvals := policy.ApprovalSnapshot{
+			RequiredTools: append([]string(nil), v.Approvals.RequiredTools...),
+			ExemptTools:   append([]string(nil), v.Approvals.ExemptTools...),
+		}
+		p.snapshot = &approvals
+		if len(v.Tools.Tools) > 0 {
+			tools := policy.ToolSettingsSnapshot{Tools: make(map[string]policy.ToolSettings, len(v.Tools.Tools))}
+			for name, settings := range v.Tools.Tools {
+				tools.Tools[name] = settings
+			}
+			p.tools = &tools
+		}
+		if len(v.Rules) > 0 {
+			rules, err := v.RuleSet()
+			if err != nil {
+				return nil, fmt.Errorf("policy version %d: %w", v.Version, err)
+			}
+			p.rules = rules
+		}
+	}
+
+	p.constraints = e.constraints
+	if p.rules != nil {
+		p.constraints = append(p.constraints[:len(p.constraints):len(p.constraints)], p.rules)
+	}
+	// A custom middleware chain is shared by every version
+	p.middleware = e.middleware
+	if e.defaultChain {
+		p.middleware = e.defaultMiddlewareChain(p)
+	}
+	return p, nil
+}
+
+// ApplyPolicyVersion makes v the policy for runs started from now on.
+// Runs in flight keep the policy they started with. Applying an earlier
+// version rolls back immediately.
+func (e *Engine) ApplyPolicyVersion(v *policy.PolicyVersion) error {
+	if v == nil {
+		return fmt.Errorf("policy version is nil")
+	}
+	p, err := e.buildPolicy(v)
+	if err != nil {
+		return err
+	}
+	e.active.Store(p)
+
+	logging.Info().
+		Add(logging.Int("policy_version", v.Version)).
+		Msg("policy version applied")
+	return nil
+}
+
+// RefreshPolicy applies the version store's current version, if it differs
+// from the active one. Engines subscribe to stores implementing
+// policy.VersionNotifier; RefreshPolicy serves stores that do not.
+func (e *Engine) RefreshPolicy(ctx context.Context) error {
+	if e.versions == nil {
+		return fmt.Errorf("no policy version store configured")
+	}
+	v, err := e.versions.GetCurrent(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get current policy version: %w", err)
+	}
+	if v.Version == e.PolicyVersion() {
+		return nil
+	}
+	return e.ApplyPolicyVersion(v)
+}
+
+// PolicyVersion returns the version number of the active policy, or zero
+// when no policy version has been applied.
+func (e *Engine) PolicyVersion() int {
+	return e.active.Load().version
+}
+
+// Close cancels the engine's subscription to its policy version store.
+func (e *Engine) Close() {
+	if e.unsubscribe != nil {
+		e.unsubscribe()
+	}
+}
+
+// watchPolicyVersions loads the store's current version and subscribes to
+// new ones when the store supports notifications.
+func (e *Engine) watchPolicyVersions(ctx context.Context) {
+	if v, err := e.versions.GetCurrent(ctx); err == nil {
+		if err := e.ApplyPolicyVersion(v); err != nil {
+			logPolicyError(v, err)
+		}
+	}
+
+	notifier, ok := e.versions.(policy.VersionNotifier)
+	if !ok {
+		return
+	}
+	e.unsubscribe = notifier.Subscribe(func(v *policy.PolicyVersion) {
+		// Notifications may race; never replace a newer version with an older one.
+		p, err := e.buildPolicy(v)
+		if err != nil {
+			logPolicyError(v, err)
+			return
+		}
+		for {
+			current := e.active.Load()
+			if current.version >= v.Version {
+				return
+			}
+			if e.active.CompareAndSwap(current, p) {
+				logging.Info().
+					Add(logging.Int("policy_version", v.Version)).
+					Msg("policy version applied")
+				return
+			}
+		}
+	})
+}
+
+// policyFor returns the policy a resumed run continues under: the version
+// it started with, when it is no longer the active one. Runs resumed during
+// an evaluation stay in the arm they started in.
+func (e *Engine) policyFor(ctx context.Context, run *agent.Run) *runPolicy {
+	current := e.active.Load()
+	ev := e.evaluation.Load()
+	if run.PolicyVersion == current.version {
+		if ev != nil {
+			return ev.compare(current, ev.candidate, false)
+		}
+		return current
+	}
+	if ev != nil && ev.mode == policy.EvaluationCanary && run.PolicyVersion == ev.candidate.version {
+		return ev.compare(ev.candidate, current, true)
+	}
+	if run.PolicyVersion == 0 {
+		return e.base
+	}
+	if e.versions != nil {
+		v, err := e.versions.Get(ctx, run.PolicyVersion)
+		if err == nil {
+			var p *runPolicy
+			if p, err = e.buildPolicy(v); err == nil {
+				return p
+			}
+		}
+		logging.Warn().
+			Add(logging.RunID(run.ID)).
+			Add(logging.Int("policy_version", run.PolicyVersion)).
+			Add(logging.ErrorField(err)).
+			Msg("policy version unavailable, resuming under the active policy")
+	}
+	return current
+}
+
+func logPolicyError(v *policy.PolicyVersion, err error) {
+	logging.Error().
+		Add(logging.Int("policy_version", v.Version)).
+		Add(logging.ErrorField(err)).
+		Msg("failed to apply policy version")
+}
@@ -0,0 +1,134 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/proposal"
+	"github.com/felixgeelhaar/agent-go/infrastructure/logging"
+	infraProposal "github.com/felixgeelhaar/agent-go/infrastructure/proposal"
+)
+
+// EvaluationOutcome is the result of a finished proposal evaluation.
+type EvaluationOutcome struct {
+	ProposalID string                  `json:"proposal_id"`
+	Report     policy.EvaluationReport `json:"report"`
+	Holds      bool                    `json:"holds"`
+	Reason     string                  `json:"reason,omitempty"`
+	RolledOut  bool                    `json:"rolled_out"`
+}
+
+// ProposalEvaluator evaluates a proposal's candidate policy version on an
+// engine's live runs, attaches the results to the proposal as evidence and
+// applies the proposal when the rollout criteria hold.
+type ProposalEvaluator struct {
+	engine   *Engine
+	workflow *infraProposal.WorkflowService
+
+	mu         sync.Mutex
+	proposalID string
+	criteria   policy.RolloutCriteria
+}
+
+// NewProposalEvaluator creates a proposal evaluator.
+func NewProposalEvaluator(engine *Engine, workflow *infraProposal.WorkflowService) *ProposalEvaluator {
+	return &ProposalEvaluator{
+		engine:   engine,
+		workflow: workflow,
+	}
+}
+
+// Start evaluates the policy version the proposal would produce. The
+// criteria must require at least one run.
+func (p *ProposalEvaluator) Start(ctx context.Context, proposalID string, cfg EvaluationConfig, criteria policy.RolloutCriteria) error {
+	if err := criteria.Validate(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.proposalID != "" {
+		return ErrEvaluationInProgress
+	}
+
+	candidate, err := p.workflow.Candidate(ctx, proposalID)
+	if err != nil {
+		return fmt.Errorf("failed to build candidate policy: %w", err)
+	}
+	if err := p.engine.StartEvaluation(candidate, cfg); err != nil {
+		return err
+	}
+	p.proposalID = proposalID
+	p.criteria = criteria
+	return nil
+}
+
+// Check finishes the evaluation once the candidate has been evaluated on
+// the required number of runs. It returns nil while the evaluation is still
+// collecting runs.
+func (p *ProposalEvaluator) Check(ctx context.Context) (*EvaluationOutcome, error) {
+	p.mu.Lock()
+	criteria := p.criteria
+	p.mu.Unlock()
+
+	report, ok := p.engine.EvaluationReport()
+	if !ok {
+		return nil, ErrNoEvaluation
+	}
+	if report.Runs() < criteria.MinRuns {
+		return nil, nil
+	}
+	return p.Finish(ctx)
+}
+
+// Finish stops the evaluation, attaches its report to the proposal and
+// applies the proposal when the criteria hold and it has been approved.
+func (p *ProposalEvaluator) Finish(ctx context.Context) (*EvaluationOutcome, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.proposalID == "" {
+		return nil, ErrNoEvaluation
+	}
+	proposalID := p.proposalID
+	p.proposalID = ""
+
+	report, err := p.engine.StopEvaluation()
+	if err != nil {
+		return nil, err
+	}
+	outcome := &EvaluationOutcome{ProposalID: proposalID, Report: report}
+	outcome.Holds, outcome.Reason = p.criteria.Holds(report)
+
+	description := fmt.Sprintf("%s evaluation of policy version %d on %d runs: %d of %d decisions disagreed",
+		report.Mode, report.CandidateVersion, report.Runs(), report.Disagreements, report.Decisions)
+	if err := p.workflow.AddEvidence(ctx, proposalID, string(report.Mode)+"_evaluation", description, report); err != nil {
+		return outcome, fmt.Errorf("failed to attach evaluation evidence: %w", err)
+	}
+
+	if outcome.Holds {
+		err := p.workflow.Apply(ctx, proposalID)
+		switch {
+		case errors.Is(err, proposal.ErrInvalidStatusTransition):
+			outcome.Reason = "proposal is not approved"
+		case err != nil:
+			return outcome, fmt.Errorf("failed to apply proposal: %w", err)
+		default:
+			outcome.RolledOut = true
+			if p.engine.versions != nil {
+				if err := p.engine.RefreshPolicy(ctx); err != nil {
+					return outcome, err
+				}
+			}
+		}
+	}
+
+	logging.Info().
+		Add(logging.Str("proposal_id", proposalID)).
+		Add(logging.Int("policy_version", report.CandidateVersion)).
+		Add(logging.Bool("rolled_out", outcome.RolledOut)).
+		Add(logging.Str("reason", outcome.Reason)).
+		Msg("policy evaluation finished")
+	return outcome, nil
+}
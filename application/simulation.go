@@ -0,0 +1,479 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/event"
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	inframw "github.com/felixgeelhaar/agent-go/infrastructure/middleware"
+)
+
+// FindingKind classifies how a simulated policy would have changed a
+// recorded run.
+type FindingKind string
+
+const (
+	// FindingToolIneligible marks a tool call the policy would not have
+	// allowed, by eligibility or a deny rule.
+	FindingToolIneligible FindingKind = "tool_ineligible"
+
+	// FindingTransitionBlocked marks a transition the policy would have
+	// blocked, by the transition table or a deny rule.
+	FindingTransitionBlocked FindingKind = "transition_blocked"
+
+	// FindingBudgetExhausted marks where a budget would have run out.
+	FindingBudgetExhausted FindingKind = "budget_exhausted"
+
+	// FindingApprovalRequired marks a tool call that ran without approval
+	// but would have required it.
+	FindingApprovalRequired FindingKind = "approval_required"
+)
+
+// SimulationFinding is a recorded action the simulated policy would have
+// treated differently.
+type SimulationFinding struct {
+	Kind     FindingKind `json:"kind"`
+	Sequence uint64      `json:"sequence"`
+	State    agent.State `json:"state"`
+	ToolName string      `json:"tool_name,omitempty"`
+	ToState  agent.State `json:"to_state,omitempty"`
+	Budget   string      `json:"budget,omitempty"`
+	Reason   string      `json:"reason"`
+}
+
+// RunSimulation is the outcome of re-evaluating one recorded run.
+type RunSimulation struct {
+	RunID string `json:"run_id"`
+	Goal  string `json:"goal"`
+
+	// PolicyVersion is the version the run was recorded under.
+	PolicyVersion int `json:"policy_version"`
+
+	// Status is the recorded outcome of the run.
+	Status agent.RunStatus `json:"status"`
+
+	ToolCalls   int `json:"tool_calls"`
+	Transitions int `json:"transitions"`
+
+	// Findings lists the differences in event order. Findings after the
+	// first blocking one describe actions the run might never have reached.
+	Findings []SimulationFinding `json:"findings,omitempty"`
+}
+
+// Blocked reports whether the simulated policy would have stopped the run
+// rather than only requiring an approval.
+func (r RunSimulation) Blocked() bool {
+	for _, f := range r.Findings {
+		if f.Kind != FindingApprovalRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// SimulationReport summarizes a counterfactual policy simulation.
+type SimulationReport struct {
+	PolicyVersion int             `json:"policy_version"`
+	Runs          []RunSimulation `json:"runs"`
+
+	// Counts holds the number of findings of each kind across all runs.
+	Counts map[FindingKind]int `json:"counts"`
+}
+
+// Affected returns the number of runs with at least one finding.
+func (r *SimulationReport) Affected() int {
+	n := 0
+	for _, run := range r.Runs {
+		if len(run.Findings) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// SimulationConfig configures a policy simulation.
+type SimulationConfig struct {
+	// Version is the policy version the runs are re-evaluated under.
+	// Sections it leaves empty (eligibility, transitions, budgets, rules)
+	// are not checked, as engines keep their configured policy for them.
+	Version *policy.PolicyVersion
+
+	// RunIDs selects the runs to simulate. When empty, every run in the
+	// event store is simulated, if the store implements event.Querier.
+	RunIDs []string
+
+	// Registry resolves tool annotations for rules and approval decisions.
+	// Optional.
+	Registry tool.Registry
+
+	// ApprovalPolicy decides which tools require approval, as the engine's
+	// WithApprovalPolicy does. When nil, tool annotations decide. Optional.
+	ApprovalPolicy *policy.ApprovalPolicy
+
+	// Constraints are evaluated for tool calls and transitions alongside
+	// the version's rules. Engines hold constraints registered in code
+	// outside any policy version, so pass the engine's constraints here
+	// to simulate them too. Optional.
+	Constraints []policy.Constraint
+}
+
+// Simulate re-evaluates recorded runs under a different policy version and
+// reports which tool calls would have been ineligible, which transitions
+// blocked, where budgets would have been exhausted and which calls would
+// have required approval. Constraints registered on an engine in code are
+// only checked when passed in cfg.Constraints. Recorded runs are not
+// modified.
+func (r *Replay) Simulate(ctx context.Context, cfg SimulationConfig) (*SimulationReport, error) {
+	if cfg.Version == nil {
+		return nil, fmt.Errorf("policy version is nil")
+	}
+	sim, err := newSimulator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	runIDs := cfg.RunIDs
+	if len(runIDs) == 0 {
+		querier, ok := r.eventStore.(event.Querier)
+		if !ok {
+			return nil, errors.New("event store cannot list runs: run IDs required")
+		}
+		if runIDs, err = querier.ListRuns(ctx); err != nil {
+			return nil, fmt.Errorf("list runs: %w", err)
+		}
+		sort.Strings(runIDs)
+	}
+
+	report := &SimulationReport{
+		PolicyVersion: cfg.Version.Version,
+		Runs:          make([]RunSimulation, 0, len(runIDs)),
+		Counts:        make(map[FindingKind]int),
+	}
+	for _, runID := range runIDs {
+		events, err := r.eventStore.LoadEvents(ctx, runID)
+		if err != nil {
+			return nil, fmt.Errorf("load events: %w", err)
+		}
+		if len(events) == 0 {
+			return nil, fmt.Errorf("run %s: %w", runID, event.ErrRunNotFound)
+		}
+		run, err := sim.simulate(runID, events)
+		if err != nil {
+			return nil, fmt.Errorf("run %s: %w", runID, err)
+		}
+		for _, f := range run.Findings {
+			report.Counts[f.Kind]++
+		}
+		report.Runs = append(report.Runs, run)
+	}
+	return report, nil
+}
+
+// simulator holds the policy version sections a simulation checks.
+type simulator struct {
+	eligibility *policy.ToolEligibility
+	transitions *policy.StateTransitions
+	limits      map[string]int
+	approvals   policy.ApprovalSnapshot
+	policy      *policy.ApprovalPolicy
+	rules       *policy.RuleSet
+	constraints []policy.Constraint
+	registry    tool.Registry
+}
+
+func newSimulator(cfg SimulationConfig) (*simulator, error) {
+	v := cfg.Version
+	s := &simulator{
+		limits:      v.Budgets.Limits,
+		approvals:   v.Approvals,
+		policy:      cfg.ApprovalPolicy,
+		constraints: cfg.Constraints,
+		registry:    cfg.Registry,
+	}
+	if len(v.Eligibility.StateTools) > 0 {
+		s.eligibility = policy.NewToolEligibilityWith(policy.EligibilityRules(v.Eligibility.StateTools))
+	}
+	if len(v.Transitions.Transitions) > 0 {
+		s.transitions = policy.NewStateTransitionsWith(policy.TransitionRules(v.Transitions.Transitions))
+	}
+	if len(v.Rules) > 0 {
+		rules, err := v.RuleSet()
+		if err != nil {
+			return nil, fmt.Errorf("policy version %d: %w", v.Version, err)
+		}
+		s.rules = rules
+	}
+	return s, nil
+}
+
+// pendingCall is a recorded tool call awaiting its result.
+type pendingCall struct {
+	name          string
+	sequence      uint64
+	state         agent.State
+	needsApproval string
+	requested     bool
+}
+
+// simulate walks a run's events in order under the simulated policy.
+func (s *simulator) simulate(runID string, events []event.Event) (RunSimulation, error) {
+	result := RunSimulation{RunID: runID, Status: agent.RunStatusRunning}
+	run := agent.NewRun(runID, "")
+	budget := policy.NewBudget(s.limits)
+	exhausted := make(map[string]bool)
+	var history []policy.ToolCallRecord
+	var evidence int // tool results and human input, as the engine counts
+	var call *pendingCall
+	var lastTool string
+
+	// flush reports a call that ran without the approval it would need
+	flush := func() {
+		if call != nil && call.needsApproval != "" && !call.requested {
+			result.Findings = append(result.Findings, SimulationFinding{
+				Kind:     FindingApprovalRequired,
+				Sequence: call.sequence,
+				State:    call.state,
+				ToolName: call.name,
+				Reason:   call.needsApproval,
+			})
+		}
+		call = nil
+	}
+
+	for _, e := range events {
+		switch e.Type {
+		case event.TypeRunStarted:
+			var payload event.RunStartedPayload
+			if err := e.UnmarshalPayload(&payload); err != nil {
+				return result, fmt.Errorf("unmarshal run.started: %w", err)
+			}
+			result.Goal = payload.Goal
+			result.PolicyVersion = payload.PolicyVersion
+			for k, v := range payload.Vars {
+				run.SetVar(k, v)
+			}
+
+		case event.TypeVariableSet:
+			var payload event.VariableSetPayload
+			if err := e.UnmarshalPayload(&payload); err != nil {
+				return result, fmt.Errorf("unmarshal variable.set: %w", err)
+			}
+			run.SetVar(payload.Key, payload.Value)
+
+		case event.TypeStateTransitioned:
+			var payload event.StateTransitionedPayload
+			if err := e.UnmarshalPayload(&payload); err != nil {
+				return result, fmt.Errorf("unmarshal state.transitioned: %w", err)
+			}
+			result.Transitions++
+			if reason := s.checkTransition(run, payload.FromState, payload.ToState, budget, evidence, history); reason != "" {
+				result.Findings = append(result.Findings, SimulationFinding{
+					Kind:     FindingTransitionBlocked,
+					Sequence: e.Sequence,
+					State:    payload.FromState,
+					ToState:  payload.ToState,
+					Reason:   reason,
+				})
+			}
+			run.CurrentState = payload.ToState
+
+		case event.TypeToolCalled:
+			flush()
+			var payload event.ToolCalledPayload
+			if err := e.UnmarshalPayload(&payload); err != nil {
+				return result, fmt.Errorf("unmarshal tool.called: %w", err)
+			}
+			result.ToolCalls++
+			lastTool = payload.ToolName
+			cc := policy.ConstraintContext{
+				RunID:         runID,
+				CurrentState:  payload.State,
+				ToolName:      payload.ToolName,
+				Input:         payload.Input,
+				Annotations:   s.annotations(payload.ToolName),
+				Budget:        budget,
+				Vars:          run.Vars,
+				EvidenceCount: evidence,
+				History:       history,
+			}
+			if reason := s.checkToolCall(cc); reason != "" {
+				result.Findings = append(result.Findings, SimulationFinding{
+					Kind:     FindingToolIneligible,
+					Sequence: e.Sequence,
+					State:    payload.State,
+					ToolName: payload.ToolName,
+					Reason:   reason,
+				})
+			}
+			call = &pendingCall{
+				name:          payload.ToolName,
+				sequence:      e.Sequence,
+				state:         payload.State,
+				needsApproval: s.approvalReason(cc),
+			}
+
+		case event.TypeApprovalRequested:
+			var payload event.ApprovalRequestedPayload
+			if err := e.UnmarshalPayload(&payload); err != nil {
+				return result, fmt.Errorf("unmarshal approval.requested: %w", err)
+			}
+			if call != nil && call.name == payload.ToolName {
+				call.requested = true
+			}
+
+		case event.TypeToolSucceeded:
+			var payload event.ToolSucceededPayload
+			if err := e.UnmarshalPayload(&payload); err != nil {
+				return result, fmt.Errorf("unmarshal tool.succeeded: %w", err)
+			}
+			state := run.CurrentState
+			if call != nil {
+				state = call.state
+			}
+			history = append(history, policy.ToolCallRecord{ToolName: payload.ToolName, State: state})
+			evidence++
+			flush()
+
+		case event.TypeEvidenceAdded:
+			evidence++
+
+		case event.TypeToolFailed:
+			flush()
+
+		case event.TypeBudgetConsumed:
+			var payload event.BudgetConsumedPayload
+			if err := e.UnmarshalPayload(&payload); err != nil {
+				return result, fmt.Errorf("unmarshal budget.consumed: %w", err)
+			}
+			if budget.Consume(payload.BudgetName, payload.Amount) != nil && !exhausted[payload.BudgetName] {
+				exhausted[payload.BudgetName] = true
+				result.Findings = append(result.Findings, SimulationFinding{
+					Kind:     FindingBudgetExhausted,
+					Sequence: e.Sequence,
+					State:    run.CurrentState,
+					ToolName: lastTool,
+					Budget:   payload.BudgetName,
+					Reason: fmt.Sprintf("budget %s of %d exhausted",
+						payload.BudgetName, s.limits[payload.BudgetName]),
+				})
+			}
+
+		case event.TypeRunCompleted:
+			result.Status = agent.RunStatusCompleted
+
+		case event.TypeRunFailed:
+			result.Status = agent.RunStatusFailed
+		}
+	}
+	flush()
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return result.Findings[i].Sequence < result.Findings[j].Sequence
+	})
+	return result, nil
+}
+
+// checkToolCall returns why the policy would not have allowed the call.
+func (s *simulator) checkToolCall(cc policy.ConstraintContext) string {
+	if s.eligibility != nil && !s.eligibility.IsAllowed(cc.CurrentState, cc.ToolName) {
+		return fmt.Sprintf("tool %s not allowed in state %s", cc.ToolName, cc.CurrentState)
+	}
+	if ok, reason := policy.EvaluateConstraints(cc, s.constraints...); !ok {
+		return reason
+	}
+	if s.rules != nil {
+		if ok, reason := s.rules.Evaluate(cc); !ok {
+			return reason
+		}
+	}
+	return ""
+}
+
+// checkTransition returns why the policy would have blocked the transition.
+// Like the engine, constraints and rules are not evaluated for transitions
+// to failed.
+func (s *simulator) checkTransition(run *agent.Run, from, to agent.State, budget *policy.Budget, evidence int, history []policy.ToolCallRecord) string {
+	if s.transitions != nil && !s.transitions.CanTransition(from, to) {
+		return fmt.Sprintf("transition from %s to %s not allowed", from, to)
+	}
+	if to == agent.StateFailed {
+		return ""
+	}
+	cc := policy.ConstraintContext{
+		RunID:         run.ID,
+		CurrentState:  from,
+		ToState:       to,
+		Budget:        budget,
+		Vars:          run.Vars,
+		EvidenceCount: evidence,
+		History:       history,
+	}
+	if ok, reason := policy.EvaluateConstraints(cc, s.constraints...); !ok {
+		return reason
+	}
+	if s.rules != nil {
+		if ok, reason := s.rules.Evaluate(cc); !ok {
+			return reason
+		}
+	}
+	return ""
+}
+
+// approvalReason returns why the policy would require approval for the
+// call, or an empty string. It decides as the approval middleware does,
+// from the approval policy, the version's snapshot, tool annotations and
+// require_approval rules.
+func (s *simulator) approvalReason(cc policy.ConstraintContext) string {
+	t := s.resolveTool(cc.ToolName)
+	if t == nil {
+		return ""
+	}
+	execCtx := &middleware.ExecutionContext{
+		RunID:         cc.RunID,
+		CurrentState:  cc.CurrentState,
+		Tool:          t,
+		Input:         cc.Input,
+		Budget:        cc.Budget,
+		Vars:          cc.Vars,
+		EvidenceCount: cc.EvidenceCount,
+		History:       cc.History,
+	}
+	cfg := inframw.ApprovalConfig{Policy: s.policy, Snapshot: &s.approvals, Rules: s.rules}
+	if !inframw.ApprovalRequired(cfg, execCtx) {
+		return ""
+	}
+	if s.rules != nil {
+		if required, reason := s.rules.RequiresApproval(cc); required {
+			return reason
+		}
+	}
+	return fmt.Sprintf("tool %s requires approval", cc.ToolName)
+}
+
+// resolveTool returns the registered tool, or a tool without annotations
+// when it is not registered.
+func (s *simulator) resolveTool(name string) tool.Tool {
+	if s.registry != nil {
+		if t, ok := s.registry.Get(name); ok {
+			return t
+		}
+	}
+	t, err := tool.NewBuilder(name).Build()
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// annotations returns the annotations of a registered tool.
+func (s *simulator) annotations(name string) tool.Annotations {
+	if t := s.resolveTool(name); t != nil {
+		return t.Annotations()
+	}
+	return tool.Annotations{}
+}
@@ -0,0 +1,283 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/event"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/planner"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
+)
+
+// recordRuns executes scripted runs on an engine storing events.
+func recordRuns(t *testing.T, events event.Store, runs int, steps []planner.ScriptStep) []string {
+	t.Helper()
+	var script []planner.ScriptStep
+	for i := 0; i < runs; i++ {
+		script = append(script, steps...)
+	}
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("read_file", true), newTestTool("write_file", false))),
+		WithPlanner(planner.NewScriptedPlanner(script...)),
+		WithEligibility(newTestEligibility(map[agent.State][]string{agent.StateExplore: {"read_file"}})),
+		WithEventStore(events),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	var ids []string
+	for i := 0; i < runs; i++ {
+		run, err := engine.Run(context.Background(), "read the file")
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		ids = append(ids, run.ID)
+	}
+	return ids
+}
+
+func TestReplay_Simulate(t *testing.T) {
+	ctx := context.Background()
+	events := memory.NewEventStore()
+	ids := recordRuns(t, events, 2, readFileRun())
+
+	tests := []struct {
+		name    string
+		version *policy.PolicyVersion
+		want    []FindingKind
+	}{
+		{
+			name:    "unchanged policy",
+			version: exploreVersion(2, "read_file"),
+		},
+		{
+			name:    "revoked tool",
+			version: exploreVersion(2, "write_file"),
+			want:    []FindingKind{FindingToolIneligible},
+		},
+		{
+			name: "blocked transition",
+			version: func() *policy.PolicyVersion {
+				v := exploreVersion(2)
+				v.Transitions.AddTransition(agent.StateIntake, agent.StateExplore)
+				v.Transitions.AddTransition(agent.StateDecide, agent.StateDone)
+				return v
+			}(),
+			want: []FindingKind{FindingTransitionBlocked},
+		},
+		{
+			name: "exhausted budget",
+			version: func() *policy.PolicyVersion {
+				v := exploreVersion(2)
+				v.Budgets.SetLimit("tool_calls", 0)
+				return v
+			}(),
+			want: []FindingKind{FindingBudgetExhausted},
+		},
+		{
+			name: "approval and deny rules",
+			version: func() *policy.PolicyVersion {
+				v := exploreVersion(2)
+				v.Approvals.RequireApproval("read_file")
+				v.Rules = []policy.RuleDefinition{
+					{Name: "no-decide", Rule: `action == "transition" && to == "decide" => deny`, Reason: "deciding is frozen"},
+				}
+				return v
+			}(),
+			want: []FindingKind{FindingApprovalRequired, FindingTransitionBlocked},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewReplay(events).Simulate(ctx, SimulationConfig{Version: tt.version})
+			if err != nil {
+				t.Fatalf("simulation failed: %v", err)
+			}
+			if len(report.Runs) != len(ids) {
+				t.Fatalf("expected %d runs, got %d", len(ids), len(report.Runs))
+			}
+			for _, run := range report.Runs {
+				if run.Status != agent.RunStatusCompleted || run.ToolCalls != 1 || run.Transitions != 3 {
+					t.Errorf("unexpected run summary: %+v", run)
+				}
+				if len(run.Findings) != len(tt.want) {
+					t.Fatalf("expected findings %v, got %+v", tt.want, run.Findings)
+				}
+				for i, f := range run.Findings {
+					if f.Kind != tt.want[i] {
+						t.Errorf("finding %d: expected %s, got %+v", i, tt.want[i], f)
+					}
+				}
+			}
+			if len(tt.want) > 0 && (report.Affected() != len(ids) || report.Counts[tt.want[0]] != len(ids)) {
+				t.Errorf("expected every run affected, got %d (counts %v)", report.Affected(), report.Counts)
+			}
+		})
+	}
+
+	t.Run("selected runs", func(t *testing.T) {
+		report, err := NewReplay(events).Simulate(ctx, SimulationConfig{
+			Version: exploreVersion(2, "write_file"),
+			RunIDs:  ids[:1],
+		})
+		if err != nil {
+			t.Fatalf("simulation failed: %v", err)
+		}
+		if len(report.Runs) != 1 || report.Runs[0].RunID != ids[0] || !report.Runs[0].Blocked() {
+			t.Errorf("unexpected report: %+v", report)
+		}
+		f := report.Runs[0].Findings[0]
+		if f.ToolName != "read_file" || f.State != agent.StateExplore || f.Reason == "" {
+			t.Errorf("unexpected finding: %+v", f)
+		}
+	})
+
+	t.Run("engine constraints", func(t *testing.T) {
+		noReads := policy.ConstraintFunc(func(cc policy.ConstraintContext) (bool, string) {
+			if cc.ToolName == "read_file" {
+				return false, "reads are frozen"
+			}
+			return true, ""
+		})
+		noDone := policy.ConstraintFunc(func(cc policy.ConstraintContext) (bool, string) {
+			return cc.ToState != agent.StateDone, "runs may not finish"
+		})
+		report, err := NewReplay(events).Simulate(ctx, SimulationConfig{
+			Version:     exploreVersion(2, "read_file"),
+			RunIDs:      ids[:1],
+			Constraints: []policy.Constraint{noReads, noDone},
+		})
+		if err != nil {
+			t.Fatalf("simulation failed: %v", err)
+		}
+		findings := report.Runs[0].Findings
+		if len(findings) != 2 ||
+			findings[0].Kind != FindingToolIneligible || findings[0].Reason != "reads are frozen" ||
+			findings[1].Kind != FindingTransitionBlocked || findings[1].ToState != agent.StateDone {
+			t.Errorf("unexpected findings: %+v", findings)
+		}
+	})
+
+	t.Run("unknown run", func(t *testing.T) {
+		_, err := NewReplay(events).Simulate(ctx, SimulationConfig{Version: exploreVersion(2), RunIDs: []string{"missing"}})
+		if !errors.Is(err, event.ErrRunNotFound) {
+			t.Errorf("expected ErrRunNotFound, got %v", err)
+		}
+	})
+}
+
+func TestReplay_SimulateApproval(t *testing.T) {
+	ctx := context.Background()
+	events := memory.NewEventStore()
+	ids := recordRuns(t, events, 1, readFileRun())
+	annotated := func(a tool.Annotations) tool.Registry {
+		return newTestRegistry(tool.NewBuilder("read_file").WithAnnotations(a).MustBuild())
+	}
+	exempt := exploreVersion(2, "read_file")
+	exempt.Approvals.Exempt("read_file")
+
+	tests := []struct {
+		name     string
+		cfg      SimulationConfig
+		required bool
+	}{
+		{
+			name:     "annotations require approval",
+			cfg:      SimulationConfig{Version: exploreVersion(2, "read_file"), Registry: annotated(tool.Annotations{Destructive: true})},
+			required: true,
+		},
+		{
+			name: "approval policy exempts the tool",
+			cfg: SimulationConfig{
+				Version:        exploreVersion(2, "read_file"),
+				Registry:       annotated(tool.Annotations{Destructive: true}),
+				ApprovalPolicy: &policy.ApprovalPolicy{ExemptTools: []string{"read_file"}},
+			},
+		},
+		{
+			name: "version exempts a high-risk tool",
+			cfg:  SimulationConfig{Version: exempt, Registry: annotated(tool.Annotations{RiskLevel: tool.RiskHigh})},
+		},
+		{
+			name:     "version cannot exempt a destructive tool",
+			cfg:      SimulationConfig{Version: exempt, Registry: annotated(tool.Annotations{Destructive: true})},
+			required: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.RunIDs = ids
+			report, err := NewReplay(events).Simulate(ctx, tt.cfg)
+			if err != nil {
+				t.Fatalf("simulation failed: %v", err)
+			}
+			findings := report.Runs[0].Findings
+			if required := len(findings) == 1 && findings[0].Kind == FindingApprovalRequired; required != tt.required || len(findings) > 1 {
+				t.Errorf("expected approval required = %v, got %+v", tt.required, findings)
+			}
+		})
+	}
+}
+
+func TestReplay_SimulateCountsHumanEvidence(t *testing.T) {
+	ctx := context.Background()
+	events := memory.NewEventStore()
+	engine, err := NewEngineWithOptions(
+		WithRegistry(newTestRegistry(newTestTool("read_file", true))),
+		WithPlanner(planner.NewScriptedPlanner(
+			planner.ScriptStep{ExpectState: agent.StateIntake, Decision: agent.NewTransitionDecision(agent.StateExplore, "start")},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.Decision{
+				Type:     agent.DecisionAskHuman,
+				AskHuman: &agent.AskHumanDecision{Question: "Which file?"},
+			}},
+			planner.ScriptStep{ExpectState: agent.StateExplore, Decision: agent.NewTransitionDecision(agent.StateDecide, "answered")},
+			planner.ScriptStep{ExpectState: agent.StateDecide, Decision: agent.NewFinishDecision("done", json.RawMessage(`{}`))},
+		)),
+		WithEventStore(events),
+	)
+	if err != nil {
+		t.Fatalf("failed to create engine: %v", err)
+	}
+	run, err := engine.Run(ctx, "ask first")
+	if !errors.Is(err, agent.ErrAwaitingHumanInput) {
+		t.Fatalf("expected the run to pause, got %v", err)
+	}
+	if _, err := engine.ResumeWithInput(ctx, run, "notes.txt"); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+
+	// The answer is the run's only evidence when it moves to decide.
+	for evidence, blocked := range map[int]bool{1: false, 2: true} {
+		v := exploreVersion(2)
+		v.Rules = []policy.RuleDefinition{{Rule: fmt.Sprintf(`to == "decide" && run.evidence < %d => deny`, evidence)}}
+		report, err := NewReplay(events).Simulate(ctx, SimulationConfig{Version: v, RunIDs: []string{run.ID}})
+		if err != nil {
+			t.Fatalf("simulation failed: %v", err)
+		}
+		if got := report.Runs[0].Blocked(); got != blocked {
+			t.Errorf("run.evidence < %d: blocked = %v, want %v (%+v)", evidence, got, blocked, report.Runs[0].Findings)
+		}
+	}
+}
+
+func TestReplay_ReconstructRecordedRun(t *testing.T) {
+	events := memory.NewEventStore()
+	ids := recordRuns(t, events, 1, readFileRun())
+
+	run, err := NewReplay(events).ReconstructRun(context.Background(), ids[0])
+	if err != nil {
+		t.Fatalf("failed to reconstruct run: %v", err)
+	}
+	if run.Goal != "read the file" || run.Status != agent.RunStatusCompleted || run.CurrentState != agent.StateDone {
+		t.Errorf("unexpected run: %+v", run)
+	}
+	if string(run.Result) != "{}" {
+		t.Errorf("unexpected result: %s", run.Result)
+	}
+}
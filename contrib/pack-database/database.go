@@ -8,28 +8,125 @@
 //   - db_tables: List tables in the database
 //   - db_describe: Describe a table's columns and types
 //
-// Supports PostgreSQL, MySQL, SQLite, and SQL Server.
-// Queries are parameterized to prevent SQL injection.
+// Supports PostgreSQL, MySQL, SQLite, and SQL Server through database/sql;
+// the application registers the drivers it needs. Tools address named
+// connections from Config. Queries are parameterized to prevent SQL
+// injection, db_query rejects statements that could write and runs inside
+// a read-only transaction that is always rolled back, and results are
+// capped by row count and encoded size.
 package database
 
 import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Errors returned by the database tools.
+var (
+	// ErrUnknownConnection indicates the requested connection is not configured.
+	ErrUnknownConnection = errors.New("unknown connection")
+
+	// ErrReadOnlyConnection indicates a write against a read-only connection.
+	ErrReadOnlyConnection = errors.New("connection is read-only")
+
+	// ErrInvalidIdentifier indicates an unsafe table or schema name.
+	ErrInvalidIdentifier = errors.New("invalid identifier")
+)
+
+// Dialect identifies the SQL flavour of a connection.
+type Dialect string
+
+// Supported dialects.
+const (
+	DialectSQLite    Dialect = "sqlite"
+	DialectPostgres  Dialect = "postgres"
+	DialectMySQL     Dialect = "mysql"
+	DialectSQLServer Dialect = "sqlserver"
+)
+
+// Connection is a named database the tools may use.
+type Connection struct {
+	// Driver is the database/sql driver name, e.g. "sqlite3", "pgx",
+	// "postgres", "mysql" or "sqlserver".
+	Driver string
+
+	// DSN is the driver-specific data source name.
+	DSN string
+
+	// DB is an already opened handle. When set, Driver and DSN are only used
+	// to infer the dialect and the pack does not close it.
+	DB *sql.DB
+
+	// Dialect overrides the dialect inferred from Driver.
+	Dialect Dialect
+
+	// ReadOnly rejects db_execute and db_transaction on this connection.
+	ReadOnly bool
+}
+
+func (c Connection) dialect() Dialect {
+	if c.Dialect != "" {
+		return c.Dialect
+	}
+	switch c.Driver {
+	case "postgres", "pgx":
+		return DialectPostgres
+	case "mysql":
+		return DialectMySQL
+	case "sqlserver", "mssql":
+		return DialectSQLServer
+	default:
+		return DialectSQLite
+	}
+}
+
+// Config configures the database pack.
+type Config struct {
+	// Connections are the named databases, keyed by name.
+	Connections map[string]Connection
+
+	// DefaultConnection is used when a call does not name one. Defaults to
+	// the only connection when exactly one is configured.
+	DefaultConnection string
+
+	// MaxRows caps the rows returned by db_query. Defaults to 1000.
+	MaxRows int
+
+	// MaxBytes caps the JSON-encoded size of returned rows. Defaults to 1 MiB.
+	MaxBytes int
+
+	// QueryTimeout bounds every statement. Defaults to 30 seconds.
+	QueryTimeout time.Duration
+}
+
 // Pack returns the database tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	p := newDatabasePack(cfg)
+
 	return pack.NewBuilder("database").
 		WithDescription("Database query and management tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			dbQuery(),
-			dbExecute(),
-			dbTransaction(),
-			dbSchema(),
-			dbTables(),
-			dbDescribe(),
+			p.dbQuery(),
+			p.dbExecute(),
+			p.dbTransaction(),
+			p.dbSchema(),
+			p.dbTables(),
+			p.dbDescribe(),
 		).
 		AllowInState(agent.StateExplore, "db_query", "db_schema", "db_tables", "db_describe").
 		AllowInState(agent.StateAct, "db_query", "db_execute", "db_transaction", "db_schema", "db_tables", "db_describe").
@@ -37,49 +134,592 @@ func Pack() *pack.Pack {
 		Build()
 }
 
-func dbQuery() tool.Tool {
+type databasePack struct {
+	cfg Config
+
+	mu  sync.Mutex
+	dbs map[string]*sql.DB
+}
+
+func newDatabasePack(cfg Config) *databasePack {
+	if cfg.MaxRows <= 0 {
+		cfg.MaxRows = 1000
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = 1 << 20
+	}
+	if cfg.QueryTimeout <= 0 {
+		cfg.QueryTimeout = 30 * time.Second
+	}
+	if cfg.DefaultConnection == "" && len(cfg.Connections) == 1 {
+		for name := range cfg.Connections {
+			cfg.DefaultConnection = name
+		}
+	}
+	return &databasePack{cfg: cfg, dbs: make(map[string]*sql.DB)}
+}
+
+// conn resolves a connection by name and opens it on first use.
+func (p *databasePack) conn(ctx context.Context, name string) (*sql.DB, Connection, error) {
+	if name == "" {
+		name = p.cfg.DefaultConnection
+	}
+	c, ok := p.cfg.Connections[name]
+	if !ok {
+		return nil, Connection{}, fmt.Errorf("%w: %q", ErrUnknownConnection, name)
+	}
+	if c.DB != nil {
+		return c.DB, c, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if db, ok := p.dbs[name]; ok {
+		return db, c, nil
+	}
+	db, err := sql.Open(c.Driver, c.DSN)
+	if err != nil {
+		return nil, c, fmt.Errorf("failed to open connection %q: %w", name, err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, c, fmt.Errorf("failed to connect to %q: %w", name, err)
+	}
+	p.dbs[name] = db
+	return db, c, nil
+}
+
+func (p *databasePack) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, p.cfg.QueryTimeout)
+}
+
+// sqlIdentifierRegex validates table and schema names. Only letters, digits
+// and underscores are allowed, starting with a letter or underscore.
+var sqlIdentifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func validateIdentifier(name string) error {
+	if len(name) > 128 || !sqlIdentifierRegex.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+	return nil
+}
+
+// placeholder returns the n-th (1-based) bind parameter for a dialect.
+func placeholder(d Dialect, n int) string {
+	switch d {
+	case DialectPostgres:
+		return fmt.Sprintf("$%d", n)
+	case DialectSQLServer:
+		return fmt.Sprintf("@p%d", n)
+	default:
+		return "?"
+	}
+}
+
+// ============================================================================
+// Result Encoding
+// ============================================================================
+
+// rowSet is a bounded query result.
+type rowSet struct {
+	Columns   []string         `json:"columns"`
+	Rows      []map[string]any `json:"rows"`
+	Count     int              `json:"count"`
+	Truncated bool             `json:"truncated"`
+}
+
+// readRows scans rows until maxRows rows or maxBytes of encoded row data
+// have been collected.
+func readRows(rows *sql.Rows, maxRows, maxBytes int) (*rowSet, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &rowSet{Columns: columns, Rows: []map[string]any{}}
+	size := 0
+	for rows.Next() {
+		if len(rs.Rows) >= maxRows {
+			rs.Truncated = true
+			break
+		}
+
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, col := range columns {
+			row[col] = normalizeValue(values[i])
+		}
+		encoded, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		if size+len(encoded) > maxBytes {
+			rs.Truncated = true
+			break
+		}
+		size += len(encoded)
+		rs.Rows = append(rs.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rs.Count = len(rs.Rows)
+	return rs, nil
+}
+
+// normalizeValue converts driver values into JSON-friendly forms. Byte
+// slices become strings when they are valid UTF-8 and base64 otherwise.
+func normalizeValue(v any) any {
+	switch v := v.(type) {
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// ============================================================================
+// Query Tools
+// ============================================================================
+
+type statement struct {
+	SQL    string `json:"sql"`
+	Params []any  `json:"params,omitempty"`
+}
+
+func (p *databasePack) dbQuery() tool.Tool {
 	return tool.NewBuilder("db_query").
-		WithDescription("Execute a SELECT query and return results as JSON").
+		WithDescription("Execute a read-only SELECT query with positional parameters and return results as JSON").
 		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Connection string `json:"connection,omitempty"`
+				statement
+				MaxRows int `json:"max_rows,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			db, c, err := p.conn(ctx, in.Connection)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if err := checkReadOnly(in.SQL, c.dialect()); err != nil {
+				return tool.Result{}, err
+			}
+
+			maxRows := p.cfg.MaxRows
+			if in.MaxRows > 0 && in.MaxRows < maxRows {
+				maxRows = in.MaxRows
+			}
+			ctx, cancel := p.withTimeout(ctx)
+			defer cancel()
+
+			// The lexical check is backed by a read-only transaction that is
+			// never committed. Drivers that cannot start one are refused
+			// rather than silently given a writable transaction.
+			tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to begin read-only transaction: %w", err)
+			}
+			defer func() { _ = tx.Rollback() }()
+
+			rows, err := tx.QueryContext(ctx, in.SQL, in.Params...)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("query failed: %w", err)
+			}
+			defer rows.Close()
+
+			rs, err := readRows(rows, maxRows, p.cfg.MaxBytes)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to read results: %w", err)
+			}
+
+			output, _ := json.Marshal(rs)
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func dbExecute() tool.Tool {
+func (p *databasePack) dbExecute() tool.Tool {
 	return tool.NewBuilder("db_execute").
-		WithDescription("Execute an INSERT, UPDATE, or DELETE statement").
-		WithRiskLevel(tool.RiskHigh).
-		RequiresApproval().
+		WithDescription("Execute an INSERT, UPDATE, or DELETE statement with positional parameters").
+		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Connection string `json:"connection,omitempty"`
+				statement
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if strings.TrimSpace(in.SQL) == "" {
+				return tool.Result{}, errors.New("sql is required")
+			}
+
+			db, err := p.writableConn(ctx, in.Connection)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			ctx, cancel := p.withTimeout(ctx)
+			defer cancel()
+
+			res, err := db.ExecContext(ctx, in.SQL, in.Params...)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("execute failed: %w", err)
+			}
+
+			output, _ := json.Marshal(execResult(res))
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func dbTransaction() tool.Tool {
+func (p *databasePack) dbTransaction() tool.Tool {
 	return tool.NewBuilder("db_transaction").
-		WithDescription("Execute multiple statements in a transaction").
-		WithRiskLevel(tool.RiskHigh).
-		RequiresApproval().
+		WithDescription("Execute multiple statements atomically; all are rolled back if any fails").
+		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Connection string      `json:"connection,omitempty"`
+				Statements []statement `json:"statements"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Statements) == 0 {
+				return tool.Result{}, errors.New("statements are required")
+			}
+
+			db, err := p.writableConn(ctx, in.Connection)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			ctx, cancel := p.withTimeout(ctx)
+			defer cancel()
+
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to begin transaction: %w", err)
+			}
+			defer func() { _ = tx.Rollback() }()
+
+			results := make([]map[string]any, 0, len(in.Statements))
+			for i, stmt := range in.Statements {
+				res, err := tx.ExecContext(ctx, stmt.SQL, stmt.Params...)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("statement %d failed, transaction rolled back: %w", i, err)
+				}
+				results = append(results, execResult(res))
+			}
+			if err := tx.Commit(); err != nil {
+				return tool.Result{}, fmt.Errorf("failed to commit transaction: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"committed":  true,
+				"statements": results,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func dbSchema() tool.Tool {
+func (p *databasePack) writableConn(ctx context.Context, name string) (*sql.DB, error) {
+	db, c, err := p.conn(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if c.ReadOnly {
+		return nil, ErrReadOnlyConnection
+	}
+	return db, nil
+}
+
+func execResult(res sql.Result) map[string]any {
+	out := map[string]any{}
+	if n, err := res.RowsAffected(); err == nil {
+		out["rows_affected"] = n
+	}
+	if id, err := res.LastInsertId(); err == nil && id != 0 {
+		out["last_insert_id"] = id
+	}
+	return out
+}
+
+// ============================================================================
+// Schema Tools
+// ============================================================================
+
+// column describes a table column.
+type column struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type"`
+	Nullable   bool    `json:"nullable"`
+	Default    *string `json:"default,omitempty"`
+	PrimaryKey bool    `json:"primary_key,omitempty"`
+}
+
+// listTables returns the table names of a connection, optionally limited to
+// one schema.
+func listTables(ctx context.Context, db *sql.DB, d Dialect, schema string) ([]string, error) {
+	var query string
+	var args []any
+	switch d {
+	case DialectSQLite:
+		query = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+	default:
+		query = "SELECT table_name FROM information_schema.tables WHERE table_type = 'BASE TABLE'"
+		switch {
+		case schema != "":
+			query += " AND table_schema = " + placeholder(d, 1)
+			args = append(args, schema)
+		case d == DialectPostgres:
+			query += " AND table_schema = current_schema()"
+		case d == DialectMySQL:
+			query += " AND table_schema = DATABASE()"
+		case d == DialectSQLServer:
+			query += " AND table_schema = SCHEMA_NAME()"
+		}
+		query += " ORDER BY table_name"
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tables := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// describeTable returns the columns of a table in ordinal order.
+func describeTable(ctx context.Context, db *sql.DB, d Dialect, table string) ([]column, error) {
+	if err := validateIdentifier(table); err != nil {
+		return nil, err
+	}
+
+	if d == DialectSQLite {
+		// PRAGMA arguments cannot be bound; the identifier is validated above.
+		rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%q)", table))
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		columns := []column{}
+		for rows.Next() {
+			var (
+				cid     int
+				c       column
+				notNull bool
+				dflt    sql.NullString
+				pk      int
+			)
+			if err := rows.Scan(&cid, &c.Name, &c.Type, &notNull, &dflt, &pk); err != nil {
+				return nil, err
+			}
+			c.Nullable = !notNull
+			c.PrimaryKey = pk > 0
+			if dflt.Valid {
+				c.Default = &dflt.String
+			}
+			columns = append(columns, c)
+		}
+		return columns, rows.Err()
+	}
+
+	query := "SELECT column_name, data_type, is_nullable, column_default FROM information_schema.columns WHERE table_name = " +
+		placeholder(d, 1)
+	switch d {
+	case DialectPostgres:
+		query += " AND table_schema = current_schema()"
+	case DialectMySQL:
+		query += " AND table_schema = DATABASE()"
+	case DialectSQLServer:
+		query += " AND table_schema = SCHEMA_NAME()"
+	}
+	query += " ORDER BY ordinal_position"
+
+	rows, err := db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := []column{}
+	for rows.Next() {
+		var (
+			c        column
+			nullable string
+			dflt     sql.NullString
+		)
+		if err := rows.Scan(&c.Name, &c.Type, &nullable, &dflt); err != nil {
+			return nil, err
+		}
+		c.Nullable = strings.EqualFold(nullable, "YES")
+		if dflt.Valid {
+			c.Default = &dflt.String
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func (p *databasePack) dbSchema() tool.Tool {
 	return tool.NewBuilder("db_schema").
-		WithDescription("Get the database schema as JSON").
+		WithDescription("Get the database schema as JSON: every table with its columns").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Connection string `json:"connection,omitempty"`
+				Schema     string `json:"schema,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			db, c, err := p.conn(ctx, in.Connection)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			ctx, cancel := p.withTimeout(ctx)
+			defer cancel()
+
+			tables, err := listTables(ctx, db, c.dialect(), in.Schema)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to list tables: %w", err)
+			}
+
+			schema := make(map[string][]column, len(tables))
+			for _, table := range tables {
+				// Tables with names that cannot be safely described are
+				// listed without columns.
+				if validateIdentifier(table) != nil {
+					schema[table] = nil
+					continue
+				}
+				columns, err := describeTable(ctx, db, c.dialect(), table)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("failed to describe %s: %w", table, err)
+				}
+				schema[table] = columns
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"dialect": c.dialect(),
+				"tables":  schema,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func dbTables() tool.Tool {
+func (p *databasePack) dbTables() tool.Tool {
 	return tool.NewBuilder("db_tables").
-		WithDescription("List all tables in the database").
+		WithDescription("List all tables in the database, or all configured connections when asked").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Connection      string `json:"connection,omitempty"`
+				Schema          string `json:"schema,omitempty"`
+				ListConnections bool   `json:"list_connections,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			if in.ListConnections {
+				names := make([]string, 0, len(p.cfg.Connections))
+				for name := range p.cfg.Connections {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				output, _ := json.Marshal(map[string]any{
+					"connections": names,
+					"default":     p.cfg.DefaultConnection,
+				})
+				return tool.Result{Output: output}, nil
+			}
+
+			db, c, err := p.conn(ctx, in.Connection)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			ctx, cancel := p.withTimeout(ctx)
+			defer cancel()
+
+			tables, err := listTables(ctx, db, c.dialect(), in.Schema)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to list tables: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"tables": tables,
+				"count":  len(tables),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func dbDescribe() tool.Tool {
+func (p *databasePack) dbDescribe() tool.Tool {
 	return tool.NewBuilder("db_describe").
 		WithDescription("Describe a table's columns, types, and constraints").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Connection string `json:"connection,omitempty"`
+				Table      string `json:"table"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			db, c, err := p.conn(ctx, in.Connection)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			ctx, cancel := p.withTimeout(ctx)
+			defer cancel()
+
+			columns, err := describeTable(ctx, db, c.dialect(), in.Table)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if len(columns) == 0 {
+				return tool.Result{}, fmt.Errorf("table %q not found", in.Table)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"table":   in.Table,
+				"columns": columns,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
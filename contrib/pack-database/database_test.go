@@ -0,0 +1,242 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+func newTestPack(t *testing.T, cfg Config) *pack.Pack {
+	t.Helper()
+
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, email TEXT);
+		INSERT INTO users (name, email) VALUES ('ada', 'ada@example.com'), ('grace', NULL), ('linus', 'l@example.com');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Connections = map[string]Connection{
+		"main": {Driver: "sqlite3", DB: db},
+		"ro":   {Driver: "sqlite3", DSN: dsn, ReadOnly: true},
+	}
+	cfg.DefaultConnection = "main"
+	return Pack(cfg)
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	t.Parallel()
+
+	allowed := []string{
+		"SELECT * FROM users",
+		"select replace(name, 'a', 'b') from users;",
+		"WITH x AS (SELECT 1) SELECT * FROM x",
+		"SELECT 'DELETE FROM users' AS s",
+		`SELECT "update" FROM t -- DROP TABLE t`,
+		"SELECT $$ INSERT $$, $1",
+		"EXPLAIN SELECT 1",
+	}
+	for _, q := range allowed {
+		if err := checkReadOnly(q, DialectPostgres); err != nil {
+			t.Errorf("%q: unexpected error %v", q, err)
+		}
+	}
+
+	rejected := []string{
+		"DELETE FROM users",
+		"SELECT 1; DROP TABLE users",
+		"WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d",
+		"SELECT * INTO backup FROM users",
+		"SELECT * FROM users FOR UPDATE",
+		"EXPLAIN ANALYZE DELETE FROM users",
+		"PRAGMA writable_schema = 1",
+		"SELECT /* unterminated",
+	}
+	for _, q := range rejected {
+		if err := checkReadOnly(q, DialectPostgres); !errors.Is(err, ErrWriteStatement) {
+			t.Errorf("%q: expected ErrWriteStatement, got %v", q, err)
+		}
+	}
+}
+
+func TestCheckReadOnlyHash(t *testing.T) {
+	t.Parallel()
+
+	// "#" is a comment only in MySQL; in other dialects the rest of the line
+	// is still SQL and must be checked.
+	tests := []struct {
+		sql     string
+		dialect Dialect
+		allowed bool
+	}{
+		{"SELECT 1 # DELETE FROM users", DialectMySQL, true},
+		{"SELECT #a; DELETE FROM users", DialectMySQL, true},
+		{"SELECT #a; DELETE FROM users", DialectPostgres, false},
+		{"SELECT #a; DELETE FROM users", DialectSQLite, false},
+		{"SELECT * FROM #tmp; DELETE FROM users", DialectSQLServer, false},
+		{"SELECT 5 # 3", DialectPostgres, true},
+		{"SELECT * FROM users WHERE id = #id", DialectSQLite, true},
+		{"SELECT * FROM #tmp", DialectSQLServer, true},
+	}
+	for _, tt := range tests {
+		err := checkReadOnly(tt.sql, tt.dialect)
+		if tt.allowed && err != nil {
+			t.Errorf("%s %q: unexpected error %v", tt.dialect, tt.sql, err)
+		}
+		if !tt.allowed && !errors.Is(err, ErrWriteStatement) {
+			t.Errorf("%s %q: expected ErrWriteStatement, got %v", tt.dialect, tt.sql, err)
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t, Config{})
+	out, err := call(p, "db_query", map[string]any{
+		"sql":    "SELECT id, name, email FROM users WHERE name = ?",
+		"params": []any{"ada"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := out["rows"].([]any)
+	if len(rows) != 1 || rows[0].(map[string]any)["email"] != "ada@example.com" {
+		t.Errorf("unexpected rows: %v", out)
+	}
+
+	if _, err := call(p, "db_query", map[string]any{"sql": "DELETE FROM users"}); !errors.Is(err, ErrWriteStatement) {
+		t.Errorf("expected ErrWriteStatement, got %v", err)
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t, Config{MaxRows: 2})
+	out, err := call(p, "db_query", map[string]any{"sql": "SELECT * FROM users ORDER BY id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["count"].(float64) != 2 || out["truncated"] != true {
+		t.Errorf("expected 2 truncated rows, got %v", out)
+	}
+
+	p = newTestPack(t, Config{MaxBytes: 60})
+	out, err = call(p, "db_query", map[string]any{"sql": "SELECT * FROM users ORDER BY id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["count"].(float64) != 1 || out["truncated"] != true {
+		t.Errorf("expected 1 truncated row, got %v", out)
+	}
+}
+
+func TestExecuteAndTransaction(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t, Config{})
+	for _, name := range []string{"db_execute", "db_transaction"} {
+		tl, _ := p.GetTool(name)
+		if !tl.Annotations().Destructive || !tl.Annotations().RequiresApproval || tl.Annotations().RiskLevel != tool.RiskHigh {
+			t.Errorf("%s should be destructive and require approval", name)
+		}
+	}
+
+	out, err := call(p, "db_execute", map[string]any{
+		"sql":    "UPDATE users SET email = ? WHERE name = ?",
+		"params": []any{"grace@example.com", "grace"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["rows_affected"].(float64) != 1 {
+		t.Errorf("rows_affected = %v", out["rows_affected"])
+	}
+
+	// A failing statement rolls back the whole transaction.
+	_, err = call(p, "db_transaction", map[string]any{
+		"statements": []map[string]any{
+			{"sql": "INSERT INTO users (name) VALUES (?)", "params": []any{"ken"}},
+			{"sql": "INSERT INTO users (name) VALUES (NULL)"},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected transaction to fail")
+	}
+	out, err = call(p, "db_query", map[string]any{"sql": "SELECT COUNT(*) AS n FROM users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := out["rows"].([]any)[0].(map[string]any)["n"]; n != float64(3) {
+		t.Errorf("expected rollback, got %v users", n)
+	}
+
+	if _, err := call(p, "db_execute", map[string]any{"connection": "ro", "sql": "DELETE FROM users"}); !errors.Is(err, ErrReadOnlyConnection) {
+		t.Errorf("expected ErrReadOnlyConnection, got %v", err)
+	}
+}
+
+func TestSchemaTools(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t, Config{})
+	out, err := call(p, "db_tables", map[string]any{"connection": "ro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tables := out["tables"].([]any); len(tables) != 1 || tables[0] != "users" {
+		t.Errorf("unexpected tables: %v", out)
+	}
+
+	out, err = call(p, "db_describe", map[string]any{"table": "users"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := out["columns"].([]any)
+	if len(columns) != 3 {
+		t.Fatalf("unexpected columns: %v", columns)
+	}
+	id := columns[0].(map[string]any)
+	if id["name"] != "id" || id["primary_key"] != true {
+		t.Errorf("unexpected id column: %v", id)
+	}
+
+	if _, err := call(p, "db_describe", map[string]any{"table": "users; DROP TABLE users"}); !errors.Is(err, ErrInvalidIdentifier) {
+		t.Errorf("expected ErrInvalidIdentifier, got %v", err)
+	}
+
+	out, err = call(p, "db_schema", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := out["tables"].(map[string]any)["users"]; !ok {
+		t.Errorf("schema missing users: %v", out)
+	}
+}
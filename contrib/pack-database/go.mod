@@ -4,4 +4,6 @@ go 1.25.0
 
 require github.com/felixgeelhaar/agent-go v0.0.0
 
+require github.com/mattn/go-sqlite3 v1.14.28
+
 replace github.com/felixgeelhaar/agent-go => ../..
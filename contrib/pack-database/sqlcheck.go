@@ -0,0 +1,177 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrWriteStatement indicates a statement that may modify data was passed
+// to a read-only tool.
+var ErrWriteStatement = errors.New("statement is not read-only")
+
+// readOnlyLeaders are the statement keywords accepted by db_query.
+var readOnlyLeaders = map[string]bool{
+	"SELECT":   true,
+	"WITH":     true,
+	"VALUES":   true,
+	"TABLE":    true,
+	"EXPLAIN":  true,
+	"SHOW":     true,
+	"DESCRIBE": true,
+	"DESC":     true,
+}
+
+// writeKeywords may not appear anywhere in a read-only statement, outside
+// of string literals, quoted identifiers, comments and function calls such
+// as REPLACE(...). This also rejects data-modifying CTEs, SELECT ... INTO
+// and row-locking clauses.
+var writeKeywords = map[string]bool{
+	"INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+	"UPSERT": true, "REPLACE": true, "CREATE": true, "ALTER": true,
+	"DROP": true, "TRUNCATE": true, "RENAME": true, "GRANT": true,
+	"REVOKE": true, "ATTACH": true, "DETACH": true, "COPY": true,
+	"CALL": true, "EXEC": true, "EXECUTE": true, "DO": true,
+	"LOCK": true, "UNLOCK": true, "VACUUM": true, "REINDEX": true,
+	"ANALYZE": true, "CLUSTER": true, "SET": true, "RESET": true,
+	"INTO": true, "PRAGMA": true, "LOAD": true, "HANDLER": true,
+	"COMMIT": true, "ROLLBACK": true, "BEGIN": true, "SAVEPOINT": true,
+	"NOTIFY": true, "LISTEN": true, "REFRESH": true, "IMPORT": true,
+	"OUTFILE": true, "DUMPFILE": true,
+}
+
+// token is a lexical SQL token. Only words and statement separators are
+// significant for classification.
+type token struct {
+	word string // upper-cased keyword or identifier
+	call bool   // word is immediately followed by "(" (a function call)
+	semi bool   // statement separator
+}
+
+func isWordByte(c byte, first bool) bool {
+	if c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return !first && ((c >= '0' && c <= '9') || c == '$')
+}
+
+// lexSQL splits SQL into words and separators, skipping whitespace,
+// comments, string literals (including Postgres dollar-quoted strings) and
+// quoted identifiers. "#" starts a comment only in MySQL; elsewhere it is an
+// operator or part of a parameter or temporary table name.
+func lexSQL(sql string, d Dialect) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(sql[i:], "--") || (c == '#' && d == DialectMySQL):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return tokens, nil
+			}
+			i += end + 1
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment")
+			}
+			i += 2 + end + 2
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(sql); j++ {
+				if sql[j] == '\\' && c == '\'' {
+					j++
+					continue
+				}
+				if sql[j] == c {
+					if j+1 < len(sql) && sql[j+1] == c {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j >= len(sql) {
+				return nil, errors.New("unterminated quoted string")
+			}
+			i = j + 1
+		case c == '[':
+			end := strings.IndexByte(sql[i:], ']')
+			if end < 0 {
+				return nil, errors.New("unterminated bracketed identifier")
+			}
+			i += end + 1
+		case c == '$':
+			// Dollar-quoted string ($$...$$ or $tag$...$tag$); otherwise a
+			// positional parameter such as $1.
+			j := i + 1
+			for j < len(sql) && isWordByte(sql[j], false) && sql[j] != '$' {
+				j++
+			}
+			if j < len(sql) && sql[j] == '$' && (j == i+1 || isWordByte(sql[i+1], true)) {
+				tag := sql[i : j+1]
+				end := strings.Index(sql[j+1:], tag)
+				if end < 0 {
+					return nil, errors.New("unterminated dollar-quoted string")
+				}
+				i = j + 1 + end + len(tag)
+			} else {
+				i = j
+			}
+		case c == ';':
+			tokens = append(tokens, token{semi: true})
+			i++
+		case isWordByte(c, true):
+			j := i
+			for j < len(sql) && isWordByte(sql[j], false) {
+				j++
+			}
+			t := token{word: strings.ToUpper(sql[i:j])}
+			k := j
+			for k < len(sql) && (sql[k] == ' ' || sql[k] == '\t' || sql[k] == '\n' || sql[k] == '\r') {
+				k++
+			}
+			t.call = k < len(sql) && sql[k] == '('
+			tokens = append(tokens, t)
+			i = j
+		default:
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// checkReadOnly verifies that sql is a single statement in dialect d that
+// cannot modify data or schema. It is a lexical check; db_query additionally
+// runs every statement in a read-only transaction that is always rolled back.
+func checkReadOnly(sql string, d Dialect) error {
+	tokens, err := lexSQL(sql, d)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWriteStatement, err)
+	}
+
+	// Drop trailing separators, then require a single statement.
+	for len(tokens) > 0 && tokens[len(tokens)-1].semi {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return errors.New("empty statement")
+	}
+	for _, t := range tokens {
+		if t.semi {
+			return fmt.Errorf("%w: multiple statements are not allowed", ErrWriteStatement)
+		}
+	}
+
+	if !readOnlyLeaders[tokens[0].word] {
+		return fmt.Errorf("%w: %s statements are not allowed", ErrWriteStatement, tokens[0].word)
+	}
+	for _, t := range tokens[1:] {
+		if writeKeywords[t.word] && !t.call {
+			return fmt.Errorf("%w: contains %s", ErrWriteStatement, t.word)
+		}
+	}
+	return nil
+}
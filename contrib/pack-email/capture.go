@@ -0,0 +1,435 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CapturedMessage is a message received by a CaptureServer.
+type CapturedMessage struct {
+	// From and To are the envelope sender and recipients, including Bcc.
+	From string
+	To   []string
+
+	// Data is the raw message as received.
+	Data []byte
+
+	// Username is the authenticated user, if any.
+	Username string
+
+	// TLS reports whether the message was received over STARTTLS.
+	TLS bool
+
+	// Header, Subject, Text and HTML are parsed from Data. Body parts are
+	// decoded from their transfer encoding.
+	Header  mail.Header
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// CaptureConfig configures a CaptureServer.
+type CaptureConfig struct {
+	// TLSConfig enables STARTTLS when set.
+	TLSConfig *tls.Config
+
+	// Username and Password require clients to authenticate with PLAIN or
+	// LOGIN before sending.
+	Username string
+	Password string
+}
+
+// CaptureServer is an in-process SMTP server for tests. It accepts every
+// message on 127.0.0.1 and records it instead of delivering it.
+//
+//	srv, _ := email.NewCaptureServer(email.CaptureConfig{})
+//	defer srv.Close()
+//	p := email.Pack(email.Config{SMTP: srv.SMTPConfig(), From: "agent@example.com"})
+type CaptureServer struct {
+	cfg CaptureConfig
+	ln  net.Listener
+	wg  sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []CapturedMessage
+	changed  chan struct{}
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewCaptureServer starts a capture server on a random local port.
+func NewCaptureServer(cfg CaptureConfig) (*CaptureServer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &CaptureServer{
+		cfg:     cfg,
+		ln:      ln,
+		changed: make(chan struct{}),
+		conns:   make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the server's host:port.
+func (s *CaptureServer) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// SMTPConfig returns a client configuration that delivers to this server.
+// It uses STARTTLS without certificate verification when TLS is enabled and
+// plain text otherwise, and includes the configured credentials.
+func (s *CaptureServer) SMTPConfig() SMTPConfig {
+	addr := s.ln.Addr().(*net.TCPAddr)
+	cfg := SMTPConfig{
+		Host:     addr.IP.String(),
+		Port:     addr.Port,
+		Username: s.cfg.Username,
+		Password: s.cfg.Password,
+		TLS:      TLSNone,
+	}
+	if s.cfg.TLSConfig != nil {
+		cfg.TLS = TLSStartTLS
+		cfg.TLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // test server with a self-signed certificate
+	}
+	return cfg
+}
+
+// Messages returns a copy of the messages received so far.
+func (s *CaptureServer) Messages() []CapturedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CapturedMessage(nil), s.messages...)
+}
+
+// Reset discards received messages.
+func (s *CaptureServer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+// WaitForMessages blocks until at least n messages have been received or
+// ctx is done, and returns the messages received so far.
+func (s *CaptureServer) WaitForMessages(ctx context.Context, n int) ([]CapturedMessage, error) {
+	for {
+		s.mu.Lock()
+		if len(s.messages) >= n {
+			msgs := append([]CapturedMessage(nil), s.messages...)
+			s.mu.Unlock()
+			return msgs, nil
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return s.Messages(), ctx.Err()
+		}
+	}
+}
+
+// Close stops the server and closes open connections.
+func (s *CaptureServer) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *CaptureServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+func (s *CaptureServer) record(msg CapturedMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// session is the state of one SMTP connection.
+type session struct {
+	tp       *textproto.Conn
+	tls      bool
+	username string
+	from     string
+	to       []string
+	inMail   bool
+}
+
+func (s *CaptureServer) handle(conn net.Conn) {
+	_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	ss := &session{tp: textproto.NewConn(conn)}
+	_ = ss.tp.PrintfLine("220 localhost ESMTP capture")
+
+	for {
+		line, err := ss.tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ss.reset()
+			lines := []string{"localhost", "8BITMIME", "PIPELINING"}
+			if s.cfg.TLSConfig != nil && !ss.tls {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.cfg.Username != "" {
+				lines = append(lines, "AUTH PLAIN LOGIN")
+			}
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				_ = ss.tp.PrintfLine("250%s%s", sep, l)
+			}
+		case "HELO":
+			ss.reset()
+			_ = ss.tp.PrintfLine("250 localhost")
+		case "STARTTLS":
+			if s.cfg.TLSConfig == nil || ss.tls {
+				_ = ss.tp.PrintfLine("502 STARTTLS not available")
+				continue
+			}
+			_ = ss.tp.PrintfLine("220 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.cfg.TLSConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			// The session restarts from scratch after the upgrade.
+			ss = &session{tp: textproto.NewConn(tlsConn), tls: true}
+		case "AUTH":
+			s.auth(ss, arg)
+		case "MAIL":
+			if s.cfg.Username != "" && ss.username == "" {
+				_ = ss.tp.PrintfLine("530 Authentication required")
+				continue
+			}
+			ss.reset()
+			ss.from = pathArg(arg, "FROM:")
+			ss.inMail = true
+			_ = ss.tp.PrintfLine("250 OK")
+		case "RCPT":
+			if !ss.inMail {
+				_ = ss.tp.PrintfLine("503 MAIL first")
+				continue
+			}
+			ss.to = append(ss.to, pathArg(arg, "TO:"))
+			_ = ss.tp.PrintfLine("250 OK")
+		case "DATA":
+			if len(ss.to) == 0 {
+				_ = ss.tp.PrintfLine("503 RCPT first")
+				continue
+			}
+			_ = ss.tp.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(ss.tp.DotReader())
+			if err != nil {
+				return
+			}
+			s.record(parseCaptured(ss, data))
+			ss.reset()
+			_ = ss.tp.PrintfLine("250 OK queued")
+		case "RSET":
+			ss.reset()
+			_ = ss.tp.PrintfLine("250 OK")
+		case "NOOP":
+			_ = ss.tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = ss.tp.PrintfLine("221 Bye")
+			return
+		default:
+			_ = ss.tp.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func (ss *session) reset() {
+	ss.from, ss.to, ss.inMail = "", nil, false
+}
+
+// pathArg extracts the address from "FROM:<addr> PARAMS".
+func pathArg(arg, prefix string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = strings.TrimSpace(arg[len(prefix):])
+	}
+	if end := strings.Index(arg, ">"); strings.HasPrefix(arg, "<") && end > 0 {
+		return arg[1:end]
+	}
+	addr, _, _ := strings.Cut(arg, " ")
+	return addr
+}
+
+func (s *CaptureServer) auth(ss *session, arg string) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	challenge := func(prompt string) (string, bool) {
+		_ = ss.tp.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, err := ss.tp.ReadLine()
+		if err != nil || line == "*" {
+			return "", false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(line)
+		return string(decoded), err == nil
+	}
+
+	var username, password string
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		var response string
+		if initial != "" {
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			if err != nil {
+				_ = ss.tp.PrintfLine("501 Invalid encoding")
+				return
+			}
+			response = string(decoded)
+		} else {
+			var ok bool
+			if response, ok = challenge(""); !ok {
+				_ = ss.tp.PrintfLine("501 Authentication cancelled")
+				return
+			}
+		}
+		parts := strings.SplitN(response, "\x00", 3)
+		if len(parts) != 3 {
+			_ = ss.tp.PrintfLine("501 Invalid PLAIN response")
+			return
+		}
+		username, password = parts[1], parts[2]
+	case "LOGIN":
+		var ok bool
+		if username, ok = challenge("Username:"); !ok {
+			_ = ss.tp.PrintfLine("501 Authentication cancelled")
+			return
+		}
+		if password, ok = challenge("Password:"); !ok {
+			_ = ss.tp.PrintfLine("501 Authentication cancelled")
+			return
+		}
+	default:
+		_ = ss.tp.PrintfLine("504 Unrecognized authentication type")
+		return
+	}
+
+	if s.cfg.Username == "" || username != s.cfg.Username || password != s.cfg.Password {
+		_ = ss.tp.PrintfLine("535 Authentication credentials invalid")
+		return
+	}
+	ss.username = username
+	_ = ss.tp.PrintfLine("235 Authentication successful")
+}
+
+// parseCaptured parses a received message. Parse failures leave the
+// derived fields empty; Data always holds the raw message.
+func parseCaptured(ss *session, data []byte) CapturedMessage {
+	msg := CapturedMessage{
+		From:     ss.from,
+		To:       append([]string(nil), ss.to...),
+		Data:     data,
+		Username: ss.username,
+		TLS:      ss.tls,
+	}
+	m, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return msg
+	}
+	msg.Header = m.Header
+	msg.Subject, err = new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		msg.Subject = m.Header.Get("Subject")
+	}
+	_ = collectParts(&msg, textproto.MIMEHeader(m.Header), m.Body)
+	return msg
+}
+
+// collectParts walks a MIME entity and stores its text and HTML parts.
+func collectParts(msg *CapturedMessage, header textproto.MIMEHeader, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			// NextPart already decodes quoted-printable parts.
+			if err := collectParts(msg, part.Header, part); err != nil {
+				return err
+			}
+		}
+	}
+
+	if strings.EqualFold(header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		body = quotedprintable.NewReader(body)
+	} else if strings.EqualFold(header.Get("Content-Transfer-Encoding"), "base64") {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	switch mediaType {
+	case "text/plain":
+		msg.Text = string(content)
+	case "text/html":
+		msg.HTML = string(content)
+	}
+	return nil
+}
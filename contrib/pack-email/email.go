@@ -7,67 +7,604 @@
 //   - email_validate: Validate an email address
 //   - email_list_templates: List available email templates
 //
-// Supports SMTP, SendGrid, Mailgun, AWS SES, and Postmark.
-// Templates support variable substitution and HTML/plain text formats.
+// Mail is delivered over SMTP with STARTTLS or implicit TLS and optional
+// authentication; other providers can be plugged in through the Sender
+// interface. Templates use text/template for the subject and plain-text
+// part and html/template for the HTML part. Every recipient is checked
+// against domain allow and deny lists, the sender address is fixed by
+// configuration, and bulk sends are rate limited. CaptureServer is an
+// in-process SMTP server that records delivered mail for tests.
 package email
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"net"
+	"net/mail"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/felixgeelhaar/fortify/ratelimit"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Errors returned by the email tools.
+var (
+	// ErrRecipientNotAllowed indicates a recipient domain is denied or not allowlisted.
+	ErrRecipientNotAllowed = errors.New("recipient not allowed")
+
+	// ErrInvalidAddress indicates an address could not be parsed.
+	ErrInvalidAddress = errors.New("invalid email address")
+
+	// ErrInvalidMessage indicates a message is missing required parts or has invalid fields.
+	ErrInvalidMessage = errors.New("invalid message")
+
+	// ErrTooManyRecipients indicates a message or bulk send exceeds the configured limits.
+	ErrTooManyRecipients = errors.New("too many recipients")
+
+	// ErrUnknownTemplate indicates the requested template is not configured.
+	ErrUnknownTemplate = errors.New("unknown template")
+
+	// ErrNoSender indicates neither a Sender nor an SMTP host is configured.
+	ErrNoSender = errors.New("no sender configured")
+)
+
+// Template is a named message template. Subject and Text are rendered with
+// text/template, HTML with html/template. At least one of Text and HTML is
+// required. Referencing a key missing from the data is an error.
+type Template struct {
+	// Description is shown by email_list_templates.
+	Description string
+
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Config configures the email pack.
+type Config struct {
+	// SMTP configures the built-in SMTP sender. Ignored when Sender is set.
+	SMTP SMTPConfig
+
+	// Sender overrides SMTP delivery.
+	Sender Sender
+
+	// From is the sender address of every message, such as
+	// "Agent <agent@example.com>". The planner cannot change it.
+	From string
+
+	// ReplyTo is the default Reply-To address.
+	ReplyTo string
+
+	// AllowedDomains lists recipient domain patterns, such as "example.com"
+	// or "*.example.com". Empty allows any domain that is not denied.
+	AllowedDomains []string
+
+	// DeniedDomains lists recipient domain patterns that are always rejected.
+	DeniedDomains []string
+
+	// Templates are the named templates callers may use.
+	Templates map[string]Template
+
+	// MaxRecipients caps To, Cc and Bcc of a single message. Defaults to 50.
+	MaxRecipients int
+
+	// MaxBulk caps the number of messages in one bulk send. Defaults to 1000.
+	MaxBulk int
+
+	// BulkRate is the number of bulk messages sent per second. Defaults to 10.
+	BulkRate int
+
+	// Limiter overrides the bulk rate limiter. It is shared by all bulk
+	// sends of the pack.
+	Limiter ratelimit.RateLimiter
+
+	// LookupMX resolves MX records for email_validate. Defaults to
+	// net.DefaultResolver.LookupMX.
+	LookupMX func(ctx context.Context, domain string) ([]*net.MX, error)
+}
+
 // Pack returns the email tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	p := newEmailPack(cfg)
+
 	return pack.NewBuilder("email").
 		WithDescription("Email sending and template tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			emailSend(),
-			emailSendTemplate(),
-			emailSendBulk(),
-			emailValidate(),
-			emailListTemplates(),
+			p.emailSend(),
+			p.emailSendTemplate(),
+			p.emailSendBulk(),
+			p.emailValidate(),
+			p.emailListTemplates(),
 		).
 		AllowInState(agent.StateExplore, "email_validate", "email_list_templates").
 		AllowInState(agent.StateAct, "email_send", "email_send_template", "email_send_bulk", "email_validate", "email_list_templates").
 		Build()
 }
 
-func emailSend() tool.Tool {
+type compiledTemplate struct {
+	spec    Template
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+	err     error
+}
+
+type emailPack struct {
+	cfg       Config
+	sender    Sender
+	templates map[string]*compiledTemplate
+}
+
+func newEmailPack(cfg Config) *emailPack {
+	if cfg.MaxRecipients <= 0 {
+		cfg.MaxRecipients = 50
+	}
+	if cfg.MaxBulk <= 0 {
+		cfg.MaxBulk = 1000
+	}
+	if cfg.BulkRate <= 0 {
+		cfg.BulkRate = 10
+	}
+	if cfg.Limiter == nil {
+		cfg.Limiter = ratelimit.New(&ratelimit.Config{
+			Rate:     cfg.BulkRate,
+			Burst:    cfg.BulkRate,
+			Interval: time.Second,
+		})
+	}
+	if cfg.LookupMX == nil {
+		cfg.LookupMX = net.DefaultResolver.LookupMX
+	}
+
+	p := &emailPack{cfg: cfg, sender: cfg.Sender, templates: make(map[string]*compiledTemplate)}
+	if p.sender == nil && cfg.SMTP.Host != "" {
+		p.sender = NewSMTPSender(cfg.SMTP)
+	}
+	for name, spec := range cfg.Templates {
+		p.templates[name] = compileTemplate(name, spec)
+	}
+	return p
+}
+
+// compileTemplate parses a template. Parse errors are kept and reported
+// when the template is used, so one bad template does not disable the pack.
+func compileTemplate(name string, spec Template) *compiledTemplate {
+	t := &compiledTemplate{spec: spec}
+	if spec.Text == "" && spec.HTML == "" {
+		t.err = fmt.Errorf("%w: template %s has no text or html body", ErrInvalidMessage, name)
+		return t
+	}
+	if t.subject, t.err = template.New(name + ".subject").Option("missingkey=error").Parse(spec.Subject); t.err != nil {
+		return t
+	}
+	if spec.Text != "" {
+		if t.text, t.err = template.New(name + ".text").Option("missingkey=error").Parse(spec.Text); t.err != nil {
+			return t
+		}
+	}
+	if spec.HTML != "" {
+		t.html, t.err = htmltemplate.New(name + ".html").Option("missingkey=error").Parse(spec.HTML)
+	}
+	return t
+}
+
+// render executes the template into subject, text and HTML parts.
+func (t *compiledTemplate) render(data map[string]any) (subject, text, html string, err error) {
+	if t.err != nil {
+		return "", "", "", t.err
+	}
+	var b strings.Builder
+	if err := t.subject.Execute(&b, data); err != nil {
+		return "", "", "", fmt.Errorf("failed to render subject: %w", err)
+	}
+	subject = strings.TrimSpace(b.String())
+	if t.text != nil {
+		b.Reset()
+		if err := t.text.Execute(&b, data); err != nil {
+			return "", "", "", fmt.Errorf("failed to render text: %w", err)
+		}
+		text = b.String()
+	}
+	if t.html != nil {
+		b.Reset()
+		if err := t.html.Execute(&b, data); err != nil {
+			return "", "", "", fmt.Errorf("failed to render html: %w", err)
+		}
+		html = b.String()
+	}
+	return subject, text, html, nil
+}
+
+func (p *emailPack) template(name string) (*compiledTemplate, error) {
+	t, ok := p.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	return t, nil
+}
+
+// ============================================================================
+// Recipient Policy
+// ============================================================================
+
+// matchDomain reports whether domain matches any of the patterns.
+// "*.example.com" matches subdomains of example.com but not example.com itself.
+func matchDomain(patterns []string, domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == domain {
+			return true
+		}
+		if ok, _ := path.Match(pattern, domain); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddress parses a single address and returns it with its domain.
+func parseAddress(s string) (*mail.Address, string, error) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %q: %v", ErrInvalidAddress, s, err)
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	if at <= 0 || at == len(addr.Address)-1 {
+		return nil, "", fmt.Errorf("%w: %q has no domain", ErrInvalidAddress, s)
+	}
+	return addr, strings.ToLower(addr.Address[at+1:]), nil
+}
+
+// checkDomain applies the domain allow and deny lists.
+func (p *emailPack) checkDomain(domain string) error {
+	if matchDomain(p.cfg.DeniedDomains, domain) {
+		return fmt.Errorf("%w: domain %s is denied", ErrRecipientNotAllowed, domain)
+	}
+	if len(p.cfg.AllowedDomains) > 0 && !matchDomain(p.cfg.AllowedDomains, domain) {
+		return fmt.Errorf("%w: domain %s is not allowlisted", ErrRecipientNotAllowed, domain)
+	}
+	return nil
+}
+
+// checkRecipients validates and normalizes every recipient of msg.
+func (p *emailPack) checkRecipients(msg *Message) error {
+	total := 0
+	for _, list := range []*[]string{&msg.To, &msg.Cc, &msg.Bcc} {
+		for i, s := range *list {
+			addr, domain, err := parseAddress(s)
+			if err != nil {
+				return err
+			}
+			if err := p.checkDomain(domain); err != nil {
+				return err
+			}
+			(*list)[i] = addr.String()
+		}
+		total += len(*list)
+	}
+	if len(msg.To) == 0 {
+		return fmt.Errorf("%w: at least one to recipient is required", ErrInvalidMessage)
+	}
+	if total > p.cfg.MaxRecipients {
+		return fmt.Errorf("%w: %d recipients exceeds limit of %d", ErrTooManyRecipients, total, p.cfg.MaxRecipients)
+	}
+	return nil
+}
+
+// ============================================================================
+// Sending
+// ============================================================================
+
+// recipients is the common recipient input of the send tools.
+type recipients struct {
+	To  []string `json:"to"`
+	Cc  []string `json:"cc,omitempty"`
+	Bcc []string `json:"bcc,omitempty"`
+}
+
+// prepare builds a message from rendered content and checks it.
+func (p *emailPack) prepare(r recipients, replyTo, subject, text, html string) (*Message, error) {
+	if p.sender == nil {
+		return nil, ErrNoSender
+	}
+	if p.cfg.From == "" {
+		return nil, fmt.Errorf("%w: no from address configured", ErrInvalidMessage)
+	}
+	if replyTo == "" {
+		replyTo = p.cfg.ReplyTo
+	}
+	msg := &Message{
+		From:    p.cfg.From,
+		To:      append([]string(nil), r.To...),
+		Cc:      append([]string(nil), r.Cc...),
+		Bcc:     append([]string(nil), r.Bcc...),
+		ReplyTo: replyTo,
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+	}
+	if strings.TrimSpace(msg.Subject) == "" {
+		return nil, fmt.Errorf("%w: subject is required", ErrInvalidMessage)
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, fmt.Errorf("%w: text or html body is required", ErrInvalidMessage)
+	}
+	if msg.ReplyTo != "" {
+		if _, _, err := parseAddress(msg.ReplyTo); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.checkRecipients(msg); err != nil {
+		return nil, err
+	}
+	msg.MessageID = newMessageID(msg.From)
+	return msg, nil
+}
+
+func sentOutput(msg *Message) json.RawMessage {
+	output, _ := json.Marshal(map[string]any{
+		"sent":       true,
+		"message_id": msg.MessageID,
+		"recipients": len(msg.Recipients()),
+	})
+	return output
+}
+
+func (p *emailPack) emailSend() tool.Tool {
 	return tool.NewBuilder("email_send").
-		WithDescription("Send an email message").
+		WithDescription("Send an email with a plain-text and/or HTML body from the configured sender address").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				recipients
+				ReplyTo string `json:"reply_to,omitempty"`
+				Subject string `json:"subject"`
+				Text    string `json:"text,omitempty"`
+				HTML    string `json:"html,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			msg, err := p.prepare(in.recipients, in.ReplyTo, in.Subject, in.Text, in.HTML)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.sender.Send(ctx, msg); err != nil {
+				return tool.Result{}, fmt.Errorf("failed to send email: %w", err)
+			}
+			return tool.Result{Output: sentOutput(msg)}, nil
+		}).
 		MustBuild()
 }
 
-func emailSendTemplate() tool.Tool {
+func (p *emailPack) emailSendTemplate() tool.Tool {
 	return tool.NewBuilder("email_send_template").
-		WithDescription("Send an email using a predefined template").
+		WithDescription("Send an email rendered from a configured template with the given data").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				recipients
+				Template string         `json:"template"`
+				Data     map[string]any `json:"data,omitempty"`
+				ReplyTo  string         `json:"reply_to,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			t, err := p.template(in.Template)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			subject, text, html, err := t.render(in.Data)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			msg, err := p.prepare(in.recipients, in.ReplyTo, subject, text, html)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.sender.Send(ctx, msg); err != nil {
+				return tool.Result{}, fmt.Errorf("failed to send email: %w", err)
+			}
+			return tool.Result{Output: sentOutput(msg)}, nil
+		}).
 		MustBuild()
 }
 
-func emailSendBulk() tool.Tool {
+func (p *emailPack) emailSendBulk() tool.Tool {
 	return tool.NewBuilder("email_send_bulk").
-		WithDescription("Send bulk emails to multiple recipients").
+		WithDescription("Send a templated or literal email individually to many recipients, rate limited").
 		WithRiskLevel(tool.RiskHigh).
 		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Template   string `json:"template,omitempty"`
+				Subject    string `json:"subject,omitempty"`
+				Text       string `json:"text,omitempty"`
+				HTML       string `json:"html,omitempty"`
+				Recipients []struct {
+					To   string         `json:"to"`
+					Data map[string]any `json:"data,omitempty"`
+				} `json:"recipients"`
+				StopOnError bool `json:"stop_on_error,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Recipients) == 0 {
+				return tool.Result{}, fmt.Errorf("%w: recipients are required", ErrInvalidMessage)
+			}
+			if len(in.Recipients) > p.cfg.MaxBulk {
+				return tool.Result{}, fmt.Errorf("%w: %d messages exceeds bulk limit of %d", ErrTooManyRecipients, len(in.Recipients), p.cfg.MaxBulk)
+			}
+
+			var t *compiledTemplate
+			if in.Template != "" {
+				var err error
+				if t, err = p.template(in.Template); err != nil {
+					return tool.Result{}, err
+				}
+			}
+
+			// Render and check every message before sending any, so a bad
+			// recipient or template error does not leave a partial send.
+			msgs := make([]*Message, len(in.Recipients))
+			for i, r := range in.Recipients {
+				subject, text, html := in.Subject, in.Text, in.HTML
+				if t != nil {
+					var err error
+					if subject, text, html, err = t.render(r.Data); err != nil {
+						return tool.Result{}, fmt.Errorf("recipient %d (%s): %w", i, r.To, err)
+					}
+				}
+				msg, err := p.prepare(recipients{To: []string{r.To}}, "", subject, text, html)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("recipient %d (%s): %w", i, r.To, err)
+				}
+				msgs[i] = msg
+			}
+
+			results := make([]map[string]any, 0, len(msgs))
+			sent, failed := 0, 0
+			for _, msg := range msgs {
+				if err := p.cfg.Limiter.Wait(ctx, "email_send_bulk"); err != nil {
+					if ctx.Err() != nil {
+						break
+					}
+					return tool.Result{}, fmt.Errorf("rate limiter: %w", err)
+				}
+				result := map[string]any{"to": msg.To[0]}
+				if err := p.sender.Send(ctx, msg); err != nil {
+					failed++
+					result["error"] = err.Error()
+					results = append(results, result)
+					if in.StopOnError || ctx.Err() != nil {
+						break
+					}
+					continue
+				}
+				sent++
+				result["message_id"] = msg.MessageID
+				results = append(results, result)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"total":   len(msgs),
+				"sent":    sent,
+				"failed":  failed,
+				"skipped": len(msgs) - sent - failed,
+				"results": results,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func emailValidate() tool.Tool {
+// ============================================================================
+// Validation and Templates
+// ============================================================================
+
+func (p *emailPack) emailValidate() tool.Tool {
 	return tool.NewBuilder("email_validate").
-		WithDescription("Validate an email address format and deliverability").
+		WithDescription("Validate an email address format, check it against the recipient policy and optionally look up its MX records").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Email   string `json:"email"`
+				CheckMX bool   `json:"check_mx,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			out := map[string]any{"email": in.Email}
+			addr, domain, err := parseAddress(in.Email)
+			if err != nil {
+				out["valid"] = false
+				out["reason"] = err.Error()
+				output, _ := json.Marshal(out)
+				return tool.Result{Output: output}, nil
+			}
+			out["valid"] = true
+			out["address"] = addr.Address
+			out["domain"] = domain
+			if addr.Name != "" {
+				out["name"] = addr.Name
+			}
+			if err := p.checkDomain(domain); err != nil {
+				out["allowed"] = false
+				out["reason"] = err.Error()
+			} else {
+				out["allowed"] = true
+			}
+
+			if in.CheckMX {
+				records, err := p.cfg.LookupMX(ctx, domain)
+				hosts := make([]string, 0, len(records))
+				for _, mx := range records {
+					hosts = append(hosts, strings.TrimSuffix(mx.Host, "."))
+				}
+				out["mx"] = hosts
+				out["deliverable"] = err == nil && len(hosts) > 0
+				if err != nil {
+					out["mx_error"] = err.Error()
+				}
+			}
+
+			output, _ := json.Marshal(out)
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func emailListTemplates() tool.Tool {
+func (p *emailPack) emailListTemplates() tool.Tool {
 	return tool.NewBuilder("email_list_templates").
-		WithDescription("List available email templates").
+		WithDescription("List the configured email templates").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			names := make([]string, 0, len(p.templates))
+			for name := range p.templates {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			templates := make([]map[string]any, 0, len(names))
+			for _, name := range names {
+				t := p.templates[name]
+				entry := map[string]any{
+					"name":        name,
+					"description": t.spec.Description,
+					"subject":     t.spec.Subject,
+					"has_text":    t.spec.Text != "",
+					"has_html":    t.spec.HTML != "",
+				}
+				if t.err != nil {
+					entry["error"] = t.err.Error()
+				}
+				templates = append(templates, entry)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"templates": templates,
+				"count":     len(templates),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
@@ -0,0 +1,278 @@
+package email
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+func newCapture(t *testing.T, cfg CaptureConfig) *CaptureServer {
+	t.Helper()
+	srv, err := NewCaptureServer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = srv.Close() })
+	return srv
+}
+
+func selfSignedTLS(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func TestSendMultipart(t *testing.T) {
+	t.Parallel()
+
+	srv := newCapture(t, CaptureConfig{})
+	p := Pack(Config{SMTP: srv.SMTPConfig(), From: "Agent <agent@example.com>"})
+
+	out, err := call(p, "email_send", map[string]any{
+		"to":      []string{"Alice <alice@example.com>"},
+		"bcc":     []string{"audit@example.com"},
+		"subject": "Grüße",
+		"text":    "Hello Alice",
+		"html":    "<p>Hello <b>Alice</b></p>",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["recipients"].(float64) != 2 {
+		t.Errorf("recipients = %v", out["recipients"])
+	}
+
+	msgs := srv.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("captured %d messages", len(msgs))
+	}
+	msg := msgs[0]
+	if msg.From != "agent@example.com" || len(msg.To) != 2 || msg.To[1] != "audit@example.com" {
+		t.Errorf("envelope = %s -> %v", msg.From, msg.To)
+	}
+	if msg.Subject != "Grüße" || msg.Text != "Hello Alice" || msg.HTML != "<p>Hello <b>Alice</b></p>" {
+		t.Errorf("parsed = %q / %q / %q", msg.Subject, msg.Text, msg.HTML)
+	}
+	if msg.Header.Get("Bcc") != "" || strings.Contains(string(msg.Data), "audit@") {
+		t.Error("bcc recipient leaked into headers")
+	}
+	if msg.Header.Get("Message-Id") != out["message_id"] {
+		t.Errorf("message id = %q, want %v", msg.Header.Get("Message-Id"), out["message_id"])
+	}
+}
+
+func TestStartTLSAndAuth(t *testing.T) {
+	t.Parallel()
+
+	srv := newCapture(t, CaptureConfig{TLSConfig: selfSignedTLS(t), Username: "agent", Password: "s3cret"})
+	p := Pack(Config{SMTP: srv.SMTPConfig(), From: "agent@example.com"})
+
+	if _, err := call(p, "email_send", map[string]any{"to": []string{"bob@example.com"}, "subject": "hi", "text": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	msg := srv.Messages()[0]
+	if !msg.TLS || msg.Username != "agent" {
+		t.Errorf("tls = %v, username = %q", msg.TLS, msg.Username)
+	}
+
+	bad := srv.SMTPConfig()
+	bad.Password = "wrong"
+	p = Pack(Config{SMTP: bad, From: "agent@example.com"})
+	if _, err := call(p, "email_send", map[string]any{"to": []string{"bob@example.com"}, "subject": "hi", "text": "x"}); err == nil {
+		t.Error("expected authentication failure")
+	}
+
+	// STARTTLS is required by default, so a plain-only server is refused.
+	plain := newCapture(t, CaptureConfig{})
+	cfg := plain.SMTPConfig()
+	cfg.TLS = TLSStartTLS
+	p = Pack(Config{SMTP: cfg, From: "agent@example.com"})
+	if _, err := call(p, "email_send", map[string]any{"to": []string{"bob@example.com"}, "subject": "hi", "text": "x"}); err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("expected STARTTLS error, got %v", err)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	t.Parallel()
+
+	srv := newCapture(t, CaptureConfig{})
+	p := Pack(Config{
+		SMTP: srv.SMTPConfig(),
+		From: "agent@example.com",
+		Templates: map[string]Template{
+			"welcome": {
+				Description: "Welcome mail",
+				Subject:     "Welcome, {{.name}}",
+				Text:        "Hi {{.name}}",
+				HTML:        "<p>Hi {{.name}}</p>",
+			},
+			"broken": {Subject: "{{.x", Text: "x"},
+		},
+	})
+
+	list, err := call(p, "email_list_templates", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list["count"].(float64) != 2 {
+		t.Errorf("count = %v", list["count"])
+	}
+
+	_, err = call(p, "email_send_template", map[string]any{
+		"template": "welcome",
+		"to":       []string{"eve@example.com"},
+		"data":     map[string]any{"name": "<Eve>"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := srv.Messages()[0]
+	if msg.Subject != "Welcome, <Eve>" || msg.Text != "Hi <Eve>" || msg.HTML != "<p>Hi &lt;Eve&gt;</p>" {
+		t.Errorf("rendered = %q / %q / %q", msg.Subject, msg.Text, msg.HTML)
+	}
+
+	if _, err := call(p, "email_send_template", map[string]any{"template": "welcome", "to": []string{"eve@example.com"}}); err == nil {
+		t.Error("expected missing key error")
+	}
+	if _, err := call(p, "email_send_template", map[string]any{"template": "broken", "to": []string{"eve@example.com"}}); err == nil {
+		t.Error("expected parse error")
+	}
+	if _, err := call(p, "email_send_template", map[string]any{"template": "nope", "to": []string{"eve@example.com"}}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("expected ErrUnknownTemplate, got %v", err)
+	}
+}
+
+func TestRecipientPolicy(t *testing.T) {
+	t.Parallel()
+
+	srv := newCapture(t, CaptureConfig{})
+	p := Pack(Config{
+		SMTP:           srv.SMTPConfig(),
+		From:           "agent@example.com",
+		AllowedDomains: []string{"example.com", "*.example.com"},
+		DeniedDomains:  []string{"hr.example.com"},
+		MaxRecipients:  2,
+	})
+	send := func(to ...string) error {
+		_, err := call(p, "email_send", map[string]any{"to": to, "subject": "s", "text": "t"})
+		return err
+	}
+
+	if err := send("a@mail.example.com"); err != nil {
+		t.Errorf("subdomain should be allowed: %v", err)
+	}
+	if err := send("a@example.org"); !errors.Is(err, ErrRecipientNotAllowed) {
+		t.Errorf("expected ErrRecipientNotAllowed, got %v", err)
+	}
+	if err := send("a@hr.example.com"); !errors.Is(err, ErrRecipientNotAllowed) {
+		t.Errorf("denied domain should win, got %v", err)
+	}
+	if err := send("a@example.com", "b@example.com", "c@example.com"); !errors.Is(err, ErrTooManyRecipients) {
+		t.Errorf("expected ErrTooManyRecipients, got %v", err)
+	}
+	if err := send("not an address"); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("expected ErrInvalidAddress, got %v", err)
+	}
+	if _, err := call(p, "email_send", map[string]any{"to": []string{"a@example.com"}, "subject": "a\r\nBcc: x@evil.test", "text": "t"}); !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("expected header injection to be rejected, got %v", err)
+	}
+	if n := len(srv.Messages()); n != 1 {
+		t.Errorf("captured %d messages, want 1", n)
+	}
+
+	out, err := call(p, "email_validate", map[string]any{"email": "x@example.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["valid"] != true || out["allowed"] != false {
+		t.Errorf("validate = %v", out)
+	}
+}
+
+func TestBulkRateLimited(t *testing.T) {
+	t.Parallel()
+
+	srv := newCapture(t, CaptureConfig{})
+	p := Pack(Config{
+		SMTP:      srv.SMTPConfig(),
+		From:      "agent@example.com",
+		BulkRate:  2,
+		Templates: map[string]Template{"note": {Subject: "Note for {{.name}}", Text: "Hi {{.name}}"}},
+	})
+
+	recipients := []map[string]any{}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		recipients = append(recipients, map[string]any{"to": name + "@example.com", "data": map[string]any{"name": name}})
+	}
+
+	start := time.Now()
+	out, err := call(p, "email_send_bulk", map[string]any{"template": "note", "recipients": recipients})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 800*time.Millisecond {
+		t.Errorf("4 messages at 2/s took %v", elapsed)
+	}
+	if out["sent"].(float64) != 4 || out["failed"].(float64) != 0 {
+		t.Errorf("bulk = %v", out)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	msgs, err := srv.WaitForMessages(ctx, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msgs[3].Subject != "Note for d" || len(msgs[3].To) != 1 || msgs[3].To[0] != "d@example.com" {
+		t.Errorf("last message = %q to %v", msgs[3].Subject, msgs[3].To)
+	}
+
+	// A bad recipient rejects the whole batch before anything is sent.
+	srv.Reset()
+	recipients = append(recipients, map[string]any{"to": "broken", "data": map[string]any{"name": "x"}})
+	if _, err := call(p, "email_send_bulk", map[string]any{"template": "note", "recipients": recipients}); !errors.Is(err, ErrInvalidAddress) {
+		t.Errorf("expected ErrInvalidAddress, got %v", err)
+	}
+	if n := len(srv.Messages()); n != 0 {
+		t.Errorf("captured %d messages after rejected batch", n)
+	}
+}
@@ -2,6 +2,9 @@ module github.com/felixgeelhaar/agent-go/contrib/pack-email
 
 go 1.25.0
 
-require github.com/felixgeelhaar/agent-go v0.0.0
+require (
+	github.com/felixgeelhaar/agent-go v0.0.0
+	github.com/felixgeelhaar/fortify v1.1.2
+)
 
 replace github.com/felixgeelhaar/agent-go => ../..
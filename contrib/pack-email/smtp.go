@@ -0,0 +1,370 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSMode selects how the SMTP connection is secured.
+type TLSMode string
+
+const (
+	// TLSStartTLS upgrades a plain connection with STARTTLS and fails if the
+	// server does not offer it. This is the default.
+	TLSStartTLS TLSMode = "starttls"
+
+	// TLSImplicit connects with TLS from the start (SMTPS, usually port 465).
+	TLSImplicit TLSMode = "tls"
+
+	// TLSNone sends mail in plain text. Only suitable for local relays and tests.
+	TLSNone TLSMode = "none"
+)
+
+// Message is an outgoing email.
+type Message struct {
+	From    string
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+	Subject string
+
+	// Text and HTML are the body parts. When both are set the message is
+	// sent as multipart/alternative.
+	Text string
+	HTML string
+
+	// MessageID is assigned by the pack before sending.
+	MessageID string
+}
+
+// Recipients returns every envelope recipient, including Bcc.
+func (m *Message) Recipients() []string {
+	rcpts := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	rcpts = append(rcpts, m.To...)
+	rcpts = append(rcpts, m.Cc...)
+	return append(rcpts, m.Bcc...)
+}
+
+// Sender delivers messages. SMTPSender is the built-in implementation;
+// API-based providers can be plugged in by implementing Sender.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPConfig configures an SMTPSender.
+type SMTPConfig struct {
+	// Host is the SMTP server host name.
+	Host string
+
+	// Port is the SMTP server port. Defaults to 465 for TLSImplicit and 587
+	// otherwise.
+	Port int
+
+	// Username and Password enable SMTP AUTH (PLAIN, or LOGIN when the
+	// server only offers that). Authentication is never attempted over an
+	// unencrypted connection except to localhost.
+	Username string
+	Password string
+
+	// TLS selects the transport security. Defaults to TLSStartTLS.
+	TLS TLSMode
+
+	// TLSConfig overrides the TLS client configuration. ServerName
+	// defaults to Host.
+	TLSConfig *tls.Config
+
+	// LocalName is the host name sent in EHLO. Defaults to "localhost".
+	LocalName string
+
+	// Timeout bounds a whole delivery including dialing. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+// SMTPSender delivers messages over SMTP.
+type SMTPSender struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPSender creates an SMTP sender, applying defaults.
+func NewSMTPSender(cfg SMTPConfig) *SMTPSender {
+	if cfg.TLS == "" {
+		cfg.TLS = TLSStartTLS
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.TLS == TLSImplicit {
+			cfg.Port = 465
+		}
+	}
+	if cfg.LocalName == "" {
+		cfg.LocalName = "localhost"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &SMTPSender{cfg: cfg}
+}
+
+func (s *SMTPSender) tlsConfig() *tls.Config {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.cfg.TLSConfig != nil {
+		cfg = s.cfg.TLSConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = s.cfg.Host
+	}
+	return cfg
+}
+
+// Send delivers msg in a single SMTP session.
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.bytes()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{}
+	var conn net.Conn
+	switch s.cfg.TLS {
+	case TLSImplicit:
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: s.tlsConfig()}).DialContext(ctx, "tcp", addr)
+	case TLSStartTLS, TLSNone:
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	default:
+		return fmt.Errorf("unknown TLS mode %q", s.cfg.TLS)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	// Abort the session if the context is cancelled mid-conversation.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+
+	if err := s.deliver(c, msg, data); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *SMTPSender) deliver(c *smtp.Client, msg *Message, data []byte) error {
+	if err := c.Hello(s.cfg.LocalName); err != nil {
+		return fmt.Errorf("EHLO failed: %w", err)
+	}
+	if s.cfg.TLS == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+		if err := c.StartTLS(s.tlsConfig()); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		ok, mechanisms := c.Extension("AUTH")
+		if !ok {
+			return errors.New("server does not support AUTH")
+		}
+		var auth smtp.Auth
+		if !strings.Contains(" "+strings.ToUpper(mechanisms)+" ", " PLAIN ") && strings.Contains(strings.ToUpper(mechanisms), "LOGIN") {
+			auth = &loginAuth{username: s.cfg.Username, password: s.cfg.Password, host: s.cfg.Host}
+		} else {
+			auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+		}
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := c.Mail(envelopeAddress(msg.From)); err != nil {
+		return fmt.Errorf("MAIL FROM rejected: %w", err)
+	}
+	for _, rcpt := range msg.Recipients() {
+		if err := c.Rcpt(envelopeAddress(rcpt)); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA rejected: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return c.Quit()
+}
+
+// loginAuth implements the non-standard but widely deployed LOGIN
+// mechanism. Like smtp.PlainAuth it refuses to send credentials over an
+// unencrypted connection to anything but localhost.
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSuffix(string(fromServer), ":")) {
+	case "username":
+		return []byte(a.username), nil
+	case "password":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected LOGIN challenge %q", fromServer)
+	}
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
+
+// ============================================================================
+// Message Encoding
+// ============================================================================
+
+// envelopeAddress returns the bare address of a possibly named address.
+func envelopeAddress(s string) string {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Address
+	}
+	return s
+}
+
+// formatAddresses renders addresses for a header, encoding display names.
+func formatAddresses(list []string) string {
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if addr, err := mail.ParseAddress(s); err == nil {
+			out = append(out, addr.String())
+		} else {
+			out = append(out, s)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// newMessageID returns a random Message-ID in the domain of from.
+func newMessageID(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(envelopeAddress(from), "@"); at >= 0 {
+		domain = envelopeAddress(from)[at+1:]
+	}
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return "<" + hex.EncodeToString(b[:]) + "@" + domain + ">"
+}
+
+// bytes renders msg as an RFC 5322 message. Bcc recipients are left out
+// of the headers.
+func (m *Message) bytes() ([]byte, error) {
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return nil, fmt.Errorf("%w: subject must be a single line", ErrInvalidMessage)
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+		}
+	}
+	header("From", formatAddresses([]string{m.From}))
+	header("To", formatAddresses(m.To))
+	header("Cc", formatAddresses(m.Cc))
+	if m.ReplyTo != "" {
+		header("Reply-To", formatAddresses([]string{m.ReplyTo}))
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", m.MessageID)
+	header("MIME-Version", "1.0")
+
+	if m.Text != "" && m.HTML != "" {
+		mw := multipart.NewWriter(&buf)
+		header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()}))
+		buf.WriteString("\r\n")
+		for _, part := range []struct{ contentType, body string }{
+			{"text/plain; charset=utf-8", m.Text},
+			{"text/html; charset=utf-8", m.HTML},
+		} {
+			w, err := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Type":              {part.contentType},
+				"Content-Transfer-Encoding": {"quoted-printable"},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := writeQuotedPrintable(w, part.body); err != nil {
+				return nil, err
+			}
+		}
+		if err := mw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	contentType, body := "text/plain; charset=utf-8", m.Text
+	if m.HTML != "" {
+		contentType, body = "text/html; charset=utf-8", m.HTML
+	}
+	header("Content-Type", contentType)
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+	if err := writeQuotedPrintable(&buf, body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
@@ -0,0 +1,412 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	udiff "github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// fileDiff is the structured diff of a single file. It mirrors a unified
+// diff, but with explicit fields so that planners do not need to parse
+// patch text.
+type fileDiff struct {
+	Path      string     `json:"path"`
+	OldPath   string     `json:"old_path,omitempty"`
+	Status    string     `json:"status"` // added, deleted, modified, renamed
+	Binary    bool       `json:"binary,omitempty"`
+	Additions int        `json:"additions"`
+	Deletions int        `json:"deletions"`
+	Hunks     []diffHunk `json:"hunks,omitempty"`
+}
+
+// diffHunk is a contiguous region of changes with surrounding context.
+type diffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []diffLine `json:"lines"`
+}
+
+// diffLine is a single line of a hunk. Op is " " for context, "+" for an
+// added line and "-" for a removed line.
+type diffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// chunk is a run of lines sharing one operation.
+type chunk struct {
+	op   string
+	text string
+}
+
+// diffCommits diffs two revisions. When from is empty, to is compared
+// against its first parent (or the empty tree for a root commit).
+func diffCommits(ctx context.Context, repo *gogit.Repository, from, to string, contextLines int) ([]fileDiff, error) {
+	toCommit, err := resolveCommit(repo, to)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := toCommit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var fromTree *object.Tree
+	switch {
+	case from != "":
+		fromCommit, err := resolveCommit(repo, from)
+		if err != nil {
+			return nil, err
+		}
+		if fromTree, err = fromCommit.Tree(); err != nil {
+			return nil, err
+		}
+	case toCommit.NumParents() > 0:
+		parent, err := toCommit.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if fromTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff trees: %w", err)
+	}
+	patch, err := changes.PatchContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute patch: %w", err)
+	}
+
+	var files []fileDiff
+	for _, fp := range patch.FilePatches() {
+		fromFile, toFile := fp.Files()
+		fd := fileDiff{Binary: fp.IsBinary()}
+		switch {
+		case fromFile == nil:
+			fd.Path, fd.Status = toFile.Path(), "added"
+		case toFile == nil:
+			fd.Path, fd.Status = fromFile.Path(), "deleted"
+		case fromFile.Path() != toFile.Path():
+			fd.Path, fd.OldPath, fd.Status = toFile.Path(), fromFile.Path(), "renamed"
+		default:
+			fd.Path, fd.Status = toFile.Path(), "modified"
+		}
+
+		var chunks []chunk
+		for _, c := range fp.Chunks() {
+			chunks = append(chunks, chunk{op: patchOp(c.Type()), text: c.Content()})
+		}
+		fd.Hunks, fd.Additions, fd.Deletions = buildHunks(chunks, contextLines)
+		files = append(files, fd)
+	}
+	return files, nil
+}
+
+func patchOp(op fdiff.Operation) string {
+	switch op {
+	case fdiff.Add:
+		return "+"
+	case fdiff.Delete:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// diffStaged diffs HEAD against the index.
+func diffStaged(repo *gogit.Repository, contextLines int) ([]fileDiff, error) {
+	headFiles, err := headBlobs(repo)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index: %w", err)
+	}
+
+	indexFiles := make(map[string]plumbing.Hash, len(idx.Entries))
+	for _, e := range idx.Entries {
+		indexFiles[e.Name] = e.Hash
+	}
+
+	oldFiles := make(map[string]plumbing.Hash)
+	newFiles := make(map[string]plumbing.Hash)
+	for path, h := range indexFiles {
+		if old, ok := headFiles[path]; !ok || old != h {
+			newFiles[path] = h
+			if ok {
+				oldFiles[path] = old
+			}
+		}
+	}
+	for path, h := range headFiles {
+		if _, ok := indexFiles[path]; !ok {
+			oldFiles[path] = h
+		}
+	}
+
+	readOld := func(h plumbing.Hash) ([]byte, error) { return readBlob(repo, h) }
+	readNew := func(path string) ([]byte, error) { return readBlob(repo, newFiles[path]) }
+	return diffFiles(oldFiles, newFiles, readOld, readNew, contextLines)
+}
+
+// diffWorktree diffs HEAD against tracked files in the working tree.
+// Untracked files are not included, matching `git diff HEAD`.
+func diffWorktree(repo *gogit.Repository, contextLines int) ([]fileDiff, error) {
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	status, err := wt.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	headFiles, err := headBlobs(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	root := wt.Filesystem.Root()
+	oldFiles := make(map[string]plumbing.Hash)
+	newFiles := make(map[string]plumbing.Hash)
+	for path, s := range status {
+		if s.Worktree == gogit.Untracked {
+			continue
+		}
+		if h, ok := headFiles[path]; ok {
+			oldFiles[path] = h
+		}
+		if s.Worktree != gogit.Deleted {
+			// The hash is only a presence marker; content is read from disk.
+			newFiles[path] = plumbing.ZeroHash
+		}
+	}
+
+	readOld := func(h plumbing.Hash) ([]byte, error) { return readBlob(repo, h) }
+	readNew := func(path string) ([]byte, error) {
+		// #nosec G304 -- path comes from the repository status, under the worktree root
+		return os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+	}
+	return diffFiles(oldFiles, newFiles, readOld, readNew, contextLines)
+}
+
+// headBlobs returns the blob hash of every file in HEAD, or an empty map for
+// a repository without commits.
+func headBlobs(repo *gogit.Repository) (map[string]plumbing.Hash, error) {
+	files := make(map[string]plumbing.Hash)
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files[f.Name] = f.Hash
+		return nil
+	})
+	return files, err
+}
+
+func readBlob(repo *gogit.Repository, h plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(h)
+	if err != nil {
+		return nil, err
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// diffFiles diffs every path present in either map. Old content is read
+// by hash and new content by path; identical contents are skipped.
+func diffFiles(oldFiles, newFiles map[string]plumbing.Hash, readOld func(plumbing.Hash) ([]byte, error), readNew func(string) ([]byte, error), contextLines int) ([]fileDiff, error) {
+	paths := make(map[string]struct{}, len(oldFiles)+len(newFiles))
+	for p := range oldFiles {
+		paths[p] = struct{}{}
+	}
+	for p := range newFiles {
+		paths[p] = struct{}{}
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var files []fileDiff
+	for _, path := range sorted {
+		oldHash, hasOld := oldFiles[path]
+		_, hasNew := newFiles[path]
+
+		var oldContent, newContent []byte
+		var err error
+		if hasOld {
+			if oldContent, err = readOld(oldHash); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+		}
+		if hasNew {
+			if newContent, err = readNew(path); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+		}
+		if hasOld && hasNew && bytes.Equal(oldContent, newContent) {
+			continue
+		}
+
+		fd := fileDiff{Path: path, Status: "modified"}
+		switch {
+		case !hasOld:
+			fd.Status = "added"
+		case !hasNew:
+			fd.Status = "deleted"
+		}
+		if isBinary(oldContent) || isBinary(newContent) {
+			fd.Binary = true
+			files = append(files, fd)
+			continue
+		}
+
+		var chunks []chunk
+		for _, d := range udiff.Do(string(oldContent), string(newContent)) {
+			chunks = append(chunks, chunk{op: dmpOp(d.Type), text: d.Text})
+		}
+		fd.Hunks, fd.Additions, fd.Deletions = buildHunks(chunks, contextLines)
+		files = append(files, fd)
+	}
+	return files, nil
+}
+
+func dmpOp(op diffmatchpatch.Operation) string {
+	switch op {
+	case diffmatchpatch.DiffInsert:
+		return "+"
+	case diffmatchpatch.DiffDelete:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+func isBinary(content []byte) bool {
+	if len(content) > 8000 {
+		content = content[:8000]
+	}
+	return bytes.IndexByte(content, 0) >= 0
+}
+
+// buildHunks groups line chunks into hunks with contextLines lines of
+// context, merging hunks whose context would overlap.
+func buildHunks(chunks []chunk, contextLines int) ([]diffHunk, int, int) {
+	type numberedLine struct {
+		diffLine
+		old, new int
+	}
+
+	var lines []numberedLine
+	additions, deletions := 0, 0
+	oldLine, newLine := 1, 1
+	for _, c := range chunks {
+		text := strings.TrimSuffix(c.text, "\n")
+		if c.text == "" {
+			continue
+		}
+		for _, l := range strings.Split(text, "\n") {
+			lines = append(lines, numberedLine{diffLine{Op: c.op, Text: l}, oldLine, newLine})
+			switch c.op {
+			case "+":
+				newLine++
+				additions++
+			case "-":
+				oldLine++
+				deletions++
+			default:
+				oldLine++
+				newLine++
+			}
+		}
+	}
+
+	var hunks []diffHunk
+	for i := 0; i < len(lines); {
+		if lines[i].Op == " " {
+			i++
+			continue
+		}
+
+		start := max(0, i-contextLines)
+		last := i
+		for j := i; j < len(lines); j++ {
+			if lines[j].Op != " " {
+				last = j
+			} else if j-last > 2*contextLines {
+				break
+			}
+		}
+		end := min(len(lines), last+contextLines+1)
+
+		h := diffHunk{OldStart: lines[start].old, NewStart: lines[start].new}
+		for _, l := range lines[start:end] {
+			h.Lines = append(h.Lines, l.diffLine)
+			if l.Op != "+" {
+				h.OldLines++
+			}
+			if l.Op != "-" {
+				h.NewLines++
+			}
+		}
+		hunks = append(hunks, h)
+		i = end
+	}
+	return hunks, additions, deletions
+}
+
+// capDiff limits the total number of hunk lines. Files beyond the limit
+// keep their summary counts but lose their hunks.
+func capDiff(files []fileDiff, maxLines int) ([]fileDiff, bool) {
+	remaining := maxLines
+	truncated := false
+	for i := range files {
+		n := 0
+		for _, h := range files[i].Hunks {
+			n += len(h.Lines)
+		}
+		if n > remaining {
+			files[i].Hunks = nil
+			truncated = true
+			continue
+		}
+		remaining -= n
+	}
+	return files, truncated
+}
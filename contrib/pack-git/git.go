@@ -9,118 +9,990 @@
 //   - git_commit: Create a new commit
 //   - git_push: Push commits to remote
 //   - git_pull: Pull changes from remote
+//   - git_fetch: Fetch refs from remote
 //   - git_clone: Clone a repository
 //   - git_add: Stage files for commit
 //   - git_reset: Unstage files or reset to a commit
 //
-// Supports authentication via SSH keys or credentials.
+// All operations are implemented with go-git, so no git binary is required.
+// Repositories are confined to a configured root directory and remote
+// operations authenticate with the configured transport.AuthMethod.
 package git
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 )
 
+// Errors returned by the git tools.
+var (
+	// ErrPathOutsideRoot indicates a repository path escapes the configured root.
+	ErrPathOutsideRoot = errors.New("path outside root")
+
+	// ErrForcePushDisabled indicates a force push was requested without AllowForcePush.
+	ErrForcePushDisabled = errors.New("force push is disabled")
+
+	// ErrDirNotEmpty indicates git_clone targets an existing, non-empty directory.
+	ErrDirNotEmpty = errors.New("directory not empty")
+)
+
+// Config configures the git pack.
+type Config struct {
+	// Root confines repository paths. Defaults to the current directory.
+	Root string
+
+	// Auth authenticates remote operations (push, pull, fetch, clone).
+	Auth transport.AuthMethod
+
+	// AuthorName and AuthorEmail are used for commits when the caller does
+	// not supply an author. When empty, the repository configuration is used.
+	AuthorName  string
+	AuthorEmail string
+
+	// AllowForcePush permits git_push with force enabled.
+	AllowForcePush bool
+
+	// MaxDiffLines caps the number of diff lines returned by git_diff.
+	// Defaults to 5000.
+	MaxDiffLines int
+}
+
 // Pack returns the Git tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	p := newGitPack(cfg)
+
 	return pack.NewBuilder("git").
 		WithDescription("Git version control tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			gitStatus(),
-			gitLog(),
-			gitDiff(),
-			gitBranch(),
-			gitCheckout(),
-			gitCommit(),
-			gitPush(),
-			gitPull(),
-			gitClone(),
-			gitAdd(),
-			gitReset(),
+			p.gitStatus(),
+			p.gitLog(),
+			p.gitDiff(),
+			p.gitBranch(),
+			p.gitCheckout(),
+			p.gitCommit(),
+			p.gitPush(),
+			p.gitPull(),
+			p.gitFetch(),
+			p.gitClone(),
+			p.gitAdd(),
+			p.gitReset(),
 		).
 		AllowInState(agent.StateExplore, "git_status", "git_log", "git_diff", "git_branch").
-		AllowInState(agent.StateAct, "git_status", "git_log", "git_diff", "git_branch", "git_checkout", "git_commit", "git_push", "git_pull", "git_clone", "git_add", "git_reset").
+		AllowInState(agent.StateAct, "git_status", "git_log", "git_diff", "git_branch", "git_checkout", "git_commit", "git_push", "git_pull", "git_fetch", "git_clone", "git_add", "git_reset").
 		AllowInState(agent.StateValidate, "git_status", "git_log", "git_diff").
 		Build()
 }
 
-func gitStatus() tool.Tool {
+type gitPack struct {
+	cfg Config
+}
+
+func newGitPack(cfg Config) *gitPack {
+	if cfg.Root == "" {
+		cfg.Root, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(cfg.Root); err == nil {
+		cfg.Root = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(cfg.Root); err == nil {
+		cfg.Root = resolved
+	}
+	if cfg.MaxDiffLines <= 0 {
+		cfg.MaxDiffLines = 5000
+	}
+	return &gitPack{cfg: cfg}
+}
+
+// resolvePath resolves path relative to the root and rejects escapes.
+// The path does not need to exist yet.
+func (p *gitPack) resolvePath(path string) (string, error) {
+	if path == "" {
+		return p.cfg.Root, nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.cfg.Root, path)
+	}
+	path = filepath.Clean(path)
+
+	// Resolve symlinks on the longest existing prefix.
+	existing, rest := path, ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(existing); err == nil {
+			path = filepath.Join(resolved, rest)
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	rel, err := filepath.Rel(p.cfg.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrPathOutsideRoot, path)
+	}
+	return path, nil
+}
+
+func (p *gitPack) open(path string) (*gogit.Repository, string, error) {
+	dir, err := p.resolvePath(path)
+	if err != nil {
+		return nil, "", err
+	}
+	// No .git detection: a path inside the root must not bind to a
+	// repository above it.
+	repo, err := gogit.PlainOpen(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open repository %s: %w", dir, err)
+	}
+	return repo, dir, nil
+}
+
+func (p *gitPack) worktree(path string) (*gogit.Repository, *gogit.Worktree, error) {
+	repo, _, err := p.open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, err
+	}
+	return repo, wt, nil
+}
+
+// resolveCommit resolves a revision (branch, tag, hash, HEAD~1, ...) to a commit.
+func resolveCommit(repo *gogit.Repository, rev string) (*object.Commit, error) {
+	if rev == "" {
+		rev = "HEAD"
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %q: %w", rev, err)
+	}
+	return repo.CommitObject(*hash)
+}
+
+func headInfo(repo *gogit.Repository) map[string]any {
+	head, err := repo.Head()
+	if err != nil {
+		return map[string]any{"head": nil}
+	}
+	info := map[string]any{"head": head.Hash().String()}
+	if head.Name().IsBranch() {
+		info["branch"] = head.Name().Short()
+	} else {
+		info["detached"] = true
+	}
+	return info
+}
+
+func commitSummary(c *object.Commit) map[string]any {
+	return map[string]any{
+		"hash":         c.Hash.String(),
+		"short_hash":   c.Hash.String()[:7],
+		"author":       c.Author.Name,
+		"author_email": c.Author.Email,
+		"date":         c.Author.When.Format(time.RFC3339),
+		"message":      strings.TrimRight(c.Message, "\n"),
+		"parents":      len(c.ParentHashes),
+	}
+}
+
+var statusNames = map[gogit.StatusCode]string{
+	gogit.Unmodified:         "unmodified",
+	gogit.Untracked:          "untracked",
+	gogit.Modified:           "modified",
+	gogit.Added:              "added",
+	gogit.Deleted:            "deleted",
+	gogit.Renamed:            "renamed",
+	gogit.Copied:             "copied",
+	gogit.UpdatedButUnmerged: "unmerged",
+}
+
+func output(v any) (tool.Result, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return tool.Result{}, err
+	}
+	return tool.Result{Output: out}, nil
+}
+
+// ============================================================================
+// Inspection Tools
+// ============================================================================
+
+func (p *gitPack) gitStatus() tool.Tool {
 	return tool.NewBuilder("git_status").
 		WithDescription("Get the status of the working tree").
 		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path string `json:"path,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			repo, wt, err := p.worktree(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			status, err := wt.Status()
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to get status: %w", err)
+			}
+
+			paths := make([]string, 0, len(status))
+			for path := range status {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+
+			files := make([]map[string]any, 0, len(paths))
+			for _, path := range paths {
+				s := status[path]
+				entry := map[string]any{
+					"path":     path,
+					"staging":  statusNames[s.Staging],
+					"worktree": statusNames[s.Worktree],
+				}
+				if s.Extra != "" {
+					entry["from"] = s.Extra
+				}
+				files = append(files, entry)
+			}
+
+			result := headInfo(repo)
+			result["clean"] = status.IsClean()
+			result["files"] = files
+			return output(result)
+		}).
 		MustBuild()
 }
 
-func gitLog() tool.Tool {
+func (p *gitPack) gitLog() tool.Tool {
 	return tool.NewBuilder("git_log").
 		WithDescription("View commit history").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path  string `json:"path,omitempty"`
+				Ref   string `json:"ref,omitempty"`
+				File  string `json:"file,omitempty"`
+				Limit int    `json:"limit,omitempty"`
+				Stat  bool   `json:"stat,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Limit <= 0 || in.Limit > 500 {
+				in.Limit = 20
+			}
+
+			repo, _, err := p.open(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			from, err := resolveCommit(repo, in.Ref)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			opts := &gogit.LogOptions{From: from.Hash}
+			if in.File != "" {
+				opts.FileName = &in.File
+			}
+			iter, err := repo.Log(opts)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to read log: %w", err)
+			}
+			defer iter.Close()
+
+			var commits []map[string]any
+			for len(commits) < in.Limit {
+				c, err := iter.Next()
+				if err != nil {
+					break
+				}
+				summary := commitSummary(c)
+				if in.Stat {
+					if stats, err := c.Stats(); err == nil {
+						files := make([]map[string]any, 0, len(stats))
+						for _, s := range stats {
+							files = append(files, map[string]any{
+								"path":      s.Name,
+								"additions": s.Addition,
+								"deletions": s.Deletion,
+							})
+						}
+						summary["files"] = files
+					}
+				}
+				commits = append(commits, summary)
+			}
+
+			return output(map[string]any{
+				"commits": commits,
+				"count":   len(commits),
+			})
+		}).
 		MustBuild()
 }
 
-func gitDiff() tool.Tool {
+func (p *gitPack) gitDiff() tool.Tool {
 	return tool.NewBuilder("git_diff").
-		WithDescription("Show changes between commits, branches, or working tree").
+		WithDescription("Show changes between commits, the index, or the working tree as structured file, hunk and line records").
 		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path         string `json:"path,omitempty"`
+				From         string `json:"from,omitempty"`
+				To           string `json:"to,omitempty"`
+				Staged       bool   `json:"staged,omitempty"`
+				ContextLines *int   `json:"context_lines,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			contextLines := 3
+			if in.ContextLines != nil && *in.ContextLines >= 0 {
+				contextLines = *in.ContextLines
+			}
+
+			repo, _, err := p.open(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			var files []fileDiff
+			mode := "worktree"
+			switch {
+			case in.To != "":
+				mode = "commits"
+				files, err = diffCommits(ctx, repo, in.From, in.To, contextLines)
+			case in.Staged:
+				mode = "staged"
+				files, err = diffStaged(repo, contextLines)
+			default:
+				files, err = diffWorktree(repo, contextLines)
+			}
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			files, truncated := capDiff(files, p.cfg.MaxDiffLines)
+			additions, deletions := 0, 0
+			for _, f := range files {
+				additions += f.Additions
+				deletions += f.Deletions
+			}
+
+			return output(map[string]any{
+				"mode":      mode,
+				"files":     files,
+				"additions": additions,
+				"deletions": deletions,
+				"truncated": truncated,
+			})
+		}).
 		MustBuild()
 }
 
-func gitBranch() tool.Tool {
+// ============================================================================
+// Branch Tools
+// ============================================================================
+
+func (p *gitPack) gitBranch() tool.Tool {
 	return tool.NewBuilder("git_branch").
 		WithDescription("List, create, or delete branches").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path   string `json:"path,omitempty"`
+				Action string `json:"action,omitempty"` // list, create, delete
+				Name   string `json:"name,omitempty"`
+				Start  string `json:"start,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			repo, _, err := p.open(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			switch in.Action {
+			case "", "list":
+				head, _ := repo.Head()
+				iter, err := repo.Branches()
+				if err != nil {
+					return tool.Result{}, err
+				}
+				var branches []map[string]any
+				_ = iter.ForEach(func(ref *plumbing.Reference) error {
+					branches = append(branches, map[string]any{
+						"name":    ref.Name().Short(),
+						"hash":    ref.Hash().String(),
+						"current": head != nil && head.Name() == ref.Name(),
+					})
+					return nil
+				})
+				return output(map[string]any{"branches": branches, "count": len(branches)})
+
+			case "create":
+				if in.Name == "" {
+					return tool.Result{}, errors.New("branch name is required")
+				}
+				start, err := resolveCommit(repo, in.Start)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				name := plumbing.NewBranchReferenceName(in.Name)
+				if _, err := repo.Reference(name, false); err == nil {
+					return tool.Result{}, fmt.Errorf("branch %s already exists", in.Name)
+				}
+				if err := repo.Storer.SetReference(plumbing.NewHashReference(name, start.Hash)); err != nil {
+					return tool.Result{}, fmt.Errorf("failed to create branch: %w", err)
+				}
+				return output(map[string]any{"created": in.Name, "hash": start.Hash.String()})
+
+			case "delete":
+				if in.Name == "" {
+					return tool.Result{}, errors.New("branch name is required")
+				}
+				name := plumbing.NewBranchReferenceName(in.Name)
+				if head, err := repo.Head(); err == nil && head.Name() == name {
+					return tool.Result{}, fmt.Errorf("cannot delete the checked out branch %s", in.Name)
+				}
+				ref, err := repo.Reference(name, false)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("branch %s not found: %w", in.Name, err)
+				}
+				if err := repo.Storer.RemoveReference(name); err != nil {
+					return tool.Result{}, fmt.Errorf("failed to delete branch: %w", err)
+				}
+				_ = repo.DeleteBranch(in.Name) // drop tracking config if any
+				return output(map[string]any{"deleted": in.Name, "hash": ref.Hash().String()})
+
+			default:
+				return tool.Result{}, fmt.Errorf("unknown branch action: %s", in.Action)
+			}
+		}).
 		MustBuild()
 }
 
-func gitCheckout() tool.Tool {
+func (p *gitPack) gitCheckout() tool.Tool {
 	return tool.NewBuilder("git_checkout").
 		WithDescription("Switch branches or restore working tree files").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path   string   `json:"path,omitempty"`
+				Branch string   `json:"branch,omitempty"`
+				Rev    string   `json:"rev,omitempty"`
+				Create bool     `json:"create,omitempty"`
+				Force  bool     `json:"force,omitempty"`
+				Files  []string `json:"files,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			repo, wt, err := p.worktree(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			if len(in.Files) > 0 {
+				if err := wt.Restore(&gogit.RestoreOptions{Worktree: true, Files: in.Files}); err != nil {
+					return tool.Result{}, fmt.Errorf("failed to restore files: %w", err)
+				}
+				return output(map[string]any{"restored": in.Files})
+			}
+
+			opts := &gogit.CheckoutOptions{Create: in.Create, Force: in.Force}
+			if in.Branch != "" {
+				opts.Branch = plumbing.NewBranchReferenceName(in.Branch)
+			}
+			if in.Rev != "" {
+				c, err := resolveCommit(repo, in.Rev)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				opts.Hash = c.Hash
+			}
+			if in.Branch == "" && in.Rev == "" {
+				return tool.Result{}, errors.New("branch, rev or files is required")
+			}
+
+			if err := wt.Checkout(opts); err != nil {
+				return tool.Result{}, fmt.Errorf("checkout failed: %w", err)
+			}
+			return output(headInfo(repo))
+		}).
+		MustBuild()
+}
+
+// ============================================================================
+// Change Tools
+// ============================================================================
+
+func (p *gitPack) gitAdd() tool.Tool {
+	return tool.NewBuilder("git_add").
+		WithDescription("Stage files for the next commit").
+		WithRiskLevel(tool.RiskLow).
+		Idempotent().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path  string   `json:"path,omitempty"`
+				Files []string `json:"files,omitempty"`
+				All   bool     `json:"all,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			_, wt, err := p.worktree(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			if in.All {
+				if err := wt.AddWithOptions(&gogit.AddOptions{All: true}); err != nil {
+					return tool.Result{}, fmt.Errorf("failed to stage changes: %w", err)
+				}
+			} else {
+				if len(in.Files) == 0 {
+					return tool.Result{}, errors.New("files or all is required")
+				}
+				for _, f := range in.Files {
+					if _, err := wt.Add(f); err != nil {
+						return tool.Result{}, fmt.Errorf("failed to stage %s: %w", f, err)
+					}
+				}
+			}
+
+			status, err := wt.Status()
+			if err != nil {
+				return tool.Result{}, err
+			}
+			var staged []string
+			for path, s := range status {
+				if s.Staging != gogit.Unmodified && s.Staging != gogit.Untracked {
+					staged = append(staged, path)
+				}
+			}
+			sort.Strings(staged)
+			return output(map[string]any{"staged": staged, "count": len(staged)})
+		}).
 		MustBuild()
 }
 
-func gitCommit() tool.Tool {
+func (p *gitPack) gitCommit() tool.Tool {
 	return tool.NewBuilder("git_commit").
 		WithDescription("Create a new commit with staged changes").
 		WithRiskLevel(tool.RiskLow).
+		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path        string `json:"path,omitempty"`
+				Message     string `json:"message"`
+				All         bool   `json:"all,omitempty"`
+				AllowEmpty  bool   `json:"allow_empty,omitempty"`
+				AuthorName  string `json:"author_name,omitempty"`
+				AuthorEmail string `json:"author_email,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if strings.TrimSpace(in.Message) == "" {
+				return tool.Result{}, errors.New("commit message is required")
+			}
+
+			repo, wt, err := p.worktree(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			opts := &gogit.CommitOptions{All: in.All, AllowEmptyCommits: in.AllowEmpty}
+			name, email := in.AuthorName, in.AuthorEmail
+			if name == "" {
+				name = p.cfg.AuthorName
+			}
+			if email == "" {
+				email = p.cfg.AuthorEmail
+			}
+			if name != "" || email != "" {
+				opts.Author = &object.Signature{Name: name, Email: email, When: time.Now()}
+			}
+
+			hash, err := wt.Commit(in.Message, opts)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("commit failed: %w", err)
+			}
+			c, err := repo.CommitObject(hash)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return output(commitSummary(c))
+		}).
+		MustBuild()
+}
+
+func (p *gitPack) gitReset() tool.Tool {
+	return tool.NewBuilder("git_reset").
+		WithDescription("Unstage files or reset to a previous commit").
+		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path  string   `json:"path,omitempty"`
+				Rev   string   `json:"rev,omitempty"`
+				Mode  string   `json:"mode,omitempty"` // soft, mixed, hard
+				Files []string `json:"files,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			repo, wt, err := p.worktree(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			target, err := resolveCommit(repo, in.Rev)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			opts := &gogit.ResetOptions{Commit: target.Hash, Files: in.Files}
+			switch in.Mode {
+			case "", "mixed":
+				opts.Mode = gogit.MixedReset
+			case "soft":
+				opts.Mode = gogit.SoftReset
+			case "hard":
+				opts.Mode = gogit.HardReset
+			default:
+				return tool.Result{}, fmt.Errorf("unknown reset mode: %s", in.Mode)
+			}
+			if len(in.Files) > 0 && opts.Mode != gogit.MixedReset {
+				return tool.Result{}, errors.New("file resets only support mixed mode")
+			}
+
+			if err := wt.Reset(opts); err != nil {
+				return tool.Result{}, fmt.Errorf("reset failed: %w", err)
+			}
+			result := headInfo(repo)
+			result["reset_to"] = target.Hash.String()
+			return output(result)
+		}).
 		MustBuild()
 }
 
-func gitPush() tool.Tool {
+// ============================================================================
+// Remote Tools
+// ============================================================================
+
+func refSpecs(specs []string) []config.RefSpec {
+	out := make([]config.RefSpec, 0, len(specs))
+	for _, s := range specs {
+		out = append(out, config.RefSpec(s))
+	}
+	return out
+}
+
+func (p *gitPack) gitPush() tool.Tool {
 	return tool.NewBuilder("git_push").
 		WithDescription("Push commits to a remote repository").
-		WithRiskLevel(tool.RiskMedium).
+		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path     string   `json:"path,omitempty"`
+				Remote   string   `json:"remote,omitempty"`
+				RefSpecs []string `json:"refspecs,omitempty"`
+				Force    bool     `json:"force,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Force && !p.cfg.AllowForcePush {
+				return tool.Result{}, ErrForcePushDisabled
+			}
+			if in.Remote == "" {
+				in.Remote = gogit.DefaultRemoteName
+			}
+
+			repo, _, err := p.open(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			opts := &gogit.PushOptions{
+				RemoteName: in.Remote,
+				RefSpecs:   refSpecs(in.RefSpecs),
+				Force:      in.Force,
+				Auth:       p.cfg.Auth,
+			}
+			if len(opts.RefSpecs) == 0 {
+				// Default to pushing the current branch, like push.default=simple.
+				head, err := repo.Head()
+				if err != nil {
+					return tool.Result{}, err
+				}
+				if !head.Name().IsBranch() {
+					return tool.Result{}, errors.New("HEAD is detached; specify refspecs")
+				}
+				opts.RefSpecs = []config.RefSpec{config.RefSpec(head.Name().String() + ":" + head.Name().String())}
+			}
+
+			err = repo.PushContext(ctx, opts)
+			upToDate := errors.Is(err, gogit.NoErrAlreadyUpToDate)
+			if err != nil && !upToDate {
+				return tool.Result{}, fmt.Errorf("push failed: %w", err)
+			}
+			specs := make([]string, 0, len(opts.RefSpecs))
+			for _, s := range opts.RefSpecs {
+				specs = append(specs, s.String())
+			}
+			return output(map[string]any{
+				"remote":     in.Remote,
+				"refspecs":   specs,
+				"up_to_date": upToDate,
+			})
+		}).
 		MustBuild()
 }
 
-func gitPull() tool.Tool {
+func (p *gitPack) gitPull() tool.Tool {
 	return tool.NewBuilder("git_pull").
 		WithDescription("Pull changes from a remote repository").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path   string `json:"path,omitempty"`
+				Remote string `json:"remote,omitempty"`
+				Branch string `json:"branch,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Remote == "" {
+				in.Remote = gogit.DefaultRemoteName
+			}
+
+			repo, wt, err := p.worktree(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			before, _ := repo.Head()
+
+			opts := &gogit.PullOptions{RemoteName: in.Remote, Auth: p.cfg.Auth}
+			if in.Branch != "" {
+				opts.ReferenceName = plumbing.NewBranchReferenceName(in.Branch)
+			}
+			err = wt.PullContext(ctx, opts)
+			upToDate := errors.Is(err, gogit.NoErrAlreadyUpToDate)
+			if err != nil && !upToDate {
+				return tool.Result{}, fmt.Errorf("pull failed: %w", err)
+			}
+
+			result := headInfo(repo)
+			result["up_to_date"] = upToDate
+			if before != nil {
+				result["previous_head"] = before.Hash().String()
+			}
+			return output(result)
+		}).
 		MustBuild()
 }
 
-func gitClone() tool.Tool {
-	return tool.NewBuilder("git_clone").
-		WithDescription("Clone a repository into a new directory").
+func (p *gitPack) gitFetch() tool.Tool {
+	return tool.NewBuilder("git_fetch").
+		WithDescription("Fetch branches and tags from a remote repository").
 		WithRiskLevel(tool.RiskLow).
+		Idempotent().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Path     string   `json:"path,omitempty"`
+				Remote   string   `json:"remote,omitempty"`
+				RefSpecs []string `json:"refspecs,omitempty"`
+				Depth    int      `json:"depth,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Remote == "" {
+				in.Remote = gogit.DefaultRemoteName
+			}
+
+			repo, _, err := p.open(in.Path)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			err = repo.FetchContext(ctx, &gogit.FetchOptions{
+				RemoteName: in.Remote,
+				RefSpecs:   refSpecs(in.RefSpecs),
+				Depth:      in.Depth,
+				Auth:       p.cfg.Auth,
+			})
+			upToDate := errors.Is(err, gogit.NoErrAlreadyUpToDate)
+			if err != nil && !upToDate {
+				return tool.Result{}, fmt.Errorf("fetch failed: %w", err)
+			}
+			return output(map[string]any{"remote": in.Remote, "up_to_date": upToDate})
+		}).
 		MustBuild()
 }
 
-func gitAdd() tool.Tool {
-	return tool.NewBuilder("git_add").
-		WithDescription("Stage files for the next commit").
+func (p *gitPack) gitClone() tool.Tool {
+	return tool.NewBuilder("git_clone").
+		WithDescription("Clone a repository into a new directory").
 		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				URL    string `json:"url"`
+				Dir    string `json:"dir"`
+				Branch string `json:"branch,omitempty"`
+				Depth  int    `json:"depth,omitempty"`
+				Bare   bool   `json:"bare,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.URL == "" || in.Dir == "" {
+				return tool.Result{}, errors.New("url and dir are required")
+			}
+
+			url, err := p.cloneURL(in.URL)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			dir, err := p.resolvePath(in.Dir)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			created, err := cloneTarget(dir)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			opts := &gogit.CloneOptions{
+				URL:   url,
+				Depth: in.Depth,
+				Auth:  p.cfg.Auth,
+			}
+			if in.Branch != "" {
+				opts.ReferenceName = plumbing.NewBranchReferenceName(in.Branch)
+				opts.SingleBranch = true
+			}
+
+			repo, err := gogit.PlainCloneContext(ctx, dir, in.Bare, opts)
+			empty := errors.Is(err, transport.ErrEmptyRemoteRepository)
+			if empty {
+				// Like git, cloning an empty repository yields an empty
+				// repository with the remote configured.
+				removeCloned(dir, created)
+				repo, err = initWithRemote(dir, in.Bare, url)
+			}
+			if err != nil {
+				removeCloned(dir, created)
+				return tool.Result{}, fmt.Errorf("clone failed: %w", err)
+			}
+
+			result := headInfo(repo)
+			result["dir"] = dir
+			result["bare"] = in.Bare
+			result["empty"] = empty
+			return output(result)
+		}).
 		MustBuild()
 }
 
-func gitReset() tool.Tool {
-	return tool.NewBuilder("git_reset").
-		WithDescription("Unstage files or reset to a previous commit").
-		WithRiskLevel(tool.RiskHigh).
-		RequiresApproval().
-		MustBuild()
+// cloneURL rejects local clone sources outside the root. Relative local
+// paths are resolved against the root rather than the working directory.
+func (p *gitPack) cloneURL(url string) (string, error) {
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	if ep.Protocol != "file" {
+		return url, nil
+	}
+	path := url
+	if strings.HasPrefix(url, "file://") {
+		path = ep.Path
+	}
+	return p.resolvePath(path)
+}
+
+// cloneTarget checks that dir is missing or empty and returns the topmost
+// directory the clone will create, or "" when dir already exists.
+func cloneTarget(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err == nil {
+		if len(entries) > 0 {
+			return "", fmt.Errorf("%w: %s", ErrDirNotEmpty, dir)
+		}
+		return "", nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	created := dir
+	for parent := filepath.Dir(created); parent != created; parent = filepath.Dir(created) {
+		if _, err := os.Stat(parent); err == nil {
+			break
+		}
+		created = parent
+	}
+	return created, nil
+}
+
+// removeCloned removes what a failed clone wrote: the directories it
+// created, or the contents of a directory that was empty beforehand.
+func removeCloned(dir, created string) {
+	if created != "" {
+		_ = os.RemoveAll(created)
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		_ = os.RemoveAll(filepath.Join(dir, e.Name()))
+	}
+}
+
+func initWithRemote(dir string, bare bool, url string) (*gogit.Repository, error) {
+	repo, err := gogit.PlainInit(dir, bare)
+	if err != nil {
+		return nil, err
+	}
+	_, err = repo.CreateRemote(&config.RemoteConfig{
+		Name: gogit.DefaultRemoteName,
+		URLs: []string{url},
+	})
+	return repo, err
 }
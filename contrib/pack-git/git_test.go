@@ -0,0 +1,248 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	gogit "github.com/go-git/go-git/v5"
+)
+
+func execTool(t *testing.T, p *pack.Pack, name string, input any) map[string]any {
+	t.Helper()
+	tl, ok := p.GetTool(name)
+	if !ok {
+		t.Fatalf("tool %s not found", name)
+	}
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(result.Output, &out); err != nil {
+		t.Fatalf("%s returned invalid JSON: %v", name, err)
+	}
+	return out
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setup creates a bare remote, clones it through git_clone and returns the pack.
+func setup(t *testing.T) (*pack.Pack, string) {
+	t.Helper()
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote.git")
+	if _, err := gogit.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+
+	p := Pack(Config{Root: root, AuthorName: "Agent", AuthorEmail: "agent@example.com"})
+	execTool(t, p, "git_clone", map[string]any{"url": remote, "dir": "work"})
+	return p, root
+}
+
+func TestAnnotations(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{Root: t.TempDir()})
+	for _, name := range []string{"git_push", "git_reset"} {
+		tl, _ := p.GetTool(name)
+		if !tl.Annotations().Destructive {
+			t.Errorf("%s should be destructive", name)
+		}
+	}
+	commit, _ := p.GetTool("git_commit")
+	if !commit.Annotations().RequiresApproval {
+		t.Error("git_commit should require approval")
+	}
+	for _, name := range []string{"git_status", "git_log", "git_diff"} {
+		tl, _ := p.GetTool(name)
+		if !tl.Annotations().ReadOnly {
+			t.Errorf("%s should be read-only", name)
+		}
+	}
+}
+
+func TestCommitPushAndPull(t *testing.T) {
+	t.Parallel()
+
+	p, root := setup(t)
+	work := filepath.Join(root, "work")
+
+	writeFile(t, filepath.Join(work, "README.md"), "hello\n")
+	status := execTool(t, p, "git_status", map[string]any{"path": "work"})
+	if status["clean"] != false {
+		t.Fatalf("expected dirty worktree, got %v", status)
+	}
+
+	execTool(t, p, "git_add", map[string]any{"path": "work", "files": []string{"README.md"}})
+	commit := execTool(t, p, "git_commit", map[string]any{"path": "work", "message": "initial"})
+	if commit["author"] != "Agent" {
+		t.Errorf("author = %v, want Agent", commit["author"])
+	}
+
+	push := execTool(t, p, "git_push", map[string]any{"path": "work"})
+	if push["up_to_date"] != false {
+		t.Errorf("first push should transfer objects: %v", push)
+	}
+
+	// A second clone sees the pushed commit and pulls a follow-up change.
+	execTool(t, p, "git_clone", map[string]any{"url": filepath.Join(root, "remote.git"), "dir": "other"})
+	log := execTool(t, p, "git_log", map[string]any{"path": "other"})
+	if log["count"].(float64) != 1 {
+		t.Fatalf("expected 1 commit in clone, got %v", log)
+	}
+
+	writeFile(t, filepath.Join(work, "README.md"), "hello\nworld\n")
+	execTool(t, p, "git_commit", map[string]any{"path": "work", "message": "second", "all": true})
+	execTool(t, p, "git_push", map[string]any{"path": "work"})
+
+	pull := execTool(t, p, "git_pull", map[string]any{"path": "other"})
+	if pull["up_to_date"] != false || pull["head"] == pull["previous_head"] {
+		t.Errorf("pull should advance HEAD: %v", pull)
+	}
+}
+
+func TestStructuredDiff(t *testing.T) {
+	t.Parallel()
+
+	p, root := setup(t)
+	work := filepath.Join(root, "work")
+
+	writeFile(t, filepath.Join(work, "a.txt"), "one\ntwo\nthree\n")
+	execTool(t, p, "git_add", map[string]any{"path": "work", "all": true})
+	execTool(t, p, "git_commit", map[string]any{"path": "work", "message": "add a"})
+
+	writeFile(t, filepath.Join(work, "a.txt"), "one\n2\nthree\n")
+	diff := execTool(t, p, "git_diff", map[string]any{"path": "work"})
+	files := diff["files"].([]any)
+	if len(files) != 1 {
+		t.Fatalf("expected 1 changed file, got %v", diff)
+	}
+	file := files[0].(map[string]any)
+	if file["path"] != "a.txt" || file["status"] != "modified" {
+		t.Errorf("unexpected file entry: %v", file)
+	}
+	if file["additions"].(float64) != 1 || file["deletions"].(float64) != 1 {
+		t.Errorf("unexpected counts: %v", file)
+	}
+	hunk := file["hunks"].([]any)[0].(map[string]any)
+	lines := hunk["lines"].([]any)
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 hunk lines, got %v", lines)
+	}
+	if removed := lines[1].(map[string]any); removed["op"] != "-" || removed["text"] != "two" {
+		t.Errorf("unexpected removed line: %v", removed)
+	}
+
+	execTool(t, p, "git_add", map[string]any{"path": "work", "files": []string{"a.txt"}})
+	staged := execTool(t, p, "git_diff", map[string]any{"path": "work", "staged": true})
+	if len(staged["files"].([]any)) != 1 {
+		t.Errorf("expected staged change, got %v", staged)
+	}
+
+	execTool(t, p, "git_commit", map[string]any{"path": "work", "message": "edit a"})
+	commits := execTool(t, p, "git_diff", map[string]any{"path": "work", "to": "HEAD"})
+	if len(commits["files"].([]any)) != 1 || commits["mode"] != "commits" {
+		t.Errorf("expected one file in HEAD diff, got %v", commits)
+	}
+
+	execTool(t, p, "git_reset", map[string]any{"path": "work", "rev": "HEAD~1", "mode": "hard"})
+	content, _ := os.ReadFile(filepath.Join(work, "a.txt"))
+	if string(content) != "one\ntwo\nthree\n" {
+		t.Errorf("hard reset did not restore content: %q", content)
+	}
+}
+
+func TestPathConfinement(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{Root: t.TempDir()})
+	tl, _ := p.GetTool("git_status")
+	if _, err := tl.Execute(context.Background(), json.RawMessage(`{"path":"../.."}`)); err == nil {
+		t.Error("expected error for path outside root")
+	}
+	push, _ := p.GetTool("git_push")
+	if _, err := push.Execute(context.Background(), json.RawMessage(`{"force":true}`)); err != ErrForcePushDisabled {
+		t.Errorf("expected ErrForcePushDisabled, got %v", err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if _, err := gogit.PlainInit(filepath.Join(root, "empty.git"), true); err != nil {
+		t.Fatal(err)
+	}
+	p := Pack(Config{Root: root})
+	clone, _ := p.GetTool("git_clone")
+	run := func(input map[string]any) error {
+		raw, _ := json.Marshal(input)
+		_, err := clone.Execute(context.Background(), raw)
+		return err
+	}
+
+	// An existing, non-empty directory is never a clone target.
+	if err := run(map[string]any{"url": "empty.git", "dir": "."}); !errors.Is(err, ErrDirNotEmpty) {
+		t.Errorf("expected ErrDirNotEmpty, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "empty.git")); err != nil {
+		t.Fatalf("root contents removed: %v", err)
+	}
+
+	out := execTool(t, p, "git_clone", map[string]any{"url": "empty.git", "dir": "a/b"})
+	if out["empty"] != true {
+		t.Errorf("expected an empty clone, got %v", out)
+	}
+	if _, err := gogit.PlainOpen(filepath.Join(root, "a", "b")); err != nil {
+		t.Errorf("expected a repository: %v", err)
+	}
+
+	// A failed clone removes only the directories it created.
+	if err := os.Mkdir(filepath.Join(root, "kept"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(map[string]any{"url": "missing.git", "dir": "kept/x/y"}); err == nil {
+		t.Error("expected an error for a missing remote")
+	}
+	if _, err := os.Stat(filepath.Join(root, "kept", "x")); !os.IsNotExist(err) {
+		t.Errorf("expected kept/x to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "kept")); err != nil {
+		t.Errorf("expected kept to remain: %v", err)
+	}
+
+	outside := t.TempDir()
+	if _, err := gogit.PlainInit(outside, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, url := range []string{outside, "file://" + outside, "../outside.git"} {
+		if err := run(map[string]any{"url": url, "dir": "c"}); !errors.Is(err, ErrPathOutsideRoot) {
+			t.Errorf("%s: expected ErrPathOutsideRoot, got %v", url, err)
+		}
+	}
+}
+
+func TestOpenDoesNotDetectParentRepository(t *testing.T) {
+	t.Parallel()
+
+	p, root := setup(t)
+	if err := os.Mkdir(filepath.Join(root, "work", "sub"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	tl, _ := p.GetTool("git_status")
+	if _, err := tl.Execute(context.Background(), json.RawMessage(`{"path":"work/sub"}`)); err == nil {
+		t.Error("expected no repository at a subdirectory")
+	}
+}
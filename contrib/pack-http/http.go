@@ -8,73 +8,545 @@
 //   - http_head: Perform HTTP HEAD requests
 //   - http_patch: Perform HTTP PATCH requests with JSON body
 //
-// All tools support custom headers, timeouts, and authentication.
+// All tools support custom headers, timeouts, and authentication. Requests
+// are subject to an egress policy: host allow and deny lists, blocking of
+// private, loopback and link-local addresses (checked at connect time, so
+// DNS rebinding and redirects cannot bypass it) and response size caps.
+// Credentials are never taken from planner input; the planner names a
+// configured credential and its value is resolved from a secrets.Manager.
 package http
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+)
+
+// Errors returned by the HTTP tools.
+var (
+	// ErrHostNotAllowed indicates the request host is denied or not allowlisted.
+	ErrHostNotAllowed = errors.New("host not allowed")
+
+	// ErrAddressBlocked indicates the host resolved to a blocked address.
+	ErrAddressBlocked = errors.New("address blocked by egress policy")
+
+	// ErrSchemeNotAllowed indicates a URL scheme other than http or https.
+	ErrSchemeNotAllowed = errors.New("scheme not allowed")
+
+	// ErrForbiddenHeader indicates planner input tried to set a credential header.
+	ErrForbiddenHeader = errors.New("header may not be set by the caller")
+
+	// ErrUnknownCredential indicates the requested credential is not configured.
+	ErrUnknownCredential = errors.New("unknown credential")
 )
 
+// CredentialType selects how a credential is injected into requests.
+type CredentialType string
+
+const (
+	// CredentialBearer sends "Authorization: Bearer <secret>".
+	CredentialBearer CredentialType = "bearer"
+
+	// CredentialBasic sends HTTP basic auth with Username and the secret as password.
+	CredentialBasic CredentialType = "basic"
+
+	// CredentialHeader sends the secret verbatim in Header.
+	CredentialHeader CredentialType = "header"
+)
+
+// Credential is a named reference to a secret that tools may attach to
+// requests. The planner only ever sees and supplies the name.
+type Credential struct {
+	// Secret is the key of the secret in the secrets.Manager.
+	Secret string
+
+	// Type selects how the secret is injected. Defaults to CredentialBearer.
+	Type CredentialType
+
+	// Header is the header name for CredentialHeader, e.g. "X-API-Key".
+	Header string
+
+	// Username is the user name for CredentialBasic.
+	Username string
+
+	// Hosts restricts which hosts the credential may be sent to, using the
+	// same patterns as Config.AllowedHosts. Required.
+	Hosts []string
+}
+
+// Config configures the HTTP pack.
+type Config struct {
+	// AllowedHosts lists host patterns that may be contacted, such as
+	// "api.example.com" or "*.example.com". Empty allows any host that is
+	// not denied.
+	AllowedHosts []string
+
+	// DeniedHosts lists host patterns that are always rejected.
+	DeniedHosts []string
+
+	// AllowPrivateNetworks disables blocking of loopback, private,
+	// link-local and other non-public addresses.
+	AllowPrivateNetworks bool
+
+	// Timeout is the default request timeout. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	// MaxTimeout caps caller-supplied timeouts. Defaults to 2 minutes.
+	MaxTimeout time.Duration
+
+	// MaxResponseBytes caps the response body that is read. Defaults to 1 MiB.
+	MaxResponseBytes int64
+
+	// MaxRedirects caps followed redirects. Defaults to 5; negative disables redirects.
+	MaxRedirects int
+
+	// Secrets resolves credential values.
+	Secrets secrets.Manager
+
+	// Credentials are the named credentials callers may reference.
+	Credentials map[string]Credential
+
+	// UserAgent is sent when the caller does not set one.
+	UserAgent string
+}
+
 // Pack returns the HTTP tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	p := newHTTPPack(cfg)
+
 	return pack.NewBuilder("http").
 		WithDescription("HTTP request tools for making web API calls").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			httpGet(),
-			httpPost(),
-			httpPut(),
-			httpDelete(),
-			httpHead(),
-			httpPatch(),
+			p.httpGet(),
+			p.httpPost(),
+			p.httpPut(),
+			p.httpDelete(),
+			p.httpHead(),
+			p.httpPatch(),
 		).
 		AllowInState(agent.StateExplore, "http_get", "http_head").
 		AllowInState(agent.StateAct, "http_get", "http_post", "http_put", "http_delete", "http_head", "http_patch").
 		Build()
 }
 
-func httpGet() tool.Tool {
+type httpPack struct {
+	cfg    Config
+	client *http.Client
+}
+
+func newHTTPPack(cfg Config) *httpPack {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxTimeout <= 0 {
+		cfg.MaxTimeout = 2 * time.Minute
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = 1 << 20
+	}
+	if cfg.MaxRedirects == 0 {
+		cfg.MaxRedirects = 5
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "agent-go-http/0.2"
+	}
+
+	p := &httpPack{cfg: cfg}
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: p.checkDial,
+	}
+	transport := &http.Transport{
+		// Proxies are disabled so that the dial-time address check always
+		// applies to the real destination.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          50,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	p.client = &http.Client{
+		Transport:     transport,
+		CheckRedirect: p.checkRedirect,
+	}
+	return p
+}
+
+// ============================================================================
+// Egress Policy
+// ============================================================================
+
+// matchHost reports whether host matches any of the patterns.
+// "*.example.com" matches subdomains of example.com but not example.com itself.
+func matchHost(patterns []string, host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == host {
+			return true
+		}
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL enforces scheme and host rules before a request is sent.
+func (p *httpPack) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: %s", ErrSchemeNotAllowed, u.Scheme)
+	}
+	host := u.Hostname()
+	if host == "" {
+		return errors.New("url has no host")
+	}
+	if matchHost(p.cfg.DeniedHosts, host) {
+		return fmt.Errorf("%w: %s is denied", ErrHostNotAllowed, host)
+	}
+	if len(p.cfg.AllowedHosts) > 0 && !matchHost(p.cfg.AllowedHosts, host) {
+		return fmt.Errorf("%w: %s is not allowlisted", ErrHostNotAllowed, host)
+	}
+	return nil
+}
+
+// blockedPrefixes are non-public ranges not covered by the netip predicates.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// isPublicAddr reports whether addr is a globally routable unicast address.
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkDial runs after DNS resolution for every connection, including
+// those made while following redirects.
+func (p *httpPack) checkDial(network, address string, _ syscall.RawConn) error {
+	if p.cfg.AllowPrivateNetworks {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrAddressBlocked, address)
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrAddressBlocked, addrPort.Addr())
+	}
+	return nil
+}
+
+func (p *httpPack) checkRedirect(req *http.Request, via []*http.Request) error {
+	if p.cfg.MaxRedirects < 0 || len(via) > p.cfg.MaxRedirects {
+		return http.ErrUseLastResponse
+	}
+	if err := p.checkURL(req.URL); err != nil {
+		return err
+	}
+	// Never carry an injected credential to a host it is not scoped to.
+	if cred, ok := req.Context().Value(credentialKey{}).(Credential); ok && !matchHost(cred.Hosts, req.URL.Hostname()) {
+		req.Header.Del("Authorization")
+		if cred.Header != "" {
+			req.Header.Del(cred.Header)
+		}
+	}
+	return nil
+}
+
+type credentialKey struct{}
+
+// forbiddenHeaders may only be set through configured credentials.
+var forbiddenHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// applyCredential resolves a named credential and attaches it to req.
+func (p *httpPack) applyCredential(ctx context.Context, req *http.Request, name string) (*http.Request, error) {
+	cred, ok := p.cfg.Credentials[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCredential, name)
+	}
+	if !matchHost(cred.Hosts, req.URL.Hostname()) {
+		return nil, fmt.Errorf("%w: credential %s is not scoped to %s", ErrHostNotAllowed, name, req.URL.Hostname())
+	}
+	if p.cfg.Secrets == nil {
+		return nil, errors.New("no secrets manager configured")
+	}
+	value, err := p.cfg.Secrets.Get(ctx, cred.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve credential %s: %w", name, err)
+	}
+
+	switch cred.Type {
+	case "", CredentialBearer:
+		req.Header.Set("Authorization", "Bearer "+value)
+	case CredentialBasic:
+		req.SetBasicAuth(cred.Username, value)
+	case CredentialHeader:
+		if cred.Header == "" {
+			return nil, fmt.Errorf("credential %s has no header name", name)
+		}
+		req.Header.Set(cred.Header, value)
+	default:
+		return nil, fmt.Errorf("credential %s has unknown type %s", name, cred.Type)
+	}
+	return req.WithContext(context.WithValue(req.Context(), credentialKey{}, cred)), nil
+}
+
+// ============================================================================
+// Requests
+// ============================================================================
+
+// requestParams is the common input of all HTTP tools.
+type requestParams struct {
+	URL            string            `json:"url"`
+	Query          map[string]string `json:"query,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           json.RawMessage   `json:"body,omitempty"`
+	BodyText       string            `json:"body_text,omitempty"`
+	Credential     string            `json:"credential,omitempty"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+}
+
+func (p *httpPack) do(ctx context.Context, method string, input json.RawMessage) (tool.Result, error) {
+	var in requestParams
+	if err := json.Unmarshal(input, &in); err != nil {
+		return tool.Result{}, err
+	}
+
+	u, err := url.Parse(in.URL)
+	if err != nil {
+		return tool.Result{}, fmt.Errorf("invalid url: %w", err)
+	}
+	if err := p.checkURL(u); err != nil {
+		return tool.Result{}, err
+	}
+	if len(in.Query) > 0 {
+		q := u.Query()
+		for k, v := range in.Query {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	timeout := p.cfg.Timeout
+	if in.TimeoutSeconds > 0 {
+		timeout = min(time.Duration(in.TimeoutSeconds)*time.Second, p.cfg.MaxTimeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var body io.Reader
+	contentType := ""
+	switch {
+	case len(in.Body) > 0:
+		body = bytes.NewReader(in.Body)
+		contentType = "application/json"
+	case in.BodyText != "":
+		body = strings.NewReader(in.BodyText)
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return tool.Result{}, err
+	}
+	req.Header.Set("User-Agent", p.cfg.UserAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for k, v := range in.Headers {
+		if forbiddenHeaders[http.CanonicalHeaderKey(k)] {
+			return tool.Result{}, fmt.Errorf("%w: %s", ErrForbiddenHeader, k)
+		}
+		req.Header.Set(k, v)
+	}
+	if in.Credential != "" {
+		if req, err = p.applyCredential(ctx, req, in.Credential); err != nil {
+			return tool.Result{}, err
+		}
+	}
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return tool.Result{}, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.cfg.MaxResponseBytes+1))
+	if err != nil {
+		return tool.Result{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := int64(len(data)) > p.cfg.MaxResponseBytes
+	if truncated {
+		data = data[:p.cfg.MaxResponseBytes]
+	}
+
+	result := map[string]any{
+		"status":       resp.Status,
+		"status_code":  resp.StatusCode,
+		"url":          resp.Request.URL.String(),
+		"headers":      flattenHeaders(resp.Header),
+		"content_type": resp.Header.Get("Content-Type"),
+		"bytes":        len(data),
+		"truncated":    truncated,
+	}
+	if method != http.MethodHead {
+		decodeBody(result, resp.Header.Get("Content-Type"), data, truncated)
+	}
+
+	output, _ := json.Marshal(result)
+	return tool.Result{Output: output, Duration: time.Since(start)}, nil
+}
+
+// flattenHeaders joins repeated header values, dropping cookies.
+func flattenHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if k == "Set-Cookie" {
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// decodeBody adds the body to result based on the content type: parsed
+// JSON under "json", text under "body" and anything else base64-encoded
+// under "body_base64". Without a content type the body is sniffed: JSON is
+// parsed and valid UTF-8 is returned as text.
+func decodeBody(result map[string]any, contentType string, data []byte, truncated bool) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var parsed any
+		if !truncated && json.Unmarshal(data, &parsed) == nil {
+			result["json"] = parsed
+			return
+		}
+		result["body"] = string(data)
+	case mediaType == "" && !truncated && len(data) > 0 && json.Valid(data):
+		var parsed any
+		_ = json.Unmarshal(data, &parsed)
+		result["json"] = parsed
+	case isText(mediaType), mediaType == "" && utf8.Valid(data):
+		result["body"] = string(data)
+	default:
+		result["body_base64"] = base64.StdEncoding.EncodeToString(data)
+	}
+}
+
+func isText(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/xml",
+		mediaType == "application/javascript",
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/x-ndjson":
+		return true
+	}
+	return false
+}
+
+func (p *httpPack) handler(method string) tool.Handler {
+	return func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+		return p.do(ctx, method, input)
+	}
+}
+
+func (p *httpPack) httpGet() tool.Tool {
 	return tool.NewBuilder("http_get").
 		WithDescription("Perform an HTTP GET request").
 		ReadOnly().
 		Cacheable().
+		WithHandler(p.handler(http.MethodGet)).
 		MustBuild()
 }
 
-func httpPost() tool.Tool {
+func (p *httpPack) httpPost() tool.Tool {
 	return tool.NewBuilder("http_post").
 		WithDescription("Perform an HTTP POST request with JSON body").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(p.handler(http.MethodPost)).
 		MustBuild()
 }
 
-func httpPut() tool.Tool {
+func (p *httpPack) httpPut() tool.Tool {
 	return tool.NewBuilder("http_put").
 		WithDescription("Perform an HTTP PUT request with JSON body").
 		WithRiskLevel(tool.RiskMedium).
+		Idempotent().
+		WithHandler(p.handler(http.MethodPut)).
 		MustBuild()
 }
 
-func httpDelete() tool.Tool {
+func (p *httpPack) httpDelete() tool.Tool {
 	return tool.NewBuilder("http_delete").
 		WithDescription("Perform an HTTP DELETE request").
 		Destructive().
+		WithHandler(p.handler(http.MethodDelete)).
 		MustBuild()
 }
 
-func httpHead() tool.Tool {
+func (p *httpPack) httpHead() tool.Tool {
 	return tool.NewBuilder("http_head").
 		WithDescription("Perform an HTTP HEAD request").
 		ReadOnly().
 		Cacheable().
+		WithHandler(p.handler(http.MethodHead)).
 		MustBuild()
 }
 
-func httpPatch() tool.Tool {
+func (p *httpPack) httpPatch() tool.Tool {
 	return tool.NewBuilder("http_patch").
 		WithDescription("Perform an HTTP PATCH request with JSON body").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(p.handler(http.MethodPatch)).
 		MustBuild()
 }
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+func TestJSONResponse(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"q":"` + r.URL.Query().Get("q") + `","method":"` + r.Method + `"}`))
+	}))
+	defer srv.Close()
+
+	p := Pack(Config{AllowPrivateNetworks: true})
+	out, err := call(p, "http_get", map[string]any{"url": srv.URL, "query": map[string]string{"q": "agents"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, ok := out["json"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected parsed json, got %v", out)
+	}
+	if body["q"] != "agents" || body["method"] != "GET" {
+		t.Errorf("unexpected body: %v", body)
+	}
+	if out["status_code"].(float64) != 200 {
+		t.Errorf("status_code = %v", out["status_code"])
+	}
+}
+
+func TestPrivateAddressesBlocked(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	p := Pack(Config{})
+	_, err := call(p, "http_get", map[string]any{"url": srv.URL})
+	if !errors.Is(err, ErrAddressBlocked) {
+		t.Fatalf("expected ErrAddressBlocked, got %v", err)
+	}
+
+	for _, u := range []string{"http://169.254.169.254/latest/meta-data", "http://[::1]/", "http://10.0.0.1/"} {
+		if _, err := call(p, "http_get", map[string]any{"url": u, "timeout_seconds": 1}); !errors.Is(err, ErrAddressBlocked) {
+			t.Errorf("%s: expected ErrAddressBlocked, got %v", u, err)
+		}
+	}
+}
+
+func TestRedirectToDisallowedHost(t *testing.T) {
+	t.Parallel()
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer target.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, strings.Replace(target.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+	}))
+	defer srv.Close()
+
+	p := Pack(Config{AllowPrivateNetworks: true, AllowedHosts: []string{"127.0.0.1"}})
+	if _, err := call(p, "http_get", map[string]any{"url": srv.URL}); !errors.Is(err, ErrHostNotAllowed) {
+		t.Fatalf("expected ErrHostNotAllowed, got %v", err)
+	}
+}
+
+func TestCredentialInjection(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	p := Pack(Config{
+		AllowPrivateNetworks: true,
+		Secrets:              secrets.NewMemoryManager(secrets.WithInitialSecrets(map[string]string{"api/token": "s3cret"})),
+		Credentials: map[string]Credential{
+			"api":   {Secret: "api/token", Hosts: []string{"127.0.0.1"}},
+			"other": {Secret: "api/token", Hosts: []string{"example.com"}},
+		},
+	})
+
+	out, err := call(p, "http_get", map[string]any{"url": srv.URL, "credential": "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["body"] != "Bearer s3cret" {
+		t.Errorf("body = %v, want injected bearer token", out["body"])
+	}
+
+	if _, err := call(p, "http_get", map[string]any{"url": srv.URL, "credential": "other"}); !errors.Is(err, ErrHostNotAllowed) {
+		t.Errorf("expected credential host scoping error, got %v", err)
+	}
+	if _, err := call(p, "http_get", map[string]any{"url": srv.URL, "headers": map[string]string{"authorization": "Bearer x"}}); !errors.Is(err, ErrForbiddenHeader) {
+		t.Errorf("expected ErrForbiddenHeader, got %v", err)
+	}
+}
+
+func TestResponseSizeCap(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer srv.Close()
+
+	p := Pack(Config{AllowPrivateNetworks: true, MaxResponseBytes: 10})
+	out, err := call(p, "http_post", map[string]any{"url": srv.URL, "body": map[string]any{"a": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["truncated"] != true || out["body"] != strings.Repeat("x", 10) {
+		t.Errorf("expected truncated body, got %v", out)
+	}
+}
+
+func TestResponseWithoutContentType(t *testing.T) {
+	t.Parallel()
+
+	bodies := map[string][]byte{
+		"/json":   []byte(`{"ok":true}`),
+		"/text":   []byte("plain text"),
+		"/binary": {0xff, 0xfe, 0x00},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Suppress the server's content sniffing.
+		w.Header()["Content-Type"] = nil
+		_, _ = w.Write(bodies[r.URL.Path])
+	}))
+	defer srv.Close()
+
+	p := Pack(Config{AllowPrivateNetworks: true})
+	out, err := call(p, "http_get", map[string]any{"url": srv.URL + "/json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, ok := out["json"].(map[string]any); !ok || body["ok"] != true {
+		t.Errorf("expected sniffed json, got %v", out)
+	}
+
+	out, err = call(p, "http_get", map[string]any{"url": srv.URL + "/text"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["body"] != "plain text" || out["json"] != nil {
+		t.Errorf("expected text body, got %v", out)
+	}
+
+	out, err = call(p, "http_get", map[string]any{"url": srv.URL + "/binary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["body_base64"] != "//4A" {
+		t.Errorf("expected base64 body, got %v", out)
+	}
+}
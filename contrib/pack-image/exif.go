@@ -0,0 +1,144 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// maxExifValue caps the length of a single EXIF value in tool output, so
+// that maker notes and embedded blobs do not flood the planner.
+const maxExifValue = 256
+
+// orientation returns the EXIF orientation of an encoded image, or 1
+// (upright) when it has none.
+func orientation(data []byte) int {
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 1
+	}
+	tag, err := x.Get(exif.Orientation)
+	if err != nil {
+		return 1
+	}
+	o, err := tag.Int(0)
+	if err != nil || o < 1 || o > 8 {
+		return 1
+	}
+	return o
+}
+
+// exifFields collects printable EXIF tags, skipping binary values.
+type exifFields map[string]string
+
+func (f exifFields) Walk(name exif.FieldName, tag *tiff.Tag) error {
+	if tag.Format() == tiff.OtherVal || name == exif.MakerNote || name == exif.UserComment {
+		return nil
+	}
+	var value string
+	if tag.Format() == tiff.StringVal {
+		value, _ = tag.StringVal()
+	} else {
+		value = tag.String()
+	}
+	value = strings.TrimSpace(strings.Trim(value, "\x00\""))
+	if value == "" {
+		return nil
+	}
+	if len(value) > maxExifValue {
+		value = strings.ToValidUTF8(value[:maxExifValue], "") + "…"
+	}
+	f[string(name)] = value
+	return nil
+}
+
+// exifSummary extracts EXIF tags and commonly used derived values.
+func exifSummary(data []byte) map[string]any {
+	x, err := exif.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	fields := exifFields{}
+	_ = x.Walk(fields)
+	summary := map[string]any{"tags": fields}
+	if t, err := x.DateTime(); err == nil {
+		summary["taken_at"] = t.Format(time.RFC3339)
+	}
+	if lat, long, err := x.LatLong(); err == nil {
+		summary["gps"] = map[string]float64{"latitude": lat, "longitude": long}
+	}
+	for key, name := range map[string]exif.FieldName{"camera_make": exif.Make, "camera_model": exif.Model} {
+		if v, ok := fields[string(name)]; ok {
+			summary[key] = v
+		}
+	}
+	return summary
+}
+
+func colorModelName(m color.Model) string {
+	switch m {
+	case color.RGBAModel, color.NRGBAModel:
+		return "rgba"
+	case color.RGBA64Model, color.NRGBA64Model:
+		return "rgba64"
+	case color.GrayModel:
+		return "gray"
+	case color.Gray16Model:
+		return "gray16"
+	case color.YCbCrModel, color.NYCbCrAModel:
+		return "ycbcr"
+	case color.CMYKModel:
+		return "cmyk"
+	}
+	if _, ok := m.(color.Palette); ok {
+		return "paletted"
+	}
+	return "other"
+}
+
+func (p *imagePack) imageMetadata() tool.Tool {
+	return tool.NewBuilder("image_metadata").
+		WithDescription("Get an image artifact's format, dimensions and EXIF metadata without decoding pixels").
+		ReadOnly().
+		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID string `json:"artifact_id"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			src, err := p.read(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			out := map[string]any{
+				"format":      src.format,
+				"width":       src.config.Width,
+				"height":      src.config.Height,
+				"megapixels":  float64(src.config.Width*src.config.Height) / 1e6,
+				"color_model": colorModelName(src.config.ColorModel),
+				"size_bytes":  len(src.data),
+				"orientation": orientation(src.data),
+				"decodable":   int64(src.config.Width)*int64(src.config.Height) <= p.cfg.MaxPixels,
+			}
+			if summary := exifSummary(src.data); summary != nil {
+				out["exif"] = summary
+			}
+
+			output, _ := json.Marshal(out)
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
@@ -2,6 +2,10 @@ module github.com/felixgeelhaar/agent-go/contrib/pack-image
 
 go 1.25.0
 
-require github.com/felixgeelhaar/agent-go v0.0.0
+require (
+	github.com/felixgeelhaar/agent-go v0.0.0
+	github.com/rwcarlsen/goexif v0.0.0-20190401172101-9e8deecbddbd
+	golang.org/x/image v0.44.0
+)
 
 replace github.com/felixgeelhaar/agent-go => ../..
@@ -10,29 +10,93 @@
 //   - image_metadata: Extract image metadata (EXIF, dimensions)
 //   - image_watermark: Add a watermark to an image
 //
-// Supports common formats: PNG, JPEG, GIF, WebP, TIFF, BMP.
+// All processing is pure Go. PNG, JPEG, GIF, BMP and TIFF are read and
+// written; WebP is read only. Images are read from and written to an
+// artifact.Store, so tools take and return artifact IDs. Source artifacts
+// are never modified. Dimensions are checked before pixels are decoded to
+// guard against decompression bombs.
 package image
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp" // register the WebP decoder
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/artifact"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Errors returned by the image tools.
+var (
+	// ErrNoArtifactStore indicates the pack was configured without a store.
+	ErrNoArtifactStore = errors.New("image pack requires an artifact store")
+
+	// ErrImageTooLarge indicates an input or output exceeding the size limits.
+	ErrImageTooLarge = errors.New("image exceeds size limits")
+
+	// ErrUnsupportedFormat indicates a format that cannot be decoded or encoded.
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+
+	// ErrInvalidParameters indicates invalid dimensions, regions or options.
+	ErrInvalidParameters = errors.New("invalid parameters")
+)
+
+// Config configures the image pack.
+type Config struct {
+	// Artifacts stores input and output images. Required.
+	Artifacts artifact.Store
+
+	// MaxInputBytes caps the encoded size of each input image. Defaults to 50 MiB.
+	MaxInputBytes int64
+
+	// MaxPixels caps width×height of decoded and produced images. Defaults
+	// to 50 megapixels.
+	MaxPixels int64
+
+	// DefaultQuality is the JPEG quality used when none is given. Defaults to 85.
+	DefaultQuality int
+}
+
 // Pack returns the image processing tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.MaxInputBytes <= 0 {
+		cfg.MaxInputBytes = 50 << 20
+	}
+	if cfg.MaxPixels <= 0 {
+		cfg.MaxPixels = 50_000_000
+	}
+	if cfg.DefaultQuality <= 0 || cfg.DefaultQuality > 100 {
+		cfg.DefaultQuality = 85
+	}
+	p := &imagePack{cfg: cfg}
+
 	return pack.NewBuilder("image").
 		WithDescription("Image processing and manipulation tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			imageResize(),
-			imageCrop(),
-			imageConvert(),
-			imageCompress(),
-			imageRotate(),
-			imageThumbnail(),
-			imageMetadata(),
-			imageWatermark(),
+			p.imageResize(),
+			p.imageCrop(),
+			p.imageConvert(),
+			p.imageCompress(),
+			p.imageRotate(),
+			p.imageThumbnail(),
+			p.imageMetadata(),
+			p.imageWatermark(),
 		).
 		AllowInState(agent.StateExplore, "image_metadata").
 		AllowInState(agent.StateAct, "image_resize", "image_crop", "image_convert", "image_compress", "image_rotate", "image_thumbnail", "image_metadata", "image_watermark").
@@ -40,65 +104,481 @@ func Pack() *pack.Pack {
 		Build()
 }
 
-func imageResize() tool.Tool {
+type imagePack struct {
+	cfg Config
+}
+
+// source is a loaded input image.
+type source struct {
+	id     string
+	name   string // artifact name without extension
+	data   []byte
+	format string
+	config image.Config
+}
+
+// read loads an artifact and its header. Pixels are not decoded.
+func (p *imagePack) read(ctx context.Context, id string) (*source, error) {
+	if p.cfg.Artifacts == nil {
+		return nil, ErrNoArtifactStore
+	}
+	rc, err := p.cfg.Artifacts.Retrieve(ctx, artifact.NewRef(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact %s: %w", id, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, p.cfg.MaxInputBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact %s: %w", id, err)
+	}
+	if int64(len(data)) > p.cfg.MaxInputBytes {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrImageTooLarge, id, p.cfg.MaxInputBytes)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedFormat, id, err)
+	}
+
+	src := &source{id: id, name: id, data: data, format: format, config: config}
+	if meta, err := p.cfg.Artifacts.Metadata(ctx, artifact.NewRef(id)); err == nil && meta.Name != "" {
+		src.name = strings.TrimSuffix(path.Base(meta.Name), path.Ext(meta.Name))
+	}
+	return src, nil
+}
+
+// checkPixels rejects dimensions beyond the configured pixel budget.
+func (p *imagePack) checkPixels(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("%w: dimensions must be positive, got %dx%d", ErrInvalidParameters, width, height)
+	}
+	if int64(width)*int64(height) > p.cfg.MaxPixels {
+		return fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrImageTooLarge, width, height, p.cfg.MaxPixels)
+	}
+	return nil
+}
+
+// decode loads and decodes an artifact after checking its dimensions. Only
+// the first frame of animated images is decoded.
+func (p *imagePack) decode(ctx context.Context, id string) (*source, image.Image, error) {
+	src, err := p.read(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.checkPixels(src.config.Width, src.config.Height); err != nil {
+		return nil, nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(src.data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode %s: %w", id, err)
+	}
+	return src, img, nil
+}
+
+// outputFormat normalizes a requested format, defaulting to the source
+// format when it can be encoded and PNG otherwise.
+func outputFormat(requested, sourceFormat string) (string, error) {
+	format := strings.ToLower(strings.TrimPrefix(requested, "."))
+	if format == "" {
+		format = sourceFormat
+		if format == "webp" {
+			return "png", nil
+		}
+	}
+	switch format {
+	case "jpg", "jpeg":
+		return "jpeg", nil
+	case "png", "gif", "bmp":
+		return format, nil
+	case "tif", "tiff":
+		return "tiff", nil
+	case "webp":
+		return "", fmt.Errorf("%w: webp can be read but not written", ErrUnsupportedFormat)
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+var extensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"gif":  ".gif",
+	"bmp":  ".bmp",
+	"tiff": ".tiff",
+}
+
+// encodeOptions controls output encoding.
+type encodeOptions struct {
+	Format     string `json:"format,omitempty"`
+	Quality    int    `json:"quality,omitempty"`
+	OutputName string `json:"output_name,omitempty"`
+}
+
+func (p *imagePack) encode(w io.Writer, img image.Image, format string, quality int) error {
+	if quality <= 0 || quality > 100 {
+		quality = p.cfg.DefaultQuality
+	}
+	switch format {
+	case "jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "png":
+		enc := png.Encoder{CompressionLevel: png.DefaultCompression}
+		if quality < 50 {
+			enc.CompressionLevel = png.BestCompression
+		}
+		return enc.Encode(w, img)
+	case "gif":
+		return gif.Encode(w, img, &gif.Options{NumColors: 256})
+	case "bmp":
+		return bmp.Encode(w, img)
+	case "tiff":
+		return tiff.Encode(w, img, &tiff.Options{Compression: tiff.Deflate, Predictor: true})
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// write encodes img and stores it as a new artifact, returning the tool
+// result describing it.
+func (p *imagePack) write(ctx context.Context, src *source, img image.Image, opts encodeOptions, suffix string, extra map[string]any) (tool.Result, error) {
+	format, err := outputFormat(opts.Format, src.format)
+	if err != nil {
+		return tool.Result{}, err
+	}
+	bounds := img.Bounds()
+	if err := p.checkPixels(bounds.Dx(), bounds.Dy()); err != nil {
+		return tool.Result{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := p.encode(&buf, img, format, opts.Quality); err != nil {
+		return tool.Result{}, fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	size := buf.Len()
+
+	name := src.name + suffix + extensions[format]
+	if opts.OutputName != "" {
+		name = path.Base(opts.OutputName)
+		if path.Ext(name) == "" {
+			name += extensions[format]
+		}
+	}
+	storeOpts := artifact.DefaultStoreOptions().
+		WithName(name).
+		WithContentType("image/"+format).
+		WithMetadata("source", src.id)
+	ref, err := p.cfg.Artifacts.Store(ctx, &buf, storeOpts)
+	if err != nil {
+		return tool.Result{}, fmt.Errorf("failed to store %s: %w", name, err)
+	}
+
+	out := map[string]any{
+		"artifact_id":   ref.ID,
+		"name":          name,
+		"format":        format,
+		"width":         bounds.Dx(),
+		"height":        bounds.Dy(),
+		"size_bytes":    size,
+		"source_format": src.format,
+		"source_width":  src.config.Width,
+		"source_height": src.config.Height,
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	output, _ := json.Marshal(out)
+	return tool.Result{
+		Output:    output,
+		Artifacts: []tool.ArtifactRef{{ID: ref.ID, Name: name}},
+	}, nil
+}
+
+// ============================================================================
+// Transformation Tools
+// ============================================================================
+
+func (p *imagePack) imageResize() tool.Tool {
 	return tool.NewBuilder("image_resize").
-		WithDescription("Resize an image to specified dimensions").
+		WithDescription("Resize an image artifact to specified dimensions (fit, fill or stretch) into a new artifact").
+		ReadOnly().
 		Idempotent().
-		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID string `json:"artifact_id"`
+				Width      int    `json:"width,omitempty"`
+				Height     int    `json:"height,omitempty"`
+				Mode       string `json:"mode,omitempty"` // fit (default), fill, stretch
+				encodeOptions
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			src, img, err := p.decode(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			w, h, err := targetSize(img.Bounds(), in.Width, in.Height, in.Mode)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkPixels(w, h); err != nil {
+				return tool.Result{}, err
+			}
+
+			var out image.Image
+			if in.Mode == "fill" {
+				out = fill(img, in.Width, in.Height)
+			} else {
+				out = resize(img, w, h)
+			}
+			return p.write(ctx, src, out, in.encodeOptions, fmt.Sprintf("_%dx%d", out.Bounds().Dx(), out.Bounds().Dy()), nil)
+		}).
 		MustBuild()
 }
 
-func imageCrop() tool.Tool {
+func (p *imagePack) imageCrop() tool.Tool {
 	return tool.NewBuilder("image_crop").
-		WithDescription("Crop an image to a specified region").
+		WithDescription("Crop an image artifact to a region into a new artifact").
+		ReadOnly().
 		Idempotent().
-		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID string `json:"artifact_id"`
+				X          int    `json:"x"`
+				Y          int    `json:"y"`
+				Width      int    `json:"width"`
+				Height     int    `json:"height"`
+				encodeOptions
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			src, img, err := p.decode(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			b := img.Bounds()
+			rect := image.Rect(in.X, in.Y, in.X+in.Width, in.Y+in.Height).Add(b.Min)
+			if in.Width <= 0 || in.Height <= 0 || !rect.In(b) {
+				return tool.Result{}, fmt.Errorf("%w: region %dx%d+%d+%d is outside the %dx%d image",
+					ErrInvalidParameters, in.Width, in.Height, in.X, in.Y, b.Dx(), b.Dy())
+			}
+			return p.write(ctx, src, crop(img, rect), in.encodeOptions, "_crop", nil)
+		}).
 		MustBuild()
 }
 
-func imageConvert() tool.Tool {
+func (p *imagePack) imageConvert() tool.Tool {
 	return tool.NewBuilder("image_convert").
-		WithDescription("Convert image to a different format").
+		WithDescription("Convert an image artifact to PNG, JPEG, GIF, BMP or TIFF into a new artifact").
+		ReadOnly().
 		Idempotent().
-		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID string `json:"artifact_id"`
+				encodeOptions
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Format == "" {
+				return tool.Result{}, fmt.Errorf("%w: format is required", ErrInvalidParameters)
+			}
+
+			src, img, err := p.decode(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return p.write(ctx, src, img, in.encodeOptions, "", nil)
+		}).
 		MustBuild()
 }
 
-func imageCompress() tool.Tool {
+func (p *imagePack) imageCompress() tool.Tool {
 	return tool.NewBuilder("image_compress").
-		WithDescription("Compress an image to reduce file size").
+		WithDescription("Re-encode an image artifact at lower quality to reduce file size").
+		ReadOnly().
 		Idempotent().
-		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID string `json:"artifact_id"`
+				encodeOptions
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Quality == 0 {
+				in.Quality = 70
+			}
+
+			src, img, err := p.decode(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			// Formats without lossy encoding are compressed as JPEG unless
+			// the caller asks otherwise; PNG is kept but losslessly squeezed.
+			if in.Format == "" && src.format != "png" {
+				in.Format = "jpeg"
+			}
+			return p.write(ctx, src, img, in.encodeOptions, "_compressed", map[string]any{
+				"original_size": len(src.data),
+			})
+		}).
 		MustBuild()
 }
 
-func imageRotate() tool.Tool {
+func (p *imagePack) imageRotate() tool.Tool {
 	return tool.NewBuilder("image_rotate").
-		WithDescription("Rotate an image by specified degrees").
+		WithDescription("Rotate an image artifact clockwise by degrees, flip it, or apply its EXIF orientation").
+		ReadOnly().
 		Idempotent().
-		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID string  `json:"artifact_id"`
+				Degrees    float64 `json:"degrees,omitempty"`
+				Flip       string  `json:"flip,omitempty"` // horizontal, vertical
+				AutoOrient bool    `json:"auto_orient,omitempty"`
+				Background string  `json:"background,omitempty"`
+				encodeOptions
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			src, img, err := p.decode(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if in.AutoOrient {
+				img = applyOrientation(img, orientation(src.data))
+			}
+			switch in.Flip {
+			case "":
+			case "horizontal":
+				img = flipHorizontal(img)
+			case "vertical":
+				img = flipVertical(img)
+			default:
+				return tool.Result{}, fmt.Errorf("%w: flip must be horizontal or vertical", ErrInvalidParameters)
+			}
+			if in.Degrees != 0 {
+				bg, err := parseColor(in.Background)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				w, h := rotatedSize(img.Bounds(), in.Degrees)
+				if err := p.checkPixels(w, h); err != nil {
+					return tool.Result{}, err
+				}
+				img = rotate(img, in.Degrees, bg)
+			}
+			return p.write(ctx, src, img, in.encodeOptions, "_rotated", nil)
+		}).
 		MustBuild()
 }
 
-func imageThumbnail() tool.Tool {
+func (p *imagePack) imageThumbnail() tool.Tool {
 	return tool.NewBuilder("image_thumbnail").
-		WithDescription("Generate a thumbnail for an image").
+		WithDescription("Generate a thumbnail artifact that fits within a square of the given size").
+		ReadOnly().
 		Idempotent().
-		WithRiskLevel(tool.RiskLow).
-		MustBuild()
-}
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID string `json:"artifact_id"`
+				Size       int    `json:"size,omitempty"`
+				encodeOptions
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Size <= 0 {
+				in.Size = 256
+			}
+			if in.Size > 2048 {
+				return tool.Result{}, fmt.Errorf("%w: thumbnail size is at most 2048", ErrInvalidParameters)
+			}
 
-func imageMetadata() tool.Tool {
-	return tool.NewBuilder("image_metadata").
-		WithDescription("Extract metadata from an image (EXIF, dimensions, format)").
-		ReadOnly().
-		Cacheable().
+			src, img, err := p.decode(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			img = applyOrientation(img, orientation(src.data))
+			w, h, _ := targetSize(img.Bounds(), in.Size, in.Size, "fit")
+			// Never enlarge.
+			if b := img.Bounds(); w > b.Dx() || h > b.Dy() {
+				w, h = b.Dx(), b.Dy()
+			}
+			if in.Format == "" && src.format != "png" && src.format != "gif" {
+				in.Format = "jpeg"
+			}
+			return p.write(ctx, src, resize(img, w, h), in.encodeOptions, "_thumb", nil)
+		}).
 		MustBuild()
 }
 
-func imageWatermark() tool.Tool {
+func (p *imagePack) imageWatermark() tool.Tool {
 	return tool.NewBuilder("image_watermark").
-		WithDescription("Add a watermark to an image").
-		WithRiskLevel(tool.RiskLow).
+		WithDescription("Add a text or image watermark to an image artifact into a new artifact").
+		ReadOnly().
+		Idempotent().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactID          string  `json:"artifact_id"`
+				Text                string  `json:"text,omitempty"`
+				WatermarkArtifactID string  `json:"watermark_artifact_id,omitempty"`
+				Position            string  `json:"position,omitempty"`
+				Opacity             float64 `json:"opacity,omitempty"`
+				Scale               float64 `json:"scale,omitempty"`
+				Color               string  `json:"color,omitempty"`
+				encodeOptions
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if (in.Text == "") == (in.WatermarkArtifactID == "") {
+				return tool.Result{}, fmt.Errorf("%w: exactly one of text or watermark_artifact_id is required", ErrInvalidParameters)
+			}
+			if in.Opacity <= 0 || in.Opacity > 1 {
+				in.Opacity = 0.5
+			}
+			if in.Scale <= 0 || in.Scale > 1 {
+				in.Scale = 0.25
+			}
+			if in.Position == "" {
+				in.Position = "bottom-right"
+			}
+
+			src, img, err := p.decode(ctx, in.ArtifactID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			var mark image.Image
+			if in.Text != "" {
+				c, err := parseColor(in.Color)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				if in.Color == "" {
+					c = defaultTextColor
+				}
+				mark = textImage(in.Text, c)
+			} else {
+				_, wm, err := p.decode(ctx, in.WatermarkArtifactID)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				mark = wm
+			}
+
+			out, err := watermark(img, mark, in.Position, in.Opacity, in.Scale)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return p.write(ctx, src, out, in.encodeOptions, "_watermarked", nil)
+		}).
 		MustBuild()
 }
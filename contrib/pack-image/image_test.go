@@ -0,0 +1,237 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/artifact"
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/filesystem"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+// newTestPack returns a pack over a fresh store holding a 40x20 PNG whose
+// left half is red and right half is blue.
+func newTestPack(t *testing.T, cfg Config) (*pack.Pack, *filesystem.ArtifactStore, string) {
+	t.Helper()
+
+	store, err := filesystem.NewArtifactStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= 20 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := store.Store(context.Background(), &buf, artifact.DefaultStoreOptions().WithName("photo.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Artifacts = store
+	return Pack(cfg), store, ref.ID
+}
+
+// load decodes an output artifact.
+func load(t *testing.T, store artifact.Store, id string) (image.Image, string) {
+	t.Helper()
+	rc, err := store.Retrieve(context.Background(), artifact.NewRef(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	img, format, err := image.Decode(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img, format
+}
+
+func TestMetadata(t *testing.T) {
+	t.Parallel()
+
+	p, _, id := newTestPack(t, Config{})
+	out, err := call(p, "image_metadata", map[string]any{"artifact_id": id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["format"] != "png" || out["width"] != float64(40) || out["height"] != float64(20) || out["decodable"] != true {
+		t.Errorf("unexpected metadata: %v", out)
+	}
+}
+
+func TestResize(t *testing.T) {
+	t.Parallel()
+
+	p, store, id := newTestPack(t, Config{})
+	tests := []struct {
+		input         map[string]any
+		width, height int
+	}{
+		{map[string]any{"width": 20}, 20, 10},
+		{map[string]any{"width": 10, "height": 10}, 10, 5},
+		{map[string]any{"width": 10, "height": 10, "mode": "fill"}, 10, 10},
+		{map[string]any{"width": 10, "height": 10, "mode": "stretch"}, 10, 10},
+	}
+	for _, tt := range tests {
+		tt.input["artifact_id"] = id
+		out, err := call(p, "image_resize", tt.input)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.input, err)
+		}
+		img, format := load(t, store, out["artifact_id"].(string))
+		if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height || format != "png" {
+			t.Errorf("%v: got %s %dx%d, want png %dx%d", tt.input, format, b.Dx(), b.Dy(), tt.width, tt.height)
+		}
+	}
+
+	if _, err := call(p, "image_resize", map[string]any{"artifact_id": id}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("expected ErrInvalidParameters, got %v", err)
+	}
+}
+
+func TestCropAndRotate(t *testing.T) {
+	t.Parallel()
+
+	p, store, id := newTestPack(t, Config{})
+
+	out, err := call(p, "image_crop", map[string]any{"artifact_id": id, "x": 20, "y": 0, "width": 20, "height": 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _ := load(t, store, out["artifact_id"].(string))
+	if r, _, b, _ := img.At(0, 0).RGBA(); r != 0 || b == 0 {
+		t.Errorf("expected the blue half, got %v", img.At(0, 0))
+	}
+	if _, err := call(p, "image_crop", map[string]any{"artifact_id": id, "x": 30, "y": 0, "width": 20, "height": 20}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("expected ErrInvalidParameters for a region outside the image, got %v", err)
+	}
+
+	out, err = call(p, "image_rotate", map[string]any{"artifact_id": id, "degrees": 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["width"] != float64(20) || out["height"] != float64(40) {
+		t.Errorf("expected 20x40 after rotating 90 degrees, got %vx%v", out["width"], out["height"])
+	}
+
+	out, err = call(p, "image_rotate", map[string]any{"artifact_id": id, "flip": "horizontal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, _ = load(t, store, out["artifact_id"].(string))
+	if _, _, b, _ := img.At(0, 0).RGBA(); b == 0 {
+		t.Errorf("expected blue at the left after a horizontal flip, got %v", img.At(0, 0))
+	}
+}
+
+func TestConvertAndCompress(t *testing.T) {
+	t.Parallel()
+
+	p, store, id := newTestPack(t, Config{})
+
+	for _, format := range []string{"jpeg", "gif", "bmp", "tiff"} {
+		out, err := call(p, "image_convert", map[string]any{"artifact_id": id, "format": format})
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if _, got := load(t, store, out["artifact_id"].(string)); got != format {
+			t.Errorf("converted to %s, decoded as %s", format, got)
+		}
+	}
+	if _, err := call(p, "image_convert", map[string]any{"artifact_id": id, "format": "webp"}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("expected ErrUnsupportedFormat for webp output, got %v", err)
+	}
+
+	out, err := call(p, "image_compress", map[string]any{"artifact_id": id, "format": "jpeg", "quality": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["format"] != "jpeg" || out["original_size"] == nil {
+		t.Errorf("unexpected result: %v", out)
+	}
+}
+
+func TestThumbnailAndWatermark(t *testing.T) {
+	t.Parallel()
+
+	p, _, id := newTestPack(t, Config{})
+
+	out, err := call(p, "image_thumbnail", map[string]any{"artifact_id": id, "size": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["width"] != float64(10) || out["height"] != float64(5) {
+		t.Errorf("expected a 10x5 thumbnail, got %vx%v", out["width"], out["height"])
+	}
+	out, err = call(p, "image_thumbnail", map[string]any{"artifact_id": id, "size": 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["width"] != float64(40) {
+		t.Errorf("thumbnails must not enlarge, got width %v", out["width"])
+	}
+
+	out, err = call(p, "image_watermark", map[string]any{"artifact_id": id, "text": "(c)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["width"] != float64(40) || out["name"] != "photo_watermarked.png" {
+		t.Errorf("unexpected result: %v", out)
+	}
+	if _, err := call(p, "image_watermark", map[string]any{"artifact_id": id}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("expected ErrInvalidParameters, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+
+	p, _, id := newTestPack(t, Config{MaxPixels: 100})
+	if _, err := call(p, "image_resize", map[string]any{"artifact_id": id, "width": 5}); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge before decoding, got %v", err)
+	}
+	out, err := call(p, "image_metadata", map[string]any{"artifact_id": id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["decodable"] != false {
+		t.Errorf("expected decodable=false, got %v", out)
+	}
+
+	p, _, id = newTestPack(t, Config{MaxInputBytes: 10})
+	if _, err := call(p, "image_metadata", map[string]any{"artifact_id": id}); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("expected ErrImageTooLarge for a large input, got %v", err)
+	}
+
+	if _, err := call(Pack(Config{}), "image_metadata", map[string]any{"artifact_id": "x"}); !errors.Is(err, ErrNoArtifactStore) {
+		t.Errorf("expected ErrNoArtifactStore, got %v", err)
+	}
+}
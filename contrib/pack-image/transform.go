@@ -0,0 +1,274 @@
+package image
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/f64"
+	"golang.org/x/image/math/fixed"
+)
+
+// toNRGBA returns img as an *image.NRGBA with bounds starting at the origin.
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	if n, ok := img.(*image.NRGBA); ok && b.Min == (image.Point{}) {
+		return n
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+// targetSize computes output dimensions for a resize mode. With one
+// dimension given, the other follows the aspect ratio.
+func targetSize(b image.Rectangle, width, height int, mode string) (int, int, error) {
+	srcW, srcH := b.Dx(), b.Dy()
+	if width < 0 || height < 0 || (width == 0 && height == 0) {
+		return 0, 0, fmt.Errorf("%w: width or height is required", ErrInvalidParameters)
+	}
+
+	switch mode {
+	case "", "fit":
+		switch {
+		case width == 0:
+			width = max(1, int(math.Round(float64(srcW)*float64(height)/float64(srcH))))
+		case height == 0:
+			height = max(1, int(math.Round(float64(srcH)*float64(width)/float64(srcW))))
+		default:
+			scale := math.Min(float64(width)/float64(srcW), float64(height)/float64(srcH))
+			width = max(1, int(math.Round(float64(srcW)*scale)))
+			height = max(1, int(math.Round(float64(srcH)*scale)))
+		}
+		return width, height, nil
+	case "fill", "stretch":
+		if width == 0 || height == 0 {
+			return 0, 0, fmt.Errorf("%w: mode %s requires width and height", ErrInvalidParameters, mode)
+		}
+		return width, height, nil
+	default:
+		return 0, 0, fmt.Errorf("%w: mode must be fit, fill or stretch", ErrInvalidParameters)
+	}
+}
+
+// resize scales img to exactly width×height.
+func resize(img image.Image, width, height int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+// fill scales img to cover width×height and crops the centre.
+func fill(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	scale := math.Max(float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy()))
+	cropW := min(b.Dx(), int(math.Round(float64(width)/scale)))
+	cropH := min(b.Dy(), int(math.Round(float64(height)/scale)))
+	x := b.Min.X + (b.Dx()-cropW)/2
+	y := b.Min.Y + (b.Dy()-cropH)/2
+	return resize(crop(img, image.Rect(x, y, x+cropW, y+cropH)), width, height)
+}
+
+// crop returns the region r of img.
+func crop(img image.Image, r image.Rectangle) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+// remap builds a w×h image whose pixel (x, y) is taken from src at the
+// position returned by at.
+func remap(src *image.NRGBA, w, h int, at func(x, y int) (int, int)) *image.NRGBA {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			sx, sy := at(x, y)
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
+
+func flipHorizontal(img image.Image) image.Image {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	return remap(src, w, h, func(x, y int) (int, int) { return w - 1 - x, y })
+}
+
+func flipVertical(img image.Image) image.Image {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	return remap(src, w, h, func(x, y int) (int, int) { return x, h - 1 - y })
+}
+
+// rotate90 rotates clockwise by quarter turns without resampling.
+func rotate90(img image.Image, turns int) image.Image {
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	switch ((turns % 4) + 4) % 4 {
+	case 1:
+		return remap(src, h, w, func(x, y int) (int, int) { return y, h - 1 - x })
+	case 2:
+		return remap(src, w, h, func(x, y int) (int, int) { return w - 1 - x, h - 1 - y })
+	case 3:
+		return remap(src, h, w, func(x, y int) (int, int) { return w - 1 - y, x })
+	default:
+		return src
+	}
+}
+
+// rotatedSize returns the bounding box of b rotated by degrees.
+func rotatedSize(b image.Rectangle, degrees float64) (int, int) {
+	rad := degrees * math.Pi / 180
+	sin, cos := math.Abs(math.Sin(rad)), math.Abs(math.Cos(rad))
+	w, h := float64(b.Dx()), float64(b.Dy())
+	return int(math.Ceil(w*cos + h*sin - 1e-9)), int(math.Ceil(w*sin + h*cos - 1e-9))
+}
+
+// rotate rotates img clockwise by degrees. Quarter turns are exact; other
+// angles are resampled onto a canvas that fits the rotated image, with bg
+// filling the corners.
+func rotate(img image.Image, degrees float64, bg color.Color) image.Image {
+	degrees = math.Mod(degrees, 360)
+	if degrees < 0 {
+		degrees += 360
+	}
+	if q := degrees / 90; q == math.Trunc(q) {
+		return rotate90(img, int(q))
+	}
+
+	b := img.Bounds()
+	w, h := rotatedSize(b, degrees)
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	rad := degrees * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	cx, cy := float64(b.Min.X)+float64(b.Dx())/2, float64(b.Min.Y)+float64(b.Dy())/2
+	dcx, dcy := float64(w)/2, float64(h)/2
+	s2d := f64.Aff3{
+		cos, -sin, dcx - cos*cx + sin*cy,
+		sin, cos, dcy - sin*cx - cos*cy,
+	}
+	xdraw.BiLinear.Transform(dst, s2d, img, b, xdraw.Over, nil)
+	return dst
+}
+
+// applyOrientation transforms img so that an image with the given EXIF
+// orientation displays upright.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return flipHorizontal(img)
+	case 3:
+		return rotate90(img, 2)
+	case 4:
+		return flipVertical(img)
+	case 5:
+		return flipHorizontal(rotate90(img, 1))
+	case 6:
+		return rotate90(img, 1)
+	case 7:
+		return flipHorizontal(rotate90(img, 3))
+	case 8:
+		return rotate90(img, 3)
+	default:
+		return img
+	}
+}
+
+var namedColors = map[string]color.NRGBA{
+	"transparent": {},
+	"white":       {255, 255, 255, 255},
+	"black":       {0, 0, 0, 255},
+	"red":         {255, 0, 0, 255},
+	"green":       {0, 128, 0, 255},
+	"blue":        {0, 0, 255, 255},
+	"gray":        {128, 128, 128, 255},
+}
+
+var defaultTextColor = color.NRGBA{255, 255, 255, 255}
+
+// parseColor parses "#rrggbb", "#rrggbbaa" or a basic color name. Empty
+// means transparent.
+func parseColor(s string) (color.NRGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return color.NRGBA{}, nil
+	}
+	if c, ok := namedColors[s]; ok {
+		return c, nil
+	}
+	raw, err := hex.DecodeString(strings.TrimPrefix(s, "#"))
+	if err != nil || (len(raw) != 3 && len(raw) != 4) {
+		return color.NRGBA{}, fmt.Errorf("%w: color %q must be #rrggbb, #rrggbbaa or a name", ErrInvalidParameters, s)
+	}
+	c := color.NRGBA{raw[0], raw[1], raw[2], 255}
+	if len(raw) == 4 {
+		c.A = raw[3]
+	}
+	return c, nil
+}
+
+// textImage renders text in a fixed bitmap font on a transparent
+// background. It is scaled to size by watermark.
+func textImage(text string, c color.Color) image.Image {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	metrics := face.Metrics()
+	dst := image.NewNRGBA(image.Rect(0, 0, max(width, 1), (metrics.Ascent + metrics.Descent).Ceil()))
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.Point26_6{Y: metrics.Ascent},
+	}
+	d.DrawString(text)
+	return dst
+}
+
+// watermark composites mark onto img at a position, scaled to a fraction
+// of the image width and blended with the given opacity.
+func watermark(img, mark image.Image, position string, opacity, scale float64) (image.Image, error) {
+	// Draw into a copy so the decoded source is never modified.
+	b := image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy())
+	dst := image.NewNRGBA(b)
+	draw.Draw(dst, b, img, img.Bounds().Min, draw.Src)
+	mb := mark.Bounds()
+
+	w := max(1, int(float64(b.Dx())*scale))
+	h := max(1, int(math.Round(float64(mb.Dy())*float64(w)/float64(mb.Dx()))))
+	if h > b.Dy() {
+		h = b.Dy()
+		w = max(1, int(math.Round(float64(mb.Dx())*float64(h)/float64(mb.Dy()))))
+	}
+	scaled := resize(mark, w, h)
+
+	margin := min(b.Dx(), b.Dy()) / 50
+	var at image.Point
+	switch position {
+	case "top-left":
+		at = image.Pt(margin, margin)
+	case "top-right":
+		at = image.Pt(b.Dx()-w-margin, margin)
+	case "bottom-left":
+		at = image.Pt(margin, b.Dy()-h-margin)
+	case "bottom-right":
+		at = image.Pt(b.Dx()-w-margin, b.Dy()-h-margin)
+	case "center":
+		at = image.Pt((b.Dx()-w)/2, (b.Dy()-h)/2)
+	default:
+		return nil, fmt.Errorf("%w: position must be top-left, top-right, bottom-left, bottom-right or center", ErrInvalidParameters)
+	}
+
+	alpha := image.NewUniform(color.Alpha{A: uint8(math.Round(opacity * 255))})
+	draw.DrawMask(dst, image.Rectangle{Min: at, Max: at.Add(image.Pt(w, h))}, scaled, image.Point{}, alpha, image.Point{}, draw.Over)
+	return dst, nil
+}
@@ -0,0 +1,315 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// decodeManifest decodes a YAML or JSON manifest with one or more
+// documents. List kinds are expanded into their items.
+func decodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	var objs []*unstructured.Unstructured
+	for {
+		var doc json.RawMessage
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 || bytes.Equal(bytes.TrimSpace(doc), []byte("null")) {
+			continue
+		}
+		// Unstructured decoding keeps integers as int64, as the API does.
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(doc); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+		}
+		if obj.IsList() {
+			list, err := obj.ToList()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+			}
+			for i := range list.Items {
+				objs = append(objs, &list.Items[i])
+			}
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("%w: no objects", ErrInvalidManifest)
+	}
+	for i, obj := range objs {
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" || obj.GetName() == "" {
+			return nil, fmt.Errorf("%w: object %d needs apiVersion, kind and metadata.name", ErrInvalidManifest, i)
+		}
+	}
+	return objs, nil
+}
+
+// diffable strips server-populated fields so diffs show only meaningful
+// changes.
+func diffable(obj *unstructured.Unstructured) map[string]any {
+	if obj == nil {
+		return nil
+	}
+	out := obj.DeepCopy().Object
+	for _, field := range [][]string{
+		{"metadata", "managedFields"},
+		{"metadata", "resourceVersion"},
+		{"metadata", "generation"},
+		{"metadata", "uid"},
+		{"metadata", "creationTimestamp"},
+		{"metadata", "annotations", lastAppliedAnnotation},
+		{"status"},
+	} {
+		unstructured.RemoveNestedField(out, field...)
+	}
+	if annotations, ok, _ := unstructured.NestedMap(out, "metadata", "annotations"); ok && len(annotations) == 0 {
+		unstructured.RemoveNestedField(out, "metadata", "annotations")
+	}
+	return out
+}
+
+// redactSecretDiff replaces Secret values in both sides of a diff, marking
+// values that changed so the diff stays informative without revealing them.
+func redactSecretDiff(before, after map[string]any) {
+	for _, field := range []string{"data", "stringData"} {
+		old, _, _ := unstructured.NestedMap(before, field)
+		cur, _, _ := unstructured.NestedMap(after, field)
+		for key, value := range cur {
+			if prev, ok := old[key]; ok && prev == value {
+				cur[key] = redacted
+			} else {
+				cur[key] = redacted + " (changed)"
+			}
+		}
+		for key := range old {
+			old[key] = redacted
+		}
+		if old != nil {
+			_ = unstructured.SetNestedMap(before, old, field)
+		}
+		if cur != nil {
+			_ = unstructured.SetNestedMap(after, cur, field)
+		}
+	}
+}
+
+// unifiedDiff renders a unified YAML diff between two object states.
+func (p *k8sPack) unifiedDiff(name string, before, after *unstructured.Unstructured) (string, error) {
+	b, a := diffable(before), diffable(after)
+	if after != nil && after.GetKind() == "Secret" && !p.cfg.RevealSecrets {
+		redactSecretDiff(b, a)
+	}
+	toLines := func(obj map[string]any) ([]string, error) {
+		if obj == nil {
+			return nil, nil
+		}
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		return difflib.SplitLines(string(data)), nil
+	}
+	from, err := toLines(b)
+	if err != nil {
+		return "", err
+	}
+	to, err := toLines(a)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        from,
+		B:        to,
+		FromFile: "live/" + name,
+		ToFile:   "applied/" + name,
+		Context:  3,
+	})
+}
+
+// applyParams is the input of k8s_apply and k8s_diff.
+type applyParams struct {
+	Manifest  string `json:"manifest"`
+	Namespace string `json:"namespace,omitempty"`
+	Force     bool   `json:"force,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+}
+
+// apply server-side applies every object in the manifest and returns a
+// per-object result with a diff against the live state.
+func (p *k8sPack) apply(ctx context.Context, in applyParams) (tool.Result, error) {
+	results, changed, err := p.applyObjects(ctx, in)
+	if err != nil {
+		return tool.Result{}, err
+	}
+	output, _ := json.Marshal(map[string]any{
+		"dry_run": in.DryRun,
+		"objects": results,
+		"changed": changed,
+	})
+	return tool.Result{Output: output}, nil
+}
+
+// applyObjects applies the manifest's objects and returns their results and
+// the number that changed. All objects are resolved and checked against the
+// namespace policy before any is sent.
+func (p *k8sPack) applyObjects(ctx context.Context, in applyParams) ([]map[string]any, int, error) {
+	objs, err := decodeManifest(in.Manifest)
+	if err != nil {
+		return nil, 0, err
+	}
+	c, err := p.client()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	targets := make([]target, len(objs))
+	for i, obj := range objs {
+		t, err := p.targetFor(c, obj.GroupVersionKind(), firstNonEmpty(obj.GetNamespace(), in.Namespace), false, true)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s/%s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		if in.Namespace != "" && obj.GetNamespace() != "" && obj.GetNamespace() != in.Namespace {
+			return nil, 0, fmt.Errorf("%w: %s/%s is in namespace %s, not %s", ErrInvalidParameters, obj.GetKind(), obj.GetName(), obj.GetNamespace(), in.Namespace)
+		}
+		if t.namespaced {
+			obj.SetNamespace(t.namespace)
+		}
+		targets[i] = t
+	}
+
+	opts := metav1.ApplyOptions{FieldManager: p.cfg.FieldManager, Force: in.Force}
+	if in.DryRun {
+		opts.DryRun = []string{metav1.DryRunAll}
+	}
+
+	results := make([]map[string]any, 0, len(objs))
+	changed := 0
+	for i, obj := range objs {
+		ri := targets[i].resourceInterface(c)
+		name := strings.ToLower(obj.GetKind()) + "/" + obj.GetName()
+
+		live, err := ri.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			live = nil
+		} else if err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", name, err)
+		}
+
+		applied, err := ri.Apply(ctx, obj.GetName(), obj, opts)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: apply failed: %w", name, err)
+		}
+		diff, err := p.unifiedDiff(name, live, applied)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		action := "configured"
+		switch {
+		case live == nil:
+			action = "created"
+		case diff == "":
+			action = "unchanged"
+		}
+		if action != "unchanged" {
+			changed++
+		}
+		result := map[string]any{
+			"kind":   obj.GetKind(),
+			"name":   obj.GetName(),
+			"action": action,
+			"diff":   diff,
+		}
+		if targets[i].namespaced {
+			result["namespace"] = obj.GetNamespace()
+		}
+		results = append(results, result)
+	}
+
+	return results, changed, nil
+}
+
+// previewApply server-side dry-runs a k8s_apply call and renders the diff
+// per object, so approvers see what the call would change before it runs.
+func (p *k8sPack) previewApply(ctx context.Context, input json.RawMessage) (string, error) {
+	var in applyParams
+	if err := json.Unmarshal(input, &in); err != nil {
+		return "", err
+	}
+	if in.DryRun {
+		return "", nil
+	}
+	in.DryRun = true
+	results, changed, err := p.applyObjects(ctx, in)
+	if err != nil {
+		return "", fmt.Errorf("dry-run: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Server-side dry-run: %d of %d objects change.\n", changed, len(results))
+	for _, result := range results {
+		fmt.Fprintf(&b, "\n%s/%s %s\n", strings.ToLower(result["kind"].(string)), result["name"], result["action"])
+		b.WriteString(result["diff"].(string))
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (p *k8sPack) k8sDiff() tool.Tool {
+	return tool.NewBuilder("k8s_diff").
+		WithDescription("Server-side dry-run apply a manifest and return a diff against the live objects, without changing the cluster").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in applyParams
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			in.DryRun = true
+			return p.apply(ctx, in)
+		}).
+		MustBuild()
+}
+
+func (p *k8sPack) k8sApply() tool.Tool {
+	return tool.NewBuilder("k8s_apply").
+		WithDescription("Server-side apply a manifest to the Kubernetes cluster; returns a diff per object, and with dry_run changes nothing").
+		Idempotent().
+		WithRiskLevel(tool.RiskHigh).
+		RequiresApproval().
+		WithPreview(p.previewApply).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in applyParams
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			return p.apply(ctx, in)
+		}).
+		MustBuild()
+}
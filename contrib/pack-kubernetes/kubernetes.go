@@ -3,108 +3,598 @@
 // This pack includes tools for Kubernetes cluster management:
 //   - k8s_get: Get a Kubernetes resource
 //   - k8s_list: List Kubernetes resources
+//   - k8s_diff: Server-side dry-run a manifest and diff it against the cluster
 //   - k8s_apply: Apply a manifest to the cluster
 //   - k8s_delete: Delete a Kubernetes resource
 //   - k8s_logs: Get pod logs
 //   - k8s_exec: Execute a command in a pod
 //   - k8s_scale: Scale a deployment or statefulset
-//   - k8s_rollout: Manage rollouts (status, restart, undo)
+//   - k8s_rollout: Manage rollouts (status, history, restart, undo)
 //   - k8s_port_forward: Forward a local port to a pod
 //
-// Supports kubeconfig-based authentication and in-cluster config.
+// Resources are addressed generically through client-go dynamic clients and
+// a discovery-backed REST mapper, so custom resources work like built-in
+// ones. Manifests are applied with server-side apply. Before k8s_apply runs,
+// a server-side dry-run diff against the live objects is added to the
+// approval request, so approvers see what will change. Port forwarding
+// exposes pod ports locally and, like exec, requires approval.
+// Namespace allow and deny lists restrict every operation, writes to
+// cluster-scoped resources are opt-in, and Secret values are redacted.
+//
+// Supports kubeconfig-based authentication and in-cluster config. Tests can
+// inject the fake dynamic and typed clientsets through Config.
 package kubernetes
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
-// Pack returns the Kubernetes tools pack.
-func Pack() *pack.Pack {
+// Errors returned by the Kubernetes tools.
+var (
+	// ErrNamespaceNotAllowed indicates the namespace is denied or not allowlisted.
+	ErrNamespaceNotAllowed = errors.New("namespace not allowed")
+
+	// ErrClusterScoped indicates a write to a cluster-scoped resource without AllowClusterScoped.
+	ErrClusterScoped = errors.New("cluster-scoped resources are not writable")
+
+	// ErrUnknownResource indicates the resource type could not be resolved.
+	ErrUnknownResource = errors.New("unknown resource type")
+
+	// ErrInvalidManifest indicates a manifest could not be decoded.
+	ErrInvalidManifest = errors.New("invalid manifest")
+
+	// ErrInvalidParameters indicates missing or invalid tool input.
+	ErrInvalidParameters = errors.New("invalid parameters")
+
+	// ErrNotSupported indicates an operation unavailable with the configured clients.
+	ErrNotSupported = errors.New("not supported")
+)
+
+// Config configures the Kubernetes pack.
+type Config struct {
+	// Kubeconfig is the kubeconfig path. Empty uses the default loading
+	// rules ($KUBECONFIG, ~/.kube/config) and falls back to in-cluster config.
+	Kubeconfig string
+
+	// Context selects a kubeconfig context. Empty uses the current context.
+	Context string
+
+	// RESTConfig overrides kubeconfig loading.
+	RESTConfig *rest.Config
+
+	// Dynamic, Clientset and Mapper override the clients built from the
+	// REST config. Tests set all three to the client-go fakes; exec and
+	// port forwarding additionally need RESTConfig.
+	Dynamic   dynamic.Interface
+	Clientset kubernetes.Interface
+	Mapper    meta.RESTMapper
+
+	// AllowedNamespaces lists namespace patterns that may be accessed, such
+	// as "staging" or "team-*". Empty allows any namespace that is not denied.
+	AllowedNamespaces []string
+
+	// DeniedNamespaces lists namespace patterns that are always rejected.
+	DeniedNamespaces []string
+
+	// AllowClusterScoped permits writes to cluster-scoped resources such as
+	// namespaces, nodes and cluster roles. Reads are always permitted.
+	AllowClusterScoped bool
+
+	// DefaultNamespace is used when input omits the namespace. Defaults to
+	// the kubeconfig context namespace, or "default".
+	DefaultNamespace string
+
+	// FieldManager is the server-side apply field manager. Defaults to "agent-go".
+	FieldManager string
+
+	// RevealSecrets returns Secret data instead of redacting it.
+	RevealSecrets bool
+
+	// MaxListItems caps items returned by k8s_list. Defaults to 200.
+	MaxListItems int64
+
+	// MaxOutputBytes caps log and exec output. Defaults to 256 KiB.
+	MaxOutputBytes int64
+
+	// ExecTimeout is the default k8s_exec timeout. Defaults to 60 seconds.
+	ExecTimeout time.Duration
+
+	// MaxPortForward caps how long a port forward stays open. Defaults to 10 minutes.
+	MaxPortForward time.Duration
+}
+
+// Pack returns the Kubernetes tools pack. Clients are created on first use,
+// so building the pack never contacts the cluster.
+func Pack(cfg Config) *pack.Pack {
+	p := newK8sPack(cfg)
+
 	return pack.NewBuilder("kubernetes").
 		WithDescription("Kubernetes cluster management tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			k8sGet(),
-			k8sList(),
-			k8sApply(),
-			k8sDelete(),
-			k8sLogs(),
-			k8sExec(),
-			k8sScale(),
-			k8sRollout(),
-			k8sPortForward(),
+			p.k8sGet(),
+			p.k8sList(),
+			p.k8sDiff(),
+			p.k8sApply(),
+			p.k8sDelete(),
+			p.k8sLogs(),
+			p.k8sExec(),
+			p.k8sScale(),
+			p.k8sRollout(),
+			p.k8sPortForward(),
 		).
-		AllowInState(agent.StateExplore, "k8s_get", "k8s_list", "k8s_logs").
-		AllowInState(agent.StateAct, "k8s_get", "k8s_list", "k8s_apply", "k8s_delete", "k8s_logs", "k8s_exec", "k8s_scale", "k8s_rollout", "k8s_port_forward").
-		AllowInState(agent.StateValidate, "k8s_get", "k8s_list", "k8s_logs").
+		AllowInState(agent.StateExplore, "k8s_get", "k8s_list", "k8s_diff", "k8s_logs").
+		AllowInState(agent.StateAct, "k8s_get", "k8s_list", "k8s_diff", "k8s_apply", "k8s_delete", "k8s_logs", "k8s_exec", "k8s_scale", "k8s_rollout", "k8s_port_forward").
+		AllowInState(agent.StateValidate, "k8s_get", "k8s_list", "k8s_diff", "k8s_logs").
 		Build()
 }
 
-func k8sGet() tool.Tool {
-	return tool.NewBuilder("k8s_get").
-		WithDescription("Get a Kubernetes resource by name").
-		ReadOnly().
-		MustBuild()
+// clients bundles the API clients used by the tools.
+type clients struct {
+	dynamic   dynamic.Interface
+	typed     kubernetes.Interface
+	mapper    meta.RESTMapper
+	rest      *rest.Config
+	namespace string
 }
 
-func k8sList() tool.Tool {
-	return tool.NewBuilder("k8s_list").
-		WithDescription("List Kubernetes resources with optional label selector").
-		ReadOnly().
-		Cacheable().
-		MustBuild()
+type k8sPack struct {
+	cfg Config
+
+	once    sync.Once
+	clients *clients
+	err     error
 }
 
-func k8sApply() tool.Tool {
-	return tool.NewBuilder("k8s_apply").
-		WithDescription("Apply a manifest to the Kubernetes cluster").
-		Idempotent().
-		WithRiskLevel(tool.RiskHigh).
-		RequiresApproval().
-		MustBuild()
+func newK8sPack(cfg Config) *k8sPack {
+	if cfg.FieldManager == "" {
+		cfg.FieldManager = "agent-go"
+	}
+	if cfg.MaxListItems <= 0 {
+		cfg.MaxListItems = 200
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = 256 << 10
+	}
+	if cfg.ExecTimeout <= 0 {
+		cfg.ExecTimeout = 60 * time.Second
+	}
+	if cfg.MaxPortForward <= 0 {
+		cfg.MaxPortForward = 10 * time.Minute
+	}
+	return &k8sPack{cfg: cfg}
 }
 
-func k8sDelete() tool.Tool {
-	return tool.NewBuilder("k8s_delete").
-		WithDescription("Delete a Kubernetes resource").
-		Destructive().
-		MustBuild()
+// client returns the API clients, loading configuration on first use.
+func (p *k8sPack) client() (*clients, error) {
+	p.once.Do(func() {
+		p.clients, p.err = p.connect()
+	})
+	return p.clients, p.err
 }
 
-func k8sLogs() tool.Tool {
-	return tool.NewBuilder("k8s_logs").
-		WithDescription("Get logs from a pod").
-		ReadOnly().
-		MustBuild()
+func (p *k8sPack) connect() (*clients, error) {
+	c := &clients{
+		dynamic:   p.cfg.Dynamic,
+		typed:     p.cfg.Clientset,
+		mapper:    p.cfg.Mapper,
+		rest:      p.cfg.RESTConfig,
+		namespace: p.cfg.DefaultNamespace,
+	}
+
+	needREST := c.dynamic == nil || c.typed == nil || c.mapper == nil
+	if c.rest == nil && needREST {
+		loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: p.cfg.Kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: p.cfg.Context},
+		)
+		restConfig, err := loader.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubernetes config: %w", err)
+		}
+		c.rest = restConfig
+		if c.namespace == "" {
+			c.namespace, _, _ = loader.Namespace()
+		}
+	}
+	if c.namespace == "" {
+		c.namespace = metav1.NamespaceDefault
+	}
+
+	var err error
+	if c.dynamic == nil {
+		if c.dynamic, err = dynamic.NewForConfig(c.rest); err != nil {
+			return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+		}
+	}
+	if c.typed == nil {
+		if c.typed, err = kubernetes.NewForConfig(c.rest); err != nil {
+			return nil, fmt.Errorf("failed to create clientset: %w", err)
+		}
+	}
+	if c.mapper == nil {
+		cached := memory.NewMemCacheClient(c.typed.Discovery())
+		c.mapper = restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached, nil)
+	}
+	return c, nil
 }
 
-func k8sExec() tool.Tool {
-	return tool.NewBuilder("k8s_exec").
-		WithDescription("Execute a command in a pod container").
-		WithRiskLevel(tool.RiskHigh).
-		RequiresApproval().
-		MustBuild()
+// ============================================================================
+// Namespace Policy
+// ============================================================================
+
+// matchNamespace reports whether ns matches any of the patterns.
+func matchNamespace(patterns []string, ns string) bool {
+	for _, pattern := range patterns {
+		if pattern == ns {
+			return true
+		}
+		if ok, _ := path.Match(pattern, ns); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceAllowed applies the namespace allow and deny lists.
+func (p *k8sPack) namespaceAllowed(ns string) bool {
+	if matchNamespace(p.cfg.DeniedNamespaces, ns) {
+		return false
+	}
+	return len(p.cfg.AllowedNamespaces) == 0 || matchNamespace(p.cfg.AllowedNamespaces, ns)
+}
+
+func (p *k8sPack) checkNamespace(ns string) error {
+	if !p.namespaceAllowed(ns) {
+		return fmt.Errorf("%w: %s", ErrNamespaceNotAllowed, ns)
+	}
+	return nil
+}
+
+// ============================================================================
+// Resource Resolution
+// ============================================================================
+
+// target is a resolved resource type and namespace.
+type target struct {
+	gvr        schema.GroupVersionResource
+	gvk        schema.GroupVersionKind
+	namespaced bool
+	namespace  string
+}
+
+// resourceInterface returns the dynamic client for the target.
+func (t target) resourceInterface(c *clients) dynamic.ResourceInterface {
+	if t.namespaced {
+		return c.dynamic.Resource(t.gvr).Namespace(t.namespace)
+	}
+	return c.dynamic.Resource(t.gvr)
 }
 
-func k8sScale() tool.Tool {
-	return tool.NewBuilder("k8s_scale").
-		WithDescription("Scale a deployment or statefulset").
-		WithRiskLevel(tool.RiskMedium).
+// resourceRef is the common input that addresses a resource type.
+type resourceRef struct {
+	// Resource is a resource or kind name such as "pods", "Deployment"
+	// or "deploy".
+	Resource string `json:"resource"`
+
+	// APIVersion disambiguates the group and version, e.g. "apps/v1".
+	APIVersion string `json:"api_version,omitempty"`
+
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// resolve maps a resource reference to a target and checks the namespace
+// policy. An empty namespace on a namespaced resource means the default
+// namespace unless allNamespaces is set. write additionally requires
+// AllowClusterScoped for cluster-scoped resources.
+func (p *k8sPack) resolve(c *clients, ref resourceRef, allNamespaces, write bool) (target, error) {
+	if ref.Resource == "" {
+		return target{}, fmt.Errorf("%w: resource is required", ErrInvalidParameters)
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return target{}, fmt.Errorf("%w: api_version %q: %v", ErrInvalidParameters, ref.APIVersion, err)
+	}
+	gvr, err := c.mapper.ResourceFor(gv.WithResource(strings.ToLower(ref.Resource)))
+	if err != nil {
+		return target{}, fmt.Errorf("%w: %s: %v", ErrUnknownResource, ref.Resource, err)
+	}
+	gvk, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return target{}, fmt.Errorf("%w: %s: %v", ErrUnknownResource, ref.Resource, err)
+	}
+	return p.targetFor(c, gvk, ref.Namespace, allNamespaces, write)
+}
+
+// targetFor resolves a kind and applies the namespace policy.
+func (p *k8sPack) targetFor(c *clients, gvk schema.GroupVersionKind, namespace string, allNamespaces, write bool) (target, error) {
+	mapping, err := c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return target{}, fmt.Errorf("%w: %s: %v", ErrUnknownResource, gvk, err)
+	}
+	t := target{
+		gvr:        mapping.Resource,
+		gvk:        mapping.GroupVersionKind,
+		namespaced: mapping.Scope.Name() == meta.RESTScopeNameNamespace,
+	}
+
+	if !t.namespaced {
+		if write && !p.cfg.AllowClusterScoped {
+			return target{}, fmt.Errorf("%w: %s", ErrClusterScoped, t.gvk.Kind)
+		}
+		return t, nil
+	}
+	t.namespace = namespace
+	if t.namespace == "" && !allNamespaces {
+		t.namespace = c.namespace
+	}
+	if t.namespace != "" {
+		if err := p.checkNamespace(t.namespace); err != nil {
+			return target{}, err
+		}
+	}
+	return t, nil
+}
+
+// ============================================================================
+// Output
+// ============================================================================
+
+const redacted = "<redacted>"
+
+// clean prepares an object for output: it drops managed fields and
+// redacts Secret values unless RevealSecrets is set.
+func (p *k8sPack) clean(obj *unstructured.Unstructured) map[string]any {
+	out := obj.DeepCopy()
+	unstructured.RemoveNestedField(out.Object, "metadata", "managedFields")
+	if out.GetKind() == "Secret" && out.GroupVersionKind().Group == "" && !p.cfg.RevealSecrets {
+		for _, field := range []string{"data", "stringData"} {
+			if values, ok, _ := unstructured.NestedMap(out.Object, field); ok {
+				for key := range values {
+					values[key] = redacted
+				}
+				_ = unstructured.SetNestedMap(out.Object, values, field)
+			}
+		}
+		annotations := out.GetAnnotations()
+		if _, ok := annotations[lastAppliedAnnotation]; ok {
+			annotations[lastAppliedAnnotation] = redacted
+			out.SetAnnotations(annotations)
+		}
+	}
+	return out.Object
+}
+
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// summarize returns the fields of an object shown in list output.
+func summarize(obj *unstructured.Unstructured) map[string]any {
+	s := map[string]any{
+		"name":    obj.GetName(),
+		"kind":    obj.GetKind(),
+		"created": obj.GetCreationTimestamp().UTC().Format(time.RFC3339),
+	}
+	if ns := obj.GetNamespace(); ns != "" {
+		s["namespace"] = ns
+	}
+	if labels := obj.GetLabels(); len(labels) > 0 {
+		s["labels"] = labels
+	}
+	if phase, ok, _ := unstructured.NestedString(obj.Object, "status", "phase"); ok {
+		s["phase"] = phase
+	}
+	if replicas, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); ok {
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		s["replicas"] = replicas
+		s["ready_replicas"] = ready
+	}
+	return s
+}
+
+// ============================================================================
+// Get, List and Delete
+// ============================================================================
+
+func (p *k8sPack) k8sGet() tool.Tool {
+	return tool.NewBuilder("k8s_get").
+		WithDescription("Get a Kubernetes resource by name").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				resourceRef
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Name == "" {
+				return tool.Result{}, fmt.Errorf("%w: name is required", ErrInvalidParameters)
+			}
+
+			c, err := p.client()
+			if err != nil {
+				return tool.Result{}, err
+			}
+			t, err := p.resolve(c, in.resourceRef, false, false)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			obj, err := t.resourceInterface(c).Get(ctx, in.Name, metav1.GetOptions{})
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			output, _ := json.Marshal(map[string]any{"object": p.clean(obj)})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func k8sRollout() tool.Tool {
-	return tool.NewBuilder("k8s_rollout").
-		WithDescription("Manage rollouts: status, restart, or undo").
-		WithRiskLevel(tool.RiskMedium).
+func (p *k8sPack) k8sList() tool.Tool {
+	return tool.NewBuilder("k8s_list").
+		WithDescription("List Kubernetes resources with optional label and field selectors").
+		ReadOnly().
+		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				resourceRef
+				AllNamespaces bool   `json:"all_namespaces,omitempty"`
+				LabelSelector string `json:"label_selector,omitempty"`
+				FieldSelector string `json:"field_selector,omitempty"`
+				Limit         int64  `json:"limit,omitempty"`
+				Continue      string `json:"continue,omitempty"`
+				Full          bool   `json:"full,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.AllNamespaces && in.Namespace != "" {
+				return tool.Result{}, fmt.Errorf("%w: namespace and all_namespaces are exclusive", ErrInvalidParameters)
+			}
+			if in.Limit <= 0 || in.Limit > p.cfg.MaxListItems {
+				in.Limit = p.cfg.MaxListItems
+			}
+
+			c, err := p.client()
+			if err != nil {
+				return tool.Result{}, err
+			}
+			t, err := p.resolve(c, in.resourceRef, in.AllNamespaces, false)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			list, err := t.resourceInterface(c).List(ctx, metav1.ListOptions{
+				LabelSelector: in.LabelSelector,
+				FieldSelector: in.FieldSelector,
+				Limit:         in.Limit,
+				Continue:      in.Continue,
+			})
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			items := make([]map[string]any, 0, len(list.Items))
+			filtered := 0
+			for i := range list.Items {
+				obj := &list.Items[i]
+				// Listing across namespaces returns everything the
+				// credentials can see; drop what the policy forbids.
+				if t.namespaced && !p.namespaceAllowed(obj.GetNamespace()) {
+					filtered++
+					continue
+				}
+				if in.Full {
+					items = append(items, p.clean(obj))
+				} else {
+					items = append(items, summarize(obj))
+				}
+			}
+			sort.SliceStable(items, func(i, j int) bool {
+				ni, _ := items[i]["namespace"].(string)
+				nj, _ := items[j]["namespace"].(string)
+				if ni != nj {
+					return ni < nj
+				}
+				return fmt.Sprint(items[i]["name"]) < fmt.Sprint(items[j]["name"])
+			})
+
+			out := map[string]any{
+				"kind":  t.gvk.Kind,
+				"items": items,
+				"count": len(items),
+			}
+			if next := list.GetContinue(); next != "" {
+				out["continue"] = next
+			}
+			if filtered > 0 {
+				out["filtered"] = filtered
+			}
+			output, _ := json.Marshal(out)
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func k8sPortForward() tool.Tool {
-	return tool.NewBuilder("k8s_port_forward").
-		WithDescription("Forward a local port to a pod").
-		WithRiskLevel(tool.RiskLow).
+func (p *k8sPack) k8sDelete() tool.Tool {
+	return tool.NewBuilder("k8s_delete").
+		WithDescription("Delete a Kubernetes resource, optionally as a server-side dry-run").
+		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				resourceRef
+				Name               string `json:"name"`
+				Propagation        string `json:"propagation,omitempty"`
+				GracePeriodSeconds *int64 `json:"grace_period_seconds,omitempty"`
+				DryRun             bool   `json:"dry_run,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Name == "" {
+				return tool.Result{}, fmt.Errorf("%w: name is required", ErrInvalidParameters)
+			}
+			var propagation metav1.DeletionPropagation
+			switch strings.ToLower(in.Propagation) {
+			case "", "background":
+				propagation = metav1.DeletePropagationBackground
+			case "foreground":
+				propagation = metav1.DeletePropagationForeground
+			case "orphan":
+				propagation = metav1.DeletePropagationOrphan
+			default:
+				return tool.Result{}, fmt.Errorf("%w: propagation must be background, foreground or orphan", ErrInvalidParameters)
+			}
+
+			c, err := p.client()
+			if err != nil {
+				return tool.Result{}, err
+			}
+			t, err := p.resolve(c, in.resourceRef, false, true)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			opts := metav1.DeleteOptions{
+				PropagationPolicy:  &propagation,
+				GracePeriodSeconds: in.GracePeriodSeconds,
+			}
+			if in.DryRun {
+				opts.DryRun = []string{metav1.DryRunAll}
+			}
+			if err := t.resourceInterface(c).Delete(ctx, in.Name, opts); err != nil {
+				return tool.Result{}, err
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"deleted":   !in.DryRun,
+				"dry_run":   in.DryRun,
+				"kind":      t.gvk.Kind,
+				"name":      in.Name,
+				"namespace": t.namespace,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
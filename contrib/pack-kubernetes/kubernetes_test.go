@@ -0,0 +1,411 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+var (
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMapsGVR  = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+func testMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, gvk := range []schema.GroupVersionKind{
+		{Version: "v1", Kind: "Pod"},
+		{Version: "v1", Kind: "ConfigMap"},
+		{Version: "v1", Kind: "Secret"},
+		{Group: "apps", Version: "v1", Kind: "Deployment"},
+		{Group: "apps", Version: "v1", Kind: "ReplicaSet"},
+	} {
+		mapper.Add(gvk, meta.RESTScopeNamespace)
+	}
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	return mapper
+}
+
+func object(apiVersion, kind, namespace, name string, fields map[string]any) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]any{}}
+	for k, v := range fields {
+		obj.Object[k] = v
+	}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// applyReactor emulates server-side apply on the fake dynamic client, which
+// otherwise ignores dry-run and cannot create objects through apply. Fields
+// are merged recursively, which is enough for these tests.
+func applyReactor(client *dynamicfake.FakeDynamicClient) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, ok := action.(k8stesting.PatchActionImpl)
+		if !ok || patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		applied := &unstructured.Unstructured{}
+		if err := applied.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		tracker := client.Tracker()
+		existing, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName())
+		if err != nil {
+			applied.SetUID(types.UID("uid-" + patch.GetName()))
+			if len(patch.PatchOptions.DryRun) > 0 {
+				return true, applied, nil
+			}
+			return true, applied, tracker.Create(patch.GetResource(), applied, patch.GetNamespace())
+		}
+		merged := existing.(*unstructured.Unstructured).DeepCopy()
+		mergeMaps(merged.Object, applied.Object)
+		if len(patch.PatchOptions.DryRun) == 0 {
+			err = tracker.Update(patch.GetResource(), merged, patch.GetNamespace())
+		}
+		return true, merged, err
+	}
+}
+
+func mergeMaps(dst, src map[string]any) {
+	for k, v := range src {
+		if sm, ok := v.(map[string]any); ok {
+			if dm, ok := dst[k].(map[string]any); ok {
+				mergeMaps(dm, sm)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
+
+func newTestPack(t *testing.T, cfg Config, objects ...runtime.Object) (*pack.Pack, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	client := dynamicfake.NewSimpleDynamicClient(scheme.Scheme, objects...)
+	client.PrependReactor("patch", "*", applyReactor(client))
+	cfg.Dynamic = client
+	cfg.Clientset = fake.NewClientset()
+	cfg.Mapper = testMapper()
+	return Pack(cfg), client
+}
+
+func deployment(namespace, name string, replicas int64) *unstructured.Unstructured {
+	return object("apps/v1", "Deployment", namespace, name, map[string]any{
+		"spec": map[string]any{
+			"replicas": replicas,
+			"template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "app", "image": "app:1"},
+			}}},
+		},
+	})
+}
+
+func TestGetAndNamespacePolicy(t *testing.T) {
+	t.Parallel()
+
+	secret := object("v1", "Secret", "staging", "db", map[string]any{"data": map[string]any{"password": "c2VjcmV0"}})
+	p, _ := newTestPack(t, Config{AllowedNamespaces: []string{"staging", "team-*"}, DeniedNamespaces: []string{"team-secret"}},
+		object("v1", "ConfigMap", "staging", "settings", map[string]any{"data": map[string]any{"mode": "fast"}}),
+		object("v1", "ConfigMap", "team-a", "settings", nil),
+		object("v1", "ConfigMap", "team-secret", "settings", nil),
+		object("v1", "ConfigMap", "prod", "settings", nil),
+		secret,
+	)
+
+	out, err := call(p, "k8s_get", map[string]any{"resource": "configmaps", "namespace": "staging", "name": "settings"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := out["object"].(map[string]any)["data"].(map[string]any)
+	if data["mode"] != "fast" {
+		t.Errorf("unexpected object: %v", out)
+	}
+
+	out, err = call(p, "k8s_get", map[string]any{"resource": "Secret", "namespace": "staging", "name": "db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out["object"].(map[string]any)["data"].(map[string]any)["password"]; got != redacted {
+		t.Errorf("secret value not redacted: %v", got)
+	}
+
+	for _, ns := range []string{"prod", "team-secret"} {
+		if _, err := call(p, "k8s_get", map[string]any{"resource": "configmap", "namespace": ns, "name": "settings"}); !errors.Is(err, ErrNamespaceNotAllowed) {
+			t.Errorf("%s: expected ErrNamespaceNotAllowed, got %v", ns, err)
+		}
+	}
+	// The default namespace is not allowlisted either.
+	if _, err := call(p, "k8s_get", map[string]any{"resource": "configmaps", "name": "settings"}); !errors.Is(err, ErrNamespaceNotAllowed) {
+		t.Errorf("expected ErrNamespaceNotAllowed for default namespace, got %v", err)
+	}
+
+	out, err = call(p, "k8s_list", map[string]any{"resource": "configmaps", "all_namespaces": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := out["items"].([]any)
+	if len(items) != 2 || out["filtered"].(float64) != 2 {
+		t.Fatalf("list = %v", out)
+	}
+	if ns := items[0].(map[string]any)["namespace"]; ns != "staging" {
+		t.Errorf("first item namespace = %v", ns)
+	}
+
+	if _, err := call(p, "k8s_get", map[string]any{"resource": "widgets", "name": "x"}); !errors.Is(err, ErrUnknownResource) {
+		t.Errorf("expected ErrUnknownResource, got %v", err)
+	}
+}
+
+const manifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  mode: fast
+`
+
+func TestDiffAndApply(t *testing.T) {
+	t.Parallel()
+
+	p, client := newTestPack(t, Config{DefaultNamespace: "staging"}, deployment("staging", "web", 1))
+	ctx := context.Background()
+
+	out, err := call(p, "k8s_diff", map[string]any{"manifest": manifest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := out["objects"].([]any)
+	web, cm := objects[0].(map[string]any), objects[1].(map[string]any)
+	if web["action"] != "configured" || cm["action"] != "created" {
+		t.Fatalf("actions = %v, %v", web["action"], cm["action"])
+	}
+	diff := web["diff"].(string)
+	if !strings.Contains(diff, "-  replicas: 1") || !strings.Contains(diff, "+  replicas: 3") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+	if !strings.Contains(cm["diff"].(string), "+  mode: fast") {
+		t.Errorf("unexpected diff:\n%s", cm["diff"])
+	}
+
+	// The dry-run left the cluster untouched.
+	live, err := client.Resource(deploymentsGVR).Namespace("staging").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); replicas != 1 {
+		t.Errorf("dry-run changed replicas to %d", replicas)
+	}
+	if _, err := client.Resource(configMapsGVR).Namespace("staging").Get(ctx, "web-config", metav1.GetOptions{}); err == nil {
+		t.Error("dry-run created the config map")
+	}
+
+	// Approvers see the dry-run diff before the apply runs.
+	apply, _ := p.GetTool("k8s_apply")
+	raw, _ := json.Marshal(map[string]any{"manifest": manifest})
+	preview, err := apply.(tool.Previewer).Preview(ctx, raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(preview, "deployment/web configured") || !strings.Contains(preview, "+  replicas: 3") {
+		t.Errorf("unexpected preview:\n%s", preview)
+	}
+	live, _ = client.Resource(deploymentsGVR).Namespace("staging").Get(ctx, "web", metav1.GetOptions{})
+	if replicas, _, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); replicas != 1 {
+		t.Errorf("preview changed replicas to %d", replicas)
+	}
+
+	if _, err := call(p, "k8s_apply", map[string]any{"manifest": manifest}); err != nil {
+		t.Fatal(err)
+	}
+	live, _ = client.Resource(deploymentsGVR).Namespace("staging").Get(ctx, "web", metav1.GetOptions{})
+	if replicas, _, _ := unstructured.NestedInt64(live.Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("replicas = %d after apply", replicas)
+	}
+
+	out, err = call(p, "k8s_apply", map[string]any{"manifest": manifest})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["changed"].(float64) != 0 {
+		t.Errorf("reapply changed %v objects", out["changed"])
+	}
+}
+
+func TestApplyPolicy(t *testing.T) {
+	t.Parallel()
+
+	p, client := newTestPack(t, Config{AllowedNamespaces: []string{"staging"}})
+
+	// One object in a denied namespace rejects the whole manifest.
+	mixed := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: staging\n---\n" +
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n  namespace: prod\n"
+	if _, err := call(p, "k8s_apply", map[string]any{"manifest": mixed}); !errors.Is(err, ErrNamespaceNotAllowed) {
+		t.Errorf("expected ErrNamespaceNotAllowed, got %v", err)
+	}
+	if list, _ := client.Resource(configMapsGVR).Namespace("staging").List(context.Background(), metav1.ListOptions{}); len(list.Items) != 0 {
+		t.Error("partial apply")
+	}
+
+	ns := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: sandbox\n"
+	if _, err := call(p, "k8s_apply", map[string]any{"manifest": ns}); !errors.Is(err, ErrClusterScoped) {
+		t.Errorf("expected ErrClusterScoped, got %v", err)
+	}
+	if _, err := call(p, "k8s_apply", map[string]any{"manifest": "kind: ConfigMap"}); !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("expected ErrInvalidManifest, got %v", err)
+	}
+}
+
+func TestScaleRolloutDelete(t *testing.T) {
+	t.Parallel()
+
+	web := deployment("default", "web", 2)
+	web.SetUID("web-uid")
+	owner := []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "web-uid"}}
+	var replicaSets []runtime.Object
+	for i, image := range []string{"app:1", "app:2"} {
+		rs := object("apps/v1", "ReplicaSet", "default", "web-"+image[4:], map[string]any{
+			"spec": map[string]any{"template": map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "web", "pod-template-hash": "h" + image[4:]}},
+				"spec":     map[string]any{"containers": []any{map[string]any{"name": "app", "image": image}}},
+			}},
+		})
+		rs.SetOwnerReferences(owner)
+		rs.SetAnnotations(map[string]string{revisionAnnotation: string(rune('1' + i))})
+		replicaSets = append(replicaSets, rs)
+	}
+	p, client := newTestPack(t, Config{}, append(replicaSets, web)...)
+	ctx := context.Background()
+	get := func() *unstructured.Unstructured {
+		obj, err := client.Resource(deploymentsGVR).Namespace("default").Get(ctx, "web", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	out, err := call(p, "k8s_scale", map[string]any{"name": "web", "replicas": 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["previous_replicas"].(float64) != 2 {
+		t.Errorf("scale = %v", out)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(get().Object, "spec", "replicas"); replicas != 5 {
+		t.Errorf("replicas = %d", replicas)
+	}
+
+	out, err = call(p, "k8s_rollout", map[string]any{"name": "web", "action": "status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["done"] != false || !strings.Contains(out["message"].(string), "0 out of 5") {
+		t.Errorf("status = %v", out)
+	}
+
+	if _, err := call(p, "k8s_rollout", map[string]any{"name": "web", "action": "restart"}); err != nil {
+		t.Fatal(err)
+	}
+	annotations, _, _ := unstructured.NestedStringMap(get().Object, "spec", "template", "metadata", "annotations")
+	if annotations[restartedAtAnnotation] == "" {
+		t.Error("restart annotation not set")
+	}
+
+	out, err = call(p, "k8s_rollout", map[string]any{"name": "web", "action": "history"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(out["revisions"].([]any)); n != 2 {
+		t.Fatalf("history has %d revisions", n)
+	}
+
+	out, err = call(p, "k8s_rollout", map[string]any{"name": "web", "action": "undo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["rolled_back_to"].(float64) != 1 {
+		t.Errorf("undo = %v", out)
+	}
+	containers, _, _ := unstructured.NestedSlice(get().Object, "spec", "template", "spec", "containers")
+	if image := containers[0].(map[string]any)["image"]; image != "app:1" {
+		t.Errorf("image after undo = %v", image)
+	}
+	labels, _, _ := unstructured.NestedStringMap(get().Object, "spec", "template", "metadata", "labels")
+	if _, ok := labels["pod-template-hash"]; ok {
+		t.Error("pod-template-hash copied into deployment template")
+	}
+
+	if _, err := call(p, "k8s_scale", map[string]any{"resource": "configmaps", "name": "web", "replicas": 1}); !errors.Is(err, ErrInvalidParameters) {
+		t.Errorf("expected ErrInvalidParameters, got %v", err)
+	}
+
+	if _, err := call(p, "k8s_delete", map[string]any{"resource": "deployments", "name": "web"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Resource(deploymentsGVR).Namespace("default").Get(ctx, "web", metav1.GetOptions{}); err == nil {
+		t.Error("deployment still exists")
+	}
+}
+
+func TestLogsAndExec(t *testing.T) {
+	t.Parallel()
+
+	p, _ := newTestPack(t, Config{MaxOutputBytes: 4})
+
+	out, err := call(p, "k8s_logs", map[string]any{"pod": "web-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fake clientset always returns "fake logs".
+	if out["logs"] != "fake" || out["truncated"] != true {
+		t.Errorf("logs = %v", out)
+	}
+
+	if _, err := call(p, "k8s_exec", map[string]any{"pod": "web-0", "command": []string{"ls"}}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported without a REST config, got %v", err)
+	}
+
+	for _, name := range []string{"k8s_exec", "k8s_port_forward"} {
+		tl, _ := p.GetTool(name)
+		if a := tl.Annotations(); !a.RequiresApproval || a.RiskLevel < tool.RiskHigh {
+			t.Errorf("%s annotations = %+v, want high risk with approval", name, a)
+		}
+	}
+}
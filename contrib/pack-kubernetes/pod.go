@@ -0,0 +1,286 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+	utilexec "k8s.io/client-go/util/exec"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// limitedBuffer keeps the first max bytes written and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	max       int64
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	if room := b.max - int64(b.buf.Len()); room < int64(len(data)) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(data[:room])
+		}
+		return len(data), nil
+	}
+	return b.buf.Write(data)
+}
+
+// podRef is the input that addresses a pod.
+type podRef struct {
+	Pod       string `json:"pod"`
+	Namespace string `json:"namespace,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+// pod checks a pod reference and returns the clients and namespace.
+func (p *k8sPack) pod(ref podRef) (*clients, string, error) {
+	if ref.Pod == "" {
+		return nil, "", fmt.Errorf("%w: pod is required", ErrInvalidParameters)
+	}
+	c, err := p.client()
+	if err != nil {
+		return nil, "", err
+	}
+	ns := firstNonEmpty(ref.Namespace, c.namespace)
+	if err := p.checkNamespace(ns); err != nil {
+		return nil, "", err
+	}
+	return c, ns, nil
+}
+
+func (p *k8sPack) k8sLogs() tool.Tool {
+	return tool.NewBuilder("k8s_logs").
+		WithDescription("Get logs from a pod container").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				podRef
+				TailLines    *int64 `json:"tail_lines,omitempty"`
+				SinceSeconds *int64 `json:"since_seconds,omitempty"`
+				Previous     bool   `json:"previous,omitempty"`
+				Timestamps   bool   `json:"timestamps,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			c, ns, err := p.pod(in.podRef)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			limit := p.cfg.MaxOutputBytes + 1
+			stream, err := c.typed.CoreV1().Pods(ns).GetLogs(in.Pod, &corev1.PodLogOptions{
+				Container:    in.Container,
+				TailLines:    in.TailLines,
+				SinceSeconds: in.SinceSeconds,
+				Previous:     in.Previous,
+				Timestamps:   in.Timestamps,
+				LimitBytes:   &limit,
+			}).Stream(ctx)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			defer func() { _ = stream.Close() }()
+
+			out := &limitedBuffer{max: p.cfg.MaxOutputBytes}
+			if _, err := io.Copy(out, io.LimitReader(stream, limit)); err != nil {
+				return tool.Result{}, fmt.Errorf("failed to read logs: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"pod":       in.Pod,
+				"namespace": ns,
+				"container": in.Container,
+				"logs":      strings.ToValidUTF8(out.buf.String(), "�"),
+				"truncated": out.truncated,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *k8sPack) k8sExec() tool.Tool {
+	return tool.NewBuilder("k8s_exec").
+		WithDescription("Execute a command in a pod container and return its output and exit code").
+		WithRiskLevel(tool.RiskHigh).
+		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				podRef
+				Command        []string `json:"command"`
+				TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Command) == 0 {
+				return tool.Result{}, fmt.Errorf("%w: command is required", ErrInvalidParameters)
+			}
+			c, ns, err := p.pod(in.podRef)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if c.rest == nil {
+				return tool.Result{}, fmt.Errorf("%w: exec requires a REST config", ErrNotSupported)
+			}
+
+			timeout := p.cfg.ExecTimeout
+			if in.TimeoutSeconds > 0 {
+				timeout = min(time.Duration(in.TimeoutSeconds)*time.Second, 10*p.cfg.ExecTimeout)
+			}
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			req := c.typed.CoreV1().RESTClient().Post().
+				Resource("pods").Namespace(ns).Name(in.Pod).SubResource("exec").
+				VersionedParams(&corev1.PodExecOptions{
+					Container: in.Container,
+					Command:   in.Command,
+					Stdout:    true,
+					Stderr:    true,
+				}, scheme.ParameterCodec)
+
+			// Prefer the WebSocket protocol and fall back to SPDY for older
+			// API servers, as kubectl does.
+			spdyExec, err := remotecommand.NewSPDYExecutor(c.rest, http.MethodPost, req.URL())
+			if err != nil {
+				return tool.Result{}, err
+			}
+			wsExec, err := remotecommand.NewWebSocketExecutor(c.rest, http.MethodGet, req.URL().String())
+			if err != nil {
+				return tool.Result{}, err
+			}
+			executor, err := remotecommand.NewFallbackExecutor(wsExec, spdyExec, func(err error) bool {
+				return httpstream.IsUpgradeFailure(err) || httpstream.IsHTTPSProxyError(err)
+			})
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			stdout := &limitedBuffer{max: p.cfg.MaxOutputBytes}
+			stderr := &limitedBuffer{max: p.cfg.MaxOutputBytes}
+			exitCode := 0
+			err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: stdout, Stderr: stderr})
+			var exitErr utilexec.ExitError
+			switch {
+			case errors.As(err, &exitErr) && exitErr.Exited():
+				exitCode = exitErr.ExitStatus()
+			case err != nil:
+				return tool.Result{}, fmt.Errorf("exec failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"pod":       in.Pod,
+				"namespace": ns,
+				"exit_code": exitCode,
+				"stdout":    strings.ToValidUTF8(stdout.buf.String(), "�"),
+				"stderr":    strings.ToValidUTF8(stderr.buf.String(), "�"),
+				"truncated": stdout.truncated || stderr.truncated,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *k8sPack) k8sPortForward() tool.Tool {
+	return tool.NewBuilder("k8s_port_forward").
+		WithDescription("Forward local ports on 127.0.0.1 to a pod for a limited duration").
+		WithRiskLevel(tool.RiskHigh).
+		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				podRef
+				// Ports are "local:remote" or "remote" (random local port).
+				Ports           []string `json:"ports"`
+				DurationSeconds int      `json:"duration_seconds,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Ports) == 0 {
+				return tool.Result{}, fmt.Errorf("%w: ports are required", ErrInvalidParameters)
+			}
+			for i, port := range in.Ports {
+				// A bare remote port gets a random local port rather than
+				// the same number, which may be privileged or taken.
+				if !strings.Contains(port, ":") {
+					in.Ports[i] = ":" + port
+				}
+			}
+			c, ns, err := p.pod(in.podRef)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if c.rest == nil {
+				return tool.Result{}, fmt.Errorf("%w: port forwarding requires a REST config", ErrNotSupported)
+			}
+			duration := p.cfg.MaxPortForward
+			if in.DurationSeconds > 0 {
+				duration = min(time.Duration(in.DurationSeconds)*time.Second, p.cfg.MaxPortForward)
+			}
+
+			transport, upgrader, err := spdy.RoundTripperFor(c.rest)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			req := c.typed.CoreV1().RESTClient().Post().
+				Resource("pods").Namespace(ns).Name(in.Pod).SubResource("portforward")
+			dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+			stop := make(chan struct{})
+			ready := make(chan struct{})
+			errOut := &limitedBuffer{max: 4096}
+			fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, in.Ports, stop, ready, io.Discard, errOut)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("%w: %v", ErrInvalidParameters, err)
+			}
+			failed := make(chan error, 1)
+			go func() { failed <- fw.ForwardPorts() }()
+
+			select {
+			case <-ready:
+			case err := <-failed:
+				return tool.Result{}, fmt.Errorf("port forward failed: %w", err)
+			case <-ctx.Done():
+				close(stop)
+				return tool.Result{}, ctx.Err()
+			}
+			// The forward outlives the tool call and stops on its own.
+			time.AfterFunc(duration, func() { close(stop) })
+
+			ports, err := fw.GetPorts()
+			if err != nil {
+				return tool.Result{}, err
+			}
+			forwarded := make([]map[string]any, 0, len(ports))
+			for _, port := range ports {
+				forwarded = append(forwarded, map[string]any{
+					"local_address": fmt.Sprintf("127.0.0.1:%d", port.Local),
+					"remote_port":   port.Remote,
+				})
+			}
+			output, _ := json.Marshal(map[string]any{
+				"pod":        in.Pod,
+				"namespace":  ns,
+				"ports":      forwarded,
+				"expires_at": time.Now().Add(duration).UTC().Format(time.RFC3339),
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
@@ -0,0 +1,348 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+const (
+	revisionAnnotation    = "deployment.kubernetes.io/revision"
+	restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+)
+
+// workloadKinds are the kinds k8s_scale and k8s_rollout operate on.
+var workloadKinds = map[string]bool{
+	"Deployment":  true,
+	"StatefulSet": true,
+	"DaemonSet":   true,
+	"ReplicaSet":  true,
+}
+
+// workloadRef is the input that addresses a workload. Resource defaults to
+// deployments.
+type workloadRef struct {
+	resourceRef
+	Name string `json:"name"`
+}
+
+// workload resolves and fetches a workload for a write operation.
+func (p *k8sPack) workload(ctx context.Context, in workloadRef) (*clients, target, *unstructured.Unstructured, error) {
+	if in.Name == "" {
+		return nil, target{}, nil, fmt.Errorf("%w: name is required", ErrInvalidParameters)
+	}
+	if in.Resource == "" {
+		in.Resource = "deployments"
+	}
+	c, err := p.client()
+	if err != nil {
+		return nil, target{}, nil, err
+	}
+	t, err := p.resolve(c, in.resourceRef, false, true)
+	if err != nil {
+		return nil, target{}, nil, err
+	}
+	if !workloadKinds[t.gvk.Kind] {
+		return nil, target{}, nil, fmt.Errorf("%w: %s is not a workload", ErrInvalidParameters, t.gvk.Kind)
+	}
+	obj, err := t.resourceInterface(c).Get(ctx, in.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, target{}, nil, err
+	}
+	return c, t, obj, nil
+}
+
+func mergePatch(patch map[string]any) []byte {
+	data, _ := json.Marshal(patch)
+	return data
+}
+
+func (p *k8sPack) k8sScale() tool.Tool {
+	return tool.NewBuilder("k8s_scale").
+		WithDescription("Scale a deployment, statefulset or replicaset to a number of replicas").
+		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				workloadRef
+				Replicas *int64 `json:"replicas"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Replicas == nil || *in.Replicas < 0 {
+				return tool.Result{}, fmt.Errorf("%w: replicas must be zero or more", ErrInvalidParameters)
+			}
+
+			c, t, obj, err := p.workload(ctx, in.workloadRef)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if t.gvk.Kind == "DaemonSet" {
+				return tool.Result{}, fmt.Errorf("%w: daemonsets cannot be scaled", ErrInvalidParameters)
+			}
+			previous, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+
+			patch := mergePatch(map[string]any{"spec": map[string]any{"replicas": *in.Replicas}})
+			if _, err := t.resourceInterface(c).Patch(ctx, in.Name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: p.cfg.FieldManager}); err != nil {
+				return tool.Result{}, err
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"kind":              t.gvk.Kind,
+				"name":              in.Name,
+				"namespace":         t.namespace,
+				"previous_replicas": previous,
+				"replicas":          *in.Replicas,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *k8sPack) k8sRollout() tool.Tool {
+	return tool.NewBuilder("k8s_rollout").
+		WithDescription("Manage workload rollouts: status, history, restart, or undo to a previous revision").
+		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				workloadRef
+				Action     string `json:"action"`
+				ToRevision int64  `json:"to_revision,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			c, t, obj, err := p.workload(ctx, in.workloadRef)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if t.gvk.Kind == "ReplicaSet" {
+				return tool.Result{}, fmt.Errorf("%w: replicasets have no rollouts", ErrInvalidParameters)
+			}
+
+			out := map[string]any{
+				"kind":      t.gvk.Kind,
+				"name":      in.Name,
+				"namespace": t.namespace,
+				"action":    in.Action,
+			}
+			switch in.Action {
+			case "status":
+				done, message := rolloutStatus(obj)
+				out["done"] = done
+				out["message"] = message
+			case "history":
+				revisions, err := p.revisions(ctx, c, t, obj)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				history := make([]map[string]any, 0, len(revisions))
+				for _, r := range revisions {
+					history = append(history, map[string]any{"revision": r.number, "source": r.source, "created": r.created})
+				}
+				out["revisions"] = history
+			case "restart":
+				patch := mergePatch(map[string]any{"spec": map[string]any{"template": map[string]any{"metadata": map[string]any{
+					"annotations": map[string]any{restartedAtAnnotation: time.Now().UTC().Format(time.RFC3339)},
+				}}}})
+				if _, err := t.resourceInterface(c).Patch(ctx, in.Name, types.MergePatchType, patch, metav1.PatchOptions{FieldManager: p.cfg.FieldManager}); err != nil {
+					return tool.Result{}, err
+				}
+				out["restarted"] = true
+			case "undo":
+				revision, err := p.undo(ctx, c, t, obj, in.ToRevision)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				out["rolled_back_to"] = revision
+			default:
+				return tool.Result{}, fmt.Errorf("%w: action must be status, history, restart or undo", ErrInvalidParameters)
+			}
+
+			output, _ := json.Marshal(out)
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+// rolloutStatus reports whether a workload has finished rolling out, with a
+// kubectl-style message.
+func rolloutStatus(obj *unstructured.Unstructured) (bool, string) {
+	num := func(fields ...string) int64 {
+		v, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+		return v
+	}
+	if num("status", "observedGeneration") < obj.GetGeneration() {
+		return false, "waiting for the spec update to be observed"
+	}
+
+	switch obj.GetKind() {
+	case "Deployment":
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			if cond, ok := c.(map[string]any); ok && cond["type"] == "Progressing" && cond["reason"] == "ProgressDeadlineExceeded" {
+				return false, fmt.Sprintf("deployment %q exceeded its progress deadline", obj.GetName())
+			}
+		}
+		desired := int64(1)
+		if v, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); ok {
+			desired = v
+		}
+		updated, total, available := num("status", "updatedReplicas"), num("status", "replicas"), num("status", "availableReplicas")
+		switch {
+		case updated < desired:
+			return false, fmt.Sprintf("%d out of %d new replicas have been updated", updated, desired)
+		case total > updated:
+			return false, fmt.Sprintf("%d old replicas are pending termination", total-updated)
+		case available < updated:
+			return false, fmt.Sprintf("%d of %d updated replicas are available", available, updated)
+		}
+		return true, fmt.Sprintf("deployment %q successfully rolled out", obj.GetName())
+	case "StatefulSet":
+		desired := int64(1)
+		if v, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); ok {
+			desired = v
+		}
+		if ready := num("status", "readyReplicas"); ready < desired {
+			return false, fmt.Sprintf("%d of %d replicas are ready", ready, desired)
+		}
+		current, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+		update, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+		if update != "" && current != update {
+			return false, fmt.Sprintf("%d of %d replicas are updated", num("status", "updatedReplicas"), desired)
+		}
+		return true, fmt.Sprintf("statefulset %q successfully rolled out", obj.GetName())
+	default:
+		desired := num("status", "desiredNumberScheduled")
+		if updated := num("status", "updatedNumberScheduled"); updated < desired {
+			return false, fmt.Sprintf("%d out of %d new pods have been updated", updated, desired)
+		}
+		if available := num("status", "numberAvailable"); available < desired {
+			return false, fmt.Sprintf("%d of %d updated pods are available", available, desired)
+		}
+		return true, fmt.Sprintf("daemonset %q successfully rolled out", obj.GetName())
+	}
+}
+
+// revision is one entry of a workload's rollout history. For deployments
+// it is backed by a ReplicaSet, otherwise by a ControllerRevision.
+type revision struct {
+	number  int64
+	source  string
+	created string
+	object  *unstructured.Unstructured
+}
+
+var (
+	replicaSetsGVR         = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}
+	controllerRevisionsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "controllerrevisions"}
+)
+
+// revisions lists the owned revisions of a workload, oldest first.
+func (p *k8sPack) revisions(ctx context.Context, c *clients, t target, obj *unstructured.Unstructured) ([]revision, error) {
+	gvr := controllerRevisionsGVR
+	if t.gvk.Kind == "Deployment" {
+		gvr = replicaSetsGVR
+	}
+	list, err := c.dynamic.Resource(gvr).Namespace(t.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var revisions []revision
+	for i := range list.Items {
+		item := &list.Items[i]
+		if !ownedBy(item, obj) {
+			continue
+		}
+		var number int64
+		if t.gvk.Kind == "Deployment" {
+			number, _ = strconv.ParseInt(item.GetAnnotations()[revisionAnnotation], 10, 64)
+		} else {
+			number, _, _ = unstructured.NestedInt64(item.Object, "revision")
+		}
+		if number == 0 {
+			continue
+		}
+		revisions = append(revisions, revision{
+			number:  number,
+			source:  item.GetName(),
+			created: item.GetCreationTimestamp().UTC().Format(time.RFC3339),
+			object:  item,
+		})
+	}
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].number < revisions[j].number })
+	return revisions, nil
+}
+
+func ownedBy(item, owner *unstructured.Unstructured) bool {
+	for _, ref := range item.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() || (ref.UID == "" && ref.Kind == owner.GetKind() && ref.Name == owner.GetName()) {
+			return true
+		}
+	}
+	return false
+}
+
+// undo rolls a workload back to toRevision, or to the previous revision
+// when toRevision is zero, and returns the revision used.
+func (p *k8sPack) undo(ctx context.Context, c *clients, t target, obj *unstructured.Unstructured, toRevision int64) (int64, error) {
+	revisions, err := p.revisions(ctx, c, t, obj)
+	if err != nil {
+		return 0, err
+	}
+	var chosen *revision
+	if toRevision > 0 {
+		for i := range revisions {
+			if revisions[i].number == toRevision {
+				chosen = &revisions[i]
+			}
+		}
+		if chosen == nil {
+			return 0, fmt.Errorf("%w: revision %d not found", ErrInvalidParameters, toRevision)
+		}
+	} else {
+		if len(revisions) < 2 {
+			return 0, fmt.Errorf("%w: no previous revision to roll back to", ErrInvalidParameters)
+		}
+		chosen = &revisions[len(revisions)-2]
+	}
+
+	ri := t.resourceInterface(c)
+	opts := metav1.PatchOptions{FieldManager: p.cfg.FieldManager}
+	if t.gvk.Kind != "Deployment" {
+		// ControllerRevision data is a strategic merge patch that restores
+		// the pod template, as used by kubectl.
+		data, ok, _ := unstructured.NestedMap(chosen.object.Object, "data")
+		if !ok {
+			return 0, fmt.Errorf("revision %d has no data", chosen.number)
+		}
+		patch, _ := json.Marshal(data)
+		if _, err := ri.Patch(ctx, obj.GetName(), types.StrategicMergePatchType, patch, opts); err != nil {
+			return 0, err
+		}
+		return chosen.number, nil
+	}
+
+	template, ok, _ := unstructured.NestedMap(chosen.object.Object, "spec", "template")
+	if !ok {
+		return 0, fmt.Errorf("revision %d has no pod template", chosen.number)
+	}
+	unstructured.RemoveNestedField(template, "metadata", "labels", "pod-template-hash")
+	patch, _ := json.Marshal([]map[string]any{{"op": "replace", "path": "/spec/template", "value": template}})
+	if _, err := ri.Patch(ctx, obj.GetName(), types.JSONPatchType, patch, opts); err != nil {
+		return 0, err
+	}
+	return chosen.number, nil
+}
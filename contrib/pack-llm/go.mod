@@ -2,6 +2,13 @@ module github.com/felixgeelhaar/agent-go/contrib/pack-llm
 
 go 1.25.0
 
-require github.com/felixgeelhaar/agent-go v0.0.0
+require (
+	github.com/felixgeelhaar/agent-go v0.0.0
+	github.com/felixgeelhaar/agent-go/contrib/planner-llm v0.0.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+)
 
-replace github.com/felixgeelhaar/agent-go => ../..
+replace (
+	github.com/felixgeelhaar/agent-go => ../..
+	github.com/felixgeelhaar/agent-go/contrib/planner-llm => ../planner-llm
+)
@@ -9,28 +9,105 @@
 //   - llm_classify: Classify text into categories
 //   - llm_translate: Translate text between languages
 //
-// Supports OpenAI, Anthropic, Google, Azure OpenAI, and local models via Ollama.
+// Tools run on a plannerllm.Provider, so one provider configuration serves
+// both planning and tools. Every tool reports input_tokens, output_tokens
+// and total_tokens in its output, which middleware.LLMCostCalculator reads
+// for the CostTracking middleware, and charges the tokens to the budget of
+// the run it executes in (policy.BudgetFromContext), so a run's token limit
+// covers tool calls as well as planning. plannerllm.NewFakeProvider gives
+// deterministic responses for tests.
 package llm
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	plannerllm "github.com/felixgeelhaar/agent-go/contrib/planner-llm"
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+var (
+	// ErrNoProvider indicates the pack was configured without a provider.
+	ErrNoProvider = errors.New("no LLM provider configured")
+
+	// ErrNotSupported indicates the provider cannot perform the operation,
+	// such as embeddings on a completion-only provider.
+	ErrNotSupported = errors.New("not supported by LLM provider")
+
+	// ErrInputTooLarge indicates input text over MaxInputBytes.
+	ErrInputTooLarge = errors.New("input too large")
+
+	// ErrInvalidOutput indicates model output that failed validation after
+	// all retries.
+	ErrInvalidOutput = errors.New("invalid model output")
+)
+
+// Config configures the LLM pack.
+type Config struct {
+	// Provider serves completions, and embeddings when it implements
+	// plannerllm.EmbeddingProvider.
+	Provider plannerllm.Provider
+
+	// Model is the completion model. Empty uses the provider default.
+	Model string
+
+	// EmbeddingModel is the embedding model. Empty uses the provider default.
+	EmbeddingModel string
+
+	// Temperature is used for free-form generation. Extraction and
+	// classification always use 0.
+	Temperature float64
+
+	// MaxTokens caps completion length. Defaults to 1024.
+	MaxTokens int
+
+	// MaxInputBytes caps the text sent per call. Defaults to 200KiB.
+	MaxInputBytes int
+
+	// ExtractRetries is how many times llm_extract and llm_classify re-ask
+	// the model after invalid output. Defaults to 2; negative disables retries.
+	ExtractRetries int
+
+	// TokenBudget names the run budget charged with the tokens each call
+	// uses. Calls are refused once it is exhausted. Defaults to "tokens".
+	TokenBudget string
+}
+
 // Pack returns the LLM tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 1024
+	}
+	if cfg.MaxInputBytes <= 0 {
+		cfg.MaxInputBytes = 200 << 10
+	}
+	if cfg.ExtractRetries < 0 {
+		cfg.ExtractRetries = 0
+	} else if cfg.ExtractRetries == 0 {
+		cfg.ExtractRetries = 2
+	}
+	if cfg.TokenBudget == "" {
+		cfg.TokenBudget = "tokens"
+	}
+	p := &llmPack{cfg: cfg}
+
 	return pack.NewBuilder("llm").
 		WithDescription("LLM completion and text processing tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			llmComplete(),
-			llmChat(),
-			llmEmbed(),
-			llmSummarize(),
-			llmExtract(),
-			llmClassify(),
-			llmTranslate(),
+			p.llmComplete(),
+			p.llmChat(),
+			p.llmEmbed(),
+			p.llmSummarize(),
+			p.llmExtract(),
+			p.llmClassify(),
+			p.llmTranslate(),
 		).
 		AllowInState(agent.StateExplore, "llm_complete", "llm_chat", "llm_embed", "llm_summarize", "llm_extract", "llm_classify", "llm_translate").
 		AllowInState(agent.StateAct, "llm_complete", "llm_chat", "llm_embed", "llm_summarize", "llm_extract", "llm_classify", "llm_translate").
@@ -38,58 +115,366 @@ func Pack() *pack.Pack {
 		Build()
 }
 
-func llmComplete() tool.Tool {
+type llmPack struct {
+	cfg Config
+}
+
+// usage accumulates token counts across the provider calls of one tool call.
+type usage struct {
+	input, output int
+}
+
+// add records a provider's usage and returns the tokens it spent.
+// Providers that only report totals are counted as input.
+func (u *usage) add(in plannerllm.Usage) int {
+	if in.PromptTokens == 0 && in.CompletionTokens == 0 {
+		u.input += in.TotalTokens
+		return in.TotalTokens
+	}
+	u.input += in.PromptTokens
+	u.output += in.CompletionTokens
+	return in.PromptTokens + in.CompletionTokens
+}
+
+// result marshals a tool output with the token fields read by
+// middleware.LLMCostCalculator.
+func (u usage) result(out map[string]any) tool.Result {
+	out["input_tokens"] = u.input
+	out["output_tokens"] = u.output
+	out["total_tokens"] = u.input + u.output
+	output, _ := json.Marshal(out)
+	return tool.Result{Output: output}
+}
+
+// checkBudget refuses calls once the run's token budget is exhausted.
+func (p *llmPack) checkBudget(ctx context.Context) error {
+	budget, ok := policy.BudgetFromContext(ctx)
+	if ok && !budget.CanConsume(p.cfg.TokenBudget, 1) {
+		return fmt.Errorf("%w: %s", policy.ErrBudgetExceeded, p.cfg.TokenBudget)
+	}
+	return nil
+}
+
+// charge records tokens already spent against the run's budget. A call
+// that overshoots the limit exhausts the budget rather than going
+// unrecorded.
+func (p *llmPack) charge(ctx context.Context, tokens int) {
+	budget, ok := policy.BudgetFromContext(ctx)
+	if !ok || tokens <= 0 {
+		return
+	}
+	if err := budget.Consume(p.cfg.TokenBudget, tokens); err != nil {
+		if remaining := budget.Remaining(p.cfg.TokenBudget); remaining > 0 {
+			_ = budget.Consume(p.cfg.TokenBudget, remaining)
+		}
+	}
+}
+
+func (p *llmPack) checkInput(texts ...string) error {
+	size := 0
+	for _, t := range texts {
+		size += len(t)
+	}
+	if size > p.cfg.MaxInputBytes {
+		return fmt.Errorf("%w: %d bytes (max %d)", ErrInputTooLarge, size, p.cfg.MaxInputBytes)
+	}
+	return nil
+}
+
+// complete sends one completion request and accounts for its usage.
+func (p *llmPack) complete(ctx context.Context, messages []plannerllm.Message, temperature float64, maxTokens int, u *usage) (plannerllm.CompletionResponse, error) {
+	if p.cfg.Provider == nil {
+		return plannerllm.CompletionResponse{}, ErrNoProvider
+	}
+	if err := p.checkBudget(ctx); err != nil {
+		return plannerllm.CompletionResponse{}, err
+	}
+	if maxTokens <= 0 || maxTokens > p.cfg.MaxTokens {
+		maxTokens = p.cfg.MaxTokens
+	}
+	resp, err := p.cfg.Provider.Complete(ctx, plannerllm.CompletionRequest{
+		Model:       p.cfg.Model,
+		Messages:    messages,
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+	})
+	if err == nil {
+		err = resp.Error
+	}
+	if err != nil {
+		return plannerllm.CompletionResponse{}, fmt.Errorf("%s completion failed: %w", p.cfg.Provider.Name(), err)
+	}
+	p.charge(ctx, u.add(resp.Usage))
+	return resp, nil
+}
+
+// prompt runs a single-turn system + user exchange and returns the reply.
+func (p *llmPack) prompt(ctx context.Context, system, user string, temperature float64, u *usage) (string, string, error) {
+	var messages []plannerllm.Message
+	if system != "" {
+		messages = append(messages, plannerllm.Message{Role: "system", Content: system})
+	}
+	messages = append(messages, plannerllm.Message{Role: "user", Content: user})
+	resp, err := p.complete(ctx, messages, temperature, 0, u)
+	if err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(resp.Message.Content), resp.Model, nil
+}
+
+// ============================================================================
+// Generation Tools
+// ============================================================================
+
+func (p *llmPack) llmComplete() tool.Tool {
 	return tool.NewBuilder("llm_complete").
 		WithDescription("Generate text completion from a prompt").
 		ReadOnly().
 		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Prompt      string   `json:"prompt"`
+				System      string   `json:"system,omitempty"`
+				MaxTokens   int      `json:"max_tokens,omitempty"`
+				Temperature *float64 `json:"temperature,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Prompt == "" {
+				return tool.Result{}, errors.New("prompt is required")
+			}
+			if err := p.checkInput(in.Prompt, in.System); err != nil {
+				return tool.Result{}, err
+			}
+			temperature := p.cfg.Temperature
+			if in.Temperature != nil {
+				temperature = *in.Temperature
+			}
+
+			var messages []plannerllm.Message
+			if in.System != "" {
+				messages = append(messages, plannerllm.Message{Role: "system", Content: in.System})
+			}
+			messages = append(messages, plannerllm.Message{Role: "user", Content: in.Prompt})
+
+			var u usage
+			resp, err := p.complete(ctx, messages, temperature, in.MaxTokens, &u)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return u.result(map[string]any{
+				"text":  resp.Message.Content,
+				"model": resp.Model,
+			}), nil
+		}).
 		MustBuild()
 }
 
-func llmChat() tool.Tool {
+func (p *llmPack) llmChat() tool.Tool {
 	return tool.NewBuilder("llm_chat").
 		WithDescription("Have a multi-turn conversation with an LLM").
 		ReadOnly().
 		WithRiskLevel(tool.RiskLow).
-		MustBuild()
-}
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Messages []struct {
+					Role    string `json:"role"`
+					Content string `json:"content"`
+				} `json:"messages"`
+				System      string   `json:"system,omitempty"`
+				MaxTokens   int      `json:"max_tokens,omitempty"`
+				Temperature *float64 `json:"temperature,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Messages) == 0 {
+				return tool.Result{}, errors.New("messages are required")
+			}
 
-func llmEmbed() tool.Tool {
-	return tool.NewBuilder("llm_embed").
-		WithDescription("Generate vector embeddings for text").
-		ReadOnly().
-		Cacheable().
+			texts := []string{in.System}
+			var messages []plannerllm.Message
+			if in.System != "" {
+				messages = append(messages, plannerllm.Message{Role: "system", Content: in.System})
+			}
+			for i, m := range in.Messages {
+				switch m.Role {
+				case "user", "assistant":
+				case "system":
+					if i > 0 {
+						return tool.Result{}, fmt.Errorf("message %d: system messages must come first", i)
+					}
+				default:
+					return tool.Result{}, fmt.Errorf("message %d: role must be user, assistant or system", i)
+				}
+				messages = append(messages, plannerllm.Message{Role: m.Role, Content: m.Content})
+				texts = append(texts, m.Content)
+			}
+			if err := p.checkInput(texts...); err != nil {
+				return tool.Result{}, err
+			}
+			temperature := p.cfg.Temperature
+			if in.Temperature != nil {
+				temperature = *in.Temperature
+			}
+
+			var u usage
+			resp, err := p.complete(ctx, messages, temperature, in.MaxTokens, &u)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return u.result(map[string]any{
+				"message": map[string]string{"role": "assistant", "content": resp.Message.Content},
+				"model":   resp.Model,
+			}), nil
+		}).
 		MustBuild()
 }
 
-func llmSummarize() tool.Tool {
+func (p *llmPack) llmSummarize() tool.Tool {
 	return tool.NewBuilder("llm_summarize").
 		WithDescription("Summarize text content").
 		ReadOnly().
 		Cacheable().
-		MustBuild()
-}
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Text     string `json:"text"`
+				MaxWords int    `json:"max_words,omitempty"`
+				Style    string `json:"style,omitempty"`
+				Focus    string `json:"focus,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Text == "" {
+				return tool.Result{}, errors.New("text is required")
+			}
+			if err := p.checkInput(in.Text, in.Focus); err != nil {
+				return tool.Result{}, err
+			}
+			if in.MaxWords <= 0 {
+				in.MaxWords = 150
+			}
 
-func llmExtract() tool.Tool {
-	return tool.NewBuilder("llm_extract").
-		WithDescription("Extract structured data from unstructured text").
-		ReadOnly().
-		Cacheable().
+			system := fmt.Sprintf("Summarize the text the user provides in at most %d words. Reply with the summary only.", in.MaxWords)
+			switch in.Style {
+			case "", "paragraph":
+			case "bullets":
+				system += " Use a bulleted list."
+			default:
+				return tool.Result{}, errors.New("style must be paragraph or bullets")
+			}
+			if in.Focus != "" {
+				system += " Focus on: " + in.Focus
+			}
+
+			var u usage
+			summary, model, err := p.prompt(ctx, system, in.Text, p.cfg.Temperature, &u)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return u.result(map[string]any{
+				"summary": summary,
+				"model":   model,
+			}), nil
+		}).
 		MustBuild()
 }
 
-func llmClassify() tool.Tool {
-	return tool.NewBuilder("llm_classify").
-		WithDescription("Classify text into predefined categories").
+func (p *llmPack) llmTranslate() tool.Tool {
+	return tool.NewBuilder("llm_translate").
+		WithDescription("Translate text between languages").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Text           string `json:"text"`
+				TargetLanguage string `json:"target_language"`
+				SourceLanguage string `json:"source_language,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Text == "" || in.TargetLanguage == "" {
+				return tool.Result{}, errors.New("text and target_language are required")
+			}
+			if err := p.checkInput(in.Text); err != nil {
+				return tool.Result{}, err
+			}
+
+			source := "the source language"
+			if in.SourceLanguage != "" {
+				source = in.SourceLanguage
+			}
+			system := fmt.Sprintf("Translate the text the user provides from %s to %s. Preserve formatting. Reply with the translation only.", source, in.TargetLanguage)
+
+			var u usage
+			translation, model, err := p.prompt(ctx, system, in.Text, p.cfg.Temperature, &u)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return u.result(map[string]any{
+				"translation":     translation,
+				"target_language": in.TargetLanguage,
+				"model":           model,
+			}), nil
+		}).
 		MustBuild()
 }
 
-func llmTranslate() tool.Tool {
-	return tool.NewBuilder("llm_translate").
-		WithDescription("Translate text between languages").
+func (p *llmPack) llmEmbed() tool.Tool {
+	return tool.NewBuilder("llm_embed").
+		WithDescription("Generate vector embeddings for text").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Text  string   `json:"text,omitempty"`
+				Texts []string `json:"texts,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Text != "" {
+				in.Texts = append([]string{in.Text}, in.Texts...)
+			}
+			if len(in.Texts) == 0 {
+				return tool.Result{}, errors.New("text or texts is required")
+			}
+			if err := p.checkInput(in.Texts...); err != nil {
+				return tool.Result{}, err
+			}
+			if p.cfg.Provider == nil {
+				return tool.Result{}, ErrNoProvider
+			}
+			embedder, ok := p.cfg.Provider.(plannerllm.EmbeddingProvider)
+			if !ok {
+				return tool.Result{}, fmt.Errorf("%w: %s has no embeddings", ErrNotSupported, p.cfg.Provider.Name())
+			}
+			if err := p.checkBudget(ctx); err != nil {
+				return tool.Result{}, err
+			}
+
+			resp, err := embedder.Embed(ctx, plannerllm.EmbeddingRequest{Model: p.cfg.EmbeddingModel, Input: in.Texts})
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("%s embedding failed: %w", p.cfg.Provider.Name(), err)
+			}
+			if len(resp.Embeddings) != len(in.Texts) {
+				return tool.Result{}, fmt.Errorf("%w: %d embeddings for %d texts", ErrInvalidOutput, len(resp.Embeddings), len(in.Texts))
+			}
+			var u usage
+			p.charge(ctx, u.add(resp.Usage))
+
+			dimensions := 0
+			if len(resp.Embeddings) > 0 {
+				dimensions = len(resp.Embeddings[0])
+			}
+			return u.result(map[string]any{
+				"embeddings": resp.Embeddings,
+				"dimensions": dimensions,
+				"model":      resp.Model,
+			}), nil
+		}).
 		MustBuild()
 }
@@ -0,0 +1,276 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	plannerllm "github.com/felixgeelhaar/agent-go/contrib/planner-llm"
+	domainmw "github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/middleware"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	return callCtx(context.Background(), p, name, input)
+}
+
+func callCtx(ctx context.Context, p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+// completionOnly hides the fake provider's embeddings.
+type completionOnly struct {
+	plannerllm.Provider
+}
+
+func TestCompleteReportsUsageToBudgetAndCostTracking(t *testing.T) {
+	t.Parallel()
+	budget := policy.NewBudget(map[string]int{"tokens": 100})
+	ctx := policy.WithBudget(context.Background(), budget)
+	p := Pack(Config{Provider: plannerllm.NewFakeProvider()})
+
+	out, err := callCtx(ctx, p, "llm_complete", map[string]any{"prompt": "hello there world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["text"] != "hello there world" || out["total_tokens"] != 6.0 || out["input_tokens"] != 3.0 {
+		t.Fatalf("unexpected output: %v", out)
+	}
+	if got := budget.Remaining("tokens"); got != 94 {
+		t.Errorf("remaining tokens = %d, want 94", got)
+	}
+
+	// The same output feeds the CostTracking middleware's LLM calculator.
+	tl, _ := p.GetTool("llm_complete")
+	var tokens float64
+	handler := middleware.CostTracking(middleware.CostTrackingConfig{
+		Calculator: middleware.LLMCostCalculator(0.001, 0.002),
+		OnCostRecorded: func(e middleware.CostEntry) {
+			if e.Unit == middleware.CostUnitTokens {
+				tokens += e.Amount
+			}
+		},
+	})(func(ctx context.Context, execCtx *domainmw.ExecutionContext) (tool.Result, error) {
+		return tl.Execute(ctx, execCtx.Input)
+	})
+	if _, err := handler(context.Background(), &domainmw.ExecutionContext{
+		RunID: "run-1",
+		Tool:  tl,
+		Input: json.RawMessage(`{"prompt":"one two"}`),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if tokens != 4 {
+		t.Errorf("cost tracking recorded %v tokens, want 4", tokens)
+	}
+}
+
+func TestBudgetExhaustionRefusesCalls(t *testing.T) {
+	t.Parallel()
+	budget := policy.NewBudget(map[string]int{"tokens": 5})
+	ctx := policy.WithBudget(context.Background(), budget)
+	p := Pack(Config{Provider: plannerllm.NewFakeProvider()})
+
+	// The first call overshoots and exhausts the budget.
+	if _, err := callCtx(ctx, p, "llm_complete", map[string]any{"prompt": "a b c d"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := budget.Remaining("tokens"); got != 0 {
+		t.Fatalf("remaining tokens = %d, want 0", got)
+	}
+	if _, err := callCtx(ctx, p, "llm_complete", map[string]any{"prompt": "again"}); !errors.Is(err, policy.ErrBudgetExceeded) {
+		t.Fatalf("expected ErrBudgetExceeded, got %v", err)
+	}
+
+	// Each run carries its own budget; a fresh run is not refused.
+	fresh := policy.WithBudget(context.Background(), policy.NewBudget(map[string]int{"tokens": 5}))
+	if _, err := callCtx(fresh, p, "llm_complete", map[string]any{"prompt": "again"}); err != nil {
+		t.Fatalf("fresh run budget: %v", err)
+	}
+	// Without a budget in the context calls are not limited.
+	if _, err := call(p, "llm_complete", map[string]any{"prompt": "again"}); err != nil {
+		t.Fatalf("no budget: %v", err)
+	}
+}
+
+func TestExtractValidatesAndRetries(t *testing.T) {
+	t.Parallel()
+	fake := plannerllm.NewFakeProvider(plannerllm.WithScript(
+		`{"name": "Ada"}`,
+		"Here you go:\n```json\n{\"name\": \"Ada\", \"born\": 1815}\n```",
+	))
+	p := Pack(Config{Provider: fake})
+
+	out, err := call(p, "llm_extract", map[string]any{
+		"text": "Ada Lovelace was born in 1815.",
+		"schema": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"name": map[string]any{"type": "string"}, "born": map[string]any{"type": "integer"}},
+			"required":   []string{"name", "born"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := out["data"].(map[string]any)
+	if data["name"] != "Ada" || data["born"] != 1815.0 || out["attempts"] != 2.0 {
+		t.Fatalf("unexpected output: %v", out)
+	}
+
+	requests := fake.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(requests))
+	}
+	retry := requests[1].Messages[len(requests[1].Messages)-1].Content
+	if !strings.Contains(retry, "born") {
+		t.Errorf("retry prompt does not describe the validation error: %q", retry)
+	}
+	if requests[0].Temperature != 0 {
+		t.Errorf("extraction should use temperature 0, got %v", requests[0].Temperature)
+	}
+}
+
+func TestExtractGivesUpOnInvalidOutput(t *testing.T) {
+	t.Parallel()
+	p := Pack(Config{
+		Provider:       plannerllm.NewFakeProvider(plannerllm.WithScript("no json here")),
+		ExtractRetries: -1,
+	})
+	_, err := call(p, "llm_extract", map[string]any{"text": "x", "schema": map[string]any{"type": "object"}})
+	if !errors.Is(err, ErrInvalidOutput) {
+		t.Fatalf("expected ErrInvalidOutput, got %v", err)
+	}
+
+	_, err = call(p, "llm_extract", map[string]any{"text": "x", "schema": map[string]any{"type": 5}})
+	if err == nil || !strings.Contains(err.Error(), "invalid schema") {
+		t.Fatalf("expected invalid schema error, got %v", err)
+	}
+}
+
+func TestExtractRejectsExternalSchemaRefs(t *testing.T) {
+	t.Parallel()
+	p := Pack(Config{Provider: plannerllm.NewFakeProvider(plannerllm.WithScript(`{"name":"Ada"}`))})
+
+	for _, ref := range []string{"file:///etc/passwd", "/dev/zero", "https://example.com/schema.json"} {
+		schema := map[string]any{"type": "object", "properties": map[string]any{"name": map[string]any{"$ref": ref}}}
+		_, err := call(p, "llm_extract", map[string]any{"text": "x", "schema": schema})
+		if err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("%s: expected the reference to be rejected, got %v", ref, err)
+		}
+	}
+
+	// References within the schema still resolve.
+	schema := map[string]any{
+		"$defs":      map[string]any{"name": map[string]any{"type": "string"}},
+		"type":       "object",
+		"properties": map[string]any{"name": map[string]any{"$ref": "#/$defs/name"}},
+	}
+	if _, err := call(p, "llm_extract", map[string]any{"text": "x", "schema": schema}); err != nil {
+		t.Errorf("expected a local reference to resolve, got %v", err)
+	}
+}
+
+func TestClassifyRestrictsLabelsToCategories(t *testing.T) {
+	t.Parallel()
+	fake := plannerllm.NewFakeProvider(plannerllm.WithScript(
+		`{"labels": ["refund"]}`,
+		`{"labels": ["billing"]}`,
+	))
+	p := Pack(Config{Provider: fake})
+
+	out, err := call(p, "llm_classify", map[string]any{
+		"text":       "I was charged twice",
+		"categories": []string{"billing", "technical", "other"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["label"] != "billing" || out["attempts"] != 2.0 {
+		t.Fatalf("unexpected output: %v", out)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	t.Parallel()
+	p := Pack(Config{Provider: plannerllm.NewFakeProvider(plannerllm.WithEmbeddingDimensions(8))})
+
+	out, err := call(p, "llm_embed", map[string]any{"texts": []string{"reset my password", "Reset my password"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	embeddings := out["embeddings"].([]any)
+	if out["dimensions"] != 8.0 || len(embeddings) != 2 {
+		t.Fatalf("unexpected output: %v", out)
+	}
+	a, _ := json.Marshal(embeddings[0])
+	b, _ := json.Marshal(embeddings[1])
+	if string(a) != string(b) {
+		t.Error("fake embeddings should be deterministic and case-insensitive")
+	}
+
+	p = Pack(Config{Provider: completionOnly{plannerllm.NewFakeProvider()}})
+	if _, err := call(p, "llm_embed", map[string]any{"text": "x"}); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestChatAndTextTools(t *testing.T) {
+	t.Parallel()
+	fake := plannerllm.NewFakeProvider(plannerllm.WithResponder(func(req plannerllm.CompletionRequest) (string, error) {
+		return req.Messages[0].Content, nil
+	}))
+	p := Pack(Config{Provider: fake})
+
+	out, err := call(p, "llm_translate", map[string]any{"text": "hallo", "target_language": "English", "source_language": "German"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out["translation"].(string), "from German to English") {
+		t.Errorf("unexpected translation prompt: %v", out["translation"])
+	}
+
+	out, err = call(p, "llm_summarize", map[string]any{"text": "long text", "max_words": 20, "style": "bullets"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out["summary"].(string), "at most 20 words") {
+		t.Errorf("unexpected summary prompt: %v", out["summary"])
+	}
+
+	out, err = call(p, "llm_chat", map[string]any{"messages": []map[string]string{
+		{"role": "user", "content": "hi"},
+		{"role": "assistant", "content": "hello"},
+		{"role": "user", "content": "bye"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["message"].(map[string]any)["content"] != "hi" {
+		t.Errorf("unexpected chat reply: %v", out["message"])
+	}
+	if _, err := call(p, "llm_chat", map[string]any{"messages": []map[string]string{{"role": "tool", "content": "x"}}}); err == nil {
+		t.Error("expected an error for an unsupported role")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	tl, _ := p.GetTool("llm_complete")
+	if _, err := tl.Execute(ctx, json.RawMessage(`{"prompt":"x"}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context error, got %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	plannerllm "github.com/felixgeelhaar/agent-go/contrib/planner-llm"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// compileSchema compiles a caller-supplied JSON schema. The schema comes
+// from the planner, so $ref may only point into the schema itself: loading
+// external references would let it read local files or fetch URLs.
+func compileSchema(schema json.RawMessage) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		return nil, fmt.Errorf("external reference %s not allowed", url)
+	}
+	if err := compiler.AddResource("schema.json", bytes.NewReader(schema)); err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	compiled, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %w", err)
+	}
+	return compiled, nil
+}
+
+// parseJSON decodes the JSON value in a model reply, tolerating code
+// fences and surrounding prose.
+func parseJSON(reply string) (any, error) {
+	text := strings.TrimSpace(reply)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```")
+		if nl := strings.IndexByte(text, '\n'); nl >= 0 {
+			text = text[nl+1:]
+		}
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	}
+
+	var value any
+	err := json.Unmarshal([]byte(text), &value)
+	if err == nil {
+		return value, nil
+	}
+	start := strings.IndexAny(text, "{[")
+	end := strings.LastIndexAny(text, "}]")
+	if start >= 0 && end > start {
+		if json.Unmarshal([]byte(text[start:end+1]), &value) == nil {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("reply is not JSON: %v", err)
+}
+
+// describeValidation flattens a schema validation error into one line per
+// failing location, which models can act on when retrying.
+func describeValidation(err error) string {
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err.Error()
+	}
+	var lines []string
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			location := e.InstanceLocation
+			if location == "" {
+				location = "/"
+			}
+			lines = append(lines, location+": "+e.Message)
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(ve)
+	return strings.Join(lines, "; ")
+}
+
+// structured asks for JSON matching schema and re-asks with the
+// validation errors until the reply validates or retries run out.
+func (p *llmPack) structured(ctx context.Context, system, text string, schema *jsonschema.Schema, u *usage) (any, int, string, error) {
+	messages := []plannerllm.Message{
+		{Role: "system", Content: system},
+		{Role: "user", Content: text},
+	}
+	var lastErr error
+	for attempt := 1; attempt <= p.cfg.ExtractRetries+1; attempt++ {
+		resp, err := p.complete(ctx, messages, 0, 0, u)
+		if err != nil {
+			return nil, attempt, "", err
+		}
+		reply := resp.Message.Content
+
+		value, err := parseJSON(reply)
+		if err == nil {
+			if err = schema.Validate(value); err == nil {
+				return value, attempt, resp.Model, nil
+			}
+			err = errors.New(describeValidation(err))
+		}
+		lastErr = err
+		messages = append(messages,
+			plannerllm.Message{Role: "assistant", Content: reply},
+			plannerllm.Message{Role: "user", Content: "That reply was invalid: " + err.Error() + ". Reply with corrected JSON only."},
+		)
+	}
+	return nil, p.cfg.ExtractRetries + 1, "", fmt.Errorf("%w: %v", ErrInvalidOutput, lastErr)
+}
+
+// ============================================================================
+// Structured Tools
+// ============================================================================
+
+func (p *llmPack) llmExtract() tool.Tool {
+	return tool.NewBuilder("llm_extract").
+		WithDescription("Extract structured data from unstructured text as JSON validated against a JSON schema").
+		ReadOnly().
+		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Text         string          `json:"text"`
+				Schema       json.RawMessage `json:"schema"`
+				Instructions string          `json:"instructions,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Text == "" || len(in.Schema) == 0 {
+				return tool.Result{}, errors.New("text and schema are required")
+			}
+			if err := p.checkInput(in.Text, in.Instructions); err != nil {
+				return tool.Result{}, err
+			}
+			schema, err := compileSchema(in.Schema)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			system := "Extract data from the text the user provides. Reply with a single JSON value matching this JSON schema and nothing else; omit optional fields the text does not state.\n\nSchema:\n" + string(in.Schema)
+			if in.Instructions != "" {
+				system += "\n\nInstructions: " + in.Instructions
+			}
+
+			var u usage
+			data, attempts, model, err := p.structured(ctx, system, in.Text, schema, &u)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			return u.result(map[string]any{
+				"data":     data,
+				"attempts": attempts,
+				"model":    model,
+			}), nil
+		}).
+		MustBuild()
+}
+
+func (p *llmPack) llmClassify() tool.Tool {
+	return tool.NewBuilder("llm_classify").
+		WithDescription("Classify text into predefined categories").
+		ReadOnly().
+		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Text         string   `json:"text"`
+				Categories   []string `json:"categories"`
+				MultiLabel   bool     `json:"multi_label,omitempty"`
+				Instructions string   `json:"instructions,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Text == "" || len(in.Categories) < 2 {
+				return tool.Result{}, errors.New("text and at least two categories are required")
+			}
+			if err := p.checkInput(in.Text, in.Instructions); err != nil {
+				return tool.Result{}, err
+			}
+
+			labels := map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"enum": in.Categories},
+				"minItems":    1,
+				"uniqueItems": true,
+			}
+			if !in.MultiLabel {
+				labels["maxItems"] = 1
+			}
+			schemaJSON, _ := json.Marshal(map[string]any{
+				"type":       "object",
+				"properties": map[string]any{"labels": labels},
+				"required":   []string{"labels"},
+			})
+			schema, err := compileSchema(schemaJSON)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			quoted, _ := json.Marshal(in.Categories)
+			system := "Classify the text the user provides into "
+			if in.MultiLabel {
+				system += "one or more of these categories: "
+			} else {
+				system += "exactly one of these categories: "
+			}
+			system += string(quoted) + `. Reply with JSON of the form {"labels": [...]} and nothing else.`
+			if in.Instructions != "" {
+				system += "\n\nInstructions: " + in.Instructions
+			}
+
+			var u usage
+			data, attempts, model, err := p.structured(ctx, system, in.Text, schema, &u)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			var chosen []string
+			for _, label := range data.(map[string]any)["labels"].([]any) {
+				chosen = append(chosen, label.(string))
+			}
+			return u.result(map[string]any{
+				"label":    chosen[0],
+				"labels":   chosen,
+				"attempts": attempts,
+				"model":    model,
+			}), nil
+		}).
+		MustBuild()
+}
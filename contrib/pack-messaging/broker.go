@@ -0,0 +1,116 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Errors returned by brokers and tools.
+var (
+	// ErrQueueNotFound indicates the named queue does not exist.
+	ErrQueueNotFound = errors.New("queue not found")
+
+	// ErrInvalidQueue indicates an invalid queue name.
+	ErrInvalidQueue = errors.New("invalid queue")
+
+	// ErrQueueNotAllowed indicates the queue is outside the configured policy.
+	ErrQueueNotAllowed = errors.New("queue not allowed")
+
+	// ErrDeliveryNotFound indicates an unknown, already settled or expired
+	// delivery. Expired deliveries are redelivered to a later pull.
+	ErrDeliveryNotFound = errors.New("delivery not found")
+
+	// ErrMessageTooLarge indicates a message body over the configured limit.
+	ErrMessageTooLarge = errors.New("message too large")
+)
+
+// queueNameRegex restricts queue names to a subset valid as NATS stream
+// names, RabbitMQ queues and SQS queues alike.
+var queueNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// ValidateQueueName checks a queue name.
+func ValidateQueueName(name string) error {
+	if !queueNameRegex.MatchString(name) {
+		return fmt.Errorf("%w: name %q must be alphanumeric, '-' or '_'", ErrInvalidQueue, name)
+	}
+	return nil
+}
+
+// Message is a message to publish.
+type Message struct {
+	Body    []byte
+	Headers map[string]string
+}
+
+// Delivery is a message received from a queue. It must be acked or nacked
+// with its DeliveryID before the broker's ack wait elapses, or it is
+// delivered again.
+type Delivery struct {
+	DeliveryID  string            `json:"delivery_id"`
+	MessageID   string            `json:"message_id"`
+	Queue       string            `json:"queue"`
+	Body        []byte            `json:"-"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	PublishedAt time.Time         `json:"published_at"`
+
+	// Attempt counts deliveries of this message, starting at 1.
+	Attempt int `json:"attempt"`
+}
+
+// PullOptions bounds a pull.
+type PullOptions struct {
+	// Max is the most messages returned.
+	Max int
+
+	// Wait is how long to wait for the first message when the queue is
+	// empty. Zero returns immediately.
+	Wait time.Duration
+}
+
+// QueueInfo describes a queue.
+type QueueInfo struct {
+	Name string `json:"name"`
+
+	// Ready counts messages waiting for delivery.
+	Ready int `json:"ready"`
+
+	// InFlight counts delivered messages awaiting ack.
+	InFlight int `json:"in_flight"`
+
+	// Oldest is when the oldest unacked message was published.
+	Oldest *time.Time `json:"oldest,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Broker is a message queue with at-least-once delivery. Queues are
+// created on first publish. The memory and NATS JetStream brokers
+// implement it; adapters for RabbitMQ, SQS or Pub/Sub plug in behind the
+// same interface.
+type Broker interface {
+	// Publish appends a message to a queue and returns its ID.
+	Publish(ctx context.Context, queue string, msg Message) (string, error)
+
+	// Pull receives up to opts.Max messages, waiting at most opts.Wait.
+	Pull(ctx context.Context, queue string, opts PullOptions) ([]Delivery, error)
+
+	// Ack settles a delivery as processed.
+	Ack(ctx context.Context, queue, deliveryID string) error
+
+	// Nack settles a delivery as failed. With requeue the message is
+	// delivered again; otherwise it is dropped.
+	Nack(ctx context.Context, queue, deliveryID string, requeue bool) error
+
+	// ListQueues returns all queues sorted by name.
+	ListQueues(ctx context.Context) ([]QueueInfo, error)
+
+	// QueueInfo describes one queue.
+	QueueInfo(ctx context.Context, queue string) (QueueInfo, error)
+
+	// Purge removes all messages from a queue, in flight or not, and
+	// returns how many were removed.
+	Purge(ctx context.Context, queue string) (int, error)
+}
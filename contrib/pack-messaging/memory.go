@@ -0,0 +1,316 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryBroker is an in-process Broker. Unacked deliveries are redelivered
+// after the ack wait, as with hosted brokers. Messages do not survive a
+// restart.
+type MemoryBroker struct {
+	ackWait time.Duration
+
+	mu     sync.Mutex
+	queues map[string]*memQueue
+}
+
+type memMessage struct {
+	id        string
+	body      []byte
+	headers   map[string]string
+	published time.Time
+	attempts  int
+}
+
+type memDelivery struct {
+	msg      *memMessage
+	deadline time.Time
+}
+
+type memQueue struct {
+	created  time.Time
+	ready    []*memMessage
+	inFlight map[string]memDelivery
+
+	// signal is closed and replaced whenever messages become ready.
+	signal chan struct{}
+}
+
+// MemoryOption configures the memory broker.
+type MemoryOption func(*MemoryBroker)
+
+// WithAckWait sets how long a delivery may stay unacked before it is
+// redelivered. Defaults to 30 seconds.
+func WithAckWait(d time.Duration) MemoryOption {
+	return func(b *MemoryBroker) {
+		if d > 0 {
+			b.ackWait = d
+		}
+	}
+}
+
+// NewMemoryBroker creates an in-memory broker.
+func NewMemoryBroker(opts ...MemoryOption) *MemoryBroker {
+	b := &MemoryBroker{
+		ackWait: 30 * time.Second,
+		queues:  make(map[string]*memQueue),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func (q *memQueue) wake() {
+	close(q.signal)
+	q.signal = make(chan struct{})
+}
+
+// reclaim returns expired deliveries to the front of the queue, oldest
+// first, and reports the next deadline still pending.
+func (q *memQueue) reclaim(now time.Time) time.Time {
+	var expired []*memMessage
+	var next time.Time
+	for id, d := range q.inFlight {
+		if !now.Before(d.deadline) {
+			expired = append(expired, d.msg)
+			delete(q.inFlight, id)
+		} else if next.IsZero() || d.deadline.Before(next) {
+			next = d.deadline
+		}
+	}
+	if len(expired) > 0 {
+		sort.Slice(expired, func(i, j int) bool { return expired[i].published.Before(expired[j].published) })
+		q.ready = append(expired, q.ready...)
+		q.wake()
+	}
+	return next
+}
+
+func (b *MemoryBroker) queue(name string) (*memQueue, error) {
+	q, ok := b.queues[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrQueueNotFound, name)
+	}
+	return q, nil
+}
+
+// Publish appends a message, creating the queue if needed.
+func (b *MemoryBroker) Publish(ctx context.Context, queue string, msg Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if err := ValidateQueueName(queue); err != nil {
+		return "", err
+	}
+	m := &memMessage{
+		id:        uuid.NewString(),
+		body:      append([]byte(nil), msg.Body...),
+		headers:   maps.Clone(msg.Headers),
+		published: time.Now().UTC(),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q, ok := b.queues[queue]
+	if !ok {
+		q = &memQueue{
+			created:  m.published,
+			inFlight: make(map[string]memDelivery),
+			signal:   make(chan struct{}),
+		}
+		b.queues[queue] = q
+	}
+	q.ready = append(q.ready, m)
+	q.wake()
+	return m.id, nil
+}
+
+// Pull receives up to opts.Max messages, waiting at most opts.Wait for
+// the first one.
+func (b *MemoryBroker) Pull(ctx context.Context, queue string, opts PullOptions) ([]Delivery, error) {
+	if opts.Max <= 0 {
+		opts.Max = 1
+	}
+	deadline := time.Now().Add(opts.Wait)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		b.mu.Lock()
+		q, err := b.queue(queue)
+		if err != nil {
+			b.mu.Unlock()
+			return nil, err
+		}
+		now := time.Now()
+		next := q.reclaim(now)
+
+		if n := min(opts.Max, len(q.ready)); n > 0 {
+			deliveries := make([]Delivery, n)
+			for i, m := range q.ready[:n] {
+				m.attempts++
+				id := uuid.NewString()
+				q.inFlight[id] = memDelivery{msg: m, deadline: now.Add(b.ackWait)}
+				deliveries[i] = Delivery{
+					DeliveryID:  id,
+					MessageID:   m.id,
+					Queue:       queue,
+					Body:        m.body,
+					Headers:     m.headers,
+					PublishedAt: m.published,
+					Attempt:     m.attempts,
+				}
+			}
+			q.ready = q.ready[n:]
+			b.mu.Unlock()
+			return deliveries, nil
+		}
+		signal := q.signal
+		b.mu.Unlock()
+
+		if !now.Before(deadline) {
+			return []Delivery{}, nil
+		}
+		// Wake for new messages, the pull deadline, or the next
+		// redelivery, whichever comes first.
+		wake := deadline
+		if !next.IsZero() && next.Before(wake) {
+			wake = next
+		}
+		timer := time.NewTimer(time.Until(wake))
+		select {
+		case <-signal:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+}
+
+// settle removes a live delivery from the in-flight set.
+func (b *MemoryBroker) settle(queue, deliveryID string) (*memQueue, *memMessage, error) {
+	q, err := b.queue(queue)
+	if err != nil {
+		return nil, nil, err
+	}
+	q.reclaim(time.Now())
+	d, ok := q.inFlight[deliveryID]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %s", ErrDeliveryNotFound, deliveryID)
+	}
+	delete(q.inFlight, deliveryID)
+	return q, d.msg, nil
+}
+
+// Ack settles a delivery as processed.
+func (b *MemoryBroker) Ack(ctx context.Context, queue, deliveryID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, _, err := b.settle(queue, deliveryID)
+	return err
+}
+
+// Nack settles a delivery as failed, requeueing it at the front of the
+// queue or dropping it.
+func (b *MemoryBroker) Nack(ctx context.Context, queue, deliveryID string, requeue bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q, m, err := b.settle(queue, deliveryID)
+	if err != nil {
+		return err
+	}
+	if requeue {
+		q.ready = append([]*memMessage{m}, q.ready...)
+		q.wake()
+	}
+	return nil
+}
+
+func (b *MemoryBroker) info(name string, q *memQueue) QueueInfo {
+	q.reclaim(time.Now())
+	info := QueueInfo{
+		Name:      name,
+		Ready:     len(q.ready),
+		InFlight:  len(q.inFlight),
+		CreatedAt: q.created,
+	}
+	var oldest time.Time
+	for _, m := range q.ready {
+		if oldest.IsZero() || m.published.Before(oldest) {
+			oldest = m.published
+		}
+	}
+	for _, d := range q.inFlight {
+		if oldest.IsZero() || d.msg.published.Before(oldest) {
+			oldest = d.msg.published
+		}
+	}
+	if !oldest.IsZero() {
+		info.Oldest = &oldest
+	}
+	return info
+}
+
+// ListQueues returns all queues sorted by name.
+func (b *MemoryBroker) ListQueues(ctx context.Context) ([]QueueInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	names := make([]string, 0, len(b.queues))
+	for name := range b.queues {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	infos := make([]QueueInfo, len(names))
+	for i, name := range names {
+		infos[i] = b.info(name, b.queues[name])
+	}
+	return infos, nil
+}
+
+// QueueInfo describes one queue.
+func (b *MemoryBroker) QueueInfo(ctx context.Context, queue string) (QueueInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return QueueInfo{}, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q, err := b.queue(queue)
+	if err != nil {
+		return QueueInfo{}, err
+	}
+	return b.info(queue, q), nil
+}
+
+// Purge removes all messages from a queue.
+func (b *MemoryBroker) Purge(ctx context.Context, queue string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q, err := b.queue(queue)
+	if err != nil {
+		return 0, err
+	}
+	n := len(q.ready) + len(q.inFlight)
+	q.ready = nil
+	q.inFlight = make(map[string]memDelivery)
+	return n, nil
+}
@@ -9,81 +9,373 @@
 //   - mq_queue_info: Get queue statistics and metadata
 //   - mq_purge: Purge all messages from a queue
 //
-// Supports RabbitMQ, Apache Kafka, AWS SQS, Google Pub/Sub, and NATS.
+// Queues are provided by a Broker with at-least-once delivery. The
+// built-in MemoryBroker runs in-process; NATSBroker uses NATS JetStream
+// work-queue streams. mq_subscribe is a bounded pull: it waits at most a
+// capped duration and returns, so a tool call never blocks forever.
+// Received messages must be settled with mq_ack or mq_nack before the
+// broker's ack wait elapses, or they are delivered again.
 package messaging
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Config configures the messaging pack.
+type Config struct {
+	// Broker provides the queues. Defaults to NewMemoryBroker().
+	Broker Broker
+
+	// AllowedQueues restricts accessible queues by path.Match pattern,
+	// such as "jobs-*". Empty allows all queues.
+	AllowedQueues []string
+
+	// DeniedQueues blocks queues by pattern. Takes precedence over
+	// AllowedQueues.
+	DeniedQueues []string
+
+	// MaxPull caps the messages returned per mq_subscribe. Defaults to 100.
+	MaxPull int
+
+	// MaxWait caps how long mq_subscribe waits for messages. Defaults to
+	// 30 seconds.
+	MaxWait time.Duration
+
+	// MaxMessageBytes caps published message bodies. Defaults to 1 MiB.
+	MaxMessageBytes int
+}
+
+// defaultWait is how long mq_subscribe waits when the call does not say.
+const defaultWait = time.Second
+
 // Pack returns the messaging tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.Broker == nil {
+		cfg.Broker = NewMemoryBroker()
+	}
+	if cfg.MaxPull <= 0 {
+		cfg.MaxPull = 100
+	}
+	if cfg.MaxWait <= 0 {
+		cfg.MaxWait = 30 * time.Second
+	}
+	if cfg.MaxMessageBytes <= 0 {
+		cfg.MaxMessageBytes = 1 << 20
+	}
+	p := &messagingPack{cfg: cfg}
+
 	return pack.NewBuilder("messaging").
 		WithDescription("Message queue tools for pub/sub and queue operations").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			mqPublish(),
-			mqSubscribe(),
-			mqAck(),
-			mqNack(),
-			mqListQueues(),
-			mqQueueInfo(),
-			mqPurge(),
+			p.mqPublish(),
+			p.mqSubscribe(),
+			p.mqAck(),
+			p.mqNack(),
+			p.mqListQueues(),
+			p.mqQueueInfo(),
+			p.mqPurge(),
 		).
 		AllowInState(agent.StateExplore, "mq_list_queues", "mq_queue_info").
 		AllowInState(agent.StateAct, "mq_publish", "mq_subscribe", "mq_ack", "mq_nack", "mq_list_queues", "mq_queue_info", "mq_purge").
 		Build()
 }
 
-func mqPublish() tool.Tool {
+type messagingPack struct {
+	cfg Config
+}
+
+func matchQueue(patterns []string, queue string) bool {
+	for _, pattern := range patterns {
+		if pattern == queue {
+			return true
+		}
+		if ok, _ := path.Match(pattern, queue); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *messagingPack) allowed(queue string) bool {
+	if matchQueue(p.cfg.DeniedQueues, queue) {
+		return false
+	}
+	return len(p.cfg.AllowedQueues) == 0 || matchQueue(p.cfg.AllowedQueues, queue)
+}
+
+func (p *messagingPack) checkQueue(queue string) error {
+	if err := ValidateQueueName(queue); err != nil {
+		return err
+	}
+	if !p.allowed(queue) {
+		return fmt.Errorf("%w: %s", ErrQueueNotAllowed, queue)
+	}
+	return nil
+}
+
+// messageBody accepts a string body verbatim and encodes any other JSON
+// value as JSON.
+func messageBody(raw json.RawMessage) ([]byte, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, errors.New("body is required")
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return []byte(s), nil
+	}
+	return raw, nil
+}
+
+// settleInput identifies a delivery to ack or nack.
+type settleInput struct {
+	Queue      string `json:"queue"`
+	DeliveryID string `json:"delivery_id"`
+}
+
+func (in settleInput) validate(p *messagingPack) error {
+	if err := p.checkQueue(in.Queue); err != nil {
+		return err
+	}
+	if in.DeliveryID == "" {
+		return errors.New("delivery_id is required")
+	}
+	return nil
+}
+
+// ============================================================================
+// Queue Tools
+// ============================================================================
+
+func (p *messagingPack) mqPublish() tool.Tool {
 	return tool.NewBuilder("mq_publish").
-		WithDescription("Publish a message to a topic or queue").
+		WithDescription("Publish a message to a queue; a string body is sent verbatim, any other JSON value is sent as JSON").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Queue   string            `json:"queue"`
+				Body    json.RawMessage   `json:"body"`
+				Headers map[string]string `json:"headers,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkQueue(in.Queue); err != nil {
+				return tool.Result{}, err
+			}
+			body, err := messageBody(in.Body)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if len(body) > p.cfg.MaxMessageBytes {
+				return tool.Result{}, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrMessageTooLarge, len(body), p.cfg.MaxMessageBytes)
+			}
+
+			id, err := p.cfg.Broker.Publish(ctx, in.Queue, Message{Body: body, Headers: in.Headers})
+			if err != nil {
+				return tool.Result{}, err
+			}
+			output, _ := json.Marshal(map[string]any{
+				"queue":      in.Queue,
+				"message_id": id,
+				"bytes":      len(body),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func mqSubscribe() tool.Tool {
+func (p *messagingPack) mqSubscribe() tool.Tool {
 	return tool.NewBuilder("mq_subscribe").
-		WithDescription("Subscribe and receive messages from a topic or queue").
+		WithDescription("Receive up to max messages from a queue, waiting at most wait_seconds; settle each delivery with mq_ack or mq_nack").
 		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Queue       string   `json:"queue"`
+				Max         int      `json:"max,omitempty"`
+				WaitSeconds *float64 `json:"wait_seconds,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkQueue(in.Queue); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Max <= 0 {
+				in.Max = 1
+			}
+			in.Max = min(in.Max, p.cfg.MaxPull)
+			wait := min(defaultWait, p.cfg.MaxWait)
+			if in.WaitSeconds != nil {
+				if *in.WaitSeconds < 0 {
+					return tool.Result{}, errors.New("wait_seconds must not be negative")
+				}
+				// Clamp before converting: large values overflow a Duration.
+				wait = time.Duration(min(*in.WaitSeconds, p.cfg.MaxWait.Seconds()) * float64(time.Second))
+			}
+
+			deliveries, err := p.cfg.Broker.Pull(ctx, in.Queue, PullOptions{Max: in.Max, Wait: wait})
+			if err != nil {
+				return tool.Result{}, err
+			}
+			type message struct {
+				Delivery
+				Body string `json:"body"`
+			}
+			messages := make([]message, len(deliveries))
+			for i, d := range deliveries {
+				messages[i] = message{Delivery: d, Body: string(d.Body)}
+			}
+			output, _ := json.Marshal(map[string]any{
+				"queue":    in.Queue,
+				"messages": messages,
+				"count":    len(messages),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func mqAck() tool.Tool {
+func (p *messagingPack) mqAck() tool.Tool {
 	return tool.NewBuilder("mq_ack").
 		WithDescription("Acknowledge successful message processing").
 		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in settleInput
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := in.validate(p); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.cfg.Broker.Ack(ctx, in.Queue, in.DeliveryID); err != nil {
+				return tool.Result{}, err
+			}
+			output, _ := json.Marshal(map[string]any{
+				"queue":       in.Queue,
+				"delivery_id": in.DeliveryID,
+				"acked":       true,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func mqNack() tool.Tool {
+func (p *messagingPack) mqNack() tool.Tool {
 	return tool.NewBuilder("mq_nack").
-		WithDescription("Negative acknowledge a message for requeue or dead-letter").
+		WithDescription("Negative acknowledge a message; it is requeued for redelivery unless requeue is false, in which case it is dropped").
 		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				settleInput
+				Requeue *bool `json:"requeue,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := in.validate(p); err != nil {
+				return tool.Result{}, err
+			}
+			requeue := in.Requeue == nil || *in.Requeue
+			if err := p.cfg.Broker.Nack(ctx, in.Queue, in.DeliveryID, requeue); err != nil {
+				return tool.Result{}, err
+			}
+			output, _ := json.Marshal(map[string]any{
+				"queue":       in.Queue,
+				"delivery_id": in.DeliveryID,
+				"requeued":    requeue,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func mqListQueues() tool.Tool {
+// ============================================================================
+// Admin Tools
+// ============================================================================
+
+func (p *messagingPack) mqListQueues() tool.Tool {
 	return tool.NewBuilder("mq_list_queues").
 		WithDescription("List available queues and topics").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, _ json.RawMessage) (tool.Result, error) {
+			infos, err := p.cfg.Broker.ListQueues(ctx)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			queues := make([]QueueInfo, 0, len(infos))
+			for _, info := range infos {
+				if p.allowed(info.Name) {
+					queues = append(queues, info)
+				}
+			}
+			output, _ := json.Marshal(map[string]any{
+				"queues": queues,
+				"count":  len(queues),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func mqQueueInfo() tool.Tool {
+func (p *messagingPack) mqQueueInfo() tool.Tool {
 	return tool.NewBuilder("mq_queue_info").
 		WithDescription("Get queue statistics and metadata").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Queue string `json:"queue"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkQueue(in.Queue); err != nil {
+				return tool.Result{}, err
+			}
+			info, err := p.cfg.Broker.QueueInfo(ctx, in.Queue)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			output, _ := json.Marshal(info)
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func mqPurge() tool.Tool {
+func (p *messagingPack) mqPurge() tool.Tool {
 	return tool.NewBuilder("mq_purge").
-		WithDescription("Purge all messages from a queue").
+		WithDescription("Purge all messages from a queue, including unacknowledged deliveries").
 		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Queue string `json:"queue"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkQueue(in.Queue); err != nil {
+				return tool.Result{}, err
+			}
+			n, err := p.cfg.Broker.Purge(ctx, in.Queue)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			output, _ := json.Marshal(map[string]any{
+				"queue":  in.Queue,
+				"purged": n,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
@@ -0,0 +1,245 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+// natsBroker starts an embedded JetStream server for the test.
+func natsBroker(t *testing.T, ackWait time.Duration) Broker {
+	t.Helper()
+	srv, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Start()
+	t.Cleanup(srv.Shutdown)
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	nc, err := nats.Connect(srv.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	b, err := NewNATSBroker(NATSConfig{Conn: nc, AckWait: ackWait})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func brokers(t *testing.T, ackWait time.Duration) map[string]Broker {
+	return map[string]Broker{
+		"memory": NewMemoryBroker(WithAckWait(ackWait)),
+		"nats":   natsBroker(t, ackWait),
+	}
+}
+
+func subscribe(t *testing.T, p *pack.Pack, queue string, max int, wait float64) []map[string]any {
+	t.Helper()
+	out, err := call(p, "mq_subscribe", map[string]any{"queue": queue, "max": max, "wait_seconds": wait})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []map[string]any
+	for _, m := range out["messages"].([]any) {
+		messages = append(messages, m.(map[string]any))
+	}
+	return messages
+}
+
+func TestPublishSubscribeAck(t *testing.T) {
+	t.Parallel()
+	for name, broker := range brokers(t, time.Minute) {
+		t.Run(name, func(t *testing.T) {
+			p := Pack(Config{Broker: broker})
+
+			if _, err := call(p, "mq_publish", map[string]any{"queue": "jobs", "body": "first", "headers": map[string]string{"Kind": "text"}}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := call(p, "mq_publish", map[string]any{"queue": "jobs", "body": map[string]any{"n": 2}}); err != nil {
+				t.Fatal(err)
+			}
+
+			messages := subscribe(t, p, "jobs", 10, 1)
+			if len(messages) != 2 {
+				t.Fatalf("expected 2 messages, got %v", messages)
+			}
+			if messages[0]["body"] != "first" || messages[1]["body"] != `{"n":2}` {
+				t.Fatalf("unexpected bodies: %v", messages)
+			}
+			if messages[0]["headers"].(map[string]any)["Kind"] != "text" || messages[0]["attempt"] != 1.0 {
+				t.Fatalf("unexpected delivery: %v", messages[0])
+			}
+
+			info, err := call(p, "mq_queue_info", map[string]any{"queue": "jobs"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info["ready"] != 0.0 || info["in_flight"] != 2.0 {
+				t.Fatalf("unexpected info: %v", info)
+			}
+
+			for _, m := range messages {
+				if _, err := call(p, "mq_ack", map[string]any{"queue": "jobs", "delivery_id": m["delivery_id"]}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			info, _ = call(p, "mq_queue_info", map[string]any{"queue": "jobs"})
+			if info["ready"] != 0.0 || info["in_flight"] != 0.0 {
+				t.Fatalf("unexpected info after ack: %v", info)
+			}
+			if _, err := call(p, "mq_ack", map[string]any{"queue": "jobs", "delivery_id": "bogus"}); !errors.Is(err, ErrDeliveryNotFound) {
+				t.Fatalf("expected ErrDeliveryNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSubscribeIsBounded(t *testing.T) {
+	t.Parallel()
+	for name, broker := range brokers(t, time.Minute) {
+		t.Run(name, func(t *testing.T) {
+			p := Pack(Config{Broker: broker, MaxWait: 200 * time.Millisecond})
+			if _, err := call(p, "mq_publish", map[string]any{"queue": "idle", "body": "x"}); err != nil {
+				t.Fatal(err)
+			}
+			subscribe(t, p, "idle", 1, 0)
+
+			// An empty queue returns after MaxWait even when asked to
+			// wait much longer, including waits too long for a Duration.
+			for _, wait := range []float64{3600, 1e12} {
+				start := time.Now()
+				if messages := subscribe(t, p, "idle", 1, wait); len(messages) != 0 {
+					t.Fatalf("expected no messages, got %v", messages)
+				}
+				if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > 5*time.Second {
+					t.Fatalf("subscribe with wait_seconds %v waited %v", wait, elapsed)
+				}
+			}
+		})
+	}
+}
+
+func TestNackAndRedelivery(t *testing.T) {
+	t.Parallel()
+	for name, broker := range brokers(t, time.Second) {
+		t.Run(name, func(t *testing.T) {
+			p := Pack(Config{Broker: broker})
+			if _, err := call(p, "mq_publish", map[string]any{"queue": "retry", "body": "work"}); err != nil {
+				t.Fatal(err)
+			}
+
+			first := subscribe(t, p, "retry", 1, 1)
+			if _, err := call(p, "mq_nack", map[string]any{"queue": "retry", "delivery_id": first[0]["delivery_id"]}); err != nil {
+				t.Fatal(err)
+			}
+			second := subscribe(t, p, "retry", 1, 2)
+			if len(second) != 1 || second[0]["attempt"] != 2.0 || second[0]["message_id"] != first[0]["message_id"] {
+				t.Fatalf("expected requeued message, got %v", second)
+			}
+
+			// Unsettled deliveries come back after the ack wait.
+			third := subscribe(t, p, "retry", 1, 5)
+			if len(third) != 1 || third[0]["attempt"] != 3.0 {
+				t.Fatalf("expected redelivery after ack wait, got %v", third)
+			}
+
+			if _, err := call(p, "mq_nack", map[string]any{"queue": "retry", "delivery_id": third[0]["delivery_id"], "requeue": false}); err != nil {
+				t.Fatal(err)
+			}
+			if messages := subscribe(t, p, "retry", 1, 0); len(messages) != 0 {
+				t.Fatalf("dropped message was redelivered: %v", messages)
+			}
+		})
+	}
+}
+
+func TestListAndPurge(t *testing.T) {
+	t.Parallel()
+	for name, broker := range brokers(t, time.Minute) {
+		t.Run(name, func(t *testing.T) {
+			p := Pack(Config{Broker: broker, DeniedQueues: []string{"internal-*"}})
+			for _, queue := range []string{"orders", "emails"} {
+				for range 3 {
+					if _, err := call(p, "mq_publish", map[string]any{"queue": queue, "body": "m"}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if _, err := broker.Publish(context.Background(), "internal-audit", Message{Body: []byte("x")}); err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := call(p, "mq_list_queues", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			queues := out["queues"].([]any)
+			if len(queues) != 2 || queues[0].(map[string]any)["name"] != "emails" || queues[1].(map[string]any)["ready"] != 3.0 {
+				t.Fatalf("unexpected queues: %v", queues)
+			}
+
+			subscribe(t, p, "orders", 1, 1)
+			out, err = call(p, "mq_purge", map[string]any{"queue": "orders"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out["purged"] != 3.0 {
+				t.Fatalf("expected 3 purged, got %v", out)
+			}
+			if messages := subscribe(t, p, "orders", 10, 0); len(messages) != 0 {
+				t.Fatalf("purged queue still delivers: %v", messages)
+			}
+
+			if _, err := call(p, "mq_purge", map[string]any{"queue": "internal-audit"}); !errors.Is(err, ErrQueueNotAllowed) {
+				t.Fatalf("expected ErrQueueNotAllowed, got %v", err)
+			}
+			if _, err := call(p, "mq_queue_info", map[string]any{"queue": "missing"}); !errors.Is(err, ErrQueueNotFound) {
+				t.Fatalf("expected ErrQueueNotFound, got %v", err)
+			}
+			if _, err := call(p, "mq_publish", map[string]any{"queue": "bad.name", "body": "x"}); !errors.Is(err, ErrInvalidQueue) {
+				t.Fatalf("expected ErrInvalidQueue, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPublishRejectsLargeMessages(t *testing.T) {
+	t.Parallel()
+	p := Pack(Config{MaxMessageBytes: 4})
+	if _, err := call(p, "mq_publish", map[string]any{"queue": "q", "body": "too long"}); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("expected ErrMessageTooLarge, got %v", err)
+	}
+	if _, err := call(p, "mq_publish", map[string]any{"queue": "q"}); err == nil {
+		t.Fatal("expected an error for a missing body")
+	}
+}
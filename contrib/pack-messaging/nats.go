@@ -0,0 +1,336 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSConfig configures the NATS JetStream broker.
+type NATSConfig struct {
+	// Conn is a connection to a server with JetStream enabled. The broker
+	// does not close it.
+	Conn *nats.Conn
+
+	// StreamPrefix prefixes stream names. Each queue is a work-queue
+	// stream named StreamPrefix + queue. Defaults to "MQ_".
+	StreamPrefix string
+
+	// SubjectPrefix prefixes the subject of each queue, as
+	// SubjectPrefix + "." + queue. Defaults to "mq".
+	SubjectPrefix string
+
+	// Consumer names the durable pull consumer shared by all pulls of a
+	// queue. Defaults to "agent-go".
+	Consumer string
+
+	// AckWait is how long a delivery may stay unacked before it is
+	// redelivered. Defaults to 30 seconds.
+	AckWait time.Duration
+
+	// MaxAge drops messages older than this. Zero keeps them until acked.
+	MaxAge time.Duration
+
+	// Storage selects file or memory storage for new streams. Defaults to
+	// file storage.
+	Storage jetstream.StorageType
+}
+
+// NATSBroker is a Broker on NATS JetStream. Delivery IDs are the ack
+// subjects of JetStream messages, so any broker instance on the same
+// server can settle them.
+type NATSBroker struct {
+	cfg NATSConfig
+	js  jetstream.JetStream
+
+	mu        sync.Mutex
+	consumers map[string]jetstream.Consumer
+}
+
+// NewNATSBroker creates a broker on a JetStream-enabled connection.
+func NewNATSBroker(cfg NATSConfig) (*NATSBroker, error) {
+	if cfg.Conn == nil {
+		return nil, errors.New("nats: connection is required")
+	}
+	if cfg.StreamPrefix == "" {
+		cfg.StreamPrefix = "MQ_"
+	}
+	if cfg.SubjectPrefix == "" {
+		cfg.SubjectPrefix = "mq"
+	}
+	if cfg.Consumer == "" {
+		cfg.Consumer = "agent-go"
+	}
+	if cfg.AckWait <= 0 {
+		cfg.AckWait = 30 * time.Second
+	}
+	js, err := jetstream.New(cfg.Conn)
+	if err != nil {
+		return nil, fmt.Errorf("nats: %w", err)
+	}
+	return &NATSBroker{cfg: cfg, js: js, consumers: make(map[string]jetstream.Consumer)}, nil
+}
+
+func (b *NATSBroker) streamName(queue string) string {
+	return b.cfg.StreamPrefix + queue
+}
+
+func (b *NATSBroker) subject(queue string) string {
+	return b.cfg.SubjectPrefix + "." + queue
+}
+
+func (b *NATSBroker) stream(ctx context.Context, queue string) (jetstream.Stream, error) {
+	if err := ValidateQueueName(queue); err != nil {
+		return nil, err
+	}
+	s, err := b.js.Stream(ctx, b.streamName(queue))
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrQueueNotFound, queue)
+	}
+	return s, err
+}
+
+// consumer returns the queue's durable pull consumer, creating it on first
+// use.
+func (b *NATSBroker) consumer(ctx context.Context, queue string) (jetstream.Consumer, error) {
+	b.mu.Lock()
+	c, ok := b.consumers[queue]
+	b.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+	s, err := b.stream(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+	c, err = s.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:   b.cfg.Consumer,
+		AckPolicy: jetstream.AckExplicitPolicy,
+		AckWait:   b.cfg.AckWait,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("nats: create consumer: %w", err)
+	}
+	b.mu.Lock()
+	b.consumers[queue] = c
+	b.mu.Unlock()
+	return c, nil
+}
+
+// Publish appends a message, creating the queue's stream if needed.
+func (b *NATSBroker) Publish(ctx context.Context, queue string, msg Message) (string, error) {
+	if err := ValidateQueueName(queue); err != nil {
+		return "", err
+	}
+	_, err := b.js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:      b.streamName(queue),
+		Subjects:  []string{b.subject(queue)},
+		Retention: jetstream.WorkQueuePolicy,
+		Storage:   b.cfg.Storage,
+		MaxAge:    b.cfg.MaxAge,
+	})
+	if err != nil && !errors.Is(err, jetstream.ErrStreamNameAlreadyInUse) {
+		return "", fmt.Errorf("nats: create stream: %w", err)
+	}
+
+	id := uuid.NewString()
+	m := nats.NewMsg(b.subject(queue))
+	m.Data = msg.Body
+	for k, v := range msg.Headers {
+		m.Header.Set(k, v)
+	}
+	m.Header.Set(jetstream.MsgIDHeader, id)
+	if _, err := b.js.PublishMsg(ctx, m); err != nil {
+		return "", fmt.Errorf("nats: publish: %w", err)
+	}
+	return id, nil
+}
+
+// Pull fetches up to opts.Max messages from the queue's durable consumer.
+func (b *NATSBroker) Pull(ctx context.Context, queue string, opts PullOptions) ([]Delivery, error) {
+	if opts.Max <= 0 {
+		opts.Max = 1
+	}
+	c, err := b.consumer(ctx, queue)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch jetstream.MessageBatch
+	if opts.Wait <= 0 {
+		batch, err = c.FetchNoWait(opts.Max)
+	} else {
+		batch, err = c.Fetch(opts.Max, jetstream.FetchMaxWait(opts.Wait))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("nats: fetch: %w", err)
+	}
+
+	deliveries := []Delivery{}
+	for m := range batch.Messages() {
+		meta, err := m.Metadata()
+		if err != nil {
+			return nil, fmt.Errorf("nats: %w", err)
+		}
+		d := Delivery{
+			DeliveryID:  m.Reply(),
+			MessageID:   m.Headers().Get(jetstream.MsgIDHeader),
+			Queue:       queue,
+			Body:        m.Data(),
+			PublishedAt: meta.Timestamp.UTC(),
+			Attempt:     int(meta.NumDelivered),
+		}
+		if d.MessageID == "" {
+			d.MessageID = strconv.FormatUint(meta.Sequence.Stream, 10)
+		}
+		for k, v := range m.Headers() {
+			if k == jetstream.MsgIDHeader || len(v) == 0 {
+				continue
+			}
+			if d.Headers == nil {
+				d.Headers = make(map[string]string)
+			}
+			d.Headers[k] = v[0]
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := batch.Error(); err != nil && !errors.Is(err, nats.ErrTimeout) && !errors.Is(err, jetstream.ErrNoMessages) {
+		return deliveries, fmt.Errorf("nats: fetch: %w", err)
+	}
+	return deliveries, nil
+}
+
+// settle sends an ack of the given kind to a delivery's ack subject and
+// waits for the server to confirm it.
+func (b *NATSBroker) settle(ctx context.Context, queue, deliveryID, kind string) error {
+	if err := ValidateQueueName(queue); err != nil {
+		return err
+	}
+	// Ack subjects carry the stream and consumer names as consecutive
+	// tokens; only accept subjects for this queue's consumer.
+	tokens := strings.Split(deliveryID, ".")
+	valid := len(tokens) > 4 && tokens[0] == "$JS" && tokens[1] == "ACK"
+	if valid {
+		valid = false
+		for i := 2; i+1 < len(tokens); i++ {
+			if tokens[i] == b.streamName(queue) && tokens[i+1] == b.cfg.Consumer {
+				valid = true
+				break
+			}
+		}
+	}
+	if !valid {
+		return fmt.Errorf("%w: %s", ErrDeliveryNotFound, deliveryID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := b.cfg.Conn.RequestWithContext(ctx, deliveryID, []byte(kind)); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, nats.ErrNoResponders) {
+			return fmt.Errorf("%w: %s", ErrDeliveryNotFound, deliveryID)
+		}
+		return fmt.Errorf("nats: %w", err)
+	}
+	return nil
+}
+
+// Ack settles a delivery as processed.
+func (b *NATSBroker) Ack(ctx context.Context, queue, deliveryID string) error {
+	return b.settle(ctx, queue, deliveryID, "+ACK")
+}
+
+// Nack settles a delivery as failed: requeued deliveries are redelivered
+// immediately, others are terminated and never redelivered.
+func (b *NATSBroker) Nack(ctx context.Context, queue, deliveryID string, requeue bool) error {
+	if requeue {
+		return b.settle(ctx, queue, deliveryID, "-NAK")
+	}
+	return b.settle(ctx, queue, deliveryID, "+TERM")
+}
+
+func (b *NATSBroker) info(ctx context.Context, queue string, s jetstream.Stream) (QueueInfo, error) {
+	si, err := s.Info(ctx)
+	if err != nil {
+		return QueueInfo{}, fmt.Errorf("nats: %w", err)
+	}
+	info := QueueInfo{
+		Name:      queue,
+		Ready:     int(si.State.Msgs),
+		CreatedAt: si.Created.UTC(),
+	}
+	if si.State.Msgs > 0 {
+		oldest := si.State.FirstTime.UTC()
+		info.Oldest = &oldest
+	}
+	// Work-queue streams keep messages until acked, so in-flight messages
+	// are part of the stream state.
+	if c, err := s.Consumer(ctx, b.cfg.Consumer); err == nil {
+		if ci, err := c.Info(ctx); err == nil {
+			info.InFlight = ci.NumAckPending
+			info.Ready = max(0, info.Ready-ci.NumAckPending)
+		}
+	}
+	return info, nil
+}
+
+// ListQueues returns the queues of streams with the configured prefix.
+func (b *NATSBroker) ListQueues(ctx context.Context) ([]QueueInfo, error) {
+	names := b.js.StreamNames(ctx)
+	var queues []string
+	for name := range names.Name() {
+		if queue, ok := strings.CutPrefix(name, b.cfg.StreamPrefix); ok && ValidateQueueName(queue) == nil {
+			queues = append(queues, queue)
+		}
+	}
+	if err := names.Err(); err != nil {
+		return nil, fmt.Errorf("nats: %w", err)
+	}
+	sort.Strings(queues)
+
+	infos := make([]QueueInfo, 0, len(queues))
+	for _, queue := range queues {
+		info, err := b.QueueInfo(ctx, queue)
+		if errors.Is(err, ErrQueueNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// QueueInfo describes one queue.
+func (b *NATSBroker) QueueInfo(ctx context.Context, queue string) (QueueInfo, error) {
+	s, err := b.stream(ctx, queue)
+	if err != nil {
+		return QueueInfo{}, err
+	}
+	return b.info(ctx, queue, s)
+}
+
+// Purge removes all messages from the queue's stream.
+func (b *NATSBroker) Purge(ctx context.Context, queue string) (int, error) {
+	s, err := b.stream(ctx, queue)
+	if err != nil {
+		return 0, err
+	}
+	si, err := s.Info(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("nats: %w", err)
+	}
+	if err := s.Purge(ctx); err != nil {
+		return 0, fmt.Errorf("nats: purge: %w", err)
+	}
+	return int(si.State.Msgs), nil
+}
@@ -0,0 +1,264 @@
+package monitoring
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// amAlert is an alert in the Alertmanager v2 API.
+type amAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	Fingerprint string            `json:"fingerprint"`
+	Status      struct {
+		State       string   `json:"state"`
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+}
+
+// amMatcher is a silence matcher in the Alertmanager v2 API.
+type amMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// severityRank orders common severity labels, most severe first.
+var severityRank = map[string]int{
+	"critical": 0,
+	"page":     0,
+	"error":    1,
+	"high":     1,
+	"warning":  2,
+	"medium":   2,
+	"info":     3,
+	"low":      3,
+	"none":     4,
+}
+
+func rankSeverity(severity string) int {
+	if rank, ok := severityRank[strings.ToLower(severity)]; ok {
+		return rank
+	}
+	return 5
+}
+
+// amError extracts the message of an Alertmanager error response.
+func amError(status int, data []byte) error {
+	msg := strings.TrimSpace(string(data))
+	var body struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		msg = body.Message
+	} else if unquoted, err := strconv.Unquote(msg); err == nil {
+		msg = unquoted
+	}
+	return fmt.Errorf("%w: HTTP %d: %s", ErrRequestFailed, status, msg)
+}
+
+// ============================================================================
+// Alert Tools
+// ============================================================================
+
+func (p *monitoringPack) alertsList() tool.Tool {
+	return tool.NewBuilder("alerts_list").
+		WithDescription("List alerts from Alertmanager, most severe first; silenced and inhibited alerts are excluded unless requested").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Filter           map[string]string `json:"filter,omitempty"`
+				Receiver         string            `json:"receiver,omitempty"`
+				IncludeSilenced  bool              `json:"include_silenced,omitempty"`
+				IncludeInhibited bool              `json:"include_inhibited,omitempty"`
+				Limit            int               `json:"limit,omitempty"`
+			}
+			if len(input) > 0 {
+				if err := json.Unmarshal(input, &in); err != nil {
+					return tool.Result{}, err
+				}
+			}
+
+			query := url.Values{
+				"active":    {"true"},
+				"silenced":  {strconv.FormatBool(in.IncludeSilenced)},
+				"inhibited": {strconv.FormatBool(in.IncludeInhibited)},
+			}
+			for _, name := range slices.Sorted(maps.Keys(in.Filter)) {
+				if !labelNameRegex.MatchString(name) {
+					return tool.Result{}, fmt.Errorf("invalid filter label %q", name)
+				}
+				query.Add("filter", name+"="+strconv.Quote(in.Filter[name]))
+			}
+			if in.Receiver != "" {
+				query.Set("receiver", in.Receiver)
+			}
+			u, err := endpoint(p.cfg.AlertmanagerURL, "Alertmanager", "/api/v2/alerts?"+query.Encode())
+			if err != nil {
+				return tool.Result{}, err
+			}
+			data, status, err := p.call(ctx, http.MethodGet, u, "", nil)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if status != http.StatusOK {
+				return tool.Result{}, amError(status, data)
+			}
+			var alerts []amAlert
+			if err := json.Unmarshal(data, &alerts); err != nil {
+				return tool.Result{}, fmt.Errorf("%w: invalid response: %v", ErrRequestFailed, err)
+			}
+
+			slices.SortStableFunc(alerts, func(a, b amAlert) int {
+				return cmp.Or(
+					cmp.Compare(rankSeverity(a.Labels["severity"]), rankSeverity(b.Labels["severity"])),
+					a.StartsAt.Compare(b.StartsAt),
+				)
+			})
+			bySeverity := make(map[string]int)
+			for _, a := range alerts {
+				severity := a.Labels["severity"]
+				if severity == "" {
+					severity = "none"
+				}
+				bySeverity[severity]++
+			}
+
+			now := time.Now()
+			shown := alerts[:min(p.limit(in.Limit), len(alerts))]
+			items := make([]map[string]any, len(shown))
+			evidence := make([]string, len(shown))
+			for i, a := range shown {
+				name := a.Labels["alertname"]
+				summary := cmp.Or(a.Annotations["summary"], a.Annotations["description"])
+				item := map[string]any{
+					"name":        name,
+					"state":       a.Status.State,
+					"starts_at":   a.StartsAt,
+					"fingerprint": a.Fingerprint,
+					"labels":      a.Labels,
+				}
+				if severity := a.Labels["severity"]; severity != "" {
+					item["severity"] = severity
+				}
+				if summary != "" {
+					item["summary"] = summary
+				}
+				if len(a.Status.SilencedBy) > 0 {
+					item["silenced_by"] = a.Status.SilencedBy
+				}
+				items[i] = item
+
+				line := fmt.Sprintf("[%s] %s %s for %s", cmp.Or(a.Labels["severity"], "none"), name, a.Status.State,
+					now.Sub(a.StartsAt).Round(time.Minute))
+				if summary != "" {
+					line += ": " + summary
+				}
+				evidence[i] = line
+			}
+			output, _ := json.Marshal(map[string]any{
+				"alerts":      items,
+				"evidence":    evidence,
+				"count":       len(alerts),
+				"by_severity": bySeverity,
+				"truncated":   len(alerts) > len(shown),
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *monitoringPack) alertsSilence() tool.Tool {
+	return tool.NewBuilder("alerts_silence").
+		WithDescription("Silence alerts matching exact label values in Alertmanager for a duration; a comment explaining why is required").
+		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Matchers map[string]string `json:"matchers"`
+				Duration string            `json:"duration,omitempty"`
+				Comment  string            `json:"comment"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Matchers) == 0 {
+				return tool.Result{}, errors.New("at least one matcher is required")
+			}
+			if strings.TrimSpace(in.Comment) == "" {
+				return tool.Result{}, errors.New("comment is required")
+			}
+			duration := time.Hour
+			if in.Duration != "" {
+				d, err := time.ParseDuration(in.Duration)
+				if err != nil || d <= 0 {
+					return tool.Result{}, fmt.Errorf("invalid duration %q", in.Duration)
+				}
+				duration = d
+			}
+			if duration > p.cfg.MaxSilence {
+				return tool.Result{}, fmt.Errorf("duration %s exceeds the maximum of %s", duration, p.cfg.MaxSilence)
+			}
+
+			matchers := make([]amMatcher, 0, len(in.Matchers))
+			for _, name := range slices.Sorted(maps.Keys(in.Matchers)) {
+				if !labelNameRegex.MatchString(name) {
+					return tool.Result{}, fmt.Errorf("invalid matcher label %q", name)
+				}
+				// An empty value would also match alerts without the label.
+				if in.Matchers[name] == "" {
+					return tool.Result{}, fmt.Errorf("matcher %s has an empty value", name)
+				}
+				matchers = append(matchers, amMatcher{Name: name, Value: in.Matchers[name], IsEqual: true})
+			}
+
+			start := time.Now().UTC()
+			end := start.Add(duration)
+			body, _ := json.Marshal(map[string]any{
+				"matchers":  matchers,
+				"startsAt":  start,
+				"endsAt":    end,
+				"createdBy": p.cfg.SilenceCreator,
+				"comment":   in.Comment,
+			})
+			u, err := endpoint(p.cfg.AlertmanagerURL, "Alertmanager", "/api/v2/silences")
+			if err != nil {
+				return tool.Result{}, err
+			}
+			data, status, err := p.call(ctx, http.MethodPost, u, "application/json", body)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if status != http.StatusOK {
+				return tool.Result{}, amError(status, data)
+			}
+			var resp struct {
+				SilenceID string `json:"silenceID"`
+			}
+			if err := json.Unmarshal(data, &resp); err != nil {
+				return tool.Result{}, fmt.Errorf("%w: invalid response: %v", ErrRequestFailed, err)
+			}
+			output, _ := json.Marshal(map[string]any{
+				"silence_id": resp.SilenceID,
+				"matchers":   in.Matchers,
+				"starts_at":  start,
+				"ends_at":    end,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
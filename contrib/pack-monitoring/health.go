@@ -0,0 +1,158 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// healthBodyBytes caps the response body read from health endpoints.
+const healthBodyBytes = 64 << 10
+
+// healthResult is the outcome of probing one endpoint.
+type healthResult struct {
+	Name       string `json:"name"`
+	URL        string `json:"url"`
+	Healthy    bool   `json:"healthy"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+
+	// Status is the "status" field of a JSON response body, as reported
+	// by most health endpoints.
+	Status string `json:"status,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// probe performs one GET. 2xx responses are healthy unless the body
+// reports a down status.
+func (p *monitoringPack) probe(ctx context.Context, name, rawURL string, timeout time.Duration) healthResult {
+	result := healthResult{Name: name, URL: rawURL}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	start := time.Now()
+	resp, err := p.cfg.HTTPClient.Do(req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, healthBodyBytes))
+
+	result.StatusCode = resp.StatusCode
+	result.Healthy = resp.StatusCode/100 == 2
+	var body struct {
+		Status string `json:"status"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Status != "" {
+		result.Status = body.Status
+		switch strings.ToLower(body.Status) {
+		case "down", "fail", "failed", "unhealthy", "error", "critical":
+			result.Healthy = false
+		}
+	}
+	return result
+}
+
+func (p *monitoringPack) healthCheck() tool.Tool {
+	return tool.NewBuilder("health_check").
+		WithDescription("Check health of services via HTTP endpoints; probes the named targets, or all configured targets when none are given").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Targets        []string `json:"targets,omitempty"`
+				URLs           []string `json:"urls,omitempty"`
+				TimeoutSeconds int      `json:"timeout_seconds,omitempty"`
+			}
+			if len(input) > 0 {
+				if err := json.Unmarshal(input, &in); err != nil {
+					return tool.Result{}, err
+				}
+			}
+
+			type target struct{ name, url string }
+			var targets []target
+			names := in.Targets
+			if len(names) == 0 && len(in.URLs) == 0 {
+				names = slices.Sorted(maps.Keys(p.cfg.HealthTargets))
+			}
+			for _, name := range names {
+				u, ok := p.cfg.HealthTargets[name]
+				if !ok {
+					return tool.Result{}, fmt.Errorf("%w: unknown target %s", ErrTargetNotAllowed, name)
+				}
+				targets = append(targets, target{name, u})
+			}
+			for _, raw := range in.URLs {
+				if !p.cfg.AllowHealthURLs {
+					return tool.Result{}, fmt.Errorf("%w: only configured targets may be checked", ErrTargetNotAllowed)
+				}
+				u, err := url.Parse(raw)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return tool.Result{}, fmt.Errorf("invalid url %q", raw)
+				}
+				targets = append(targets, target{raw, raw})
+			}
+			if len(targets) == 0 {
+				return tool.Result{}, errors.New("no health check targets configured")
+			}
+
+			timeout := p.cfg.Timeout
+			if in.TimeoutSeconds > 0 {
+				timeout = min(time.Duration(in.TimeoutSeconds)*time.Second, p.cfg.Timeout)
+			}
+			results := make([]healthResult, len(targets))
+			var wg sync.WaitGroup
+			for i, t := range targets {
+				wg.Go(func() {
+					results[i] = p.probe(ctx, t.name, t.url, timeout)
+				})
+			}
+			wg.Wait()
+
+			healthy := 0
+			evidence := make([]string, len(results))
+			for i, r := range results {
+				state := "unhealthy"
+				if r.Healthy {
+					healthy++
+					state = "healthy"
+				}
+				switch {
+				case r.Error != "":
+					evidence[i] = fmt.Sprintf("%s: %s (%s)", r.Name, state, r.Error)
+				case r.Status != "":
+					evidence[i] = fmt.Sprintf("%s: %s (HTTP %d, status %s, %dms)", r.Name, state, r.StatusCode, r.Status, r.LatencyMS)
+				default:
+					evidence[i] = fmt.Sprintf("%s: %s (HTTP %d, %dms)", r.Name, state, r.StatusCode, r.LatencyMS)
+				}
+			}
+			output, _ := json.Marshal(map[string]any{
+				"healthy":  healthy == len(results),
+				"passing":  healthy,
+				"failing":  len(results) - healthy,
+				"results":  results,
+				"evidence": evidence,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
@@ -10,29 +10,130 @@
 //   - health_check: Check service health endpoints
 //   - dashboard_get: Get dashboard configuration
 //
-// Supports Prometheus, Grafana, Datadog, CloudWatch, and OpenTelemetry.
+// Metrics are queried through the Prometheus HTTP API and pushed to a
+// Prometheus Pushgateway; alerts are listed and silenced through the
+// Alertmanager v2 API. Query results are reduced to compact evidence: one
+// line per series with its value, or its range statistics and trend, so
+// that large results do not flood the planner's context. Health checks
+// probe configured HTTP endpoints concurrently. Logs, traces and
+// dashboards have no built-in backend yet.
 package monitoring
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Errors returned by the monitoring tools.
+var (
+	// ErrNotConfigured indicates the backend a tool needs has no URL configured.
+	ErrNotConfigured = errors.New("backend not configured")
+
+	// ErrNotSupported indicates the tool has no built-in backend.
+	ErrNotSupported = errors.New("not supported")
+
+	// ErrRequestFailed indicates the backend rejected or failed a request.
+	ErrRequestFailed = errors.New("monitoring request failed")
+
+	// ErrTargetNotAllowed indicates a health check target outside the
+	// configured targets.
+	ErrTargetNotAllowed = errors.New("health check target not allowed")
+)
+
+// Config configures the monitoring pack.
+type Config struct {
+	// PrometheusURL is the base URL of the Prometheus HTTP API, such as
+	// "http://prometheus:9090". Required for metrics_query.
+	PrometheusURL string
+
+	// AlertmanagerURL is the base URL of Alertmanager, such as
+	// "http://alertmanager:9093". Required for alerts_list and
+	// alerts_silence.
+	AlertmanagerURL string
+
+	// PushgatewayURL is the base URL of a Prometheus Pushgateway.
+	// Required for metrics_push.
+	PushgatewayURL string
+
+	// Headers are sent with every Prometheus, Alertmanager and Pushgateway
+	// request, for example an Authorization header. They are never sent to
+	// health check targets.
+	Headers map[string]string
+
+	// HealthTargets names the endpoints health_check may probe.
+	HealthTargets map[string]string
+
+	// AllowHealthURLs lets health_check probe caller-supplied URLs in
+	// addition to HealthTargets.
+	AllowHealthURLs bool
+
+	// HTTPClient sends all requests. Defaults to a client with Timeout.
+	HTTPClient *http.Client
+
+	// Timeout bounds each request. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	// MaxResults caps the series and alerts summarised per call.
+	// Defaults to 20.
+	MaxResults int
+
+	// MaxResponseBytes caps the response body that is read. Defaults to
+	// 10 MiB.
+	MaxResponseBytes int64
+
+	// MaxSilence caps silence durations. Defaults to 24 hours.
+	MaxSilence time.Duration
+
+	// SilenceCreator is recorded as the author of silences. Defaults to
+	// "agent-go".
+	SilenceCreator string
+}
+
 // Pack returns the monitoring tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: cfg.Timeout}
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = 20
+	}
+	if cfg.MaxResponseBytes <= 0 {
+		cfg.MaxResponseBytes = 10 << 20
+	}
+	if cfg.MaxSilence <= 0 {
+		cfg.MaxSilence = 24 * time.Hour
+	}
+	if cfg.SilenceCreator == "" {
+		cfg.SilenceCreator = "agent-go"
+	}
+	p := &monitoringPack{cfg: cfg}
+
 	return pack.NewBuilder("monitoring").
 		WithDescription("Monitoring and observability tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			metricsQuery(),
-			metricsPush(),
-			alertsList(),
-			alertsSilence(),
-			logsQuery(),
-			tracesQuery(),
-			healthCheck(),
-			dashboardGet(),
+			p.metricsQuery(),
+			p.metricsPush(),
+			p.alertsList(),
+			p.alertsSilence(),
+			p.logsQuery(),
+			p.tracesQuery(),
+			p.healthCheck(),
+			p.dashboardGet(),
 		).
 		AllowInState(agent.StateExplore, "metrics_query", "alerts_list", "logs_query", "traces_query", "health_check", "dashboard_get").
 		AllowInState(agent.StateAct, "metrics_query", "metrics_push", "alerts_list", "alerts_silence", "logs_query", "traces_query", "health_check", "dashboard_get").
@@ -40,59 +141,94 @@ func Pack() *pack.Pack {
 		Build()
 }
 
-func metricsQuery() tool.Tool {
-	return tool.NewBuilder("metrics_query").
-		WithDescription("Query metrics using PromQL or similar query language").
-		ReadOnly().
-		MustBuild()
+type monitoringPack struct {
+	cfg Config
 }
 
-func metricsPush() tool.Tool {
-	return tool.NewBuilder("metrics_push").
-		WithDescription("Push custom metrics to a monitoring system").
-		WithRiskLevel(tool.RiskLow).
-		MustBuild()
+// endpoint joins a configured base URL and an API path.
+func endpoint(base, name, apiPath string) (string, error) {
+	if base == "" {
+		return "", fmt.Errorf("%w: %s URL is not set", ErrNotConfigured, name)
+	}
+	return strings.TrimSuffix(base, "/") + apiPath, nil
 }
 
-func alertsList() tool.Tool {
-	return tool.NewBuilder("alerts_list").
-		WithDescription("List active alerts and their status").
-		ReadOnly().
-		MustBuild()
+// call sends a request to a configured backend and returns the response
+// body and status.
+func (p *monitoringPack) call(ctx context.Context, method, rawURL, contentType string, body []byte) ([]byte, int, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := p.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrRequestFailed, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.cfg.MaxResponseBytes+1))
+	if err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("%w: %v", ErrRequestFailed, err)
+	}
+	if int64(len(data)) > p.cfg.MaxResponseBytes {
+		return nil, resp.StatusCode, fmt.Errorf("%w: response exceeds %d bytes", ErrRequestFailed, p.cfg.MaxResponseBytes)
+	}
+	return data, resp.StatusCode, nil
 }
 
-func alertsSilence() tool.Tool {
-	return tool.NewBuilder("alerts_silence").
-		WithDescription("Silence an alert for a specified duration").
-		WithRiskLevel(tool.RiskMedium).
-		MustBuild()
+// limit applies a caller-supplied limit capped by MaxResults.
+func (p *monitoringPack) limit(n int) int {
+	if n <= 0 {
+		return p.cfg.MaxResults
+	}
+	return min(n, p.cfg.MaxResults)
+}
+
+// ============================================================================
+// Unsupported Tools
+// ============================================================================
+
+func notSupported(what string) tool.Handler {
+	return func(context.Context, json.RawMessage) (tool.Result, error) {
+		return tool.Result{}, fmt.Errorf("%w: no %s backend is built in", ErrNotSupported, what)
+	}
 }
 
-func logsQuery() tool.Tool {
+func (p *monitoringPack) logsQuery() tool.Tool {
 	return tool.NewBuilder("logs_query").
 		WithDescription("Query structured logs using LogQL or similar").
 		ReadOnly().
+		WithHandler(notSupported("logs")).
 		MustBuild()
 }
 
-func tracesQuery() tool.Tool {
+func (p *monitoringPack) tracesQuery() tool.Tool {
 	return tool.NewBuilder("traces_query").
 		WithDescription("Query distributed traces by trace ID or filters").
 		ReadOnly().
+		WithHandler(notSupported("tracing")).
 		MustBuild()
 }
 
-func healthCheck() tool.Tool {
-	return tool.NewBuilder("health_check").
-		WithDescription("Check health of services via HTTP endpoints").
-		ReadOnly().
-		MustBuild()
-}
-
-func dashboardGet() tool.Tool {
+func (p *monitoringPack) dashboardGet() tool.Tool {
 	return tool.NewBuilder("dashboard_get").
 		WithDescription("Get dashboard configuration and panels").
 		ReadOnly().
 		Cacheable().
+		WithHandler(notSupported("dashboard")).
 		MustBuild()
 }
@@ -0,0 +1,300 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+func strs(v any) []string {
+	var out []string
+	for _, s := range v.([]any) {
+		out = append(out, s.(string))
+	}
+	return out
+}
+
+// prometheus is a stand-in for the Prometheus query API.
+func prometheus(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = r.ParseForm()
+		query := r.PostForm.Get("query")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case query == "bad(":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"status":"error","errorType":"bad_data","error":"parse error: unexpected end of input"}`)
+		case r.URL.Path == "/api/v1/query" && query == "up":
+			_, _ = io.WriteString(w, `{"status":"success","data":{"resultType":"vector","result":[
+				{"metric":{"__name__":"up","job":"api","instance":"a:80"},"value":[1760000000,"1"]},
+				{"metric":{"__name__":"up","job":"api","instance":"b:80"},"value":[1760000000,"0"]},
+				{"metric":{"__name__":"up","job":"db","instance":"c:5432"},"value":[1760000000,"1"]}]}}`)
+		case r.URL.Path == "/api/v1/query" && query == "scalar(1)":
+			_, _ = io.WriteString(w, `{"status":"success","data":{"resultType":"scalar","result":[1760000000,"1"]}}`)
+		case r.URL.Path == "/api/v1/query_range":
+			if r.PostForm.Get("step") != "60" || r.PostForm.Get("start") == "" {
+				t.Errorf("unexpected range params: %v", r.PostForm)
+			}
+			_, _ = io.WriteString(w, `{"status":"success","data":{"resultType":"matrix","result":[
+				{"metric":{"route":"/login"},"values":[[1760000000,"0.1"],[1760000060,"0.2"],[1760000120,"0.9"]]},
+				{"metric":{"route":"/home"},"values":[[1760000000,"0.05"],[1760000060,"NaN"],[1760000120,"0.05"]]}]},
+				"warnings":["partial data"]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.URL.Path, query)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMetricsQueryInstant(t *testing.T) {
+	t.Parallel()
+	srv := prometheus(t)
+	p := Pack(Config{PrometheusURL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}, MaxResults: 2})
+
+	out, err := call(p, "metrics_query", map[string]any{"query": "up"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	evidence := strs(out["evidence"])
+	if out["count"] != 3.0 || out["truncated"] != true || len(evidence) != 2 {
+		t.Fatalf("unexpected output: %v", out)
+	}
+	if evidence[0] != `up{instance="a:80",job="api"} = 1` {
+		t.Errorf("unexpected evidence: %v", evidence)
+	}
+	if stats := out["stats"].(map[string]any); stats["min"] != 0.0 || stats["sum"] != 2.0 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+
+	out, err = call(p, "metrics_query", map[string]any{"query": "scalar(1)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["result_type"] != "scalar" || out["value"] != "1" {
+		t.Fatalf("unexpected scalar output: %v", out)
+	}
+
+	if _, err := call(p, "metrics_query", map[string]any{"query": "bad("}); !errors.Is(err, ErrRequestFailed) || !strings.Contains(err.Error(), "parse error") {
+		t.Fatalf("expected query error, got %v", err)
+	}
+}
+
+func TestMetricsQueryRangeSummarisesSeries(t *testing.T) {
+	t.Parallel()
+	srv := prometheus(t)
+	p := Pack(Config{PrometheusURL: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+
+	out, err := call(p, "metrics_query", map[string]any{"query": "errors", "range": "1h", "step": "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	series := out["series"].([]any)
+	login := series[0].(map[string]any)
+	if login["series"] != `{route="/login"}` || login["trend"] != "rising" || login["max"] != 0.9 || login["samples"] != 3.0 {
+		t.Fatalf("unexpected series: %v", login)
+	}
+	home := series[1].(map[string]any)
+	if home["samples"] != 2.0 || home["trend"] != "flat" {
+		t.Fatalf("NaN samples should be skipped: %v", home)
+	}
+	evidence := strs(out["evidence"])
+	if !strings.HasPrefix(evidence[0], `{route="/login"}: last 0.9 (min 0.1, max 0.9, avg 0.4) over 2m0s, rising`) {
+		t.Errorf("unexpected evidence: %v", evidence)
+	}
+	if len(strs(out["warnings"])) != 1 {
+		t.Errorf("expected warnings to be passed through: %v", out)
+	}
+}
+
+func TestMetricsPush(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	var gotPath, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		gotPath, gotBody = r.URL.EscapedPath(), string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	p := Pack(Config{PushgatewayURL: srv.URL})
+
+	_, err := call(p, "metrics_push", map[string]any{
+		"job":      "deploy",
+		"grouping": map[string]string{"path": "a/b"},
+		"metrics": []map[string]any{
+			{"name": "deploy_duration_seconds", "value": 42.5, "help": "Deploy time", "labels": map[string]string{"env": `pr"od`}},
+			{"name": "deploy_total", "value": 1, "type": "counter"},
+			{"name": "deploy_duration_seconds", "value": 12, "labels": map[string]string{"env": "dev"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if gotPath != "/metrics/job/deploy/path@base64/YS9i" {
+		t.Errorf("unexpected path %s", gotPath)
+	}
+	want := "# HELP deploy_duration_seconds Deploy time\n# TYPE deploy_duration_seconds gauge\n" +
+		"deploy_duration_seconds{env=\"pr\\\"od\"} 42.5\ndeploy_duration_seconds{env=\"dev\"} 12\n" +
+		"# TYPE deploy_total counter\ndeploy_total 1\n"
+	if gotBody != want {
+		t.Errorf("unexpected body:\n%s\nwant:\n%s", gotBody, want)
+	}
+
+	if _, err := call(p, "metrics_push", map[string]any{"job": "x", "metrics": []map[string]any{{"name": "bad-name", "value": 1}}}); err == nil {
+		t.Error("expected an error for an invalid metric name")
+	}
+}
+
+func TestAlertsListAndSilence(t *testing.T) {
+	t.Parallel()
+	var silence map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v2/alerts":
+			q := r.URL.Query()
+			if q.Get("silenced") != "false" || q.Get("inhibited") != "false" || q.Get("filter") != `team="payments"` {
+				t.Errorf("unexpected query: %v", q)
+			}
+			_, _ = io.WriteString(w, `[
+				{"labels":{"alertname":"DiskFilling","severity":"warning","team":"payments"},"annotations":{"description":"disk 90%"},"startsAt":"2026-01-01T00:00:00Z","fingerprint":"a","status":{"state":"active"}},
+				{"labels":{"alertname":"HighErrorRate","severity":"critical","team":"payments"},"annotations":{"summary":"5xx above 5%"},"startsAt":"2026-01-01T01:00:00Z","fingerprint":"b","status":{"state":"active"}}]`)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+			_ = json.NewDecoder(r.Body).Decode(&silence)
+			_, _ = io.WriteString(w, `{"silenceID":"s-1"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	p := Pack(Config{AlertmanagerURL: srv.URL, MaxSilence: 2 * time.Hour})
+
+	out, err := call(p, "alerts_list", map[string]any{"filter": map[string]string{"team": "payments"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := out["alerts"].([]any)
+	first := alerts[0].(map[string]any)
+	if first["name"] != "HighErrorRate" || first["summary"] != "5xx above 5%" {
+		t.Fatalf("expected the critical alert first: %v", alerts)
+	}
+	if out["by_severity"].(map[string]any)["warning"] != 1.0 {
+		t.Errorf("unexpected severity counts: %v", out["by_severity"])
+	}
+	if evidence := strs(out["evidence"]); !strings.HasPrefix(evidence[0], "[critical] HighErrorRate active for ") {
+		t.Errorf("unexpected evidence: %v", evidence)
+	}
+
+	out, err = call(p, "alerts_silence", map[string]any{
+		"matchers": map[string]string{"alertname": "DiskFilling"},
+		"duration": "30m",
+		"comment":  "cleanup in progress",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["silence_id"] != "s-1" || silence["createdBy"] != "agent-go" || silence["comment"] != "cleanup in progress" {
+		t.Fatalf("unexpected silence: %v %v", out, silence)
+	}
+	matcher := silence["matchers"].([]any)[0].(map[string]any)
+	if matcher["name"] != "alertname" || matcher["isEqual"] != true || matcher["isRegex"] != false {
+		t.Errorf("unexpected matcher: %v", matcher)
+	}
+
+	if _, err := call(p, "alerts_silence", map[string]any{"matchers": map[string]string{"alertname": "X"}, "duration": "48h", "comment": "c"}); err == nil {
+		t.Error("expected an error for a silence over MaxSilence")
+	}
+	if _, err := call(p, "alerts_silence", map[string]any{"matchers": map[string]string{}, "comment": "c"}); err == nil {
+		t.Error("expected an error for a silence without matchers")
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Error("backend headers leaked to a health target")
+		}
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = io.WriteString(w, `{"status":"UP"}`)
+		case "/degraded":
+			_, _ = io.WriteString(w, `{"status":"DOWN"}`)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	p := Pack(Config{
+		Headers: map[string]string{"Authorization": "Bearer token"},
+		HealthTargets: map[string]string{
+			"api":    srv.URL + "/ok",
+			"worker": srv.URL + "/degraded",
+			"db":     srv.URL + "/fail",
+		},
+	})
+
+	out, err := call(p, "health_check", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["healthy"] != false || out["passing"] != 1.0 || out["failing"] != 2.0 {
+		t.Fatalf("unexpected output: %v", out)
+	}
+	evidence := strs(out["evidence"])
+	if !strings.HasPrefix(evidence[0], "api: healthy (HTTP 200, status UP") || !strings.HasPrefix(evidence[1], "db: unhealthy (HTTP 503") {
+		t.Errorf("unexpected evidence: %v", evidence)
+	}
+
+	out, err = call(p, "health_check", map[string]any{"targets": []string{"api"}})
+	if err != nil || out["healthy"] != true {
+		t.Fatalf("expected api to be healthy: %v %v", out, err)
+	}
+	if _, err := call(p, "health_check", map[string]any{"urls": []string{srv.URL}}); !errors.Is(err, ErrTargetNotAllowed) {
+		t.Fatalf("expected ErrTargetNotAllowed, got %v", err)
+	}
+}
+
+func TestUnconfiguredBackends(t *testing.T) {
+	t.Parallel()
+	p := Pack(Config{})
+	if _, err := call(p, "metrics_query", map[string]any{"query": "up"}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+	if _, err := call(p, "alerts_list", nil); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured, got %v", err)
+	}
+	if _, err := call(p, "logs_query", map[string]any{"query": "{app=\"api\"}"}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
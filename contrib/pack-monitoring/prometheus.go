@@ -0,0 +1,496 @@
+package monitoring
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// promResponse is the envelope of every Prometheus HTTP API response.
+type promResponse struct {
+	Status    string          `json:"status"`
+	Data      json.RawMessage `json:"data"`
+	ErrorType string          `json:"errorType"`
+	Error     string          `json:"error"`
+	Warnings  []string        `json:"warnings"`
+}
+
+type promResult struct {
+	ResultType string          `json:"resultType"`
+	Result     json.RawMessage `json:"result"`
+}
+
+type promSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  promSample        `json:"value"`
+	Values []promSample      `json:"values"`
+}
+
+// promSample is a [unix seconds, "value"] pair.
+type promSample struct {
+	Time  time.Time
+	Value float64
+}
+
+func (s *promSample) UnmarshalJSON(data []byte) error {
+	var pair [2]any
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	ts, ok := pair[0].(float64)
+	raw, ok2 := pair[1].(string)
+	if !ok || !ok2 {
+		return fmt.Errorf("invalid sample %s", data)
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sample value %q", raw)
+	}
+	sec, frac := math.Modf(ts)
+	s.Time = time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	s.Value = value
+	return nil
+}
+
+// seriesName formats a series as name{label="value",...} with sorted
+// labels.
+func seriesName(metric map[string]string) string {
+	name := metric["__name__"]
+	var labels []string
+	for _, k := range slices.Sorted(maps.Keys(metric)) {
+		if k != "__name__" {
+			labels = append(labels, k+"="+strconv.Quote(metric[k]))
+		}
+	}
+	if len(labels) == 0 && name != "" {
+		return name
+	}
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
+
+// number keeps non-finite values, which JSON cannot encode, as strings.
+func number(f float64) any {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return f
+}
+
+func formatValue(f float64) string {
+	return strconv.FormatFloat(f, 'g', 6, 64)
+}
+
+// compareValues orders descending with NaN last.
+func compareValues(a, b float64) int {
+	switch {
+	case math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case math.IsNaN(a):
+		return 1
+	case math.IsNaN(b):
+		return -1
+	}
+	return cmp.Compare(b, a)
+}
+
+// stats holds summary statistics over finite values.
+type stats struct {
+	n                   int
+	min, max, sum       float64
+	firstSet            bool
+	first, last         float64
+	firstTime, lastTime time.Time
+}
+
+func (s *stats) add(sample promSample) {
+	if !s.firstSet {
+		s.first, s.firstTime, s.firstSet = sample.Value, sample.Time, true
+	}
+	s.last, s.lastTime = sample.Value, sample.Time
+	if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+		return
+	}
+	if s.n == 0 || sample.Value < s.min {
+		s.min = sample.Value
+	}
+	if s.n == 0 || sample.Value > s.max {
+		s.max = sample.Value
+	}
+	s.sum += sample.Value
+	s.n++
+}
+
+func (s *stats) avg() float64 {
+	if s.n == 0 {
+		return math.NaN()
+	}
+	return s.sum / float64(s.n)
+}
+
+// trend classifies the change from first to last relative to the range
+// of values.
+func (s *stats) trend() string {
+	spread := s.max - s.min
+	change := s.last - s.first
+	switch {
+	case s.n < 2 || spread == 0 || math.IsNaN(change):
+		return "flat"
+	case change > spread/10:
+		return "rising"
+	case change < -spread/10:
+		return "falling"
+	}
+	return "flat"
+}
+
+// summarizeVector reduces an instant vector to its top series by value.
+func summarizeVector(series []promSeries, limit int) map[string]any {
+	slices.SortStableFunc(series, func(a, b promSeries) int { return compareValues(a.Value.Value, b.Value.Value) })
+
+	var all stats
+	for _, s := range series {
+		all.add(s.Value)
+	}
+	shown := series[:min(limit, len(series))]
+	items := make([]map[string]any, len(shown))
+	evidence := make([]string, len(shown))
+	for i, s := range shown {
+		name := seriesName(s.Metric)
+		items[i] = map[string]any{"series": name, "value": number(s.Value.Value)}
+		evidence[i] = name + " = " + formatValue(s.Value.Value)
+	}
+	out := map[string]any{
+		"series":    items,
+		"evidence":  evidence,
+		"count":     len(series),
+		"truncated": len(series) > len(shown),
+	}
+	if all.n > 0 {
+		out["stats"] = map[string]any{"min": all.min, "max": all.max, "avg": all.avg(), "sum": all.sum}
+	}
+	if len(series) > 0 {
+		out["time"] = series[0].Value.Time
+	}
+	return out
+}
+
+// summarizeMatrix reduces a range result to per-series statistics, with
+// the series that peaked highest first.
+func summarizeMatrix(series []promSeries, limit int) map[string]any {
+	type summary struct {
+		name string
+		st   stats
+	}
+	summaries := make([]summary, len(series))
+	for i, s := range series {
+		summaries[i].name = seriesName(s.Metric)
+		for _, sample := range s.Values {
+			summaries[i].st.add(sample)
+		}
+	}
+	slices.SortStableFunc(summaries, func(a, b summary) int {
+		ma, mb := a.st.max, b.st.max
+		if a.st.n == 0 {
+			ma = math.NaN()
+		}
+		if b.st.n == 0 {
+			mb = math.NaN()
+		}
+		return compareValues(ma, mb)
+	})
+
+	shown := summaries[:min(limit, len(summaries))]
+	items := make([]map[string]any, len(shown))
+	evidence := make([]string, len(shown))
+	for i, s := range shown {
+		st := s.st
+		items[i] = map[string]any{
+			"series":  s.name,
+			"samples": st.n,
+			"first":   number(st.first),
+			"last":    number(st.last),
+			"min":     number(st.min),
+			"max":     number(st.max),
+			"avg":     number(st.avg()),
+			"trend":   st.trend(),
+			"from":    st.firstTime,
+			"to":      st.lastTime,
+		}
+		evidence[i] = fmt.Sprintf("%s: last %s (min %s, max %s, avg %s) over %s, %s",
+			s.name, formatValue(st.last), formatValue(st.min), formatValue(st.max), formatValue(st.avg()),
+			st.lastTime.Sub(st.firstTime), st.trend())
+	}
+	return map[string]any{
+		"series":    items,
+		"evidence":  evidence,
+		"count":     len(series),
+		"truncated": len(series) > len(shown),
+	}
+}
+
+// parseTime accepts RFC 3339 or unix seconds; empty means now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return now, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if sec, err := strconv.ParseFloat(s, 64); err == nil {
+		whole, frac := math.Modf(sec)
+		return time.Unix(int64(whole), int64(frac*1e9)), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or unix seconds", s)
+}
+
+func formatUnix(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', -1, 64)
+}
+
+// maxRangePoints keeps automatic steps well under Prometheus's limit of
+// 11,000 points per series.
+const maxRangePoints = 250
+
+// ============================================================================
+// Metrics Tools
+// ============================================================================
+
+func (p *monitoringPack) metricsQuery() tool.Tool {
+	return tool.NewBuilder("metrics_query").
+		WithDescription("Query Prometheus with PromQL; returns compact evidence per series: the value for instant queries, or last/min/max/avg and trend when range (e.g. \"1h\") is given").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Query string `json:"query"`
+				Time  string `json:"time,omitempty"`
+				Range string `json:"range,omitempty"`
+				End   string `json:"end,omitempty"`
+				Step  string `json:"step,omitempty"`
+				Limit int    `json:"limit,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if strings.TrimSpace(in.Query) == "" {
+				return tool.Result{}, errors.New("query is required")
+			}
+
+			form := url.Values{"query": {in.Query}}
+			apiPath := "/api/v1/query"
+			now := time.Now()
+			if in.Range != "" {
+				window, err := time.ParseDuration(in.Range)
+				if err != nil || window <= 0 {
+					return tool.Result{}, fmt.Errorf("invalid range %q: use a duration such as \"30m\" or \"6h\"", in.Range)
+				}
+				end, err := parseTime(in.End, now)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				step := (window / maxRangePoints).Round(time.Second)
+				if in.Step != "" {
+					if step, err = time.ParseDuration(in.Step); err != nil || step <= 0 {
+						return tool.Result{}, fmt.Errorf("invalid step %q", in.Step)
+					}
+				}
+				step = max(step, time.Second)
+				apiPath = "/api/v1/query_range"
+				form.Set("start", formatUnix(end.Add(-window)))
+				form.Set("end", formatUnix(end))
+				form.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+			} else if in.Time != "" {
+				at, err := parseTime(in.Time, now)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				form.Set("time", formatUnix(at))
+			}
+
+			u, err := endpoint(p.cfg.PrometheusURL, "Prometheus", apiPath)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			data, status, err := p.call(ctx, http.MethodPost, u, "application/x-www-form-urlencoded", []byte(form.Encode()))
+			if err != nil {
+				return tool.Result{}, err
+			}
+			var resp promResponse
+			if err := json.Unmarshal(data, &resp); err != nil {
+				return tool.Result{}, fmt.Errorf("%w: HTTP %d: invalid response", ErrRequestFailed, status)
+			}
+			if resp.Status != "success" {
+				return tool.Result{}, fmt.Errorf("%w: %s: %s", ErrRequestFailed, resp.ErrorType, resp.Error)
+			}
+			var result promResult
+			if err := json.Unmarshal(resp.Data, &result); err != nil {
+				return tool.Result{}, fmt.Errorf("%w: invalid result: %v", ErrRequestFailed, err)
+			}
+
+			limit := p.limit(in.Limit)
+			var out map[string]any
+			switch result.ResultType {
+			case "vector", "matrix":
+				var series []promSeries
+				if err := json.Unmarshal(result.Result, &series); err != nil {
+					return tool.Result{}, fmt.Errorf("%w: invalid result: %v", ErrRequestFailed, err)
+				}
+				if result.ResultType == "vector" {
+					out = summarizeVector(series, limit)
+				} else {
+					out = summarizeMatrix(series, limit)
+				}
+			case "scalar", "string":
+				var raw [2]any
+				if err := json.Unmarshal(result.Result, &raw); err != nil {
+					return tool.Result{}, fmt.Errorf("%w: invalid result: %v", ErrRequestFailed, err)
+				}
+				out = map[string]any{"value": raw[1], "evidence": []string{fmt.Sprintf("%s = %v", in.Query, raw[1])}}
+			default:
+				return tool.Result{}, fmt.Errorf("%w: unknown result type %q", ErrRequestFailed, result.ResultType)
+			}
+			out["query"] = in.Query
+			out["result_type"] = result.ResultType
+			if len(resp.Warnings) > 0 {
+				out["warnings"] = resp.Warnings
+			}
+			output, _ := json.Marshal(out)
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// labelValueEscaper escapes label values in the text exposition format.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// groupingPath encodes Pushgateway grouping labels, using the base64 form
+// for values a path segment cannot hold.
+func groupingPath(job string, grouping map[string]string) (string, error) {
+	segment := func(name, value string) string {
+		if value == "" || strings.Contains(value, "/") {
+			return "/" + name + "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+		}
+		return "/" + name + "/" + url.PathEscape(value)
+	}
+	var b strings.Builder
+	b.WriteString("/metrics" + segment("job", job))
+	for _, name := range slices.Sorted(maps.Keys(grouping)) {
+		if !labelNameRegex.MatchString(name) || name == "job" {
+			return "", fmt.Errorf("invalid grouping label %q", name)
+		}
+		b.WriteString(segment(name, grouping[name]))
+	}
+	return b.String(), nil
+}
+
+func (p *monitoringPack) metricsPush() tool.Tool {
+	return tool.NewBuilder("metrics_push").
+		WithDescription("Push gauge or counter samples to the Prometheus Pushgateway under a job and grouping labels").
+		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Job      string            `json:"job"`
+				Grouping map[string]string `json:"grouping,omitempty"`
+				Metrics  []struct {
+					Name   string            `json:"name"`
+					Value  float64           `json:"value"`
+					Type   string            `json:"type,omitempty"`
+					Help   string            `json:"help,omitempty"`
+					Labels map[string]string `json:"labels,omitempty"`
+				} `json:"metrics"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Job == "" || len(in.Metrics) == 0 {
+				return tool.Result{}, errors.New("job and at least one metric are required")
+			}
+			apiPath, err := groupingPath(in.Job, in.Grouping)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			// Samples of one metric must be contiguous under a single
+			// TYPE line.
+			var order []string
+			lines := make(map[string][]string)
+			header := make(map[string]string)
+			for _, m := range in.Metrics {
+				if !metricNameRegex.MatchString(m.Name) {
+					return tool.Result{}, fmt.Errorf("invalid metric name %q", m.Name)
+				}
+				switch m.Type {
+				case "":
+					m.Type = "gauge"
+				case "gauge", "counter", "untyped":
+				default:
+					return tool.Result{}, fmt.Errorf("metric %s: type must be gauge, counter or untyped", m.Name)
+				}
+				if _, seen := lines[m.Name]; !seen {
+					order = append(order, m.Name)
+					h := ""
+					if m.Help != "" {
+						h = "# HELP " + m.Name + " " + strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(m.Help) + "\n"
+					}
+					header[m.Name] = h + "# TYPE " + m.Name + " " + m.Type + "\n"
+				}
+				var labels []string
+				for _, k := range slices.Sorted(maps.Keys(m.Labels)) {
+					if !labelNameRegex.MatchString(k) {
+						return tool.Result{}, fmt.Errorf("metric %s: invalid label name %q", m.Name, k)
+					}
+					labels = append(labels, k+`="`+labelValueEscaper.Replace(m.Labels[k])+`"`)
+				}
+				line := m.Name
+				if len(labels) > 0 {
+					line += "{" + strings.Join(labels, ",") + "}"
+				}
+				lines[m.Name] = append(lines[m.Name], line+" "+strconv.FormatFloat(m.Value, 'g', -1, 64))
+			}
+			var body strings.Builder
+			for _, name := range order {
+				body.WriteString(header[name])
+				for _, line := range lines[name] {
+					body.WriteString(line + "\n")
+				}
+			}
+
+			u, err := endpoint(p.cfg.PushgatewayURL, "Pushgateway", apiPath)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			// POST replaces only the pushed metric names within the group.
+			data, status, err := p.call(ctx, http.MethodPost, u, "text/plain; version=0.0.4", []byte(body.String()))
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if status/100 != 2 {
+				return tool.Result{}, fmt.Errorf("%w: HTTP %d: %s", ErrRequestFailed, status, strings.TrimSpace(string(data)))
+			}
+			output, _ := json.Marshal(map[string]any{
+				"job":     in.Job,
+				"pushed":  len(in.Metrics),
+				"metrics": order,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
@@ -7,98 +7,668 @@
 //   - pdf_merge: Merge multiple PDFs into one
 //   - pdf_split: Split a PDF into multiple files
 //   - pdf_compress: Compress a PDF to reduce file size
-//   - pdf_to_images: Convert PDF pages to images
-//   - pdf_from_html: Generate PDF from HTML content
 //
-// Supports encrypted PDFs with password handling.
+// All processing is pure Go. Documents are read from and written to an
+// artifact.Store; tools take and return artifact IDs rather than inline
+// content. Encrypted PDFs are opened with a configured password, which
+// callers reference by name so the password itself never appears in tool
+// input or the run ledger; derived documents are written unencrypted.
+// Rendering pages to images or HTML to PDF needs a rasterizer or browser
+// engine, so the pack provides no tools for it.
 package pdf
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	pdfcpu "github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/artifact"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+)
+
+// Errors returned by the PDF tools.
+var (
+	// ErrNoArtifactStore indicates the pack was configured without a store.
+	ErrNoArtifactStore = errors.New("pdf pack requires an artifact store")
+
+	// ErrPasswordRequired indicates an encrypted PDF and a missing or wrong password.
+	ErrPasswordRequired = errors.New("pdf is encrypted: correct password required")
+
+	// ErrUnknownPassword indicates a password name that is not configured.
+	ErrUnknownPassword = errors.New("unknown password")
+
+	// ErrTooManyParts indicates a split that would exceed MaxParts.
+	ErrTooManyParts = errors.New("too many parts")
+
+	// ErrInputTooLarge indicates an input document exceeds MaxInputBytes.
+	ErrInputTooLarge = errors.New("pdf exceeds maximum input size")
+
+	// ErrInvalidPages indicates a malformed page selection.
+	ErrInvalidPages = errors.New("invalid page selection")
 )
 
+const contentTypePDF = "application/pdf"
+
+// Config configures the PDF pack.
+type Config struct {
+	// Artifacts stores input and output documents. Required.
+	Artifacts artifact.Store
+
+	// MaxInputBytes caps the size of each input document. Defaults to 100 MiB.
+	MaxInputBytes int64
+
+	// MaxInlineText caps the extracted text returned inline. Longer text is
+	// stored as a text/plain artifact. Defaults to 256 KiB.
+	MaxInlineText int
+
+	// MaxImages caps the images extracted per call. Defaults to 100.
+	MaxImages int
+
+	// MaxParts caps the documents pdf_split produces per call. Defaults to 100.
+	MaxParts int
+
+	// Secrets resolves document passwords.
+	Secrets secrets.Manager
+
+	// Passwords maps the password names callers may reference
+	// (password_name) to keys of secrets in Secrets.
+	Passwords map[string]string
+}
+
 // Pack returns the PDF processing tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.MaxInputBytes <= 0 {
+		cfg.MaxInputBytes = 100 << 20
+	}
+	if cfg.MaxInlineText <= 0 {
+		cfg.MaxInlineText = 256 << 10
+	}
+	if cfg.MaxImages <= 0 {
+		cfg.MaxImages = 100
+	}
+	if cfg.MaxParts <= 0 {
+		cfg.MaxParts = 100
+	}
+	p := &pdfPack{cfg: cfg}
+
 	return pack.NewBuilder("pdf").
 		WithDescription("PDF processing and manipulation tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			pdfExtractText(),
-			pdfExtractImages(),
-			pdfMetadata(),
-			pdfMerge(),
-			pdfSplit(),
-			pdfCompress(),
-			pdfToImages(),
-			pdfFromHTML(),
+			p.pdfExtractText(),
+			p.pdfExtractImages(),
+			p.pdfMetadata(),
+			p.pdfMerge(),
+			p.pdfSplit(),
+			p.pdfCompress(),
 		).
 		AllowInState(agent.StateExplore, "pdf_extract_text", "pdf_metadata").
-		AllowInState(agent.StateAct, "pdf_extract_text", "pdf_extract_images", "pdf_metadata", "pdf_merge", "pdf_split", "pdf_compress", "pdf_to_images", "pdf_from_html").
+		AllowInState(agent.StateAct, "pdf_extract_text", "pdf_extract_images", "pdf_metadata", "pdf_merge", "pdf_split", "pdf_compress").
 		AllowInState(agent.StateValidate, "pdf_metadata").
 		Build()
 }
 
-func pdfExtractText() tool.Tool {
+type pdfPack struct {
+	cfg Config
+}
+
+// pdfcpu otherwise creates a configuration directory under the user's
+// config dir on first use.
+var disableConfigDir sync.Once
+
+func newConfiguration() *model.Configuration {
+	disableConfigDir.Do(api.DisableConfigDir)
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	return conf
+}
+
+// document is a loaded, decrypted PDF.
+type document struct {
+	id        string
+	name      string // artifact name without extension, for derived outputs
+	data      []byte
+	encrypted bool
+}
+
+func (d *document) reader() io.ReadSeeker { return bytes.NewReader(d.data) }
+
+// password resolves a named password. An empty name yields no password.
+func (p *pdfPack) password(ctx context.Context, name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	key, ok := p.cfg.Passwords[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownPassword, name)
+	}
+	if p.cfg.Secrets == nil {
+		return "", errors.New("no secrets manager configured")
+	}
+	value, err := p.cfg.Secrets.Get(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve password %s: %w", name, err)
+	}
+	return value, nil
+}
+
+// load reads a PDF artifact and decrypts it, with the named password, when
+// it is encrypted.
+func (p *pdfPack) load(ctx context.Context, id, passwordName string) (*document, error) {
+	if p.cfg.Artifacts == nil {
+		return nil, ErrNoArtifactStore
+	}
+	password, err := p.password(ctx, passwordName)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := p.cfg.Artifacts.Retrieve(ctx, artifact.NewRef(id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact %s: %w", id, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, p.cfg.MaxInputBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read artifact %s: %w", id, err)
+	}
+	if int64(len(data)) > p.cfg.MaxInputBytes {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrInputTooLarge, id, p.cfg.MaxInputBytes)
+	}
+
+	doc := &document{id: id, name: id, data: data}
+	if meta, err := p.cfg.Artifacts.Metadata(ctx, artifact.NewRef(id)); err == nil && meta.Name != "" {
+		doc.name = strings.TrimSuffix(path.Base(meta.Name), path.Ext(meta.Name))
+	}
+
+	conf := newConfiguration()
+	conf.UserPW, conf.OwnerPW = password, password
+	var plain bytes.Buffer
+	err = api.Decrypt(bytes.NewReader(data), &plain, conf)
+	switch {
+	case err == nil:
+		doc.data, doc.encrypted = plain.Bytes(), true
+		return doc, nil
+	case errors.Is(err, pdfcpu.ErrNotEncrypted):
+		return doc, nil
+	case errors.Is(err, pdfcpu.ErrWrongPassword):
+		return nil, fmt.Errorf("%w: %s", ErrPasswordRequired, id)
+	default:
+		return nil, fmt.Errorf("failed to read pdf %s: %w", id, err)
+	}
+}
+
+// store saves a derived document and returns its reference.
+func (p *pdfPack) store(ctx context.Context, r io.Reader, name, contentType string) (artifact.Ref, error) {
+	opts := artifact.DefaultStoreOptions().WithName(name).WithContentType(contentType)
+	ref, err := p.cfg.Artifacts.Store(ctx, r, opts)
+	if err != nil {
+		return artifact.Ref{}, fmt.Errorf("failed to store %s: %w", name, err)
+	}
+	return ref, nil
+}
+
+// outputName derives an artifact name from an optional caller-supplied
+// name and a default.
+func outputName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".pdf") {
+		name += ".pdf"
+	}
+	return path.Base(name)
+}
+
+// parsePages expands a selection such as "1-3,5,8-" into page numbers,
+// validated against the page count. An empty selection selects every page.
+func parsePages(spec string, count int) ([]int, error) {
+	if strings.TrimSpace(spec) == "" {
+		pages := make([]int, count)
+		for i := range pages {
+			pages[i] = i + 1
+		}
+		return pages, nil
+	}
+
+	seen := make(map[int]bool)
+	var pages []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		from, thru, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidPages, part)
+		}
+		last := first
+		if isRange {
+			last = count
+			if t := strings.TrimSpace(thru); t != "" {
+				if last, err = strconv.Atoi(t); err != nil {
+					return nil, fmt.Errorf("%w: %q", ErrInvalidPages, part)
+				}
+			}
+		}
+		if first < 1 || last > count || first > last {
+			return nil, fmt.Errorf("%w: %q is outside 1-%d", ErrInvalidPages, part, count)
+		}
+		for n := first; n <= last; n++ {
+			if !seen[n] {
+				seen[n] = true
+				pages = append(pages, n)
+			}
+		}
+	}
+	return pages, nil
+}
+
+// pdfcpuPages converts page numbers to a pdfcpu page selection.
+func pdfcpuPages(pages []int) []string {
+	selection := make([]string, len(pages))
+	for i, n := range pages {
+		selection[i] = strconv.Itoa(n)
+	}
+	return selection
+}
+
+func toArtifactRefs(refs []artifact.Ref) []tool.ArtifactRef {
+	out := make([]tool.ArtifactRef, len(refs))
+	for i, r := range refs {
+		out[i] = tool.ArtifactRef{ID: r.ID, Name: r.Name}
+	}
+	return out
+}
+
+type documentInput struct {
+	ArtifactID   string `json:"artifact_id"`
+	PasswordName string `json:"password_name,omitempty"`
+}
+
+// ============================================================================
+// Extraction Tools
+// ============================================================================
+
+func (p *pdfPack) pdfExtractText() tool.Tool {
 	return tool.NewBuilder("pdf_extract_text").
-		WithDescription("Extract text content from a PDF document").
+		WithDescription("Extract text content from a PDF artifact, per page").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				documentInput
+				Pages string `json:"pages,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			doc, err := p.load(ctx, in.ArtifactID, in.PasswordName)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			r, err := pdf.NewReader(bytes.NewReader(doc.data), int64(len(doc.data)))
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to parse pdf: %w", err)
+			}
+			pages, err := parsePages(in.Pages, r.NumPage())
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			type pageText struct {
+				Page int    `json:"page"`
+				Text string `json:"text"`
+			}
+			var full strings.Builder
+			texts := make([]pageText, 0, len(pages))
+			for _, n := range pages {
+				if err := ctx.Err(); err != nil {
+					return tool.Result{}, err
+				}
+				text, err := r.Page(n).GetPlainText(nil)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("failed to extract page %d: %w", n, err)
+				}
+				texts = append(texts, pageText{Page: n, Text: text})
+				full.WriteString(text)
+				full.WriteString("\f")
+			}
+
+			out := map[string]any{
+				"page_count": r.NumPage(),
+				"characters": full.Len(),
+				"encrypted":  doc.encrypted,
+			}
+			result := tool.Result{}
+			if full.Len() <= p.cfg.MaxInlineText {
+				out["pages"] = texts
+			} else {
+				// Too large to return inline: store the full text, pages
+				// separated by form feeds, and return a prefix.
+				ref, err := p.store(ctx, strings.NewReader(full.String()), doc.name+".txt", "text/plain; charset=utf-8")
+				if err != nil {
+					return tool.Result{}, err
+				}
+				out["text"] = strings.ToValidUTF8(full.String()[:p.cfg.MaxInlineText], "")
+				out["truncated"] = true
+				out["text_artifact"] = ref.ID
+				result.Artifacts = toArtifactRefs([]artifact.Ref{ref})
+			}
+
+			result.Output, _ = json.Marshal(out)
+			return result, nil
+		}).
 		MustBuild()
 }
 
-func pdfExtractImages() tool.Tool {
+func (p *pdfPack) pdfExtractImages() tool.Tool {
 	return tool.NewBuilder("pdf_extract_images").
-		WithDescription("Extract embedded images from a PDF document").
+		WithDescription("Extract embedded images from a PDF artifact into image artifacts").
 		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				documentInput
+				Pages string `json:"pages,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			doc, err := p.load(ctx, in.ArtifactID, in.PasswordName)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			conf := newConfiguration()
+			count, err := api.PageCount(doc.reader(), conf)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to parse pdf: %w", err)
+			}
+			pages, err := parsePages(in.Pages, count)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			perPage, err := api.ExtractImagesRaw(doc.reader(), pdfcpuPages(pages), conf)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to extract images: %w", err)
+			}
+
+			var refs []artifact.Ref
+			images := []map[string]any{}
+			truncated := false
+		extract:
+			for _, m := range perPage {
+				for _, img := range m {
+					if len(refs) >= p.cfg.MaxImages {
+						truncated = true
+						break extract
+					}
+					name := fmt.Sprintf("page%d-%s.%s", img.PageNr, img.Name, img.FileType)
+					ref, err := p.store(ctx, img, name, "image/"+img.FileType)
+					if err != nil {
+						return tool.Result{}, err
+					}
+					refs = append(refs, ref)
+					image := map[string]any{
+						"artifact_id": ref.ID,
+						"name":        name,
+						"page":        img.PageNr,
+						"format":      img.FileType,
+					}
+					if img.Width > 0 && img.Height > 0 {
+						image["width"], image["height"] = img.Width, img.Height
+					}
+					images = append(images, image)
+				}
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"images":    images,
+				"count":     len(images),
+				"truncated": truncated,
+			})
+			return tool.Result{Output: output, Artifacts: toArtifactRefs(refs)}, nil
+		}).
 		MustBuild()
 }
 
-func pdfMetadata() tool.Tool {
+func (p *pdfPack) pdfMetadata() tool.Tool {
 	return tool.NewBuilder("pdf_metadata").
 		WithDescription("Get PDF metadata including title, author, page count").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in documentInput
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			doc, err := p.load(ctx, in.ArtifactID, in.PasswordName)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			info, err := api.PDFInfo(doc.reader(), in.ArtifactID, nil, false, newConfiguration())
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to read metadata: %w", err)
+			}
+
+			out := map[string]any{
+				"version":           info.Version,
+				"page_count":        info.PageCount,
+				"title":             info.Title,
+				"author":            info.Author,
+				"subject":           info.Subject,
+				"creator":           info.Creator,
+				"producer":          info.Producer,
+				"creation_date":     info.CreationDate,
+				"modification_date": info.ModificationDate,
+				"keywords":          info.Keywords,
+				"tagged":            info.Tagged,
+				"form":              info.Form,
+				"signatures":        info.Signatures,
+				"bookmarks":         info.Outlines,
+				"encrypted":         doc.encrypted,
+				"size_bytes":        len(doc.data),
+			}
+			if len(info.Dimensions) > 0 {
+				out["page_size"] = map[string]float64{
+					"width":  info.Dimensions[0].Width,
+					"height": info.Dimensions[0].Height,
+				}
+				out["unit"] = info.UnitString
+			}
+			if len(info.Properties) > 0 {
+				out["properties"] = info.Properties
+			}
+
+			output, _ := json.Marshal(out)
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func pdfMerge() tool.Tool {
+// ============================================================================
+// Transformation Tools
+// ============================================================================
+
+func (p *pdfPack) pdfMerge() tool.Tool {
 	return tool.NewBuilder("pdf_merge").
-		WithDescription("Merge multiple PDF documents into one").
+		WithDescription("Merge multiple PDF artifacts, in order, into a new PDF artifact").
 		Idempotent().
 		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				ArtifactIDs   []string          `json:"artifact_ids"`
+				PasswordNames map[string]string `json:"password_names,omitempty"`
+				OutputName    string            `json:"output_name,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.ArtifactIDs) < 2 {
+				return tool.Result{}, errors.New("at least two artifact_ids are required")
+			}
+
+			readers := make([]io.ReadSeeker, 0, len(in.ArtifactIDs))
+			for _, id := range in.ArtifactIDs {
+				doc, err := p.load(ctx, id, in.PasswordNames[id])
+				if err != nil {
+					return tool.Result{}, err
+				}
+				readers = append(readers, doc.reader())
+			}
+
+			var merged bytes.Buffer
+			if err := api.MergeRaw(readers, &merged, false, newConfiguration()); err != nil {
+				return tool.Result{}, fmt.Errorf("failed to merge: %w", err)
+			}
+			size := merged.Len()
+			pages, _ := api.PageCount(bytes.NewReader(merged.Bytes()), newConfiguration())
+
+			ref, err := p.store(ctx, &merged, outputName(in.OutputName, "merged.pdf"), contentTypePDF)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"artifact_id": ref.ID,
+				"name":        ref.Name,
+				"page_count":  pages,
+				"size_bytes":  size,
+			})
+			return tool.Result{Output: output, Artifacts: toArtifactRefs([]artifact.Ref{ref})}, nil
+		}).
 		MustBuild()
 }
 
-func pdfSplit() tool.Tool {
+func (p *pdfPack) pdfSplit() tool.Tool {
 	return tool.NewBuilder("pdf_split").
-		WithDescription("Split a PDF into multiple documents by page range").
+		WithDescription("Split a PDF artifact into new PDF artifacts by page ranges or every N pages").
 		Idempotent().
 		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				documentInput
+				Ranges []string `json:"ranges,omitempty"`
+				Span   int      `json:"span,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			doc, err := p.load(ctx, in.ArtifactID, in.PasswordName)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			count, err := api.PageCount(doc.reader(), newConfiguration())
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to parse pdf: %w", err)
+			}
+
+			// Each part is a page selection; without ranges, split every
+			// span pages (default one page per part).
+			parts := in.Ranges
+			if len(parts) == 0 {
+				span := max(in.Span, 1)
+				if n := (count + span - 1) / span; n > p.cfg.MaxParts {
+					return tool.Result{}, fmt.Errorf("%w: splitting %d pages every %d would produce %d parts (max %d)",
+						ErrTooManyParts, count, span, n, p.cfg.MaxParts)
+				}
+				for from := 1; from <= count; from += span {
+					parts = append(parts, fmt.Sprintf("%d-%d", from, min(from+span-1, count)))
+				}
+			}
+			if len(parts) > p.cfg.MaxParts {
+				return tool.Result{}, fmt.Errorf("%w: %d ranges (max %d)", ErrTooManyParts, len(parts), p.cfg.MaxParts)
+			}
+
+			var refs []artifact.Ref
+			files := make([]map[string]any, 0, len(parts))
+			for _, part := range parts {
+				pages, err := parsePages(part, count)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				var buf bytes.Buffer
+				if err := api.Trim(doc.reader(), &buf, pdfcpuPages(pages), newConfiguration()); err != nil {
+					return tool.Result{}, fmt.Errorf("failed to extract pages %s: %w", part, err)
+				}
+				size := buf.Len()
+				name := fmt.Sprintf("%s_%s.pdf", doc.name, strings.ReplaceAll(part, ",", "_"))
+				ref, err := p.store(ctx, &buf, name, contentTypePDF)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				refs = append(refs, ref)
+				files = append(files, map[string]any{
+					"artifact_id": ref.ID,
+					"name":        name,
+					"pages":       part,
+					"page_count":  len(pages),
+					"size_bytes":  size,
+				})
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"files": files,
+				"count": len(files),
+			})
+			return tool.Result{Output: output, Artifacts: toArtifactRefs(refs)}, nil
+		}).
 		MustBuild()
 }
 
-func pdfCompress() tool.Tool {
+func (p *pdfPack) pdfCompress() tool.Tool {
 	return tool.NewBuilder("pdf_compress").
-		WithDescription("Compress a PDF to reduce file size").
+		WithDescription("Optimize a PDF artifact by removing redundant objects and compressing streams").
 		Idempotent().
 		WithRiskLevel(tool.RiskLow).
-		MustBuild()
-}
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				documentInput
+				OutputName string `json:"output_name,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
 
-func pdfToImages() tool.Tool {
-	return tool.NewBuilder("pdf_to_images").
-		WithDescription("Convert PDF pages to image files").
-		Idempotent().
-		WithRiskLevel(tool.RiskLow).
-		MustBuild()
-}
+			doc, err := p.load(ctx, in.ArtifactID, in.PasswordName)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			var buf bytes.Buffer
+			if err := api.Optimize(doc.reader(), &buf, newConfiguration()); err != nil {
+				return tool.Result{}, fmt.Errorf("failed to optimize: %w", err)
+			}
+			size := buf.Len()
 
-func pdfFromHTML() tool.Tool {
-	return tool.NewBuilder("pdf_from_html").
-		WithDescription("Generate a PDF document from HTML content").
-		Idempotent().
-		WithRiskLevel(tool.RiskLow).
+			ref, err := p.store(ctx, &buf, outputName(in.OutputName, "compressed.pdf"), contentTypePDF)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"artifact_id":   ref.ID,
+				"name":          ref.Name,
+				"original_size": len(doc.data),
+				"size_bytes":    size,
+			})
+			return tool.Result{Output: output, Artifacts: toArtifactRefs([]artifact.Ref{ref})}, nil
+		}).
 		MustBuild()
 }
@@ -0,0 +1,242 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+
+	"github.com/felixgeelhaar/agent-go/domain/artifact"
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/filesystem"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+// makePDF builds a minimal document whose pages read "Page 1", "Page 2", …
+func makePDF(pages int) []byte {
+	var objects []string
+	kids := make([]string, pages)
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	)
+	for i := range pages {
+		content := fmt.Sprintf("BT /F1 24 Tf 72 700 Td (Page %d) Tj ET", i+1)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+type fixture struct {
+	store *filesystem.ArtifactStore
+	plain string // a 5-page document
+	enc   string // the same document encrypted with "hunter2"
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	store, err := filesystem.NewArtifactStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(name string, data []byte) string {
+		ref, err := store.Store(context.Background(), bytes.NewReader(data), artifact.DefaultStoreOptions().WithName(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref.ID
+	}
+
+	plain := makePDF(5)
+	var enc bytes.Buffer
+	conf := model.NewAESConfiguration("hunter2", "hunter2", 256)
+	if err := api.Encrypt(bytes.NewReader(plain), &enc, conf); err != nil {
+		t.Fatal(err)
+	}
+	return fixture{store: store, plain: put("report.pdf", plain), enc: put("secret.pdf", enc.Bytes())}
+}
+
+func TestExtractTextAndMetadata(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+	p := Pack(Config{Artifacts: f.store})
+
+	out, err := call(p, "pdf_extract_text", map[string]any{"artifact_id": f.plain, "pages": "2-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := out["pages"].([]any)
+	if len(pages) != 2 {
+		t.Fatalf("expected 2 pages, got %v", out)
+	}
+	for i, page := range pages {
+		page := page.(map[string]any)
+		if want := fmt.Sprintf("Page %d", i+2); page["page"] != float64(i+2) || !strings.Contains(page["text"].(string), want) {
+			t.Errorf("page %d: %v", i+2, page)
+		}
+	}
+
+	// Text beyond MaxInlineText is stored as an artifact.
+	out, err = call(Pack(Config{Artifacts: f.store, MaxInlineText: 8}), "pdf_extract_text", map[string]any{"artifact_id": f.plain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["truncated"] != true || out["text_artifact"] == nil || len(out["text"].(string)) != 8 {
+		t.Errorf("unexpected result: %v", out)
+	}
+	if _, err := call(p, "pdf_extract_text", map[string]any{"artifact_id": f.plain, "pages": "4-9"}); !errors.Is(err, ErrInvalidPages) {
+		t.Errorf("expected ErrInvalidPages, got %v", err)
+	}
+
+	out, err = call(p, "pdf_metadata", map[string]any{"artifact_id": f.plain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["page_count"] != 5.0 || out["encrypted"] != false {
+		t.Errorf("unexpected metadata: %v", out)
+	}
+}
+
+func TestEncryptedDocuments(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+	manager := secrets.NewMemoryManager(secrets.WithInitialSecrets(map[string]string{
+		"pdf/finance": "hunter2",
+		"pdf/other":   "wrong",
+	}))
+	p := Pack(Config{
+		Artifacts: f.store,
+		Secrets:   manager,
+		Passwords: map[string]string{"finance": "pdf/finance", "other": "pdf/other"},
+	})
+
+	out, err := call(p, "pdf_metadata", map[string]any{"artifact_id": f.enc, "password_name": "finance"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["encrypted"] != true || out["page_count"] != 5.0 {
+		t.Errorf("unexpected metadata: %v", out)
+	}
+
+	tests := []struct {
+		input map[string]any
+		want  error
+	}{
+		{map[string]any{"artifact_id": f.enc}, ErrPasswordRequired},
+		{map[string]any{"artifact_id": f.enc, "password_name": "other"}, ErrPasswordRequired},
+		{map[string]any{"artifact_id": f.enc, "password_name": "hunter2"}, ErrUnknownPassword},
+		// Passwords are never accepted inline.
+		{map[string]any{"artifact_id": f.enc, "password": "hunter2"}, ErrPasswordRequired},
+	}
+	for _, tt := range tests {
+		if _, err := call(p, "pdf_metadata", tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("%v: expected %v, got %v", tt.input, tt.want, err)
+		}
+	}
+
+	out, err = call(p, "pdf_merge", map[string]any{
+		"artifact_ids":   []string{f.plain, f.enc},
+		"password_names": map[string]string{f.enc: "finance"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["page_count"] != 10.0 {
+		t.Errorf("merged %v pages, want 10", out["page_count"])
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+	p := Pack(Config{Artifacts: f.store, MaxParts: 3})
+
+	out, err := call(p, "pdf_split", map[string]any{"artifact_id": f.plain, "span": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := out["files"].([]any)
+	if len(files) != 3 {
+		t.Fatalf("expected 3 parts, got %v", out)
+	}
+	last := files[2].(map[string]any)
+	if last["pages"] != "5-5" || last["page_count"] != 1.0 || last["name"] != "report_5-5.pdf" {
+		t.Errorf("unexpected last part: %v", last)
+	}
+
+	out, err = call(p, "pdf_split", map[string]any{"artifact_id": f.plain, "ranges": []string{"1,3", "4-"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["count"] != 2.0 || out["files"].([]any)[1].(map[string]any)["page_count"] != 2.0 {
+		t.Errorf("unexpected split: %v", out)
+	}
+
+	if _, err := call(p, "pdf_split", map[string]any{"artifact_id": f.plain}); !errors.Is(err, ErrTooManyParts) {
+		t.Errorf("one page per part: expected ErrTooManyParts, got %v", err)
+	}
+	if _, err := call(p, "pdf_split", map[string]any{"artifact_id": f.plain, "ranges": []string{"1", "2", "3", "4"}}); !errors.Is(err, ErrTooManyParts) {
+		t.Errorf("ranges: expected ErrTooManyParts, got %v", err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	t.Parallel()
+
+	f := newFixture(t)
+	if _, err := call(Pack(Config{Artifacts: f.store, MaxInputBytes: 10}), "pdf_metadata", map[string]any{"artifact_id": f.plain}); !errors.Is(err, ErrInputTooLarge) {
+		t.Errorf("expected ErrInputTooLarge, got %v", err)
+	}
+	if _, err := call(Pack(Config{}), "pdf_metadata", map[string]any{"artifact_id": f.plain}); !errors.Is(err, ErrNoArtifactStore) {
+		t.Errorf("expected ErrNoArtifactStore, got %v", err)
+	}
+	// Rendering needs a backend the pack does not have, so it offers no
+	// tools that could only fail.
+	for _, name := range []string{"pdf_to_images", "pdf_from_html"} {
+		if _, ok := Pack(Config{Artifacts: f.store}).GetTool(name); ok {
+			t.Errorf("%s is registered without a rendering backend", name)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package search
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxTermLength drops tokens that are unlikely to be words, such as
+// base64 blobs, from the term dictionary.
+const maxTermLength = 64
+
+// stopwords are common English words that carry no ranking signal.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true, "in": true,
+	"into": true, "is": true, "it": true, "no": true, "not": true, "of": true,
+	"on": true, "or": true, "such": true, "that": true, "the": true,
+	"their": true, "then": true, "there": true, "these": true, "they": true,
+	"this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// span is the byte range of a token in the analyzed text.
+type span struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lowercase runs of letters and digits.
+func tokenize(text string) []span {
+	var spans []span
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		term := strings.ToLower(text[start:end])
+		if len([]rune(term)) <= maxTermLength {
+			spans = append(spans, span{term: term, start: start, end: end})
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return spans
+}
+
+// analyze returns the indexed terms of text in order.
+func analyze(text string) []string {
+	spans := tokenize(text)
+	terms := make([]string, 0, len(spans))
+	for _, s := range spans {
+		if !stopwords[s.term] {
+			terms = append(terms, s.term)
+		}
+	}
+	return terms
+}
+
+// flatten walks a document and collects analyzed text per field and the
+// exact keyword values used by filters and aggregations. Nested objects
+// become dotted field names and lists contribute every element.
+func flatten(prefix string, value any, text map[string][]string, keywords map[string][]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			name := k
+			if prefix != "" {
+				name = prefix + "." + k
+			}
+			flatten(name, child, text, keywords)
+		}
+	case []any:
+		for _, item := range v {
+			flatten(prefix, item, text, keywords)
+		}
+	case []string:
+		for _, item := range v {
+			flatten(prefix, item, text, keywords)
+		}
+	case string:
+		text[prefix] = append(text[prefix], v)
+		keywords[prefix] = append(keywords[prefix], v)
+	case nil:
+	default:
+		if k, ok := keyword(v); ok {
+			keywords[prefix] = append(keywords[prefix], k)
+		}
+	}
+}
+
+// keyword formats a scalar as the exact value matched by filters.
+func keyword(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	}
+	return "", false
+}
+
+// highlight returns a snippet of text around the first matched term, with
+// matched terms wrapped in "**".
+func highlight(text string, terms map[string]bool, width int) (string, bool) {
+	spans := tokenize(text)
+	var matched []span
+	for _, s := range spans {
+		if terms[s.term] {
+			matched = append(matched, s)
+		}
+	}
+	if len(matched) == 0 {
+		return "", false
+	}
+
+	from := max(0, matched[0].start-width/4)
+	to := min(len(text), from+width)
+	// Snap to token boundaries so the snippet does not cut words or runes.
+	for _, s := range spans {
+		if s.start < from && s.end > from {
+			from = s.start
+		}
+		if s.start < to && s.end > to {
+			to = s.end
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, s := range matched {
+		if s.start < from || s.end > to {
+			continue
+		}
+		b.WriteString(text[pos:s.start])
+		b.WriteString("**")
+		b.WriteString(text[s.start:s.end])
+		b.WriteString("**")
+		pos = s.end
+	}
+	b.WriteString(text[pos:to])
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return strings.Join(strings.Fields(b.String()), " "), true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,168 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Errors returned by backends and tools.
+var (
+	// ErrIndexNotFound indicates the named index does not exist.
+	ErrIndexNotFound = errors.New("index not found")
+
+	// ErrIndexExists indicates an index with the same name already exists.
+	ErrIndexExists = errors.New("index already exists")
+
+	// ErrInvalidIndex indicates an invalid index name.
+	ErrInvalidIndex = errors.New("invalid index")
+
+	// ErrInvalidDocument indicates a document without an ID or fields.
+	ErrInvalidDocument = errors.New("invalid document")
+
+	// ErrInvalidQuery indicates malformed query parameters.
+	ErrInvalidQuery = errors.New("invalid query")
+)
+
+// indexNameRegex restricts index names to a portable subset accepted by
+// hosted search engines and safe as file names.
+var indexNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidateIndexName checks an index name.
+func ValidateIndexName(name string) error {
+	if !indexNameRegex.MatchString(name) {
+		return fmt.Errorf("%w: name %q must be lowercase alphanumeric, '-' or '_'", ErrInvalidIndex, name)
+	}
+	return nil
+}
+
+// Document is a JSON document. String fields are analyzed for full-text
+// search; strings, numbers and booleans can be filtered and aggregated on.
+// Nested objects are addressed with dotted field names such as "author.name".
+type Document struct {
+	ID     string         `json:"id"`
+	Fields map[string]any `json:"fields"`
+}
+
+// Query is a full-text query.
+type Query struct {
+	// Text is the query text. Terms are OR'ed and ranked with BM25; "+term"
+	// requires a term and "-term" excludes it. Empty matches every document
+	// that passes the filters.
+	Text string
+
+	// Fields restricts the searched fields, with optional boosts such as
+	// "title^2". Empty searches all text fields.
+	Fields []string
+
+	// Filters restricts results by exact field value. A scalar requires
+	// equality and a list requires any of its values.
+	Filters map[string]any
+
+	// MatchAll requires every unexcluded term to match.
+	MatchAll bool
+
+	Limit  int
+	Offset int
+}
+
+// Hit is a matching document.
+type Hit struct {
+	ID       string         `json:"id"`
+	Score    float64        `json:"score"`
+	Document map[string]any `json:"document"`
+
+	// Highlights maps fields to a snippet with matched terms marked **like this**.
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+// Result is the result of a search.
+type Result struct {
+	Total int   `json:"total"`
+	Hits  []Hit `json:"hits"`
+}
+
+// SuggestRequest asks for completions of a prefix.
+type SuggestRequest struct {
+	// Prefix is the text typed so far; its last word is completed.
+	Prefix string
+
+	// Field restricts suggestions to one field. Empty uses all text fields.
+	Field string
+
+	Limit int
+}
+
+// Suggestion is a completion with the number of documents containing it.
+type Suggestion struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+// AggregateRequest asks for a terms aggregation.
+type AggregateRequest struct {
+	// Field is the field whose exact values are counted.
+	Field string
+
+	// Size caps the number of buckets.
+	Size int
+
+	// Query restricts the aggregated documents. Nil aggregates all.
+	Query *Query
+}
+
+// Bucket is a value and the number of documents that have it.
+type Bucket struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// Aggregation is the result of a terms aggregation.
+type Aggregation struct {
+	Buckets []Bucket `json:"buckets"`
+
+	// Other counts documents in buckets beyond Size.
+	Other int `json:"other"`
+
+	// Missing counts matching documents without the field.
+	Missing int `json:"missing"`
+}
+
+// IndexInfo describes an index.
+type IndexInfo struct {
+	Name      string    `json:"name"`
+	Documents int       `json:"documents"`
+	Fields    []string  `json:"fields"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Backend is a search engine with named indices. The embedded backend
+// implements it in-process; adapters for Elasticsearch, OpenSearch,
+// Meilisearch or Typesense plug in behind the same interface.
+type Backend interface {
+	// CreateIndex creates an empty index.
+	CreateIndex(ctx context.Context, name string) error
+
+	// DeleteIndex removes an index and all of its documents.
+	DeleteIndex(ctx context.Context, name string) error
+
+	// ListIndices returns all indices sorted by name.
+	ListIndices(ctx context.Context) ([]IndexInfo, error)
+
+	// Index adds or replaces documents.
+	Index(ctx context.Context, index string, docs []Document) error
+
+	// Delete removes documents by ID and returns how many existed.
+	Delete(ctx context.Context, index string, ids []string) (int, error)
+
+	// Search runs a full-text query.
+	Search(ctx context.Context, index string, q Query) (*Result, error)
+
+	// Suggest completes a prefix from the indexed terms.
+	Suggest(ctx context.Context, index string, req SuggestRequest) ([]Suggestion, error)
+
+	// Aggregate counts documents per field value.
+	Aggregate(ctx context.Context, index string, req AggregateRequest) (*Aggregation, error)
+}
@@ -0,0 +1,871 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BM25 parameters, as used by Lucene.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// snippetWidth is the approximate length of highlight snippets in bytes.
+const snippetWidth = 160
+
+// minCompaction is the number of logged document changes below which the
+// change log is never folded into the snapshot.
+const minCompaction = 1024
+
+// EmbeddedBackend is an in-process inverted index with BM25 ranking. With
+// a directory each index is persisted as a <dir>/<name>.json snapshot and
+// a <dir>/<name>.log of changes since the snapshot. Every write is appended
+// to the log before it is applied in memory, and the log is folded into
+// the snapshot once it outgrows the index, so bulk indexing costs
+// amortized constant time per document. Indices are reloaded on start; the
+// inverted index is rebuilt in memory. It suits local corpora of up to a
+// few hundred thousand documents.
+type EmbeddedBackend struct {
+	dir string
+
+	mu      sync.RWMutex
+	indices map[string]*invertedIndex
+}
+
+// NewMemoryBackend returns an embedded backend that keeps indices in memory.
+func NewMemoryBackend() *EmbeddedBackend {
+	return &EmbeddedBackend{indices: make(map[string]*invertedIndex)}
+}
+
+// NewEmbeddedBackend returns an embedded backend persisted to dir, loading
+// any indices already stored there.
+func NewEmbeddedBackend(dir string) (*EmbeddedBackend, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create index directory: %w", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read index directory: %w", err)
+	}
+
+	b := &EmbeddedBackend{dir: dir, indices: make(map[string]*invertedIndex)}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() || ValidateIndexName(name) != nil {
+			continue
+		}
+		ix, err := loadIndex(filepath.Join(dir, entry.Name()), filepath.Join(dir, name+".log"))
+		if err != nil {
+			return nil, fmt.Errorf("load index %s: %w", name, err)
+		}
+		ix.name = name
+		b.indices[name] = ix
+	}
+	return b, nil
+}
+
+// CreateIndex creates an empty index.
+func (b *EmbeddedBackend) CreateIndex(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := ValidateIndexName(name); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.indices[name]; ok {
+		return fmt.Errorf("%w: %s", ErrIndexExists, name)
+	}
+	ix := newInvertedIndex(name, time.Now().UTC())
+	if err := b.persist(ix); err != nil {
+		return err
+	}
+	b.indices[name] = ix
+	return nil
+}
+
+// DeleteIndex removes an index and its file.
+func (b *EmbeddedBackend) DeleteIndex(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.indices[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrIndexNotFound, name)
+	}
+	if b.dir != "" {
+		for _, path := range []string{b.path(name), b.logPath(name)} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("remove index %s: %w", name, err)
+			}
+		}
+	}
+	delete(b.indices, name)
+	return nil
+}
+
+// ListIndices returns all indices sorted by name.
+func (b *EmbeddedBackend) ListIndices(ctx context.Context) ([]IndexInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	infos := make([]IndexInfo, 0, len(b.indices))
+	for _, name := range sortedKeys(b.indices) {
+		infos = append(infos, b.indices[name].info())
+	}
+	return infos, nil
+}
+
+// Index adds or replaces documents. The batch is validated before any
+// document is written.
+func (b *EmbeddedBackend) Index(ctx context.Context, index string, docs []Document) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	normalized := make([]Document, len(docs))
+	for i, doc := range docs {
+		if doc.ID == "" {
+			return fmt.Errorf("%w: document %d has no id", ErrInvalidDocument, i)
+		}
+		fields, err := normalize(doc.Fields)
+		if err != nil {
+			return fmt.Errorf("%w: document %s: %v", ErrInvalidDocument, doc.ID, err)
+		}
+		normalized[i] = Document{ID: doc.ID, Fields: fields}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ix, err := b.lookup(index)
+	if err != nil {
+		return err
+	}
+	if err := b.appendLog(ix, logEntry{Index: normalized}); err != nil {
+		return err
+	}
+	for _, doc := range normalized {
+		ix.add(doc)
+	}
+	b.logged(ix, len(normalized))
+	return nil
+}
+
+// Delete removes documents by ID and returns how many existed.
+func (b *EmbeddedBackend) Delete(ctx context.Context, index string, ids []string) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ix, err := b.lookup(index)
+	if err != nil {
+		return 0, err
+	}
+	var existing []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := ix.docs[id]; ok && !seen[id] {
+			seen[id] = true
+			existing = append(existing, id)
+		}
+	}
+	if len(existing) == 0 {
+		return 0, nil
+	}
+	if err := b.appendLog(ix, logEntry{Delete: existing}); err != nil {
+		return 0, err
+	}
+	for _, id := range existing {
+		ix.remove(id)
+	}
+	b.logged(ix, len(existing))
+	return len(existing), nil
+}
+
+// Search ranks documents against the query with BM25.
+func (b *EmbeddedBackend) Search(ctx context.Context, index string, q Query) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	ix, err := b.lookup(index)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ix.match(q)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Total: len(m.hits), Hits: []Hit{}}
+	if q.Offset >= len(m.hits) {
+		return result, nil
+	}
+	page := m.hits[max(0, q.Offset):]
+	if q.Limit > 0 && len(page) > q.Limit {
+		page = page[:q.Limit]
+	}
+	for _, h := range page {
+		doc := ix.docs[h.id]
+		hit := Hit{ID: h.id, Score: h.score, Document: doc.source}
+		if len(m.terms) > 0 {
+			for field := range m.fields {
+				for _, text := range doc.text[field] {
+					if snippet, ok := highlight(text, m.terms, snippetWidth); ok {
+						if hit.Highlights == nil {
+							hit.Highlights = make(map[string]string)
+						}
+						hit.Highlights[field] = snippet
+						break
+					}
+				}
+			}
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result, nil
+}
+
+// Suggest completes the last word of the prefix from the term dictionary,
+// ranked by the number of documents containing each term.
+func (b *EmbeddedBackend) Suggest(ctx context.Context, index string, req SuggestRequest) ([]Suggestion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	spans := tokenize(req.Prefix)
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("%w: prefix has no words", ErrInvalidQuery)
+	}
+	last := spans[len(spans)-1]
+	head := req.Prefix[:last.start]
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	ix, err := b.lookup(index)
+	if err != nil {
+		return nil, err
+	}
+	fields := []string{req.Field}
+	if req.Field == "" {
+		fields = sortedKeys(ix.postings)
+	}
+
+	docs := make(map[string]map[string]bool)
+	for _, field := range fields {
+		for term, postings := range ix.postings[field] {
+			if !strings.HasPrefix(term, last.term) {
+				continue
+			}
+			if docs[term] == nil {
+				docs[term] = make(map[string]bool, len(postings))
+			}
+			for id := range postings {
+				docs[term][id] = true
+			}
+		}
+	}
+
+	suggestions := make([]Suggestion, 0, len(docs))
+	for term, ids := range docs {
+		suggestions = append(suggestions, Suggestion{Text: head + term, Count: len(ids)})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+	if req.Limit > 0 && len(suggestions) > req.Limit {
+		suggestions = suggestions[:req.Limit]
+	}
+	return suggestions, nil
+}
+
+// Aggregate counts matching documents per exact value of a field.
+func (b *EmbeddedBackend) Aggregate(ctx context.Context, index string, req AggregateRequest) (*Aggregation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if req.Field == "" {
+		return nil, fmt.Errorf("%w: field is required", ErrInvalidQuery)
+	}
+	if req.Size <= 0 {
+		req.Size = 10
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	ix, err := b.lookup(index)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if req.Query != nil {
+		m, err := ix.match(*req.Query)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range m.hits {
+			ids = append(ids, h.id)
+		}
+	} else {
+		ids = sortedKeys(ix.docs)
+	}
+
+	agg := &Aggregation{Buckets: []Bucket{}}
+	counts := make(map[string]int)
+	for _, id := range ids {
+		values := ix.docs[id].keywords[req.Field]
+		if len(values) == 0 {
+			agg.Missing++
+			continue
+		}
+		seen := make(map[string]bool, len(values))
+		for _, v := range values {
+			if !seen[v] {
+				seen[v] = true
+				counts[v]++
+			}
+		}
+	}
+	for key, count := range counts {
+		agg.Buckets = append(agg.Buckets, Bucket{Key: key, Count: count})
+	}
+	sort.Slice(agg.Buckets, func(i, j int) bool {
+		if agg.Buckets[i].Count != agg.Buckets[j].Count {
+			return agg.Buckets[i].Count > agg.Buckets[j].Count
+		}
+		return agg.Buckets[i].Key < agg.Buckets[j].Key
+	})
+	if len(agg.Buckets) > req.Size {
+		for _, bucket := range agg.Buckets[req.Size:] {
+			agg.Other += bucket.Count
+		}
+		agg.Buckets = agg.Buckets[:req.Size]
+	}
+	return agg, nil
+}
+
+func (b *EmbeddedBackend) lookup(name string) (*invertedIndex, error) {
+	ix, ok := b.indices[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrIndexNotFound, name)
+	}
+	return ix, nil
+}
+
+func (b *EmbeddedBackend) path(name string) string {
+	return filepath.Join(b.dir, name+".json")
+}
+
+func (b *EmbeddedBackend) logPath(name string) string {
+	return filepath.Join(b.dir, name+".log")
+}
+
+// ============================================================================
+// Persistence
+// ============================================================================
+
+// indexFile is the on-disk form of an index. Only documents are stored;
+// postings are rebuilt on load so the analyzer can evolve.
+type indexFile struct {
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	Documents []Document `json:"documents"`
+}
+
+// logEntry is one line of an index's change log: documents added or
+// replaced, or IDs deleted. Replaying a log is idempotent, so a log left
+// behind by an interrupted compaction can be replayed over the snapshot.
+type logEntry struct {
+	Index  []Document `json:"index,omitempty"`
+	Delete []string   `json:"delete,omitempty"`
+}
+
+// appendLog durably appends a change to the index's log.
+func (b *EmbeddedBackend) appendLog(ix *invertedIndex, entry logEntry) error {
+	if b.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode change to index %s: %w", ix.name, err)
+	}
+	f, err := os.OpenFile(b.logPath(ix.name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	return nil
+}
+
+// logged counts n document changes appended to the log and compacts the
+// log into the snapshot once it holds more changes than the index has
+// documents. The changes are already durable, so a failed compaction is
+// retried on a later write rather than reported.
+func (b *EmbeddedBackend) logged(ix *invertedIndex, n int) {
+	if b.dir == "" {
+		return
+	}
+	ix.pending += n
+	if ix.pending < max(minCompaction, len(ix.docs)) {
+		return
+	}
+	if err := b.persist(ix); err != nil {
+		return
+	}
+	if err := os.Remove(b.logPath(ix.name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	ix.pending = 0
+}
+
+// persist writes an index snapshot atomically through a temporary file.
+func (b *EmbeddedBackend) persist(ix *invertedIndex) error {
+	if b.dir == "" {
+		return nil
+	}
+	file := indexFile{Name: ix.name, CreatedAt: ix.created, Documents: make([]Document, 0, len(ix.docs))}
+	for _, id := range sortedKeys(ix.docs) {
+		file.Documents = append(file.Documents, Document{ID: id, Fields: ix.docs[id].source})
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("encode index %s: %w", ix.name, err)
+	}
+
+	tmp, err := os.CreateTemp(b.dir, "."+ix.name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	if err := os.Rename(tmp.Name(), b.path(ix.name)); err != nil {
+		return fmt.Errorf("persist index %s: %w", ix.name, err)
+	}
+	return nil
+}
+
+// loadIndex reads an index snapshot and replays its change log.
+func loadIndex(path, logPath string) (*invertedIndex, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	ix := newInvertedIndex(file.Name, file.CreatedAt)
+	for _, doc := range file.Documents {
+		ix.add(doc)
+	}
+	if err := replayLog(ix, logPath); err != nil {
+		return nil, err
+	}
+	return ix, nil
+}
+
+// replayLog applies a change log to the index. A torn final entry, left by
+// a crash during an append, was never acknowledged; it is cut off so later
+// appends start on a clean line.
+func replayLog(ix *invertedIndex, path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	for {
+		valid := dec.InputOffset()
+		var entry logEntry
+		err := dec.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return f.Truncate(valid)
+		}
+		if err != nil {
+			return fmt.Errorf("change log: %w", err)
+		}
+		for _, doc := range entry.Index {
+			ix.add(doc)
+		}
+		for _, id := range entry.Delete {
+			ix.remove(id)
+		}
+		ix.pending += len(entry.Index) + len(entry.Delete)
+	}
+}
+
+// normalize round-trips fields through JSON so stored documents have the
+// same types whether they came from Go callers, tool input or disk.
+func normalize(fields map[string]any) (map[string]any, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("no fields")
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ============================================================================
+// Inverted Index
+// ============================================================================
+
+type document struct {
+	source   map[string]any
+	text     map[string][]string
+	keywords map[string][]string
+
+	// terms maps text fields to term frequencies; lengths to token counts.
+	terms   map[string]map[string]int
+	lengths map[string]int
+}
+
+type invertedIndex struct {
+	name    string
+	created time.Time
+	docs    map[string]*document
+
+	// postings maps field → term → document ID → term frequency.
+	postings map[string]map[string]map[string]int
+
+	// tokens and textDocs give the average field length for BM25.
+	tokens   map[string]int
+	textDocs map[string]int
+
+	// fields counts the documents that have each field.
+	fields map[string]int
+
+	// pending counts the document changes in the change log.
+	pending int
+}
+
+func newInvertedIndex(name string, created time.Time) *invertedIndex {
+	return &invertedIndex{
+		name:     name,
+		created:  created,
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]map[string]int),
+		tokens:   make(map[string]int),
+		textDocs: make(map[string]int),
+		fields:   make(map[string]int),
+	}
+}
+
+func (ix *invertedIndex) info() IndexInfo {
+	return IndexInfo{
+		Name:      ix.name,
+		Documents: len(ix.docs),
+		Fields:    sortedKeys(ix.fields),
+		CreatedAt: ix.created,
+	}
+}
+
+func (ix *invertedIndex) add(doc Document) {
+	ix.remove(doc.ID)
+
+	d := &document{
+		source:   doc.Fields,
+		text:     make(map[string][]string),
+		keywords: make(map[string][]string),
+		terms:    make(map[string]map[string]int),
+		lengths:  make(map[string]int),
+	}
+	flatten("", doc.Fields, d.text, d.keywords)
+
+	for field := range d.keywords {
+		ix.fields[field]++
+	}
+	for field, values := range d.text {
+		tf := make(map[string]int)
+		for _, value := range values {
+			for _, term := range analyze(value) {
+				tf[term]++
+				d.lengths[field]++
+			}
+		}
+		if len(tf) == 0 {
+			continue
+		}
+		d.terms[field] = tf
+
+		if ix.postings[field] == nil {
+			ix.postings[field] = make(map[string]map[string]int)
+		}
+		for term, n := range tf {
+			if ix.postings[field][term] == nil {
+				ix.postings[field][term] = make(map[string]int)
+			}
+			ix.postings[field][term][doc.ID] = n
+		}
+		ix.tokens[field] += d.lengths[field]
+		ix.textDocs[field]++
+	}
+	ix.docs[doc.ID] = d
+}
+
+func (ix *invertedIndex) remove(id string) bool {
+	d, ok := ix.docs[id]
+	if !ok {
+		return false
+	}
+	for field := range d.keywords {
+		if ix.fields[field]--; ix.fields[field] == 0 {
+			delete(ix.fields, field)
+		}
+	}
+	for field, tf := range d.terms {
+		for term := range tf {
+			delete(ix.postings[field][term], id)
+			if len(ix.postings[field][term]) == 0 {
+				delete(ix.postings[field], term)
+			}
+		}
+		if len(ix.postings[field]) == 0 {
+			delete(ix.postings, field)
+		}
+		ix.tokens[field] -= d.lengths[field]
+		if ix.textDocs[field]--; ix.textDocs[field] == 0 {
+			delete(ix.textDocs, field)
+			delete(ix.tokens, field)
+		}
+	}
+	delete(ix.docs, id)
+	return true
+}
+
+type scoredDoc struct {
+	id    string
+	score float64
+}
+
+// matches is the full, ranked result of a query.
+type matches struct {
+	hits   []scoredDoc
+	fields map[string]float64
+	terms  map[string]bool
+}
+
+// parsedQuery splits query text into optional, required and excluded terms.
+type parsedQuery struct {
+	should, must, mustNot []string
+}
+
+func parseQueryText(text string) parsedQuery {
+	var q parsedQuery
+	for _, word := range strings.Fields(text) {
+		target := &q.should
+		switch {
+		case strings.HasPrefix(word, "+"):
+			target, word = &q.must, word[1:]
+		case strings.HasPrefix(word, "-"):
+			target, word = &q.mustNot, word[1:]
+		}
+		*target = append(*target, analyze(word)...)
+	}
+	return q
+}
+
+// parseFields resolves searched fields and their boosts.
+func (ix *invertedIndex) parseFields(specs []string) (map[string]float64, error) {
+	fields := make(map[string]float64)
+	if len(specs) == 0 {
+		for field := range ix.postings {
+			fields[field] = 1
+		}
+		return fields, nil
+	}
+	for _, spec := range specs {
+		field, boost := spec, 1.0
+		if name, b, ok := strings.Cut(spec, "^"); ok {
+			v, err := strconv.ParseFloat(b, 64)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("%w: bad boost in %q", ErrInvalidQuery, spec)
+			}
+			field, boost = name, v
+		}
+		if field == "" {
+			return nil, fmt.Errorf("%w: empty field name", ErrInvalidQuery)
+		}
+		fields[field] = boost
+	}
+	return fields, nil
+}
+
+// parseFilters converts filter values to exact keyword options per field.
+func parseFilters(filters map[string]any) (map[string][]string, error) {
+	out := make(map[string][]string, len(filters))
+	for field, value := range filters {
+		values, ok := value.([]any)
+		if !ok {
+			values = []any{value}
+		}
+		for _, v := range values {
+			k, ok := keyword(v)
+			if !ok {
+				return nil, fmt.Errorf("%w: filter %q must be a string, number, boolean or a list of them", ErrInvalidQuery, field)
+			}
+			out[field] = append(out[field], k)
+		}
+	}
+	return out, nil
+}
+
+func (d *document) matchesFilters(filters map[string][]string) bool {
+	for field, options := range filters {
+		matched := false
+		for _, have := range d.keywords[field] {
+			for _, want := range options {
+				if have == want {
+					matched = true
+				}
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// match scores every document against q and returns them ranked by score,
+// then ID.
+func (ix *invertedIndex) match(q Query) (*matches, error) {
+	fields, err := ix.parseFields(q.Fields)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := parseFilters(q.Filters)
+	if err != nil {
+		return nil, err
+	}
+	parsed := parseQueryText(q.Text)
+
+	positive := make(map[string]bool)
+	for _, term := range append(parsed.should, parsed.must...) {
+		positive[term] = true
+	}
+
+	scores := make(map[string]float64)
+	matched := make(map[string]map[string]bool)
+	n := float64(len(ix.docs))
+	for term := range positive {
+		for field, boost := range fields {
+			postings := ix.postings[field][term]
+			if len(postings) == 0 {
+				continue
+			}
+			df := float64(len(postings))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			avgdl := float64(ix.tokens[field]) / float64(ix.textDocs[field])
+			for id, tf := range postings {
+				dl := float64(ix.docs[id].lengths[field])
+				f := float64(tf)
+				scores[id] += boost * idf * f * (bm25K1 + 1) / (f + bm25K1*(1-bm25B+bm25B*dl/avgdl))
+				if matched[id] == nil {
+					matched[id] = make(map[string]bool)
+				}
+				matched[id][term] = true
+			}
+		}
+	}
+
+	// Without positive terms every document is a candidate.
+	candidates := make([]string, 0, len(scores))
+	if len(positive) == 0 {
+		candidates = sortedKeys(ix.docs)
+	} else {
+		for id := range scores {
+			candidates = append(candidates, id)
+		}
+	}
+
+	hits := make([]scoredDoc, 0, len(candidates))
+	for _, id := range candidates {
+		if !ix.accept(id, matched[id], parsed, positive, q.MatchAll, fields, filters) {
+			continue
+		}
+		hits = append(hits, scoredDoc{id: id, score: scores[id]})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+	return &matches{hits: hits, fields: fields, terms: positive}, nil
+}
+
+func (ix *invertedIndex) accept(id string, matched map[string]bool, q parsedQuery, positive map[string]bool, matchAll bool, fields map[string]float64, filters map[string][]string) bool {
+	for _, term := range q.must {
+		if !matched[term] {
+			return false
+		}
+	}
+	if matchAll {
+		for term := range positive {
+			if !matched[term] {
+				return false
+			}
+		}
+	}
+	doc := ix.docs[id]
+	for _, term := range q.mustNot {
+		for field := range fields {
+			if doc.terms[field][term] > 0 {
+				return false
+			}
+		}
+	}
+	return doc.matchesFilters(filters)
+}
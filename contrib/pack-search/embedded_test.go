@@ -0,0 +1,335 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+var articles = []Document{
+	{ID: "go", Fields: map[string]any{"title": "Concurrency in Go", "body": "Goroutines and channels make concurrency simple.", "lang": "go", "year": 2012}},
+	{ID: "rust", Fields: map[string]any{"title": "Fearless concurrency", "body": "Ownership rules out data races at compile time.", "lang": "rust", "year": 2015}},
+	{ID: "gc", Fields: map[string]any{"title": "Garbage collection", "body": "The Go garbage collector is concurrent.", "lang": "go", "year": 2018}},
+	{ID: "py", Fields: map[string]any{"title": "Python generators", "body": "Generators yield values lazily.", "lang": "python"}},
+}
+
+func newTestBackend(t *testing.T, b *EmbeddedBackend) *EmbeddedBackend {
+	t.Helper()
+	ctx := context.Background()
+	if err := b.CreateIndex(ctx, "articles"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Index(ctx, "articles", articles); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func ids(hits []Hit) []string {
+	out := make([]string, len(hits))
+	for i, h := range hits {
+		out[i] = h.ID
+	}
+	return out
+}
+
+func TestSearchRanking(t *testing.T) {
+	t.Parallel()
+
+	b := newTestBackend(t, NewMemoryBackend())
+	ctx := context.Background()
+
+	tests := []struct {
+		query Query
+		want  []string
+	}{
+		// Two matches in a short document outrank one in a longer one.
+		{Query{Text: "concurrency"}, []string{"go", "rust"}},
+		{Query{Text: "concurrency", Fields: []string{"body"}}, []string{"go"}},
+		{Query{Text: "garbage concurrency", Fields: []string{"title^5", "body"}}, []string{"gc", "go", "rust"}},
+		{Query{Text: "+go concurrency"}, []string{"go", "gc"}},
+		{Query{Text: "concurrency -ownership"}, []string{"go"}},
+		{Query{Text: "go concurrency", MatchAll: true}, []string{"go"}},
+		{Query{Text: "concurrency", Filters: map[string]any{"lang": "rust"}}, []string{"rust"}},
+		{Query{Filters: map[string]any{"year": []any{2012, 2018}}}, []string{"gc", "go"}},
+	}
+	for _, tt := range tests {
+		result, err := b.Search(ctx, "articles", tt.query)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.query, err)
+		}
+		if got := ids(result.Hits); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%+v: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	result, err := b.Search(ctx, "articles", Query{Text: "garbage", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Hits[0].Highlights["title"] != "**Garbage** collection" || result.Hits[0].Score <= 0 {
+		t.Errorf("unexpected hit: %+v", result.Hits[0])
+	}
+
+	if _, err := b.Search(ctx, "articles", Query{Text: "x", Fields: []string{"title^0"}}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+	if _, err := b.Search(ctx, "missing", Query{}); !errors.Is(err, ErrIndexNotFound) {
+		t.Errorf("expected ErrIndexNotFound, got %v", err)
+	}
+}
+
+func TestSuggestAndAggregate(t *testing.T) {
+	t.Parallel()
+
+	b := newTestBackend(t, NewMemoryBackend())
+	ctx := context.Background()
+
+	suggestions, err := b.Suggest(ctx, "articles", SuggestRequest{Prefix: "fearless con"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Suggestion{{Text: "fearless concurrency", Count: 2}, {Text: "fearless concurrent", Count: 1}}
+	if fmt.Sprint(suggestions) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", suggestions, want)
+	}
+	suggestions, err = b.Suggest(ctx, "articles", SuggestRequest{Prefix: "g", Field: "title", Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Text != "garbage" {
+		t.Errorf("unexpected suggestions: %v", suggestions)
+	}
+	if _, err := b.Suggest(ctx, "articles", SuggestRequest{Prefix: "  "}); !errors.Is(err, ErrInvalidQuery) {
+		t.Errorf("expected ErrInvalidQuery, got %v", err)
+	}
+
+	agg, err := b.Aggregate(ctx, "articles", AggregateRequest{Field: "lang", Size: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(agg.Buckets) != "[{go 2}]" || agg.Other != 2 || agg.Missing != 0 {
+		t.Errorf("unexpected aggregation: %+v", agg)
+	}
+	agg, err = b.Aggregate(ctx, "articles", AggregateRequest{Field: "year", Query: &Query{Text: "concurrency"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(agg.Buckets) != "[{2012 1} {2015 1}]" || agg.Missing != 0 {
+		t.Errorf("unexpected aggregation: %+v", agg)
+	}
+	agg, err = b.Aggregate(ctx, "articles", AggregateRequest{Field: "year"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.Missing != 1 {
+		t.Errorf("missing = %d, want 1", agg.Missing)
+	}
+}
+
+func TestEmbeddedReload(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	b, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTestBackend(t, b)
+	if n, err := b.Delete(ctx, "articles", []string{"py", "py", "unknown"}); err != nil || n != 1 {
+		t.Fatalf("delete: %d, %v", n, err)
+	}
+	if err := b.Index(ctx, "articles", []Document{{ID: "go", Fields: map[string]any{"title": "Go memory model", "lang": "go"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Small writes are appended to the change log, not the snapshot.
+	if _, err := os.Stat(filepath.Join(dir, "articles.log")); err != nil {
+		t.Fatalf("expected a change log: %v", err)
+	}
+
+	reloaded, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := reloaded.ListIndices(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Documents != 3 {
+		t.Fatalf("unexpected indices after reload: %+v", infos)
+	}
+	result, err := reloaded.Search(ctx, "articles", Query{Text: "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(result.Hits); fmt.Sprint(got) != "[go]" {
+		t.Errorf("replaced document not reloaded, got %v", got)
+	}
+
+	if err := reloaded.DeleteIndex(ctx, "articles"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"articles.json", "articles.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s was not removed: %v", name, err)
+		}
+	}
+}
+
+func TestEmbeddedCompaction(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	b, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CreateIndex(ctx, "docs"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < minCompaction; i++ {
+		doc := Document{ID: fmt.Sprint(i), Fields: map[string]any{"n": i, "text": "word"}}
+		if err := b.Index(ctx, "docs", []Document{doc}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The log was folded into the snapshot once it reached the threshold.
+	if _, err := os.Stat(filepath.Join(dir, "docs.log")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the change log to be compacted, got %v", err)
+	}
+	reloaded, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := reloaded.Search(ctx, "docs", Query{Text: "word"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != minCompaction {
+		t.Errorf("reloaded %d documents, want %d", result.Total, minCompaction)
+	}
+}
+
+func TestEmbeddedTornLog(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	b, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTestBackend(t, b)
+
+	// A crash during an append leaves a partial last line.
+	log, err := os.OpenFile(filepath.Join(dir, "articles.log"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := log.WriteString(`{"delete":["go"`); err != nil {
+		t.Fatal(err)
+	}
+	_ = log.Close()
+
+	reloaded, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Index(ctx, "articles", []Document{{ID: "new", Fields: map[string]any{"title": "after the crash"}}}); err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, _ := again.ListIndices(ctx)
+	if len(infos) != 1 || infos[0].Documents != len(articles)+1 {
+		t.Errorf("unexpected indices: %+v", infos)
+	}
+}
+
+func TestEmbeddedWriteFailureLeavesIndexUnchanged(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	b, err := NewEmbeddedBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newTestBackend(t, b)
+
+	// Replace the log with a directory so appends fail.
+	if err := os.Remove(filepath.Join(dir, "articles.log")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "articles.log"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Index(ctx, "articles", []Document{{ID: "new", Fields: map[string]any{"title": "lost"}}}); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if _, err := b.Delete(ctx, "articles", []string{"go"}); err == nil {
+		t.Fatal("expected the delete to fail")
+	}
+	result, err := b.Search(ctx, "articles", Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != len(articles) {
+		t.Errorf("failed writes changed the index: %v", ids(result.Hits))
+	}
+}
+
+func TestPackTools(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{DefaultIndex: "notes"})
+	if _, err := call(p, "search_bulk_index", map[string]any{"documents": []map[string]any{
+		{"id": "1", "document": map[string]any{"text": "deploy the api service", "team": "ops"}},
+		{"id": "2", "document": map[string]any{"text": "api design review", "team": "dev"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := call(p, "search_query", map[string]any{"query": "api", "filters": map[string]any{"team": "ops"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["total"] != 1.0 {
+		t.Errorf("unexpected result: %v", out)
+	}
+	out, err = call(p, "search_delete", map[string]any{"ids": []string{"1", "3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["deleted_count"] != 1.0 {
+		t.Errorf("unexpected result: %v", out)
+	}
+	if _, err := call(Pack(Config{}), "search_query", map[string]any{"query": "api"}); !errors.Is(err, ErrIndexNotFound) {
+		t.Errorf("expected ErrIndexNotFound without a default index, got %v", err)
+	}
+}
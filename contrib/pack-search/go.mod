@@ -2,6 +2,9 @@ module github.com/felixgeelhaar/agent-go/contrib/pack-search
 
 go 1.25.0
 
-require github.com/felixgeelhaar/agent-go v0.0.0
+require (
+	github.com/felixgeelhaar/agent-go v0.0.0
+	github.com/google/uuid v1.6.0
+)
 
 replace github.com/felixgeelhaar/agent-go => ../..
@@ -4,33 +4,72 @@
 //   - search_query: Execute a search query
 //   - search_index: Index a document
 //   - search_bulk_index: Bulk index multiple documents
-//   - search_delete: Delete a document from the index
+//   - search_delete: Delete documents from the index
 //   - search_suggest: Get search suggestions/autocomplete
 //   - search_aggregate: Run aggregation queries
 //   - search_indices: List available indices
 //
-// Supports Elasticsearch, OpenSearch, Meilisearch, and Typesense.
+// Indices are provided by a Backend. The built-in EmbeddedBackend is an
+// inverted index with BM25 ranking, prefix suggestions and terms
+// aggregations, optionally persisted to a directory, so agents can search
+// local corpora with no service running. Adapters for Elasticsearch,
+// OpenSearch, Meilisearch or Typesense plug in behind the same interface.
 package search
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Config configures the search pack.
+type Config struct {
+	// Backend provides the indices. Defaults to NewMemoryBackend(); use
+	// NewEmbeddedBackend(dir) to persist indices across runs.
+	Backend Backend
+
+	// DefaultIndex is used when a call does not name an index.
+	DefaultIndex string
+
+	// MaxResults caps the hits, suggestions and buckets per call.
+	// Defaults to 100.
+	MaxResults int
+
+	// MaxBatch caps the documents per bulk index or delete. Defaults to 1000.
+	MaxBatch int
+}
+
 // Pack returns the search tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.Backend == nil {
+		cfg.Backend = NewMemoryBackend()
+	}
+	if cfg.MaxResults <= 0 {
+		cfg.MaxResults = 100
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 1000
+	}
+	p := &searchPack{cfg: cfg}
+
 	return pack.NewBuilder("search").
 		WithDescription("Search engine tools for indexing and querying").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			searchQuery(),
-			searchIndex(),
-			searchBulkIndex(),
-			searchDelete(),
-			searchSuggest(),
-			searchAggregate(),
-			searchIndices(),
+			p.searchQuery(),
+			p.searchIndex(),
+			p.searchBulkIndex(),
+			p.searchDelete(),
+			p.searchSuggest(),
+			p.searchAggregate(),
+			p.searchIndices(),
 		).
 		AllowInState(agent.StateExplore, "search_query", "search_suggest", "search_aggregate", "search_indices").
 		AllowInState(agent.StateAct, "search_query", "search_index", "search_bulk_index", "search_delete", "search_suggest", "search_aggregate", "search_indices").
@@ -38,54 +77,335 @@ func Pack() *pack.Pack {
 		Build()
 }
 
-func searchQuery() tool.Tool {
-	return tool.NewBuilder("search_query").
-		WithDescription("Execute a search query and return matching documents").
-		ReadOnly().
-		MustBuild()
+type searchPack struct {
+	cfg Config
 }
 
-func searchIndex() tool.Tool {
-	return tool.NewBuilder("search_index").
-		WithDescription("Index a document for searching").
-		Idempotent().
-		WithRiskLevel(tool.RiskLow).
-		MustBuild()
+func (p *searchPack) indexName(name string) (string, error) {
+	if name == "" {
+		name = p.cfg.DefaultIndex
+	}
+	if name == "" {
+		return "", fmt.Errorf("%w: no index given and no default configured", ErrIndexNotFound)
+	}
+	return name, nil
 }
 
-func searchBulkIndex() tool.Tool {
-	return tool.NewBuilder("search_bulk_index").
-		WithDescription("Bulk index multiple documents").
-		WithRiskLevel(tool.RiskMedium).
-		MustBuild()
+// write indexes documents, creating the index on first use as hosted
+// engines do.
+func (p *searchPack) write(ctx context.Context, index string, docs []Document) error {
+	err := p.cfg.Backend.Index(ctx, index, docs)
+	if !errors.Is(err, ErrIndexNotFound) {
+		return err
+	}
+	if err := p.cfg.Backend.CreateIndex(ctx, index); err != nil && !errors.Is(err, ErrIndexExists) {
+		return err
+	}
+	return p.cfg.Backend.Index(ctx, index, docs)
 }
 
-func searchDelete() tool.Tool {
-	return tool.NewBuilder("search_delete").
-		WithDescription("Delete a document from the search index").
-		Destructive().
+// queryInput is the query shared by search_query and search_aggregate.
+type queryInput struct {
+	Query    string         `json:"query,omitempty"`
+	Fields   []string       `json:"fields,omitempty"`
+	Filters  map[string]any `json:"filters,omitempty"`
+	MatchAll bool           `json:"match_all,omitempty"`
+}
+
+func (in queryInput) toQuery() Query {
+	return Query{Text: in.Query, Fields: in.Fields, Filters: in.Filters, MatchAll: in.MatchAll}
+}
+
+// ============================================================================
+// Query Tools
+// ============================================================================
+
+func (p *searchPack) searchQuery() tool.Tool {
+	return tool.NewBuilder("search_query").
+		WithDescription("Execute a full-text search ranked by relevance; supports field boosts (\"title^2\"), +required and -excluded terms, and exact-value filters").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index string `json:"index,omitempty"`
+				queryInput
+				Limit  int `json:"limit,omitempty"`
+				Offset int `json:"offset,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Offset < 0 {
+				return tool.Result{}, fmt.Errorf("%w: offset must not be negative", ErrInvalidQuery)
+			}
+			if in.Limit <= 0 {
+				in.Limit = 10
+			}
+			index, err := p.indexName(in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			q := in.toQuery()
+			q.Limit = min(in.Limit, p.cfg.MaxResults)
+			q.Offset = in.Offset
+			result, err := p.cfg.Backend.Search(ctx, index, q)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("search failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index": index,
+				"total": result.Total,
+				"hits":  result.Hits,
+				"count": len(result.Hits),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func searchSuggest() tool.Tool {
+func (p *searchPack) searchSuggest() tool.Tool {
 	return tool.NewBuilder("search_suggest").
-		WithDescription("Get search suggestions and autocomplete results").
+		WithDescription("Complete the last word of a prefix from indexed terms, ranked by document frequency").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index  string `json:"index,omitempty"`
+				Prefix string `json:"prefix"`
+				Field  string `json:"field,omitempty"`
+				Limit  int    `json:"limit,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Prefix == "" {
+				return tool.Result{}, errors.New("prefix is required")
+			}
+			if in.Limit <= 0 {
+				in.Limit = 5
+			}
+			index, err := p.indexName(in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			suggestions, err := p.cfg.Backend.Suggest(ctx, index, SuggestRequest{
+				Prefix: in.Prefix,
+				Field:  in.Field,
+				Limit:  min(in.Limit, p.cfg.MaxResults),
+			})
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("suggest failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":       index,
+				"suggestions": suggestions,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func searchAggregate() tool.Tool {
+func (p *searchPack) searchAggregate() tool.Tool {
 	return tool.NewBuilder("search_aggregate").
-		WithDescription("Run aggregation queries for analytics").
+		WithDescription("Count documents per value of a field, optionally restricted by a query and filters").
 		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index string `json:"index,omitempty"`
+				Field string `json:"field"`
+				Size  int    `json:"size,omitempty"`
+				queryInput
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Field == "" {
+				return tool.Result{}, errors.New("field is required")
+			}
+			if in.Size <= 0 {
+				in.Size = 10
+			}
+			index, err := p.indexName(in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			req := AggregateRequest{Field: in.Field, Size: min(in.Size, p.cfg.MaxResults)}
+			if in.Query != "" || len(in.Filters) > 0 {
+				q := in.toQuery()
+				req.Query = &q
+			}
+			agg, err := p.cfg.Backend.Aggregate(ctx, index, req)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("aggregate failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":   index,
+				"field":   in.Field,
+				"buckets": agg.Buckets,
+				"other":   agg.Other,
+				"missing": agg.Missing,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func searchIndices() tool.Tool {
+func (p *searchPack) searchIndices() tool.Tool {
 	return tool.NewBuilder("search_indices").
-		WithDescription("List available search indices").
+		WithDescription("List available search indices with document counts and fields").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, _ json.RawMessage) (tool.Result, error) {
+			indices, err := p.cfg.Backend.ListIndices(ctx)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			output, _ := json.Marshal(map[string]any{
+				"indices": indices,
+				"count":   len(indices),
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+// ============================================================================
+// Write Tools
+// ============================================================================
+
+func (p *searchPack) searchIndex() tool.Tool {
+	return tool.NewBuilder("search_index").
+		WithDescription("Index a document for searching, replacing any document with the same ID; the index is created if missing").
+		Idempotent().
+		WithRiskLevel(tool.RiskLow).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index    string         `json:"index,omitempty"`
+				ID       string         `json:"id,omitempty"`
+				Document map[string]any `json:"document"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Document) == 0 {
+				return tool.Result{}, errors.New("document is required")
+			}
+			if in.ID == "" {
+				in.ID = uuid.NewString()
+			}
+			index, err := p.indexName(in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			if err := p.write(ctx, index, []Document{{ID: in.ID, Fields: in.Document}}); err != nil {
+				return tool.Result{}, fmt.Errorf("index failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index": index,
+				"id":    in.ID,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *searchPack) searchBulkIndex() tool.Tool {
+	return tool.NewBuilder("search_bulk_index").
+		WithDescription("Bulk index multiple documents; the batch is validated before any is written").
+		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index     string `json:"index,omitempty"`
+				Documents []struct {
+					ID       string         `json:"id,omitempty"`
+					Document map[string]any `json:"document"`
+				} `json:"documents"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Documents) == 0 {
+				return tool.Result{}, errors.New("documents are required")
+			}
+			if len(in.Documents) > p.cfg.MaxBatch {
+				return tool.Result{}, fmt.Errorf("too many documents: %d (max %d)", len(in.Documents), p.cfg.MaxBatch)
+			}
+			index, err := p.indexName(in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			docs := make([]Document, len(in.Documents))
+			ids := make([]string, len(in.Documents))
+			for i, d := range in.Documents {
+				if len(d.Document) == 0 {
+					return tool.Result{}, fmt.Errorf("document %d: %w: no fields", i, ErrInvalidDocument)
+				}
+				if d.ID == "" {
+					d.ID = uuid.NewString()
+				}
+				docs[i] = Document{ID: d.ID, Fields: d.Document}
+				ids[i] = d.ID
+			}
+
+			if err := p.write(ctx, index, docs); err != nil {
+				return tool.Result{}, fmt.Errorf("bulk index failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":         index,
+				"indexed_count": len(docs),
+				"ids":           ids,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *searchPack) searchDelete() tool.Tool {
+	return tool.NewBuilder("search_delete").
+		WithDescription("Delete documents from the search index by ID").
+		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index string   `json:"index,omitempty"`
+				ID    string   `json:"id,omitempty"`
+				IDs   []string `json:"ids,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.ID != "" {
+				in.IDs = append(in.IDs, in.ID)
+			}
+			if len(in.IDs) == 0 {
+				return tool.Result{}, errors.New("id or ids is required")
+			}
+			if len(in.IDs) > p.cfg.MaxBatch {
+				return tool.Result{}, fmt.Errorf("too many ids: %d (max %d)", len(in.IDs), p.cfg.MaxBatch)
+			}
+			index, err := p.indexName(in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			deleted, err := p.cfg.Backend.Delete(ctx, index, in.IDs)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("delete failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":         index,
+				"deleted_count": deleted,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
@@ -1,87 +1,404 @@
 // Package secrets provides secret management tools for agent-go.
 //
 // This pack includes tools for secret management:
-//   - secrets_get: Retrieve a secret by key
+//   - secrets_get: Retrieve a handle to a secret by key
 //   - secrets_set: Store a secret
 //   - secrets_delete: Delete a secret
 //   - secrets_list: List available secrets (keys only)
 //   - secrets_rotate: Rotate a secret value
-//   - secrets_version: Get a specific version of a secret
+//   - secrets_version: List versions of a secret or get a handle to one
 //
-// Supports HashiCorp Vault, AWS Secrets Manager, GCP Secret Manager,
-// Azure Key Vault, and local encrypted storage.
+// The pack works over any secrets.Manager from
+// infrastructure/security/secrets; secrets.FileManager provides local
+// AES-GCM encrypted storage with versioning, and adapters for Vault or
+// cloud secret managers plug in behind the same interface.
+//
+// Secret values never appear in tool output. secrets_get returns an opaque
+// handle such as "secret:3f9a…". An engine configured with the same
+// secrets.HandleStore (api.WithSecretHandles) replaces handles in any tool's
+// input right before the tool runs, so values stay out of planner evidence
+// and the ledger.
 package secrets
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
+	secretstore "github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+)
+
+var (
+	// ErrKeyNotAllowed indicates the secret key is outside the configured policy.
+	ErrKeyNotAllowed = errors.New("secret key not allowed")
+
+	// ErrNotSupported indicates the manager cannot perform the operation,
+	// such as versioning on a manager that keeps only current values.
+	ErrNotSupported = errors.New("not supported by secrets manager")
 )
 
+// Config configures the secrets pack.
+type Config struct {
+	// Manager stores the secrets. Defaults to an in-memory manager.
+	Manager secretstore.Manager
+
+	// Handles issues the handles returned by the pack. Share it with the
+	// engine (api.WithSecretHandles) so tools receive the values. Defaults
+	// to a store over Manager.
+	Handles *secretstore.HandleStore
+
+	// AllowedKeys restricts accessible keys by path.Match pattern, such as
+	// "app/*". Empty allows all keys.
+	AllowedKeys []string
+
+	// DeniedKeys blocks keys by pattern. Takes precedence over AllowedKeys.
+	DeniedKeys []string
+
+	// GeneratedBytes is the entropy of values generated by secrets_rotate.
+	// Defaults to 32.
+	GeneratedBytes int
+}
+
 // Pack returns the secrets management tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.Manager == nil {
+		cfg.Manager = secretstore.NewMemoryManager()
+	}
+	if cfg.Handles == nil {
+		cfg.Handles = secretstore.NewHandleStore(cfg.Manager)
+	}
+	if cfg.GeneratedBytes <= 0 {
+		cfg.GeneratedBytes = 32
+	}
+	p := &secretsPack{cfg: cfg}
+
 	return pack.NewBuilder("secrets").
 		WithDescription("Secret management tools for secure credential storage").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			secretsGet(),
-			secretsSet(),
-			secretsDelete(),
-			secretsList(),
-			secretsRotate(),
-			secretsVersion(),
+			p.secretsGet(),
+			p.secretsSet(),
+			p.secretsDelete(),
+			p.secretsList(),
+			p.secretsRotate(),
+			p.secretsVersion(),
 		).
 		AllowInState(agent.StateExplore, "secrets_list").
 		AllowInState(agent.StateAct, "secrets_get", "secrets_set", "secrets_delete", "secrets_list", "secrets_rotate", "secrets_version").
 		Build()
 }
 
-func secretsGet() tool.Tool {
+type secretsPack struct {
+	cfg Config
+}
+
+func matchKey(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if pattern == key {
+			return true
+		}
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *secretsPack) checkKey(key string) error {
+	if key == "" {
+		return errors.New("key is required")
+	}
+	if matchKey(p.cfg.DeniedKeys, key) {
+		return fmt.Errorf("%w: %s is denied", ErrKeyNotAllowed, key)
+	}
+	if len(p.cfg.AllowedKeys) > 0 && !matchKey(p.cfg.AllowedKeys, key) {
+		return fmt.Errorf("%w: %s", ErrKeyNotAllowed, key)
+	}
+	return nil
+}
+
+func (p *secretsPack) versioned() (secretstore.VersionedManager, error) {
+	versioned, ok := p.cfg.Manager.(secretstore.VersionedManager)
+	if !ok {
+		return nil, fmt.Errorf("%w: manager does not keep versions", ErrNotSupported)
+	}
+	return versioned, nil
+}
+
+// value resolves a value given as a handle, so secrets can be copied
+// without their values passing through the planner.
+func (p *secretsPack) value(ctx context.Context, value string) (string, error) {
+	if secretstore.IsHandle(value) {
+		return p.cfg.Handles.Resolve(ctx, value)
+	}
+	return value, nil
+}
+
+// currentVersion reports the version number of a secret, or 0 when the
+// manager does not keep versions.
+func (p *secretsPack) currentVersion(ctx context.Context, key string) int {
+	versioned, ok := p.cfg.Manager.(secretstore.VersionedManager)
+	if !ok {
+		return 0
+	}
+	versions, err := versioned.Versions(ctx, key)
+	if err != nil || len(versions) == 0 {
+		return 0
+	}
+	return versions[len(versions)-1].Version
+}
+
+func (p *secretsPack) handleResult(h secretstore.Handle, extra map[string]any) tool.Result {
+	out := map[string]any{
+		"key":        h.Key,
+		"handle":     h.ID,
+		"expires_at": h.ExpiresAt,
+	}
+	if h.Version > 0 {
+		out["version"] = h.Version
+	}
+	for k, v := range extra {
+		out[k] = v
+	}
+	output, _ := json.Marshal(out)
+	return tool.Result{Output: output}
+}
+
+// ============================================================================
+// Read Tools
+// ============================================================================
+
+func (p *secretsPack) secretsGet() tool.Tool {
 	return tool.NewBuilder("secrets_get").
-		WithDescription("Retrieve a secret value by key").
+		WithDescription("Get an opaque handle to a secret; pass the handle to other tools, which resolve it without revealing the value").
 		ReadOnly().
 		WithRiskLevel(tool.RiskHigh).
 		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Key     string `json:"key"`
+				Version int    `json:"version,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkKey(in.Key); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Version > 0 {
+				if _, err := p.versioned(); err != nil {
+					return tool.Result{}, err
+				}
+			}
+
+			h, err := p.cfg.Handles.Issue(ctx, in.Key, in.Version)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("secret %s: %w", in.Key, err)
+			}
+			return p.handleResult(h, nil), nil
+		}).
 		MustBuild()
 }
 
-func secretsSet() tool.Tool {
+func (p *secretsPack) secretsList() tool.Tool {
+	return tool.NewBuilder("secrets_list").
+		WithDescription("List available secret keys (not values)").
+		ReadOnly().
+		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Prefix string `json:"prefix,omitempty"`
+			}
+			if len(input) > 0 {
+				if err := json.Unmarshal(input, &in); err != nil {
+					return tool.Result{}, err
+				}
+			}
+
+			all, err := p.cfg.Manager.List(ctx, in.Prefix)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("list failed: %w", err)
+			}
+			keys := make([]string, 0, len(all))
+			for _, key := range all {
+				if p.checkKey(key) == nil {
+					keys = append(keys, key)
+				}
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"keys":  keys,
+				"count": len(keys),
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *secretsPack) secretsVersion() tool.Tool {
+	return tool.NewBuilder("secrets_version").
+		WithDescription("List the versions of a secret, or get a handle to a specific version").
+		ReadOnly().
+		WithRiskLevel(tool.RiskHigh).
+		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Key     string `json:"key"`
+				Version int    `json:"version,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkKey(in.Key); err != nil {
+				return tool.Result{}, err
+			}
+			versioned, err := p.versioned()
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			if in.Version > 0 {
+				h, err := p.cfg.Handles.Issue(ctx, in.Key, in.Version)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("secret %s: %w", in.Key, err)
+				}
+				return p.handleResult(h, nil), nil
+			}
+
+			versions, err := versioned.Versions(ctx, in.Key)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("secret %s: %w", in.Key, err)
+			}
+			output, _ := json.Marshal(map[string]any{
+				"key":      in.Key,
+				"versions": versions,
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+// ============================================================================
+// Write Tools
+// ============================================================================
+
+func (p *secretsPack) secretsSet() tool.Tool {
 	return tool.NewBuilder("secrets_set").
-		WithDescription("Store or update a secret").
+		WithDescription("Store or update a secret; the value may be a handle to copy another secret").
 		Idempotent().
 		WithRiskLevel(tool.RiskHigh).
 		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkKey(in.Key); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Value == "" {
+				return tool.Result{}, errors.New("value is required")
+			}
+			value, err := p.value(ctx, in.Value)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			if err := p.cfg.Manager.Set(ctx, in.Key, value); err != nil {
+				return tool.Result{}, fmt.Errorf("set failed: %w", err)
+			}
+
+			out := map[string]any{"key": in.Key, "stored": true}
+			if v := p.currentVersion(ctx, in.Key); v > 0 {
+				out["version"] = v
+			}
+			output, _ := json.Marshal(out)
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func secretsDelete() tool.Tool {
+func (p *secretsPack) secretsDelete() tool.Tool {
 	return tool.NewBuilder("secrets_delete").
-		WithDescription("Delete a secret").
+		WithDescription("Delete a secret and all of its versions").
 		Destructive().
-		MustBuild()
-}
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Key string `json:"key"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkKey(in.Key); err != nil {
+				return tool.Result{}, err
+			}
 
-func secretsList() tool.Tool {
-	return tool.NewBuilder("secrets_list").
-		WithDescription("List available secret keys (not values)").
-		ReadOnly().
-		Cacheable().
+			if err := p.cfg.Manager.Delete(ctx, in.Key); err != nil {
+				return tool.Result{}, fmt.Errorf("delete failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"key":     in.Key,
+				"deleted": true,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func secretsRotate() tool.Tool {
+func (p *secretsPack) secretsRotate() tool.Tool {
 	return tool.NewBuilder("secrets_rotate").
-		WithDescription("Rotate a secret to a new value").
+		WithDescription("Rotate an existing secret to a new value, generated randomly unless a value or handle is given; returns a handle to the new value").
 		WithRiskLevel(tool.RiskHigh).
 		RequiresApproval().
-		MustBuild()
-}
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Key   string `json:"key"`
+				Value string `json:"value,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.checkKey(in.Key); err != nil {
+				return tool.Result{}, err
+			}
+			exists, err := p.cfg.Manager.Exists(ctx, in.Key)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			if !exists {
+				return tool.Result{}, fmt.Errorf("secret %s: %w", in.Key, secretstore.ErrSecretNotFound)
+			}
 
-func secretsVersion() tool.Tool {
-	return tool.NewBuilder("secrets_version").
-		WithDescription("Get a specific version of a secret").
-		ReadOnly().
-		WithRiskLevel(tool.RiskHigh).
-		RequiresApproval().
+			value, generated := in.Value, in.Value == ""
+			if generated {
+				buf := make([]byte, p.cfg.GeneratedBytes)
+				if _, err := rand.Read(buf); err != nil {
+					return tool.Result{}, err
+				}
+				value = base64.RawURLEncoding.EncodeToString(buf)
+			} else if value, err = p.value(ctx, value); err != nil {
+				return tool.Result{}, err
+			}
+
+			if err := p.cfg.Manager.Set(ctx, in.Key, value); err != nil {
+				return tool.Result{}, fmt.Errorf("rotate failed: %w", err)
+			}
+			h, err := p.cfg.Handles.Issue(ctx, in.Key, p.currentVersion(ctx, in.Key))
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("secret %s: %w", in.Key, err)
+			}
+			return p.handleResult(h, map[string]any{"rotated": true, "generated": generated}), nil
+		}).
 		MustBuild()
 }
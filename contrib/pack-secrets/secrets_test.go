@@ -0,0 +1,139 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	secretstore "github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+func TestGetIssuesResolvableHandles(t *testing.T) {
+	t.Parallel()
+
+	manager := secretstore.NewMemoryManager(secretstore.WithInitialSecrets(map[string]string{"app/db": "hunter2"}))
+	handles := secretstore.NewHandleStore(manager)
+	p := Pack(Config{Manager: manager, Handles: handles})
+
+	out, err := call(p, "secrets_get", map[string]any{"key": "app/db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := json.Marshal(out)
+	if strings.Contains(string(raw), "hunter2") {
+		t.Fatalf("output reveals the value: %s", raw)
+	}
+	handle, _ := out["handle"].(string)
+	if !secretstore.IsHandle(handle) || out["key"] != "app/db" {
+		t.Fatalf("unexpected output: %v", out)
+	}
+	value, err := handles.Resolve(context.Background(), handle)
+	if err != nil || value != "hunter2" {
+		t.Errorf("Resolve() = %q, %v; want the secret value", value, err)
+	}
+
+	// Handles copy secrets without the value passing through the planner.
+	if _, err := call(p, "secrets_set", map[string]any{"key": "app/db-copy", "value": handle}); err != nil {
+		t.Fatal(err)
+	}
+	if copied, _ := manager.Get(context.Background(), "app/db-copy"); copied != "hunter2" {
+		t.Errorf("copied value = %q, want the secret value", copied)
+	}
+
+	if _, err := call(p, "secrets_get", map[string]any{"key": "app/missing"}); !errors.Is(err, secretstore.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+	if _, err := call(p, "secrets_set", map[string]any{"key": "app/x", "value": "secret:00000000000000000000000000000000"}); !errors.Is(err, secretstore.ErrInvalidHandle) {
+		t.Errorf("expected ErrInvalidHandle, got %v", err)
+	}
+}
+
+func TestKeyPolicy(t *testing.T) {
+	t.Parallel()
+
+	manager := secretstore.NewMemoryManager(secretstore.WithInitialSecrets(map[string]string{
+		"app/db":     "a",
+		"app/root":   "b",
+		"infra/keys": "c",
+	}))
+	p := Pack(Config{Manager: manager, AllowedKeys: []string{"app/*"}, DeniedKeys: []string{"app/root"}})
+
+	for _, key := range []string{"app/root", "infra/keys"} {
+		for _, name := range []string{"secrets_get", "secrets_delete", "secrets_rotate"} {
+			if _, err := call(p, name, map[string]any{"key": key}); !errors.Is(err, ErrKeyNotAllowed) {
+				t.Errorf("%s %s: expected ErrKeyNotAllowed, got %v", name, key, err)
+			}
+		}
+		if _, err := call(p, "secrets_set", map[string]any{"key": key, "value": "x"}); !errors.Is(err, ErrKeyNotAllowed) {
+			t.Errorf("secrets_set %s: expected ErrKeyNotAllowed, got %v", key, err)
+		}
+	}
+	if v, _ := manager.Get(context.Background(), "app/root"); v != "b" {
+		t.Errorf("denied secret was changed: %q", v)
+	}
+
+	out, err := call(p, "secrets_list", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys := out["keys"].([]any); len(keys) != 1 || keys[0] != "app/db" {
+		t.Errorf("expected only the allowed keys to be listed, got %v", keys)
+	}
+}
+
+func TestRotateAndVersions(t *testing.T) {
+	t.Parallel()
+
+	manager, err := secretstore.NewFileManager(filepath.Join(t.TempDir(), "secrets.enc"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handles := secretstore.NewHandleStore(manager)
+	p := Pack(Config{Manager: manager, Handles: handles})
+
+	if _, err := call(p, "secrets_rotate", map[string]any{"key": "app/token"}); !errors.Is(err, secretstore.ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound for a missing secret, got %v", err)
+	}
+	if _, err := call(p, "secrets_set", map[string]any{"key": "app/token", "value": "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := call(p, "secrets_rotate", map[string]any{"key": "app/token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["generated"] != true || out["version"] != 2.0 {
+		t.Errorf("unexpected rotation: %v", out)
+	}
+	rotated, _ := handles.Resolve(context.Background(), out["handle"].(string))
+	if rotated == "" || rotated == "v1" {
+		t.Errorf("expected a generated value, got %q", rotated)
+	}
+
+	out, err = call(p, "secrets_version", map[string]any{"key": "app/token", "version": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old, _ := handles.Resolve(context.Background(), out["handle"].(string)); old != "v1" {
+		t.Errorf("version 1 handle resolved to %q, want v1", old)
+	}
+
+	if _, err := call(Pack(Config{}), "secrets_version", map[string]any{"key": "app/token"}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("expected ErrNotSupported without versioning, got %v", err)
+	}
+}
@@ -2,6 +2,9 @@ module github.com/felixgeelhaar/agent-go/contrib/pack-shell
 
 go 1.25.0
 
-require github.com/felixgeelhaar/agent-go v0.0.0
+require (
+	github.com/felixgeelhaar/agent-go v0.0.0
+	golang.org/x/sys v0.40.0
+)
 
 replace github.com/felixgeelhaar/agent-go => ../..
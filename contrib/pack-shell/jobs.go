@@ -0,0 +1,333 @@
+package shell
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// Background job states.
+const (
+	jobRunning  = "running"
+	jobExited   = "exited"
+	jobKilled   = "killed"
+	jobTimedOut = "timed_out"
+)
+
+// job is a background process tracked by ID.
+type job struct {
+	id     string
+	proc   *process
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	state  string
+	result map[string]any
+	ended  time.Time
+}
+
+func (j *job) status() map[string]any {
+	j.mu.Lock()
+	state, result, ended := j.state, j.result, j.ended
+	j.mu.Unlock()
+
+	status := map[string]any{
+		"job_id":     j.id,
+		"command":    j.proc.argv,
+		"state":      state,
+		"started_at": j.proc.started.Format(time.RFC3339),
+	}
+	if state == jobRunning {
+		stdout, stdoutBytes := j.proc.stdout.snapshot()
+		stderr, stderrBytes := j.proc.stderr.snapshot()
+		status["stdout"] = stdout
+		status["stdout_bytes"] = stdoutBytes
+		status["stderr"] = stderr
+		status["stderr_bytes"] = stderrBytes
+		status["running_ms"] = time.Since(j.proc.started).Milliseconds()
+		return status
+	}
+	for k, v := range result {
+		if k != "command" {
+			status[k] = v
+		}
+	}
+	status["ended_at"] = ended.Format(time.RFC3339)
+	return status
+}
+
+// jobManager tracks background jobs. Finished jobs are retained until the
+// number of tracked jobs exceeds four times the running limit, at which point
+// the oldest finished jobs are forgotten.
+type jobManager struct {
+	mu       sync.Mutex
+	jobs     map[string]*job
+	reserved int // slots taken by jobs that are starting
+	maxJobs  int
+}
+
+func newJobManager(maxJobs int) *jobManager {
+	return &jobManager{jobs: make(map[string]*job), maxJobs: maxJobs}
+}
+
+func (m *jobManager) get(id string) (*job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return j, nil
+}
+
+// reserve takes a running-job slot and prunes old finished jobs. The slot
+// is held until it is handed to add or returned with release, so concurrent
+// callers cannot exceed the limit between the check and the job starting.
+func (m *jobManager) reserve() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	running := 0
+	var finished []*job
+	for _, j := range m.jobs {
+		j.mu.Lock()
+		if j.state == jobRunning {
+			running++
+		} else {
+			finished = append(finished, j)
+		}
+		j.mu.Unlock()
+	}
+	if running+m.reserved >= m.maxJobs {
+		return fmt.Errorf("%w: limit is %d", ErrTooManyJobs, m.maxJobs)
+	}
+	m.reserved++
+
+	excess := len(m.jobs) - 4*m.maxJobs + 1
+	if excess > 0 {
+		sort.Slice(finished, func(a, b int) bool {
+			return finished[a].ended.Before(finished[b].ended)
+		})
+		for i := 0; i < excess && i < len(finished); i++ {
+			delete(m.jobs, finished[i].id)
+		}
+	}
+	return nil
+}
+
+// add registers a started job in a slot taken by reserve.
+func (m *jobManager) add(j *job) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reserved--
+	m.jobs[j.id] = j
+}
+
+// release returns a slot taken by reserve for a job that failed to start.
+func (m *jobManager) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reserved--
+}
+
+func (m *jobManager) list() []map[string]any {
+	m.mu.Lock()
+	jobs := make([]*job, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		jobs = append(jobs, j)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].proc.started.Before(jobs[b].proc.started)
+	})
+	summaries := make([]map[string]any, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		summaries = append(summaries, map[string]any{
+			"job_id":  j.id,
+			"command": j.proc.argv,
+			"state":   j.state,
+		})
+		j.mu.Unlock()
+	}
+	return summaries
+}
+
+func generateJobID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "job-" + hex.EncodeToString(b)
+}
+
+func (p *shellPack) shellExecBackground() tool.Tool {
+	return tool.NewBuilder("shell_exec_background").
+		WithDescription("Execute a command in the background and return a job ID").
+		WithRiskLevel(tool.RiskHigh).
+		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var params execParams
+			if err := json.Unmarshal(input, &params); err != nil {
+				return tool.Result{}, err
+			}
+			if err := p.jobs.reserve(); err != nil {
+				return tool.Result{}, err
+			}
+
+			// Background jobs outlive the tool call, so detach from its
+			// cancellation while keeping its values.
+			timeout := p.cfg.MaxTimeout
+			if params.TimeoutSeconds > 0 {
+				timeout = p.timeout(params.TimeoutSeconds)
+			}
+			jobCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+
+			proc, err := p.prepare(jobCtx, params.argv(), params)
+			if err != nil {
+				cancel()
+				p.jobs.release()
+				return tool.Result{}, err
+			}
+			if err := proc.start(); err != nil {
+				cancel()
+				p.jobs.release()
+				return tool.Result{}, err
+			}
+
+			j := &job{
+				id:     generateJobID(),
+				proc:   proc,
+				cancel: cancel,
+				done:   make(chan struct{}),
+				state:  jobRunning,
+			}
+			p.jobs.add(j)
+
+			go func() {
+				defer close(j.done)
+				defer cancel()
+				result := proc.wait(jobCtx)
+
+				j.mu.Lock()
+				defer j.mu.Unlock()
+				j.result = result
+				j.ended = time.Now()
+				switch {
+				case j.state == jobKilled:
+				case errors.Is(jobCtx.Err(), context.DeadlineExceeded):
+					j.state = jobTimedOut
+				default:
+					j.state = jobExited
+				}
+			}()
+
+			output, _ := json.Marshal(map[string]any{
+				"job_id":          j.id,
+				"command":         proc.argv,
+				"state":           jobRunning,
+				"timeout_seconds": int(timeout.Seconds()),
+			})
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
+
+func (p *shellPack) jobStatus() tool.Tool {
+	return tool.NewBuilder("shell_job_status").
+		WithDescription("Get the state and output of a background job, or list all jobs when no ID is given").
+		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var params struct {
+				JobID       string `json:"job_id,omitempty"`
+				WaitSeconds int    `json:"wait_seconds,omitempty"`
+			}
+			if err := json.Unmarshal(input, &params); err != nil {
+				return tool.Result{}, err
+			}
+
+			if params.JobID == "" {
+				jobs := p.jobs.list()
+				output, _ := json.Marshal(map[string]any{
+					"jobs":  jobs,
+					"count": len(jobs),
+				})
+				return tool.Result{Output: output}, nil
+			}
+
+			j, err := p.jobs.get(params.JobID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			// Optionally wait for completion, bounded by the timeout policy.
+			if params.WaitSeconds > 0 {
+				timer := time.NewTimer(p.timeout(params.WaitSeconds))
+				select {
+				case <-j.done:
+				case <-timer.C:
+				case <-ctx.Done():
+				}
+				timer.Stop()
+			}
+
+			result := tool.Result{}
+			result.Output, _ = json.Marshal(j.status())
+			select {
+			case <-j.done:
+				result.Artifacts = j.proc.artifacts()
+			default:
+			}
+			return result, nil
+		}).
+		MustBuild()
+}
+
+func (p *shellPack) jobKill() tool.Tool {
+	return tool.NewBuilder("shell_job_kill").
+		WithDescription("Terminate a running background job and its child processes").
+		WithRiskLevel(tool.RiskMedium).
+		Idempotent().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var params struct {
+				JobID string `json:"job_id"`
+			}
+			if err := json.Unmarshal(input, &params); err != nil {
+				return tool.Result{}, err
+			}
+
+			j, err := p.jobs.get(params.JobID)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			j.mu.Lock()
+			running := j.state == jobRunning
+			if running {
+				j.state = jobKilled
+			}
+			j.mu.Unlock()
+
+			if running {
+				killProcess(j.proc.cmd)
+				j.cancel()
+				select {
+				case <-j.done:
+				case <-ctx.Done():
+					return tool.Result{}, ctx.Err()
+				}
+			}
+
+			output, _ := json.Marshal(j.status())
+			return tool.Result{Output: output}, nil
+		}).
+		MustBuild()
+}
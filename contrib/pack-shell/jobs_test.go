@@ -0,0 +1,135 @@
+package shell
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{AllowAll: true})
+
+	out, err := call(p, "shell_exec_background", map[string]any{"command": "sh", "args": []string{"-c", "echo done"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := out["job_id"].(string)
+	if out["state"] != jobRunning {
+		t.Errorf("state = %v, want %s", out["state"], jobRunning)
+	}
+
+	status, err := call(p, "shell_job_status", map[string]any{"job_id": id, "wait_seconds": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status["state"] != jobExited || status["stdout"] != "done\n" || status["exit_code"] != float64(0) {
+		t.Errorf("unexpected status: %v", status)
+	}
+
+	out, err = call(p, "shell_exec_background", map[string]any{"command": "sleep", "args": []string{"30"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sleeper := out["job_id"].(string)
+
+	list, err := call(p, "shell_job_status", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list["count"] != float64(2) {
+		t.Errorf("count = %v, want 2", list["count"])
+	}
+
+	killed, err := call(p, "shell_job_kill", map[string]any{"job_id": sleeper})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if killed["state"] != jobKilled {
+		t.Errorf("state = %v, want %s", killed["state"], jobKilled)
+	}
+
+	// Killing a finished job is a no-op.
+	if again, err := call(p, "shell_job_kill", map[string]any{"job_id": sleeper}); err != nil || again["state"] != jobKilled {
+		t.Errorf("second kill: %v, %v", again, err)
+	}
+
+	if _, err := call(p, "shell_job_status", map[string]any{"job_id": "job-unknown"}); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{AllowAll: true})
+	out, err := call(p, "shell_exec_background", map[string]any{
+		"command":         "sleep",
+		"args":            []string{"30"},
+		"timeout_seconds": 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := call(p, "shell_job_status", map[string]any{"job_id": out["job_id"], "wait_seconds": 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status["state"] != jobTimedOut {
+		t.Errorf("state = %v, want %s", status["state"], jobTimedOut)
+	}
+}
+
+func TestJobLimit(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{AllowAll: true, MaxJobs: 1})
+	out, err := call(p, "shell_exec_background", map[string]any{"command": "sleep", "args": []string{"30"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call(p, "shell_exec_background", map[string]any{"command": "sleep", "args": []string{"30"}}); !errors.Is(err, ErrTooManyJobs) {
+		t.Errorf("expected ErrTooManyJobs, got %v", err)
+	}
+
+	// A job that fails to start gives its slot back.
+	if _, err := call(p, "shell_job_kill", map[string]any{"job_id": out["job_id"]}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call(p, "shell_exec_background", map[string]any{"command": "/nonexistent/cmd"}); err == nil {
+		t.Error("expected start failure")
+	}
+	out, err = call(p, "shell_exec_background", map[string]any{"command": "sleep", "args": []string{"30"}})
+	if err != nil {
+		t.Fatalf("slot was not released: %v", err)
+	}
+	_, _ = call(p, "shell_job_kill", map[string]any{"job_id": out["job_id"]})
+}
+
+func TestJobManagerReserveConcurrent(t *testing.T) {
+	t.Parallel()
+
+	m := newJobManager(3)
+	var granted atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if m.reserve() == nil {
+				granted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := granted.Load(); got != 3 {
+		t.Errorf("granted %d slots, want 3", got)
+	}
+
+	m.release()
+	if err := m.reserve(); err != nil {
+		t.Errorf("released slot was not reusable: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// configureProcess sets up process-group handling, rlimits, namespaces and
+// the per-command cgroup before the command starts. The returned cleanup
+// function must be called once the process has exited.
+func configureProcess(cmd *exec.Cmd, l Limits) (func(), error) {
+	if err := wrapRlimits(cmd, l); err != nil {
+		return nil, err
+	}
+
+	attr := &syscall.SysProcAttr{
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGKILL,
+	}
+	cmd.SysProcAttr = attr
+	cmd.Cancel = func() error {
+		killProcess(cmd)
+		return nil
+	}
+
+	if l.Isolate || l.NoNetwork {
+		attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+		if l.NoNetwork {
+			attr.Cloneflags |= syscall.CLONE_NEWNET
+		}
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+		attr.GidMappingsEnableSetgroups = false
+	}
+
+	if l.CgroupParent == "" {
+		if l.Processes > 0 {
+			return nil, errors.New("process limit requires a cgroup parent")
+		}
+		return func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp(l.CgroupParent, "shell-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	removeCgroup := func() {
+		// Kill stragglers (cgroup.kill requires Linux 5.14) and retry the
+		// removal briefly while the kernel reaps them.
+		_ = os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0o600)
+		for i := 0; i < 10; i++ {
+			if err := os.Remove(dir); err == nil || os.IsNotExist(err) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	controls := map[string]uint64{
+		"memory.max": l.MemoryBytes,
+		"pids.max":   l.Processes,
+	}
+	for file, value := range controls {
+		if value == 0 {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(strconv.FormatUint(value, 10)), 0o600); err != nil {
+			removeCgroup()
+			return nil, fmt.Errorf("failed to set %s: %w", file, err)
+		}
+	}
+
+	fd, err := unix.Open(dir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		removeCgroup()
+		return nil, fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	attr.UseCgroupFD = true
+	attr.CgroupFD = fd
+
+	return func() {
+		_ = syscall.Close(fd)
+		removeCgroup()
+	}, nil
+}
+
+// wrapRlimits makes the command exec through prlimit(1), which installs the
+// rlimits in its own process and then execs the command. The limits are
+// therefore in place before the command runs its first instruction; setting
+// them from the parent after Start would leave a window in which the child
+// runs, and forks, unconstrained.
+func wrapRlimits(cmd *exec.Cmd, l Limits) error {
+	cpu := uint64(l.CPUTime.Round(time.Second) / time.Second)
+	if l.CPUTime > 0 && cpu == 0 {
+		cpu = 1
+	}
+	limits := []struct {
+		flag  string
+		value uint64
+	}{
+		{"--cpu", cpu},
+		{"--as", l.MemoryBytes},
+		{"--fsize", l.FileSizeBytes},
+		{"--nofile", l.OpenFiles},
+	}
+
+	var opts []string
+	for _, limit := range limits {
+		if limit.value > 0 {
+			v := strconv.FormatUint(limit.value, 10)
+			opts = append(opts, limit.flag+"="+v+":"+v)
+		}
+	}
+	if len(opts) == 0 || cmd.Err != nil {
+		// Nothing to apply, or Start will report the lookup error.
+		return nil
+	}
+
+	prlimit, err := exec.LookPath("prlimit")
+	if err != nil {
+		return fmt.Errorf("%w: rlimits require the prlimit utility: %v", ErrLimitsUnsupported, err)
+	}
+	args := append([]string{prlimit}, opts...)
+	args = append(args, "--", cmd.Path)
+	cmd.Args = append(args, cmd.Args[1:]...)
+	cmd.Path = prlimit
+	return nil
+}
+
+// killProcess kills the command's whole process group.
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		_ = cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package shell
+
+import (
+	"os/exec"
+)
+
+// configureProcess rejects resource limits, which are only enforced on Linux.
+func configureProcess(cmd *exec.Cmd, l Limits) (func(), error) {
+	if !l.IsZero() {
+		return nil, ErrLimitsUnsupported
+	}
+	return func() {}, nil
+}
+
+// killProcess kills the command's process.
+func killProcess(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
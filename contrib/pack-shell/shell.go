@@ -1,54 +1,705 @@
 // Package shell provides shell command execution tools for agent-go.
 //
 // This pack includes tools for executing shell commands:
-//   - shell_exec: Execute a shell command and return output
+//   - shell_exec: Execute a command and return output
 //   - shell_exec_background: Execute a command in the background
 //   - shell_script: Execute a shell script
+//   - shell_job_status: Inspect a background job
+//   - shell_job_kill: Terminate a background job
 //
-// Commands can be allowlisted/denylisted for security.
-// Supports timeout configuration and working directory specification.
+// Commands are executed directly (never through a shell) and checked against
+// argv-level allow and deny rules before they start. Callers may only set
+// environment variables named in Config.AllowedEnv. Working directories are
+// confined to a root directory, every command runs under a timeout, and
+// output is truncated inline with the full stream spilled into an artifact
+// store when one is configured. On Linux, rlimits, cgroup v2 limits and
+// namespaces can be applied to every child process.
 package shell
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/artifact"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Errors returned by the shell tools.
+var (
+	// ErrCommandDenied indicates the argv matched a deny rule or no allow rule.
+	ErrCommandDenied = errors.New("command denied by policy")
+
+	// ErrWorkDirOutsideRoot indicates the requested working directory escapes the root.
+	ErrWorkDirOutsideRoot = errors.New("working directory outside root")
+
+	// ErrScriptsDisabled indicates shell_script was called without AllowScripts.
+	ErrScriptsDisabled = errors.New("shell scripts are disabled")
+
+	// ErrEnvDenied indicates the caller set an environment variable that is
+	// not in Config.AllowedEnv.
+	ErrEnvDenied = errors.New("environment variable not allowed")
+
+	// ErrJobNotFound indicates an unknown background job ID.
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrTooManyJobs indicates the background job limit was reached.
+	ErrTooManyJobs = errors.New("too many running jobs")
+
+	// ErrLimitsUnsupported indicates resource limits are configured on a
+	// platform that cannot enforce them.
+	ErrLimitsUnsupported = errors.New("resource limits not supported on this platform")
+)
+
+// Config configures the shell pack.
+type Config struct {
+	// Allow lists argv patterns that may run. Each pattern is a
+	// whitespace-separated list of glob tokens matched against argv
+	// position by position; "**" matches any number of arguments.
+	// For example "git status", "git log **" or "ls -l *".
+	// An empty list denies every command unless AllowAll is set.
+	Allow []string
+
+	// AllowAll lets every command run that is not denied, ignoring Allow.
+	// Only use it when Deny and the sandbox limits are the whole policy.
+	AllowAll bool
+
+	// Deny lists argv patterns that are always rejected. Deny rules take
+	// precedence over allow rules and also match commands given by path.
+	Deny []string
+
+	// Root confines working directories. Defaults to the current directory.
+	Root string
+
+	// Env is the base environment for commands. When nil, only PATH, HOME,
+	// LANG and TMPDIR are inherited from the current process.
+	Env []string
+
+	// AllowedEnv lists the environment variable names callers may set through
+	// the env input. Any other name is rejected with ErrEnvDenied, so by
+	// default callers cannot change the environment at all.
+	AllowedEnv []string
+
+	// DefaultTimeout applies when the caller does not specify a timeout.
+	// Defaults to 30 seconds.
+	DefaultTimeout time.Duration
+
+	// MaxTimeout caps caller-supplied timeouts. Defaults to 10 minutes.
+	MaxTimeout time.Duration
+
+	// MaxOutputBytes is the number of bytes returned inline per stream.
+	// Defaults to 64 KiB.
+	MaxOutputBytes int
+
+	// Artifacts receives the complete output of streams that exceed
+	// MaxOutputBytes. When nil, excess output is discarded.
+	Artifacts artifact.Store
+
+	// AllowScripts enables shell_script. Scripts bypass argv rules for the
+	// commands they run, so they are disabled by default.
+	AllowScripts bool
+
+	// Interpreter is the argv prefix used to run scripts; the script is
+	// passed on stdin. Defaults to ["/bin/sh", "-s"]. The interpreter argv
+	// itself must pass the allow and deny rules.
+	Interpreter []string
+
+	// MaxJobs limits concurrently running background jobs. Defaults to 8.
+	MaxJobs int
+
+	// Limits are resource limits applied to every child process.
+	Limits Limits
+}
+
+// Limits configures per-process resource limits. Zero values disable the
+// corresponding limit. Limits are only enforced on Linux; configuring any
+// of them elsewhere causes commands to fail with ErrLimitsUnsupported. The
+// rlimits are installed by exec'ing the command through prlimit(1), which
+// must be on the PATH of the current process.
+type Limits struct {
+	// CPUTime limits consumed CPU time (RLIMIT_CPU).
+	CPUTime time.Duration
+
+	// MemoryBytes limits the address space (RLIMIT_AS) and, when a cgroup
+	// is used, memory.max.
+	MemoryBytes uint64
+
+	// FileSizeBytes limits the size of files the process may write (RLIMIT_FSIZE).
+	FileSizeBytes uint64
+
+	// OpenFiles limits open file descriptors (RLIMIT_NOFILE).
+	OpenFiles uint64
+
+	// Processes limits the number of processes via pids.max in the cgroup.
+	// Requires CgroupParent.
+	Processes uint64
+
+	// CgroupParent is a writable cgroup v2 directory. When set, each command
+	// runs in its own child cgroup which is removed after it exits.
+	CgroupParent string
+
+	// Isolate runs commands in new user, PID, mount, IPC and UTS namespaces.
+	Isolate bool
+
+	// NoNetwork additionally runs commands in a new, empty network namespace.
+	NoNetwork bool
+}
+
+// IsZero reports whether no limits are configured.
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
 // Pack returns the shell tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	p := newShellPack(cfg)
+
 	return pack.NewBuilder("shell").
 		WithDescription("Shell command execution tools").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			shellExec(),
-			shellExecBackground(),
-			shellScript(),
+			p.shellExec(),
+			p.shellExecBackground(),
+			p.shellScript(),
+			p.jobStatus(),
+			p.jobKill(),
 		).
-		AllowInState(agent.StateAct, "shell_exec", "shell_exec_background", "shell_script").
+		AllowInState(agent.StateExplore, "shell_job_status").
+		AllowInState(agent.StateAct, "shell_exec", "shell_exec_background", "shell_script", "shell_job_status", "shell_job_kill").
+		AllowInState(agent.StateValidate, "shell_job_status").
 		Build()
 }
 
-func shellExec() tool.Tool {
-	return tool.NewBuilder("shell_exec").
-		WithDescription("Execute a shell command and return stdout/stderr").
-		WithRiskLevel(tool.RiskHigh).
-		RequiresApproval().
-		MustBuild()
+type shellPack struct {
+	cfg        Config
+	allow      []rule
+	deny       []rule
+	allowedEnv map[string]bool
+	jobs       *jobManager
+}
+
+func newShellPack(cfg Config) *shellPack {
+	if cfg.Root == "" {
+		cfg.Root, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(cfg.Root); err == nil {
+		cfg.Root = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(cfg.Root); err == nil {
+		cfg.Root = resolved
+	}
+	if cfg.Env == nil {
+		for _, key := range []string{"PATH", "HOME", "LANG", "TMPDIR"} {
+			if v, ok := os.LookupEnv(key); ok {
+				cfg.Env = append(cfg.Env, key+"="+v)
+			}
+		}
+	}
+	if cfg.DefaultTimeout <= 0 {
+		cfg.DefaultTimeout = 30 * time.Second
+	}
+	if cfg.MaxTimeout <= 0 {
+		cfg.MaxTimeout = 10 * time.Minute
+	}
+	if cfg.DefaultTimeout > cfg.MaxTimeout {
+		cfg.DefaultTimeout = cfg.MaxTimeout
+	}
+	if cfg.MaxOutputBytes <= 0 {
+		cfg.MaxOutputBytes = 64 * 1024
+	}
+	if len(cfg.Interpreter) == 0 {
+		cfg.Interpreter = []string{"/bin/sh", "-s"}
+	}
+	if cfg.MaxJobs <= 0 {
+		cfg.MaxJobs = 8
+	}
+
+	p := &shellPack{
+		cfg:        cfg,
+		allowedEnv: make(map[string]bool, len(cfg.AllowedEnv)),
+		jobs:       newJobManager(cfg.MaxJobs),
+	}
+	for _, key := range cfg.AllowedEnv {
+		p.allowedEnv[key] = true
+	}
+	for _, s := range cfg.Allow {
+		p.allow = append(p.allow, parseRule(s))
+	}
+	for _, s := range cfg.Deny {
+		p.deny = append(p.deny, parseRule(s))
+	}
+	return p
+}
+
+// ============================================================================
+// Policy
+// ============================================================================
+
+// rule is a tokenized argv pattern.
+type rule []string
+
+func parseRule(s string) rule {
+	return rule(strings.Fields(s))
+}
+
+// match reports whether argv matches the rule. When byBase is true, the
+// command token is also compared against the base name of argv[0] so that
+// deny rules catch commands invoked by path.
+func (r rule) match(argv []string, byBase bool) bool {
+	if len(r) == 0 || len(argv) == 0 {
+		return false
+	}
+	if !matchCommand(r[0], argv[0], byBase) {
+		return false
+	}
+	return matchTokens(r[1:], argv[1:])
+}
+
+func matchCommand(pattern, command string, byBase bool) bool {
+	if ok, _ := filepath.Match(pattern, command); ok {
+		return true
+	}
+	if byBase && strings.ContainsRune(command, filepath.Separator) {
+		ok, _ := filepath.Match(pattern, filepath.Base(command))
+		return ok
+	}
+	return false
+}
+
+func matchTokens(patterns, args []string) bool {
+	if len(patterns) == 0 {
+		return len(args) == 0
+	}
+	if patterns[0] == "**" {
+		for i := 0; i <= len(args); i++ {
+			if matchTokens(patterns[1:], args[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(args) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(patterns[0], args[0]); !ok {
+		return false
+	}
+	return matchTokens(patterns[1:], args[1:])
+}
+
+// checkArgv enforces the allow and deny rules.
+func (p *shellPack) checkArgv(argv []string) error {
+	if len(argv) == 0 || argv[0] == "" {
+		return errors.New("command is required")
+	}
+	for _, r := range p.deny {
+		if r.match(argv, true) {
+			return fmt.Errorf("%w: %q matches deny rule %q", ErrCommandDenied, strings.Join(argv, " "), strings.Join(r, " "))
+		}
+	}
+	if p.cfg.AllowAll {
+		return nil
+	}
+	for _, r := range p.allow {
+		if r.match(argv, false) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q matches no allow rule", ErrCommandDenied, strings.Join(argv, " "))
+}
+
+// checkEnv rejects caller-supplied variables that are not explicitly allowed.
+func (p *shellPack) checkEnv(env map[string]string) error {
+	for key := range env {
+		if !p.allowedEnv[key] {
+			return fmt.Errorf("%w: %q", ErrEnvDenied, key)
+		}
+	}
+	return nil
 }
 
-func shellExecBackground() tool.Tool {
-	return tool.NewBuilder("shell_exec_background").
-		WithDescription("Execute a shell command in the background").
+// resolveWorkDir resolves dir relative to the root and rejects escapes,
+// including escapes through symlinks.
+func (p *shellPack) resolveWorkDir(dir string) (string, error) {
+	if dir == "" {
+		return p.cfg.Root, nil
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(p.cfg.Root, dir)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(dir))
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory: %w", err)
+	}
+	rel, err := filepath.Rel(p.cfg.Root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrWorkDirOutsideRoot, dir)
+	}
+	return resolved, nil
+}
+
+func (p *shellPack) timeout(seconds int) time.Duration {
+	if seconds <= 0 {
+		return p.cfg.DefaultTimeout
+	}
+	d := time.Duration(seconds) * time.Second
+	if d > p.cfg.MaxTimeout {
+		return p.cfg.MaxTimeout
+	}
+	return d
+}
+
+// ============================================================================
+// Execution
+// ============================================================================
+
+// execParams is the common input of the execution tools.
+type execParams struct {
+	Command        string            `json:"command"`
+	Args           []string          `json:"args,omitempty"`
+	Dir            string            `json:"dir,omitempty"`
+	Env            map[string]string `json:"env,omitempty"`
+	Stdin          string            `json:"stdin,omitempty"`
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+}
+
+func (e execParams) argv() []string {
+	return append([]string{e.Command}, e.Args...)
+}
+
+// process is a prepared, policy-checked command with captured output.
+type process struct {
+	argv    []string
+	cmd     *exec.Cmd
+	stdout  *capture
+	stderr  *capture
+	cleanup func()
+	started time.Time
+}
+
+// prepare validates the request and builds a command bound to ctx.
+func (p *shellPack) prepare(ctx context.Context, argv []string, params execParams) (*process, error) {
+	if err := p.checkArgv(argv); err != nil {
+		return nil, err
+	}
+	if err := p.checkEnv(params.Env); err != nil {
+		return nil, err
+	}
+	dir, err := p.resolveWorkDir(params.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// #nosec G204 -- argv is checked against the configured allow/deny rules
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append([]string(nil), p.cfg.Env...)
+	for k, v := range params.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	if params.Stdin != "" {
+		cmd.Stdin = strings.NewReader(params.Stdin)
+	}
+
+	name := filepath.Base(argv[0])
+	proc := &process{
+		argv:   argv,
+		cmd:    cmd,
+		stdout: newCapture(p.cfg.MaxOutputBytes, p.cfg.Artifacts, name+".stdout"),
+		stderr: newCapture(p.cfg.MaxOutputBytes, p.cfg.Artifacts, name+".stderr"),
+	}
+	cmd.Stdout = proc.stdout
+	cmd.Stderr = proc.stderr
+	cmd.WaitDelay = 2 * time.Second
+
+	cleanup, err := configureProcess(cmd, p.cfg.Limits)
+	if err != nil {
+		return nil, err
+	}
+	proc.cleanup = cleanup
+	return proc, nil
+}
+
+// start launches the process.
+func (proc *process) start() error {
+	proc.started = time.Now()
+	if err := proc.cmd.Start(); err != nil {
+		proc.cleanup()
+		return fmt.Errorf("failed to start command: %w", err)
+	}
+	return nil
+}
+
+// wait blocks until the process exits and reports its result.
+func (proc *process) wait(ctx context.Context) map[string]any {
+	err := proc.cmd.Wait()
+	duration := time.Since(proc.started)
+	proc.cleanup()
+
+	exitCode := 0
+	if proc.cmd.ProcessState != nil {
+		exitCode = proc.cmd.ProcessState.ExitCode()
+	}
+
+	result := map[string]any{
+		"command":     proc.argv,
+		"exit_code":   exitCode,
+		"duration_ms": duration.Milliseconds(),
+		"timed_out":   errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			result["error"] = err.Error()
+		}
+	}
+	proc.stdout.finish("stdout", result)
+	proc.stderr.finish("stderr", result)
+	return result
+}
+
+func (proc *process) artifacts() []tool.ArtifactRef {
+	var refs []tool.ArtifactRef
+	for _, c := range []*capture{proc.stdout, proc.stderr} {
+		if ref, ok := c.ref(); ok {
+			refs = append(refs, tool.ArtifactRef{ID: ref.ID, Name: ref.Name})
+		}
+	}
+	return refs
+}
+
+// run executes argv to completion under the configured timeout.
+func (p *shellPack) run(ctx context.Context, argv []string, params execParams) (tool.Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout(params.TimeoutSeconds))
+	defer cancel()
+
+	proc, err := p.prepare(ctx, argv, params)
+	if err != nil {
+		return tool.Result{}, err
+	}
+	if err := proc.start(); err != nil {
+		return tool.Result{}, err
+	}
+	result := proc.wait(ctx)
+
+	output, _ := json.Marshal(result)
+	return tool.Result{
+		Output:    output,
+		Artifacts: proc.artifacts(),
+		Duration:  time.Since(proc.started),
+	}, nil
+}
+
+func (p *shellPack) shellExec() tool.Tool {
+	return tool.NewBuilder("shell_exec").
+		WithDescription("Execute a command with arguments and return exit code, stdout and stderr").
 		WithRiskLevel(tool.RiskHigh).
 		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var params execParams
+			if err := json.Unmarshal(input, &params); err != nil {
+				return tool.Result{}, err
+			}
+			return p.run(ctx, params.argv(), params)
+		}).
 		MustBuild()
 }
 
-func shellScript() tool.Tool {
+func (p *shellPack) shellScript() tool.Tool {
 	return tool.NewBuilder("shell_script").
 		WithDescription("Execute a shell script from a string").
 		WithRiskLevel(tool.RiskHigh).
 		RequiresApproval().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var params struct {
+				Script         string            `json:"script"`
+				Args           []string          `json:"args,omitempty"`
+				Dir            string            `json:"dir,omitempty"`
+				Env            map[string]string `json:"env,omitempty"`
+				TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+			}
+			if err := json.Unmarshal(input, &params); err != nil {
+				return tool.Result{}, err
+			}
+			if !p.cfg.AllowScripts {
+				return tool.Result{}, ErrScriptsDisabled
+			}
+			if strings.TrimSpace(params.Script) == "" {
+				return tool.Result{}, errors.New("script is required")
+			}
+
+			argv := append(append([]string(nil), p.cfg.Interpreter...), params.Args...)
+			return p.run(ctx, argv, execParams{
+				Dir:            params.Dir,
+				Env:            params.Env,
+				Stdin:          params.Script,
+				TimeoutSeconds: params.TimeoutSeconds,
+			})
+		}).
 		MustBuild()
 }
+
+// ============================================================================
+// Output capture
+// ============================================================================
+
+// artifactQueueChunks bounds the output chunks buffered between a command
+// and a slow artifact store. os/exec copies output in 32 KiB chunks, so this
+// is roughly 8 MiB per stream.
+const artifactQueueChunks = 256
+
+// capture keeps the first limit bytes of a stream in memory. Once the limit
+// is exceeded and an artifact store is configured, the buffered head and all
+// further output are queued for a goroutine that streams them into a new
+// artifact. Write never waits for the store: if the queue fills up, the rest
+// of the stream is left out of the artifact and the capture reports it as
+// incomplete.
+type capture struct {
+	mu       sync.Mutex
+	limit    int
+	store    artifact.Store
+	name     string
+	buf      bytes.Buffer
+	total    int64
+	chunks   chan []byte
+	dropped  bool
+	finished bool
+	stored   chan storeResult
+	result   *storeResult
+}
+
+type storeResult struct {
+	ref artifact.Ref
+	err error
+}
+
+func newCapture(limit int, store artifact.Store, name string) *capture {
+	return &capture{limit: limit, store: store, name: name}
+}
+
+// Write implements io.Writer. It never fails or blocks on the artifact
+// store so that a slow or failing store cannot stall the child process.
+func (c *capture) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := len(b)
+	c.total += int64(n)
+	head := 0
+	if room := c.limit - c.buf.Len(); room > 0 {
+		head = min(room, n)
+		c.buf.Write(b[:head])
+	}
+
+	switch {
+	case c.finished:
+	case c.chunks != nil:
+		c.stream(b)
+	case c.total > int64(c.limit) && c.store != nil:
+		c.startArtifact()
+		c.stream(c.buf.Bytes())
+		c.stream(b[head:])
+	}
+	return n, nil
+}
+
+// stream queues a copy of b for the artifact writer. Callers hold c.mu.
+func (c *capture) stream(b []byte) {
+	if c.dropped || len(b) == 0 {
+		return
+	}
+	select {
+	case c.chunks <- bytes.Clone(b):
+	default:
+		c.dropped = true
+	}
+}
+
+func (c *capture) startArtifact() {
+	pr, pw := io.Pipe()
+	chunks := make(chan []byte, artifactQueueChunks)
+	c.chunks = chunks
+	c.stored = make(chan storeResult, 1)
+	opts := artifact.DefaultStoreOptions().
+		WithName(c.name).
+		WithContentType("text/plain")
+
+	store, stored := c.store, c.stored
+	go func() {
+		ref, err := store.Store(context.Background(), pr, opts)
+		_ = pr.CloseWithError(err)
+		stored <- storeResult{ref: ref, err: err}
+	}()
+	go func() {
+		// Keep draining after a write error so stream never blocks; the
+		// store's error is reported through stored.
+		for b := range chunks {
+			_, _ = pw.Write(b)
+		}
+		_ = pw.Close()
+	}()
+}
+
+// snapshot returns the inline output collected so far.
+func (c *capture) snapshot() (string, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.String(), c.total
+}
+
+// finish closes the artifact stream and adds the capture summary to result
+// under keys prefixed with name.
+func (c *capture) finish(name string, result map[string]any) {
+	c.mu.Lock()
+	streaming := c.chunks != nil && !c.finished
+	if streaming {
+		close(c.chunks)
+	}
+	c.finished = true
+	c.mu.Unlock()
+
+	if streaming {
+		res := <-c.stored
+		c.mu.Lock()
+		c.result = &res
+		c.mu.Unlock()
+	}
+
+	c.mu.Lock()
+	out, total, dropped, res := c.buf.String(), c.total, c.dropped, c.result
+	c.mu.Unlock()
+
+	result[name] = out
+	result[name+"_bytes"] = total
+	result[name+"_truncated"] = total > int64(c.limit)
+	switch {
+	case res == nil:
+	case res.err != nil:
+		result[name+"_artifact_error"] = res.err.Error()
+	default:
+		result[name+"_artifact"] = res.ref.ID
+		if dropped {
+			result[name+"_artifact_incomplete"] = true
+		}
+	}
+}
+
+func (c *capture) ref() (artifact.Ref, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.result == nil || c.result.err != nil {
+		return artifact.Ref{}, false
+	}
+	return c.result.ref, true
+}
@@ -0,0 +1,311 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/artifact"
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/filesystem"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+func TestCheckArgv(t *testing.T) {
+	t.Parallel()
+
+	p := newShellPack(Config{
+		Allow: []string{"git status", "git log **", "ls -l *", "echo **"},
+		Deny:  []string{"git log --all **", "rm **"},
+	})
+
+	allowed := [][]string{
+		{"git", "status"},
+		{"git", "log"},
+		{"git", "log", "--oneline", "-n", "5"},
+		{"ls", "-l", "src"},
+		{"echo"},
+	}
+	for _, argv := range allowed {
+		if err := p.checkArgv(argv); err != nil {
+			t.Errorf("%q: unexpected error %v", argv, err)
+		}
+	}
+
+	denied := [][]string{
+		{"git", "push"},
+		{"git", "status", "--short"},
+		{"git", "log", "--all", "--oneline"},
+		{"ls", "-l"},
+		{"ls", "-l", "a", "b"},
+		{"rm", "-rf", "/"},
+		{"/bin/rm", "file"},
+		{"curl", "example.com"},
+	}
+	for _, argv := range denied {
+		if err := p.checkArgv(argv); !errors.Is(err, ErrCommandDenied) {
+			t.Errorf("%q: expected ErrCommandDenied, got %v", argv, err)
+		}
+	}
+
+	if err := p.checkArgv(nil); err == nil {
+		t.Error("expected error for empty argv")
+	}
+
+	if err := newShellPack(Config{}).checkArgv([]string{"ls"}); !errors.Is(err, ErrCommandDenied) {
+		t.Errorf("empty allow list: expected ErrCommandDenied, got %v", err)
+	}
+	open := newShellPack(Config{AllowAll: true, Deny: []string{"rm **"}})
+	if err := open.checkArgv([]string{"anything", "goes"}); err != nil {
+		t.Errorf("AllowAll: unexpected error %v", err)
+	}
+	if err := open.checkArgv([]string{"/usr/bin/rm", "x"}); !errors.Is(err, ErrCommandDenied) {
+		t.Errorf("deny by path: expected ErrCommandDenied, got %v", err)
+	}
+}
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{Allow: []string{"sh -c *"}})
+	out, err := call(p, "shell_exec", map[string]any{
+		"command": "sh",
+		"args":    []string{"-c", "echo out; echo err >&2; exit 3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["stdout"] != "out\n" || out["stderr"] != "err\n" || out["exit_code"] != float64(3) {
+		t.Errorf("unexpected result: %v", out)
+	}
+
+	if _, err := call(p, "shell_exec", map[string]any{"command": "echo", "args": []string{"hi"}}); !errors.Is(err, ErrCommandDenied) {
+		t.Errorf("expected ErrCommandDenied, got %v", err)
+	}
+}
+
+func TestExecEnv(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{
+		AllowAll:   true,
+		Env:        []string{"BASE=1"},
+		AllowedEnv: []string{"GREETING"},
+	})
+	out, err := call(p, "shell_exec", map[string]any{
+		"command": "sh",
+		"args":    []string{"-c", "echo $BASE $GREETING"},
+		"env":     map[string]string{"GREETING": "hello"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["stdout"] != "1 hello\n" {
+		t.Errorf("stdout = %q, want %q", out["stdout"], "1 hello\n")
+	}
+
+	for _, key := range []string{"LD_PRELOAD", "PATH", "GREETING=x"} {
+		_, err := call(p, "shell_exec", map[string]any{
+			"command": "true",
+			"env":     map[string]string{key: "x"},
+		})
+		if !errors.Is(err, ErrEnvDenied) {
+			t.Errorf("%s: expected ErrEnvDenied, got %v", key, err)
+		}
+	}
+
+	// Without an allow-list no variable may be set.
+	if _, err := call(Pack(Config{AllowAll: true}), "shell_exec", map[string]any{
+		"command": "true",
+		"env":     map[string]string{"GREETING": "hello"},
+	}); !errors.Is(err, ErrEnvDenied) {
+		t.Errorf("expected ErrEnvDenied, got %v", err)
+	}
+}
+
+func TestExecWorkDir(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sub"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(os.TempDir(), filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	p := Pack(Config{AllowAll: true, Root: root})
+
+	out, err := call(p, "shell_exec", map[string]any{"command": "pwd", "dir": "sub"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(out["stdout"].(string)), "sub") {
+		t.Errorf("unexpected working directory: %v", out["stdout"])
+	}
+
+	for _, dir := range []string{"..", "/", "escape"} {
+		if _, err := call(p, "shell_exec", map[string]any{"command": "pwd", "dir": dir}); !errors.Is(err, ErrWorkDirOutsideRoot) {
+			t.Errorf("%s: expected ErrWorkDirOutsideRoot, got %v", dir, err)
+		}
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	t.Parallel()
+
+	p := Pack(Config{AllowAll: true, DefaultTimeout: 100 * time.Millisecond})
+	start := time.Now()
+	out, err := call(p, "shell_exec", map[string]any{"command": "sleep", "args": []string{"10"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["timed_out"] != true {
+		t.Errorf("expected timed_out, got %v", out)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("command was not stopped at the timeout, took %v", elapsed)
+	}
+}
+
+func TestExecOutputTruncation(t *testing.T) {
+	t.Parallel()
+
+	store, err := filesystem.NewArtifactStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Pack(Config{AllowAll: true, MaxOutputBytes: 16, Artifacts: store})
+
+	tl, _ := p.GetTool("shell_exec")
+	raw, _ := json.Marshal(map[string]any{
+		"command": "sh",
+		"args":    []string{"-c", "i=0; while [ $i -lt 1000 ]; do echo line-$i; i=$((i+1)); done"},
+	})
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(result.Output, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out["stdout"] != "line-0\nline-1\nli" || out["stdout_truncated"] != true {
+		t.Errorf("unexpected inline output: %v", out)
+	}
+	if len(result.Artifacts) != 1 || out["stdout_artifact"] != result.Artifacts[0].ID {
+		t.Fatalf("expected one stdout artifact, got %v / %v", result.Artifacts, out)
+	}
+
+	rc, err := store.Retrieve(context.Background(), artifact.Ref{ID: result.Artifacts[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	full, _ := io.ReadAll(rc)
+	if int64(len(full)) != int64(out["stdout_bytes"].(float64)) || !strings.HasSuffix(string(full), "line-999\n") {
+		t.Errorf("artifact holds %d bytes, want %v", len(full), out["stdout_bytes"])
+	}
+
+	// Without a store the excess is discarded.
+	out, err = call(Pack(Config{AllowAll: true, MaxOutputBytes: 4}), "shell_exec", map[string]any{"command": "echo", "args": []string{"truncated"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["stdout"] != "trun" || out["stdout_truncated"] != true || out["stdout_bytes"] != float64(10) {
+		t.Errorf("unexpected result: %v", out)
+	}
+}
+
+// failingStore rejects every artifact without reading it.
+type failingStore struct{ *filesystem.ArtifactStore }
+
+func (failingStore) Store(context.Context, io.Reader, artifact.StoreOptions) (artifact.Ref, error) {
+	return artifact.Ref{}, errors.New("store unavailable")
+}
+
+func TestCaptureFailingStore(t *testing.T) {
+	t.Parallel()
+
+	c := newCapture(4, failingStore{}, "out")
+	chunk := make([]byte, 32*1024)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2*artifactQueueChunks; i++ {
+			_, _ = c.Write(chunk)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked on the artifact store")
+	}
+
+	result := map[string]any{}
+	c.finish("stdout", result)
+	if result["stdout_artifact_error"] != "store unavailable" || result["stdout_truncated"] != true {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
+
+func TestScriptsDisabled(t *testing.T) {
+	t.Parallel()
+
+	if _, err := call(Pack(Config{}), "shell_script", map[string]any{"script": "echo hi"}); !errors.Is(err, ErrScriptsDisabled) {
+		t.Errorf("expected ErrScriptsDisabled, got %v", err)
+	}
+
+	out, err := call(Pack(Config{AllowAll: true, AllowScripts: true}), "shell_script", map[string]any{"script": "echo $((1+2))"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["stdout"] != "3\n" {
+		t.Errorf("stdout = %q, want %q", out["stdout"], "3\n")
+	}
+}
+
+func TestExecRlimits(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS != "linux" {
+		t.Skip("rlimits are only enforced on Linux")
+	}
+	if _, err := exec.LookPath("prlimit"); err != nil {
+		t.Skip("prlimit is not installed")
+	}
+
+	p := Pack(Config{AllowAll: true, Limits: Limits{OpenFiles: 17}})
+	out, err := call(p, "shell_exec", map[string]any{
+		"command": "sh",
+		"args":    []string{"-c", "ulimit -n; ulimit -Hn"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["stdout"] != "17\n17\n" {
+		t.Errorf("stdout = %q, want soft and hard limit of 17", out["stdout"])
+	}
+	if got := out["command"].([]any); len(got) != 3 || got[0] != "sh" {
+		t.Errorf("command = %v, want the caller's argv", got)
+	}
+}
@@ -0,0 +1,185 @@
+package vectordb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/knowledge"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
+)
+
+// Errors returned by backends and tools.
+var (
+	// ErrIndexNotFound indicates the named index does not exist.
+	ErrIndexNotFound = errors.New("index not found")
+
+	// ErrIndexExists indicates an index with the same name already exists.
+	ErrIndexExists = errors.New("index already exists")
+
+	// ErrInvalidIndex indicates an invalid index name or dimension.
+	ErrInvalidIndex = errors.New("invalid index")
+)
+
+// indexNameRegex restricts index names to a portable subset accepted by
+// hosted vector databases.
+var indexNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// IndexSpec describes a named vector index. Similarity is cosine, matching
+// knowledge.Store.
+type IndexSpec struct {
+	Name      string    `json:"name"`
+	Dimension int       `json:"dimension"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks the index name and dimension.
+func (s IndexSpec) Validate() error {
+	if !indexNameRegex.MatchString(s.Name) {
+		return fmt.Errorf("%w: name %q must be lowercase alphanumeric, '-' or '_'", ErrInvalidIndex, s.Name)
+	}
+	if s.Dimension <= 0 {
+		return fmt.Errorf("%w: dimension must be positive", ErrInvalidIndex)
+	}
+	return nil
+}
+
+// Backend manages named indexes, each stored in a knowledge.Store. Vendor
+// adapters (Pinecone, Qdrant, pgvector, ...) implement Backend and expose
+// each of their indexes as a knowledge.Store.
+type Backend interface {
+	// CreateIndex creates an empty index.
+	CreateIndex(ctx context.Context, spec IndexSpec) error
+
+	// DeleteIndex removes an index and all of its vectors.
+	DeleteIndex(ctx context.Context, name string) error
+
+	// ListIndexes returns all indexes sorted by name.
+	ListIndexes(ctx context.Context) ([]IndexSpec, error)
+
+	// Index returns the spec and store of a named index.
+	Index(ctx context.Context, name string) (IndexSpec, knowledge.Store, error)
+}
+
+// StoreFactory creates the store backing a new index.
+type StoreFactory func(spec IndexSpec) (knowledge.Store, error)
+
+// StoreBackend is a Backend that keeps one knowledge.Store per index.
+type StoreBackend struct {
+	newStore StoreFactory
+
+	mu      sync.RWMutex
+	indexes map[string]storeIndex
+}
+
+type storeIndex struct {
+	spec  IndexSpec
+	store knowledge.Store
+}
+
+// NewStoreBackend creates a backend whose indexes are created by newStore.
+func NewStoreBackend(newStore StoreFactory) *StoreBackend {
+	return &StoreBackend{newStore: newStore, indexes: make(map[string]storeIndex)}
+}
+
+// NewMemoryBackend creates a backend with in-memory indexes, suitable for
+// offline use and tests.
+func NewMemoryBackend() *StoreBackend {
+	return NewStoreBackend(func(spec IndexSpec) (knowledge.Store, error) {
+		return memory.NewKnowledgeStore(spec.Dimension), nil
+	})
+}
+
+// Attach registers an existing store as an index, e.g. the engine's
+// knowledge store.
+func (b *StoreBackend) Attach(spec IndexSpec, store knowledge.Store) error {
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	if spec.CreatedAt.IsZero() {
+		spec.CreatedAt = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.indexes[spec.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrIndexExists, spec.Name)
+	}
+	b.indexes[spec.Name] = storeIndex{spec: spec, store: store}
+	return nil
+}
+
+// CreateIndex implements Backend.
+func (b *StoreBackend) CreateIndex(ctx context.Context, spec IndexSpec) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.indexes[spec.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrIndexExists, spec.Name)
+	}
+	store, err := b.newStore(spec)
+	if err != nil {
+		return fmt.Errorf("failed to create index %s: %w", spec.Name, err)
+	}
+	if spec.CreatedAt.IsZero() {
+		spec.CreatedAt = time.Now()
+	}
+	b.indexes[spec.Name] = storeIndex{spec: spec, store: store}
+	return nil
+}
+
+// DeleteIndex implements Backend.
+func (b *StoreBackend) DeleteIndex(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.indexes[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrIndexNotFound, name)
+	}
+	delete(b.indexes, name)
+	return nil
+}
+
+// ListIndexes implements Backend.
+func (b *StoreBackend) ListIndexes(ctx context.Context) ([]IndexSpec, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	specs := make([]IndexSpec, 0, len(b.indexes))
+	for _, idx := range b.indexes {
+		specs = append(specs, idx.spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Name < specs[j].Name })
+	return specs, nil
+}
+
+// Index implements Backend.
+func (b *StoreBackend) Index(ctx context.Context, name string) (IndexSpec, knowledge.Store, error) {
+	if err := ctx.Err(); err != nil {
+		return IndexSpec{}, nil, err
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	idx, ok := b.indexes[name]
+	if !ok {
+		return IndexSpec{}, nil, fmt.Errorf("%w: %s", ErrIndexNotFound, name)
+	}
+	return idx.spec, idx.store, nil
+}
@@ -10,29 +10,67 @@
 //   - vector_delete_index: Delete a vector index
 //   - vector_describe_index: Get index statistics
 //
-// Supports Pinecone, Weaviate, Milvus, Qdrant, and pgvector.
+// Indexes are provided by a Backend. The built-in StoreBackend keeps each
+// named index in a domain/knowledge.Store, so the pack works fully offline
+// with the in-memory store; adapters for Pinecone, Weaviate, Milvus, Qdrant
+// or pgvector plug in behind the same interface.
 package vectordb
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/knowledge"
 	"github.com/felixgeelhaar/agent-go/domain/pack"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
+// Config configures the vector database pack.
+type Config struct {
+	// Backend provides the indexes. Defaults to NewMemoryBackend().
+	Backend Backend
+
+	// DefaultIndex is used when a call does not name an index.
+	DefaultIndex string
+
+	// MaxTopK caps the number of query matches. Defaults to 100.
+	MaxTopK int
+
+	// MaxBatch caps the vectors per upsert, fetch or delete. Defaults to 1000.
+	MaxBatch int
+}
+
 // Pack returns the vector database tools pack.
-func Pack() *pack.Pack {
+func Pack(cfg Config) *pack.Pack {
+	if cfg.Backend == nil {
+		cfg.Backend = NewMemoryBackend()
+	}
+	if cfg.MaxTopK <= 0 {
+		cfg.MaxTopK = 100
+	}
+	if cfg.MaxBatch <= 0 {
+		cfg.MaxBatch = 1000
+	}
+	p := &vectorPack{cfg: cfg}
+
 	return pack.NewBuilder("vectordb").
 		WithDescription("Vector database tools for similarity search").
-		WithVersion("0.1.0").
+		WithVersion("0.2.0").
 		AddTools(
-			vectorUpsert(),
-			vectorQuery(),
-			vectorDelete(),
-			vectorFetch(),
-			vectorList(),
-			vectorCreateIndex(),
-			vectorDeleteIndex(),
-			vectorDescribeIndex(),
+			p.vectorUpsert(),
+			p.vectorQuery(),
+			p.vectorDelete(),
+			p.vectorFetch(),
+			p.vectorList(),
+			p.vectorCreateIndex(),
+			p.vectorDeleteIndex(),
+			p.vectorDescribeIndex(),
 		).
 		AllowInState(agent.StateExplore, "vector_query", "vector_fetch", "vector_list", "vector_describe_index").
 		AllowInState(agent.StateAct, "vector_upsert", "vector_query", "vector_delete", "vector_fetch", "vector_list", "vector_create_index", "vector_delete_index", "vector_describe_index").
@@ -40,61 +78,682 @@ func Pack() *pack.Pack {
 		Build()
 }
 
-func vectorUpsert() tool.Tool {
+type vectorPack struct {
+	cfg Config
+}
+
+func (p *vectorPack) index(ctx context.Context, name string) (IndexSpec, knowledge.Store, error) {
+	if name == "" {
+		name = p.cfg.DefaultIndex
+	}
+	if name == "" {
+		return IndexSpec{}, nil, fmt.Errorf("%w: no index given and no default configured", ErrIndexNotFound)
+	}
+	return p.cfg.Backend.Index(ctx, name)
+}
+
+func checkDimension(spec IndexSpec, values []float32) error {
+	if len(values) != spec.Dimension {
+		return fmt.Errorf("%w: index %s expects %d, got %d", knowledge.ErrDimensionMismatch, spec.Name, spec.Dimension, len(values))
+	}
+	return nil
+}
+
+// ============================================================================
+// Metadata Filters
+// ============================================================================
+
+// filter matches vector metadata. Each key must match; a string value
+// requires equality and a list of strings requires any of them.
+type filter map[string]any
+
+// split returns the equality conditions, which stores evaluate natively,
+// and the remaining "any of" conditions.
+func (f filter) split() (map[string]string, map[string][]string, error) {
+	eq := make(map[string]string)
+	in := make(map[string][]string)
+	for k, v := range f {
+		switch v := v.(type) {
+		case string:
+			eq[k] = v
+		case float64, bool:
+			eq[k] = fmt.Sprint(v)
+		case []any:
+			for _, item := range v {
+				s, ok := item.(string)
+				if !ok {
+					s = fmt.Sprint(item)
+				}
+				in[k] = append(in[k], s)
+			}
+		default:
+			return nil, nil, fmt.Errorf("unsupported filter value for %q: want a string or a list of strings", k)
+		}
+	}
+	return eq, in, nil
+}
+
+func matchesAny(metadata map[string]string, in map[string][]string) bool {
+	for k, options := range in {
+		got, ok := metadata[k]
+		if !ok {
+			return false
+		}
+		matched := false
+		for _, o := range options {
+			if got == o {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// matches reports whether metadata satisfies every condition of f.
+func (f filter) matches(metadata map[string]string) (bool, error) {
+	eq, in, err := f.split()
+	if err != nil {
+		return false, err
+	}
+	for k, v := range eq {
+		if got, ok := metadata[k]; !ok || got != v {
+			return false, nil
+		}
+	}
+	return matchesAny(metadata, in), nil
+}
+
+// maxFilterExpansion bounds the equality filters an "any of" filter is
+// expanded into for stores that search with a filter.
+const maxFilterExpansion = 32
+
+// expand turns the conditions of f into equality filters, one per
+// combination of "any of" options. It reports false when there are more
+// than maxFilterExpansion combinations.
+func (f filter) expand() ([]map[string]string, bool, error) {
+	eq, in, err := f.split()
+	if err != nil {
+		return nil, false, err
+	}
+	combos := []map[string]string{eq}
+	for k, options := range in {
+		if len(combos)*len(options) > maxFilterExpansion {
+			return nil, false, nil
+		}
+		next := make([]map[string]string, 0, len(combos)*len(options))
+		for _, combo := range combos {
+			for _, o := range options {
+				c := make(map[string]string, len(combo)+1)
+				for ck, cv := range combo {
+					c[ck] = cv
+				}
+				c[k] = o
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos, true, nil
+}
+
+// search returns the topK vectors most similar to embedding that match f.
+// Stores implementing knowledge.FilteredSearcher filter during the search;
+// other stores, or filters expanding to too many combinations, score every
+// matching vector exactly.
+func search(ctx context.Context, store knowledge.Store, embedding []float32, topK int, f filter) ([]knowledge.SearchResult, error) {
+	if len(f) == 0 {
+		return store.Search(ctx, embedding, topK)
+	}
+
+	var results []knowledge.SearchResult
+	fs, ok := store.(knowledge.FilteredSearcher)
+	combos, expanded, err := f.expand()
+	if err != nil {
+		return nil, err
+	}
+	if ok && expanded {
+		seen := make(map[string]bool)
+		for _, metadata := range combos {
+			found, err := fs.SearchFiltered(ctx, embedding, topK, knowledge.ListFilter{Metadata: metadata})
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range found {
+				if !seen[r.ID] {
+					seen[r.ID] = true
+					results = append(results, r)
+				}
+			}
+		}
+	} else {
+		candidates, err := filtered(ctx, store, f, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range candidates {
+			results = append(results, knowledge.SearchResult{
+				ID:       v.ID,
+				Text:     v.Text,
+				Score:    cosine(embedding, v.Embedding),
+				Metadata: v.Metadata,
+			})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > topK {
+		results = results[:topK]
+	}
+	return results, nil
+}
+
+// filtered lists every vector of a store matching f.
+func filtered(ctx context.Context, store knowledge.Store, f filter, idPrefix string) ([]*knowledge.Vector, error) {
+	eq, in, err := f.split()
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := store.List(ctx, knowledge.ListFilter{IDPrefix: idPrefix, Metadata: eq})
+	if err != nil {
+		return nil, err
+	}
+	if len(in) == 0 {
+		return vectors, nil
+	}
+	matched := vectors[:0]
+	for _, v := range vectors {
+		if matchesAny(v.Metadata, in) {
+			matched = append(matched, v)
+		}
+	}
+	return matched, nil
+}
+
+// toMetadata converts JSON metadata to the string map stored with vectors.
+func toMetadata(in map[string]any) (map[string]string, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		switch v := v.(type) {
+		case string:
+			out[k] = v
+		case float64, bool:
+			out[k] = fmt.Sprint(v)
+		case nil:
+		default:
+			return nil, fmt.Errorf("metadata %q must be a string, number or boolean", k)
+		}
+	}
+	return out, nil
+}
+
+func cosine(a, b []float32) float32 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(na) * math.Sqrt(nb)))
+}
+
+// ============================================================================
+// Vector Tools
+// ============================================================================
+
+type vectorInput struct {
+	ID       string         `json:"id"`
+	Values   []float32      `json:"values"`
+	Text     string         `json:"text,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+func (p *vectorPack) vectorUpsert() tool.Tool {
 	return tool.NewBuilder("vector_upsert").
-		WithDescription("Insert or update vectors with metadata").
+		WithDescription("Insert or update vectors with text and metadata").
 		Idempotent().
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index   string        `json:"index,omitempty"`
+				Vectors []vectorInput `json:"vectors"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.Vectors) == 0 {
+				return tool.Result{}, errors.New("vectors are required")
+			}
+			if len(in.Vectors) > p.cfg.MaxBatch {
+				return tool.Result{}, fmt.Errorf("too many vectors: %d (max %d)", len(in.Vectors), p.cfg.MaxBatch)
+			}
+
+			spec, store, err := p.index(ctx, in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			// Validate the whole batch before writing anything.
+			vectors := make([]*knowledge.Vector, 0, len(in.Vectors))
+			for i, v := range in.Vectors {
+				if v.ID == "" {
+					return tool.Result{}, fmt.Errorf("vector %d: %w", i, knowledge.ErrInvalidID)
+				}
+				if err := checkDimension(spec, v.Values); err != nil {
+					return tool.Result{}, fmt.Errorf("vector %s: %w", v.ID, err)
+				}
+				metadata, err := toMetadata(v.Metadata)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("vector %s: %w", v.ID, err)
+				}
+				vectors = append(vectors, &knowledge.Vector{
+					ID:        v.ID,
+					Embedding: v.Values,
+					Text:      v.Text,
+					Metadata:  metadata,
+				})
+			}
+
+			if batch, ok := store.(knowledge.BatchStore); ok {
+				err = batch.UpsertBatch(ctx, vectors)
+			} else {
+				for _, v := range vectors {
+					if err = store.Upsert(ctx, v); err != nil {
+						break
+					}
+				}
+			}
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("upsert failed: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":          spec.Name,
+				"upserted_count": len(vectors),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func vectorQuery() tool.Tool {
+func (p *vectorPack) vectorQuery() tool.Tool {
 	return tool.NewBuilder("vector_query").
-		WithDescription("Query for similar vectors using vector similarity search").
+		WithDescription("Query for similar vectors by cosine similarity, optionally filtered by metadata").
 		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index         string    `json:"index,omitempty"`
+				Vector        []float32 `json:"vector,omitempty"`
+				ID            string    `json:"id,omitempty"`
+				TopK          int       `json:"top_k,omitempty"`
+				Filter        filter    `json:"filter,omitempty"`
+				MinScore      float32   `json:"min_score,omitempty"`
+				IncludeValues bool      `json:"include_values,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.TopK <= 0 {
+				in.TopK = 10
+			}
+			in.TopK = min(in.TopK, p.cfg.MaxTopK)
+
+			spec, store, err := p.index(ctx, in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			// Query by the embedding of a stored vector.
+			if in.ID != "" && len(in.Vector) == 0 {
+				v, err := store.Get(ctx, in.ID)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("vector %s: %w", in.ID, err)
+				}
+				in.Vector = v.Embedding
+			}
+			if err := checkDimension(spec, in.Vector); err != nil {
+				return tool.Result{}, err
+			}
+
+			results, err := search(ctx, store, in.Vector, in.TopK, in.Filter)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("query failed: %w", err)
+			}
+
+			matches := make([]map[string]any, 0, len(results))
+			for _, r := range results {
+				if r.Score < in.MinScore {
+					continue
+				}
+				m := map[string]any{
+					"id":       r.ID,
+					"score":    r.Score,
+					"text":     r.Text,
+					"metadata": r.Metadata,
+				}
+				if in.IncludeValues {
+					if v, err := store.Get(ctx, r.ID); err == nil {
+						m["values"] = v.Embedding
+					}
+				}
+				matches = append(matches, m)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":   spec.Name,
+				"matches": matches,
+				"count":   len(matches),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func vectorDelete() tool.Tool {
+func (p *vectorPack) vectorDelete() tool.Tool {
 	return tool.NewBuilder("vector_delete").
-		WithDescription("Delete vectors by ID or filter").
+		WithDescription("Delete vectors by ID or metadata filter").
 		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index  string   `json:"index,omitempty"`
+				IDs    []string `json:"ids,omitempty"`
+				Filter filter   `json:"filter,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.IDs) == 0 && len(in.Filter) == 0 {
+				return tool.Result{}, errors.New("ids or filter is required")
+			}
+
+			spec, store, err := p.index(ctx, in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			ids := in.IDs
+			switch {
+			case len(in.Filter) > 0 && len(in.IDs) > 0:
+				// Check only the listed vectors instead of listing the index.
+				ids = ids[:0:0]
+				for _, id := range in.IDs {
+					v, err := store.Get(ctx, id)
+					if errors.Is(err, knowledge.ErrNotFound) {
+						continue
+					}
+					if err != nil {
+						return tool.Result{}, fmt.Errorf("get %s failed: %w", id, err)
+					}
+					ok, err := in.Filter.matches(v.Metadata)
+					if err != nil {
+						return tool.Result{}, err
+					}
+					if ok {
+						ids = append(ids, id)
+					}
+				}
+			case len(in.Filter) > 0:
+				vectors, err := filtered(ctx, store, in.Filter, "")
+				if err != nil {
+					return tool.Result{}, err
+				}
+				ids = make([]string, 0, len(vectors))
+				for _, v := range vectors {
+					ids = append(ids, v.ID)
+				}
+			}
+			if len(ids) > p.cfg.MaxBatch {
+				return tool.Result{}, fmt.Errorf("too many vectors to delete: %d (max %d)", len(ids), p.cfg.MaxBatch)
+			}
+
+			// Deleting an absent ID is not an error, so that retries are safe.
+			deleted := 0
+			for _, id := range ids {
+				err := store.Delete(ctx, id)
+				switch {
+				case err == nil:
+					deleted++
+				case errors.Is(err, knowledge.ErrNotFound):
+				default:
+					return tool.Result{}, fmt.Errorf("delete %s failed: %w", id, err)
+				}
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":         spec.Name,
+				"deleted_count": deleted,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func vectorFetch() tool.Tool {
+func vectorOutput(v *knowledge.Vector, includeValues bool) map[string]any {
+	out := map[string]any{
+		"id":         v.ID,
+		"text":       v.Text,
+		"metadata":   v.Metadata,
+		"created_at": v.CreatedAt.Format(time.RFC3339),
+	}
+	if includeValues {
+		out["values"] = v.Embedding
+	}
+	return out
+}
+
+func (p *vectorPack) vectorFetch() tool.Tool {
 	return tool.NewBuilder("vector_fetch").
 		WithDescription("Fetch vectors by their IDs").
 		ReadOnly().
 		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index         string   `json:"index,omitempty"`
+				IDs           []string `json:"ids"`
+				IncludeValues *bool    `json:"include_values,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if len(in.IDs) > p.cfg.MaxBatch {
+				return tool.Result{}, fmt.Errorf("too many ids: %d (max %d)", len(in.IDs), p.cfg.MaxBatch)
+			}
+			includeValues := in.IncludeValues == nil || *in.IncludeValues
+
+			spec, store, err := p.index(ctx, in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			vectors := make([]map[string]any, 0, len(in.IDs))
+			missing := []string{}
+			for _, id := range in.IDs {
+				v, err := store.Get(ctx, id)
+				if errors.Is(err, knowledge.ErrNotFound) {
+					missing = append(missing, id)
+					continue
+				}
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("fetch %s failed: %w", id, err)
+				}
+				vectors = append(vectors, vectorOutput(v, includeValues))
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"index":   spec.Name,
+				"vectors": vectors,
+				"missing": missing,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func vectorList() tool.Tool {
+func (p *vectorPack) vectorList() tool.Tool {
 	return tool.NewBuilder("vector_list").
-		WithDescription("List vectors in an index with pagination").
+		WithDescription("List vectors in an index with prefix and metadata filters and pagination").
 		ReadOnly().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Index         string `json:"index,omitempty"`
+				Prefix        string `json:"prefix,omitempty"`
+				Filter        filter `json:"filter,omitempty"`
+				Limit         int    `json:"limit,omitempty"`
+				Offset        int    `json:"offset,omitempty"`
+				IncludeValues bool   `json:"include_values,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+			if in.Limit <= 0 {
+				in.Limit = 100
+			}
+			in.Limit = min(in.Limit, p.cfg.MaxBatch)
+			in.Offset = max(in.Offset, 0)
+
+			spec, store, err := p.index(ctx, in.Index)
+			if err != nil {
+				return tool.Result{}, err
+			}
+
+			all, err := filtered(ctx, store, in.Filter, in.Prefix)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("list failed: %w", err)
+			}
+			// Order by ID so that offsets are stable across calls.
+			sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+			page := all[min(in.Offset, len(all)):]
+			if len(page) > in.Limit {
+				page = page[:in.Limit]
+			}
+
+			vectors := make([]map[string]any, 0, len(page))
+			for _, v := range page {
+				vectors = append(vectors, vectorOutput(v, in.IncludeValues))
+			}
+
+			result := map[string]any{
+				"index":   spec.Name,
+				"vectors": vectors,
+				"count":   len(vectors),
+				"total":   len(all),
+			}
+			if next := in.Offset + len(page); next < len(all) {
+				result["next_offset"] = next
+			}
+			output, _ := json.Marshal(result)
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func vectorCreateIndex() tool.Tool {
+// ============================================================================
+// Index Tools
+// ============================================================================
+
+func (p *vectorPack) vectorCreateIndex() tool.Tool {
 	return tool.NewBuilder("vector_create_index").
-		WithDescription("Create a new vector index").
+		WithDescription("Create a new vector index with a fixed dimension").
 		WithRiskLevel(tool.RiskMedium).
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Name      string `json:"name"`
+				Dimension int    `json:"dimension"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			spec := IndexSpec{Name: in.Name, Dimension: in.Dimension}
+			if err := p.cfg.Backend.CreateIndex(ctx, spec); err != nil {
+				return tool.Result{}, err
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"name":      spec.Name,
+				"dimension": spec.Dimension,
+				"metric":    "cosine",
+				"created":   true,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func vectorDeleteIndex() tool.Tool {
+func (p *vectorPack) vectorDeleteIndex() tool.Tool {
 	return tool.NewBuilder("vector_delete_index").
-		WithDescription("Delete a vector index").
+		WithDescription("Delete a vector index and all of its vectors").
 		Destructive().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			if err := p.cfg.Backend.DeleteIndex(ctx, in.Name); err != nil {
+				return tool.Result{}, err
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"name":    in.Name,
+				"deleted": true,
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
 
-func vectorDescribeIndex() tool.Tool {
+func (p *vectorPack) vectorDescribeIndex() tool.Tool {
 	return tool.NewBuilder("vector_describe_index").
-		WithDescription("Get statistics and configuration for a vector index").
+		WithDescription("Get statistics and configuration for a vector index, or list all indexes").
 		ReadOnly().
-		Cacheable().
+		WithHandler(func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			var in struct {
+				Name string `json:"name,omitempty"`
+			}
+			if err := json.Unmarshal(input, &in); err != nil {
+				return tool.Result{}, err
+			}
+
+			if in.Name == "" && p.cfg.DefaultIndex == "" {
+				specs, err := p.cfg.Backend.ListIndexes(ctx)
+				if err != nil {
+					return tool.Result{}, err
+				}
+				output, _ := json.Marshal(map[string]any{
+					"indexes": specs,
+					"count":   len(specs),
+				})
+				return tool.Result{Output: output}, nil
+			}
+
+			spec, store, err := p.index(ctx, in.Name)
+			if err != nil {
+				return tool.Result{}, err
+			}
+			count, err := store.Count(ctx)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("failed to count vectors: %w", err)
+			}
+
+			output, _ := json.Marshal(map[string]any{
+				"name":         spec.Name,
+				"dimension":    spec.Dimension,
+				"metric":       "cosine",
+				"vector_count": count,
+				"created_at":   spec.CreatedAt.Format(time.RFC3339),
+			})
+			return tool.Result{Output: output}, nil
+		}).
 		MustBuild()
 }
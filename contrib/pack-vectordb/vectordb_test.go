@@ -0,0 +1,302 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/knowledge"
+	"github.com/felixgeelhaar/agent-go/domain/pack"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
+)
+
+func call(p *pack.Pack, name string, input any) (map[string]any, error) {
+	tl, _ := p.GetTool(name)
+	raw, _ := json.Marshal(input)
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	err = json.Unmarshal(result.Output, &out)
+	return out, err
+}
+
+// newTestPack returns a pack over a three-dimensional "docs" index holding
+// four vectors.
+func newTestPack(t *testing.T) *pack.Pack {
+	t.Helper()
+	p := Pack(Config{DefaultIndex: "docs"})
+	if _, err := call(p, "vector_create_index", map[string]any{"name": "docs", "dimension": 3}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := call(p, "vector_upsert", map[string]any{"vectors": []map[string]any{
+		{"id": "a", "values": []float32{1, 0, 0}, "text": "alpha", "metadata": map[string]any{"lang": "go", "tier": "gold", "year": 2024}},
+		{"id": "b", "values": []float32{0.9, 0.1, 0}, "text": "beta", "metadata": map[string]any{"lang": "rust", "tier": "silver"}},
+		{"id": "c", "values": []float32{0, 1, 0}, "text": "gamma", "metadata": map[string]any{"lang": "go", "tier": "bronze"}},
+		{"id": "d", "values": []float32{0, 0, 1}, "text": "delta", "metadata": map[string]any{"lang": "go", "tier": "gold", "draft": true}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func matchIDs(out map[string]any) []string {
+	var ids []string
+	for _, m := range out["matches"].([]any) {
+		ids = append(ids, m.(map[string]any)["id"].(string))
+	}
+	return ids
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestUpsert(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t)
+
+	// Re-upserting replaces the vector.
+	out, err := call(p, "vector_upsert", map[string]any{"vectors": []map[string]any{
+		{"id": "a", "values": []float32{0, 0, 2}, "text": "alpha v2"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["upserted_count"] != 1.0 || out["index"] != "docs" {
+		t.Errorf("unexpected result: %v", out)
+	}
+	out, err = call(p, "vector_fetch", map[string]any{"ids": []string{"a", "zzz"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetched := out["vectors"].([]any)[0].(map[string]any)
+	if fetched["text"] != "alpha v2" || len(fetched["values"].([]any)) != 3 {
+		t.Errorf("unexpected vector: %v", fetched)
+	}
+	if missing := out["missing"].([]any); len(missing) != 1 || missing[0] != "zzz" {
+		t.Errorf("missing = %v, want [zzz]", missing)
+	}
+
+	// A batch with an invalid vector writes nothing.
+	_, err = call(p, "vector_upsert", map[string]any{"vectors": []map[string]any{
+		{"id": "e", "values": []float32{1, 1, 1}},
+		{"id": "f", "values": []float32{1, 1}},
+	}})
+	if !errors.Is(err, knowledge.ErrDimensionMismatch) {
+		t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+	}
+	out, err = call(p, "vector_describe_index", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["vector_count"] != 4.0 {
+		t.Errorf("vector_count = %v, want 4", out["vector_count"])
+	}
+
+	tests := []map[string]any{
+		{"vectors": []map[string]any{{"values": []float32{1, 0, 0}}}},
+		{"vectors": []map[string]any{{"id": "x", "values": []float32{1, 0, 0}, "metadata": map[string]any{"tags": []string{"a"}}}}},
+		{"vectors": []map[string]any{}},
+		{"index": "missing", "vectors": []map[string]any{{"id": "x", "values": []float32{1, 0, 0}}}},
+	}
+	for _, input := range tests {
+		if _, err := call(p, "vector_upsert", input); err == nil {
+			t.Errorf("%v: expected an error", input)
+		}
+	}
+	if _, err := call(Pack(Config{MaxBatch: 1}), "vector_upsert", map[string]any{"vectors": []map[string]any{
+		{"id": "x", "values": []float32{1}}, {"id": "y", "values": []float32{1}},
+	}}); err == nil {
+		t.Error("expected the batch limit to apply")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t)
+	tests := []struct {
+		name  string
+		input map[string]any
+		want  []string
+	}{
+		{"nearest first", map[string]any{"vector": []float32{1, 0, 0}, "top_k": 2}, []string{"a", "b"}},
+		{"by stored id", map[string]any{"id": "c", "top_k": 1}, []string{"c"}},
+		{"min score", map[string]any{"vector": []float32{1, 0, 0}, "min_score": 0.5}, []string{"a", "b"}},
+		{"equality filter", map[string]any{"vector": []float32{1, 0.5, 0.1}, "filter": map[string]any{"lang": "go"}}, []string{"a", "c", "d"}},
+		{"any-of filter", map[string]any{"vector": []float32{0, 1, 0}, "filter": map[string]any{"tier": []string{"silver", "bronze"}}}, []string{"c", "b"}},
+		{"combined filters", map[string]any{"vector": []float32{0, 0, 1}, "filter": map[string]any{"lang": "go", "tier": []string{"gold"}}, "top_k": 1}, []string{"d"}},
+		{"number filter", map[string]any{"vector": []float32{1, 0, 0}, "filter": map[string]any{"year": 2024}}, []string{"a"}},
+		{"bool filter", map[string]any{"vector": []float32{1, 0, 0}, "filter": map[string]any{"draft": true}}, []string{"d"}},
+		{"no filter match", map[string]any{"vector": []float32{1, 0, 0}, "filter": map[string]any{"lang": "zig"}}, nil},
+	}
+	for _, tt := range tests {
+		out, err := call(p, "vector_query", tt.input)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := matchIDs(out); !equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	out, err := call(p, "vector_query", map[string]any{"vector": []float32{1, 0, 0}, "top_k": 1, "include_values": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	match := out["matches"].([]any)[0].(map[string]any)
+	if match["score"].(float64) < 0.999 || match["values"] == nil || match["metadata"].(map[string]any)["tier"] != "gold" {
+		t.Errorf("unexpected match: %v", match)
+	}
+
+	if _, err := call(p, "vector_query", map[string]any{"vector": []float32{1, 0}}); !errors.Is(err, knowledge.ErrDimensionMismatch) {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if _, err := call(p, "vector_query", map[string]any{"vector": []float32{1, 0, 0}, "filter": map[string]any{"lang": map[string]any{"$ne": "go"}}}); err == nil {
+		t.Error("expected an unsupported filter error")
+	}
+	if _, err := call(p, "vector_query", map[string]any{"index": "missing", "vector": []float32{1, 0, 0}}); !errors.Is(err, ErrIndexNotFound) {
+		t.Errorf("expected ErrIndexNotFound, got %v", err)
+	}
+}
+
+// countingStore records the calls that scan a store.
+type countingStore struct {
+	*memory.KnowledgeStore
+	lists atomic.Int32
+}
+
+func (s *countingStore) List(ctx context.Context, filter knowledge.ListFilter) ([]*knowledge.Vector, error) {
+	s.lists.Add(1)
+	return s.KnowledgeStore.List(ctx, filter)
+}
+
+func TestQueryFilteredSearch(t *testing.T) {
+	t.Parallel()
+
+	vectors := []*knowledge.Vector{
+		{ID: "a", Embedding: []float32{1, 0, 0}, Metadata: map[string]string{"lang": "go", "tier": "gold"}},
+		{ID: "b", Embedding: []float32{0.9, 0.1, 0}, Metadata: map[string]string{"lang": "rust", "tier": "silver"}},
+		{ID: "c", Embedding: []float32{0, 1, 0}, Metadata: map[string]string{"lang": "go", "tier": "bronze"}},
+	}
+	filtered := &countingStore{KnowledgeStore: memory.NewKnowledgeStore(3)}
+	plain := memory.NewKnowledgeStore(3)
+	for _, v := range vectors {
+		_ = filtered.Upsert(context.Background(), v)
+		_ = plain.Upsert(context.Background(), v)
+	}
+	backend := NewMemoryBackend()
+	_ = backend.Attach(IndexSpec{Name: "filtered", Dimension: 3}, filtered)
+	// Hide SearchFiltered so queries fall back to scoring every match.
+	_ = backend.Attach(IndexSpec{Name: "plain", Dimension: 3}, struct{ knowledge.Store }{plain})
+	p := Pack(Config{Backend: backend})
+
+	for _, index := range []string{"filtered", "plain"} {
+		out, err := call(p, "vector_query", map[string]any{
+			"index":  index,
+			"vector": []float32{1, 0, 0},
+			"filter": map[string]any{"tier": []string{"silver", "bronze"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := matchIDs(out); !equal(got, []string{"b", "c"}) {
+			t.Errorf("%s: got %v, want [b c]", index, got)
+		}
+	}
+	if n := filtered.lists.Load(); n != 0 {
+		t.Errorf("expected the filtered searcher to be used, got %d list calls", n)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t)
+
+	// Absent IDs are skipped so retries are safe.
+	out, err := call(p, "vector_delete", map[string]any{"ids": []string{"a", "missing"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["deleted_count"] != 1.0 {
+		t.Errorf("deleted_count = %v, want 1", out["deleted_count"])
+	}
+
+	// With both, only the listed IDs matching the filter are deleted.
+	out, err = call(p, "vector_delete", map[string]any{"ids": []string{"b", "c"}, "filter": map[string]any{"lang": "go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["deleted_count"] != 1.0 {
+		t.Errorf("deleted_count = %v, want 1", out["deleted_count"])
+	}
+
+	out, err = call(p, "vector_delete", map[string]any{"filter": map[string]any{"tier": []string{"gold", "silver"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["deleted_count"] != 2.0 {
+		t.Errorf("deleted_count = %v, want 2", out["deleted_count"])
+	}
+
+	out, err = call(p, "vector_list", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["total"] != 0.0 {
+		t.Errorf("vectors left after deletes: %v", out["vectors"])
+	}
+
+	if _, err := call(p, "vector_delete", map[string]any{}); err == nil {
+		t.Error("expected an error without ids or filter")
+	}
+}
+
+func TestListAndIndexes(t *testing.T) {
+	t.Parallel()
+
+	p := newTestPack(t)
+
+	out, err := call(p, "vector_list", map[string]any{"limit": 2, "filter": map[string]any{"lang": "go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["total"] != 3.0 || out["count"] != 2.0 || out["next_offset"] != 2.0 {
+		t.Errorf("unexpected page: %v", out)
+	}
+	out, err = call(p, "vector_list", map[string]any{"prefix": "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["total"] != 1.0 {
+		t.Errorf("unexpected prefix listing: %v", out)
+	}
+
+	if _, err := call(p, "vector_create_index", map[string]any{"name": "docs", "dimension": 3}); !errors.Is(err, ErrIndexExists) {
+		t.Errorf("expected ErrIndexExists, got %v", err)
+	}
+	if _, err := call(p, "vector_create_index", map[string]any{"name": "Bad Name", "dimension": 3}); !errors.Is(err, ErrInvalidIndex) {
+		t.Errorf("expected ErrInvalidIndex, got %v", err)
+	}
+	if _, err := call(p, "vector_delete_index", map[string]any{"name": "docs"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := call(p, "vector_describe_index", map[string]any{}); !errors.Is(err, ErrIndexNotFound) {
+		t.Errorf("expected ErrIndexNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package plannerllm
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"sync"
+)
+
+// FakeProvider is a deterministic provider for tests. It replies with
+// scripted responses in order, then with the result of its responder,
+// and finally by echoing the last user message. Token usage counts
+// whitespace-separated words, and embeddings hash words into a fixed
+// number of dimensions so similar texts get similar vectors.
+type FakeProvider struct {
+	mu       sync.Mutex
+	script   []string
+	respond  func(CompletionRequest) (string, error)
+	dims     int
+	requests []CompletionRequest
+	embedded []EmbeddingRequest
+}
+
+// Ensure FakeProvider implements EmbeddingProvider.
+var _ EmbeddingProvider = (*FakeProvider)(nil)
+
+// FakeOption configures the fake provider.
+type FakeOption func(*FakeProvider)
+
+// WithScript queues responses returned in order, one per completion.
+func WithScript(responses ...string) FakeOption {
+	return func(f *FakeProvider) {
+		f.script = append(f.script, responses...)
+	}
+}
+
+// WithResponder computes responses once the script is exhausted.
+func WithResponder(respond func(CompletionRequest) (string, error)) FakeOption {
+	return func(f *FakeProvider) {
+		f.respond = respond
+	}
+}
+
+// WithEmbeddingDimensions sets the embedding size. Defaults to 64.
+func WithEmbeddingDimensions(dims int) FakeOption {
+	return func(f *FakeProvider) {
+		if dims > 0 {
+			f.dims = dims
+		}
+	}
+}
+
+// NewFakeProvider creates a deterministic fake provider.
+func NewFakeProvider(opts ...FakeOption) *FakeProvider {
+	f := &FakeProvider{dims: 64}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Name returns "fake".
+func (f *FakeProvider) Name() string {
+	return "fake"
+}
+
+// Complete returns the next scripted or computed response.
+func (f *FakeProvider) Complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return CompletionResponse{}, err
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	var content string
+	scripted := len(f.script) > 0
+	if scripted {
+		content, f.script = f.script[0], f.script[1:]
+	}
+	respond := f.respond
+	f.mu.Unlock()
+
+	if !scripted {
+		if respond != nil {
+			var err error
+			if content, err = respond(req); err != nil {
+				return CompletionResponse{}, err
+			}
+		} else {
+			content = lastUserMessage(req.Messages)
+		}
+	}
+
+	prompt := 0
+	for _, m := range req.Messages {
+		prompt += countWords(m.Content)
+	}
+	completion := countWords(content)
+	return CompletionResponse{
+		ID:      "fake",
+		Model:   f.model(req.Model),
+		Message: Message{Role: "assistant", Content: content},
+		Usage: Usage{
+			PromptTokens:     prompt,
+			CompletionTokens: completion,
+			TotalTokens:      prompt + completion,
+		},
+	}, nil
+}
+
+// Embed returns deterministic bag-of-words embeddings.
+func (f *FakeProvider) Embed(ctx context.Context, req EmbeddingRequest) (EmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return EmbeddingResponse{}, err
+	}
+	f.mu.Lock()
+	f.embedded = append(f.embedded, req)
+	f.mu.Unlock()
+
+	resp := EmbeddingResponse{Model: f.model(req.Model), Embeddings: make([][]float32, len(req.Input))}
+	for i, text := range req.Input {
+		vec := make([]float32, f.dims)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(word))
+			vec[h.Sum32()%uint32(f.dims)]++
+		}
+		var norm float64
+		for _, v := range vec {
+			norm += float64(v) * float64(v)
+		}
+		if norm > 0 {
+			scale := float32(1 / math.Sqrt(norm))
+			for j := range vec {
+				vec[j] *= scale
+			}
+		}
+		resp.Embeddings[i] = vec
+		resp.Usage.PromptTokens += countWords(text)
+	}
+	resp.Usage.TotalTokens = resp.Usage.PromptTokens
+	return resp, nil
+}
+
+// Requests returns the completion requests received so far.
+func (f *FakeProvider) Requests() []CompletionRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]CompletionRequest(nil), f.requests...)
+}
+
+// EmbeddingRequests returns the embedding requests received so far.
+func (f *FakeProvider) EmbeddingRequests() []EmbeddingRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]EmbeddingRequest(nil), f.embedded...)
+}
+
+func (f *FakeProvider) model(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return "fake"
+}
+
+func lastUserMessage(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// countWords is the fake token count: whitespace-separated words.
+func countWords(text string) int {
+	return len(strings.Fields(text))
+}
@@ -0,0 +1,116 @@
+package plannerllm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func userMessage(content string) []Message {
+	return []Message{{Role: "system", Content: "be brief"}, {Role: "user", Content: content}}
+}
+
+func TestFakeProviderComplete(t *testing.T) {
+	ctx := context.Background()
+	f := NewFakeProvider(
+		WithScript("first", "second reply"),
+		WithResponder(func(req CompletionRequest) (string, error) {
+			if req.Model == "broken" {
+				return "", errors.New("responder failed")
+			}
+			return "computed", nil
+		}),
+	)
+
+	want := []string{"first", "second reply", "computed"}
+	for i, content := range want {
+		resp, err := f.Complete(ctx, CompletionRequest{Messages: userMessage("hello there")})
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if resp.Message.Content != content || resp.Message.Role != "assistant" {
+			t.Errorf("call %d: got %q, want %q", i, resp.Message.Content, content)
+		}
+	}
+	if _, err := f.Complete(ctx, CompletionRequest{Model: "broken", Messages: userMessage("x")}); err == nil {
+		t.Error("expected the responder error")
+	}
+	if got := len(f.Requests()); got != 4 {
+		t.Errorf("recorded %d requests, want 4", got)
+	}
+}
+
+func TestFakeProviderEchoAndUsage(t *testing.T) {
+	f := NewFakeProvider()
+	resp, err := f.Complete(context.Background(), CompletionRequest{Messages: userMessage("one two three")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message.Content != "one two three" || resp.Model != "fake" {
+		t.Errorf("expected an echo from model fake, got %q from %q", resp.Message.Content, resp.Model)
+	}
+	// Prompt tokens count the words of every message.
+	if u := resp.Usage; u.PromptTokens != 5 || u.CompletionTokens != 3 || u.TotalTokens != 8 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.Complete(ctx, CompletionRequest{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, err := f.Embed(ctx, EmbeddingRequest{Input: []string{"x"}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func cosine(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
+
+func TestFakeProviderEmbed(t *testing.T) {
+	var provider Provider = NewFakeProvider(WithEmbeddingDimensions(32))
+	embedder, ok := provider.(EmbeddingProvider)
+	if !ok {
+		t.Fatal("FakeProvider does not implement EmbeddingProvider")
+	}
+
+	texts := []string{"the quick brown fox", "The quick brown fox", "a quick brown dog", "", "completely unrelated words"}
+	resp, err := embedder.Embed(context.Background(), EmbeddingRequest{Model: "embed-small", Input: texts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Model != "embed-small" || len(resp.Embeddings) != len(texts) {
+		t.Fatalf("unexpected response: model %q, %d embeddings", resp.Model, len(resp.Embeddings))
+	}
+	for i, vec := range resp.Embeddings {
+		if len(vec) != 32 {
+			t.Fatalf("embedding %d has %d dimensions, want 32", i, len(vec))
+		}
+		if norm := math.Sqrt(cosine(vec, vec)); texts[i] != "" && math.Abs(norm-1) > 1e-5 {
+			t.Errorf("embedding %d has norm %v, want 1", i, norm)
+		}
+	}
+
+	// Deterministic and case-insensitive; similar texts score higher.
+	if sim := cosine(resp.Embeddings[0], resp.Embeddings[1]); math.Abs(sim-1) > 1e-5 {
+		t.Errorf("identical texts have similarity %v, want 1", sim)
+	}
+	if similar, unrelated := cosine(resp.Embeddings[0], resp.Embeddings[2]), cosine(resp.Embeddings[0], resp.Embeddings[4]); similar <= unrelated {
+		t.Errorf("similar texts scored %v, unrelated %v", similar, unrelated)
+	}
+	if resp.Usage.PromptTokens != 15 || resp.Usage.TotalTokens != 15 {
+		t.Errorf("unexpected usage: %+v", resp.Usage)
+	}
+	if got := NewFakeProvider().EmbeddingRequests(); len(got) != 0 {
+		t.Errorf("new provider recorded %d embedding requests", len(got))
+	}
+	if got := provider.(*FakeProvider).EmbeddingRequests(); len(got) != 1 || len(got[0].Input) != len(texts) {
+		t.Errorf("unexpected recorded embedding requests: %v", got)
+	}
+}
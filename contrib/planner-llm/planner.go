@@ -47,6 +47,27 @@ type StreamingProvider interface {
 	CompleteStream(ctx context.Context, req CompletionRequest) (<-chan StreamChunk, error)
 }
 
+// EmbeddingProvider extends Provider with text embeddings.
+type EmbeddingProvider interface {
+	Provider
+
+	// Embed returns one embedding per input text, in order.
+	Embed(ctx context.Context, req EmbeddingRequest) (EmbeddingResponse, error)
+}
+
+// EmbeddingRequest represents an embedding request.
+type EmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// EmbeddingResponse represents an embedding response.
+type EmbeddingResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+	Usage      Usage       `json:"usage"`
+}
+
 // CompletionRequest represents a chat completion request.
 type CompletionRequest struct {
 	Model       string    `json:"model"`
@@ -25,6 +25,12 @@ var (
 	// ErrNoPendingQuestion indicates no pending question exists for human input.
 	ErrNoPendingQuestion = errors.New("run does not have a pending question")
 
+	// ErrAwaitingApproval indicates the run is paused awaiting approval of a tool call.
+	ErrAwaitingApproval = errors.New("run is awaiting approval")
+
+	// ErrNoPendingApproval indicates no tool call is awaiting approval.
+	ErrNoPendingApproval = errors.New("run does not have a pending approval")
+
 	// ErrInvalidHumanInput indicates the human input is not valid for the pending question.
 	ErrInvalidHumanInput = errors.New("invalid human input for pending question")
 )
@@ -23,6 +23,28 @@ type PendingQuestion struct {
 	AskedAt  time.Time `json:"asked_at"`
 }
 
+// PendingApproval represents a tool call awaiting asynchronous approval.
+type PendingApproval struct {
+	// TicketID identifies the approval request in the approval store.
+	TicketID string `json:"ticket_id"`
+	// ToolName is the tool whose call awaits approval.
+	ToolName string `json:"tool_name"`
+	// Input is the tool input.
+	Input json.RawMessage `json:"input,omitempty"`
+	// Reason is the planner's reason for the call.
+	Reason string `json:"reason,omitempty"`
+	// RequestedAt is when approval was requested.
+	RequestedAt time.Time `json:"requested_at"`
+	// ExpiresAt is when the request expires, if it does.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+// ToolCall records a tool call a run executed, whether or not it succeeded.
+type ToolCall struct {
+	ToolName string `json:"tool_name"`
+	State    State  `json:"state"`
+}
+
 // Run represents a single execution of the agent.
 // It is the aggregate root for the agent domain.
 type Run struct {
@@ -37,6 +59,13 @@ type Run struct {
 	Result          json.RawMessage  `json:"result,omitempty"`
 	Error           string           `json:"error,omitempty"`
 	PendingQuestion *PendingQuestion `json:"pending_question,omitempty"`
+	PendingApproval *PendingApproval `json:"pending_approval,omitempty"`
+	// PolicyVersion is the policy version the run executes under; zero
+	// when the engine's configured policy is not from a version store.
+	PolicyVersion int `json:"policy_version,omitempty"`
+	// ToolCalls lists the tool calls executed so far. Call-count
+	// constraints and rules read it, so limits hold across pauses.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // NewRun creates a new run with the given ID and initial state.
@@ -104,6 +133,11 @@ func (r *Run) AddEvidence(e Evidence) {
 	r.Evidence = append(r.Evidence, e)
 }
 
+// RecordToolCall records that the run executed a tool call.
+func (r *Run) RecordToolCall(toolName string, state State) {
+	r.ToolCalls = append(r.ToolCalls, ToolCall{ToolName: toolName, State: state})
+}
+
 // SetVar sets a variable in the run context.
 func (r *Run) SetVar(key string, value any) {
 	r.Vars[key] = value
@@ -147,3 +181,19 @@ func (r *Run) AskHuman(question string, options []string) {
 	}
 	r.Status = RunStatusPaused
 }
+
+// HasPendingApproval returns true if the run has a tool call awaiting approval.
+func (r *Run) HasPendingApproval() bool {
+	return r.PendingApproval != nil
+}
+
+// ClearPendingApproval removes the pending approval from the run.
+func (r *Run) ClearPendingApproval() {
+	r.PendingApproval = nil
+}
+
+// AwaitApproval sets a pending approval and pauses the run.
+func (r *Run) AwaitApproval(pending PendingApproval) {
+	r.PendingApproval = &pending
+	r.Status = RunStatusPaused
+}
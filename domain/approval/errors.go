@@ -0,0 +1,31 @@
+package approval
+
+import "errors"
+
+var (
+	// ErrTicketNotFound indicates the approval ticket was not found.
+	ErrTicketNotFound = errors.New("approval ticket not found")
+
+	// ErrTicketExists indicates a ticket with this ID already exists.
+	ErrTicketExists = errors.New("approval ticket already exists")
+
+	// ErrInvalidTicket indicates the ticket is invalid.
+	ErrInvalidTicket = errors.New("invalid approval ticket")
+
+	// ErrAlreadyDecided indicates the ticket is no longer pending.
+	ErrAlreadyDecided = errors.New("approval ticket already decided")
+
+	// ErrTicketExpired indicates the ticket expired before a decision.
+	ErrTicketExpired = errors.New("approval ticket expired")
+
+	// ErrTicketConflict indicates the ticket changed since it was read.
+	ErrTicketConflict = errors.New("approval ticket changed concurrently")
+
+	// ErrTicketConsumed indicates an approved ticket was already used to
+	// execute its tool call.
+	ErrTicketConsumed = errors.New("approval ticket already consumed")
+
+	// ErrTicketMismatch indicates a resumed tool call differs from the call
+	// the ticket approved.
+	ErrTicketMismatch = errors.New("approval ticket does not match the tool call")
+)
@@ -0,0 +1,65 @@
+package approval
+
+import "context"
+
+// Store persists approval tickets.
+type Store interface {
+	// Save persists a new ticket.
+	Save(ctx context.Context, ticket *Ticket) error
+
+	// Get retrieves a ticket by ID.
+	Get(ctx context.Context, id string) (*Ticket, error)
+
+	// Update updates an existing ticket. It fails with ErrTicketConflict
+	// when the stored ticket's Revision differs from the ticket's, and
+	// increments Revision on success.
+	Update(ctx context.Context, ticket *Ticket) error
+
+	// List returns tickets matching the filter, oldest first.
+	List(ctx context.Context, filter ListFilter) ([]*Ticket, error)
+
+	// Delete removes a ticket.
+	Delete(ctx context.Context, id string) error
+}
+
+// TicketReader looks up tickets. Approvers that queue requests, such as the
+// approval inbox, implement it so a resumed run replays the tool call
+// recorded on its ticket.
+type TicketReader interface {
+	// Get retrieves a ticket by ID.
+	Get(ctx context.Context, id string) (*Ticket, error)
+}
+
+// ListFilter filters ticket queries.
+type ListFilter struct {
+	// RunID filters by run.
+	RunID string
+
+	// ToolName filters by tool.
+	ToolName string
+
+	// Status filters by ticket status.
+	Status []Status
+
+	// Limit is the maximum number of results (0 = no limit).
+	Limit int
+}
+
+// Matches reports whether the ticket satisfies the filter.
+func (f ListFilter) Matches(t *Ticket) bool {
+	if f.RunID != "" && t.Request.RunID != f.RunID {
+		return false
+	}
+	if f.ToolName != "" && t.Request.ToolName != f.ToolName {
+		return false
+	}
+	if len(f.Status) > 0 {
+		for _, s := range f.Status {
+			if t.Status == s {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
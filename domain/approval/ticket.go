@@ -0,0 +1,195 @@
+// Package approval provides the asynchronous approval inbox domain: tickets
+// for tool calls awaiting a human decision, and their persistence.
+package approval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+)
+
+// Status is the status of an approval ticket.
+type Status string
+
+const (
+	// StatusPending means the ticket awaits a decision.
+	StatusPending Status = "pending"
+	// StatusApproved means the tool call was approved.
+	StatusApproved Status = "approved"
+	// StatusDenied means the tool call was denied.
+	StatusDenied Status = "denied"
+	// StatusExpired means no decision was made in time.
+	StatusExpired Status = "expired"
+)
+
+// Ticket is a queued approval request. It outlives the process that
+// created it, so a paused run can be resumed once a decision is recorded.
+type Ticket struct {
+	// ID is the unique identifier.
+	ID string `json:"id"`
+
+	// Request is the approval request.
+	Request policy.ApprovalRequest `json:"request"`
+
+	// State is the agent state the tool call was made in.
+	State agent.State `json:"state,omitempty"`
+
+	// Status is the current status.
+	Status Status `json:"status"`
+
+	// Response is the decision, once made.
+	Response *policy.ApprovalResponse `json:"response,omitempty"`
+
+	// CreatedAt is when the ticket was created.
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt is when the ticket expires; zero means never.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+
+	// EscalateAt is when an undecided ticket is escalated; zero means never.
+	EscalateAt time.Time `json:"escalate_at,omitempty"`
+
+	// EscalatedAt is when the ticket was escalated.
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+
+	// ConsumedAt is when the approved tool call was executed. An approval
+	// is consumed once, so resuming a stale copy of the run cannot execute
+	// the call again.
+	ConsumedAt *time.Time `json:"consumed_at,omitempty"`
+
+	// Revision counts stored updates. Stores reject an update made from a
+	// stale copy, so concurrent deciders cannot overwrite each other.
+	Revision int `json:"revision"`
+}
+
+// NewTicket creates a pending ticket for the request.
+func NewTicket(req policy.ApprovalRequest) *Ticket {
+	return &Ticket{
+		ID:        uuid.New().String(),
+		Request:   req,
+		Status:    StatusPending,
+		CreatedAt: time.Now(),
+	}
+}
+
+// IsPending returns true if the ticket awaits a decision.
+func (t *Ticket) IsPending() bool {
+	return t.Status == StatusPending
+}
+
+// IsExpired returns true if the ticket is past its expiry at the given time.
+func (t *Ticket) IsExpired(now time.Time) bool {
+	return t.Status == StatusExpired || (!t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt))
+}
+
+// NeedsEscalation returns true if the pending ticket is due for escalation
+// and has not been escalated yet.
+func (t *Ticket) NeedsEscalation(now time.Time) bool {
+	return t.IsPending() && t.EscalatedAt == nil &&
+		!t.EscalateAt.IsZero() && !now.Before(t.EscalateAt)
+}
+
+// Decide records the decision on a pending ticket.
+func (t *Ticket) Decide(resp policy.ApprovalResponse) error {
+	if !t.IsPending() {
+		return fmt.Errorf("%w: ticket %s is %s", ErrAlreadyDecided, t.ID, t.Status)
+	}
+	if resp.Timestamp.IsZero() {
+		resp.Timestamp = time.Now()
+	}
+	if t.IsExpired(resp.Timestamp) {
+		t.Expire()
+		return fmt.Errorf("%w: ticket %s", ErrTicketExpired, t.ID)
+	}
+	t.Response = &resp
+	t.Status = StatusDenied
+	if resp.Approved {
+		t.Status = StatusApproved
+	}
+	return nil
+}
+
+// Expire marks a pending ticket as expired, recording a denial.
+func (t *Ticket) Expire() {
+	if !t.IsPending() {
+		return
+	}
+	t.Status = StatusExpired
+	t.Response = &policy.ApprovalResponse{
+		Approved:  false,
+		Reason:    "approval request expired",
+		Timestamp: time.Now(),
+	}
+}
+
+// Consume marks an approved ticket as used by its tool call.
+func (t *Ticket) Consume(now time.Time) error {
+	if t.Status != StatusApproved {
+		return fmt.Errorf("%w: ticket %s is %s", ErrAlreadyDecided, t.ID, t.Status)
+	}
+	if t.ConsumedAt != nil {
+		return fmt.Errorf("%w: ticket %s", ErrTicketConsumed, t.ID)
+	}
+	t.ConsumedAt = &now
+	return nil
+}
+
+// Matches reports whether the request is the tool call the ticket was
+// opened for: same run, tool and input.
+func (t *Ticket) Matches(req policy.ApprovalRequest) bool {
+	return t.Request.RunID == req.RunID && t.Request.ToolName == req.ToolName &&
+		jsonEqual(t.Request.Input, req.Input)
+}
+
+// jsonEqual compares two JSON documents ignoring insignificant whitespace.
+func jsonEqual(a, b json.RawMessage) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+// Escalate marks the ticket as escalated.
+func (t *Ticket) Escalate(now time.Time) {
+	t.EscalatedAt = &now
+}
+
+// PendingError is returned by approvers that queue a request instead of
+// deciding it. It unwraps to agent.ErrAwaitingApproval, so the engine
+// pauses the run until the ticket is decided.
+type PendingError struct {
+	Ticket *Ticket
+}
+
+// Error implements error.
+func (e *PendingError) Error() string {
+	return fmt.Sprintf("approval of %s pending (ticket %s)", e.Ticket.Request.ToolName, e.Ticket.ID)
+}
+
+// Unwrap returns agent.ErrAwaitingApproval.
+func (e *PendingError) Unwrap() error {
+	return agent.ErrAwaitingApproval
+}
+
+type ticketKey struct{}
+
+// WithTicketID returns a context carrying the ticket a resumed tool call
+// was approved under, so queueing approvers return its decision instead of
+// opening a new ticket.
+func WithTicketID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ticketKey{}, id)
+}
+
+// TicketIDFromContext returns the ticket ID carried by the context.
+func TicketIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ticketKey{}).(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,83 @@
+package approval
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+)
+
+func TestTicket_Decide(t *testing.T) {
+	ticket := NewTicket(policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"})
+	if !ticket.IsPending() || ticket.ID == "" {
+		t.Fatalf("expected a pending ticket with an ID, got %+v", ticket)
+	}
+
+	if err := ticket.Decide(policy.ApprovalResponse{Approved: true, Approver: "alice"}); err != nil {
+		t.Fatalf("Decide() error = %v", err)
+	}
+	if ticket.Status != StatusApproved || ticket.Response.Approver != "alice" || ticket.Response.Timestamp.IsZero() {
+		t.Errorf("unexpected decided ticket: %+v", ticket)
+	}
+
+	err := ticket.Decide(policy.ApprovalResponse{Approved: false})
+	if !errors.Is(err, ErrAlreadyDecided) {
+		t.Errorf("expected ErrAlreadyDecided, got %v", err)
+	}
+}
+
+func TestTicket_Expiry(t *testing.T) {
+	ticket := NewTicket(policy.ApprovalRequest{ToolName: "deploy"})
+	ticket.ExpiresAt = time.Now().Add(-time.Second)
+
+	err := ticket.Decide(policy.ApprovalResponse{Approved: true, Approver: "alice"})
+	if !errors.Is(err, ErrTicketExpired) {
+		t.Fatalf("expected ErrTicketExpired, got %v", err)
+	}
+	if ticket.Status != StatusExpired || ticket.Response == nil || ticket.Response.Approved {
+		t.Errorf("expected an expired denial, got %+v", ticket)
+	}
+}
+
+func TestTicket_NeedsEscalation(t *testing.T) {
+	now := time.Now()
+	ticket := NewTicket(policy.ApprovalRequest{ToolName: "deploy"})
+	if ticket.NeedsEscalation(now) {
+		t.Error("ticket without EscalateAt should not escalate")
+	}
+
+	ticket.EscalateAt = now.Add(-time.Minute)
+	if !ticket.NeedsEscalation(now) {
+		t.Error("overdue ticket should escalate")
+	}
+	ticket.Escalate(now)
+	if ticket.NeedsEscalation(now) {
+		t.Error("escalated ticket should not escalate again")
+	}
+}
+
+func TestPendingError(t *testing.T) {
+	ticket := NewTicket(policy.ApprovalRequest{ToolName: "deploy"})
+	var err error = &PendingError{Ticket: ticket}
+
+	if !errors.Is(err, agent.ErrAwaitingApproval) {
+		t.Error("PendingError should unwrap to agent.ErrAwaitingApproval")
+	}
+	var pending *PendingError
+	if !errors.As(err, &pending) || pending.Ticket.ID != ticket.ID {
+		t.Error("errors.As should recover the ticket")
+	}
+}
+
+func TestTicketIDFromContext(t *testing.T) {
+	if _, ok := TicketIDFromContext(context.Background()); ok {
+		t.Error("expected no ticket in background context")
+	}
+	id, ok := TicketIDFromContext(WithTicketID(context.Background(), "t-1"))
+	if !ok || id != "t-1" {
+		t.Errorf("TicketIDFromContext() = %q, %v", id, ok)
+	}
+}
@@ -120,16 +120,37 @@ type PolicyConfig struct {
 	Transitions []TransitionConfig `json:"transitions,omitempty" yaml:"transitions,omitempty"`
 	// RateLimit configures rate limiting.
 	RateLimit RateLimitConfig `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	// Rules are declarative policy rules, evaluated in order.
+	Rules []RuleConfig `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+// RuleConfig defines a declarative policy rule.
+type RuleConfig struct {
+	// Name identifies the rule.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Rule is the rule source: "<condition> => <effect>".
+	Rule string `json:"rule" yaml:"rule"`
+	// Reason explains the effect.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
 }
 
 // ApprovalConfig configures approval behavior.
+//
+// When any setting other than Mode is given, the settings replace the
+// default of requiring approval for destructive and high-risk tools, so
+// RequireForDestructive must be set explicitly to keep it.
 type ApprovalConfig struct {
 	// Mode is the approval mode (auto, manual, none).
 	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
 	// RequireForDestructive requires approval for destructive tools.
 	RequireForDestructive bool `json:"require_for_destructive,omitempty" yaml:"require_for_destructive,omitempty"`
-	// RequireForRiskLevel requires approval above this risk level.
+	// RequireForRiskLevel requires approval at or above this risk level.
+	// Defaults to high.
 	RequireForRiskLevel string `json:"require_for_risk_level,omitempty" yaml:"require_for_risk_level,omitempty"`
+	// RequireForTools lists tools that always require approval.
+	RequireForTools []string `json:"require_for_tools,omitempty" yaml:"require_for_tools,omitempty"`
+	// ExemptTools lists tools that never require approval.
+	ExemptTools []string `json:"exempt_tools,omitempty" yaml:"exempt_tools,omitempty"`
 }
 
 // TransitionConfig defines a state transition.
@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"strings"
+
+	"github.com/felixgeelhaar/agent-go/domain/policy"
 )
 
 // ValidationError represents a configuration validation error.
@@ -209,6 +211,16 @@ func (v *Validator) validatePolicy(config *AgentConfig) {
 		}
 	}
 
+	// Validate rules
+	for i, rule := range config.Policy.Rules {
+		path := fmt.Sprintf("policy.rules[%d]", i)
+		if rule.Rule == "" {
+			v.addError(path+".rule", "rule is required")
+		} else if _, err := policy.ParseRule(rule.Rule); err != nil {
+			v.addError(path+".rule", err.Error())
+		}
+	}
+
 	// Validate rate limit
 	if config.Policy.RateLimit.Enabled {
 		if config.Policy.RateLimit.Rate <= 0 {
@@ -50,8 +50,9 @@ const (
 
 // RunStartedPayload contains data for run.started events.
 type RunStartedPayload struct {
-	Goal string            `json:"goal"`
-	Vars map[string]any    `json:"vars,omitempty"`
+	Goal          string         `json:"goal"`
+	Vars          map[string]any `json:"vars,omitempty"`
+	PolicyVersion int            `json:"policy_version,omitempty"`
 }
 
 // RunCompletedPayload contains data for run.completed events.
@@ -115,9 +116,10 @@ type ApprovalRequestedPayload struct {
 
 // ApprovalResultPayload contains data for approval.granted/denied events.
 type ApprovalResultPayload struct {
-	ToolName string `json:"tool_name"`
-	Approver string `json:"approver"`
-	Reason   string `json:"reason,omitempty"`
+	ToolName      string          `json:"tool_name"`
+	Approver      string          `json:"approver"`
+	Reason        string          `json:"reason,omitempty"`
+	ModifiedInput json.RawMessage `json:"modified_input,omitempty"`
 }
 
 // BudgetConsumedPayload contains data for budget.consumed events.
@@ -68,6 +68,13 @@ type StatsProvider interface {
 	Stats(ctx context.Context) (Stats, error)
 }
 
+// FilteredSearcher is an optional interface for stores that can restrict
+// similarity search to vectors matching a filter. The filter's Limit and
+// Offset are ignored; topK bounds the results.
+type FilteredSearcher interface {
+	SearchFiltered(ctx context.Context, embedding []float32, topK int, filter ListFilter) ([]SearchResult, error)
+}
+
 // BatchStore is an optional interface for stores that support batch operations.
 type BatchStore interface {
 	Store
@@ -22,10 +22,13 @@ const (
 	EntryToolError       EntryType = "tool_error"
 	EntryApprovalRequest EntryType = "approval_request"
 	EntryApprovalResult  EntryType = "approval_result"
+	EntryApprovalVote    EntryType = "approval_vote"
+	EntryApprovalInputModified EntryType = "approval_input_modified"
 	EntryHumanInputRequest  EntryType = "human_input_request"
 	EntryHumanInputResponse EntryType = "human_input_response"
 	EntryBudgetConsumed  EntryType = "budget_consumed"
 	EntryBudgetExhausted EntryType = "budget_exhausted"
+	EntryConstraintViolation EntryType = "constraint_violation"
 )
 
 // Entry represents a single record in the ledger.
@@ -89,6 +92,37 @@ type ApprovalResultDetails struct {
 	Reason   string `json:"reason,omitempty"`
 }
 
+// ApprovalVoteDetails contains details for an individual approver's vote
+// within a multi-party approval.
+type ApprovalVoteDetails struct {
+	ToolName      string `json:"tool_name"`
+	Group         string `json:"group"`
+	Approver      string `json:"approver,omitempty"`
+	Approved      bool   `json:"approved"`
+	Justification string `json:"justification,omitempty"`
+	Counted       bool   `json:"counted"`
+	Rejected      string `json:"rejected,omitempty"`
+}
+
+// ApprovalInputDetails contains details for tool input modified by an
+// approver.
+type ApprovalInputDetails struct {
+	ToolName      string          `json:"tool_name"`
+	Approver      string          `json:"approver,omitempty"`
+	OriginalInput json.RawMessage `json:"original_input,omitempty"`
+	ApprovedInput json.RawMessage `json:"approved_input"`
+	Changes       []InputChange   `json:"changes"`
+}
+
+// InputChange describes one changed value between the original and the
+// approved input. Path is a JSON Pointer; Before or After is empty when the
+// value was added or removed.
+type InputChange struct {
+	Path   string          `json:"path"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
 // BudgetDetails contains details for budget entries.
 type BudgetDetails struct {
 	BudgetName string `json:"budget_name"`
@@ -96,6 +130,13 @@ type BudgetDetails struct {
 	Remaining  int    `json:"remaining"`
 }
 
+// ConstraintViolationDetails contains details for constraint violation entries.
+type ConstraintViolationDetails struct {
+	ToolName string      `json:"tool_name,omitempty"`
+	ToState  agent.State `json:"to_state,omitempty"`
+	Reason   string      `json:"reason"`
+}
+
 // HumanInputRequestDetails contains details for human input request entries.
 type HumanInputRequestDetails struct {
 	Question string   `json:"question"`
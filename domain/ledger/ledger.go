@@ -10,9 +10,10 @@ import (
 
 // Ledger provides an append-only record of all actions during a run.
 type Ledger struct {
-	runID   string
-	entries []Entry
-	mu      sync.RWMutex
+	runID     string
+	entries   []Entry
+	observers []func(Entry)
+	mu        sync.RWMutex
 }
 
 // New creates a new ledger for the given run.
@@ -26,7 +27,6 @@ func New(runID string) *Ledger {
 // Append adds an entry to the ledger.
 func (l *Ledger) Append(entry Entry) {
 	l.mu.Lock()
-	defer l.mu.Unlock()
 
 	entry.RunID = l.runID
 	if entry.Timestamp.IsZero() {
@@ -37,6 +37,20 @@ func (l *Ledger) Append(entry Entry) {
 	}
 
 	l.entries = append(l.entries, entry)
+	observers := l.observers
+	l.mu.Unlock()
+
+	for _, fn := range observers {
+		fn(entry)
+	}
+}
+
+// Observe registers fn to be called with every entry appended from now on,
+// once the entry is recorded.
+func (l *Ledger) Observe(fn func(Entry)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observers = append(l.observers[:len(l.observers):len(l.observers)], fn)
 }
 
 // Entries returns a copy of all entries.
@@ -185,6 +199,16 @@ func (l *Ledger) RecordApprovalResult(state agent.State, toolName string, approv
 	}))
 }
 
+// RecordApprovalVote records one approver's vote in a multi-party approval.
+func (l *Ledger) RecordApprovalVote(state agent.State, details ApprovalVoteDetails) {
+	l.Append(NewEntry(EntryApprovalVote, l.runID, state, details))
+}
+
+// RecordApprovalInputModified records tool input changed by an approver.
+func (l *Ledger) RecordApprovalInputModified(state agent.State, details ApprovalInputDetails) {
+	l.Append(NewEntry(EntryApprovalInputModified, l.runID, state, details))
+}
+
 // RecordBudgetConsumed records budget consumption.
 func (l *Ledger) RecordBudgetConsumed(state agent.State, budgetName string, amount, remaining int) {
 	l.Append(NewEntry(EntryBudgetConsumed, l.runID, state, BudgetDetails{
@@ -202,6 +226,16 @@ func (l *Ledger) RecordBudgetExhausted(state agent.State, budgetName string) {
 	}))
 }
 
+// RecordConstraintViolation records a tool call or transition denied by a
+// policy constraint. Exactly one of toolName and toState is set.
+func (l *Ledger) RecordConstraintViolation(state agent.State, toolName string, toState agent.State, reason string) {
+	l.Append(NewEntry(EntryConstraintViolation, l.runID, state, ConstraintViolationDetails{
+		ToolName: toolName,
+		ToState:  toState,
+		Reason:   reason,
+	}))
+}
+
 // RecordHumanInputRequest records a request for human input.
 func (l *Ledger) RecordHumanInputRequest(state agent.State, question string, options []string) {
 	l.Append(NewEntry(EntryHumanInputRequest, l.runID, state, HumanInputRequestDetails{
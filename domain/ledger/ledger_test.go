@@ -598,3 +598,29 @@ func TestNoOpPublisher(t *testing.T) {
 		t.Errorf("Publish() error = %v, want nil", err)
 	}
 }
+
+func TestLedger_Observe(t *testing.T) {
+	l := ledger.New("run-123")
+	l.RecordRunStarted("before observing")
+
+	var observed []ledger.Entry
+	l.Observe(func(e ledger.Entry) {
+		// Observers may read the ledger: the entry is already recorded
+		if l.LastEntry().ID != e.ID {
+			t.Errorf("entry %s observed before it was recorded", e.ID)
+		}
+		observed = append(observed, e)
+	})
+	l.RecordTransition(agent.StateIntake, agent.StateExplore, "start")
+	l.RecordToolCall(agent.StateExplore, "read_file", nil)
+
+	if len(observed) != 2 {
+		t.Fatalf("expected 2 observed entries, got %d", len(observed))
+	}
+	if observed[0].Type != ledger.EntryStateTransition || observed[1].Type != ledger.EntryToolCall {
+		t.Errorf("unexpected entries: %s, %s", observed[0].Type, observed[1].Type)
+	}
+	if observed[0].RunID != "run-123" || observed[0].ID == "" || observed[0].Timestamp.IsZero() {
+		t.Errorf("expected a complete entry, got %+v", observed[0])
+	}
+}
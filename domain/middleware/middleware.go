@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/ledger"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
@@ -33,6 +35,13 @@ type ExecutionContext struct {
 	Budget BudgetView
 	// Vars contains shared variables for the run.
 	Vars map[string]any
+	// EvidenceCount is the number of evidence items gathered so far.
+	EvidenceCount int
+	// History lists the tool calls completed earlier in the run.
+	History []policy.ToolCallRecord
+	// Ledger is the run's audit ledger, if any. Middleware may record
+	// entries in it, such as approval requests and results.
+	Ledger *ledger.Ledger
 }
 
 // Handler executes a tool and returns its result.
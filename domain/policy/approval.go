@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
 // ApprovalRequest contains information for an approval decision.
@@ -13,6 +15,7 @@ type ApprovalRequest struct {
 	Input     json.RawMessage `json:"input"`
 	Reason    string          `json:"reason"`
 	RiskLevel string          `json:"risk_level"`
+	Requester string          `json:"requester,omitempty"`
 	Timestamp time.Time       `json:"timestamp"`
 }
 
@@ -22,6 +25,14 @@ type ApprovalResponse struct {
 	Approver  string    `json:"approver,omitempty"`
 	Reason    string    `json:"reason,omitempty"`
 	Timestamp time.Time `json:"timestamp"`
+
+	// ModifiedInput, when set on an approval, replaces the tool input. It
+	// lets approvers narrow a call, such as lowering a scale count, instead
+	// of denying it. The input is validated against the tool schema again.
+	ModifiedInput json.RawMessage `json:"modified_input,omitempty"`
+
+	// Votes lists the individual decisions behind a multi-party approval.
+	Votes []ApprovalVote `json:"votes,omitempty"`
 }
 
 // Approver is the interface for approval handlers.
@@ -30,6 +41,21 @@ type Approver interface {
 	Approve(ctx context.Context, req ApprovalRequest) (ApprovalResponse, error)
 }
 
+type requesterKey struct{}
+
+// WithRequester returns a context identifying who started the run. The
+// approval middleware copies it into ApprovalRequest.Requester, so
+// approvers can enforce separation of duties.
+func WithRequester(ctx context.Context, requester string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, requester)
+}
+
+// RequesterFromContext returns the requester carried by the context.
+func RequesterFromContext(ctx context.Context) (string, bool) {
+	requester, ok := ctx.Value(requesterKey{}).(string)
+	return requester, ok && requester != ""
+}
+
 // AutoApprover automatically approves all requests.
 type AutoApprover struct {
 	approverName string
@@ -82,6 +108,10 @@ type ApprovalPolicy struct {
 
 	// ExemptTools lists tools that never require approval.
 	ExemptTools []string
+
+	// RiskThreshold, when above RiskNone, requires approval for tools at or
+	// above this risk level.
+	RiskThreshold tool.RiskLevel
 }
 
 // DefaultApprovalPolicy returns a policy requiring approval for destructive actions.
@@ -92,14 +122,38 @@ func DefaultApprovalPolicy() ApprovalPolicy {
 	}
 }
 
-// RequiresApproval checks if the given tool requires approval under this policy.
-func (p ApprovalPolicy) RequiresApproval(toolName string, isDestructive, isHighRisk bool) bool {
-	// Check exemptions first
+// Requires reports whether a call to the named tool requires approval,
+// given its annotations. Exempt tools never require approval; tools
+// explicitly annotated with RequiresApproval always do.
+func (p ApprovalPolicy) Requires(toolName string, a tool.Annotations) bool {
+	if p.IsExempt(toolName) {
+		return false
+	}
+	if a.RequiresApproval {
+		return true
+	}
+	if p.RiskThreshold > tool.RiskNone && a.RiskLevel >= p.RiskThreshold {
+		return true
+	}
+	return p.RequiresApproval(toolName, a.Destructive, a.RiskLevel >= tool.RiskHigh)
+}
+
+// IsExempt checks if the given tool is exempt from approval.
+func (p ApprovalPolicy) IsExempt(toolName string) bool {
 	for _, exempt := range p.ExemptTools {
 		if exempt == toolName {
-			return false
+			return true
 		}
 	}
+	return false
+}
+
+// RequiresApproval checks if the given tool requires approval under this policy.
+func (p ApprovalPolicy) RequiresApproval(toolName string, isDestructive, isHighRisk bool) bool {
+	// Check exemptions first
+	if p.IsExempt(toolName) {
+		return false
+	}
 
 	// Check explicit requirements
 	for _, required := range p.RequireForTools {
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
 func TestAutoApprover(t *testing.T) {
@@ -212,6 +213,41 @@ func TestApprovalPolicy_RequiresApproval(t *testing.T) {
 	}
 }
 
+func TestApprovalPolicy_Requires(t *testing.T) {
+	t.Parallel()
+
+	p := policy.ApprovalPolicy{
+		RequireForDestructive: true,
+		RequireForTools:       []string{"send_email"},
+		ExemptTools:           []string{"cleanup"},
+		RiskThreshold:         tool.RiskMedium,
+	}
+
+	tests := []struct {
+		name        string
+		toolName    string
+		annotations tool.Annotations
+		want        bool
+	}{
+		{"read-only tool", "read_file", tool.Annotations{ReadOnly: true}, false},
+		{"listed tool", "send_email", tool.Annotations{}, true},
+		{"annotated tool", "deploy", tool.Annotations{RequiresApproval: true}, true},
+		{"destructive tool", "delete_file", tool.Annotations{Destructive: true}, true},
+		{"at risk threshold", "scale", tool.Annotations{RiskLevel: tool.RiskMedium}, true},
+		{"below risk threshold", "list", tool.Annotations{RiskLevel: tool.RiskLow}, false},
+		{"exempt tool", "cleanup", tool.Annotations{Destructive: true, RequiresApproval: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.Requires(tt.toolName, tt.annotations); got != tt.want {
+				t.Errorf("Requires(%s) = %v, want %v", tt.toolName, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestApprovalRequest_Fields(t *testing.T) {
 	t.Parallel()
 
@@ -2,6 +2,7 @@
 package policy
 
 import (
+	"context"
 	"sync"
 )
 
@@ -19,6 +20,21 @@ type BudgetSnapshot struct {
 	Remaining map[string]int `json:"remaining"`
 }
 
+type budgetKey struct{}
+
+// WithBudget returns a context carrying the run's budget. The engine sets
+// it for every tool call, so tools that spend metered resources, such as
+// LLM tokens, can charge the run they execute in.
+func WithBudget(ctx context.Context, b *Budget) context.Context {
+	return context.WithValue(ctx, budgetKey{}, b)
+}
+
+// BudgetFromContext returns the budget carried by the context.
+func BudgetFromContext(ctx context.Context) (*Budget, bool) {
+	b, ok := ctx.Value(budgetKey{}).(*Budget)
+	return b, ok && b != nil
+}
+
 // NewBudget creates a budget with the given limits.
 func NewBudget(limits map[string]int) *Budget {
 	b := &Budget{
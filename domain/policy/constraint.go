@@ -1,7 +1,10 @@
 package policy
 
 import (
+	"encoding/json"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
 // ToolEligibility defines which tools are allowed in which states.
@@ -192,15 +195,95 @@ func DefaultTransitions() *StateTransitions {
 }
 
 // Constraint is a generic policy constraint that can be evaluated.
+//
+// The engine evaluates registered constraints before every tool call and
+// every planner-initiated transition. A constraint that returns false
+// denies the action; the returned string explains why and is recorded in
+// the ledger.
 type Constraint interface {
 	// Evaluate checks if the constraint is satisfied.
 	Evaluate(ctx ConstraintContext) (bool, string)
 }
 
+// ConstraintFunc adapts an ordinary function to the Constraint interface.
+type ConstraintFunc func(ctx ConstraintContext) (bool, string)
+
+// Evaluate calls f(ctx).
+func (f ConstraintFunc) Evaluate(ctx ConstraintContext) (bool, string) {
+	return f(ctx)
+}
+
 // ConstraintContext provides context for constraint evaluation.
+//
+// For tool calls ToolName, Input and Annotations describe the call and
+// ToState is empty. For transitions ToState is the target state and the
+// tool fields are empty.
 type ConstraintContext struct {
 	RunID        string
 	CurrentState agent.State
 	ToolName     string
 	Budget       *Budget
+
+	// Input is the JSON input of the tool call being evaluated.
+	Input json.RawMessage
+
+	// Annotations are the annotations of the tool being called.
+	Annotations tool.Annotations
+
+	// ToState is the target of the transition being evaluated.
+	ToState agent.State
+
+	// Vars contains the run's shared variables. Constraints must not
+	// modify it.
+	Vars map[string]any
+
+	// EvidenceCount is the number of evidence items gathered so far.
+	EvidenceCount int
+
+	// History lists the tool calls completed earlier in the run, oldest
+	// first.
+	History []ToolCallRecord
+}
+
+// ToolCallRecord describes a tool call executed during a run, whether or
+// not it succeeded.
+type ToolCallRecord struct {
+	ToolName string
+	State    agent.State
+}
+
+// IsTransition reports whether the context describes a transition rather
+// than a tool call.
+func (c ConstraintContext) IsTransition() bool {
+	return c.ToState != ""
+}
+
+// CallCount returns how many completed calls in the history match toolName
+// and state. An empty toolName or state matches any.
+func (c ConstraintContext) CallCount(toolName string, state agent.State) int {
+	n := 0
+	for _, call := range c.History {
+		if (toolName == "" || call.ToolName == toolName) && (state == "" || call.State == state) {
+			n++
+		}
+	}
+	return n
+}
+
+// EvaluateConstraints evaluates constraints in order and stops at the first
+// one that is not satisfied, returning its reason. It returns true if every
+// constraint is satisfied.
+func EvaluateConstraints(ctx ConstraintContext, constraints ...Constraint) (bool, string) {
+	for _, c := range constraints {
+		if c == nil {
+			continue
+		}
+		if ok, reason := c.Evaluate(ctx); !ok {
+			if reason == "" {
+				reason = "denied by constraint"
+			}
+			return false, reason
+		}
+	}
+	return true, ""
 }
@@ -334,3 +334,61 @@ func TestDefaultTransitions_EveryStateReachesFailed(t *testing.T) {
 	}
 }
 
+
+func TestEvaluateConstraints(t *testing.T) {
+	t.Parallel()
+
+	history := []ToolCallRecord{
+		{ToolName: "search", State: agent.StateExplore},
+		{ToolName: "search", State: agent.StateExplore},
+		{ToolName: "rollback", State: agent.StateAct},
+	}
+	call := func(state agent.State, toolName string) ConstraintContext {
+		return ConstraintContext{CurrentState: state, ToolName: toolName, History: history}
+	}
+	transition := func(from, to agent.State) ConstraintContext {
+		return ConstraintContext{CurrentState: from, ToState: to, History: history}
+	}
+
+	tests := []struct {
+		name       string
+		constraint Constraint
+		ctx        ConstraintContext
+		allowed    bool
+	}{
+		{"max calls reached", MaxToolCalls{Tool: "search", Max: 2}, call(agent.StateExplore, "search"), false},
+		{"max calls remaining", MaxToolCalls{Tool: "search", Max: 3}, call(agent.StateExplore, "search"), true},
+		{"max calls other tool", MaxToolCalls{Tool: "search", Max: 0}, call(agent.StateExplore, "fetch"), true},
+		{"max calls ignores transitions", MaxToolCalls{Tool: "search", Max: 0}, transition(agent.StateExplore, agent.StateDecide), true},
+		{"calls before met", RequireCallsBefore{State: agent.StateAct, After: agent.StateExplore, Calls: 2}, transition(agent.StateDecide, agent.StateAct), true},
+		{"calls before transition", RequireCallsBefore{State: agent.StateAct, After: agent.StateExplore, Calls: 3}, transition(agent.StateDecide, agent.StateAct), false},
+		{"calls before tool in state", RequireCallsBefore{State: agent.StateAct, After: agent.StateExplore, Calls: 3}, call(agent.StateAct, "deploy"), false},
+		{"calls before other state", RequireCallsBefore{State: agent.StateAct, After: agent.StateExplore, Calls: 3}, transition(agent.StateExplore, agent.StateDecide), true},
+		{"forbid after called", ForbidToolAfter{Tool: "deploy", After: "rollback"}, call(agent.StateAct, "deploy"), false},
+		{"forbid after not called", ForbidToolAfter{Tool: "deploy", After: "migrate"}, call(agent.StateAct, "deploy"), true},
+		{"func", ConstraintFunc(func(ctx ConstraintContext) (bool, string) { return ctx.EvidenceCount > 0, "" }), call(agent.StateAct, "deploy"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ok, reason := EvaluateConstraints(tt.ctx, tt.constraint)
+			if ok != tt.allowed {
+				t.Fatalf("EvaluateConstraints() = %v (%s), want %v", ok, reason, tt.allowed)
+			}
+			if !ok && reason == "" {
+				t.Error("expected a reason for the denial")
+			}
+		})
+	}
+
+	// The first unsatisfied constraint provides the reason.
+	ok, reason := EvaluateConstraints(call(agent.StateAct, "deploy"),
+		nil,
+		MaxToolCalls{Tool: "deploy", Max: 1},
+		ForbidToolAfter{Tool: "deploy", After: "rollback"},
+		MaxToolCalls{Tool: "deploy", Max: 0},
+	)
+	if ok || reason != "tool deploy may not be called after rollback" {
+		t.Errorf("EvaluateConstraints() = %v, %q", ok, reason)
+	}
+}
@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+)
+
+// MaxToolCalls limits how many times a tool may be called in a run.
+//
+// Example:
+//
+//	policy.MaxToolCalls{Tool: "web_search", Max: 3}
+type MaxToolCalls struct {
+	// Tool is the name of the limited tool.
+	Tool string
+	// Max is the number of calls allowed per run.
+	Max int
+}
+
+// Evaluate denies a call to Tool once it has been called Max times.
+func (c MaxToolCalls) Evaluate(ctx ConstraintContext) (bool, string) {
+	if ctx.IsTransition() || ctx.ToolName != c.Tool {
+		return true, ""
+	}
+	if ctx.CallCount(c.Tool, "") >= c.Max {
+		return false, fmt.Sprintf("tool %s may be called at most %d times per run", c.Tool, c.Max)
+	}
+	return true, ""
+}
+
+// RequireCallsBefore requires a number of tool calls in one state before
+// another state may be entered. Both the transition into State and any tool
+// call made in State are denied until the requirement is met.
+//
+// Example (no act before two explore calls):
+//
+//	policy.RequireCallsBefore{State: agent.StateAct, After: agent.StateExplore, Calls: 2}
+type RequireCallsBefore struct {
+	// State is the state that is gated.
+	State agent.State
+	// After is the state whose tool calls are counted.
+	After agent.State
+	// Calls is the number of completed calls in After that State requires.
+	Calls int
+}
+
+// Evaluate denies entering or acting in State until Calls tool calls have
+// completed in After.
+func (c RequireCallsBefore) Evaluate(ctx ConstraintContext) (bool, string) {
+	target := ctx.CurrentState
+	if ctx.IsTransition() {
+		target = ctx.ToState
+	}
+	if target != c.State {
+		return true, ""
+	}
+	if n := ctx.CallCount("", c.After); n < c.Calls {
+		return false, fmt.Sprintf("%s requires %d tool calls in %s first, have %d", c.State, c.Calls, c.After, n)
+	}
+	return true, ""
+}
+
+// ForbidToolAfter forbids calling a tool once another tool has been called
+// in the run.
+//
+// Example (no deploys after a rollback):
+//
+//	policy.ForbidToolAfter{Tool: "deploy", After: "rollback"}
+type ForbidToolAfter struct {
+	// Tool is the forbidden tool.
+	Tool string
+	// After is the tool whose call forbids Tool.
+	After string
+}
+
+// Evaluate denies a call to Tool if After appears in the run's history.
+func (c ForbidToolAfter) Evaluate(ctx ConstraintContext) (bool, string) {
+	if ctx.IsTransition() || ctx.ToolName != c.Tool {
+		return true, ""
+	}
+	if ctx.CallCount(c.After, "") > 0 {
+		return false, fmt.Sprintf("tool %s may not be called after %s", c.Tool, c.After)
+	}
+	return true, ""
+}
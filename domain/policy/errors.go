@@ -24,6 +24,18 @@ var (
 	// ErrToolNotEligible indicates the tool is not eligible in the current state.
 	ErrToolNotEligible = errors.New("tool not eligible in current state")
 
+	// ErrInvalidRule indicates a policy rule or expression that cannot be compiled.
+	ErrInvalidRule = errors.New("invalid policy rule")
+
 	// ErrRateLimitExceeded indicates the rate limit has been exceeded.
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+	// ErrCircuitOpen indicates the tool's circuit breaker is open.
+	ErrCircuitOpen = errors.New("circuit breaker open")
+
+	// ErrInvalidQuorum indicates a quorum approver configuration that can never be satisfied.
+	ErrInvalidQuorum = errors.New("invalid approval quorum")
+
+	// ErrInvalidRolloutCriteria indicates rollout criteria that would hold without evidence.
+	ErrInvalidRolloutCriteria = errors.New("invalid rollout criteria")
 )
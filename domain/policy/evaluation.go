@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+)
+
+// EvaluationMode selects how a candidate policy version is evaluated
+// against live runs.
+type EvaluationMode string
+
+const (
+	// EvaluationShadow checks every decision against the candidate without
+	// enforcing it; disagreements are recorded.
+	EvaluationShadow EvaluationMode = "shadow"
+
+	// EvaluationCanary enforces the candidate on a percentage of runs.
+	EvaluationCanary EvaluationMode = "canary"
+)
+
+// Verdict is how a policy treats a decision.
+type Verdict string
+
+const (
+	VerdictAllow           Verdict = "allow"
+	VerdictDeny            Verdict = "deny"
+	VerdictRequireApproval Verdict = "require_approval"
+)
+
+// Disagreement records a decision the candidate policy would have treated
+// differently from the active one, such as "would have been denied".
+type Disagreement struct {
+	RunID     string      `json:"run_id"`
+	State     agent.State `json:"state"`
+	ToolName  string      `json:"tool_name,omitempty"`
+	ToState   agent.State `json:"to_state,omitempty"`
+	Active    Verdict     `json:"active"`
+	Candidate Verdict     `json:"candidate"`
+	Reason    string      `json:"reason,omitempty"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// RunMetrics summarizes the outcome of the runs in one arm of an evaluation.
+type RunMetrics struct {
+	Runs      int `json:"runs"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// FailureRate returns the fraction of runs that failed.
+func (m RunMetrics) FailureRate() float64 {
+	if m.Runs == 0 {
+		return 0
+	}
+	return float64(m.Failed) / float64(m.Runs)
+}
+
+// EvaluationReport summarizes a shadow or canary evaluation.
+type EvaluationReport struct {
+	Mode             EvaluationMode `json:"mode"`
+	CandidateVersion int            `json:"candidate_version"`
+	ActiveVersion    int            `json:"active_version"`
+	CanaryPercent    int            `json:"canary_percent,omitempty"`
+	StartedAt        time.Time      `json:"started_at"`
+	EndedAt          time.Time      `json:"ended_at,omitempty"`
+
+	// Decisions is the number of decisions checked against both policies.
+	Decisions int `json:"decisions"`
+
+	// Disagreements is the number of decisions the policies disagreed on.
+	Disagreements int `json:"disagreements"`
+
+	// Samples holds the first disagreements, up to the evaluation's limit.
+	Samples []Disagreement `json:"samples,omitempty"`
+
+	// Active covers runs under the active policy; Canary covers runs under
+	// the candidate, in canary mode.
+	Active RunMetrics `json:"active"`
+	Canary RunMetrics `json:"canary"`
+}
+
+// DisagreementRate returns the fraction of checked decisions the policies
+// disagreed on.
+func (r EvaluationReport) DisagreementRate() float64 {
+	if r.Decisions == 0 {
+		return 0
+	}
+	return float64(r.Disagreements) / float64(r.Decisions)
+}
+
+// Runs returns the number of runs the candidate was evaluated on.
+func (r EvaluationReport) Runs() int {
+	if r.Mode == EvaluationCanary {
+		return r.Canary.Runs
+	}
+	return r.Active.Runs
+}
+
+// RolloutCriteria decide whether an evaluation supports rolling out the
+// candidate.
+type RolloutCriteria struct {
+	// MinRuns is the number of runs the candidate must be evaluated on.
+	// It must be at least 1.
+	MinRuns int `json:"min_runs"`
+
+	// MaxDisagreementRate bounds the shadow disagreement rate.
+	MaxDisagreementRate float64 `json:"max_disagreement_rate"`
+
+	// MaxFailureRateIncrease bounds how much higher the canary failure rate
+	// may be than the active policy's.
+	MaxFailureRateIncrease float64 `json:"max_failure_rate_increase"`
+}
+
+// Validate checks that the criteria require evidence.
+func (c RolloutCriteria) Validate() error {
+	if c.MinRuns < 1 {
+		return fmt.Errorf("%w: min runs must be at least 1, got %d", ErrInvalidRolloutCriteria, c.MinRuns)
+	}
+	return nil
+}
+
+// Holds reports whether the report meets the criteria, and why not. An
+// evaluation without runs never holds.
+func (c RolloutCriteria) Holds(r EvaluationReport) (bool, string) {
+	if required := max(c.MinRuns, 1); r.Runs() < required {
+		return false, fmt.Sprintf("evaluated on %d of %d required runs", r.Runs(), required)
+	}
+	if rate := r.DisagreementRate(); rate > c.MaxDisagreementRate {
+		return false, fmt.Sprintf("disagreement rate %.2f exceeds %.2f", rate, c.MaxDisagreementRate)
+	}
+	if r.Mode == EvaluationCanary {
+		increase := r.Canary.FailureRate() - r.Active.FailureRate()
+		if increase > c.MaxFailureRateIncrease {
+			return false, fmt.Sprintf("canary failure rate %.2f exceeds active %.2f by more than %.2f",
+				r.Canary.FailureRate(), r.Active.FailureRate(), c.MaxFailureRateIncrease)
+		}
+	}
+	return true, ""
+}
@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRolloutCriteria_Holds(t *testing.T) {
+	criteria := RolloutCriteria{MinRuns: 10, MaxDisagreementRate: 0.1, MaxFailureRateIncrease: 0.05}
+	tests := []struct {
+		name   string
+		report EvaluationReport
+		holds  bool
+		reason string
+	}{
+		{
+			name:   "too few runs",
+			report: EvaluationReport{Mode: EvaluationShadow, Active: RunMetrics{Runs: 9}},
+			reason: "9 of 10 required runs",
+		},
+		{
+			name:   "shadow within bounds",
+			report: EvaluationReport{Mode: EvaluationShadow, Active: RunMetrics{Runs: 10}, Decisions: 100, Disagreements: 10},
+			holds:  true,
+		},
+		{
+			name:   "too many disagreements",
+			report: EvaluationReport{Mode: EvaluationShadow, Active: RunMetrics{Runs: 10}, Decisions: 100, Disagreements: 11},
+			reason: "disagreement rate 0.11",
+		},
+		{
+			name: "canary counts canary runs",
+			report: EvaluationReport{Mode: EvaluationCanary,
+				Active: RunMetrics{Runs: 100}, Canary: RunMetrics{Runs: 5}},
+			reason: "5 of 10 required runs",
+		},
+		{
+			name: "canary fails more often",
+			report: EvaluationReport{Mode: EvaluationCanary,
+				Active: RunMetrics{Runs: 100, Failed: 10}, Canary: RunMetrics{Runs: 10, Failed: 2}},
+			reason: "canary failure rate 0.20",
+		},
+		{
+			name: "canary within bounds",
+			report: EvaluationReport{Mode: EvaluationCanary,
+				Active: RunMetrics{Runs: 100, Failed: 10}, Canary: RunMetrics{Runs: 20, Failed: 3}},
+			holds: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holds, reason := criteria.Holds(tt.report)
+			if holds != tt.holds || !strings.Contains(reason, tt.reason) {
+				t.Errorf("Holds() = %v, %q; want %v, %q", holds, reason, tt.holds, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRolloutCriteria_RequireRuns(t *testing.T) {
+	criteria := RolloutCriteria{}
+	if err := criteria.Validate(); !errors.Is(err, ErrInvalidRolloutCriteria) {
+		t.Errorf("expected ErrInvalidRolloutCriteria, got %v", err)
+	}
+	if err := (RolloutCriteria{MinRuns: 1}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		report EvaluationReport
+		holds  bool
+		reason string
+	}{
+		{EvaluationReport{Mode: EvaluationShadow}, false, "0 of 1 required runs"},
+		{EvaluationReport{Mode: EvaluationCanary, Active: RunMetrics{Runs: 10}}, false, "0 of 1 required runs"},
+		{EvaluationReport{Mode: EvaluationShadow, Active: RunMetrics{Runs: 1}}, true, ""},
+	}
+	for _, tt := range tests {
+		holds, reason := criteria.Holds(tt.report)
+		if holds != tt.holds || !strings.Contains(reason, tt.reason) {
+			t.Errorf("Holds(%+v) = %v, %q; want %v, %q", tt.report, holds, reason, tt.holds, tt.reason)
+		}
+	}
+}
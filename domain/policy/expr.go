@@ -0,0 +1,573 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is a compiled policy expression.
+//
+// The language is a small, CEL-style subset evaluated over JSON-like values
+// (nil, bool, float64, string, []any and map[string]any):
+//
+//	literals     "text" 'text' 42 1.5 true false null [1, 2]
+//	paths        input.replicas  tool.tags[0]  vars["region"]
+//	logic        a && b   a || b   !a   ( ... )
+//	comparison   == != < <= > >=
+//	membership   x in list   list contains x   s contains "sub"
+//	strings      s startsWith "p"   s endsWith "s"   s matches "re"
+//	functions    size(x)   has(path)
+//
+// Names must be one of the roots provided by RuleEnv (action, state, to,
+// input, vars, calls, budget, run and tool); any other name is a compile
+// error, so typos are caught before a policy is deployed. Evaluation never
+// fails: missing fields are null, ordering comparisons between mismatched
+// types are false, and only true is truthy.
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// CompileExpr parses an expression.
+func CompileExpr(src string) (*Expr, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseExpr(tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &Expr{src: strings.TrimSpace(src), root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression against env and returns its value.
+func (e *Expr) Eval(env map[string]any) any {
+	return e.root.eval(env)
+}
+
+// Matches reports whether the expression evaluates to true.
+func (e *Expr) Matches(env map[string]any) bool {
+	return truthy(e.Eval(env))
+}
+
+// ============================================================================
+// Lexer
+// ============================================================================
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+// exprOps lists operator tokens, longest first.
+var exprOps = []string{"&&", "||", "==", "!=", "<=", ">=", "=>", "<", ">", "!", "(", ")", "[", "]", ",", ".", "-"}
+
+func lexExpr(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(src) && src[i] != c {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+					switch src[i] {
+					case 'n':
+						sb.WriteByte('\n')
+					case 't':
+						sb.WriteByte('\t')
+					default:
+						sb.WriteByte(src[i])
+					}
+				} else {
+					sb.WriteByte(src[i])
+				}
+				i++
+			}
+			if i >= len(src) {
+				return nil, exprError(start, "unterminated string")
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+		case c >= '0' && c <= '9':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			n, err := strconv.ParseFloat(src[start:i], 64)
+			if err != nil {
+				return nil, exprError(start, "invalid number "+src[start:i])
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], num: n, pos: start})
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] >= 'a' && src[i] <= 'z' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], pos: start})
+		default:
+			matched := false
+			for _, op := range exprOps {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, exprError(i, fmt.Sprintf("unexpected character %q", c))
+			}
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+func exprError(pos int, msg string) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrInvalidRule, msg, pos)
+}
+
+// ============================================================================
+// Parser
+// ============================================================================
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func parseExpr(tokens []token) (exprNode, error) {
+	if len(tokens) == 1 {
+		return nil, exprError(0, "empty expression")
+	}
+	p := &exprParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, exprError(t.pos, fmt.Sprintf("unexpected %q", t.text))
+	}
+	return node, nil
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) isOp(op string) bool {
+	t := p.peek()
+	return t.kind == tokOp && t.text == op
+}
+
+func (p *exprParser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		return exprError(t.pos, fmt.Sprintf("expected %q", op))
+	}
+	p.next()
+	return nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+// envRoots are the names RuleEnv provides.
+var envRoots = map[string]bool{
+	"action": true, "state": true, "to": true, "input": true, "vars": true,
+	"calls": true, "budget": true, "run": true, "tool": true,
+}
+
+// comparisonOps are the non-associative binary operators.
+var comparisonOps = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"in": true, "contains": true, "startsWith": true, "endsWith": true, "matches": true,
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	t := p.peek()
+	if (t.kind != tokOp && t.kind != tokIdent) || !comparisonOps[t.text] {
+		return left, nil
+	}
+	p.next()
+	if t.text == "matches" {
+		pattern := p.next()
+		if pattern.kind != tokString {
+			return nil, exprError(pattern.pos, "matches requires a string literal pattern")
+		}
+		re, err := regexp.Compile(pattern.text)
+		if err != nil {
+			return nil, exprError(pattern.pos, "invalid pattern: "+err.Error())
+		}
+		return matchNode{left, re}, nil
+	}
+	right, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return binaryNode{op: t.text, left: left, right: right}, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("!") {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	if p.isOp("-") {
+		t := p.next()
+		n := p.next()
+		if n.kind != tokNumber {
+			return nil, exprError(t.pos, "'-' must precede a number")
+		}
+		return literalNode{-n.num}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *exprParser) parsePostfix() (exprNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isOp("."):
+			p.next()
+			field := p.next()
+			if field.kind != tokIdent {
+				return nil, exprError(field.pos, "expected field name")
+			}
+			node = fieldNode{node, literalNode{field.text}}
+		case p.isOp("["):
+			p.next()
+			index, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = fieldNode{node, index}
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return literalNode{t.num}, nil
+	case tokString:
+		return literalNode{t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "null":
+			return literalNode{nil}, nil
+		}
+		if comparisonOps[t.text] {
+			return nil, exprError(t.pos, fmt.Sprintf("unexpected %q", t.text))
+		}
+		if p.isOp("(") {
+			return p.parseCall(t)
+		}
+		if !envRoots[t.text] {
+			return nil, exprError(t.pos, fmt.Sprintf("unknown name %q", t.text))
+		}
+		return identNode(t.text), nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			var items listNode
+			for !p.isOp("]") {
+				item, err := p.parseOr()
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, item)
+				if !p.isOp(",") {
+					break
+				}
+				p.next()
+			}
+			return items, p.expect("]")
+		}
+	case tokEOF:
+		return nil, exprError(t.pos, "unexpected end of expression")
+	}
+	return nil, exprError(t.pos, fmt.Sprintf("unexpected %q", t.text))
+}
+
+func (p *exprParser) parseCall(name token) (exprNode, error) {
+	p.next() // (
+	arg, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	switch name.text {
+	case "size":
+		return sizeNode{arg}, nil
+	case "has":
+		switch arg.(type) {
+		case identNode, fieldNode:
+			return hasNode{arg}, nil
+		}
+		return nil, exprError(name.pos, "has requires a field path")
+	}
+	return nil, exprError(name.pos, "unknown function "+name.text)
+}
+
+// ============================================================================
+// Evaluation
+// ============================================================================
+
+type exprNode interface {
+	eval(env map[string]any) any
+}
+
+type (
+	literalNode struct{ value any }
+	identNode   string
+	listNode    []exprNode
+	fieldNode   struct{ target, key exprNode }
+	notNode     struct{ operand exprNode }
+	andNode     struct{ left, right exprNode }
+	orNode      struct{ left, right exprNode }
+	sizeNode    struct{ arg exprNode }
+	hasNode     struct{ arg exprNode }
+	matchNode   struct {
+		left exprNode
+		re   *regexp.Regexp
+	}
+	binaryNode struct {
+		op          string
+		left, right exprNode
+	}
+)
+
+func (n identNode) eval(env map[string]any) any { return env[string(n)] }
+func (n notNode) eval(env map[string]any) any   { return !truthy(n.operand.eval(env)) }
+func (n andNode) eval(env map[string]any) any {
+	return truthy(n.left.eval(env)) && truthy(n.right.eval(env))
+}
+func (n orNode) eval(env map[string]any) any {
+	return truthy(n.left.eval(env)) || truthy(n.right.eval(env))
+}
+func (n hasNode) eval(env map[string]any) any { return n.arg.eval(env) != nil }
+
+func (n listNode) eval(env map[string]any) any {
+	items := make([]any, len(n))
+	for i, item := range n {
+		items[i] = item.eval(env)
+	}
+	return items
+}
+
+func (n fieldNode) eval(env map[string]any) any {
+	switch target := n.target.eval(env).(type) {
+	case map[string]any:
+		if key, ok := n.key.eval(env).(string); ok {
+			return target[key]
+		}
+	case []any:
+		if idx, ok := n.key.eval(env).(float64); ok && idx >= 0 && int(idx) < len(target) && idx == float64(int(idx)) {
+			return target[int(idx)]
+		}
+	}
+	return nil
+}
+
+func (n sizeNode) eval(env map[string]any) any {
+	switch v := n.arg.eval(env).(type) {
+	case string:
+		return float64(len(v))
+	case []any:
+		return float64(len(v))
+	case map[string]any:
+		return float64(len(v))
+	}
+	return float64(0)
+}
+
+func (n matchNode) eval(env map[string]any) any {
+	s, ok := n.left.eval(env).(string)
+	return ok && n.re.MatchString(s)
+}
+
+func (n binaryNode) eval(env map[string]any) any {
+	left, right := n.left.eval(env), n.right.eval(env)
+	switch n.op {
+	case "==":
+		return reflect.DeepEqual(left, right)
+	case "!=":
+		return !reflect.DeepEqual(left, right)
+	case "in":
+		return contains(right, left)
+	case "contains":
+		return contains(left, right)
+	case "startsWith", "endsWith":
+		s, ok1 := left.(string)
+		affix, ok2 := right.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		if n.op == "startsWith" {
+			return strings.HasPrefix(s, affix)
+		}
+		return strings.HasSuffix(s, affix)
+	}
+
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+		cmp = compareOrdered(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false
+	}
+	switch n.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default: // ">="
+		return cmp >= 0
+	}
+}
+
+func (n literalNode) eval(map[string]any) any { return n.value }
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// contains reports whether collection holds item: an element of a list,
+// a key of a map, or a substring of a string.
+func contains(collection, item any) bool {
+	switch c := collection.(type) {
+	case []any:
+		for _, v := range c {
+			if reflect.DeepEqual(v, item) {
+				return true
+			}
+		}
+	case map[string]any:
+		if key, ok := item.(string); ok {
+			_, exists := c[key]
+			return exists
+		}
+	case string:
+		if sub, ok := item.(string); ok {
+			return strings.Contains(c, sub)
+		}
+	}
+	return false
+}
+
+func truthy(v any) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
@@ -0,0 +1,270 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// ApprovalVote records one approver's decision within a multi-party approval.
+type ApprovalVote struct {
+	// Group is the approval group the vote was cast in.
+	Group string `json:"group"`
+	// Approver identifies who voted.
+	Approver string `json:"approver,omitempty"`
+	// Approved is the voter's decision.
+	Approved bool `json:"approved"`
+	// Justification is the reason the voter gave.
+	Justification string `json:"justification,omitempty"`
+	// Counted reports whether the approval counted toward the group's quorum.
+	Counted bool `json:"counted"`
+	// Rejected explains why an approval was not counted, or the error
+	// returned by the approver.
+	Rejected string `json:"rejected,omitempty"`
+	// Timestamp is when the vote was cast.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ApprovalGroup is a named set of approvers of which Required must approve.
+type ApprovalGroup struct {
+	// Name identifies the group, such as "sre" or "security".
+	Name string
+
+	// Approvers are consulted concurrently; each contributes one vote.
+	// Any Approver works, such as a Slack approver per channel or person.
+	Approvers []Approver
+
+	// Required is the number of distinct approvals needed. Zero requires
+	// every approver in the group.
+	Required int
+
+	// Members, when set, lists the identities whose approvals count for
+	// this group. Approvals from anyone else are recorded but not counted.
+	Members []string
+}
+
+// required returns the number of approvals the group needs.
+func (g ApprovalGroup) required() int {
+	if g.Required == 0 {
+		return len(g.Approvers)
+	}
+	return g.Required
+}
+
+// isMember reports whether the identity may approve for the group.
+func (g ApprovalGroup) isMember(approver string) bool {
+	if len(g.Members) == 0 {
+		return true
+	}
+	for _, member := range g.Members {
+		if member == approver {
+			return true
+		}
+	}
+	return false
+}
+
+// QuorumConfig configures a QuorumApprover.
+type QuorumConfig struct {
+	// Groups must each reach their quorum, in order, for a request to be
+	// approved. The first group that falls short denies the request.
+	Groups []ApprovalGroup
+
+	// Fallback decides requests below MinRisk. When nil, every request
+	// needs a quorum.
+	Fallback Approver
+
+	// MinRisk is the lowest risk level decided by quorum when a Fallback
+	// is set. Defaults to tool.RiskCritical.
+	MinRisk tool.RiskLevel
+
+	// RequireJustification discards approvals that carry no reason.
+	RequireJustification bool
+}
+
+// QuorumApprover requires N-of-M approval from each of several named groups.
+//
+// It enforces separation of duties: the requester (ApprovalRequest.Requester)
+// cannot approve their own request, and an approver counts toward at most
+// one group. Requests without a requester are denied, since the rule
+// cannot be checked; set it with WithRequester. Approvals that modify the
+// input are not counted, as each voter approved a different call. Every
+// vote is returned in ApprovalResponse.Votes.
+type QuorumApprover struct {
+	groups               []ApprovalGroup
+	fallback             Approver
+	minRisk              tool.RiskLevel
+	requireJustification bool
+}
+
+// NewQuorumApprover creates a quorum approver.
+func NewQuorumApprover(cfg QuorumConfig) (*QuorumApprover, error) {
+	if len(cfg.Groups) == 0 {
+		return nil, fmt.Errorf("%w: no approval groups", ErrInvalidQuorum)
+	}
+	names := make(map[string]bool, len(cfg.Groups))
+	for _, group := range cfg.Groups {
+		switch {
+		case group.Name == "":
+			return nil, fmt.Errorf("%w: approval group without a name", ErrInvalidQuorum)
+		case names[group.Name]:
+			return nil, fmt.Errorf("%w: duplicate approval group %s", ErrInvalidQuorum, group.Name)
+		case len(group.Approvers) == 0:
+			return nil, fmt.Errorf("%w: group %s has no approvers", ErrInvalidQuorum, group.Name)
+		case group.Required < 0 || group.Required > len(group.Approvers):
+			return nil, fmt.Errorf("%w: group %s requires %d of %d approvals",
+				ErrInvalidQuorum, group.Name, group.Required, len(group.Approvers))
+		}
+		names[group.Name] = true
+	}
+
+	minRisk := cfg.MinRisk
+	if minRisk == tool.RiskNone {
+		minRisk = tool.RiskCritical
+	}
+	return &QuorumApprover{
+		groups:               cfg.Groups,
+		fallback:             cfg.Fallback,
+		minRisk:              minRisk,
+		requireJustification: cfg.RequireJustification,
+	}, nil
+}
+
+// Approve collects votes from each group in turn and approves the request
+// once every group has reached its quorum.
+func (q *QuorumApprover) Approve(ctx context.Context, req ApprovalRequest) (ApprovalResponse, error) {
+	if q.fallback != nil && parseRiskLevel(req.RiskLevel) < q.minRisk {
+		return q.fallback.Approve(ctx, req)
+	}
+	if req.Requester == "" {
+		return ApprovalResponse{
+			Approved:  false,
+			Reason:    "requester unknown: separation of duties cannot be enforced",
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	var votes []ApprovalVote
+	var approvers, summary []string
+	counted := make(map[string]string) // approver -> group counted for
+	for _, group := range q.groups {
+		groupVotes, approvals, err := q.decideGroup(ctx, req, group, counted)
+		votes = append(votes, groupVotes...)
+		if err != nil {
+			return ApprovalResponse{}, err
+		}
+		if len(approvals) < group.required() {
+			return ApprovalResponse{
+				Approved: false,
+				Reason: fmt.Sprintf("quorum not reached for group %s: %d of %d approvals",
+					group.Name, len(approvals), group.required()),
+				Timestamp: time.Now(),
+				Votes:     votes,
+			}, nil
+		}
+		approvers = append(approvers, approvals...)
+		summary = append(summary, fmt.Sprintf("%s %d/%d", group.Name, len(approvals), group.required()))
+	}
+
+	return ApprovalResponse{
+		Approved:  true,
+		Approver:  strings.Join(approvers, ", "),
+		Reason:    "quorum reached: " + strings.Join(summary, ", "),
+		Timestamp: time.Now(),
+		Votes:     votes,
+	}, nil
+}
+
+// decideGroup consults the group's approvers concurrently until the quorum
+// is reached or can no longer be reached, and returns the votes received
+// and the approvers that counted.
+func (q *QuorumApprover) decideGroup(ctx context.Context, req ApprovalRequest, group ApprovalGroup, counted map[string]string) ([]ApprovalVote, []string, error) {
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		resp ApprovalResponse
+		err  error
+	}
+	results := make(chan result, len(group.Approvers))
+	for _, approver := range group.Approvers {
+		go func(a Approver) {
+			resp, err := a.Approve(groupCtx, req)
+			results <- result{resp: resp, err: err}
+		}(approver)
+	}
+
+	required := group.required()
+	var votes []ApprovalVote
+	var approvals []string
+	for pending := len(group.Approvers); pending > 0; pending-- {
+		if len(approvals) >= required || len(approvals)+pending < required {
+			break
+		}
+		r := <-results
+		if err := ctx.Err(); err != nil {
+			return votes, nil, err
+		}
+		vote := q.vote(group, req, r.resp, r.err, counted)
+		if vote.Counted {
+			counted[vote.Approver] = group.Name
+			approvals = append(approvals, vote.Approver)
+		}
+		votes = append(votes, vote)
+	}
+	return votes, approvals, nil
+}
+
+// vote validates a single response against the group and the separation
+// of duties rules.
+func (q *QuorumApprover) vote(group ApprovalGroup, req ApprovalRequest, resp ApprovalResponse, err error, counted map[string]string) ApprovalVote {
+	vote := ApprovalVote{
+		Group:         group.Name,
+		Approver:      resp.Approver,
+		Approved:      resp.Approved,
+		Justification: resp.Reason,
+		Timestamp:     resp.Timestamp,
+	}
+	if vote.Timestamp.IsZero() {
+		vote.Timestamp = time.Now()
+	}
+
+	switch {
+	case err != nil:
+		vote.Approved = false
+		vote.Rejected = fmt.Sprintf("approver error: %v", err)
+	case !resp.Approved:
+	case resp.Approver == "":
+		vote.Rejected = "approver identity missing"
+	case resp.Approver == req.Requester:
+		vote.Rejected = "requester cannot approve their own request"
+	case len(resp.ModifiedInput) > 0:
+		vote.Rejected = "quorum approvals cannot modify the input"
+	case !group.isMember(resp.Approver):
+		vote.Rejected = fmt.Sprintf("not a member of group %s", group.Name)
+	case counted[resp.Approver] != "":
+		vote.Rejected = fmt.Sprintf("already counted for group %s", counted[resp.Approver])
+	case q.requireJustification && strings.TrimSpace(resp.Reason) == "":
+		vote.Rejected = "justification required"
+	default:
+		vote.Counted = true
+	}
+	return vote
+}
+
+// parseRiskLevel parses an ApprovalRequest risk level. Unknown levels are
+// treated as critical so they never bypass the quorum.
+func parseRiskLevel(s string) tool.RiskLevel {
+	for level := tool.RiskNone; level <= tool.RiskCritical; level++ {
+		if level.String() == s {
+			return level
+		}
+	}
+	return tool.RiskCritical
+}
+
+// Ensure QuorumApprover implements Approver
+var _ Approver = (*QuorumApprover)(nil)
@@ -0,0 +1,218 @@
+package policy_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// voter approves or denies as a named person.
+type voter struct {
+	name     string
+	approved bool
+	reason   string
+	modified json.RawMessage
+	err      error
+}
+
+func (v voter) Approve(_ context.Context, _ policy.ApprovalRequest) (policy.ApprovalResponse, error) {
+	if v.err != nil {
+		return policy.ApprovalResponse{}, v.err
+	}
+	return policy.ApprovalResponse{Approved: v.approved, Approver: v.name, Reason: v.reason, ModifiedInput: v.modified}, nil
+}
+
+func approve(name string) voter { return voter{name: name, approved: true, reason: "looks good"} }
+
+func TestNewQuorumApprover_Validation(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]policy.ApprovalGroup{
+		"no groups":      nil,
+		"unnamed":        {{Approvers: []policy.Approver{approve("alice")}}},
+		"duplicate name": {{Name: "sre", Approvers: []policy.Approver{approve("alice")}}, {Name: "sre", Approvers: []policy.Approver{approve("bob")}}},
+		"no approvers":   {{Name: "sre"}},
+		"unreachable":    {{Name: "sre", Approvers: []policy.Approver{approve("alice")}, Required: 2}},
+	}
+	for name, groups := range tests {
+		if _, err := policy.NewQuorumApprover(policy.QuorumConfig{Groups: groups}); !errors.Is(err, policy.ErrInvalidQuorum) {
+			t.Errorf("%s: expected ErrInvalidQuorum, got %v", name, err)
+		}
+	}
+}
+
+func TestQuorumApprover_Approve(t *testing.T) {
+	t.Parallel()
+
+	req := policy.ApprovalRequest{RunID: "run-1", ToolName: "drop_table", RiskLevel: "critical", Requester: "dave"}
+
+	tests := []struct {
+		name          string
+		groups        []policy.ApprovalGroup
+		justification bool
+		approved      bool
+		counted       int
+		rejected      string
+	}{
+		{
+			name: "quorum reached in every group",
+			groups: []policy.ApprovalGroup{
+				{Name: "sre", Approvers: []policy.Approver{approve("alice"), approve("bob"), voter{name: "carol"}}, Required: 2},
+				{Name: "security", Approvers: []policy.Approver{approve("erin")}},
+			},
+			approved: true,
+			counted:  3,
+		},
+		{
+			name: "quorum not reached",
+			groups: []policy.ApprovalGroup{
+				{Name: "sre", Approvers: []policy.Approver{approve("alice"), voter{name: "bob"}}, Required: 2},
+			},
+		},
+		{
+			name: "requester cannot approve",
+			groups: []policy.ApprovalGroup{
+				{Name: "sre", Approvers: []policy.Approver{approve("dave")}},
+			},
+			rejected: "requester cannot approve their own request",
+		},
+		{
+			name: "approver counts toward one group",
+			groups: []policy.ApprovalGroup{
+				{Name: "sre", Approvers: []policy.Approver{approve("alice")}},
+				{Name: "security", Approvers: []policy.Approver{approve("alice")}},
+			},
+			counted:  1,
+			rejected: "already counted for group sre",
+		},
+		{
+			name: "non-member approval is not counted",
+			groups: []policy.ApprovalGroup{
+				{Name: "security", Approvers: []policy.Approver{approve("mallory")}, Members: []string{"erin"}},
+			},
+			rejected: "not a member of group security",
+		},
+		{
+			name: "justification required",
+			groups: []policy.ApprovalGroup{
+				{Name: "sre", Approvers: []policy.Approver{voter{name: "alice", approved: true}}},
+			},
+			justification: true,
+			rejected:      "justification required",
+		},
+		{
+			name: "input-modifying approval is not counted",
+			groups: []policy.ApprovalGroup{
+				{Name: "sre", Approvers: []policy.Approver{voter{name: "bob", approved: true, reason: "with fixes", modified: json.RawMessage(`{"table":"tmp"}`)}}},
+			},
+			rejected: "quorum approvals cannot modify the input",
+		},
+		{
+			name: "approver errors are recorded",
+			groups: []policy.ApprovalGroup{
+				{Name: "sre", Approvers: []policy.Approver{voter{err: errors.New("slack unavailable")}}},
+			},
+			rejected: "approver error: slack unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			approver, err := policy.NewQuorumApprover(policy.QuorumConfig{Groups: tt.groups, RequireJustification: tt.justification})
+			if err != nil {
+				t.Fatalf("NewQuorumApprover() error = %v", err)
+			}
+			resp, err := approver.Approve(context.Background(), req)
+			if err != nil {
+				t.Fatalf("Approve() error = %v", err)
+			}
+			if resp.Approved != tt.approved {
+				t.Errorf("Approved = %v, want %v (%s)", resp.Approved, tt.approved, resp.Reason)
+			}
+
+			counted, rejected := 0, ""
+			for _, vote := range resp.Votes {
+				if vote.Counted {
+					counted++
+				}
+				if vote.Rejected != "" {
+					rejected = vote.Rejected
+				}
+			}
+			if counted != tt.counted || rejected != tt.rejected {
+				t.Errorf("counted %d (%q), want %d (%q): %+v", counted, rejected, tt.counted, tt.rejected, resp.Votes)
+			}
+		})
+	}
+}
+
+func TestQuorumApprover_Fallback(t *testing.T) {
+	t.Parallel()
+
+	approver, err := policy.NewQuorumApprover(policy.QuorumConfig{
+		Groups:   []policy.ApprovalGroup{{Name: "sre", Approvers: []policy.Approver{policy.NewDenyApprover("needs quorum")}}},
+		Fallback: policy.NewAutoApprover("bot"),
+	})
+	if err != nil {
+		t.Fatalf("NewQuorumApprover() error = %v", err)
+	}
+
+	resp, _ := approver.Approve(context.Background(), policy.ApprovalRequest{ToolName: "deploy", RiskLevel: tool.RiskHigh.String()})
+	if !resp.Approved || resp.Approver != "bot" {
+		t.Errorf("expected the fallback to decide high-risk calls, got %+v", resp)
+	}
+	resp, _ = approver.Approve(context.Background(), policy.ApprovalRequest{ToolName: "drop_table", RiskLevel: tool.RiskCritical.String(), Requester: "dave"})
+	if resp.Approved || len(resp.Votes) != 1 {
+		t.Errorf("expected the quorum to decide critical calls, got %+v", resp)
+	}
+}
+
+func TestQuorumApprover_ContextCancelled(t *testing.T) {
+	t.Parallel()
+
+	approver, err := policy.NewQuorumApprover(policy.QuorumConfig{
+		Groups: []policy.ApprovalGroup{{Name: "sre", Approvers: []policy.Approver{voter{err: context.Canceled}}}},
+	})
+	if err != nil {
+		t.Fatalf("NewQuorumApprover() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := approver.Approve(ctx, policy.ApprovalRequest{Requester: "dave"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestQuorumApprover_RequiresRequester(t *testing.T) {
+	t.Parallel()
+
+	approver, err := policy.NewQuorumApprover(policy.QuorumConfig{
+		Groups: []policy.ApprovalGroup{{Name: "sre", Approvers: []policy.Approver{approve("alice")}}},
+	})
+	if err != nil {
+		t.Fatalf("NewQuorumApprover() error = %v", err)
+	}
+	resp, err := approver.Approve(context.Background(), policy.ApprovalRequest{ToolName: "drop_table", RiskLevel: "critical"})
+	if err != nil {
+		t.Fatalf("Approve() error = %v", err)
+	}
+	if resp.Approved || len(resp.Votes) != 0 {
+		t.Errorf("expected requests without a requester to be denied unasked, got %+v", resp)
+	}
+}
+
+func TestRequesterFromContext(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := policy.RequesterFromContext(context.Background()); ok {
+		t.Error("expected no requester")
+	}
+	if got, ok := policy.RequesterFromContext(policy.WithRequester(context.Background(), "dave")); !ok || got != "dave" {
+		t.Errorf("RequesterFromContext() = %q, %v", got, ok)
+	}
+}
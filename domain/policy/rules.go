@@ -0,0 +1,289 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RuleEffect is the outcome of a matching rule.
+type RuleEffect string
+
+const (
+	// EffectNone means no rule matched.
+	EffectNone RuleEffect = ""
+	// EffectAllow permits the action and stops evaluation, so later rules
+	// cannot deny it or require approval. Eligibility and annotation-based
+	// approval still apply.
+	EffectAllow RuleEffect = "allow"
+	// EffectDeny denies the tool call or transition.
+	EffectDeny RuleEffect = "deny"
+	// EffectRequireApproval requires approval for the tool call.
+	EffectRequireApproval RuleEffect = "require_approval"
+)
+
+// RuleDefinition is the serialized form of a rule, as loaded from
+// configuration and stored in a PolicyVersion.
+type RuleDefinition struct {
+	// Name identifies the rule in denials and test reports.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Rule is the rule source: "<condition> => <effect>".
+	Rule string `json:"rule" yaml:"rule"`
+	// Reason explains the effect to the agent, approvers and auditors.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// Rule is a compiled policy rule of the form "<condition> => <effect>",
+// for example:
+//
+//	tool.tags contains "prod" && input.replicas > 10 => require_approval
+//
+// Conditions are Expr expressions evaluated over the environment built by
+// RuleEnv.
+type Rule struct {
+	Name      string
+	Reason    string
+	Condition *Expr
+	Effect    RuleEffect
+}
+
+// ParseRule compiles a single rule.
+func ParseRule(src string) (*Rule, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	arrow := -1
+	for i, t := range tokens {
+		if t.kind == tokOp && t.text == "=>" {
+			arrow = i
+			break
+		}
+	}
+	if arrow < 0 {
+		return nil, exprError(len(src), `expected "=> <effect>"`)
+	}
+	effectTok, end := tokens[arrow+1], tokens[min(arrow+2, len(tokens)-1)]
+	if effectTok.kind != tokIdent || end.kind != tokEOF {
+		return nil, exprError(effectTok.pos, "expected a single effect after =>")
+	}
+	effect := RuleEffect(effectTok.text)
+	switch effect {
+	case EffectAllow, EffectDeny, EffectRequireApproval:
+	default:
+		return nil, exprError(effectTok.pos, fmt.Sprintf("unknown effect %q", effectTok.text))
+	}
+
+	condTokens := append(tokens[:arrow:arrow], token{kind: tokEOF, pos: tokens[arrow].pos})
+	root, err := parseExpr(condTokens)
+	if err != nil {
+		return nil, err
+	}
+	cond := &Expr{src: strings.TrimSpace(src[:tokens[arrow].pos]), root: root}
+	return &Rule{Condition: cond, Effect: effect}, nil
+}
+
+// String returns the rule source.
+func (r *Rule) String() string {
+	return fmt.Sprintf("%s => %s", r.Condition, r.Effect)
+}
+
+// RuleSet is an ordered list of rules. The first rule whose condition
+// matches decides; if none matches, the rule set has no effect.
+//
+// RuleSet implements Constraint, denying actions matched by deny rules, so
+// it can be registered with the engine alongside other constraints. It is
+// immutable and safe for concurrent use.
+type RuleSet struct {
+	rules []*Rule
+	defs  []RuleDefinition
+}
+
+// CompileRules compiles rule definitions in order. Unnamed rules are named
+// after their position.
+func CompileRules(defs []RuleDefinition) (*RuleSet, error) {
+	rs := &RuleSet{
+		rules: make([]*Rule, 0, len(defs)),
+		defs:  make([]RuleDefinition, len(defs)),
+	}
+	copy(rs.defs, defs)
+	for i, def := range defs {
+		rule, err := ParseRule(def.Rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %w", i, def.Name, err)
+		}
+		rule.Name = def.Name
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		rule.Reason = def.Reason
+		rs.rules = append(rs.rules, rule)
+	}
+	return rs, nil
+}
+
+// MustCompileRules is like CompileRules but panics on error.
+func MustCompileRules(defs ...RuleDefinition) *RuleSet {
+	rs, err := CompileRules(defs)
+	if err != nil {
+		panic(err)
+	}
+	return rs
+}
+
+// Rules returns the compiled rules in evaluation order.
+func (rs *RuleSet) Rules() []*Rule {
+	return append([]*Rule(nil), rs.rules...)
+}
+
+// Definitions returns the definitions the rule set was compiled from, for
+// storing in a PolicyVersion.
+func (rs *RuleSet) Definitions() []RuleDefinition {
+	return append([]RuleDefinition(nil), rs.defs...)
+}
+
+// RuleDecision is the result of evaluating a rule set.
+type RuleDecision struct {
+	// Effect is the effect of the matching rule, or EffectNone.
+	Effect RuleEffect
+	// Rule is the matching rule, or nil.
+	Rule *Rule
+}
+
+// Reason describes the decision for denials and approval requests.
+func (d RuleDecision) Reason() string {
+	if d.Rule == nil {
+		return ""
+	}
+	if d.Rule.Reason != "" {
+		return d.Rule.Reason
+	}
+	return fmt.Sprintf("%s by rule %s", d.Effect, d.Rule.Name)
+}
+
+// Decide returns the decision of the first rule whose condition matches.
+func (rs *RuleSet) Decide(ctx ConstraintContext) RuleDecision {
+	if rs == nil || len(rs.rules) == 0 {
+		return RuleDecision{}
+	}
+	env := RuleEnv(ctx)
+	for _, rule := range rs.rules {
+		// Approval only applies to tool calls.
+		if rule.Effect == EffectRequireApproval && ctx.IsTransition() {
+			continue
+		}
+		if rule.Condition.Matches(env) {
+			return RuleDecision{Effect: rule.Effect, Rule: rule}
+		}
+	}
+	return RuleDecision{}
+}
+
+// Evaluate implements Constraint: actions matched by a deny rule are denied.
+func (rs *RuleSet) Evaluate(ctx ConstraintContext) (bool, string) {
+	d := rs.Decide(ctx)
+	if d.Effect == EffectDeny {
+		return false, d.Reason()
+	}
+	return true, ""
+}
+
+// RequiresApproval reports whether a require_approval rule matches the tool
+// call, and why.
+func (rs *RuleSet) RequiresApproval(ctx ConstraintContext) (bool, string) {
+	d := rs.Decide(ctx)
+	if d.Effect == EffectRequireApproval {
+		return true, d.Reason()
+	}
+	return false, ""
+}
+
+// RuleEnv builds the environment rule conditions are evaluated over:
+//
+//	action   "tool" or "transition"
+//	state    current state; to: target state of a transition
+//	tool     name, tags, read_only, destructive, idempotent, cacheable,
+//	         requires_approval, risk ("none" … "critical")
+//	input    the decoded tool input
+//	vars     the run's variables
+//	run      id, evidence (count), calls (count)
+//	calls    completed calls per tool name; tools not yet called are
+//	         absent, so test them with has(calls.name)
+//	budget   remaining amount per budget name
+func RuleEnv(ctx ConstraintContext) map[string]any {
+	action := "tool"
+	if ctx.IsTransition() {
+		action = "transition"
+	}
+
+	calls := make(map[string]any)
+	for _, call := range ctx.History {
+		n, _ := calls[call.ToolName].(float64)
+		calls[call.ToolName] = n + 1
+	}
+
+	budget := make(map[string]any)
+	if ctx.Budget != nil {
+		for name, remaining := range ctx.Budget.Snapshot().Remaining {
+			budget[name] = float64(remaining)
+		}
+	}
+
+	env := map[string]any{
+		"action": action,
+		"state":  string(ctx.CurrentState),
+		"to":     string(ctx.ToState),
+		"input":  jsonValue(ctx.Input),
+		"vars":   normalizeValue(ctx.Vars),
+		"calls":  calls,
+		"budget": budget,
+		"run": map[string]any{
+			"id":       ctx.RunID,
+			"evidence": float64(ctx.EvidenceCount),
+			"calls":    float64(len(ctx.History)),
+		},
+	}
+	if !ctx.IsTransition() {
+		a := ctx.Annotations
+		tags := make([]any, len(a.Tags))
+		for i, tag := range a.Tags {
+			tags[i] = tag
+		}
+		env["tool"] = map[string]any{
+			"name":              ctx.ToolName,
+			"tags":              tags,
+			"read_only":         a.ReadOnly,
+			"destructive":       a.Destructive,
+			"idempotent":        a.Idempotent,
+			"cacheable":         a.Cacheable,
+			"requires_approval": a.RequiresApproval,
+			"risk":              a.RiskLevel.String(),
+		}
+	}
+	return env
+}
+
+// jsonValue decodes raw JSON into expression values, or nil.
+func jsonValue(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// normalizeValue converts Go values into expression values by way of JSON,
+// so that all numbers are float64 and all objects map[string]any.
+func normalizeValue(v any) any {
+	if v == nil {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return jsonValue(raw)
+}
@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+func TestCompileExpr_Eval(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]any{
+		"input": map[string]any{"replicas": 12.0, "path": "/var/data/tmp", "labels": []any{"a", "b"}},
+		"tool":  map[string]any{"name": "scale", "tags": []any{"prod", "k8s"}, "destructive": false},
+		"state": "act",
+	}
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{`tool.tags contains "prod" && input.replicas > 10`, true},
+		{`tool.tags contains "dev" || input.replicas >= 13`, false},
+		{`"k8s" in tool.tags`, true},
+		{`state in ["act", "validate"]`, true},
+		{`input.replicas == 12`, true},
+		{`input.replicas != 12.0`, false},
+		{`input.replicas < -1`, false},
+		{`!tool.destructive`, true},
+		{`!(state == "act")`, false},
+		{`input.path startsWith "/var/" && input.path endsWith "tmp"`, true},
+		{`input.path matches "^/var/[a-z]+/"`, true},
+		{`input.path contains "data"`, true},
+		{`input.labels[1] == 'b'`, true},
+		{`input["replicas"] > 1`, true},
+		{`size(input.labels) == 2 && size(input.path) == 13`, true},
+		{`has(input.replicas) && !has(input.missing.deeper)`, true},
+		{`input.missing == null`, true},
+		{`input.missing > 1`, false},
+		{`input.path > 1`, false},
+		{`"b" < "c"`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			t.Parallel()
+			e, err := CompileExpr(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileExpr() error = %v", err)
+			}
+			if got := e.Eval(env); got != tt.want {
+				t.Errorf("Eval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileExpr_Errors(t *testing.T) {
+	t.Parallel()
+
+	for _, src := range []string{
+		``,
+		`input.x >`,
+		`"unterminated`,
+		`input.x == 1 == 2`,
+		`a && (b`,
+		`input.path matches input.pattern`,
+		`input.path matches "("`,
+		`len(input)`,
+		`has("x")`,
+		`input.x # 1`,
+		`- input.x`,
+		`unknown`,
+		`inptu.replicas > 10`,
+		`input.x == tool.name && varz.region == "eu"`,
+	} {
+		if _, err := CompileExpr(src); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("CompileExpr(%q) error = %v, want ErrInvalidRule", src, err)
+		}
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	t.Parallel()
+
+	rule, err := ParseRule(`tool.tags contains "prod" && input.replicas > 10 => require_approval`)
+	if err != nil {
+		t.Fatalf("ParseRule() error = %v", err)
+	}
+	if rule.Effect != EffectRequireApproval || rule.Condition.String() != `tool.tags contains "prod" && input.replicas > 10` {
+		t.Errorf("unexpected rule %s", rule)
+	}
+
+	for _, src := range []string{
+		`tool.destructive`,
+		`tool.destructive => `,
+		`tool.destructive => block`,
+		`tool.destructive => deny allow`,
+		` => deny`,
+	} {
+		if _, err := ParseRule(src); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("ParseRule(%q) error = %v, want ErrInvalidRule", src, err)
+		}
+	}
+}
+
+func TestRuleSet_Decide(t *testing.T) {
+	t.Parallel()
+
+	rules := MustCompileRules(
+		RuleDefinition{Name: "trusted", Rule: `vars.tenant == "internal" => allow`},
+		RuleDefinition{Name: "prod-scale", Rule: `tool.tags contains "prod" && input.replicas > 10 => require_approval`, Reason: "large production scale"},
+		RuleDefinition{Rule: `tool.destructive && budget.deletes < 1 => deny`},
+		RuleDefinition{Name: "explore-first", Rule: `to == "act" && (run.evidence < 2 || !has(calls.search)) => deny`, Reason: "explore before acting"},
+	)
+
+	budget := NewBudget(map[string]int{"deletes": 1})
+	_ = budget.Consume("deletes", 1)
+	scale := func(replicas int, vars map[string]any) ConstraintContext {
+		input, _ := json.Marshal(map[string]any{"replicas": replicas})
+		return ConstraintContext{
+			CurrentState: agent.StateAct,
+			ToolName:     "scale",
+			Annotations:  tool.Annotations{Tags: []string{"prod"}},
+			Input:        input,
+			Vars:         vars,
+		}
+	}
+
+	if d := rules.Decide(scale(12, nil)); d.Effect != EffectRequireApproval || d.Reason() != "large production scale" {
+		t.Errorf("expected approval for large prod scale, got %+v", d)
+	}
+	if ok, _ := rules.RequiresApproval(scale(3, nil)); ok {
+		t.Error("small scale should not require approval")
+	}
+	if d := rules.Decide(scale(12, map[string]any{"tenant": "internal"})); d.Effect != EffectAllow || d.Rule.Name != "trusted" {
+		t.Errorf("expected the allow rule to win, got %+v", d)
+	}
+
+	deleteCall := ConstraintContext{CurrentState: agent.StateAct, ToolName: "rm", Annotations: tool.Annotations{Destructive: true}, Budget: budget}
+	if ok, reason := rules.Evaluate(deleteCall); ok || reason != "deny by rule rule-3" {
+		t.Errorf("expected unnamed deny rule, got %v %q", ok, reason)
+	}
+
+	toAct := ConstraintContext{CurrentState: agent.StateDecide, ToState: agent.StateAct, EvidenceCount: 2}
+	if ok, reason := rules.Evaluate(toAct); ok || reason != "explore before acting" {
+		t.Errorf("expected transition denial, got %v %q", ok, reason)
+	}
+	toAct.History = []ToolCallRecord{{ToolName: "search", State: agent.StateExplore}}
+	if ok, _ := rules.Evaluate(toAct); !ok {
+		t.Error("expected transition to be allowed after a search")
+	}
+
+	var empty *RuleSet
+	if d := empty.Decide(deleteCall); d.Effect != EffectNone {
+		t.Errorf("nil rule set decided %s", d.Effect)
+	}
+
+	if _, err := CompileRules([]RuleDefinition{{Name: "bad", Rule: "x =>"}}); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("expected ErrInvalidRule, got %v", err)
+	}
+}
@@ -2,6 +2,8 @@
 package policy
 
 import (
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 )
 
@@ -146,6 +148,11 @@ func (s *BudgetLimitsSnapshot) GetLimit(name string) (int, bool) {
 type ApprovalSnapshot struct {
 	// RequiredTools lists tools that require approval.
 	RequiredTools []string `json:"required_tools"`
+
+	// ExemptTools lists tools that do not require approval under this
+	// snapshot, even when the approval policy requires it. Tools annotated
+	// as destructive, critical risk or RequiresApproval still do.
+	ExemptTools []string `json:"exempt_tools,omitempty"`
 }
 
 // NewApprovalSnapshot creates a new approval snapshot.
@@ -155,8 +162,10 @@ func NewApprovalSnapshot() ApprovalSnapshot {
 	}
 }
 
-// RequireApproval adds a tool to the approval requirement list.
+// RequireApproval adds a tool to the approval requirement list, revoking
+// any exemption.
 func (s *ApprovalSnapshot) RequireApproval(toolName string) {
+	s.RemoveExemption(toolName)
 	for _, t := range s.RequiredTools {
 		if t == toolName {
 			return // Already required
@@ -184,3 +193,108 @@ func (s *ApprovalSnapshot) IsRequired(toolName string) bool {
 	}
 	return false
 }
+
+// Exempt exempts a tool from approval, removing any requirement.
+func (s *ApprovalSnapshot) Exempt(toolName string) {
+	s.RemoveApproval(toolName)
+	for _, t := range s.ExemptTools {
+		if t == toolName {
+			return // Already exempt
+		}
+	}
+	s.ExemptTools = append(s.ExemptTools, toolName)
+}
+
+// RemoveExemption removes a tool from the exemption list.
+func (s *ApprovalSnapshot) RemoveExemption(toolName string) {
+	for i, t := range s.ExemptTools {
+		if t == toolName {
+			s.ExemptTools = append(s.ExemptTools[:i], s.ExemptTools[i+1:]...)
+			return
+		}
+	}
+}
+
+// IsExempt checks if a tool is exempt from approval.
+func (s *ApprovalSnapshot) IsExempt(toolName string) bool {
+	for _, t := range s.ExemptTools {
+		if t == toolName {
+			return true
+		}
+	}
+	return false
+}
+
+// ToolSettings captures the execution limits for a single tool. Zero values
+// leave the engine's defaults in place.
+type ToolSettings struct {
+	// Timeout bounds a single execution of the tool.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// RateLimit is the maximum number of calls per minute.
+	RateLimit int `json:"rate_limit,omitempty"`
+
+	// CircuitBreaker stops calls to the tool after repeated failures.
+	CircuitBreaker *CircuitBreakerSettings `json:"circuit_breaker,omitempty"`
+}
+
+// IsZero reports whether no limit is set.
+func (t ToolSettings) IsZero() bool {
+	return t.Timeout == 0 && t.RateLimit == 0 && t.CircuitBreaker == nil
+}
+
+// CircuitBreakerSettings configures a per-tool circuit breaker.
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures that open the circuit.
+	FailureThreshold int `json:"failure_threshold"`
+
+	// OpenTimeout is how long the circuit stays open before a trial call.
+	OpenTimeout time.Duration `json:"open_timeout"`
+}
+
+// ToolSettingsSnapshot captures per-tool execution limits at a point in time.
+type ToolSettingsSnapshot struct {
+	// Tools maps tool names to their settings.
+	Tools map[string]ToolSettings `json:"tools,omitempty"`
+}
+
+// NewToolSettingsSnapshot creates a new tool settings snapshot.
+func NewToolSettingsSnapshot() ToolSettingsSnapshot {
+	return ToolSettingsSnapshot{
+		Tools: make(map[string]ToolSettings),
+	}
+}
+
+// Get returns a tool's settings.
+func (s *ToolSettingsSnapshot) Get(toolName string) (ToolSettings, bool) {
+	settings, ok := s.Tools[toolName]
+	return settings, ok
+}
+
+// SetTimeout sets a tool's timeout. Zero removes it.
+func (s *ToolSettingsSnapshot) SetTimeout(toolName string, timeout time.Duration) {
+	s.update(toolName, func(t *ToolSettings) { t.Timeout = timeout })
+}
+
+// SetRateLimit sets a tool's calls-per-minute limit. Zero removes it.
+func (s *ToolSettingsSnapshot) SetRateLimit(toolName string, callsPerMinute int) {
+	s.update(toolName, func(t *ToolSettings) { t.RateLimit = callsPerMinute })
+}
+
+// SetCircuitBreaker sets a tool's circuit breaker. Nil removes it.
+func (s *ToolSettingsSnapshot) SetCircuitBreaker(toolName string, cb *CircuitBreakerSettings) {
+	s.update(toolName, func(t *ToolSettings) { t.CircuitBreaker = cb })
+}
+
+func (s *ToolSettingsSnapshot) update(toolName string, fn func(*ToolSettings)) {
+	if s.Tools == nil {
+		s.Tools = make(map[string]ToolSettings)
+	}
+	settings := s.Tools[toolName]
+	fn(&settings)
+	if settings.IsZero() {
+		delete(s.Tools, toolName)
+		return
+	}
+	s.Tools[toolName] = settings
+}
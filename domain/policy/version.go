@@ -31,6 +31,17 @@ type PolicyVersion struct {
 
 	// Approvals contains the approval requirements snapshot.
 	Approvals ApprovalSnapshot `json:"approvals"`
+
+	// Tools contains per-tool timeouts, rate limits and circuit breakers.
+	Tools ToolSettingsSnapshot `json:"tools"`
+
+	// Rules contains the declarative policy rules, in evaluation order.
+	Rules []RuleDefinition `json:"rules,omitempty"`
+}
+
+// RuleSet compiles the version's rules.
+func (v *PolicyVersion) RuleSet() (*RuleSet, error) {
+	return CompileRules(v.Rules)
 }
 
 // VersionStore persists policy versions.
@@ -51,6 +62,14 @@ type VersionStore interface {
 	GetByProposal(ctx context.Context, proposalID string) (*PolicyVersion, error)
 }
 
+// VersionNotifier is implemented by version stores that announce newly
+// saved versions, so engines can apply them without polling.
+type VersionNotifier interface {
+	// Subscribe registers fn to be called with every version saved after
+	// the call. The returned function cancels the subscription.
+	Subscribe(fn func(*PolicyVersion)) (cancel func())
+}
+
 // VersionDiff represents the differences between two policy versions.
 type VersionDiff struct {
 	// FromVersion is the starting version.
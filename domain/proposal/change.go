@@ -3,6 +3,7 @@ package proposal
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 )
@@ -15,6 +16,12 @@ const (
 	ChangeTypeTransition  ChangeType = "transition"
 	ChangeTypeBudget      ChangeType = "budget"
 	ChangeTypeApproval    ChangeType = "approval"
+
+	// Per-tool execution limits and approval exemptions
+	ChangeTypeToolTimeout       ChangeType = "tool_timeout"
+	ChangeTypeRateLimit         ChangeType = "rate_limit"
+	ChangeTypeApprovalExemption ChangeType = "approval_exemption"
+	ChangeTypeCircuitBreaker    ChangeType = "circuit_breaker"
 )
 
 // PolicyChange represents a single change to policy.
@@ -102,3 +109,31 @@ type ApprovalChange struct {
 	ToolName string `json:"tool_name"`
 	Required bool   `json:"required"`
 }
+
+// ToolTimeoutChange represents a per-tool timeout change. A zero timeout
+// removes the tool's timeout.
+type ToolTimeoutChange struct {
+	ToolName string        `json:"tool_name"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// RateLimitChange represents a per-tool rate limit change. A zero limit
+// removes the tool's rate limit.
+type RateLimitChange struct {
+	ToolName       string `json:"tool_name"`
+	CallsPerMinute int    `json:"calls_per_minute"`
+}
+
+// ApprovalExemptionChange represents an approval exemption change.
+type ApprovalExemptionChange struct {
+	ToolName string `json:"tool_name"`
+	Exempt   bool   `json:"exempt"`
+}
+
+// CircuitBreakerChange represents a per-tool circuit breaker change. A zero
+// failure threshold removes the tool's circuit breaker.
+type CircuitBreakerChange struct {
+	ToolName         string        `json:"tool_name"`
+	FailureThreshold int           `json:"failure_threshold"`
+	OpenTimeout      time.Duration `json:"open_timeout"`
+}
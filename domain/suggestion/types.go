@@ -2,6 +2,8 @@
 package suggestion
 
 import (
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 )
 
@@ -24,6 +26,12 @@ const (
 	// Approval suggestions
 	SuggestionTypeRequireApproval SuggestionType = "require_approval" // Add approval requirement
 	SuggestionTypeRemoveApproval  SuggestionType = "remove_approval"  // Remove approval requirement
+	SuggestionTypeExemptApproval  SuggestionType = "exempt_approval"  // Exempt tool from approval
+
+	// Tool limit suggestions
+	SuggestionTypeAdjustTimeout      SuggestionType = "adjust_timeout"       // Change a tool's timeout
+	SuggestionTypeAddRateLimit       SuggestionType = "add_rate_limit"       // Rate limit a tool
+	SuggestionTypeTuneCircuitBreaker SuggestionType = "tune_circuit_breaker" // Change a tool's circuit breaker
 )
 
 // SuggestionStatus tracks the lifecycle of a suggestion.
@@ -53,6 +61,11 @@ const (
 	PolicyChangeTypeTransition  PolicyChangeType = "transition"
 	PolicyChangeTypeBudget      PolicyChangeType = "budget"
 	PolicyChangeTypeApproval    PolicyChangeType = "approval"
+
+	PolicyChangeTypeToolTimeout       PolicyChangeType = "tool_timeout"
+	PolicyChangeTypeRateLimit         PolicyChangeType = "rate_limit"
+	PolicyChangeTypeApprovalExemption PolicyChangeType = "approval_exemption"
+	PolicyChangeTypeCircuitBreaker    PolicyChangeType = "circuit_breaker"
 )
 
 // PolicyChange represents a proposed change to policy.
@@ -89,3 +102,28 @@ type ApprovalChangeData struct {
 	ToolName string `json:"tool_name"`
 	Add      bool   `json:"add"` // true = require approval, false = remove requirement
 }
+
+// ToolTimeoutChangeData captures a per-tool timeout change.
+type ToolTimeoutChangeData struct {
+	ToolName string        `json:"tool_name"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// RateLimitChangeData captures a per-tool rate limit change.
+type RateLimitChangeData struct {
+	ToolName       string `json:"tool_name"`
+	CallsPerMinute int    `json:"calls_per_minute"`
+}
+
+// ApprovalExemptionChangeData captures an approval exemption change.
+type ApprovalExemptionChangeData struct {
+	ToolName string `json:"tool_name"`
+	Exempt   bool   `json:"exempt"`
+}
+
+// CircuitBreakerChangeData captures a per-tool circuit breaker change.
+type CircuitBreakerChangeData struct {
+	ToolName         string        `json:"tool_name"`
+	FailureThreshold int           `json:"failure_threshold"`
+	OpenTimeout      time.Duration `json:"open_timeout"`
+}
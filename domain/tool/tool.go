@@ -29,6 +29,18 @@ type Tool interface {
 // Handler is the function signature for tool execution.
 type Handler func(ctx context.Context, input json.RawMessage) (Result, error)
 
+// Previewer is an optional interface for tools that can describe what a
+// call would change without making the change, such as a dry-run diff.
+// The approval middleware adds the preview to approval requests.
+type Previewer interface {
+	// Preview describes the effect of a call, or returns "" when there is
+	// nothing to show.
+	Preview(ctx context.Context, input json.RawMessage) (string, error)
+}
+
+// PreviewFunc is the function signature for tool previews.
+type PreviewFunc func(ctx context.Context, input json.RawMessage) (string, error)
+
 // Definition is a concrete implementation of Tool.
 type Definition struct {
 	name         string
@@ -37,6 +49,7 @@ type Definition struct {
 	outputSchema Schema
 	annotations  Annotations
 	handler      Handler
+	preview      PreviewFunc
 }
 
 // Name returns the tool name.
@@ -72,6 +85,14 @@ func (d *Definition) Execute(ctx context.Context, input json.RawMessage) (Result
 	return d.handler(ctx, input)
 }
 
+// Preview runs the tool's preview function, if it has one.
+func (d *Definition) Preview(ctx context.Context, input json.RawMessage) (string, error) {
+	if d.preview == nil {
+		return "", nil
+	}
+	return d.preview(ctx, input)
+}
+
 // Builder provides a fluent API for constructing tools.
 type Builder struct {
 	def *Definition
@@ -192,6 +213,16 @@ func (b *Builder) WithHandler(handler Handler) *Builder {
 	return b
 }
 
+// WithPreview sets a function describing what a call would change, shown
+// to approvers before the call runs.
+func (b *Builder) WithPreview(preview PreviewFunc) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.def.preview = preview
+	return b
+}
+
 // WithTags adds tags to the tool.
 func (b *Builder) WithTags(tags ...string) *Builder {
 	if b.err != nil {
@@ -0,0 +1,100 @@
+package approval
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+)
+
+// Decision is the body of a decision request.
+type Decision struct {
+	Approved bool   `json:"approved"`
+	Approver string `json:"approver"`
+	Reason   string `json:"reason,omitempty"`
+
+	// Input optionally replaces the tool input on approval.
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// Handler returns an HTTP handler for deciding tickets, suitable as a
+// webhook target for chat and ticketing integrations:
+//
+//	GET  /approvals?status=pending&run_id=...  list tickets
+//	GET  /approvals/{id}                       get a ticket
+//	POST /approvals/{id}/decision              decide a ticket (Decision body)
+//
+// The handler does not authenticate callers; wrap it accordingly.
+func (i *Inbox) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /approvals", i.handleList)
+	mux.HandleFunc("GET /approvals/{id}", i.handleGet)
+	mux.HandleFunc("POST /approvals/{id}/decision", i.handleDecide)
+	return mux
+}
+
+func (i *Inbox) handleList(w http.ResponseWriter, r *http.Request) {
+	filter := approval.ListFilter{RunID: r.URL.Query().Get("run_id")}
+	for _, s := range r.URL.Query()["status"] {
+		filter.Status = append(filter.Status, approval.Status(s))
+	}
+	tickets, err := i.List(r.Context(), filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tickets)
+}
+
+func (i *Inbox) handleGet(w http.ResponseWriter, r *http.Request) {
+	ticket, err := i.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ticket)
+}
+
+func (i *Inbox) handleDecide(w http.ResponseWriter, r *http.Request) {
+	var d Decision
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&d); err != nil {
+		http.Error(w, "invalid decision: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if d.Approver == "" {
+		http.Error(w, "invalid decision: approver is required", http.StatusBadRequest)
+		return
+	}
+	ticket, err := i.Decide(r.Context(), r.PathValue("id"), policy.ApprovalResponse{
+		Approved:      d.Approved,
+		Approver:      d.Approver,
+		Reason:        d.Reason,
+		ModifiedInput: d.Input,
+		Timestamp:     time.Now(),
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ticket)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, approval.ErrTicketNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, approval.ErrAlreadyDecided), errors.Is(err, approval.ErrTicketExpired):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
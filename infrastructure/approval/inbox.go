@@ -0,0 +1,277 @@
+// Package approval provides the asynchronous approval inbox.
+package approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/infrastructure/logging"
+)
+
+// InboxConfig configures an approval inbox.
+type InboxConfig struct {
+	// Store persists tickets. Required. Use a durable store for pending
+	// approvals to survive restarts.
+	Store approval.Store
+
+	// Approver, if set, receives every new ticket in the background and its
+	// decision is recorded on the ticket. Any policy.Approver works,
+	// including blocking ones such as chat integrations.
+	Approver policy.Approver
+
+	// Escalation, if set, receives tickets still pending after
+	// EscalateAfter, in the background.
+	Escalation policy.Approver
+
+	// ExpireAfter is how long a ticket stays open. Zero means forever.
+	ExpireAfter time.Duration
+
+	// EscalateAfter is how long a ticket may stay pending before it is
+	// escalated. Zero disables escalation.
+	EscalateAfter time.Duration
+
+	// OnEscalate is called when a ticket is escalated. Optional.
+	OnEscalate func(ctx context.Context, ticket *approval.Ticket)
+
+	// OnDecision is called when a ticket is decided or expires, for
+	// example to resume the paused run. Optional.
+	OnDecision func(ctx context.Context, ticket *approval.Ticket)
+}
+
+// Inbox queues approval requests instead of blocking on them. As a
+// policy.Approver it opens a ticket and returns an *approval.PendingError,
+// which pauses the run; once the ticket is decided through Decide, the
+// HTTP handler, the CLI or a background approver, the engine's
+// ResumeWithApproval replays the tool call and receives the decision.
+type Inbox struct {
+	cfg InboxConfig
+	wg  sync.WaitGroup
+}
+
+// NewInbox creates an approval inbox.
+func NewInbox(cfg InboxConfig) (*Inbox, error) {
+	if cfg.Store == nil {
+		return nil, errors.New("approval store is required")
+	}
+	return &Inbox{cfg: cfg}, nil
+}
+
+// Approve implements policy.Approver. A request resumed under a ticket
+// (see approval.WithTicketID) receives that ticket's decision, provided its
+// input is the input the ticket was opened for; an approval is returned
+// once and then consumed. Any other request opens a new ticket.
+func (i *Inbox) Approve(ctx context.Context, req policy.ApprovalRequest) (policy.ApprovalResponse, error) {
+	if id, ok := approval.TicketIDFromContext(ctx); ok {
+		ticket, err := i.cfg.Store.Get(ctx, id)
+		switch {
+		case errors.Is(err, approval.ErrTicketNotFound):
+			// Fall through and open a new ticket.
+		case err != nil:
+			return policy.ApprovalResponse{}, err
+		case ticket.Request.RunID == req.RunID && ticket.Request.ToolName == req.ToolName:
+			if !ticket.Matches(req) {
+				return policy.ApprovalResponse{}, fmt.Errorf("%w: ticket %s", approval.ErrTicketMismatch, ticket.ID)
+			}
+			return i.resolve(ctx, ticket)
+		}
+	}
+	return policy.ApprovalResponse{}, i.open(ctx, req)
+}
+
+// resolve returns the decision on a ticket, expiring it if it is overdue
+// and consuming it if it is approved. It rereads the ticket when another
+// update wins the race.
+func (i *Inbox) resolve(ctx context.Context, ticket *approval.Ticket) (policy.ApprovalResponse, error) {
+	for {
+		resp, err := i.resolveOnce(ctx, ticket)
+		if !errors.Is(err, approval.ErrTicketConflict) {
+			return resp, err
+		}
+		if ticket, err = i.cfg.Store.Get(ctx, ticket.ID); err != nil {
+			return policy.ApprovalResponse{}, err
+		}
+	}
+}
+
+func (i *Inbox) resolveOnce(ctx context.Context, ticket *approval.Ticket) (policy.ApprovalResponse, error) {
+	if ticket.IsPending() && ticket.IsExpired(time.Now()) {
+		ticket.Expire()
+		if err := i.cfg.Store.Update(ctx, ticket); err != nil {
+			return policy.ApprovalResponse{}, fmt.Errorf("failed to update ticket: %w", err)
+		}
+		i.notify(ctx, ticket)
+	}
+	if ticket.IsPending() {
+		return policy.ApprovalResponse{}, &approval.PendingError{Ticket: ticket}
+	}
+	if ticket.Status == approval.StatusApproved {
+		if err := ticket.Consume(time.Now()); err != nil {
+			return policy.ApprovalResponse{}, err
+		}
+		if err := i.cfg.Store.Update(ctx, ticket); err != nil {
+			return policy.ApprovalResponse{}, fmt.Errorf("failed to update ticket: %w", err)
+		}
+	}
+	return *ticket.Response, nil
+}
+
+// open creates a ticket, hands it to the background approver and returns
+// the pending error.
+func (i *Inbox) open(ctx context.Context, req policy.ApprovalRequest) error {
+	ticket := approval.NewTicket(req)
+	if i.cfg.ExpireAfter > 0 {
+		ticket.ExpiresAt = ticket.CreatedAt.Add(i.cfg.ExpireAfter)
+	}
+	if i.cfg.EscalateAfter > 0 {
+		ticket.EscalateAt = ticket.CreatedAt.Add(i.cfg.EscalateAfter)
+	}
+	if err := i.cfg.Store.Save(ctx, ticket); err != nil {
+		return fmt.Errorf("failed to save ticket: %w", err)
+	}
+	if i.cfg.Approver != nil {
+		i.dispatch(ctx, i.cfg.Approver, ticket)
+	}
+	return &approval.PendingError{Ticket: ticket}
+}
+
+// dispatch asks an approver to decide the ticket in the background.
+func (i *Inbox) dispatch(ctx context.Context, approver policy.Approver, ticket *approval.Ticket) {
+	ctx = context.WithoutCancel(ctx)
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		if !ticket.ExpiresAt.IsZero() {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithDeadline(ctx, ticket.ExpiresAt)
+			defer cancel()
+		}
+		resp, err := approver.Approve(ctx, ticket.Request)
+		if err == nil {
+			_, err = i.Decide(ctx, ticket.ID, resp)
+		}
+		if err != nil && !errors.Is(err, approval.ErrAlreadyDecided) {
+			logging.Warn().
+				Add(logging.RunID(ticket.Request.RunID)).
+				Add(logging.Str("ticket_id", ticket.ID)).
+				Add(logging.ErrorField(err)).
+				Msg("background approval failed")
+		}
+	}()
+}
+
+// Decide records a decision on a pending ticket. Concurrent deciders race
+// on the store's revision check: one decision is recorded and notified,
+// the others get ErrAlreadyDecided.
+func (i *Inbox) Decide(ctx context.Context, id string, resp policy.ApprovalResponse) (*approval.Ticket, error) {
+	for {
+		ticket, err := i.cfg.Store.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		decideErr := ticket.Decide(resp)
+		if decideErr != nil && !errors.Is(decideErr, approval.ErrTicketExpired) {
+			return nil, decideErr
+		}
+		// An expired ticket is stored as expired before reporting the error.
+		err = i.cfg.Store.Update(ctx, ticket)
+		if errors.Is(err, approval.ErrTicketConflict) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to update ticket: %w", err)
+		}
+		i.notify(ctx, ticket)
+		return ticket, decideErr
+	}
+}
+
+// Get returns a ticket.
+func (i *Inbox) Get(ctx context.Context, id string) (*approval.Ticket, error) {
+	return i.cfg.Store.Get(ctx, id)
+}
+
+// List returns tickets matching the filter.
+func (i *Inbox) List(ctx context.Context, filter approval.ListFilter) ([]*approval.Ticket, error) {
+	return i.cfg.Store.List(ctx, filter)
+}
+
+// Sweep expires overdue tickets and escalates pending tickets past their
+// escalation time.
+func (i *Inbox) Sweep(ctx context.Context) error {
+	tickets, err := i.cfg.Store.List(ctx, approval.ListFilter{Status: []approval.Status{approval.StatusPending}})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, ticket := range tickets {
+		switch {
+		case ticket.IsExpired(now):
+			ticket.Expire()
+			err := i.cfg.Store.Update(ctx, ticket)
+			if errors.Is(err, approval.ErrTicketConflict) {
+				continue // decided meanwhile
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update ticket: %w", err)
+			}
+			i.notify(ctx, ticket)
+		case ticket.NeedsEscalation(now):
+			ticket.Escalate(now)
+			err := i.cfg.Store.Update(ctx, ticket)
+			if errors.Is(err, approval.ErrTicketConflict) {
+				continue // decided meanwhile
+			}
+			if err != nil {
+				return fmt.Errorf("failed to update ticket: %w", err)
+			}
+			if i.cfg.OnEscalate != nil {
+				i.cfg.OnEscalate(ctx, ticket)
+			}
+			if i.cfg.Escalation != nil {
+				i.dispatch(ctx, i.cfg.Escalation, ticket)
+			}
+		}
+	}
+	return nil
+}
+
+// Start sweeps the inbox at the given interval until the context is
+// cancelled.
+func (i *Inbox) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := i.Sweep(ctx); err != nil {
+				logging.Warn().
+					Add(logging.ErrorField(err)).
+					Msg("approval sweep failed")
+			}
+		}
+	}
+}
+
+// Wait blocks until background approvals have finished.
+func (i *Inbox) Wait() {
+	i.wg.Wait()
+}
+
+func (i *Inbox) notify(ctx context.Context, ticket *approval.Ticket) {
+	if i.cfg.OnDecision != nil && !ticket.IsPending() {
+		i.cfg.OnDecision(ctx, ticket)
+	}
+}
+
+// Ensure Inbox implements Approver
+var _ policy.Approver = (*Inbox)(nil)
+
+// Ensure Inbox implements TicketReader
+var _ approval.TicketReader = (*Inbox)(nil)
@@ -0,0 +1,253 @@
+package approval_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	infraApproval "github.com/felixgeelhaar/agent-go/infrastructure/approval"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
+)
+
+func newInbox(t *testing.T, cfg infraApproval.InboxConfig) *infraApproval.Inbox {
+	t.Helper()
+	if cfg.Store == nil {
+		cfg.Store = memory.NewApprovalStore()
+	}
+	inbox, err := infraApproval.NewInbox(cfg)
+	if err != nil {
+		t.Fatalf("NewInbox() error = %v", err)
+	}
+	return inbox
+}
+
+// openTicket submits a request and returns the queued ticket.
+func openTicket(t *testing.T, inbox *infraApproval.Inbox, req policy.ApprovalRequest) *approval.Ticket {
+	t.Helper()
+	_, err := inbox.Approve(context.Background(), req)
+	var pending *approval.PendingError
+	if !errors.As(err, &pending) || !errors.Is(err, agent.ErrAwaitingApproval) {
+		t.Fatalf("expected a pending error, got %v", err)
+	}
+	return pending.Ticket
+}
+
+func TestNewInbox_RequiresStore(t *testing.T) {
+	if _, err := infraApproval.NewInbox(infraApproval.InboxConfig{}); err == nil {
+		t.Error("expected error without a store")
+	}
+}
+
+func TestInbox_DecideAndResume(t *testing.T) {
+	var decided []*approval.Ticket
+	inbox := newInbox(t, infraApproval.InboxConfig{
+		OnDecision: func(_ context.Context, ticket *approval.Ticket) { decided = append(decided, ticket) },
+	})
+	req := policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"}
+	ticket := openTicket(t, inbox, req)
+
+	// Still pending when resumed under the ticket.
+	resumeCtx := approval.WithTicketID(context.Background(), ticket.ID)
+	if _, err := inbox.Approve(resumeCtx, req); !errors.Is(err, agent.ErrAwaitingApproval) {
+		t.Fatalf("expected pending, got %v", err)
+	}
+
+	if _, err := inbox.Decide(context.Background(), ticket.ID, policy.ApprovalResponse{Approved: true, Approver: "alice"}); err != nil {
+		t.Fatalf("Decide() error = %v", err)
+	}
+	if len(decided) != 1 || decided[0].Status != approval.StatusApproved {
+		t.Errorf("OnDecision not called with the approved ticket: %+v", decided)
+	}
+
+	resp, err := inbox.Approve(resumeCtx, req)
+	if err != nil || !resp.Approved || resp.Approver != "alice" {
+		t.Errorf("expected the recorded approval, got %+v, %v", resp, err)
+	}
+
+	// A ticket for another tool does not apply.
+	if _, err := inbox.Approve(resumeCtx, policy.ApprovalRequest{RunID: "run-1", ToolName: "delete"}); !errors.Is(err, agent.ErrAwaitingApproval) {
+		t.Errorf("expected a new pending ticket, got %v", err)
+	}
+
+	if _, err := inbox.Decide(context.Background(), ticket.ID, policy.ApprovalResponse{Approved: false, Approver: "bob"}); !errors.Is(err, approval.ErrAlreadyDecided) {
+		t.Errorf("expected ErrAlreadyDecided, got %v", err)
+	}
+}
+
+func TestInbox_ResumeMatchesTicket(t *testing.T) {
+	inbox := newInbox(t, infraApproval.InboxConfig{})
+	req := policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy", Input: json.RawMessage(`{"replicas": 2}`)}
+	ticket := openTicket(t, inbox, req)
+	if _, err := inbox.Decide(context.Background(), ticket.ID, policy.ApprovalResponse{Approved: true}); err != nil {
+		t.Fatalf("Decide() error = %v", err)
+	}
+	resumeCtx := approval.WithTicketID(context.Background(), ticket.ID)
+
+	other := req
+	other.Input = json.RawMessage(`{"replicas":200}`)
+	if _, err := inbox.Approve(resumeCtx, other); !errors.Is(err, approval.ErrTicketMismatch) {
+		t.Errorf("expected ErrTicketMismatch for other input, got %v", err)
+	}
+
+	req.Input = json.RawMessage(`{"replicas":2}`)
+	if resp, err := inbox.Approve(resumeCtx, req); err != nil || !resp.Approved {
+		t.Fatalf("expected the approval, got %+v, %v", resp, err)
+	}
+	if _, err := inbox.Approve(resumeCtx, req); !errors.Is(err, approval.ErrTicketConsumed) {
+		t.Errorf("expected ErrTicketConsumed on reuse, got %v", err)
+	}
+}
+
+func TestInbox_ConcurrentDecisions(t *testing.T) {
+	var notified atomic.Int32
+	inbox := newInbox(t, infraApproval.InboxConfig{
+		OnDecision: func(context.Context, *approval.Ticket) { notified.Add(1) },
+	})
+	ticket := openTicket(t, inbox, policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"})
+
+	const deciders = 16
+	var wg sync.WaitGroup
+	var decided atomic.Int32
+	start := make(chan struct{})
+	for n := range deciders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, err := inbox.Decide(context.Background(), ticket.ID, policy.ApprovalResponse{Approved: n%2 == 0})
+			switch {
+			case err == nil:
+				decided.Add(1)
+			case !errors.Is(err, approval.ErrAlreadyDecided):
+				t.Errorf("Decide() error = %v", err)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if decided.Load() != 1 || notified.Load() != 1 {
+		t.Errorf("expected one decision and one notification, got %d and %d", decided.Load(), notified.Load())
+	}
+}
+
+func TestInbox_BackgroundApprover(t *testing.T) {
+	inbox := newInbox(t, infraApproval.InboxConfig{Approver: policy.NewAutoApprover("bot")})
+	ticket := openTicket(t, inbox, policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"})
+	inbox.Wait()
+
+	got, err := inbox.Get(context.Background(), ticket.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != approval.StatusApproved || got.Response.Approver != "bot" {
+		t.Errorf("expected the background approver's decision, got %+v", got)
+	}
+}
+
+func TestInbox_Expiry(t *testing.T) {
+	inbox := newInbox(t, infraApproval.InboxConfig{ExpireAfter: time.Millisecond})
+	req := policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"}
+	ticket := openTicket(t, inbox, req)
+	time.Sleep(5 * time.Millisecond)
+
+	resp, err := inbox.Approve(approval.WithTicketID(context.Background(), ticket.ID), req)
+	if err != nil || resp.Approved {
+		t.Fatalf("expected an expired denial, got %+v, %v", resp, err)
+	}
+	got, _ := inbox.Get(context.Background(), ticket.ID)
+	if got.Status != approval.StatusExpired {
+		t.Errorf("expected expired status, got %s", got.Status)
+	}
+}
+
+func TestInbox_Sweep(t *testing.T) {
+	escalated := make(chan string, 1)
+	store := memory.NewApprovalStore()
+	inbox := newInbox(t, infraApproval.InboxConfig{
+		Store:         store,
+		EscalateAfter: time.Millisecond,
+		Escalation:    policy.NewDenyApprover("escalation denied"),
+		OnEscalate:    func(_ context.Context, ticket *approval.Ticket) { escalated <- ticket.ID },
+	})
+	escalate := openTicket(t, inbox, policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"})
+
+	expire := approval.NewTicket(policy.ApprovalRequest{RunID: "run-2", ToolName: "delete"})
+	expire.ExpiresAt = time.Now().Add(-time.Second)
+	if err := store.Save(context.Background(), expire); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if err := inbox.Sweep(context.Background()); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	inbox.Wait()
+
+	if id := <-escalated; id != escalate.ID {
+		t.Errorf("escalated %s, want %s", id, escalate.ID)
+	}
+	got, _ := inbox.Get(context.Background(), escalate.ID)
+	if got.EscalatedAt == nil || got.Status != approval.StatusDenied || got.Response.Reason != "escalation denied" {
+		t.Errorf("expected the escalation approver's decision, got %+v", got)
+	}
+	got, _ = inbox.Get(context.Background(), expire.ID)
+	if got.Status != approval.StatusExpired {
+		t.Errorf("expected expired status, got %s", got.Status)
+	}
+}
+
+func TestInbox_Handler(t *testing.T) {
+	inbox := newInbox(t, infraApproval.InboxConfig{})
+	ticket := openTicket(t, inbox, policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"})
+	server := httptest.NewServer(inbox.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/approvals?status=pending")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	var tickets []approval.Ticket
+	_ = json.NewDecoder(resp.Body).Decode(&tickets)
+	resp.Body.Close()
+	if len(tickets) != 1 || tickets[0].ID != ticket.ID {
+		t.Fatalf("expected the pending ticket, got %+v", tickets)
+	}
+
+	decide := func(body string) int {
+		resp, err := http.Post(server.URL+"/approvals/"+ticket.ID+"/decision", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST error = %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := decide(`{"approved":true}`); code != http.StatusBadRequest {
+		t.Errorf("missing approver: status %d, want 400", code)
+	}
+	if code := decide(`{"approved":true,"approver":"alice"}`); code != http.StatusOK {
+		t.Errorf("decision: status %d, want 200", code)
+	}
+	if code := decide(`{"approved":false,"approver":"bob"}`); code != http.StatusConflict {
+		t.Errorf("second decision: status %d, want 409", code)
+	}
+
+	resp, err = http.Get(server.URL + "/approvals/unknown")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown ticket: status %d, want 404", resp.StatusCode)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
@@ -42,6 +43,11 @@ type BuildResult struct {
 	InlineTools []InlineToolDef
 	// RateLimitConfig contains rate limiting configuration.
 	RateLimitConfig *RateLimitBuildResult
+	// Rules are the compiled policy rules, or nil if none are configured.
+	Rules *policy.RuleSet
+	// ApprovalPolicy decides which tools require approval, or nil to use
+	// the tool annotations.
+	ApprovalPolicy *policy.ApprovalPolicy
 }
 
 // ToolPackRequest represents a request to load a tool pack.
@@ -173,6 +179,39 @@ func (b *Builder) buildPolicy(result *BuildResult) error {
 		result.Budgets[name] = limit
 	}
 
+	// Compile rules
+	if len(b.config.Policy.Rules) > 0 {
+		defs := make([]policy.RuleDefinition, len(b.config.Policy.Rules))
+		for i, r := range b.config.Policy.Rules {
+			defs[i] = policy.RuleDefinition{Name: r.Name, Rule: r.Rule, Reason: r.Reason}
+		}
+		rules, err := policy.CompileRules(defs)
+		if err != nil {
+			return err
+		}
+		result.Rules = rules
+	}
+
+	// Build approval policy
+	approval := b.config.Policy.Approval
+	if approval.RequireForDestructive || approval.RequireForRiskLevel != "" ||
+		len(approval.RequireForTools) > 0 || len(approval.ExemptTools) > 0 {
+		p := &policy.ApprovalPolicy{
+			RequireForDestructive: approval.RequireForDestructive,
+			RequireForTools:       append([]string(nil), approval.RequireForTools...),
+			ExemptTools:           append([]string(nil), approval.ExemptTools...),
+			RiskThreshold:         tool.RiskHigh,
+		}
+		if approval.RequireForRiskLevel != "" {
+			level, err := parseRiskLevel(strings.ToLower(approval.RequireForRiskLevel))
+			if err != nil {
+				return err
+			}
+			p.RiskThreshold = level
+		}
+		result.ApprovalPolicy = p
+	}
+
 	// Build rate limit config
 	if b.config.Policy.RateLimit.Enabled {
 		result.RateLimitConfig = &RateLimitBuildResult{
@@ -6,6 +6,7 @@ import (
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	domainconfig "github.com/felixgeelhaar/agent-go/domain/config"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
 )
 
 func TestBuilder_BasicBuild(t *testing.T) {
@@ -186,6 +187,46 @@ func TestBuilder_RateLimit(t *testing.T) {
 	}
 }
 
+func TestBuilder_ApprovalPolicy(t *testing.T) {
+	cfg := &domainconfig.AgentConfig{
+		Name:    "test-agent",
+		Version: "1.0",
+		Policy: domainconfig.PolicyConfig{
+			Approval: domainconfig.ApprovalConfig{
+				RequireForDestructive: true,
+				RequireForRiskLevel:   "Medium",
+				RequireForTools:       []string{"send_email"},
+				ExemptTools:           []string{"cleanup"},
+			},
+		},
+	}
+
+	result, err := NewBuilder(cfg).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	p := result.ApprovalPolicy
+	if p == nil {
+		t.Fatal("ApprovalPolicy is nil")
+	}
+	if !p.RequireForDestructive || p.RiskThreshold != tool.RiskMedium {
+		t.Errorf("unexpected policy: %+v", p)
+	}
+	if !p.Requires("send_email", tool.Annotations{}) || p.Requires("cleanup", tool.Annotations{Destructive: true}) {
+		t.Errorf("tool lists not applied: %+v", p)
+	}
+
+	// Mode alone keeps the annotation defaults.
+	cfg.Policy.Approval = domainconfig.ApprovalConfig{Mode: "manual"}
+	result, err = NewBuilder(cfg).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if result.ApprovalPolicy != nil {
+		t.Errorf("expected no approval policy, got %+v", result.ApprovalPolicy)
+	}
+}
+
 func TestBuilder_ToolPacks(t *testing.T) {
 	cfg := &domainconfig.AgentConfig{
 		Name:    "test-agent",
@@ -0,0 +1,228 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/felixgeelhaar/agent-go/domain/config"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// PolicyFixture is a set of test cases for declarative policy rules.
+//
+// Example (YAML):
+//
+//	rules:
+//	  - name: prod-scale
+//	    rule: 'tool.tags contains "prod" && input.replicas > 10 => require_approval'
+//	cases:
+//	  - name: large prod scale needs approval
+//	    state: act
+//	    tool: {name: scale, tags: [prod]}
+//	    input: {replicas: 12}
+//	    expect: require_approval
+//	    expect_rule: prod-scale
+type PolicyFixture struct {
+	// Rules are the rules under test. They are ignored when the runner is
+	// given rules from an agent configuration.
+	Rules []config.RuleConfig `json:"rules,omitempty" yaml:"rules,omitempty"`
+	// Cases are the test cases.
+	Cases []PolicyTestCase `json:"cases" yaml:"cases"`
+}
+
+// PolicyTestCase describes a tool call or transition and the expected
+// rule decision.
+type PolicyTestCase struct {
+	// Name identifies the case in reports.
+	Name string `json:"name" yaml:"name"`
+	// State is the current state.
+	State string `json:"state" yaml:"state"`
+	// To makes the case a transition to this state.
+	To string `json:"to,omitempty" yaml:"to,omitempty"`
+	// Tool describes the tool being called.
+	Tool *PolicyTestTool `json:"tool,omitempty" yaml:"tool,omitempty"`
+	// Input is the tool input.
+	Input map[string]any `json:"input,omitempty" yaml:"input,omitempty"`
+	// Vars are the run variables.
+	Vars map[string]any `json:"vars,omitempty" yaml:"vars,omitempty"`
+	// Evidence is the number of evidence items gathered.
+	Evidence int `json:"evidence,omitempty" yaml:"evidence,omitempty"`
+	// Calls are the tool calls completed earlier in the run.
+	Calls []PolicyTestCall `json:"calls,omitempty" yaml:"calls,omitempty"`
+	// Budgets maps budget names to remaining amounts.
+	Budgets map[string]int `json:"budgets,omitempty" yaml:"budgets,omitempty"`
+	// Expect is the expected effect: allow, deny, require_approval or none.
+	Expect string `json:"expect" yaml:"expect"`
+	// ExpectRule optionally names the rule expected to decide.
+	ExpectRule string `json:"expect_rule,omitempty" yaml:"expect_rule,omitempty"`
+}
+
+// PolicyTestTool describes the tool of a test case.
+type PolicyTestTool struct {
+	Name             string   `json:"name" yaml:"name"`
+	Tags             []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	ReadOnly         bool     `json:"read_only,omitempty" yaml:"read_only,omitempty"`
+	Destructive      bool     `json:"destructive,omitempty" yaml:"destructive,omitempty"`
+	Idempotent       bool     `json:"idempotent,omitempty" yaml:"idempotent,omitempty"`
+	Cacheable        bool     `json:"cacheable,omitempty" yaml:"cacheable,omitempty"`
+	RequiresApproval bool     `json:"requires_approval,omitempty" yaml:"requires_approval,omitempty"`
+	Risk             string   `json:"risk,omitempty" yaml:"risk,omitempty"`
+}
+
+// PolicyTestCall is a completed tool call in a test case's history.
+type PolicyTestCall struct {
+	Tool  string `json:"tool" yaml:"tool"`
+	State string `json:"state" yaml:"state"`
+}
+
+// PolicyTestResult is the outcome of one test case.
+type PolicyTestResult struct {
+	// Case is the case name.
+	Case string
+	// Passed reports whether the decision matched the expectation.
+	Passed bool
+	// Effect is the effect the rules decided.
+	Effect policy.RuleEffect
+	// Rule is the name of the deciding rule, if any.
+	Rule string
+	// Message explains a failure.
+	Message string
+}
+
+// LoadPolicyFixture loads a policy fixture from a YAML or JSON file.
+func LoadPolicyFixture(path string) (*PolicyFixture, error) {
+	cleanPath := filepath.Clean(path)
+	data, err := os.ReadFile(cleanPath) // #nosec G304 - path is cleaned above
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy fixture: %w", err)
+	}
+
+	var fixture PolicyFixture
+	switch ext := strings.ToLower(filepath.Ext(cleanPath)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fixture)
+	case ".json":
+		err = json.Unmarshal(data, &fixture)
+	default:
+		return nil, fmt.Errorf("%w: %s", config.ErrUnsupportedFormat, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", config.ErrInvalidFormat, err)
+	}
+	return &fixture, nil
+}
+
+// RunPolicyFixture evaluates the fixture's cases against rules, or against
+// the fixture's own rules when rules is nil.
+func RunPolicyFixture(rules *policy.RuleSet, fixture *PolicyFixture) ([]PolicyTestResult, error) {
+	if rules == nil {
+		defs := make([]policy.RuleDefinition, len(fixture.Rules))
+		for i, r := range fixture.Rules {
+			defs[i] = policy.RuleDefinition{Name: r.Name, Rule: r.Rule, Reason: r.Reason}
+		}
+		var err error
+		if rules, err = policy.CompileRules(defs); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]PolicyTestResult, 0, len(fixture.Cases))
+	for i, tc := range fixture.Cases {
+		name := tc.Name
+		if name == "" {
+			name = fmt.Sprintf("case %d", i+1)
+		}
+		ctx, err := tc.constraintContext()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		decision := rules.Decide(ctx)
+		result := PolicyTestResult{Case: name, Effect: decision.Effect, Passed: true}
+		if decision.Rule != nil {
+			result.Rule = decision.Rule.Name
+		}
+
+		got := string(decision.Effect)
+		if got == "" {
+			got = "none"
+		}
+		switch {
+		case tc.Expect != got:
+			result.Passed = false
+			result.Message = fmt.Sprintf("expected %s, got %s", tc.Expect, got)
+		case tc.ExpectRule != "" && tc.ExpectRule != result.Rule:
+			result.Passed = false
+			result.Message = fmt.Sprintf("expected rule %s, got %q", tc.ExpectRule, result.Rule)
+		}
+		if !result.Passed && result.Rule != "" {
+			result.Message += " (rule " + result.Rule + ")"
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (tc PolicyTestCase) constraintContext() (policy.ConstraintContext, error) {
+	switch tc.Expect {
+	case "allow", "deny", "require_approval", "none":
+	default:
+		return policy.ConstraintContext{}, fmt.Errorf("invalid expect %q: must be allow, deny, require_approval or none", tc.Expect)
+	}
+
+	state, err := parseState(tc.State)
+	if err != nil {
+		return policy.ConstraintContext{}, err
+	}
+	ctx := policy.ConstraintContext{
+		RunID:         "policy-test",
+		CurrentState:  state,
+		Vars:          tc.Vars,
+		EvidenceCount: tc.Evidence,
+		Budget:        policy.NewBudget(tc.Budgets),
+	}
+
+	if tc.To != "" {
+		if ctx.ToState, err = parseState(tc.To); err != nil {
+			return policy.ConstraintContext{}, err
+		}
+	} else {
+		if tc.Tool == nil || tc.Tool.Name == "" {
+			return policy.ConstraintContext{}, fmt.Errorf("a case needs a tool or a transition target")
+		}
+		ctx.ToolName = tc.Tool.Name
+		ctx.Annotations = tool.Annotations{
+			ReadOnly:         tc.Tool.ReadOnly,
+			Destructive:      tc.Tool.Destructive,
+			Idempotent:       tc.Tool.Idempotent,
+			Cacheable:        tc.Tool.Cacheable,
+			RequiresApproval: tc.Tool.RequiresApproval,
+			Tags:             tc.Tool.Tags,
+		}
+		if tc.Tool.Risk != "" {
+			if ctx.Annotations.RiskLevel, err = parseRiskLevel(tc.Tool.Risk); err != nil {
+				return policy.ConstraintContext{}, err
+			}
+		}
+		if tc.Input != nil {
+			if ctx.Input, err = json.Marshal(tc.Input); err != nil {
+				return policy.ConstraintContext{}, fmt.Errorf("invalid input: %w", err)
+			}
+		}
+	}
+
+	for _, call := range tc.Calls {
+		callState, err := parseState(call.State)
+		if err != nil {
+			return policy.ConstraintContext{}, err
+		}
+		ctx.History = append(ctx.History, policy.ToolCallRecord{ToolName: call.Tool, State: callState})
+	}
+	return ctx, nil
+}
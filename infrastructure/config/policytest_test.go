@@ -0,0 +1,136 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	domainconfig "github.com/felixgeelhaar/agent-go/domain/config"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+)
+
+const testPolicyFixture = `
+rules:
+  - name: prod-scale
+    rule: 'tool.tags contains "prod" && input.replicas > 10 => require_approval'
+  - name: explore-first
+    rule: 'to == "act" && run.evidence < 2 => deny'
+    reason: gather evidence first
+cases:
+  - name: large prod scale
+    state: act
+    tool: {name: scale, tags: [prod], risk: medium}
+    input: {replicas: 12}
+    expect: require_approval
+    expect_rule: prod-scale
+  - name: small prod scale
+    state: act
+    tool: {name: scale, tags: [prod]}
+    input: {replicas: 2}
+    expect: none
+  - name: acting too early
+    state: decide
+    to: act
+    evidence: 1
+    calls: [{tool: search, state: explore}]
+    expect: deny
+  - name: wrong expectation
+    state: decide
+    to: act
+    expect: allow
+`
+
+func writePolicyFixture(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy_test.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestRunPolicyFixture(t *testing.T) {
+	fixture, err := LoadPolicyFixture(writePolicyFixture(t, testPolicyFixture))
+	if err != nil {
+		t.Fatalf("LoadPolicyFixture() error = %v", err)
+	}
+
+	results, err := RunPolicyFixture(nil, fixture)
+	if err != nil {
+		t.Fatalf("RunPolicyFixture() error = %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %d", len(results))
+	}
+	for _, r := range results[:3] {
+		if !r.Passed {
+			t.Errorf("%s failed: %s", r.Case, r.Message)
+		}
+	}
+	if last := results[3]; last.Passed || last.Message != "expected allow, got deny (rule explore-first)" {
+		t.Errorf("unexpected result for the wrong expectation: %+v", last)
+	}
+
+	// Rules passed in replace the fixture's own.
+	rules := policy.MustCompileRules(policy.RuleDefinition{Rule: `tool.name == "scale" => deny`})
+	results, err = RunPolicyFixture(rules, fixture)
+	if err != nil {
+		t.Fatalf("RunPolicyFixture() error = %v", err)
+	}
+	if results[0].Passed || results[0].Effect != policy.EffectDeny {
+		t.Errorf("expected the supplied rules to decide, got %+v", results[0])
+	}
+}
+
+func TestRunPolicyFixture_InvalidCases(t *testing.T) {
+	for _, content := range []string{
+		"cases:\n  - {state: act, tool: {name: x}, expect: maybe}\n",
+		"cases:\n  - {state: nowhere, tool: {name: x}, expect: none}\n",
+		"cases:\n  - {state: act, expect: none}\n",
+		"rules:\n  - rule: 'x =>'\ncases: []\n",
+	} {
+		fixture, err := LoadPolicyFixture(writePolicyFixture(t, content))
+		if err != nil {
+			t.Fatalf("LoadPolicyFixture() error = %v", err)
+		}
+		if _, err := RunPolicyFixture(nil, fixture); err == nil {
+			t.Errorf("expected an error for fixture %q", content)
+		}
+	}
+
+	if _, err := LoadPolicyFixture(filepath.Join(t.TempDir(), "fixture.txt")); err == nil {
+		t.Error("expected an error for a missing fixture")
+	}
+	path := writePolicyFixture(t, "cases: [")
+	if _, err := LoadPolicyFixture(path); !errors.Is(err, domainconfig.ErrInvalidFormat) {
+		t.Errorf("expected ErrInvalidFormat, got %v", err)
+	}
+}
+
+func TestBuilder_Rules(t *testing.T) {
+	cfg := &domainconfig.AgentConfig{
+		Name:    "test-agent",
+		Version: "1.0",
+		Policy: domainconfig.PolicyConfig{
+			Rules: []domainconfig.RuleConfig{
+				{Name: "no-prod-deletes", Rule: `tool.destructive && vars.env == "prod" => deny`},
+			},
+		},
+	}
+	result, err := NewBuilder(cfg).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if result.Rules == nil || len(result.Rules.Rules()) != 1 || result.Rules.Rules()[0].Name != "no-prod-deletes" {
+		t.Fatalf("unexpected rules: %+v", result.Rules)
+	}
+
+	cfg.Policy.Rules[0].Rule = "tool.destructive =>"
+	if _, err := NewBuilder(cfg).Build(); !errors.Is(err, policy.ErrInvalidRule) {
+		t.Errorf("expected ErrInvalidRule, got %v", err)
+	}
+	if errs := domainconfig.NewValidator().Validate(cfg); !errs.HasErrors() {
+		t.Error("expected the validator to reject the rule")
+	}
+}
@@ -294,9 +294,19 @@ func generatePolicySchema() *JSONSchema {
 					},
 					"require_for_risk_level": {
 						Type:        "string",
-						Description: "Require approval above this risk level",
+						Description: "Require approval at or above this risk level",
 						Enum:        []string{"none", "low", "medium", "high", "critical"},
 					},
+					"require_for_tools": {
+						Type:        "array",
+						Description: "Tools that always require approval",
+						Items:       &JSONSchema{Type: "string"},
+					},
+					"exempt_tools": {
+						Type:        "array",
+						Description: "Tools that never require approval",
+						Items:       &JSONSchema{Type: "string"},
+					},
 				},
 			},
 			"transitions": {
@@ -322,6 +332,28 @@ func generatePolicySchema() *JSONSchema {
 				},
 			},
 			"rate_limit": generateRateLimitSchema(),
+			"rules": {
+				Type:        "array",
+				Description: "Declarative policy rules, evaluated in order; the first match decides",
+				Items: &JSONSchema{
+					Type:     "object",
+					Required: []string{"rule"},
+					Properties: map[string]*JSONSchema{
+						"name": {
+							Type:        "string",
+							Description: "Rule name",
+						},
+						"rule": {
+							Type:        "string",
+							Description: "Rule of the form '<condition> => allow|deny|require_approval'",
+						},
+						"reason": {
+							Type:        "string",
+							Description: "Explanation recorded with denials and approval requests",
+						},
+					},
+				},
+			},
 		},
 	}
 }
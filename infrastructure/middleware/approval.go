@@ -2,23 +2,59 @@ package middleware
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/event"
+	"github.com/felixgeelhaar/agent-go/domain/ledger"
 	"github.com/felixgeelhaar/agent-go/domain/middleware"
 	"github.com/felixgeelhaar/agent-go/domain/policy"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/logging"
 )
 
 // ApprovalConfig configures the approval middleware.
 type ApprovalConfig struct {
 	// Approver handles approval requests.
 	Approver policy.Approver
+
+	// Policy decides which tools require approval. When nil, the tool
+	// annotations decide (destructive, high-risk or explicitly marked).
+	Policy *policy.ApprovalPolicy
+
+	// Snapshot lists tools that require approval under the active policy
+	// version, in addition to those Policy requires, and tools the version
+	// exempts from approval. Tools exempted by Policy stay exempt; only
+	// require_approval rules override a snapshot exemption. A snapshot
+	// cannot exempt tools whose annotations demand approval (destructive,
+	// critical risk or RequiresApproval). Optional.
+	Snapshot *policy.ApprovalSnapshot
+
+	// Rules can require approval for calls the policy does not flag,
+	// through require_approval rules. Optional.
+	Rules *policy.RuleSet
+
+	// Constraints are re-evaluated, together with the deny rules in Rules,
+	// on input an approver modified, so an edit cannot produce a call the
+	// policy would deny. Optional.
+	Constraints []policy.Constraint
+
+	// Events receives approval.requested, approval.granted and
+	// approval.denied events. Optional.
+	Events event.Store
 }
 
 // Approval returns middleware that enforces approval for high-risk tools.
-// Tools that require approval (destructive, high-risk, or explicitly marked)
-// must be approved before execution.
+// Tools that require approval (under the approval policy, the policy
+// version's snapshot, or a require_approval rule) must be approved before
+// execution. The preview of tools implementing tool.Previewer is appended
+// to the request's reason. Every request and decision is recorded in the run ledger, when
+// the execution context carries one, and in the event store.
 func Approval(cfg ApprovalConfig) middleware.Middleware {
 	return func(next middleware.Handler) middleware.Handler {
 		return func(ctx context.Context, execCtx *middleware.ExecutionContext) (tool.Result, error) {
@@ -26,7 +62,7 @@ func Approval(cfg ApprovalConfig) middleware.Middleware {
 			annotations := t.Annotations()
 
 			// Check if approval is required
-			if !annotations.ShouldRequireApproval() {
+			if !ApprovalRequired(cfg, execCtx) {
 				return next(ctx, execCtx)
 			}
 
@@ -36,21 +72,39 @@ func Approval(cfg ApprovalConfig) middleware.Middleware {
 					tool.ErrApprovalRequired, t.Name())
 			}
 
+			// Show approvers what the call would change, when the tool can tell
+			reason := execCtx.Reason
+			if previewer, ok := t.(tool.Previewer); ok {
+				preview, err := previewer.Preview(ctx, execCtx.Input)
+				if err != nil {
+					return tool.Result{}, fmt.Errorf("preview failed: %w", err)
+				}
+				if preview != "" {
+					reason = strings.TrimSpace(reason + "\n\n" + preview)
+				}
+			}
+
 			// Build approval request
 			req := policy.ApprovalRequest{
 				RunID:     execCtx.RunID,
 				ToolName:  t.Name(),
 				Input:     execCtx.Input,
-				Reason:    execCtx.Reason,
+				Reason:    reason,
 				RiskLevel: annotations.RiskLevel.String(),
 				Timestamp: time.Now(),
 			}
+			req.Requester, _ = policy.RequesterFromContext(ctx)
+			// A call replayed under an approval ticket was already requested
+			if _, resumed := approval.TicketIDFromContext(ctx); !resumed {
+				recordApprovalRequest(ctx, cfg, execCtx, req)
+			}
 
 			// Request approval
 			resp, err := cfg.Approver.Approve(ctx, req)
 			if err != nil {
 				return tool.Result{}, fmt.Errorf("approval error: %w", err)
 			}
+			recordApprovalResult(ctx, cfg, execCtx, req, resp)
 
 			if !resp.Approved {
 				reason := "approval denied"
@@ -60,7 +114,203 @@ func Approval(cfg ApprovalConfig) middleware.Middleware {
 				return tool.Result{}, fmt.Errorf("%w: %s", tool.ErrApprovalDenied, reason)
 			}
 
+			if err := applyModifiedInput(cfg, execCtx, resp); err != nil {
+				return tool.Result{}, err
+			}
+
 			return next(ctx, execCtx)
 		}
 	}
 }
+
+// ApprovalRequired reports whether the tool call needs approval under the
+// configuration's policy, snapshot and rules. The Approver is not consulted.
+func ApprovalRequired(cfg ApprovalConfig, execCtx *middleware.ExecutionContext) bool {
+	name := execCtx.Tool.Name()
+	annotations := execCtx.Tool.Annotations()
+
+	var required bool
+	if cfg.Policy != nil {
+		required = cfg.Policy.Requires(name, annotations)
+		if !required && cfg.Snapshot != nil && !cfg.Policy.IsExempt(name) {
+			required = cfg.Snapshot.IsRequired(name)
+		}
+	} else {
+		required = annotations.ShouldRequireApproval() ||
+			(cfg.Snapshot != nil && cfg.Snapshot.IsRequired(name))
+	}
+	if required && cfg.Snapshot != nil && cfg.Snapshot.IsExempt(name) && !exemptionForbidden(annotations) {
+		required = false
+	}
+	if !required && cfg.Rules != nil {
+		required, _ = cfg.Rules.RequiresApproval(ConstraintContext(execCtx))
+	}
+	return required
+}
+
+// exemptionForbidden reports whether the annotations demand approval that a
+// policy version's exemption must not lift. Exemptions come from accepted
+// proposals, so they only apply to tools that are safe to run unattended.
+func exemptionForbidden(a tool.Annotations) bool {
+	return a.RequiresApproval || a.Destructive || a.RiskLevel >= tool.RiskCritical
+}
+
+func recordApprovalRequest(ctx context.Context, cfg ApprovalConfig, execCtx *middleware.ExecutionContext, req policy.ApprovalRequest) {
+	if execCtx.Ledger != nil {
+		execCtx.Ledger.RecordApprovalRequest(execCtx.CurrentState, req.ToolName, req.Input, req.RiskLevel)
+	}
+	appendApprovalEvent(ctx, cfg.Events, req.RunID, event.TypeApprovalRequested, event.ApprovalRequestedPayload{
+		ToolName:  req.ToolName,
+		Input:     req.Input,
+		RiskLevel: req.RiskLevel,
+	})
+}
+
+func recordApprovalResult(ctx context.Context, cfg ApprovalConfig, execCtx *middleware.ExecutionContext, req policy.ApprovalRequest, resp policy.ApprovalResponse) {
+	if execCtx.Ledger != nil {
+		for _, vote := range resp.Votes {
+			execCtx.Ledger.RecordApprovalVote(execCtx.CurrentState, ledger.ApprovalVoteDetails{
+				ToolName:      req.ToolName,
+				Group:         vote.Group,
+				Approver:      vote.Approver,
+				Approved:      vote.Approved,
+				Justification: vote.Justification,
+				Counted:       vote.Counted,
+				Rejected:      vote.Rejected,
+			})
+		}
+		execCtx.Ledger.RecordApprovalResult(execCtx.CurrentState, req.ToolName, resp.Approved, resp.Approver, resp.Reason)
+	}
+	eventType := event.TypeApprovalGranted
+	if !resp.Approved {
+		eventType = event.TypeApprovalDenied
+	}
+	appendApprovalEvent(ctx, cfg.Events, req.RunID, eventType, event.ApprovalResultPayload{
+		ToolName:      req.ToolName,
+		Approver:      resp.Approver,
+		Reason:        resp.Reason,
+		ModifiedInput: resp.ModifiedInput,
+	})
+}
+
+// applyModifiedInput replaces the tool input with the input the approver
+// approved, after validating it against the tool schema and re-evaluating
+// the constraints and rules on it, and records the changes in the ledger.
+func applyModifiedInput(cfg ApprovalConfig, execCtx *middleware.ExecutionContext, resp policy.ApprovalResponse) error {
+	if len(resp.ModifiedInput) == 0 {
+		return nil
+	}
+	if err := validateInput(execCtx.Tool, resp.ModifiedInput, false); err != nil {
+		return fmt.Errorf("%w: approved input: %v", tool.ErrInvalidInput, err)
+	}
+	changes, err := diffInput(execCtx.Input, resp.ModifiedInput)
+	if err != nil {
+		return fmt.Errorf("%w: approved input: %v", tool.ErrInvalidInput, err)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := checkApprovedInput(cfg, execCtx, resp.ModifiedInput); err != nil {
+		return err
+	}
+
+	if execCtx.Ledger != nil {
+		execCtx.Ledger.RecordApprovalInputModified(execCtx.CurrentState, ledger.ApprovalInputDetails{
+			ToolName:      execCtx.Tool.Name(),
+			Approver:      resp.Approver,
+			OriginalInput: execCtx.Input,
+			ApprovedInput: resp.ModifiedInput,
+			Changes:       changes,
+		})
+	}
+	execCtx.Input = resp.ModifiedInput
+	return nil
+}
+
+// checkApprovedInput evaluates the constraints and deny rules against the
+// call with the approved input, recording a violation in the ledger.
+func checkApprovedInput(cfg ApprovalConfig, execCtx *middleware.ExecutionContext, input json.RawMessage) error {
+	approved := *execCtx
+	approved.Input = input
+	cc := ConstraintContext(&approved)
+
+	ok, reason := policy.EvaluateConstraints(cc, cfg.Constraints...)
+	if ok && cfg.Rules != nil {
+		ok, reason = cfg.Rules.Evaluate(cc)
+	}
+	if ok {
+		return nil
+	}
+	if execCtx.Ledger != nil {
+		execCtx.Ledger.RecordConstraintViolation(execCtx.CurrentState, execCtx.Tool.Name(), "", reason)
+	}
+	return fmt.Errorf("%w: approved input: %s", policy.ErrConstraintViolation, reason)
+}
+
+// diffInput lists the values that differ between two JSON documents.
+// Objects are compared key by key; other values are compared whole.
+func diffInput(before, after json.RawMessage) ([]ledger.InputChange, error) {
+	var a, b any
+	if len(before) > 0 {
+		if err := json.Unmarshal(before, &a); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(after, &b); err != nil {
+		return nil, err
+	}
+	var changes []ledger.InputChange
+	diffValue("", a, b, &changes)
+	return changes, nil
+}
+
+func diffValue(path string, before, after any, changes *[]ledger.InputChange) {
+	beforeObj, beforeIsObj := before.(map[string]any)
+	afterObj, afterIsObj := after.(map[string]any)
+	if beforeIsObj && afterIsObj {
+		keys := make([]string, 0, len(beforeObj)+len(afterObj))
+		for k := range beforeObj {
+			keys = append(keys, k)
+		}
+		for k := range afterObj {
+			if _, ok := beforeObj[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		escaper := strings.NewReplacer("~", "~0", "/", "~1")
+		for _, k := range keys {
+			diffValue(path+"/"+escaper.Replace(k), beforeObj[k], afterObj[k], changes)
+		}
+		return
+	}
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	change := ledger.InputChange{Path: path}
+	if before != nil {
+		change.Before, _ = json.Marshal(before)
+	}
+	if after != nil {
+		change.After, _ = json.Marshal(after)
+	}
+	*changes = append(*changes, change)
+}
+
+// appendApprovalEvent stores an approval event. Failures are logged rather
+// than returned: the ledger remains the authoritative record.
+func appendApprovalEvent(ctx context.Context, store event.Store, runID string, eventType event.Type, payload any) {
+	if store == nil {
+		return
+	}
+	evt, err := event.NewEvent(runID, eventType, payload)
+	if err == nil {
+		err = store.Append(ctx, evt)
+	}
+	if err != nil {
+		logging.Warn().
+			Add(logging.RunID(runID)).
+			Add(logging.ErrorField(err)).
+			Msg("failed to store approval event")
+	}
+}
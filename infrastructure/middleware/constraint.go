@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// ConstraintConfig configures the constraint middleware.
+type ConstraintConfig struct {
+	// Constraints are evaluated in order before each tool call.
+	Constraints []policy.Constraint
+
+	// OnViolation is called with the denial reason when a constraint is
+	// not satisfied, e.g. to record it in the ledger. Optional.
+	OnViolation func(execCtx *middleware.ExecutionContext, reason string)
+}
+
+// Constraints returns middleware that evaluates policy constraints before
+// tool execution. The first unsatisfied constraint blocks the call with an
+// error wrapping policy.ErrConstraintViolation.
+func Constraints(cfg ConstraintConfig) middleware.Middleware {
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, execCtx *middleware.ExecutionContext) (tool.Result, error) {
+			if len(cfg.Constraints) == 0 {
+				return next(ctx, execCtx)
+			}
+
+			ok, reason := policy.EvaluateConstraints(ConstraintContext(execCtx), cfg.Constraints...)
+			if !ok {
+				if cfg.OnViolation != nil {
+					cfg.OnViolation(execCtx, reason)
+				}
+				return tool.Result{}, fmt.Errorf("%w: %s", policy.ErrConstraintViolation, reason)
+			}
+
+			return next(ctx, execCtx)
+		}
+	}
+}
+
+// ConstraintContext builds the constraint context for a tool call.
+func ConstraintContext(execCtx *middleware.ExecutionContext) policy.ConstraintContext {
+	cc := policy.ConstraintContext{
+		RunID:         execCtx.RunID,
+		CurrentState:  execCtx.CurrentState,
+		Input:         execCtx.Input,
+		Vars:          execCtx.Vars,
+		EvidenceCount: execCtx.EvidenceCount,
+		History:       execCtx.History,
+	}
+	if execCtx.Tool != nil {
+		cc.ToolName = execCtx.Tool.Name()
+		cc.Annotations = execCtx.Tool.Annotations()
+	}
+	if budget, ok := execCtx.Budget.(*policy.Budget); ok {
+		cc.Budget = budget
+	}
+	return cc
+}
@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	domainmw "github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	mw "github.com/felixgeelhaar/agent-go/infrastructure/middleware"
+)
+
+func TestConstraints(t *testing.T) {
+	t.Parallel()
+
+	t.Run("passes enriched context to constraints", func(t *testing.T) {
+		t.Parallel()
+
+		var seen policy.ConstraintContext
+		middleware := mw.Constraints(mw.ConstraintConfig{
+			Constraints: []policy.Constraint{policy.ConstraintFunc(func(ctx policy.ConstraintContext) (bool, string) {
+				seen = ctx
+				return true, ""
+			})},
+		})
+
+		budget := policy.NewBudget(map[string]int{"tool_calls": 5})
+		execCtx := &domainmw.ExecutionContext{
+			RunID:         "run-1",
+			CurrentState:  agent.StateAct,
+			Tool:          &mockTool{name: "deploy", annotations: tool.Annotations{Destructive: true}},
+			Input:         json.RawMessage(`{"env":"prod"}`),
+			Budget:        budget,
+			Vars:          map[string]any{"tenant": "acme"},
+			EvidenceCount: 3,
+			History:       []policy.ToolCallRecord{{ToolName: "search", State: agent.StateExplore}},
+		}
+
+		if _, err := middleware(createTestHandler(tool.Result{}, nil))(context.Background(), execCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if seen.RunID != "run-1" || seen.ToolName != "deploy" || !seen.Annotations.Destructive ||
+			string(seen.Input) != `{"env":"prod"}` || seen.Vars["tenant"] != "acme" ||
+			seen.EvidenceCount != 3 || seen.CallCount("search", agent.StateExplore) != 1 || seen.Budget != budget {
+			t.Errorf("unexpected constraint context: %+v", seen)
+		}
+	})
+
+	t.Run("blocks and reports violations", func(t *testing.T) {
+		t.Parallel()
+
+		var reported string
+		middleware := mw.Constraints(mw.ConstraintConfig{
+			Constraints: []policy.Constraint{policy.MaxToolCalls{Tool: "search", Max: 1}},
+			OnViolation: func(_ *domainmw.ExecutionContext, reason string) {
+				reported = reason
+			},
+		})
+
+		called := false
+		handler := middleware(func(context.Context, *domainmw.ExecutionContext) (tool.Result, error) {
+			called = true
+			return tool.Result{}, nil
+		})
+		execCtx := &domainmw.ExecutionContext{
+			CurrentState: agent.StateExplore,
+			Tool:         &mockTool{name: "search"},
+			History:      []policy.ToolCallRecord{{ToolName: "search", State: agent.StateExplore}},
+		}
+
+		_, err := handler(context.Background(), execCtx)
+		if !errors.Is(err, policy.ErrConstraintViolation) {
+			t.Fatalf("expected ErrConstraintViolation, got %v", err)
+		}
+		if called {
+			t.Error("handler should not run when a constraint is violated")
+		}
+		if reported != "tool search may be called at most 1 times per run" {
+			t.Errorf("unexpected reported reason %q", reported)
+		}
+	})
+}
@@ -4,17 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/cache"
+	"github.com/felixgeelhaar/agent-go/domain/event"
 	"github.com/felixgeelhaar/agent-go/domain/ledger"
 	domainmw "github.com/felixgeelhaar/agent-go/domain/middleware"
 	"github.com/felixgeelhaar/agent-go/domain/policy"
 	"github.com/felixgeelhaar/agent-go/domain/simulation"
 	"github.com/felixgeelhaar/agent-go/domain/tool"
 	mw "github.com/felixgeelhaar/agent-go/infrastructure/middleware"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
 )
 
 // mockTool implements tool.Tool for testing.
@@ -315,6 +318,367 @@ func TestApproval(t *testing.T) {
 	})
 }
 
+func TestApproval_Rules(t *testing.T) {
+	t.Parallel()
+
+	rules := policy.MustCompileRules(policy.RuleDefinition{
+		Rule: `tool.tags contains "prod" && input.replicas > 10 => require_approval`,
+	})
+	middleware := mw.Approval(mw.ApprovalConfig{
+		Approver: &mockApprover{approved: false, reason: "too many replicas"},
+		Rules:    rules,
+	})
+	handler := middleware(createTestHandler(tool.Result{}, nil))
+	scale := &mockTool{name: "scale", annotations: tool.Annotations{Tags: []string{"prod"}}}
+
+	_, err := handler(context.Background(), &domainmw.ExecutionContext{
+		Tool:  scale,
+		Input: json.RawMessage(`{"replicas": 12}`),
+	})
+	if !errors.Is(err, tool.ErrApprovalDenied) {
+		t.Fatalf("expected the rule to route the call to the approver, got %v", err)
+	}
+
+	_, err = handler(context.Background(), &domainmw.ExecutionContext{
+		Tool:  scale,
+		Input: json.RawMessage(`{"replicas": 3}`),
+	})
+	if err != nil {
+		t.Errorf("expected small scale to skip approval, got %v", err)
+	}
+}
+
+func TestApproval_Policy(t *testing.T) {
+	t.Parallel()
+
+	snapshot := policy.NewApprovalSnapshot()
+	snapshot.RequireApproval("send_email")
+	snapshot.RequireApproval("cleanup")
+	snapshot.Exempt("archive")
+	snapshot.Exempt("purge")
+	snapshot.Exempt("rotate_keys")
+	approvalPolicy := &policy.ApprovalPolicy{
+		RequireForTools: []string{"deploy", "archive", "purge", "rotate_keys"},
+		ExemptTools:     []string{"cleanup"},
+	}
+
+	tests := []struct {
+		name         string
+		tool         *mockTool
+		wantApproval bool
+	}{
+		{"listed in policy", &mockTool{name: "deploy"}, true},
+		{"listed in snapshot", &mockTool{name: "send_email"}, true},
+		{"exempt overrides snapshot", &mockTool{name: "cleanup", annotations: tool.Annotations{Destructive: true}}, false},
+		{"policy replaces annotation defaults", &mockTool{name: "delete", annotations: tool.Annotations{Destructive: true}}, false},
+		{"snapshot exemption overrides policy", &mockTool{name: "archive"}, false},
+		{"snapshot cannot exempt destructive tools", &mockTool{name: "purge", annotations: tool.Annotations{Destructive: true}}, true},
+		{"snapshot cannot exempt critical tools", &mockTool{name: "rotate_keys", annotations: tool.Annotations{RiskLevel: tool.RiskCritical}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			middleware := mw.Approval(mw.ApprovalConfig{
+				Approver: &mockApprover{approved: false},
+				Policy:   approvalPolicy,
+				Snapshot: &snapshot,
+			})
+			handler := middleware(createTestHandler(tool.Result{}, nil))
+
+			_, err := handler(context.Background(), &domainmw.ExecutionContext{Tool: tt.tool})
+			if got := errors.Is(err, tool.ErrApprovalDenied); got != tt.wantApproval {
+				t.Errorf("approval requested = %v, want %v (err %v)", got, tt.wantApproval, err)
+			}
+		})
+	}
+}
+
+func TestApproval_RecordsRequestsAndResults(t *testing.T) {
+	t.Parallel()
+
+	events := memory.NewEventStore()
+	runLedger := ledger.New("run-1")
+	middleware := mw.Approval(mw.ApprovalConfig{
+		Approver: policy.NewAutoApprover("alice"),
+		Events:   events,
+	})
+	handler := middleware(createTestHandler(tool.Result{}, nil))
+
+	_, err := handler(context.Background(), &domainmw.ExecutionContext{
+		RunID:        "run-1",
+		CurrentState: agent.StateAct,
+		Tool:         &mockTool{name: "delete_file", annotations: tool.Annotations{Destructive: true}},
+		Input:        json.RawMessage(`{"path":"/tmp/x"}`),
+		Ledger:       runLedger,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := len(runLedger.EntriesByType(ledger.EntryApprovalRequest)); n != 1 {
+		t.Errorf("expected 1 approval request entry, got %d", n)
+	}
+	results := runLedger.EntriesByType(ledger.EntryApprovalResult)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 approval result entry, got %d", len(results))
+	}
+	var details ledger.ApprovalResultDetails
+	if err := json.Unmarshal(results[0].Details, &details); err != nil {
+		t.Fatalf("failed to decode details: %v", err)
+	}
+	if !details.Approved || details.Approver != "alice" || details.ToolName != "delete_file" {
+		t.Errorf("unexpected approval result: %+v", details)
+	}
+
+	stored, err := events.LoadEvents(context.Background(), "run-1")
+	if err != nil {
+		t.Fatalf("failed to load events: %v", err)
+	}
+	if len(stored) != 2 || stored[0].Type != event.TypeApprovalRequested || stored[1].Type != event.TypeApprovalGranted {
+		t.Fatalf("unexpected events: %+v", stored)
+	}
+	var payload event.ApprovalResultPayload
+	if err := stored[1].UnmarshalPayload(&payload); err != nil || payload.Approver != "alice" {
+		t.Errorf("unexpected approval payload: %+v (%v)", payload, err)
+	}
+}
+
+func TestApproval_RecordsQuorumVotes(t *testing.T) {
+	t.Parallel()
+
+	quorum, err := policy.NewQuorumApprover(policy.QuorumConfig{
+		Groups: []policy.ApprovalGroup{{
+			Name:      "sre",
+			Approvers: []policy.Approver{policy.NewAutoApprover("dave"), policy.NewAutoApprover("alice")},
+			Required:  1,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create quorum approver: %v", err)
+	}
+	runLedger := ledger.New("run-1")
+	handler := mw.Approval(mw.ApprovalConfig{Approver: quorum})(createTestHandler(tool.Result{}, nil))
+
+	ctx := policy.WithRequester(context.Background(), "dave")
+	_, err = handler(ctx, &domainmw.ExecutionContext{
+		RunID:        "run-1",
+		CurrentState: agent.StateAct,
+		Tool:         &mockTool{name: "drop_table", annotations: tool.Annotations{RiskLevel: tool.RiskCritical}},
+		Ledger:       runLedger,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	votes := runLedger.EntriesByType(ledger.EntryApprovalVote)
+	counted := 0
+	for _, entry := range votes {
+		var details ledger.ApprovalVoteDetails
+		if err := json.Unmarshal(entry.Details, &details); err != nil {
+			t.Fatalf("failed to decode details: %v", err)
+		}
+		if details.Counted {
+			counted++
+			if details.Approver != "alice" {
+				t.Errorf("requester's vote counted: %+v", details)
+			}
+		}
+	}
+	if len(votes) == 0 || counted != 1 {
+		t.Errorf("expected alice's vote to be recorded and counted, got %d entries", len(votes))
+	}
+	if n := len(runLedger.EntriesByType(ledger.EntryApprovalResult)); n != 1 {
+		t.Errorf("expected 1 approval result entry, got %d", n)
+	}
+}
+
+// recordingApprover denies every request and keeps the last one.
+type recordingApprover struct {
+	req policy.ApprovalRequest
+}
+
+func (a *recordingApprover) Approve(_ context.Context, req policy.ApprovalRequest) (policy.ApprovalResponse, error) {
+	a.req = req
+	return policy.ApprovalResponse{Approved: false, Reason: "recorded"}, nil
+}
+
+func TestApproval_Preview(t *testing.T) {
+	t.Parallel()
+
+	previewed := tool.NewBuilder("apply").
+		RequiresApproval().
+		WithPreview(func(_ context.Context, input json.RawMessage) (string, error) {
+			if string(input) == `{"bad":true}` {
+				return "", errors.New("dry run rejected")
+			}
+			return "- replicas: 1\n+ replicas: 3", nil
+		}).
+		MustBuild()
+	approver := &recordingApprover{}
+	handler := mw.Approval(mw.ApprovalConfig{Approver: approver})(createTestHandler(tool.Result{}, nil))
+
+	_, err := handler(context.Background(), &domainmw.ExecutionContext{Tool: previewed, Input: json.RawMessage(`{}`), Reason: "scale up"})
+	if !errors.Is(err, tool.ErrApprovalDenied) {
+		t.Fatalf("expected the recording approver to deny, got %v", err)
+	}
+	if approver.req.Reason != "scale up\n\n- replicas: 1\n+ replicas: 3" {
+		t.Errorf("reason = %q, want the planner's reason followed by the preview", approver.req.Reason)
+	}
+
+	approver.req = policy.ApprovalRequest{}
+	_, err = handler(context.Background(), &domainmw.ExecutionContext{Tool: previewed, Input: json.RawMessage(`{"bad":true}`)})
+	if err == nil || !strings.Contains(err.Error(), "dry run rejected") || approver.req.ToolName != "" {
+		t.Errorf("expected a failed preview to stop before approval, got %v", err)
+	}
+}
+
+// inputApprover approves with modified input.
+type inputApprover struct {
+	input json.RawMessage
+}
+
+func (a inputApprover) Approve(_ context.Context, _ policy.ApprovalRequest) (policy.ApprovalResponse, error) {
+	return policy.ApprovalResponse{Approved: true, Approver: "ops", ModifiedInput: a.input}, nil
+}
+
+func TestApproval_ModifiedInput(t *testing.T) {
+	t.Parallel()
+
+	run := func(approved string) (json.RawMessage, *ledger.Ledger, error) {
+		var executed json.RawMessage
+		runLedger := ledger.New("run-1")
+		handler := mw.Approval(mw.ApprovalConfig{Approver: inputApprover{input: json.RawMessage(approved)}})(
+			func(_ context.Context, ec *domainmw.ExecutionContext) (tool.Result, error) {
+				executed = ec.Input
+				return tool.Result{}, nil
+			})
+		_, err := handler(context.Background(), &domainmw.ExecutionContext{
+			RunID:        "run-1",
+			CurrentState: agent.StateAct,
+			Tool:         &mockTool{name: "scale", annotations: tool.Annotations{Destructive: true}},
+			Input:        json.RawMessage(`{"service":"api","replicas":10,"force":true}`),
+			Ledger:       runLedger,
+		})
+		return executed, runLedger, err
+	}
+
+	t.Run("executes the approved input and records the diff", func(t *testing.T) {
+		t.Parallel()
+
+		executed, runLedger, err := run(`{"service":"api","replicas":2}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(executed) != `{"service":"api","replicas":2}` {
+			t.Errorf("executed input = %s", executed)
+		}
+		entries := runLedger.EntriesByType(ledger.EntryApprovalInputModified)
+		if len(entries) != 1 {
+			t.Fatalf("expected 1 input modification entry, got %d", len(entries))
+		}
+		var details ledger.ApprovalInputDetails
+		if err := json.Unmarshal(entries[0].Details, &details); err != nil {
+			t.Fatalf("failed to decode details: %v", err)
+		}
+		want := []ledger.InputChange{
+			{Path: "/force", Before: json.RawMessage(`true`)},
+			{Path: "/replicas", Before: json.RawMessage(`10`), After: json.RawMessage(`2`)},
+		}
+		if len(details.Changes) != len(want) {
+			t.Fatalf("changes = %+v, want %+v", details.Changes, want)
+		}
+		for i, change := range details.Changes {
+			if change.Path != want[i].Path || string(change.Before) != string(want[i].Before) || string(change.After) != string(want[i].After) {
+				t.Errorf("change %d = %+v, want %+v", i, change, want[i])
+			}
+		}
+		if details.Approver != "ops" {
+			t.Errorf("approver = %q, want ops", details.Approver)
+		}
+	})
+
+	t.Run("unchanged input records nothing", func(t *testing.T) {
+		t.Parallel()
+
+		_, runLedger, err := run(`{"force":true,"replicas":10,"service":"api"}`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n := len(runLedger.EntriesByType(ledger.EntryApprovalInputModified)); n != 0 {
+			t.Errorf("expected no input modification entry, got %d", n)
+		}
+	})
+
+	t.Run("rejects invalid approved input", func(t *testing.T) {
+		t.Parallel()
+
+		executed, _, err := run(`{"replicas":`)
+		if !errors.Is(err, tool.ErrInvalidInput) {
+			t.Errorf("expected ErrInvalidInput, got %v", err)
+		}
+		if executed != nil {
+			t.Error("tool should not execute with invalid input")
+		}
+	})
+}
+
+func TestApproval_ModifiedInputPolicy(t *testing.T) {
+	t.Parallel()
+
+	rules := policy.MustCompileRules(policy.RuleDefinition{
+		Rule: `input.replicas > 10 => deny`,
+	})
+	noForce := policy.ConstraintFunc(func(ctx policy.ConstraintContext) (bool, string) {
+		if strings.Contains(string(ctx.Input), `"force":true`) {
+			return false, "force is not allowed"
+		}
+		return true, ""
+	})
+
+	run := func(approved string) (bool, *ledger.Ledger, error) {
+		var executed bool
+		runLedger := ledger.New("run-1")
+		handler := mw.Approval(mw.ApprovalConfig{
+			Approver:    inputApprover{input: json.RawMessage(approved)},
+			Rules:       rules,
+			Constraints: []policy.Constraint{noForce},
+		})(func(context.Context, *domainmw.ExecutionContext) (tool.Result, error) {
+			executed = true
+			return tool.Result{}, nil
+		})
+		_, err := handler(context.Background(), &domainmw.ExecutionContext{
+			RunID:        "run-1",
+			CurrentState: agent.StateAct,
+			Tool:         &mockTool{name: "scale", annotations: tool.Annotations{Destructive: true}},
+			Input:        json.RawMessage(`{"service":"api","replicas":3}`),
+			Ledger:       runLedger,
+		})
+		return executed, runLedger, err
+	}
+
+	for _, approved := range []string{`{"service":"api","replicas":100}`, `{"service":"api","replicas":3,"force":true}`} {
+		executed, runLedger, err := run(approved)
+		if !errors.Is(err, policy.ErrConstraintViolation) {
+			t.Errorf("%s: expected ErrConstraintViolation, got %v", approved, err)
+		}
+		if executed {
+			t.Errorf("%s: tool executed with input the policy denies", approved)
+		}
+		if n := len(runLedger.EntriesByType(ledger.EntryConstraintViolation)); n != 1 {
+			t.Errorf("%s: expected 1 constraint violation entry, got %d", approved, n)
+		}
+		if n := len(runLedger.EntriesByType(ledger.EntryApprovalInputModified)); n != 0 {
+			t.Errorf("%s: denied input recorded as applied", approved)
+		}
+	}
+
+	if executed, _, err := run(`{"service":"api","replicas":5}`); err != nil || !executed {
+		t.Errorf("allowed edit: executed=%v, err=%v", executed, err)
+	}
+}
+
 func TestBudget(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+)
+
+// minRedactLength is the shortest secret value redacted from tool output;
+// shorter values would match unrelated text.
+const minRedactLength = 4
+
+// SecretHandlesConfig configures the secret handle middleware.
+type SecretHandlesConfig struct {
+	// Handles resolves the handles issued to the planner, typically the
+	// HandleStore shared with the secrets pack.
+	Handles *secrets.HandleStore
+}
+
+// SecretHandles returns middleware that replaces secret handles in tool
+// input with their values just before the tool runs. Placed innermost, it
+// runs after the ledger has recorded the call and after approval, so the
+// ledger, events and approvers only ever see the handle. Values the tool
+// echoes back in its output or error are replaced with their handle again,
+// so they stay out of evidence too.
+//
+// Engines configured with secret handles add it innermost to their
+// middleware chain, default or custom.
+func SecretHandles(cfg SecretHandlesConfig) middleware.Middleware {
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, execCtx *middleware.ExecutionContext) (tool.Result, error) {
+			if cfg.Handles == nil {
+				return next(ctx, execCtx)
+			}
+
+			values, err := cfg.Handles.ResolveAll(ctx, execCtx.Input)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("%w: %v", tool.ErrInvalidInput, err)
+			}
+			if len(values) == 0 {
+				return next(ctx, execCtx)
+			}
+			input, err := cfg.Handles.ExpandJSON(ctx, execCtx.Input)
+			if err != nil {
+				return tool.Result{}, fmt.Errorf("%w: %v", tool.ErrInvalidInput, err)
+			}
+
+			// Expand into a copy so outer middleware never observes values
+			expanded := *execCtx
+			expanded.Input = input
+			result, err := next(ctx, &expanded)
+
+			r := newSecretRedactor(values)
+			result.Output = r.redactJSON(result.Output)
+			if result.Error != nil {
+				result.Error = r.redactError(result.Error)
+			}
+			if err != nil {
+				err = r.redactError(err)
+			}
+			return result, err
+		}
+	}
+}
+
+// secretRedactor replaces secret values with the handles they came from.
+type secretRedactor struct {
+	replacer *strings.Replacer
+}
+
+func newSecretRedactor(values map[string]string) secretRedactor {
+	var pairs []string
+	for id, value := range values {
+		if len(value) < minRedactLength {
+			continue
+		}
+		pairs = append(pairs, value, id)
+		// Values inside JSON strings appear escaped
+		if encoded, err := json.Marshal(value); err == nil {
+			if escaped := string(encoded[1 : len(encoded)-1]); escaped != value {
+				pairs = append(pairs, escaped, id)
+			}
+		}
+	}
+	return secretRedactor{replacer: strings.NewReplacer(pairs...)}
+}
+
+func (r secretRedactor) redactJSON(data json.RawMessage) json.RawMessage {
+	if len(data) == 0 {
+		return data
+	}
+	redacted := []byte(r.replacer.Replace(string(data)))
+	if bytes.Equal(redacted, data) {
+		return data
+	}
+	return redacted
+}
+
+func (r secretRedactor) redactError(err error) error {
+	msg := err.Error()
+	if redacted := r.replacer.Replace(msg); redacted != msg {
+		return &redactedError{err: err, msg: redacted}
+	}
+	return err
+}
+
+// redactedError reports an error with secret values replaced, while still
+// matching the original with errors.Is and errors.As.
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
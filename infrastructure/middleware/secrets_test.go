@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
+)
+
+func TestSecretHandles(t *testing.T) {
+	ctx := context.Background()
+	handles := secrets.NewHandleStore(secrets.NewMemoryManager(secrets.WithInitialSecrets(map[string]string{"api/key": "s3cr3t"})))
+	h, err := handles.Issue(ctx, "api/key", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	var seen json.RawMessage
+	handler := SecretHandles(SecretHandlesConfig{Handles: handles})(
+		func(_ context.Context, ec *middleware.ExecutionContext) (tool.Result, error) {
+			seen = ec.Input
+			return tool.Result{}, nil
+		})
+
+	t.Run("expands_handles_for_the_tool_only", func(t *testing.T) {
+		execCtx := mockExecutionContext("run-1", "http_request")
+		execCtx.Input = json.RawMessage(`{"token":"` + h.ID + `"}`)
+		if _, err := handler(ctx, execCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(seen) != `{"token":"s3cr3t"}` {
+			t.Errorf("tool input = %s, want the secret value", seen)
+		}
+		if strings.Contains(string(execCtx.Input), "s3cr3t") {
+			t.Errorf("execution context was modified: %s", execCtx.Input)
+		}
+	})
+
+	t.Run("rejects_unknown_handles", func(t *testing.T) {
+		execCtx := mockExecutionContext("run-1", "http_request")
+		execCtx.Input = json.RawMessage(`{"token":"secret:00000000000000000000000000000000"}`)
+		if _, err := handler(ctx, execCtx); !errors.Is(err, tool.ErrInvalidInput) {
+			t.Errorf("expected ErrInvalidInput, got %v", err)
+		}
+	})
+
+	t.Run("passes_through_without_store", func(t *testing.T) {
+		execCtx := mockExecutionContext("run-1", "http_request")
+		execCtx.Input = json.RawMessage(`{"token":"` + h.ID + `"}`)
+		passthrough := SecretHandles(SecretHandlesConfig{})(successHandler)
+		if _, err := passthrough(ctx, execCtx); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSecretHandles_RedactsEchoedValues(t *testing.T) {
+	ctx := context.Background()
+	handles := secrets.NewHandleStore(secrets.NewMemoryManager(secrets.WithInitialSecrets(map[string]string{
+		"api/key":   "s3cr3t",
+		"api/quote": `pa"ss`,
+	})))
+	key, _ := handles.Issue(ctx, "api/key", 0)
+	quote, _ := handles.Issue(ctx, "api/quote", 0)
+
+	toolErr := errors.New("upstream rejected")
+	echo := SecretHandles(SecretHandlesConfig{Handles: handles})(
+		func(_ context.Context, ec *middleware.ExecutionContext) (tool.Result, error) {
+			var in map[string]string
+			_ = json.Unmarshal(ec.Input, &in)
+			output, _ := json.Marshal(map[string]string{"sent": in["token"], "other": in["password"]})
+			return tool.Result{Output: output}, fmt.Errorf("%w: token %s", toolErr, in["token"])
+		})
+
+	execCtx := mockExecutionContext("run-1", "http_request")
+	execCtx.Input = json.RawMessage(`{"token":"` + key.ID + `","password":"` + quote.ID + `"}`)
+	result, err := echo(ctx, execCtx)
+
+	want := `{"other":"` + quote.ID + `","sent":"` + key.ID + `"}`
+	if string(result.Output) != want {
+		t.Errorf("output = %s, want %s", result.Output, want)
+	}
+	if !errors.Is(err, toolErr) || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("expected a redacted error wrapping the tool error, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felixgeelhaar/fortify/circuitbreaker"
+	"github.com/felixgeelhaar/fortify/ferrors"
+	"github.com/felixgeelhaar/fortify/ratelimit"
+
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/logging"
+	"github.com/felixgeelhaar/agent-go/infrastructure/resilience"
+)
+
+// ToolLimitsConfig configures the tool limits middleware.
+type ToolLimitsConfig struct {
+	// Settings holds the per-tool timeouts, rate limits and circuit
+	// breakers of the active policy version.
+	Settings *policy.ToolSettingsSnapshot
+}
+
+// toolLimiter enforces the settings of a single tool.
+type toolLimiter struct {
+	timeout time.Duration
+	limiter ratelimit.RateLimiter
+	breaker circuitbreaker.CircuitBreaker[tool.Result]
+}
+
+// ToolLimits returns middleware that enforces per-tool execution limits: a
+// rate limit in calls per minute, a circuit breaker that rejects calls after
+// consecutive failures, and an execution timeout that replaces the
+// executor's default. Tools without settings pass through unchanged.
+func ToolLimits(cfg ToolLimitsConfig) middleware.Middleware {
+	limiters := make(map[string]*toolLimiter)
+	if cfg.Settings != nil {
+		for name, settings := range cfg.Settings.Tools {
+			limiters[name] = newToolLimiter(name, settings)
+		}
+	}
+
+	return func(next middleware.Handler) middleware.Handler {
+		return func(ctx context.Context, execCtx *middleware.ExecutionContext) (tool.Result, error) {
+			l, ok := limiters[execCtx.Tool.Name()]
+			if !ok {
+				return next(ctx, execCtx)
+			}
+
+			if l.limiter != nil && !l.limiter.Allow(ctx, execCtx.Tool.Name()) {
+				logging.Warn().
+					Add(logging.RunID(execCtx.RunID)).
+					Add(logging.ToolName(execCtx.Tool.Name())).
+					Msg("tool rate limit exceeded")
+				return tool.Result{}, fmt.Errorf("%w: tool %s", policy.ErrRateLimitExceeded, execCtx.Tool.Name())
+			}
+
+			call := func(ctx context.Context) (tool.Result, error) {
+				if l.timeout > 0 {
+					var cancel context.CancelFunc
+					ctx, cancel = context.WithTimeout(resilience.ContextWithTimeout(ctx, l.timeout), l.timeout)
+					defer cancel()
+				}
+				return next(ctx, execCtx)
+			}
+			if l.breaker == nil {
+				return call(ctx)
+			}
+
+			result, err := l.breaker.Execute(ctx, call)
+			if errors.Is(err, ferrors.ErrCircuitOpen) {
+				return tool.Result{}, fmt.Errorf("%w: tool %s", policy.ErrCircuitOpen, execCtx.Tool.Name())
+			}
+			return result, err
+		}
+	}
+}
+
+func newToolLimiter(name string, settings policy.ToolSettings) *toolLimiter {
+	l := &toolLimiter{timeout: settings.Timeout}
+	if settings.RateLimit > 0 {
+		l.limiter = ratelimit.New(&ratelimit.Config{
+			Rate:     settings.RateLimit,
+			Burst:    settings.RateLimit,
+			Interval: time.Minute,
+		})
+	}
+	if cb := settings.CircuitBreaker; cb != nil && cb.FailureThreshold > 0 {
+		threshold := uint32(cb.FailureThreshold) // #nosec G115 -- checked positive above
+		l.breaker = circuitbreaker.New[tool.Result](circuitbreaker.Config{
+			MaxRequests: 1,
+			Timeout:     cb.OpenTimeout,
+			ReadyToTrip: func(counts circuitbreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= threshold
+			},
+			OnStateChange: func(from, to circuitbreaker.State) {
+				logging.Info().
+					Add(logging.ToolName(name)).
+					Add(logging.Str("from", from.String())).
+					Add(logging.Str("to", to.String())).
+					Msg("tool circuit breaker state changed")
+			},
+		})
+	}
+	return l
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/middleware"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+func TestToolLimits(t *testing.T) {
+	settings := policy.NewToolSettingsSnapshot()
+	settings.SetRateLimit("limited", 2)
+	settings.SetTimeout("bounded", 50*time.Millisecond)
+	settings.SetCircuitBreaker("flaky", &policy.CircuitBreakerSettings{FailureThreshold: 2, OpenTimeout: time.Minute})
+	mw := ToolLimits(ToolLimitsConfig{Settings: &settings})
+
+	t.Run("passes_tools_without_settings", func(t *testing.T) {
+		handler := mw(successHandler)
+		for i := 0; i < 5; i++ {
+			if _, err := handler(context.Background(), mockExecutionContext("run-1", "other")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+
+	t.Run("enforces_rate_limit", func(t *testing.T) {
+		handler := mw(successHandler)
+		for i := 0; i < 2; i++ {
+			if _, err := handler(context.Background(), mockExecutionContext("run-1", "limited")); err != nil {
+				t.Fatalf("call %d: unexpected error: %v", i, err)
+			}
+		}
+		_, err := handler(context.Background(), mockExecutionContext("run-1", "limited"))
+		if !errors.Is(err, policy.ErrRateLimitExceeded) {
+			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("applies_timeout", func(t *testing.T) {
+		handler := mw(func(ctx context.Context, _ *middleware.ExecutionContext) (tool.Result, error) {
+			deadline, ok := ctx.Deadline()
+			if !ok || time.Until(deadline) > 50*time.Millisecond {
+				t.Errorf("expected a 50ms deadline, got %v (%v)", time.Until(deadline), ok)
+			}
+			return tool.Result{}, nil
+		})
+		if _, err := handler(context.Background(), mockExecutionContext("run-1", "bounded")); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("opens_circuit_after_failures", func(t *testing.T) {
+		calls := 0
+		failure := errors.New("connection refused")
+		handler := mw(func(context.Context, *middleware.ExecutionContext) (tool.Result, error) {
+			calls++
+			return tool.Result{}, failure
+		})
+		for i := 0; i < 2; i++ {
+			if _, err := handler(context.Background(), mockExecutionContext("run-1", "flaky")); !errors.Is(err, failure) {
+				t.Fatalf("call %d: expected the tool error, got %v", i, err)
+			}
+		}
+		_, err := handler(context.Background(), mockExecutionContext("run-1", "flaky"))
+		if !errors.Is(err, policy.ErrCircuitOpen) {
+			t.Errorf("expected ErrCircuitOpen, got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("expected the open circuit to skip the tool, got %d calls", calls)
+		}
+	})
+}
@@ -4,6 +4,7 @@ package proposal
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/policy"
@@ -27,6 +28,8 @@ func (a *PolicyApplier) Apply(ctx context.Context, current *policy.PolicyVersion
 		Transitions: copyTransitionSnapshot(current.Transitions),
 		Budgets:     copyBudgetSnapshot(current.Budgets),
 		Approvals:   copyApprovalSnapshot(current.Approvals),
+		Tools:       copyToolSettingsSnapshot(current.Tools),
+		Rules:       append([]policy.RuleDefinition(nil), current.Rules...),
 	}
 
 	// Apply each change
@@ -49,6 +52,14 @@ func (a *PolicyApplier) applyChange(version *policy.PolicyVersion, change propos
 		return a.applyBudgetChange(version, change)
 	case proposal.ChangeTypeApproval:
 		return a.applyApprovalChange(version, change)
+	case proposal.ChangeTypeToolTimeout:
+		return a.applyToolTimeoutChange(version, change)
+	case proposal.ChangeTypeRateLimit:
+		return a.applyRateLimitChange(version, change)
+	case proposal.ChangeTypeApprovalExemption:
+		return a.applyApprovalExemptionChange(version, change)
+	case proposal.ChangeTypeCircuitBreaker:
+		return a.applyCircuitBreakerChange(version, change)
 	default:
 		return fmt.Errorf("unknown change type: %s", change.Type)
 	}
@@ -109,6 +120,68 @@ func (a *PolicyApplier) applyApprovalChange(version *policy.PolicyVersion, chang
 	return nil
 }
 
+func (a *PolicyApplier) applyToolTimeoutChange(version *policy.PolicyVersion, change proposal.PolicyChange) error {
+	var tc proposal.ToolTimeoutChange
+	if err := change.GetAfter(&tc); err != nil {
+		return fmt.Errorf("failed to unmarshal tool timeout change: %w", err)
+	}
+	if tc.Timeout < 0 {
+		return fmt.Errorf("negative timeout for tool %s", tc.ToolName)
+	}
+
+	version.Tools.SetTimeout(tc.ToolName, tc.Timeout)
+	return nil
+}
+
+func (a *PolicyApplier) applyRateLimitChange(version *policy.PolicyVersion, change proposal.PolicyChange) error {
+	var rc proposal.RateLimitChange
+	if err := change.GetAfter(&rc); err != nil {
+		return fmt.Errorf("failed to unmarshal rate limit change: %w", err)
+	}
+	if rc.CallsPerMinute < 0 {
+		return fmt.Errorf("negative rate limit for tool %s", rc.ToolName)
+	}
+
+	version.Tools.SetRateLimit(rc.ToolName, rc.CallsPerMinute)
+	return nil
+}
+
+func (a *PolicyApplier) applyApprovalExemptionChange(version *policy.PolicyVersion, change proposal.PolicyChange) error {
+	var ec proposal.ApprovalExemptionChange
+	if err := change.GetAfter(&ec); err != nil {
+		return fmt.Errorf("failed to unmarshal approval exemption change: %w", err)
+	}
+
+	if ec.Exempt {
+		version.Approvals.Exempt(ec.ToolName)
+	} else {
+		version.Approvals.RemoveExemption(ec.ToolName)
+	}
+
+	return nil
+}
+
+func (a *PolicyApplier) applyCircuitBreakerChange(version *policy.PolicyVersion, change proposal.PolicyChange) error {
+	var cc proposal.CircuitBreakerChange
+	if err := change.GetAfter(&cc); err != nil {
+		return fmt.Errorf("failed to unmarshal circuit breaker change: %w", err)
+	}
+	if cc.FailureThreshold < 0 || cc.OpenTimeout < 0 {
+		return fmt.Errorf("negative circuit breaker setting for tool %s", cc.ToolName)
+	}
+
+	if cc.FailureThreshold == 0 {
+		version.Tools.SetCircuitBreaker(cc.ToolName, nil)
+	} else {
+		version.Tools.SetCircuitBreaker(cc.ToolName, &policy.CircuitBreakerSettings{
+			FailureThreshold: cc.FailureThreshold,
+			OpenTimeout:      cc.OpenTimeout,
+		})
+	}
+
+	return nil
+}
+
 // Helper functions to copy snapshots
 
 func copyEligibilitySnapshot(src policy.EligibilitySnapshot) policy.EligibilitySnapshot {
@@ -144,6 +217,21 @@ func copyApprovalSnapshot(src policy.ApprovalSnapshot) policy.ApprovalSnapshot {
 	for _, tool := range src.RequiredTools {
 		dst.RequireApproval(tool)
 	}
+	for _, tool := range src.ExemptTools {
+		dst.Exempt(tool)
+	}
+	return dst
+}
+
+func copyToolSettingsSnapshot(src policy.ToolSettingsSnapshot) policy.ToolSettingsSnapshot {
+	dst := policy.NewToolSettingsSnapshot()
+	for name, settings := range src.Tools {
+		if settings.CircuitBreaker != nil {
+			cb := *settings.CircuitBreaker
+			settings.CircuitBreaker = &cb
+		}
+		dst.Tools[name] = settings
+	}
 	return dst
 }
 
@@ -205,3 +293,57 @@ func CreateApprovalChange(toolName string, required bool, description string) (*
 	}
 	return proposal.NewPolicyChange(proposal.ChangeTypeApproval, toolName, description, before, after)
 }
+
+// CreateToolTimeoutChange creates a per-tool timeout policy change.
+func CreateToolTimeoutChange(toolName string, oldTimeout, newTimeout time.Duration, description string) (*proposal.PolicyChange, error) {
+	before := proposal.ToolTimeoutChange{
+		ToolName: toolName,
+		Timeout:  oldTimeout,
+	}
+	after := proposal.ToolTimeoutChange{
+		ToolName: toolName,
+		Timeout:  newTimeout,
+	}
+	return proposal.NewPolicyChange(proposal.ChangeTypeToolTimeout, toolName, description, before, after)
+}
+
+// CreateRateLimitChange creates a per-tool rate limit policy change.
+func CreateRateLimitChange(toolName string, oldLimit, newLimit int, description string) (*proposal.PolicyChange, error) {
+	before := proposal.RateLimitChange{
+		ToolName:       toolName,
+		CallsPerMinute: oldLimit,
+	}
+	after := proposal.RateLimitChange{
+		ToolName:       toolName,
+		CallsPerMinute: newLimit,
+	}
+	return proposal.NewPolicyChange(proposal.ChangeTypeRateLimit, toolName, description, before, after)
+}
+
+// CreateApprovalExemptionChange creates an approval exemption policy change.
+func CreateApprovalExemptionChange(toolName string, exempt bool, description string) (*proposal.PolicyChange, error) {
+	before := proposal.ApprovalExemptionChange{
+		ToolName: toolName,
+		Exempt:   !exempt,
+	}
+	after := proposal.ApprovalExemptionChange{
+		ToolName: toolName,
+		Exempt:   exempt,
+	}
+	return proposal.NewPolicyChange(proposal.ChangeTypeApprovalExemption, toolName, description, before, after)
+}
+
+// CreateCircuitBreakerChange creates a per-tool circuit breaker policy
+// change. A nil setting means no circuit breaker.
+func CreateCircuitBreakerChange(toolName string, oldSettings, newSettings *policy.CircuitBreakerSettings, description string) (*proposal.PolicyChange, error) {
+	circuitBreakerChange := func(cb *policy.CircuitBreakerSettings) proposal.CircuitBreakerChange {
+		c := proposal.CircuitBreakerChange{ToolName: toolName}
+		if cb != nil {
+			c.FailureThreshold = cb.FailureThreshold
+			c.OpenTimeout = cb.OpenTimeout
+		}
+		return c
+	}
+	return proposal.NewPolicyChange(proposal.ChangeTypeCircuitBreaker, toolName, description,
+		circuitBreakerChange(oldSettings), circuitBreakerChange(newSettings))
+}
@@ -3,6 +3,7 @@ package proposal
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/policy"
@@ -469,6 +470,64 @@ func TestPolicyApplier_Apply_MultipleChanges(t *testing.T) {
 	}
 }
 
+func TestPolicyApplier_Apply_ToolSettingsChanges(t *testing.T) {
+	ctx := context.Background()
+	applier := NewPolicyApplier()
+
+	current := &policy.PolicyVersion{
+		Version:     0,
+		Eligibility: policy.NewEligibilitySnapshot(),
+		Transitions: policy.NewTransitionSnapshot(),
+		Budgets:     policy.NewBudgetLimitsSnapshot(),
+		Approvals:   policy.NewApprovalSnapshot(),
+		Tools:       policy.NewToolSettingsSnapshot(),
+	}
+	current.Approvals.RequireApproval("read_file")
+	current.Tools.SetCircuitBreaker("flaky_api", &policy.CircuitBreakerSettings{FailureThreshold: 5, OpenTimeout: time.Second})
+
+	breaker := &policy.CircuitBreakerSettings{FailureThreshold: 2, OpenTimeout: time.Minute}
+	timeoutChange, _ := CreateToolTimeoutChange("slow_api", 0, 45*time.Second, "Raise timeout")
+	rateChange, _ := CreateRateLimitChange("slow_api", 0, 30, "Throttle")
+	exemptionChange, _ := CreateApprovalExemptionChange("read_file", true, "Exempt read_file")
+	breakerChange, _ := CreateCircuitBreakerChange("flaky_api", current.Tools.Tools["flaky_api"].CircuitBreaker, breaker, "Trip sooner")
+	changes := []proposal.PolicyChange{*timeoutChange, *rateChange, *exemptionChange, *breakerChange}
+
+	newVersion, err := applier.Apply(ctx, current, changes)
+	if err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+
+	if settings, _ := newVersion.Tools.Get("slow_api"); settings.Timeout != 45*time.Second || settings.RateLimit != 30 {
+		t.Errorf("unexpected slow_api settings: %+v", settings)
+	}
+	if settings, _ := newVersion.Tools.Get("flaky_api"); settings.CircuitBreaker == nil || *settings.CircuitBreaker != *breaker {
+		t.Errorf("unexpected flaky_api settings: %+v", settings)
+	}
+	if !newVersion.Approvals.IsExempt("read_file") || newVersion.Approvals.IsRequired("read_file") {
+		t.Errorf("expected read_file to be exempt: %+v", newVersion.Approvals)
+	}
+	if current.Tools.Tools["flaky_api"].CircuitBreaker.FailureThreshold != 5 || current.Approvals.IsExempt("read_file") {
+		t.Error("applying changes must not modify the current version")
+	}
+
+	// Zero settings remove the limits again
+	removeTimeout, _ := CreateToolTimeoutChange("slow_api", 45*time.Second, 0, "")
+	removeRate, _ := CreateRateLimitChange("slow_api", 30, 0, "")
+	removeBreaker, _ := CreateCircuitBreakerChange("flaky_api", breaker, nil, "")
+	reverted, err := applier.Apply(ctx, newVersion, []proposal.PolicyChange{*removeTimeout, *removeRate, *removeBreaker})
+	if err != nil {
+		t.Fatalf("failed to apply: %v", err)
+	}
+	if len(reverted.Tools.Tools) != 0 {
+		t.Errorf("expected no tool settings, got %+v", reverted.Tools.Tools)
+	}
+
+	invalid, _ := CreateRateLimitChange("slow_api", 0, -1, "")
+	if _, err := applier.Apply(ctx, current, []proposal.PolicyChange{*invalid}); err == nil {
+		t.Error("expected an error for a negative rate limit")
+	}
+}
+
 // Change Creation Helper Tests
 
 func TestCreateBudgetChange(t *testing.T) {
@@ -107,17 +107,7 @@ func (w *WorkflowService) Apply(ctx context.Context, proposalID string) error {
 	}
 
 	// Get current policy version
-	currentVersion, err := w.versionStore.GetCurrent(ctx)
-	if err != nil {
-		// If no version exists, start with version 0
-		currentVersion = &policy.PolicyVersion{
-			Version:     0,
-			Eligibility: policy.NewEligibilitySnapshot(),
-			Transitions: policy.NewTransitionSnapshot(),
-			Budgets:     policy.NewBudgetLimitsSnapshot(),
-			Approvals:   policy.NewApprovalSnapshot(),
-		}
-	}
+	currentVersion := w.currentVersion(ctx)
 
 	// Apply changes and create new version
 	newVersion, err := w.applier.Apply(ctx, currentVersion, p.Changes)
@@ -142,6 +132,42 @@ func (w *WorkflowService) Apply(ctx context.Context, proposalID string) error {
 	return w.proposalStore.Update(ctx, p)
 }
 
+// Candidate returns the policy version the proposal's changes would
+// produce on top of the current version, without saving it. It is the
+// version evaluated in shadow or canary mode before the proposal is applied.
+func (w *WorkflowService) Candidate(ctx context.Context, proposalID string) (*policy.PolicyVersion, error) {
+	p, err := w.proposalStore.Get(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate, err := w.applier.Apply(ctx, w.currentVersion(ctx), p.Changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply changes: %w", err)
+	}
+	candidate.ProposalID = proposalID
+	candidate.Description = p.Title
+	candidate.CreatedAt = time.Now()
+	return candidate, nil
+}
+
+// currentVersion returns the current policy version, or an empty version 0
+// when none exists.
+func (w *WorkflowService) currentVersion(ctx context.Context) *policy.PolicyVersion {
+	v, err := w.versionStore.GetCurrent(ctx)
+	if err != nil {
+		return &policy.PolicyVersion{
+			Version:     0,
+			Eligibility: policy.NewEligibilitySnapshot(),
+			Transitions: policy.NewTransitionSnapshot(),
+			Budgets:     policy.NewBudgetLimitsSnapshot(),
+			Approvals:   policy.NewApprovalSnapshot(),
+			Tools:       policy.NewToolSettingsSnapshot(),
+		}
+	}
+	return v
+}
+
 // Rollback rolls back an applied proposal's changes.
 func (w *WorkflowService) Rollback(ctx context.Context, proposalID, reason string) error {
 	p, err := w.proposalStore.Get(ctx, proposalID)
@@ -169,6 +195,8 @@ func (w *WorkflowService) Rollback(ctx context.Context, proposalID, reason strin
 		Transitions: previousVersion.Transitions,
 		Budgets:     previousVersion.Budgets,
 		Approvals:   previousVersion.Approvals,
+		Tools:       previousVersion.Tools,
+		Rules:       previousVersion.Rules,
 	}
 
 	// Save rollback version
@@ -195,3 +223,17 @@ func (w *WorkflowService) AddNote(ctx context.Context, proposalID, author, conte
 
 	return w.proposalStore.Update(ctx, p)
 }
+
+// AddEvidence attaches supporting evidence to a proposal.
+func (w *WorkflowService) AddEvidence(ctx context.Context, proposalID, evidenceType, description string, data any) error {
+	p, err := w.proposalStore.Get(ctx, proposalID)
+	if err != nil {
+		return err
+	}
+
+	if err := p.AddEvidence(evidenceType, description, data); err != nil {
+		return err
+	}
+
+	return w.proposalStore.Update(ctx, p)
+}
@@ -97,6 +97,14 @@ func NewDefaultExecutor() *Executor {
 	return NewExecutor(DefaultExecutorConfig())
 }
 
+type timeoutKey struct{}
+
+// ContextWithTimeout returns a context that makes Execute use timeout
+// instead of the executor's default timeout.
+func ContextWithTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutKey{}, timeout)
+}
+
 // Execute runs a tool with resilience patterns applied.
 // Composition order: Bulkhead → Timeout → Circuit Breaker → Retry (for idempotent)
 func (e *Executor) Execute(ctx context.Context, t tool.Tool, input json.RawMessage) (tool.Result, error) {
@@ -105,7 +113,11 @@ func (e *Executor) Execute(ctx context.Context, t tool.Tool, input json.RawMessa
 	// Apply bulkhead for concurrency control
 	result, err := e.bulkhead.Execute(ctx, func(ctx context.Context) (tool.Result, error) {
 		// Apply timeout
-		ctx, cancel := context.WithTimeout(ctx, e.timeout)
+		timeout := e.timeout
+		if d, ok := ctx.Value(timeoutKey{}).(time.Duration); ok {
+			timeout = d
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
 		// Apply circuit breaker
@@ -148,6 +148,36 @@ func TestExecutor_ExecuteWithTimeout(t *testing.T) {
 	}
 }
 
+func TestExecutor_Execute_ContextTimeout(t *testing.T) {
+	executor := NewExecutor(ExecutorConfig{
+		MaxConcurrent:           1,
+		CircuitBreakerThreshold: 5,
+		CircuitBreakerTimeout:   30 * time.Second,
+		RetryMaxAttempts:        1,
+		DefaultTimeout:          10 * time.Millisecond,
+	})
+
+	mockT := &mockTool{
+		name: "slow_tool",
+		handler: func(ctx context.Context, input json.RawMessage) (tool.Result, error) {
+			select {
+			case <-ctx.Done():
+				return tool.Result{}, ctx.Err()
+			case <-time.After(50 * time.Millisecond):
+				return tool.Result{Output: json.RawMessage(`{}`)}, nil
+			}
+		},
+	}
+
+	if _, err := executor.Execute(context.Background(), mockT, json.RawMessage(`{}`)); err == nil {
+		t.Fatal("expected the default timeout to cancel the tool")
+	}
+	ctx := ContextWithTimeout(context.Background(), time.Second)
+	if _, err := executor.Execute(ctx, mockT, json.RawMessage(`{}`)); err != nil {
+		t.Errorf("expected the context timeout to replace the default, got %v", err)
+	}
+}
+
 func TestExecutor_ExecuteSimple(t *testing.T) {
 	executor := NewDefaultExecutor()
 	mockT := &mockTool{name: "simple_tool"}
@@ -0,0 +1,358 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrVersionNotFound is returned when a secret version does not exist.
+var ErrVersionNotFound = errors.New("secret version not found")
+
+// ErrInvalidKey is returned when an encryption key is not 32 bytes or
+// cannot decrypt the store.
+var ErrInvalidKey = errors.New("invalid encryption key")
+
+// VersionInfo describes one version of a secret.
+type VersionInfo struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
+}
+
+// VersionedManager is a Manager that keeps previous values of a secret.
+// Set stores a new current version.
+type VersionedManager interface {
+	Manager
+
+	// GetVersion retrieves a specific version of a secret.
+	GetVersion(ctx context.Context, key string, version int) (string, error)
+
+	// Versions lists the retained versions of a secret, oldest first.
+	Versions(ctx context.Context, key string) ([]VersionInfo, error)
+}
+
+// fileFormat identifies the file layout and authenticates it as
+// additional data, so a file cannot be passed off as another format.
+const fileFormat = "agent-go-secrets/v1"
+
+// fileEnvelope is the on-disk form: the whole store, key names included,
+// sealed with AES-256-GCM.
+type fileEnvelope struct {
+	Format     string `json:"format"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+type secretVersion struct {
+	Version   int       `json:"version"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FileManager implements VersionedManager on a single AES-256-GCM
+// encrypted file. Secrets are decrypted into memory on open and the file
+// is rewritten atomically on every change. It is safe for concurrent use
+// within a process; processes must not share a file for writing.
+type FileManager struct {
+	path        string
+	maxVersions int
+	readOnly    bool
+
+	mu      sync.RWMutex
+	aead    cipher.AEAD
+	secrets map[string][]secretVersion
+}
+
+// FileOption configures the file manager.
+type FileOption func(*FileManager)
+
+// WithMaxVersions sets how many versions are retained per secret.
+// Defaults to 10.
+func WithMaxVersions(n int) FileOption {
+	return func(m *FileManager) {
+		if n > 0 {
+			m.maxVersions = n
+		}
+	}
+}
+
+// WithFileReadOnly makes the manager read-only.
+func WithFileReadOnly() FileOption {
+	return func(m *FileManager) {
+		m.readOnly = true
+	}
+}
+
+// NewFileManager opens the encrypted secrets file at path with a 32-byte
+// key, creating it on the first write if it does not exist.
+func NewFileManager(path string, key []byte, opts ...FileOption) (*FileManager, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	m := &FileManager{
+		path:        path,
+		maxVersions: 10,
+		aead:        aead,
+		secrets:     make(map[string][]secretVersion),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read secrets file: %w", err)
+	}
+	if err := m.decode(data); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%w: need 32 bytes, got %d", ErrInvalidKey, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (m *FileManager) decode(data []byte) error {
+	var env fileEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return fmt.Errorf("decode secrets file: %w", err)
+	}
+	if env.Format != fileFormat {
+		return fmt.Errorf("decode secrets file: unsupported format %q", env.Format)
+	}
+	plaintext, err := m.aead.Open(nil, env.Nonce, env.Ciphertext, []byte(fileFormat))
+	if err != nil {
+		return fmt.Errorf("%w: cannot decrypt %s", ErrInvalidKey, m.path)
+	}
+	defer clear(plaintext)
+	secrets := make(map[string][]secretVersion)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("decode secrets file: %w", err)
+	}
+	m.secrets = secrets
+	return nil
+}
+
+// save encrypts the store with aead and atomically replaces the file.
+func (m *FileManager) save(aead cipher.AEAD) error {
+	plaintext, err := json.Marshal(m.secrets)
+	if err != nil {
+		return err
+	}
+	defer clear(plaintext)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data, err := json.Marshal(fileEnvelope{
+		Format:     fileFormat,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, plaintext, []byte(fileFormat)),
+	})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(m.path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(m.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return fmt.Errorf("write secrets file: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves the current version of a secret.
+func (m *FileManager) Get(ctx context.Context, key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.secrets[key]
+	if len(versions) == 0 {
+		return "", ErrSecretNotFound
+	}
+	return versions[len(versions)-1].Value, nil
+}
+
+// GetVersion retrieves a specific version of a secret.
+func (m *FileManager) GetVersion(ctx context.Context, key string, version int) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.secrets[key]
+	if len(versions) == 0 {
+		return "", ErrSecretNotFound
+	}
+	for _, v := range versions {
+		if v.Version == version {
+			return v.Value, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s version %d", ErrVersionNotFound, key, version)
+}
+
+// Versions lists the retained versions of a secret, oldest first.
+func (m *FileManager) Versions(ctx context.Context, key string) ([]VersionInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	versions := m.secrets[key]
+	if len(versions) == 0 {
+		return nil, ErrSecretNotFound
+	}
+	infos := make([]VersionInfo, len(versions))
+	for i, v := range versions {
+		infos[i] = VersionInfo{Version: v.Version, CreatedAt: v.CreatedAt, Current: i == len(versions)-1}
+	}
+	return infos, nil
+}
+
+// Set stores value as the new current version of a secret, dropping the
+// oldest versions beyond the retention limit.
+func (m *FileManager) Set(ctx context.Context, key, value string) error {
+	if m.readOnly {
+		return ErrSecretReadOnly
+	}
+	if key == "" {
+		return errors.New("secret key is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev := m.secrets[key]
+	next := 1
+	if len(prev) > 0 {
+		next = prev[len(prev)-1].Version + 1
+	}
+	versions := append(append([]secretVersion(nil), prev...), secretVersion{
+		Version:   next,
+		Value:     value,
+		CreatedAt: time.Now().UTC(),
+	})
+	if len(versions) > m.maxVersions {
+		versions = versions[len(versions)-m.maxVersions:]
+	}
+	m.secrets[key] = versions
+	if err := m.save(m.aead); err != nil {
+		m.secrets[key] = prev
+		return err
+	}
+	return nil
+}
+
+// Delete removes a secret and all of its versions.
+func (m *FileManager) Delete(ctx context.Context, key string) error {
+	if m.readOnly {
+		return ErrSecretReadOnly
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev, exists := m.secrets[key]
+	if !exists {
+		return ErrSecretNotFound
+	}
+	delete(m.secrets, key)
+	if err := m.save(m.aead); err != nil {
+		m.secrets[key] = prev
+		return err
+	}
+	return nil
+}
+
+// List returns all secret keys with the given prefix, sorted.
+func (m *FileManager) List(ctx context.Context, prefix string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var keys []string
+	for key := range m.secrets {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Exists checks if a secret exists.
+func (m *FileManager) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, exists := m.secrets[key]
+	return exists, nil
+}
+
+// RotateKey re-encrypts the file under a new 32-byte key. Later writes use
+// the new key; the old key can no longer open the file.
+func (m *FileManager) RotateKey(ctx context.Context, newKey []byte) error {
+	if m.readOnly {
+		return ErrSecretReadOnly
+	}
+	aead, err := newAEAD(newKey)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.save(aead); err != nil {
+		return err
+	}
+	m.aead = aead
+	return nil
+}
+
+// GenerateKey returns a random 32-byte key for NewFileManager.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
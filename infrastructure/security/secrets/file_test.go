@@ -0,0 +1,246 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestFileManager(t *testing.T, opts ...FileOption) (*FileManager, string, []byte) {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	manager, err := NewFileManager(path, key, opts...)
+	if err != nil {
+		t.Fatalf("NewFileManager failed: %v", err)
+	}
+	return manager, path, key
+}
+
+func TestFileManagerPersistsEncrypted(t *testing.T) {
+	ctx := context.Background()
+	manager, path, key := newTestFileManager(t)
+
+	if err := manager.Set(ctx, "db/password", "hunter2"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) || bytes.Contains(data, []byte("db/password")) {
+		t.Error("secrets file contains plaintext")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode().Perm())
+	}
+
+	reopened, err := NewFileManager(path, key)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	value, err := reopened.Get(ctx, "db/password")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if value != "hunter2" {
+		t.Errorf("expected hunter2, got %s", value)
+	}
+
+	wrongKey, _ := GenerateKey()
+	if _, err := NewFileManager(path, wrongKey); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for wrong key, got %v", err)
+	}
+	if _, err := NewFileManager(path, []byte("short")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for short key, got %v", err)
+	}
+}
+
+func TestFileManagerVersions(t *testing.T) {
+	ctx := context.Background()
+	manager, _, _ := newTestFileManager(t, WithMaxVersions(2))
+
+	for _, v := range []string{"one", "two", "three"} {
+		if err := manager.Set(ctx, "token", v); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	value, err := manager.Get(ctx, "token")
+	if err != nil || value != "three" {
+		t.Errorf("expected current value three, got %q (%v)", value, err)
+	}
+	value, err = manager.GetVersion(ctx, "token", 2)
+	if err != nil || value != "two" {
+		t.Errorf("expected version 2 to be two, got %q (%v)", value, err)
+	}
+	if _, err := manager.GetVersion(ctx, "token", 1); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected pruned version 1 to be gone, got %v", err)
+	}
+
+	versions, err := manager.Versions(ctx, "token")
+	if err != nil {
+		t.Fatalf("Versions failed: %v", err)
+	}
+	if len(versions) != 2 || versions[0].Version != 2 || !versions[1].Current || versions[1].Version != 3 {
+		t.Errorf("unexpected versions: %+v", versions)
+	}
+
+	if err := manager.Delete(ctx, "token"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := manager.Versions(ctx, "token"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound after delete, got %v", err)
+	}
+}
+
+func TestFileManagerRotateKey(t *testing.T) {
+	ctx := context.Background()
+	manager, path, oldKey := newTestFileManager(t)
+
+	if err := manager.Set(ctx, "api_key", "xyz"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	newKey, _ := GenerateKey()
+	if err := manager.RotateKey(ctx, newKey); err != nil {
+		t.Fatalf("RotateKey failed: %v", err)
+	}
+	if err := manager.Set(ctx, "other", "abc"); err != nil {
+		t.Fatalf("Set after rotation failed: %v", err)
+	}
+
+	if _, err := NewFileManager(path, oldKey); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected old key to be rejected, got %v", err)
+	}
+	reopened, err := NewFileManager(path, newKey)
+	if err != nil {
+		t.Fatalf("reopen with new key failed: %v", err)
+	}
+	keys, _ := reopened.List(ctx, "")
+	if len(keys) != 2 || keys[0] != "api_key" || keys[1] != "other" {
+		t.Errorf("unexpected keys after rotation: %v", keys)
+	}
+}
+
+func TestFileManagerReadOnly(t *testing.T) {
+	ctx := context.Background()
+	manager, _, _ := newTestFileManager(t, WithFileReadOnly())
+
+	if err := manager.Set(ctx, "key", "value"); err != ErrSecretReadOnly {
+		t.Errorf("expected ErrSecretReadOnly, got %v", err)
+	}
+	if err := manager.Delete(ctx, "key"); err != ErrSecretReadOnly {
+		t.Errorf("expected ErrSecretReadOnly, got %v", err)
+	}
+}
+
+func TestHandleStore(t *testing.T) {
+	ctx := context.Background()
+	manager, _, _ := newTestFileManager(t)
+	_ = manager.Set(ctx, "token", "v1")
+	_ = manager.Set(ctx, "token", "v2")
+
+	store := NewHandleStore(manager)
+	current, err := store.Issue(ctx, "token", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	pinned, err := store.Issue(ctx, "token", 1)
+	if err != nil {
+		t.Fatalf("Issue version failed: %v", err)
+	}
+	if !IsHandle(current.ID) || IsHandle("secret:xyz") {
+		t.Errorf("IsHandle misclassified %q", current.ID)
+	}
+
+	value, err := store.Resolve(ctx, current.ID)
+	if err != nil || value != "v2" {
+		t.Errorf("expected v2, got %q (%v)", value, err)
+	}
+	expanded, err := store.Expand(ctx, "Bearer "+pinned.ID)
+	if err != nil || expanded != "Bearer v1" {
+		t.Errorf("expected Bearer v1, got %q (%v)", expanded, err)
+	}
+
+	// Handles follow rotation of the current version.
+	_ = manager.Set(ctx, "token", "v3")
+	if value, _ := store.Resolve(ctx, current.ID); value != "v3" {
+		t.Errorf("expected v3 after rotation, got %q", value)
+	}
+
+	store.Revoke(current.ID)
+	if _, err := store.Resolve(ctx, current.ID); !errors.Is(err, ErrInvalidHandle) {
+		t.Errorf("expected ErrInvalidHandle after revoke, got %v", err)
+	}
+	if _, err := store.Issue(ctx, "missing", 0); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+	if _, err := NewHandleStore(NewMemoryManager(WithInitialSecrets(map[string]string{"k": "v"}))).Issue(ctx, "k", 2); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected ErrVersionNotFound for unversioned manager, got %v", err)
+	}
+}
+
+func TestHandleStoreExpiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewHandleStore(NewMemoryManager(WithInitialSecrets(map[string]string{"k": "v"})), WithHandleTTL(time.Millisecond))
+
+	h, err := store.Issue(ctx, "k", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := store.Resolve(ctx, h.ID); !errors.Is(err, ErrInvalidHandle) {
+		t.Errorf("expected ErrInvalidHandle after expiry, got %v", err)
+	}
+	if _, err := store.Expand(ctx, "x "+h.ID); !errors.Is(err, ErrInvalidHandle) {
+		t.Errorf("expected Expand to fail on expired handle, got %v", err)
+	}
+}
+
+func TestHandleStoreExpandJSON(t *testing.T) {
+	ctx := context.Background()
+	store := NewHandleStore(NewMemoryManager(WithInitialSecrets(map[string]string{"token": `p"w\d`})))
+	h, err := store.Issue(ctx, "token", 0)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	plain := json.RawMessage(`{"n": 1.50, "s": "secret:"}`)
+	if out, err := store.ExpandJSON(ctx, plain); err != nil || string(out) != string(plain) {
+		t.Errorf("expected input without handles unchanged, got %s (%v)", out, err)
+	}
+
+	doc := json.RawMessage(`{"url":"https://x","headers":{"Authorization":"Bearer ` + h.ID + `"},"args":["` + h.ID + `",2]}`)
+	out, err := store.ExpandJSON(ctx, doc)
+	if err != nil {
+		t.Fatalf("ExpandJSON failed: %v", err)
+	}
+	var got struct {
+		Headers map[string]string `json:"headers"`
+		Args    []any             `json:"args"`
+	}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatalf("expanded input is not valid JSON: %s", out)
+	}
+	if got.Headers["Authorization"] != `Bearer p"w\d` || got.Args[0] != `p"w\d` || got.Args[1] != float64(2) {
+		t.Errorf("unexpected expansion: %s", out)
+	}
+
+	store.Revoke(h.ID)
+	if _, err := store.ExpandJSON(ctx, doc); !errors.Is(err, ErrInvalidHandle) {
+		t.Errorf("expected ErrInvalidHandle for a revoked handle, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrInvalidHandle is returned when a handle is unknown, expired or revoked.
+var ErrInvalidHandle = errors.New("invalid secret handle")
+
+// HandlePrefix starts every handle string.
+const HandlePrefix = "secret:"
+
+var handlePattern = regexp.MustCompile(`secret:[0-9a-f]{32}`)
+
+// Handle is an opaque reference to a secret version. Planners and ledgers
+// only ever see the handle; tools resolve it to the value server-side.
+type Handle struct {
+	ID        string    `json:"handle"`
+	Key       string    `json:"key"`
+	Version   int       `json:"version,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// HandleStore issues and resolves secret handles. Handles record only the
+// key and version; values are read from the manager at resolution time,
+// so rotating or deleting a secret takes effect for outstanding handles.
+type HandleStore struct {
+	manager Manager
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	handles map[string]Handle
+}
+
+// HandleOption configures the handle store.
+type HandleOption func(*HandleStore)
+
+// WithHandleTTL sets how long handles stay valid. Defaults to 15 minutes.
+func WithHandleTTL(ttl time.Duration) HandleOption {
+	return func(s *HandleStore) {
+		if ttl > 0 {
+			s.ttl = ttl
+		}
+	}
+}
+
+// NewHandleStore creates a handle store over a manager.
+func NewHandleStore(manager Manager, opts ...HandleOption) *HandleStore {
+	s := &HandleStore{
+		manager: manager,
+		ttl:     15 * time.Minute,
+		handles: make(map[string]Handle),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Issue returns a handle for a secret. Version 0 tracks the current
+// version; other versions require a VersionedManager.
+func (s *HandleStore) Issue(ctx context.Context, key string, version int) (Handle, error) {
+	if version == 0 {
+		exists, err := s.manager.Exists(ctx, key)
+		if err != nil {
+			return Handle{}, err
+		}
+		if !exists {
+			return Handle{}, ErrSecretNotFound
+		}
+	} else {
+		versioned, ok := s.manager.(VersionedManager)
+		if !ok {
+			return Handle{}, fmt.Errorf("%w: manager does not keep versions", ErrVersionNotFound)
+		}
+		if _, err := versioned.GetVersion(ctx, key, version); err != nil {
+			return Handle{}, err
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Handle{}, err
+	}
+	h := Handle{
+		ID:        HandlePrefix + hex.EncodeToString(id),
+		Key:       key,
+		Version:   version,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prune()
+	s.handles[h.ID] = h
+	return h, nil
+}
+
+// Resolve returns the secret value a handle refers to.
+func (s *HandleStore) Resolve(ctx context.Context, id string) (string, error) {
+	s.mu.Lock()
+	h, ok := s.handles[id]
+	if ok && time.Now().After(h.ExpiresAt) {
+		delete(s.handles, id)
+		ok = false
+	}
+	s.mu.Unlock()
+	if !ok {
+		return "", ErrInvalidHandle
+	}
+
+	if h.Version == 0 {
+		return s.manager.Get(ctx, h.Key)
+	}
+	versioned, ok := s.manager.(VersionedManager)
+	if !ok {
+		return "", ErrVersionNotFound
+	}
+	return versioned.GetVersion(ctx, h.Key, h.Version)
+}
+
+// Expand replaces every handle in text with its value, so tools can accept
+// handles anywhere in their input.
+func (s *HandleStore) Expand(ctx context.Context, text string) (string, error) {
+	var firstErr error
+	out := handlePattern.ReplaceAllStringFunc(text, func(id string) string {
+		if firstErr != nil {
+			return id
+		}
+		value, err := s.Resolve(ctx, id)
+		if err != nil {
+			firstErr = fmt.Errorf("%s: %w", id, err)
+			return id
+		}
+		return value
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return out, nil
+}
+
+// ExpandJSON replaces every handle inside the string values and object keys
+// of a JSON document with its value. Values are re-encoded, so quotes and
+// control characters in a secret cannot alter the document's structure.
+// Documents without handles are returned unchanged.
+func (s *HandleStore) ExpandJSON(ctx context.Context, doc json.RawMessage) (json.RawMessage, error) {
+	if !handlePattern.Match(doc) {
+		return doc, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	expanded, err := s.expandValue(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(expanded)
+}
+
+func (s *HandleStore) expandValue(ctx context.Context, v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return s.Expand(ctx, v)
+	case []any:
+		for i, item := range v {
+			expanded, err := s.expandValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			expandedKey, err := s.Expand(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			expanded, err := s.expandValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			out[expandedKey] = expanded
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// ResolveAll resolves every handle in text, keyed by handle.
+func (s *HandleStore) ResolveAll(ctx context.Context, text []byte) (map[string]string, error) {
+	ids := handlePattern.FindAll(text, -1)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]string, len(ids))
+	for _, id := range ids {
+		if _, ok := values[string(id)]; ok {
+			continue
+		}
+		value, err := s.Resolve(ctx, string(id))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", id, err)
+		}
+		values[string(id)] = value
+	}
+	return values, nil
+}
+
+// Revoke invalidates a handle.
+func (s *HandleStore) Revoke(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.handles, id)
+}
+
+// IsHandle reports whether text is exactly one handle.
+func IsHandle(text string) bool {
+	return len(text) == len(HandlePrefix)+32 && handlePattern.MatchString(text)
+}
+
+// prune drops expired handles. Callers hold s.mu.
+func (s *HandleStore) prune() {
+	now := time.Now()
+	for id, h := range s.handles {
+		if now.After(h.ExpiresAt) {
+			delete(s.handles, id)
+		}
+	}
+}
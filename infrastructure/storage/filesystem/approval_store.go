@@ -0,0 +1,177 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+)
+
+// ApprovalStore implements approval.Store with one JSON file per ticket, so
+// pending approvals survive process restarts and can be decided by another
+// process, such as the CLI.
+type ApprovalStore struct {
+	mu       sync.Mutex
+	basePath string
+}
+
+// NewApprovalStore creates a new filesystem approval store.
+func NewApprovalStore(basePath string) (*ApprovalStore, error) {
+	if err := os.MkdirAll(basePath, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create approval directory: %w", err)
+	}
+	return &ApprovalStore{basePath: basePath}, nil
+}
+
+// Save persists a new ticket.
+func (s *ApprovalStore) Save(_ context.Context, t *approval.Ticket) error {
+	if t == nil || !validTicketID(t.ID) {
+		return approval.ErrInvalidTicket
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := os.Stat(s.ticketPath(t.ID)); err == nil {
+		return approval.ErrTicketExists
+	}
+	return s.write(t)
+}
+
+// Get retrieves a ticket by ID.
+func (s *ApprovalStore) Get(_ context.Context, id string) (*approval.Ticket, error) {
+	if !validTicketID(id) {
+		return nil, approval.ErrTicketNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(s.ticketPath(id))
+}
+
+// Update updates an existing ticket.
+func (s *ApprovalStore) Update(_ context.Context, t *approval.Ticket) error {
+	if t == nil || !validTicketID(t.ID) {
+		return approval.ErrInvalidTicket
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.read(s.ticketPath(t.ID))
+	if err != nil {
+		return err
+	}
+	if stored.Revision != t.Revision {
+		return fmt.Errorf("%w: ticket %s", approval.ErrTicketConflict, t.ID)
+	}
+	t.Revision++
+	if err := s.write(t); err != nil {
+		t.Revision--
+		return err
+	}
+	return nil
+}
+
+// List returns tickets matching the filter, oldest first.
+func (s *ApprovalStore) List(_ context.Context, filter approval.ListFilter) ([]*approval.Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval directory: %w", err)
+	}
+
+	results := make([]*approval.Ticket, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		t, err := s.read(filepath.Join(s.basePath, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if filter.Matches(t) {
+			results = append(results, t)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+// Delete removes a ticket.
+func (s *ApprovalStore) Delete(_ context.Context, id string) error {
+	if !validTicketID(id) {
+		return approval.ErrTicketNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.ticketPath(id)); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return approval.ErrTicketNotFound
+		}
+		return fmt.Errorf("failed to delete ticket: %w", err)
+	}
+	return nil
+}
+
+// write stores the ticket atomically through a temporary file.
+func (s *ApprovalStore) write(t *approval.Ticket) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encode ticket: %w", err)
+	}
+	tmp := s.ticketPath(t.ID) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write ticket: %w", err)
+	}
+	if err := os.Rename(tmp, s.ticketPath(t.ID)); err != nil {
+		os.Remove(tmp) // #nosec G104 -- best-effort cleanup in error path
+		return fmt.Errorf("failed to write ticket: %w", err)
+	}
+	return nil
+}
+
+func (s *ApprovalStore) read(path string) (*approval.Ticket, error) {
+	// #nosec G304 -- path is built from a validated ticket ID or a directory listing
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, approval.ErrTicketNotFound
+		}
+		return nil, fmt.Errorf("failed to read ticket: %w", err)
+	}
+	var t approval.Ticket
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode ticket %s: %w", filepath.Base(path), err)
+	}
+	return &t, nil
+}
+
+func (s *ApprovalStore) ticketPath(id string) string {
+	return filepath.Join(s.basePath, id+".json")
+}
+
+// validTicketID rejects IDs that could escape the store directory.
+func validTicketID(id string) bool {
+	return id != "" && !strings.ContainsAny(id, `/\`) && id != "." && id != ".."
+}
+
+// Ensure ApprovalStore implements Store
+var _ approval.Store = (*ApprovalStore)(nil)
@@ -0,0 +1,75 @@
+package filesystem
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+)
+
+func TestApprovalStore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ctx := context.Background()
+	store, err := NewApprovalStore(dir)
+	if err != nil {
+		t.Fatalf("NewApprovalStore() error = %v", err)
+	}
+
+	ticket := approval.NewTicket(policy.ApprovalRequest{
+		RunID:    "run-1",
+		ToolName: "deploy",
+		Input:    json.RawMessage(`{"env":"prod"}`),
+	})
+	if err := store.Save(ctx, ticket); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(ctx, ticket); !errors.Is(err, approval.ErrTicketExists) {
+		t.Errorf("expected ErrTicketExists, got %v", err)
+	}
+
+	// A second store over the same directory sees the ticket, as after a restart.
+	reopened, err := NewApprovalStore(dir)
+	if err != nil {
+		t.Fatalf("NewApprovalStore() error = %v", err)
+	}
+	got, err := reopened.Get(ctx, ticket.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Request.ToolName != "deploy" || string(got.Request.Input) != `{"env":"prod"}` {
+		t.Errorf("unexpected ticket: %+v", got)
+	}
+
+	if err := got.Decide(policy.ApprovalResponse{Approved: false, Approver: "bob"}); err != nil {
+		t.Fatalf("Decide() error = %v", err)
+	}
+	if err := reopened.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// An update from a stale copy is rejected.
+	if err := store.Update(ctx, ticket); !errors.Is(err, approval.ErrTicketConflict) {
+		t.Errorf("expected ErrTicketConflict, got %v", err)
+	}
+	denied, err := store.List(ctx, approval.ListFilter{Status: []approval.Status{approval.StatusDenied}})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(denied) != 1 || denied[0].Response.Approver != "bob" {
+		t.Errorf("expected the denied ticket, got %+v", denied)
+	}
+
+	if _, err := store.Get(ctx, "../escape"); !errors.Is(err, approval.ErrTicketNotFound) {
+		t.Errorf("expected ErrTicketNotFound for a path-like ID, got %v", err)
+	}
+	if err := store.Delete(ctx, ticket.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(ctx, ticket.ID); !errors.Is(err, approval.ErrTicketNotFound) {
+		t.Errorf("expected ErrTicketNotFound, got %v", err)
+	}
+}
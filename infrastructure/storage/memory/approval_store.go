@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+)
+
+// ApprovalStore is an in-memory implementation of approval.Store.
+type ApprovalStore struct {
+	mu      sync.RWMutex
+	tickets map[string]*approval.Ticket
+}
+
+// NewApprovalStore creates a new in-memory approval store.
+func NewApprovalStore() *ApprovalStore {
+	return &ApprovalStore{
+		tickets: make(map[string]*approval.Ticket),
+	}
+}
+
+// Save persists a new ticket.
+func (s *ApprovalStore) Save(_ context.Context, t *approval.Ticket) error {
+	if t == nil || t.ID == "" {
+		return approval.ErrInvalidTicket
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tickets[t.ID]; exists {
+		return approval.ErrTicketExists
+	}
+	s.tickets[t.ID] = copyTicket(t)
+	return nil
+}
+
+// Get retrieves a ticket by ID.
+func (s *ApprovalStore) Get(_ context.Context, id string) (*approval.Ticket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, exists := s.tickets[id]
+	if !exists {
+		return nil, approval.ErrTicketNotFound
+	}
+	return copyTicket(t), nil
+}
+
+// Update updates an existing ticket.
+func (s *ApprovalStore) Update(_ context.Context, t *approval.Ticket) error {
+	if t == nil || t.ID == "" {
+		return approval.ErrInvalidTicket
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, exists := s.tickets[t.ID]
+	if !exists {
+		return approval.ErrTicketNotFound
+	}
+	if stored.Revision != t.Revision {
+		return fmt.Errorf("%w: ticket %s", approval.ErrTicketConflict, t.ID)
+	}
+	t.Revision++
+	s.tickets[t.ID] = copyTicket(t)
+	return nil
+}
+
+// List returns tickets matching the filter, oldest first.
+func (s *ApprovalStore) List(_ context.Context, filter approval.ListFilter) ([]*approval.Ticket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]*approval.Ticket, 0)
+	for _, t := range s.tickets {
+		if filter.Matches(t) {
+			results = append(results, copyTicket(t))
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].CreatedAt.Before(results[j].CreatedAt)
+	})
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+// Delete removes a ticket.
+func (s *ApprovalStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tickets[id]; !exists {
+		return approval.ErrTicketNotFound
+	}
+	delete(s.tickets, id)
+	return nil
+}
+
+// copyTicket creates a deep copy of a ticket.
+func copyTicket(t *approval.Ticket) *approval.Ticket {
+	cp := *t
+	if t.Request.Input != nil {
+		cp.Request.Input = append([]byte(nil), t.Request.Input...)
+	}
+	if t.Response != nil {
+		resp := *t.Response
+		cp.Response = &resp
+	}
+	if t.EscalatedAt != nil {
+		at := *t.EscalatedAt
+		cp.EscalatedAt = &at
+	}
+	if t.ConsumedAt != nil {
+		at := *t.ConsumedAt
+		cp.ConsumedAt = &at
+	}
+	return &cp
+}
+
+// Ensure ApprovalStore implements Store
+var _ approval.Store = (*ApprovalStore)(nil)
@@ -0,0 +1,73 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
+)
+
+func TestApprovalStore(t *testing.T) {
+	t.Parallel()
+
+	store := memory.NewApprovalStore()
+	ctx := context.Background()
+
+	first := approval.NewTicket(policy.ApprovalRequest{RunID: "run-1", ToolName: "deploy"})
+	second := approval.NewTicket(policy.ApprovalRequest{RunID: "run-2", ToolName: "delete"})
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+
+	for _, ticket := range []*approval.Ticket{first, second} {
+		if err := store.Save(ctx, ticket); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if err := store.Save(ctx, first); !errors.Is(err, approval.ErrTicketExists) {
+		t.Errorf("expected ErrTicketExists, got %v", err)
+	}
+
+	// Stored tickets are copies.
+	first.Status = approval.StatusDenied
+	got, err := store.Get(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.Status != approval.StatusPending {
+		t.Errorf("stored ticket was mutated: %s", got.Status)
+	}
+
+	if err := got.Decide(policy.ApprovalResponse{Approved: true, Approver: "alice"}); err != nil {
+		t.Fatalf("Decide() error = %v", err)
+	}
+	if err := store.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// An update from a stale copy is rejected.
+	if err := store.Update(ctx, first); !errors.Is(err, approval.ErrTicketConflict) {
+		t.Errorf("expected ErrTicketConflict, got %v", err)
+	}
+
+	pending, err := store.List(ctx, approval.ListFilter{Status: []approval.Status{approval.StatusPending}})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != second.ID {
+		t.Errorf("expected only the second ticket pending, got %d", len(pending))
+	}
+
+	all, _ := store.List(ctx, approval.ListFilter{})
+	if len(all) != 2 || all[0].ID != first.ID {
+		t.Errorf("expected both tickets oldest first, got %d", len(all))
+	}
+
+	if err := store.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, first.ID); !errors.Is(err, approval.ErrTicketNotFound) {
+		t.Errorf("expected ErrTicketNotFound, got %v", err)
+	}
+}
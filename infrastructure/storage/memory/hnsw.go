@@ -0,0 +1,498 @@
+package memory
+
+import (
+	"container/heap"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+
+	"github.com/felixgeelhaar/agent-go/domain/knowledge"
+)
+
+// HNSWConfig tunes the approximate nearest-neighbour index.
+type HNSWConfig struct {
+	// M is the number of links per node on the upper layers; layer 0
+	// keeps up to 2*M. Higher values improve recall at the cost of memory
+	// and insert time. Defaults to 16.
+	M int
+
+	// EfConstruction is the candidate list size while inserting. Higher
+	// values build a better graph more slowly. Defaults to 200.
+	EfConstruction int
+
+	// EfSearch is the candidate list size while searching, and the main
+	// recall/latency trade-off. At least topK is always used. Defaults
+	// to 64.
+	EfSearch int
+
+	// Seed makes level assignment, and so the graph, deterministic.
+	// Zero uses a random seed.
+	Seed uint64
+}
+
+// withDefaults fills unset fields.
+func (c HNSWConfig) withDefaults() HNSWConfig {
+	if c.M <= 0 {
+		c.M = 16
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = 200
+	}
+	if c.EfSearch <= 0 {
+		c.EfSearch = 64
+	}
+	return c
+}
+
+// hnswMaxLevel bounds node levels; with M >= 2 higher levels are
+// vanishingly rare.
+const hnswMaxLevel = 16
+
+// hnswIndex is a hierarchical navigable small world graph over cosine
+// similarity (Malkov and Yashunin, 2016). Deleted and replaced nodes stay
+// in the graph as tombstones, so that it stays connected, and are skipped
+// in results; the graph is rebuilt once tombstones outnumber live nodes.
+//
+// Writes must hold the store's write lock. Searches may run concurrently
+// under its read lock.
+type hnswIndex struct {
+	cfg       HNSWConfig
+	levelMult float64
+	rng       *rand.Rand
+
+	nodes    []hnswNode
+	byID     map[string]uint32
+	entry    uint32
+	maxLevel int // -1 when empty
+	deleted  int
+
+	visited sync.Pool
+}
+
+type hnswNode struct {
+	vector  *knowledge.Vector
+	invNorm float32
+	links   [][]uint32 // per layer, 0 to the node's level
+	deleted bool
+}
+
+func newHNSWIndex(cfg HNSWConfig) *hnswIndex {
+	cfg = cfg.withDefaults()
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &hnswIndex{
+		cfg:       cfg,
+		levelMult: 1 / math.Log(float64(max(cfg.M, 2))),
+		rng:       rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		byID:      make(map[string]uint32),
+		maxLevel:  -1,
+	}
+}
+
+// live returns the number of nodes that are not tombstones.
+func (ix *hnswIndex) live() int {
+	return len(ix.nodes) - ix.deleted
+}
+
+// needsCompaction reports whether tombstones outnumber live nodes enough
+// to justify a rebuild.
+func (ix *hnswIndex) needsCompaction() bool {
+	return ix.deleted >= 256 && ix.deleted > ix.live()
+}
+
+// rebuild returns a new index over the live nodes, in insertion order.
+func (ix *hnswIndex) rebuild() *hnswIndex {
+	fresh := newHNSWIndex(ix.cfg)
+	fresh.rng = ix.rng
+	for _, n := range ix.nodes {
+		if !n.deleted {
+			fresh.insert(n.vector)
+		}
+	}
+	return fresh
+}
+
+// ============================================================================
+// Similarity
+// ============================================================================
+
+func dot(a, b []float32) float32 {
+	var s0, s1, s2, s3 float32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += a[i] * b[i]
+		s1 += a[i+1] * b[i+1]
+		s2 += a[i+2] * b[i+2]
+		s3 += a[i+3] * b[i+3]
+	}
+	for ; i < len(a); i++ {
+		s0 += a[i] * b[i]
+	}
+	return s0 + s1 + s2 + s3
+}
+
+// inverseNorm returns 1/|v|, or 0 for the zero vector, whose similarity
+// to anything is then 0 as in cosineSimilarity.
+func inverseNorm(v []float32) float32 {
+	n := math.Sqrt(float64(dot(v, v)))
+	if n == 0 {
+		return 0
+	}
+	return float32(1 / n)
+}
+
+// unit returns v scaled to unit length.
+func unit(v []float32) []float32 {
+	inv := inverseNorm(v)
+	u := make([]float32, len(v))
+	for i, x := range v {
+		u[i] = x * inv
+	}
+	return u
+}
+
+// sim is the cosine similarity between a unit query and a node.
+func (ix *hnswIndex) sim(q []float32, id uint32) float32 {
+	n := &ix.nodes[id]
+	return dot(q, n.vector.Embedding) * n.invNorm
+}
+
+// nodeSim is the cosine similarity between two nodes.
+func (ix *hnswIndex) nodeSim(a, b uint32) float32 {
+	na, nb := &ix.nodes[a], &ix.nodes[b]
+	return dot(na.vector.Embedding, nb.vector.Embedding) * na.invNorm * nb.invNorm
+}
+
+// ============================================================================
+// Graph Search
+// ============================================================================
+
+type scoredNode struct {
+	id  uint32
+	sim float32
+}
+
+// nodeHeap is a heap of scored nodes, most similar on top when max is set
+// and least similar otherwise.
+type nodeHeap struct {
+	items []scoredNode
+	max   bool
+}
+
+func (h *nodeHeap) Len() int { return len(h.items) }
+func (h *nodeHeap) Less(i, j int) bool {
+	if h.max {
+		return h.items[i].sim > h.items[j].sim
+	}
+	return h.items[i].sim < h.items[j].sim
+}
+func (h *nodeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *nodeHeap) Push(x any)    { h.items = append(h.items, x.(scoredNode)) }
+func (h *nodeHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// visitedSet marks nodes seen by one search. Sets are pooled and cleared
+// by bumping a generation instead of zeroing.
+type visitedSet struct {
+	marks []uint32
+	gen   uint32
+}
+
+func (ix *hnswIndex) acquireVisited() *visitedSet {
+	v, _ := ix.visited.Get().(*visitedSet)
+	if v == nil {
+		v = &visitedSet{}
+	}
+	if len(v.marks) < len(ix.nodes) {
+		v.marks = make([]uint32, len(ix.nodes)+len(ix.nodes)/4)
+		v.gen = 0
+	}
+	v.gen++
+	if v.gen == 0 {
+		clear(v.marks)
+		v.gen = 1
+	}
+	return v
+}
+
+// visit marks id and reports whether it was already marked.
+func (v *visitedSet) visit(id uint32) bool {
+	if v.marks[id] == v.gen {
+		return true
+	}
+	v.marks[id] = v.gen
+	return false
+}
+
+// searchLayer returns up to ef nodes of one layer most similar to q,
+// most similar first. Nodes rejected by accept are traversed but not
+// returned.
+func (ix *hnswIndex) searchLayer(q []float32, entries []scoredNode, ef, layer int, accept func(uint32) bool) []scoredNode {
+	visited := ix.acquireVisited()
+	defer ix.visited.Put(visited)
+
+	candidates := &nodeHeap{max: true}
+	results := &nodeHeap{}
+	for _, e := range entries {
+		if visited.visit(e.id) {
+			continue
+		}
+		heap.Push(candidates, e)
+		if accept == nil || accept(e.id) {
+			heap.Push(results, e)
+		}
+	}
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(scoredNode)
+		if results.Len() >= ef && c.sim < results.items[0].sim {
+			break
+		}
+		links := ix.nodes[c.id].links
+		if layer >= len(links) {
+			continue
+		}
+		for _, n := range links[layer] {
+			if visited.visit(n) {
+				continue
+			}
+			s := ix.sim(q, n)
+			if results.Len() < ef || s > results.items[0].sim {
+				heap.Push(candidates, scoredNode{n, s})
+				if accept == nil || accept(n) {
+					heap.Push(results, scoredNode{n, s})
+					if results.Len() > ef {
+						heap.Pop(results)
+					}
+				}
+			}
+		}
+	}
+	found := results.items
+	sort.Slice(found, func(i, j int) bool { return found[i].sim > found[j].sim })
+	return found
+}
+
+// descend greedily walks the layers above target and returns the entry
+// point for it.
+func (ix *hnswIndex) descend(q []float32, target int) []scoredNode {
+	ep := []scoredNode{{ix.entry, ix.sim(q, ix.entry)}}
+	for layer := ix.maxLevel; layer > target; layer-- {
+		ep = ix.searchLayer(q, ep, 1, layer, nil)[:1]
+	}
+	return ep
+}
+
+// search returns up to k nodes most similar to the unit query that
+// accept admits, searching with candidate list size ef.
+func (ix *hnswIndex) search(q []float32, k, ef int, accept func(uint32) bool) []scoredNode {
+	if ix.maxLevel < 0 || k <= 0 {
+		return nil
+	}
+	found := ix.searchLayer(q, ix.descend(q, 0), max(ef, k), 0, accept)
+	return found[:min(k, len(found))]
+}
+
+// ============================================================================
+// Graph Construction
+// ============================================================================
+
+func (ix *hnswIndex) maxLinks(layer int) int {
+	if layer == 0 {
+		return 2 * ix.cfg.M
+	}
+	return ix.cfg.M
+}
+
+func (ix *hnswIndex) randomLevel() int {
+	level := int(-math.Log(1-ix.rng.Float64()) * ix.levelMult)
+	return min(level, hnswMaxLevel)
+}
+
+func (ix *hnswIndex) isLive(id uint32) bool {
+	return !ix.nodes[id].deleted
+}
+
+// selectNeighbors picks up to m of the candidates, most similar first,
+// preferring ones not already covered by a closer selected neighbour so
+// that links span clusters. Skipped candidates fill any remaining slots.
+func (ix *hnswIndex) selectNeighbors(candidates []scoredNode, m int) []uint32 {
+	selected := make([]uint32, 0, m)
+	var skipped []uint32
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		diverse := true
+		for _, s := range selected {
+			if ix.nodeSim(c.id, s) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			selected = append(selected, c.id)
+		} else {
+			skipped = append(skipped, c.id)
+		}
+	}
+	for _, id := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, id)
+	}
+	return selected
+}
+
+// link adds a link from node to neighbour on a layer, pruning the node's
+// links when it has too many.
+func (ix *hnswIndex) link(node, neighbour uint32, layer int) {
+	links := append(ix.nodes[node].links[layer], neighbour)
+	if limit := ix.maxLinks(layer); len(links) > limit {
+		candidates := make([]scoredNode, len(links))
+		for i, id := range links {
+			candidates[i] = scoredNode{id, ix.nodeSim(node, id)}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].sim > candidates[j].sim })
+		links = ix.selectNeighbors(candidates, limit)
+	}
+	ix.nodes[node].links[layer] = links
+}
+
+// insert adds a stored vector, replacing any node with the same ID.
+func (ix *hnswIndex) insert(v *knowledge.Vector) {
+	ix.remove(v.ID)
+
+	id := uint32(len(ix.nodes))
+	level := ix.randomLevel()
+	ix.nodes = append(ix.nodes, hnswNode{
+		vector:  v,
+		invNorm: inverseNorm(v.Embedding),
+		links:   make([][]uint32, level+1),
+	})
+	ix.byID[v.ID] = id
+	if ix.maxLevel < 0 {
+		ix.entry, ix.maxLevel = id, level
+		return
+	}
+
+	q := unit(v.Embedding)
+	ep := ix.descend(q, level)
+	for layer := min(level, ix.maxLevel); layer >= 0; layer-- {
+		found := ix.searchLayer(q, ep, ix.cfg.EfConstruction, layer, ix.isLive)
+		neighbours := ix.selectNeighbors(found, ix.cfg.M)
+		ix.nodes[id].links[layer] = neighbours
+		for _, n := range neighbours {
+			ix.link(n, id, layer)
+		}
+		if len(found) > 0 {
+			ep = found
+		}
+	}
+	if level > ix.maxLevel {
+		ix.entry, ix.maxLevel = id, level
+	}
+}
+
+// remove turns the node with the given ID into a tombstone.
+func (ix *hnswIndex) remove(vectorID string) {
+	id, ok := ix.byID[vectorID]
+	if !ok {
+		return
+	}
+	delete(ix.byID, vectorID)
+	ix.nodes[id].deleted = true
+	ix.deleted++
+}
+
+// ============================================================================
+// Snapshots
+// ============================================================================
+
+type hnswSnapshot struct {
+	Config   HNSWConfig
+	Entry    uint32
+	MaxLevel int
+	Nodes    []hnswNodeSnapshot
+}
+
+type hnswNodeSnapshot struct {
+	ID      string
+	Links   [][]uint32
+	Deleted bool
+
+	// Embedding is kept for tombstones only; live nodes share the stored
+	// vector.
+	Embedding []float32
+}
+
+func (ix *hnswIndex) snapshot() *hnswSnapshot {
+	snap := &hnswSnapshot{
+		Config:   ix.cfg,
+		Entry:    ix.entry,
+		MaxLevel: ix.maxLevel,
+		Nodes:    make([]hnswNodeSnapshot, len(ix.nodes)),
+	}
+	for i, n := range ix.nodes {
+		snap.Nodes[i] = hnswNodeSnapshot{ID: n.vector.ID, Links: n.links, Deleted: n.deleted}
+		if n.deleted {
+			snap.Nodes[i].Embedding = n.vector.Embedding
+		}
+	}
+	return snap
+}
+
+// restoreHNSWIndex rebuilds an index from a snapshot over the restored
+// vectors. It reports false when the graph is inconsistent: links to
+// missing nodes or to layers the target lacks, an entry point that does not
+// span every layer, or tombstones of the wrong dimension.
+func restoreHNSWIndex(snap *hnswSnapshot, vectors map[string]*knowledge.Vector, dimension int) (*hnswIndex, bool) {
+	if len(snap.Nodes) == 0 {
+		if snap.MaxLevel != -1 {
+			return nil, false
+		}
+	} else if int(snap.Entry) >= len(snap.Nodes) || snap.MaxLevel != len(snap.Nodes[snap.Entry].Links)-1 {
+		return nil, false
+	}
+
+	ix := newHNSWIndex(snap.Config)
+	ix.entry, ix.maxLevel = snap.Entry, snap.MaxLevel
+	ix.nodes = make([]hnswNode, len(snap.Nodes))
+	for i, n := range snap.Nodes {
+		if len(n.Links) == 0 || len(n.Links) > snap.MaxLevel+1 {
+			return nil, false
+		}
+		v := &knowledge.Vector{ID: n.ID, Embedding: n.Embedding}
+		if n.Deleted {
+			if dimension == 0 || len(n.Embedding) != dimension {
+				return nil, false
+			}
+			ix.deleted++
+		} else {
+			stored, ok := vectors[n.ID]
+			if !ok {
+				return nil, false
+			}
+			v = stored
+			ix.byID[n.ID] = uint32(i)
+		}
+		for layer, links := range n.Links {
+			for _, l := range links {
+				if int(l) >= len(snap.Nodes) || layer >= len(snap.Nodes[l].Links) {
+					return nil, false
+				}
+			}
+		}
+		ix.nodes[i] = hnswNode{vector: v, invNorm: inverseNorm(v.Embedding), links: n.Links, deleted: n.Deleted}
+	}
+	if len(ix.byID) != len(vectors) {
+		return nil, false
+	}
+	return ix, true
+}
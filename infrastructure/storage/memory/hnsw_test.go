@@ -0,0 +1,370 @@
+package memory
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/felixgeelhaar/agent-go/domain/knowledge"
+)
+
+// clusteredVectors generates n vectors around a few random centres, which
+// resembles real embeddings more than uniform noise does.
+func clusteredVectors(n, dim int, seed uint64) []*knowledge.Vector {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	centres := make([][]float32, 16)
+	for i := range centres {
+		centres[i] = make([]float32, dim)
+		for j := range centres[i] {
+			centres[i][j] = float32(rng.NormFloat64())
+		}
+	}
+	vectors := make([]*knowledge.Vector, n)
+	for i := range vectors {
+		c := i % len(centres)
+		emb := make([]float32, dim)
+		for j := range emb {
+			emb[j] = centres[c][j] + float32(rng.NormFloat64())*0.5
+		}
+		vectors[i] = &knowledge.Vector{
+			ID:        fmt.Sprintf("v-%d", i),
+			Embedding: emb,
+			Metadata:  map[string]string{"cluster": fmt.Sprint(c), "parity": fmt.Sprint(i % 2)},
+		}
+	}
+	return vectors
+}
+
+func recall(t *testing.T, exact, approx *KnowledgeStore, queries []*knowledge.Vector, k int) float64 {
+	t.Helper()
+	ctx := context.Background()
+	hits, total := 0, 0
+	for _, q := range queries {
+		want, err := exact.Search(ctx, q.Embedding, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := approx.Search(ctx, q.Embedding, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[string]bool, len(got))
+		for _, r := range got {
+			found[r.ID] = true
+		}
+		for _, r := range want {
+			if found[r.ID] {
+				hits++
+			}
+		}
+		total += len(want)
+	}
+	return float64(hits) / float64(total)
+}
+
+func TestKnowledgeStore_HNSW_Recall(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	vectors := clusteredVectors(3000, 32, 1)
+	queries := clusteredVectors(50, 32, 2)
+
+	exact := NewKnowledgeStore(32)
+	approx := NewKnowledgeStore(32, WithHNSWIndex(HNSWConfig{M: 12, EfConstruction: 100, EfSearch: 64, Seed: 7}))
+	if err := exact.UpsertBatch(ctx, vectors); err != nil {
+		t.Fatal(err)
+	}
+	if err := approx.UpsertBatch(ctx, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := recall(t, exact, approx, queries, 10); r < 0.9 {
+		t.Errorf("recall@10 = %.2f, want >= 0.9", r)
+	}
+
+	// A tiny candidate list trades recall for speed; a large one restores it.
+	approx.SetEfSearch(10)
+	low := recall(t, exact, approx, queries, 10)
+	approx.SetEfSearch(400)
+	high := recall(t, exact, approx, queries, 10)
+	if high < 0.98 || high < low {
+		t.Errorf("recall with ef=10: %.2f, ef=400: %.2f", low, high)
+	}
+
+	results, _ := approx.Search(ctx, vectors[0].Embedding, 1)
+	if len(results) != 1 || results[0].ID != "v-0" || results[0].Score < 0.999 {
+		t.Errorf("expected v-0 to find itself, got %v", results)
+	}
+}
+
+func TestKnowledgeStore_HNSW_FilteredSearch(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	vectors := clusteredVectors(3000, 16, 3)
+	store := NewKnowledgeStore(16, WithHNSWIndex(HNSWConfig{EfConstruction: 64, Seed: 1}))
+	if err := store.UpsertBatch(ctx, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	// A broad filter goes through the graph; a selective one is scanned.
+	for _, filter := range []knowledge.ListFilter{
+		{Metadata: map[string]string{"parity": "1"}},
+		{Metadata: map[string]string{"cluster": "3", "parity": "1"}},
+		{IDPrefix: "v-29"},
+	} {
+		results, err := store.SearchFiltered(ctx, vectors[3].Embedding, 20, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) == 0 {
+			t.Fatalf("no results for filter %+v", filter)
+		}
+		for _, r := range results {
+			v, _ := store.Get(ctx, r.ID)
+			if !vectorMatchesFilter(v, filter) {
+				t.Fatalf("result %s does not match filter %+v", r.ID, filter)
+			}
+		}
+		if filter.IDPrefix == "" && results[0].ID != "v-3" {
+			t.Errorf("expected v-3 first for filter %+v, got %s", filter, results[0].ID)
+		}
+	}
+
+	// Stores without an index filter by exact scan.
+	exact := NewKnowledgeStore(16)
+	_ = exact.UpsertBatch(ctx, vectors[:100])
+	results, _ := exact.SearchFiltered(ctx, vectors[3].Embedding, 5, knowledge.ListFilter{Metadata: map[string]string{"parity": "0"}})
+	if len(results) != 5 || results[0].ID == "v-3" {
+		t.Errorf("unexpected exact filtered results: %v", results)
+	}
+}
+
+func TestKnowledgeStore_HNSW_UpdatesAndCompaction(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	vectors := clusteredVectors(800, 8, 4)
+	store := NewKnowledgeStore(0, WithHNSWIndex(HNSWConfig{M: 8, EfConstruction: 50, Seed: 3}))
+	if err := store.UpsertBatch(ctx, vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	// Moving v-0 to v-1's position makes it the best match there.
+	moved := *vectors[0]
+	moved.Embedding = vectors[1].Embedding
+	if err := store.Upsert(ctx, &moved); err != nil {
+		t.Fatal(err)
+	}
+	results, _ := store.Search(ctx, vectors[1].Embedding, 2)
+	if len(results) != 2 || (results[0].ID != "v-0" && results[1].ID != "v-0") {
+		t.Errorf("expected the moved vector next to v-1, got %v", results)
+	}
+
+	var ids []string
+	for _, v := range vectors[:600] {
+		ids = append(ids, v.ID)
+	}
+	if err := store.DeleteBatch(ctx, ids); err != nil {
+		t.Fatal(err)
+	}
+	// The graph is rebuilt whenever tombstones outnumber live nodes.
+	if len(store.index.nodes) > 600 || store.index.deleted > store.index.live() {
+		t.Errorf("expected compaction to drop tombstones, have %d of %d nodes deleted", store.index.deleted, len(store.index.nodes))
+	}
+	results, _ = store.Search(ctx, vectors[700].Embedding, 200)
+	if len(results) != 200 || results[0].ID != "v-700" {
+		t.Errorf("expected all 200 remaining vectors with v-700 first, got %d", len(results))
+	}
+	for _, r := range results {
+		if _, err := store.Get(ctx, r.ID); err != nil {
+			t.Fatalf("deleted vector %s returned", r.ID)
+		}
+	}
+}
+
+func TestKnowledgeStore_Snapshot(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "knowledge.snap")
+	vectors := clusteredVectors(500, 8, 5)
+
+	store := NewKnowledgeStore(0, WithHNSWIndex(HNSWConfig{EfConstruction: 50, Seed: 9}))
+	if err := store.UpsertBatch(ctx, vectors); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "v-10"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveSnapshot(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadKnowledgeStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := loaded.Count(ctx); count != 499 {
+		t.Errorf("expected 499 vectors, got %d", count)
+	}
+	if loaded.index == nil || loaded.index.deleted != 1 || loaded.index.cfg.Seed != 9 {
+		t.Fatal("expected the index to be restored with its tombstone and config")
+	}
+	for _, q := range vectors[:20] {
+		want, _ := store.Search(ctx, q.Embedding, 5)
+		got, _ := loaded.Search(ctx, q.Embedding, 5)
+		if fmt.Sprint(want) != fmt.Sprint(got) {
+			t.Fatalf("restored index answers differently:\n%v\n%v", want, got)
+		}
+	}
+	if err := loaded.Upsert(ctx, &knowledge.Vector{ID: "new", Embedding: vectors[0].Embedding}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Stores without an index round-trip too.
+	plain := NewKnowledgeStore(3)
+	_ = plain.Upsert(ctx, &knowledge.Vector{ID: "a", Embedding: []float32{1, 0, 0}, Text: "A"})
+	plainPath := filepath.Join(t.TempDir(), "plain.snap")
+	if err := plain.SaveSnapshot(ctx, plainPath); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := LoadKnowledgeStore(plainPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := restored.Get(ctx, "a"); err != nil || v.Text != "A" || restored.index != nil {
+		t.Errorf("unexpected restored store: %v %v", v, err)
+	}
+
+	if err := os.WriteFile(plainPath, []byte("not a snapshot"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKnowledgeStore(plainPath); !errors.Is(err, ErrInvalidSnapshot) {
+		t.Errorf("expected ErrInvalidSnapshot, got %v", err)
+	}
+}
+
+func TestLoadKnowledgeStore_CorruptedIndex(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "knowledge.snap")
+
+	store := NewKnowledgeStore(0, WithHNSWIndex(HNSWConfig{Seed: 3}))
+	if err := store.UpsertBatch(ctx, clusteredVectors(200, 4, 3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "v-7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SaveSnapshot(ctx, path); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(corrupt func(snap *knowledgeSnapshot)) error {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		var snap knowledgeSnapshot
+		if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+			t.Fatal(err)
+		}
+		corrupt(&snap)
+		out, err := os.CreateTemp(dir, "corrupt-*")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer out.Close()
+		if err := gob.NewEncoder(out).Encode(&snap); err != nil {
+			t.Fatal(err)
+		}
+		_, err = LoadKnowledgeStore(out.Name())
+		return err
+	}
+	// upper and base are a node spanning several layers and one that only
+	// has the base layer.
+	upper, base := -1, -1
+	_ = load(func(snap *knowledgeSnapshot) {
+		for i, n := range snap.Index.Nodes {
+			if len(n.Links) > 1 && upper < 0 {
+				upper = i
+			}
+			if len(n.Links) == 1 && base < 0 {
+				base = i
+			}
+		}
+	})
+	if upper < 0 || base < 0 {
+		t.Fatal("expected nodes on several layers")
+	}
+
+	if err := load(func(*knowledgeSnapshot) {}); err != nil {
+		t.Fatalf("unmodified snapshot failed to load: %v", err)
+	}
+	tests := map[string]func(snap *knowledgeSnapshot){
+		"max level above the entry point": func(snap *knowledgeSnapshot) { snap.Index.MaxLevel++ },
+		"negative max level":              func(snap *knowledgeSnapshot) { snap.Index.MaxLevel = -1 },
+		"entry out of range":              func(snap *knowledgeSnapshot) { snap.Index.Entry = uint32(len(snap.Index.Nodes)) },
+		"node deeper than the graph": func(snap *knowledgeSnapshot) {
+			n := &snap.Index.Nodes[base]
+			n.Links = make([][]uint32, snap.Index.MaxLevel+2)
+		},
+		"link to a missing layer": func(snap *knowledgeSnapshot) {
+			n := &snap.Index.Nodes[upper]
+			n.Links[1] = append(n.Links[1], uint32(base))
+		},
+		"link to a missing node": func(snap *knowledgeSnapshot) {
+			n := &snap.Index.Nodes[base]
+			n.Links[0] = append(n.Links[0], uint32(len(snap.Index.Nodes)))
+		},
+		"tombstone of the wrong dimension": func(snap *knowledgeSnapshot) {
+			for i := range snap.Index.Nodes {
+				if n := &snap.Index.Nodes[i]; n.Deleted {
+					n.Embedding = n.Embedding[:2]
+				}
+			}
+		},
+	}
+	for name, corrupt := range tests {
+		if err := load(corrupt); !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("%s: expected ErrInvalidSnapshot, got %v", name, err)
+		}
+	}
+}
+
+func TestKnowledgeStore_HNSW_BatchValidationAndConcurrency(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := NewKnowledgeStore(0, WithHNSWIndex(HNSWConfig{EfConstruction: 32}))
+
+	err := store.UpsertBatch(ctx, []*knowledge.Vector{
+		{ID: "a", Embedding: []float32{1, 0}},
+		{ID: "b", Embedding: []float32{1, 0, 0}},
+	})
+	if !errors.Is(err, knowledge.ErrDimensionMismatch) {
+		t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if count, _ := store.Count(ctx); count != 0 {
+		t.Fatalf("invalid batch stored %d vectors", count)
+	}
+
+	vectors := clusteredVectors(400, 8, 6)
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Go(func() {
+			_ = store.UpsertBatch(ctx, vectors[i*100:(i+1)*100])
+		})
+		wg.Go(func() {
+			_, _ = store.SearchFiltered(ctx, vectors[i].Embedding, 5, knowledge.ListFilter{Metadata: map[string]string{"parity": "0"}})
+		})
+	}
+	wg.Wait()
+	if count, _ := store.Count(ctx); count != 400 {
+		t.Errorf("expected 400 vectors, got %d", count)
+	}
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/felixgeelhaar/agent-go/domain/knowledge"
+)
+
+// knowledgeSnapshotFormat identifies snapshot files and their version.
+const knowledgeSnapshotFormat = "agent-go-knowledge/v1"
+
+// ErrInvalidSnapshot indicates a knowledge snapshot that cannot be loaded.
+var ErrInvalidSnapshot = errors.New("invalid knowledge snapshot")
+
+type knowledgeSnapshot struct {
+	Format    string
+	Dimension int
+	Vectors   []*knowledge.Vector
+	Index     *hnswSnapshot
+}
+
+// SaveSnapshot writes the store, including its index graph, to path so
+// that LoadKnowledgeStore can restore it without re-indexing. The file is
+// replaced atomically. Writes block until the snapshot is written.
+func (s *KnowledgeStore) SaveSnapshot(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := knowledgeSnapshot{
+		Format:    knowledgeSnapshotFormat,
+		Dimension: s.dimension,
+		Vectors:   make([]*knowledge.Vector, 0, len(s.vectors)),
+	}
+	for _, v := range s.vectors {
+		snap.Vectors = append(snap.Vectors, v)
+	}
+	if s.index != nil {
+		snap.Index = s.index.snapshot()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriterSize(tmp, 1<<20)
+	if err := gob.NewEncoder(w).Encode(&snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadKnowledgeStore restores a store written by SaveSnapshot, with the
+// index configuration it was saved with.
+func LoadKnowledgeStore(path string) (*KnowledgeStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	var snap knowledgeSnapshot
+	if err := gob.NewDecoder(bufio.NewReaderSize(f, 1<<20)).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snap.Format != knowledgeSnapshotFormat {
+		return nil, fmt.Errorf("%w: unknown format %q", ErrInvalidSnapshot, snap.Format)
+	}
+
+	s := NewKnowledgeStore(snap.Dimension)
+	for _, v := range snap.Vectors {
+		if validateKnowledgeVector(v) != nil || (s.dimension > 0 && len(v.Embedding) != s.dimension) {
+			return nil, fmt.Errorf("%w: vector %q is invalid", ErrInvalidSnapshot, v.ID)
+		}
+		s.vectors[v.ID] = v
+	}
+	if snap.Index != nil {
+		index, ok := restoreHNSWIndex(snap.Index, s.vectors, s.dimension)
+		if !ok {
+			return nil, fmt.Errorf("%w: index does not match vectors", ErrInvalidSnapshot)
+		}
+		s.index = index
+	}
+	return s, nil
+}
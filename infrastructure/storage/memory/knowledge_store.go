@@ -12,19 +12,48 @@ import (
 )
 
 // KnowledgeStore is an in-memory vector store with cosine similarity search.
+// By default every search scans all vectors exactly; WithHNSWIndex adds an
+// approximate nearest-neighbour index for large stores.
 type KnowledgeStore struct {
 	vectors   map[string]*knowledge.Vector
-	dimension int // 0 = auto-detect from first vector
+	dimension int        // 0 = auto-detect from first vector
+	index     *hnswIndex // nil = exact search
 	mu        sync.RWMutex
 }
 
+// KnowledgeStoreOption configures the knowledge store.
+type KnowledgeStoreOption func(*KnowledgeStore)
+
+// WithHNSWIndex serves searches from an HNSW graph instead of scanning
+// every vector. Results are approximate; raise HNSWConfig.EfSearch for
+// recall or lower it for latency.
+func WithHNSWIndex(cfg HNSWConfig) KnowledgeStoreOption {
+	return func(s *KnowledgeStore) {
+		s.index = newHNSWIndex(cfg)
+	}
+}
+
 // NewKnowledgeStore creates a new in-memory knowledge store.
 // If dimension is 0, it will be auto-detected from the first vector.
-func NewKnowledgeStore(dimension int) *KnowledgeStore {
-	return &KnowledgeStore{
+func NewKnowledgeStore(dimension int, opts ...KnowledgeStoreOption) *KnowledgeStore {
+	s := &KnowledgeStore{
 		vectors:   make(map[string]*knowledge.Vector),
 		dimension: dimension,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// SetEfSearch changes the candidate list size of the HNSW index at run
+// time. It has no effect on stores without an index.
+func (s *KnowledgeStore) SetEfSearch(ef int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil && ef > 0 {
+		s.index.cfg.EfSearch = ef
+	}
 }
 
 // Upsert stores or updates a vector.
@@ -32,11 +61,8 @@ func (s *KnowledgeStore) Upsert(ctx context.Context, v *knowledge.Vector) error
 	if err := ctx.Err(); err != nil {
 		return err
 	}
-	if v.ID == "" {
-		return knowledge.ErrInvalidID
-	}
-	if len(v.Embedding) == 0 {
-		return knowledge.ErrInvalidEmbedding
+	if err := validateKnowledgeVector(v); err != nil {
+		return err
 	}
 
 	s.mu.Lock()
@@ -49,6 +75,24 @@ func (s *KnowledgeStore) Upsert(ctx context.Context, v *knowledge.Vector) error
 		return knowledge.ErrDimensionMismatch
 	}
 
+	s.store(v)
+	return nil
+}
+
+// validateKnowledgeVector checks the fields every stored vector needs.
+func validateKnowledgeVector(v *knowledge.Vector) error {
+	if v.ID == "" {
+		return knowledge.ErrInvalidID
+	}
+	if len(v.Embedding) == 0 {
+		return knowledge.ErrInvalidEmbedding
+	}
+	return nil
+}
+
+// store saves a deep copy of a validated vector and indexes it. The
+// caller must hold the write lock.
+func (s *KnowledgeStore) store(v *knowledge.Vector) {
 	// Deep copy
 	stored := &knowledge.Vector{
 		ID:        v.ID,
@@ -64,11 +108,30 @@ func (s *KnowledgeStore) Upsert(ctx context.Context, v *knowledge.Vector) error
 	}
 
 	s.vectors[v.ID] = stored
-	return nil
+	if s.index != nil {
+		s.index.insert(stored)
+		s.compact()
+	}
+}
+
+// compact rebuilds the index once deletes and replacements have left it
+// mostly tombstones. The caller must hold the write lock.
+func (s *KnowledgeStore) compact() {
+	if s.index.needsCompaction() {
+		s.index = s.index.rebuild()
+	}
 }
 
 // Search finds similar vectors by embedding using cosine similarity.
 func (s *KnowledgeStore) Search(ctx context.Context, embedding []float32, topK int) ([]knowledge.SearchResult, error) {
+	return s.SearchFiltered(ctx, embedding, topK, knowledge.ListFilter{})
+}
+
+// SearchFiltered finds similar vectors among those matching the filter.
+// With an index, selective filters are answered by an exact scan of the
+// matching vectors; broader ones by a graph search that skips
+// non-matching vectors.
+func (s *KnowledgeStore) SearchFiltered(ctx context.Context, embedding []float32, topK int, filter knowledge.ListFilter) ([]knowledge.SearchResult, error) {
 	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
@@ -82,7 +145,62 @@ func (s *KnowledgeStore) Search(ctx context.Context, embedding []float32, topK i
 	if s.dimension > 0 && len(embedding) != s.dimension {
 		return nil, knowledge.ErrDimensionMismatch
 	}
+	if topK <= 0 {
+		return []knowledge.SearchResult{}, nil
+	}
+
+	unfiltered := filter.IDPrefix == "" && len(filter.Metadata) == 0 && filter.FromTime.IsZero() && filter.ToTime.IsZero()
+	if s.index == nil {
+		if unfiltered {
+			return s.exactSearch(embedding, topK, nil), nil
+		}
+		return s.exactSearch(embedding, topK, func(v *knowledge.Vector) bool {
+			return vectorMatchesFilter(v, filter)
+		}), nil
+	}
+
+	ix := s.index
+	accept := ix.isLive
+	if !unfiltered {
+		// Pre-filter: mark matching nodes, then pick a strategy by how
+		// many matched.
+		allowed := make([]bool, len(ix.nodes))
+		matches := 0
+		for i := range ix.nodes {
+			if !ix.nodes[i].deleted && vectorMatchesFilter(ix.nodes[i].vector, filter) {
+				allowed[i] = true
+				matches++
+			}
+		}
+		if matches <= hnswExactScanMax || matches*20 < ix.live() {
+			return s.exactSearch(embedding, topK, func(v *knowledge.Vector) bool {
+				return allowed[ix.byID[v.ID]]
+			}), nil
+		}
+		accept = func(id uint32) bool { return allowed[id] }
+	}
+
+	found := ix.search(unit(embedding), topK, ix.cfg.EfSearch, accept)
+	output := make([]knowledge.SearchResult, len(found))
+	for i, f := range found {
+		v := ix.nodes[f.id].vector
+		output[i] = knowledge.SearchResult{
+			ID:       v.ID,
+			Text:     v.Text,
+			Score:    f.sim,
+			Metadata: copyKnowledgeMetadata(v.Metadata),
+		}
+	}
+	return output, nil
+}
+
+// hnswExactScanMax is the number of filter matches up to which an exact
+// scan beats a filtered graph search.
+const hnswExactScanMax = 2048
 
+// exactSearch scores every vector that match admits. The caller must
+// hold the read lock.
+func (s *KnowledgeStore) exactSearch(embedding []float32, topK int, match func(*knowledge.Vector) bool) []knowledge.SearchResult {
 	// Calculate similarity for all vectors
 	type scored struct {
 		id    string
@@ -93,6 +211,9 @@ func (s *KnowledgeStore) Search(ctx context.Context, embedding []float32, topK i
 
 	results := make([]scored, 0, len(s.vectors))
 	for _, v := range s.vectors {
+		if match != nil && !match(v) {
+			continue
+		}
 		sim := cosineSimilarity(embedding, v.Embedding)
 		results = append(results, scored{
 			id:    v.ID,
@@ -122,7 +243,7 @@ func (s *KnowledgeStore) Search(ctx context.Context, embedding []float32, topK i
 		}
 	}
 
-	return output, nil
+	return output
 }
 
 // Get retrieves a vector by ID.
@@ -163,6 +284,10 @@ func (s *KnowledgeStore) Delete(ctx context.Context, id string) error {
 	}
 
 	delete(s.vectors, id)
+	if s.index != nil {
+		s.index.remove(id)
+		s.compact()
+	}
 	return nil
 }
 
@@ -229,13 +354,41 @@ func (s *KnowledgeStore) Stats(ctx context.Context) (knowledge.Stats, error) {
 	}, nil
 }
 
-// UpsertBatch stores or updates multiple vectors.
+// UpsertBatch stores or updates multiple vectors under one lock. The
+// whole batch is validated before any vector is stored.
 func (s *KnowledgeStore) UpsertBatch(ctx context.Context, vectors []*knowledge.Vector) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	for _, v := range vectors {
-		if err := s.Upsert(ctx, v); err != nil {
+		if err := validateKnowledgeVector(v); err != nil {
 			return err
 		}
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dimension := s.dimension
+	for _, v := range vectors {
+		if dimension == 0 {
+			dimension = len(v.Embedding)
+		} else if len(v.Embedding) != dimension {
+			return knowledge.ErrDimensionMismatch
+		}
+	}
+	s.dimension = dimension
+
+	for i, v := range vectors {
+		// Indexing is the slow part of a large batch; stop early on
+		// cancellation, keeping what was stored.
+		if i%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		s.store(v)
+	}
 	return nil
 }
 
@@ -322,7 +475,8 @@ func copyKnowledgeMetadata(m map[string]string) map[string]string {
 
 // Ensure KnowledgeStore implements the interfaces.
 var (
-	_ knowledge.Store         = (*KnowledgeStore)(nil)
-	_ knowledge.StatsProvider = (*KnowledgeStore)(nil)
-	_ knowledge.BatchStore    = (*KnowledgeStore)(nil)
+	_ knowledge.Store            = (*KnowledgeStore)(nil)
+	_ knowledge.StatsProvider    = (*KnowledgeStore)(nil)
+	_ knowledge.BatchStore       = (*KnowledgeStore)(nil)
+	_ knowledge.FilteredSearcher = (*KnowledgeStore)(nil)
 )
@@ -15,23 +15,26 @@ var ErrVersionNotFound = errors.New("policy version not found")
 
 // PolicyVersionStore is an in-memory implementation of policy.VersionStore.
 type PolicyVersionStore struct {
-	mu       sync.RWMutex
-	versions map[int]*policy.PolicyVersion
+	mu          sync.RWMutex
+	versions    map[int]*policy.PolicyVersion
+	subscribers map[int]func(*policy.PolicyVersion)
+	nextSub     int
 }
 
 // NewPolicyVersionStore creates a new in-memory policy version store.
 func NewPolicyVersionStore() *PolicyVersionStore {
 	return &PolicyVersionStore{
-		versions: make(map[int]*policy.PolicyVersion),
+		versions:    make(map[int]*policy.PolicyVersion),
+		subscribers: make(map[int]func(*policy.PolicyVersion)),
 	}
 }
 
 // Save persists a new policy version.
 func (s *PolicyVersionStore) Save(ctx context.Context, version *policy.PolicyVersion) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	if _, exists := s.versions[version.Version]; exists {
+		s.mu.Unlock()
 		return errors.New("policy version already exists")
 	}
 
@@ -39,9 +42,36 @@ func (s *PolicyVersionStore) Save(ctx context.Context, version *policy.PolicyVer
 	stored := copyPolicyVersion(version)
 	s.versions[version.Version] = stored
 
+	subscribers := make([]func(*policy.PolicyVersion), 0, len(s.subscribers))
+	for _, fn := range s.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	s.mu.Unlock()
+
+	for _, fn := range subscribers {
+		fn(copyPolicyVersion(stored))
+	}
+
 	return nil
 }
 
+// Subscribe registers fn to be called with every version saved after the
+// call. Subscribers run synchronously in Save, after the version is stored.
+func (s *PolicyVersionStore) Subscribe(fn func(*policy.PolicyVersion)) func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextSub
+	s.nextSub++
+	s.subscribers[id] = fn
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers, id)
+	}
+}
+
 // GetCurrent retrieves the current (latest) policy version.
 func (s *PolicyVersionStore) GetCurrent(ctx context.Context) (*policy.PolicyVersion, error) {
 	s.mu.RLock()
@@ -121,6 +151,8 @@ func copyPolicyVersion(v *policy.PolicyVersion) *policy.PolicyVersion {
 		Transitions: copyTransitions(v.Transitions),
 		Budgets:     copyBudgets(v.Budgets),
 		Approvals:   copyApprovals(v.Approvals),
+		Tools:       copyToolSettings(v.Tools),
+		Rules:       append([]policy.RuleDefinition(nil), v.Rules...),
 	}
 
 	return copied
@@ -159,8 +191,26 @@ func copyApprovals(src policy.ApprovalSnapshot) policy.ApprovalSnapshot {
 	for _, tool := range src.RequiredTools {
 		dst.RequireApproval(tool)
 	}
+	for _, tool := range src.ExemptTools {
+		dst.Exempt(tool)
+	}
 	return dst
 }
 
-// Ensure PolicyVersionStore implements VersionStore
-var _ policy.VersionStore = (*PolicyVersionStore)(nil)
+func copyToolSettings(src policy.ToolSettingsSnapshot) policy.ToolSettingsSnapshot {
+	dst := policy.NewToolSettingsSnapshot()
+	for name, settings := range src.Tools {
+		if settings.CircuitBreaker != nil {
+			cb := *settings.CircuitBreaker
+			settings.CircuitBreaker = &cb
+		}
+		dst.Tools[name] = settings
+	}
+	return dst
+}
+
+// Ensure PolicyVersionStore implements VersionStore and VersionNotifier
+var (
+	_ policy.VersionStore    = (*PolicyVersionStore)(nil)
+	_ policy.VersionNotifier = (*PolicyVersionStore)(nil)
+)
@@ -431,3 +431,35 @@ func TestPolicyVersionStore_DeepCopy(t *testing.T) {
 		}
 	})
 }
+
+func TestPolicyVersionStore_Subscribe(t *testing.T) {
+	t.Parallel()
+
+	store := memory.NewPolicyVersionStore()
+	ctx := context.Background()
+
+	var received []int
+	cancel := store.Subscribe(func(v *policy.PolicyVersion) {
+		received = append(received, v.Version)
+		v.Description = "mutated"
+	})
+
+	if err := store.Save(ctx, &policy.PolicyVersion{Version: 1}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(ctx, &policy.PolicyVersion{Version: 1}); err == nil {
+		t.Fatal("expected duplicate version error")
+	}
+	cancel()
+	if err := store.Save(ctx, &policy.PolicyVersion{Version: 2}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if len(received) != 1 || received[0] != 1 {
+		t.Errorf("received %v, want [1]", received)
+	}
+	stored, _ := store.Get(ctx, 1)
+	if stored.Description == "mutated" {
+		t.Error("subscribers should receive a copy")
+	}
+}
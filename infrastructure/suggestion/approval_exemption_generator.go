@@ -0,0 +1,143 @@
+package suggestion
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/felixgeelhaar/agent-go/domain/pattern"
+	"github.com/felixgeelhaar/agent-go/domain/suggestion"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+)
+
+// ApprovalExemptionGenerator generates approval exemption suggestions for
+// low-risk tools whose approval requests are consistently granted.
+type ApprovalExemptionGenerator struct {
+	registry        tool.Registry
+	minConfidence   float64
+	minApprovalRate float64
+	minApprovals    int
+}
+
+// ApprovalExemptionGeneratorOption configures the approval exemption generator.
+type ApprovalExemptionGeneratorOption func(*ApprovalExemptionGenerator)
+
+// WithExemptionMinConfidence sets the minimum confidence threshold.
+func WithExemptionMinConfidence(c float64) ApprovalExemptionGeneratorOption {
+	return func(g *ApprovalExemptionGenerator) {
+		g.minConfidence = c
+	}
+}
+
+// WithExemptionMinApprovalRate sets the share of approval requests that
+// must have been granted.
+func WithExemptionMinApprovalRate(rate float64) ApprovalExemptionGeneratorOption {
+	return func(g *ApprovalExemptionGenerator) {
+		g.minApprovalRate = rate
+	}
+}
+
+// WithExemptionMinApprovals sets the number of decided approval requests
+// needed before an exemption is suggested.
+func WithExemptionMinApprovals(n int) ApprovalExemptionGeneratorOption {
+	return func(g *ApprovalExemptionGenerator) {
+		g.minApprovals = n
+	}
+}
+
+// NewApprovalExemptionGenerator creates a new approval exemption suggestion
+// generator. The registry supplies the tool annotations that decide whether
+// a tool is low-risk; unregistered tools are never suggested.
+func NewApprovalExemptionGenerator(registry tool.Registry, opts ...ApprovalExemptionGeneratorOption) *ApprovalExemptionGenerator {
+	g := &ApprovalExemptionGenerator{
+		registry:        registry,
+		minConfidence:   0.6,
+		minApprovalRate: 0.95,
+		minApprovals:    10,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Generate creates approval exemption suggestions from patterns.
+func (g *ApprovalExemptionGenerator) Generate(ctx context.Context, patterns []pattern.Pattern) ([]suggestion.Suggestion, error) {
+	var suggestions []suggestion.Suggestion
+
+	for _, p := range patterns {
+		if p.Confidence < g.minConfidence {
+			continue
+		}
+
+		if p.Type == pattern.PatternTypeApprovalDelay {
+			sugs := g.suggestFromApprovalDelay(p)
+			suggestions = append(suggestions, sugs...)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// Types returns the suggestion types this generator can create.
+func (g *ApprovalExemptionGenerator) Types() []suggestion.SuggestionType {
+	return []suggestion.SuggestionType{
+		suggestion.SuggestionTypeExemptApproval,
+	}
+}
+
+func (g *ApprovalExemptionGenerator) suggestFromApprovalDelay(p pattern.Pattern) []suggestion.Suggestion {
+	var sugs []suggestion.Suggestion
+
+	var data pattern.ApprovalDelayData
+	if err := p.GetData(&data); err != nil || data.ToolName == "" {
+		return sugs
+	}
+	if data.TotalApprovals < g.minApprovals || data.ApprovalRate < g.minApprovalRate {
+		return sugs
+	}
+	if !g.lowRisk(data.ToolName) {
+		return sugs
+	}
+
+	s := suggestion.NewSuggestion(
+		suggestion.SuggestionTypeExemptApproval,
+		fmt.Sprintf("Exempt %s from approval", data.ToolName),
+		fmt.Sprintf("Tool '%s' was approved in %.0f%% of %d requests, with runs waiting %v on average. Consider exempting this low-risk tool from approval.",
+			data.ToolName, data.ApprovalRate*100, data.TotalApprovals, data.AverageWaitTime),
+	)
+	s.Rationale = fmt.Sprintf("Approvers consistently grant a low-risk tool; waiting on them delays runs by up to %v", data.MaxWaitTime)
+	s.Confidence = p.Confidence * data.ApprovalRate
+	s.Impact = suggestion.ImpactLevelMedium
+	s.AddPatternID(p.ID)
+	s.Change = suggestion.PolicyChange{
+		Type:   suggestion.PolicyChangeTypeApprovalExemption,
+		Target: data.ToolName,
+		From:   "approval required",
+		To:     "exempt",
+	}
+	changeData := suggestion.ApprovalExemptionChangeData{
+		ToolName: data.ToolName,
+		Exempt:   true,
+	}
+	_ = s.SetChangeData(changeData)
+	sugs = append(sugs, *s)
+
+	return sugs
+}
+
+// lowRisk reports whether the tool is registered as low-risk and not
+// destructive or explicitly marked as requiring approval.
+func (g *ApprovalExemptionGenerator) lowRisk(toolName string) bool {
+	if g.registry == nil {
+		return false
+	}
+	t, ok := g.registry.Get(toolName)
+	if !ok {
+		return false
+	}
+	annotations := t.Annotations()
+	return annotations.RiskLevel <= tool.RiskLow && !annotations.Destructive && !annotations.RequiresApproval
+}
+
+// Ensure ApprovalExemptionGenerator implements Generator
+var _ suggestion.Generator = (*ApprovalExemptionGenerator)(nil)
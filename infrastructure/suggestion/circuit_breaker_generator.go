@@ -0,0 +1,140 @@
+package suggestion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/pattern"
+	"github.com/felixgeelhaar/agent-go/domain/suggestion"
+)
+
+// transientErrorTypes are the failure classes a circuit breaker helps with.
+// Permission, validation and not-found errors depend on the call, not on
+// the health of the tool.
+var transientErrorTypes = map[string]bool{
+	"timeout":    true,
+	"network":    true,
+	"rate_limit": true,
+}
+
+// CircuitBreakerGenerator generates circuit breaker suggestions for tools
+// that fail repeatedly with transient errors.
+type CircuitBreakerGenerator struct {
+	minConfidence    float64
+	minFailures      int
+	failureThreshold int
+	openTimeout      time.Duration
+}
+
+// CircuitBreakerGeneratorOption configures the circuit breaker generator.
+type CircuitBreakerGeneratorOption func(*CircuitBreakerGenerator)
+
+// WithCircuitBreakerMinConfidence sets the minimum confidence threshold.
+func WithCircuitBreakerMinConfidence(c float64) CircuitBreakerGeneratorOption {
+	return func(g *CircuitBreakerGenerator) {
+		g.minConfidence = c
+	}
+}
+
+// WithCircuitBreakerMinFailures sets the number of failures needed before
+// a circuit breaker is suggested.
+func WithCircuitBreakerMinFailures(n int) CircuitBreakerGeneratorOption {
+	return func(g *CircuitBreakerGenerator) {
+		g.minFailures = n
+	}
+}
+
+// WithCircuitBreakerFailureThreshold sets the suggested number of
+// consecutive failures that open the circuit.
+func WithCircuitBreakerFailureThreshold(n int) CircuitBreakerGeneratorOption {
+	return func(g *CircuitBreakerGenerator) {
+		g.failureThreshold = n
+	}
+}
+
+// WithCircuitBreakerOpenTimeout sets the suggested time the circuit stays open.
+func WithCircuitBreakerOpenTimeout(d time.Duration) CircuitBreakerGeneratorOption {
+	return func(g *CircuitBreakerGenerator) {
+		g.openTimeout = d
+	}
+}
+
+// NewCircuitBreakerGenerator creates a new circuit breaker suggestion generator.
+func NewCircuitBreakerGenerator(opts ...CircuitBreakerGeneratorOption) *CircuitBreakerGenerator {
+	g := &CircuitBreakerGenerator{
+		minConfidence:    0.6,
+		minFailures:      3,
+		failureThreshold: 3,
+		openTimeout:      time.Minute,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Generate creates circuit breaker suggestions from patterns.
+func (g *CircuitBreakerGenerator) Generate(ctx context.Context, patterns []pattern.Pattern) ([]suggestion.Suggestion, error) {
+	var suggestions []suggestion.Suggestion
+
+	for _, p := range patterns {
+		if p.Confidence < g.minConfidence {
+			continue
+		}
+
+		if p.Type == pattern.PatternTypeToolFailure {
+			sugs := g.suggestFromToolFailure(p)
+			suggestions = append(suggestions, sugs...)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// Types returns the suggestion types this generator can create.
+func (g *CircuitBreakerGenerator) Types() []suggestion.SuggestionType {
+	return []suggestion.SuggestionType{
+		suggestion.SuggestionTypeTuneCircuitBreaker,
+	}
+}
+
+func (g *CircuitBreakerGenerator) suggestFromToolFailure(p pattern.Pattern) []suggestion.Suggestion {
+	var sugs []suggestion.Suggestion
+
+	var data pattern.ToolFailureData
+	if err := p.GetData(&data); err != nil || data.ToolName == "" {
+		return sugs
+	}
+	if data.ErrorCount < g.minFailures || !transientErrorTypes[data.ErrorType] {
+		return sugs
+	}
+
+	s := suggestion.NewSuggestion(
+		suggestion.SuggestionTypeTuneCircuitBreaker,
+		fmt.Sprintf("Add circuit breaker to %s", data.ToolName),
+		fmt.Sprintf("Tool '%s' failed %d times with '%s' errors. Consider opening its circuit after %d consecutive failures for %v, so runs stop calling it while it recovers.",
+			data.ToolName, data.ErrorCount, data.ErrorType, g.failureThreshold, g.openTimeout),
+	)
+	s.Rationale = fmt.Sprintf("Repeated %s errors (%d occurrences) indicate the tool is unavailable at times", data.ErrorType, data.ErrorCount)
+	s.Confidence = p.Confidence
+	s.Impact = suggestion.ImpactLevelMedium
+	s.AddPatternID(p.ID)
+	s.Change = suggestion.PolicyChange{
+		Type:   suggestion.PolicyChangeTypeCircuitBreaker,
+		Target: data.ToolName,
+		To:     fmt.Sprintf("open after %d failures for %v", g.failureThreshold, g.openTimeout),
+	}
+	changeData := suggestion.CircuitBreakerChangeData{
+		ToolName:         data.ToolName,
+		FailureThreshold: g.failureThreshold,
+		OpenTimeout:      g.openTimeout,
+	}
+	_ = s.SetChangeData(changeData)
+	sugs = append(sugs, *s)
+
+	return sugs
+}
+
+// Ensure CircuitBreakerGenerator implements Generator
+var _ suggestion.Generator = (*CircuitBreakerGenerator)(nil)
@@ -0,0 +1,123 @@
+package suggestion
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/felixgeelhaar/agent-go/domain/pattern"
+	"github.com/felixgeelhaar/agent-go/domain/suggestion"
+)
+
+// LoopGenerator generates transition-removal suggestions from state loops.
+type LoopGenerator struct {
+	minConfidence float64
+	minIterations int
+}
+
+// LoopGeneratorOption configures the loop generator.
+type LoopGeneratorOption func(*LoopGenerator)
+
+// WithLoopMinConfidence sets the minimum confidence threshold.
+func WithLoopMinConfidence(c float64) LoopGeneratorOption {
+	return func(g *LoopGenerator) {
+		g.minConfidence = c
+	}
+}
+
+// WithLoopMinIterations sets the average iterations a loop needs before
+// its transition is suggested for removal.
+func WithLoopMinIterations(n int) LoopGeneratorOption {
+	return func(g *LoopGenerator) {
+		g.minIterations = n
+	}
+}
+
+// NewLoopGenerator creates a new loop suggestion generator.
+func NewLoopGenerator(opts ...LoopGeneratorOption) *LoopGenerator {
+	g := &LoopGenerator{
+		minConfidence: 0.6,
+		minIterations: 3,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Generate creates transition suggestions from patterns.
+func (g *LoopGenerator) Generate(ctx context.Context, patterns []pattern.Pattern) ([]suggestion.Suggestion, error) {
+	var suggestions []suggestion.Suggestion
+
+	for _, p := range patterns {
+		if p.Confidence < g.minConfidence {
+			continue
+		}
+
+		if p.Type == pattern.PatternTypeStateLoop {
+			sugs := g.suggestFromStateLoop(p)
+			suggestions = append(suggestions, sugs...)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// Types returns the suggestion types this generator can create.
+func (g *LoopGenerator) Types() []suggestion.SuggestionType {
+	return []suggestion.SuggestionType{
+		suggestion.SuggestionTypeRemoveTransition,
+	}
+}
+
+func (g *LoopGenerator) suggestFromStateLoop(p pattern.Pattern) []suggestion.Suggestion {
+	var sugs []suggestion.Suggestion
+
+	var data pattern.StateLoopData
+	if err := p.GetData(&data); err != nil {
+		return sugs
+	}
+	if len(data.Loop) < 2 || data.Iterations < g.minIterations {
+		return sugs
+	}
+
+	// The transition back to the loop's first state closes the loop
+	from := data.Loop[len(data.Loop)-1]
+	to := data.Loop[0]
+	if from == to || to.IsTerminal() {
+		return sugs
+	}
+
+	s := suggestion.NewSuggestion(
+		suggestion.SuggestionTypeRemoveTransition,
+		fmt.Sprintf("Remove transition %s -> %s", from, to),
+		fmt.Sprintf("Runs loop through %v for %d iterations on average. Removing the %s -> %s transition breaks the loop.",
+			data.Loop, data.Iterations, from, to),
+	)
+	s.Rationale = fmt.Sprintf("Loop detected in %d runs", p.Frequency)
+	if data.ExitState == "" {
+		s.Rationale += " and runs end without leaving it"
+	} else {
+		s.Rationale += fmt.Sprintf(" before runs move on to %s", data.ExitState)
+	}
+	s.Confidence = p.Confidence
+	s.Impact = suggestion.ImpactLevelHigh // Removing a transition can block runs
+	s.AddPatternID(p.ID)
+	s.Change = suggestion.PolicyChange{
+		Type:   suggestion.PolicyChangeTypeTransition,
+		Target: fmt.Sprintf("%s->%s", from, to),
+		From:   "allowed",
+		To:     "removed",
+	}
+	changeData := suggestion.TransitionChangeData{
+		FromState: from,
+		ToState:   to,
+		Add:       false,
+	}
+	_ = s.SetChangeData(changeData)
+	sugs = append(sugs, *s)
+
+	return sugs
+}
+
+// Ensure LoopGenerator implements Generator
+var _ suggestion.Generator = (*LoopGenerator)(nil)
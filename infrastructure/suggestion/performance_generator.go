@@ -0,0 +1,228 @@
+package suggestion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/felixgeelhaar/agent-go/domain/pattern"
+	"github.com/felixgeelhaar/agent-go/domain/suggestion"
+)
+
+// PerformanceGenerator generates per-tool timeout and rate limit
+// suggestions from timeout and slow tool patterns.
+type PerformanceGenerator struct {
+	minConfidence       float64
+	timeoutIncreaseRate float64 // Suggested timeout increase as a percentage
+	overloadTimeoutRate float64 // Timeout rate at which a tool is rate limited instead
+	rateLimit           int     // Suggested calls per minute for overloaded tools
+	slowToolHeadroom    float64 // Suggested timeout as a multiple of the p90 duration
+}
+
+// PerformanceGeneratorOption configures the performance generator.
+type PerformanceGeneratorOption func(*PerformanceGenerator)
+
+// WithPerformanceMinConfidence sets the minimum confidence threshold.
+func WithPerformanceMinConfidence(c float64) PerformanceGeneratorOption {
+	return func(g *PerformanceGenerator) {
+		g.minConfidence = c
+	}
+}
+
+// WithTimeoutIncreaseRate sets the suggested timeout increase rate.
+func WithTimeoutIncreaseRate(rate float64) PerformanceGeneratorOption {
+	return func(g *PerformanceGenerator) {
+		g.timeoutIncreaseRate = rate
+	}
+}
+
+// WithOverloadTimeoutRate sets the timeout rate from which a rate limit is
+// suggested instead of a longer timeout.
+func WithOverloadTimeoutRate(rate float64) PerformanceGeneratorOption {
+	return func(g *PerformanceGenerator) {
+		g.overloadTimeoutRate = rate
+	}
+}
+
+// WithSuggestedRateLimit sets the suggested calls per minute for
+// overloaded tools.
+func WithSuggestedRateLimit(callsPerMinute int) PerformanceGeneratorOption {
+	return func(g *PerformanceGenerator) {
+		g.rateLimit = callsPerMinute
+	}
+}
+
+// WithSlowToolHeadroom sets the suggested timeout for slow tools as a
+// multiple of their p90 duration.
+func WithSlowToolHeadroom(factor float64) PerformanceGeneratorOption {
+	return func(g *PerformanceGenerator) {
+		g.slowToolHeadroom = factor
+	}
+}
+
+// NewPerformanceGenerator creates a new performance suggestion generator.
+func NewPerformanceGenerator(opts ...PerformanceGeneratorOption) *PerformanceGenerator {
+	g := &PerformanceGenerator{
+		minConfidence:       0.6,
+		timeoutIncreaseRate: 0.5, // 50% longer timeout
+		overloadTimeoutRate: 0.5, // Half of all calls time out
+		rateLimit:           30,
+		slowToolHeadroom:    1.5,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Generate creates timeout and rate limit suggestions from patterns.
+func (g *PerformanceGenerator) Generate(ctx context.Context, patterns []pattern.Pattern) ([]suggestion.Suggestion, error) {
+	var suggestions []suggestion.Suggestion
+
+	for _, p := range patterns {
+		if p.Confidence < g.minConfidence {
+			continue
+		}
+
+		switch p.Type {
+		case pattern.PatternTypeTimeout:
+			sugs := g.suggestFromTimeout(p)
+			suggestions = append(suggestions, sugs...)
+
+		case pattern.PatternTypeSlowTool:
+			sugs := g.suggestFromSlowTool(p)
+			suggestions = append(suggestions, sugs...)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// Types returns the suggestion types this generator can create.
+func (g *PerformanceGenerator) Types() []suggestion.SuggestionType {
+	return []suggestion.SuggestionType{
+		suggestion.SuggestionTypeAdjustTimeout,
+		suggestion.SuggestionTypeAddRateLimit,
+	}
+}
+
+func (g *PerformanceGenerator) suggestFromTimeout(p pattern.Pattern) []suggestion.Suggestion {
+	var sugs []suggestion.Suggestion
+
+	var data pattern.TimeoutData
+	if err := p.GetData(&data); err != nil || data.ToolName == "" {
+		return sugs
+	}
+
+	// When most calls time out, the tool is overloaded and a longer
+	// timeout only holds runs up longer
+	if data.TimeoutRate >= g.overloadTimeoutRate {
+		s := suggestion.NewSuggestion(
+			suggestion.SuggestionTypeAddRateLimit,
+			fmt.Sprintf("Rate limit %s", data.ToolName),
+			fmt.Sprintf("Tool '%s' times out on %.0f%% of calls (%d of %d). Consider limiting it to %d calls per minute to relieve the service behind it.",
+				data.ToolName, data.TimeoutRate*100, data.TimeoutCount, data.TotalCalls, g.rateLimit),
+		)
+		s.Rationale = fmt.Sprintf("A %.0f%% timeout rate suggests the tool is overloaded rather than slow", data.TimeoutRate*100)
+		s.Confidence = p.Confidence * 0.8
+		s.Impact = suggestion.ImpactLevelMedium
+		s.AddPatternID(p.ID)
+		s.Change = suggestion.PolicyChange{
+			Type:   suggestion.PolicyChangeTypeRateLimit,
+			Target: data.ToolName,
+			To:     g.rateLimit,
+		}
+		changeData := suggestion.RateLimitChangeData{
+			ToolName:       data.ToolName,
+			CallsPerMinute: g.rateLimit,
+		}
+		_ = s.SetChangeData(changeData)
+		sugs = append(sugs, *s)
+		return sugs
+	}
+
+	current := data.Limit
+	if current == 0 {
+		current = data.AvgDuration
+	}
+	if current <= 0 {
+		return sugs
+	}
+	timeout := roundTimeout(time.Duration(float64(current) * (1 + g.timeoutIncreaseRate)))
+
+	s := suggestion.NewSuggestion(
+		suggestion.SuggestionTypeAdjustTimeout,
+		fmt.Sprintf("Increase %s timeout", data.ToolName),
+		fmt.Sprintf("Tool '%s' timed out %d times in %d calls. Consider raising its timeout to %v.",
+			data.ToolName, data.TimeoutCount, data.TotalCalls, timeout),
+	)
+	s.Rationale = fmt.Sprintf("Calls average %v, close to the point where they time out", data.AvgDuration)
+	s.Confidence = p.Confidence
+	s.Impact = suggestion.ImpactLevelLow
+	s.AddPatternID(p.ID)
+	s.Change = suggestion.PolicyChange{
+		Type:   suggestion.PolicyChangeTypeToolTimeout,
+		Target: data.ToolName,
+		To:     timeout.String(),
+	}
+	if data.Limit > 0 {
+		s.Change.From = data.Limit.String()
+	}
+	changeData := suggestion.ToolTimeoutChangeData{
+		ToolName: data.ToolName,
+		Timeout:  timeout,
+	}
+	_ = s.SetChangeData(changeData)
+	sugs = append(sugs, *s)
+
+	return sugs
+}
+
+func (g *PerformanceGenerator) suggestFromSlowTool(p pattern.Pattern) []suggestion.Suggestion {
+	var sugs []suggestion.Suggestion
+
+	var data pattern.SlowToolData
+	if err := p.GetData(&data); err != nil || data.ToolName == "" || data.P90Duration <= 0 {
+		return sugs
+	}
+
+	// Bound the slow tail so outliers fail fast instead of stalling runs
+	if data.SlowCount >= 5 {
+		timeout := roundTimeout(time.Duration(float64(data.P90Duration) * g.slowToolHeadroom))
+		s := suggestion.NewSuggestion(
+			suggestion.SuggestionTypeAdjustTimeout,
+			fmt.Sprintf("Set %s timeout", data.ToolName),
+			fmt.Sprintf("Tool '%s' was slow %d times (p90: %v). Consider a %v timeout so outliers fail fast instead of stalling runs.",
+				data.ToolName, data.SlowCount, data.P90Duration, timeout),
+		)
+		s.Rationale = fmt.Sprintf("Average duration (%v) and p90 (%v) show a slow tail", data.AverageDuration, data.P90Duration)
+		s.Confidence = p.Confidence * 0.9
+		s.Impact = suggestion.ImpactLevelMedium
+		s.AddPatternID(p.ID)
+		s.Change = suggestion.PolicyChange{
+			Type:   suggestion.PolicyChangeTypeToolTimeout,
+			Target: data.ToolName,
+			To:     timeout.String(),
+		}
+		changeData := suggestion.ToolTimeoutChangeData{
+			ToolName: data.ToolName,
+			Timeout:  timeout,
+		}
+		_ = s.SetChangeData(changeData)
+		sugs = append(sugs, *s)
+	}
+
+	return sugs
+}
+
+// roundTimeout rounds a suggested timeout up to whole seconds.
+func roundTimeout(d time.Duration) time.Duration {
+	rounded := d.Truncate(time.Second)
+	if rounded < d {
+		rounded += time.Second
+	}
+	return rounded
+}
+
+// Ensure PerformanceGenerator implements Generator
+var _ suggestion.Generator = (*PerformanceGenerator)(nil)
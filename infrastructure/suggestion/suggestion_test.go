@@ -2,13 +2,17 @@ package suggestion
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/pattern"
+	"github.com/felixgeelhaar/agent-go/domain/proposal"
 	"github.com/felixgeelhaar/agent-go/domain/suggestion"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
 )
 
 // ===============================
@@ -625,6 +629,245 @@ func TestBudgetGenerator_Generate_EmptyPatterns(t *testing.T) {
 	}
 }
 
+// ===============================
+// Loop Generator Tests
+// ===============================
+
+func TestLoopGenerator_Generate_FromStateLoop(t *testing.T) {
+	tests := []struct {
+		name       string
+		loop       []agent.State
+		iterations int
+		want       bool
+	}{
+		{"removes closing transition", []agent.State{agent.StateExplore, agent.StateDecide}, 4, true},
+		{"ignores short loops", []agent.State{agent.StateExplore, agent.StateDecide}, 2, false},
+		{"ignores transitions into terminal states", []agent.State{agent.StateDone, agent.StateExplore}, 4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *pattern.NewPattern(pattern.PatternTypeStateLoop, "loop", "test")
+			p.Confidence = 0.8
+			p.Frequency = 6
+			_ = p.SetData(pattern.StateLoopData{Loop: tt.loop, Iterations: tt.iterations})
+
+			suggestions, err := NewLoopGenerator().Generate(context.Background(), []pattern.Pattern{p})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.want {
+				if len(suggestions) != 0 {
+					t.Errorf("expected no suggestions, got %+v", suggestions)
+				}
+				return
+			}
+			if len(suggestions) != 1 {
+				t.Fatalf("expected 1 suggestion, got %d", len(suggestions))
+			}
+			s := suggestions[0]
+			if s.Type != suggestion.SuggestionTypeRemoveTransition || s.Change.Type != suggestion.PolicyChangeTypeTransition {
+				t.Errorf("unexpected suggestion: %+v", s)
+			}
+			var change proposal.TransitionChange
+			if err := json.Unmarshal(s.ChangeData, &change); err != nil {
+				t.Fatalf("failed to decode change data: %v", err)
+			}
+			if change.FromState != agent.StateDecide || change.ToState != agent.StateExplore || change.Allowed {
+				t.Errorf("unexpected change: %+v", change)
+			}
+		})
+	}
+}
+
+// ===============================
+// Performance Generator Tests
+// ===============================
+
+func TestPerformanceGenerator_Generate_FromTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        pattern.TimeoutData
+		wantType    suggestion.SuggestionType
+		wantTimeout time.Duration
+		wantLimit   int
+	}{
+		{
+			name:        "raises timeout",
+			data:        pattern.TimeoutData{ToolName: "search", TimeoutCount: 4, TotalCalls: 20, TimeoutRate: 0.2, AvgDuration: 9 * time.Second, Limit: 10 * time.Second},
+			wantType:    suggestion.SuggestionTypeAdjustTimeout,
+			wantTimeout: 15 * time.Second,
+		},
+		{
+			name:        "raises timeout from average duration",
+			data:        pattern.TimeoutData{ToolName: "search", TimeoutCount: 4, TotalCalls: 20, TimeoutRate: 0.2, AvgDuration: 1500 * time.Millisecond},
+			wantType:    suggestion.SuggestionTypeAdjustTimeout,
+			wantTimeout: 3 * time.Second,
+		},
+		{
+			name:      "rate limits overloaded tool",
+			data:      pattern.TimeoutData{ToolName: "search", TimeoutCount: 12, TotalCalls: 20, TimeoutRate: 0.6, AvgDuration: 9 * time.Second},
+			wantType:  suggestion.SuggestionTypeAddRateLimit,
+			wantLimit: 30,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *pattern.NewPattern(pattern.PatternTypeTimeout, "timeout", "test")
+			p.Confidence = 0.8
+			_ = p.SetData(tt.data)
+
+			suggestions, err := NewPerformanceGenerator().Generate(context.Background(), []pattern.Pattern{p})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(suggestions) != 1 || suggestions[0].Type != tt.wantType {
+				t.Fatalf("expected one %s suggestion, got %+v", tt.wantType, suggestions)
+			}
+			if tt.wantType == suggestion.SuggestionTypeAddRateLimit {
+				var change proposal.RateLimitChange
+				if err := json.Unmarshal(suggestions[0].ChangeData, &change); err != nil || change.ToolName != "search" || change.CallsPerMinute != tt.wantLimit {
+					t.Errorf("unexpected change: %+v (%v)", change, err)
+				}
+				return
+			}
+			var change proposal.ToolTimeoutChange
+			if err := json.Unmarshal(suggestions[0].ChangeData, &change); err != nil || change.ToolName != "search" || change.Timeout != tt.wantTimeout {
+				t.Errorf("unexpected change: %+v (%v)", change, err)
+			}
+		})
+	}
+}
+
+func TestPerformanceGenerator_Generate_FromSlowTool(t *testing.T) {
+	generator := NewPerformanceGenerator(WithSlowToolHeadroom(2))
+
+	p := *pattern.NewPattern(pattern.PatternTypeSlowTool, "slow", "test")
+	p.Confidence = 0.8
+	_ = p.SetData(pattern.SlowToolData{ToolName: "render", AverageDuration: 3 * time.Second, P90Duration: 4500 * time.Millisecond, SlowCount: 8})
+	few := *pattern.NewPattern(pattern.PatternTypeSlowTool, "slow", "test")
+	few.Confidence = 0.8
+	_ = few.SetData(pattern.SlowToolData{ToolName: "other", P90Duration: time.Second, SlowCount: 2})
+
+	suggestions, err := generator.Generate(context.Background(), []pattern.Pattern{p, few})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(suggestions) != 1 || suggestions[0].Type != suggestion.SuggestionTypeAdjustTimeout {
+		t.Fatalf("expected one timeout suggestion, got %+v", suggestions)
+	}
+	var change proposal.ToolTimeoutChange
+	if err := json.Unmarshal(suggestions[0].ChangeData, &change); err != nil || change.ToolName != "render" || change.Timeout != 9*time.Second {
+		t.Errorf("unexpected change: %+v (%v)", change, err)
+	}
+}
+
+// ===============================
+// Approval Exemption Generator Tests
+// ===============================
+
+func TestApprovalExemptionGenerator_Generate_FromApprovalDelay(t *testing.T) {
+	registry := memory.NewToolRegistry()
+	for _, tl := range []tool.Tool{
+		tool.NewBuilder("lookup").WithRiskLevel(tool.RiskLow).MustBuild(),
+		tool.NewBuilder("deploy").WithRiskLevel(tool.RiskHigh).MustBuild(),
+		tool.NewBuilder("purge").Destructive().MustBuild(),
+	} {
+		if err := registry.Register(tl); err != nil {
+			t.Fatalf("failed to register tool: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		data     pattern.ApprovalDelayData
+		registry tool.Registry
+		want     bool
+	}{
+		{"exempts consistently approved low-risk tool", pattern.ApprovalDelayData{ToolName: "lookup", TotalApprovals: 20, ApprovalRate: 1}, registry, true},
+		{"requires enough approvals", pattern.ApprovalDelayData{ToolName: "lookup", TotalApprovals: 5, ApprovalRate: 1}, registry, false},
+		{"requires consistent approvals", pattern.ApprovalDelayData{ToolName: "lookup", TotalApprovals: 20, ApprovalRate: 0.8}, registry, false},
+		{"ignores high-risk tools", pattern.ApprovalDelayData{ToolName: "deploy", TotalApprovals: 20, ApprovalRate: 1}, registry, false},
+		{"ignores destructive tools", pattern.ApprovalDelayData{ToolName: "purge", TotalApprovals: 20, ApprovalRate: 1}, registry, false},
+		{"ignores unregistered tools", pattern.ApprovalDelayData{ToolName: "unknown", TotalApprovals: 20, ApprovalRate: 1}, registry, false},
+		{"requires a registry", pattern.ApprovalDelayData{ToolName: "lookup", TotalApprovals: 20, ApprovalRate: 1}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *pattern.NewPattern(pattern.PatternTypeApprovalDelay, "delay", "test")
+			p.Confidence = 0.8
+			_ = p.SetData(tt.data)
+
+			suggestions, err := NewApprovalExemptionGenerator(tt.registry).Generate(context.Background(), []pattern.Pattern{p})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (len(suggestions) == 1) != tt.want {
+				t.Fatalf("expected suggestion = %v, got %+v", tt.want, suggestions)
+			}
+			if !tt.want {
+				return
+			}
+			s := suggestions[0]
+			if s.Type != suggestion.SuggestionTypeExemptApproval || s.Change.Type != suggestion.PolicyChangeTypeApprovalExemption {
+				t.Errorf("unexpected suggestion: %+v", s)
+			}
+			var change proposal.ApprovalExemptionChange
+			if err := json.Unmarshal(s.ChangeData, &change); err != nil || change.ToolName != "lookup" || !change.Exempt {
+				t.Errorf("unexpected change: %+v (%v)", change, err)
+			}
+		})
+	}
+}
+
+// ===============================
+// Circuit Breaker Generator Tests
+// ===============================
+
+func TestCircuitBreakerGenerator_Generate_FromToolFailure(t *testing.T) {
+	generator := NewCircuitBreakerGenerator(
+		WithCircuitBreakerFailureThreshold(2),
+		WithCircuitBreakerOpenTimeout(30*time.Second),
+	)
+
+	tests := []struct {
+		name string
+		data pattern.ToolFailureData
+		want bool
+	}{
+		{"adds breaker for transient failures", pattern.ToolFailureData{ToolName: "api", ErrorType: "network", ErrorCount: 6}, true},
+		{"requires enough failures", pattern.ToolFailureData{ToolName: "api", ErrorType: "network", ErrorCount: 2}, false},
+		{"ignores call errors", pattern.ToolFailureData{ToolName: "api", ErrorType: "validation", ErrorCount: 6}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *pattern.NewPattern(pattern.PatternTypeToolFailure, "failure", "test")
+			p.Confidence = 0.8
+			_ = p.SetData(tt.data)
+
+			suggestions, err := generator.Generate(context.Background(), []pattern.Pattern{p})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if (len(suggestions) == 1) != tt.want {
+				t.Fatalf("expected suggestion = %v, got %+v", tt.want, suggestions)
+			}
+			if !tt.want {
+				return
+			}
+			if suggestions[0].Type != suggestion.SuggestionTypeTuneCircuitBreaker {
+				t.Errorf("unexpected suggestion type: %s", suggestions[0].Type)
+			}
+			var change proposal.CircuitBreakerChange
+			if err := json.Unmarshal(suggestions[0].ChangeData, &change); err != nil {
+				t.Fatalf("failed to decode change data: %v", err)
+			}
+			if change.ToolName != "api" || change.FailureThreshold != 2 || change.OpenTimeout != 30*time.Second {
+				t.Errorf("unexpected change: %+v", change)
+			}
+		})
+	}
+}
+
 // ===============================
 // Helper Functions
 // ===============================
@@ -93,6 +93,7 @@ import (
 	"github.com/felixgeelhaar/agent-go/application"
 	"github.com/felixgeelhaar/agent-go/domain/agent"
 	"github.com/felixgeelhaar/agent-go/domain/artifact"
+	"github.com/felixgeelhaar/agent-go/domain/event"
 	"github.com/felixgeelhaar/agent-go/domain/knowledge"
 	"github.com/felixgeelhaar/agent-go/domain/middleware"
 	"github.com/felixgeelhaar/agent-go/domain/policy"
@@ -100,6 +101,7 @@ import (
 	inframw "github.com/felixgeelhaar/agent-go/infrastructure/middleware"
 	"github.com/felixgeelhaar/agent-go/infrastructure/planner"
 	"github.com/felixgeelhaar/agent-go/infrastructure/resilience"
+	"github.com/felixgeelhaar/agent-go/infrastructure/security/secrets"
 	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
 )
 
@@ -206,6 +208,66 @@ var (
 	ErrKnowledgeDimensionMismatch = knowledge.ErrDimensionMismatch
 )
 
+// Re-export policy constraint types.
+type (
+	// Constraint is a policy rule evaluated before tool calls and transitions.
+	Constraint = policy.Constraint
+
+	// ConstraintFunc adapts a function to the Constraint interface.
+	ConstraintFunc = policy.ConstraintFunc
+
+	// ConstraintContext provides context for constraint evaluation.
+	ConstraintContext = policy.ConstraintContext
+
+	// MaxToolCalls limits how many times a tool may be called in a run.
+	MaxToolCalls = policy.MaxToolCalls
+
+	// RequireCallsBefore requires tool calls in one state before another is entered.
+	RequireCallsBefore = policy.RequireCallsBefore
+
+	// ForbidToolAfter forbids a tool once another tool has been called.
+	ForbidToolAfter = policy.ForbidToolAfter
+
+	// RuleSet is an ordered list of declarative policy rules.
+	RuleSet = policy.RuleSet
+
+	// RuleDefinition is the serialized form of a policy rule.
+	RuleDefinition = policy.RuleDefinition
+
+	// RuleEffect is the outcome of a matching rule.
+	RuleEffect = policy.RuleEffect
+
+	// ApprovalPolicy determines which tools require approval.
+	ApprovalPolicy = policy.ApprovalPolicy
+
+	// ApprovalSnapshot lists the tools a policy version requires approval for.
+	ApprovalSnapshot = policy.ApprovalSnapshot
+)
+
+// Re-export rule effects.
+const (
+	EffectNone            = policy.EffectNone
+	EffectAllow           = policy.EffectAllow
+	EffectDeny            = policy.EffectDeny
+	EffectRequireApproval = policy.EffectRequireApproval
+)
+
+// CompileRules compiles declarative policy rules of the form
+// "<condition> => <effect>".
+//
+// Example:
+//
+//	rules, err := api.CompileRules([]api.RuleDefinition{
+//	    {Name: "prod-scale", Rule: `tool.tags contains "prod" && input.replicas > 10 => require_approval`},
+//	    {Name: "no-act-early", Rule: `to == "act" && run.evidence < 2 => deny`, Reason: "gather evidence first"},
+//	})
+func CompileRules(defs []RuleDefinition) (*RuleSet, error) {
+	return policy.CompileRules(defs)
+}
+
+// ErrConstraintViolation is returned when a policy constraint denies an action.
+var ErrConstraintViolation = policy.ErrConstraintViolation
+
 // Engine is the main runtime for agent execution.
 type Engine struct {
 	engine *application.Engine
@@ -222,17 +284,24 @@ func New(opts ...Option) (*Engine, error) {
 	}
 
 	appConfig := application.EngineConfig{
-		Registry:     config.registry,
-		Planner:      config.planner,
-		Executor:     config.executor,
-		Artifacts:    config.artifacts,
-		Knowledge:    config.knowledge,
-		Eligibility:  config.eligibility,
-		Transitions:  config.transitions,
-		Approver:     config.approver,
-		BudgetLimits: config.budgets,
-		MaxSteps:     config.maxSteps,
-		Middleware:   config.middleware,
+		Registry:         config.registry,
+		Planner:          config.planner,
+		Executor:         config.executor,
+		Artifacts:        config.artifacts,
+		Knowledge:        config.knowledge,
+		Eligibility:      config.eligibility,
+		Transitions:      config.transitions,
+		Approver:         config.approver,
+		ApprovalPolicy:   config.approvalPolicy,
+		ApprovalSnapshot: config.approvalSnapshot,
+		Events:           config.events,
+		BudgetLimits:     config.budgets,
+		MaxSteps:         config.maxSteps,
+		Middleware:       config.middleware,
+		Constraints:      config.constraints,
+		Rules:            config.rules,
+		PolicyVersions:   config.policyVersions,
+		SecretHandles:    config.secretHandles,
 	}
 
 	engine, err := application.NewEngine(appConfig)
@@ -269,6 +338,54 @@ func (e *Engine) ResumeWithInput(ctx context.Context, run *Run, input string) (*
 	return e.engine.ResumeWithInput(ctx, run, input)
 }
 
+// ResumeWithApproval continues a run paused with ErrAwaitingApproval,
+// replaying the pending tool call under its approval ticket. If the ticket
+// is still pending, the run pauses again.
+func (e *Engine) ResumeWithApproval(ctx context.Context, run *Run) (*Run, error) {
+	return e.engine.ResumeWithApproval(ctx, run)
+}
+
+// ApplyPolicyVersion makes v the policy for runs started from now on; runs
+// in flight keep the policy they started with. Applying an earlier version
+// rolls back immediately.
+func (e *Engine) ApplyPolicyVersion(v *PolicyVersion) error {
+	return e.engine.ApplyPolicyVersion(v)
+}
+
+// RefreshPolicy applies the policy version store's current version, for
+// stores that do not notify the engine of new versions.
+func (e *Engine) RefreshPolicy(ctx context.Context) error {
+	return e.engine.RefreshPolicy(ctx)
+}
+
+// PolicyVersion returns the version number of the active policy, or zero
+// when no policy version has been applied.
+func (e *Engine) PolicyVersion() int {
+	return e.engine.PolicyVersion()
+}
+
+// Close cancels the engine's subscription to its policy version store.
+func (e *Engine) Close() {
+	e.engine.Close()
+}
+
+// StartEvaluation evaluates a candidate policy version on runs started from
+// now on. Shadow mode checks every decision against the candidate without
+// enforcing it; canary mode enforces the candidate on a percentage of runs.
+func (e *Engine) StartEvaluation(candidate *PolicyVersion, cfg EvaluationConfig) error {
+	return e.engine.StartEvaluation(candidate, cfg)
+}
+
+// StopEvaluation ends the evaluation and returns its final report.
+func (e *Engine) StopEvaluation() (EvaluationReport, error) {
+	return e.engine.StopEvaluation()
+}
+
+// EvaluationReport returns the report of the evaluation in progress.
+func (e *Engine) EvaluationReport() (EvaluationReport, bool) {
+	return e.engine.EvaluationReport()
+}
+
 // Knowledge returns the knowledge store, if configured.
 // Returns nil if no knowledge store was provided via WithKnowledgeStore.
 func (e *Engine) Knowledge() knowledge.Store {
@@ -288,6 +405,14 @@ type engineConfig struct {
 	budgets     map[string]int
 	maxSteps    int
 	middleware  *middleware.Registry
+	constraints []policy.Constraint
+	rules       *policy.RuleSet
+
+	approvalPolicy   *policy.ApprovalPolicy
+	approvalSnapshot *policy.ApprovalSnapshot
+	events           event.Store
+	policyVersions   policy.VersionStore
+	secretHandles    *secrets.HandleStore
 }
 
 // Option configures the Engine.
@@ -361,6 +486,96 @@ func WithApprover(a policy.Approver) Option {
 	}
 }
 
+// WithApprovalPolicy sets which tools require approval. Without it, tools
+// that are destructive, high-risk or explicitly marked require approval.
+//
+// Example:
+//
+//	engine, _ := api.New(
+//	    api.WithPlanner(planner),
+//	    api.WithApprover(approver),
+//	    api.WithApprovalPolicy(api.ApprovalPolicy{
+//	        RequireForDestructive: true,
+//	        RequireForTools:       []string{"send_email"},
+//	        ExemptTools:           []string{"delete_tmp_file"},
+//	    }),
+//	)
+func WithApprovalPolicy(p ApprovalPolicy) Option {
+	return func(c *engineConfig) {
+		c.approvalPolicy = &p
+	}
+}
+
+// WithApprovalSnapshot additionally requires approval for the tools in the
+// snapshot, typically the Approvals of the active policy version.
+func WithApprovalSnapshot(s ApprovalSnapshot) Option {
+	return func(c *engineConfig) {
+		c.approvalSnapshot = &s
+	}
+}
+
+// WithEventStore sets the event store that receives each run's history
+// (transitions, tool calls, budget consumption and outcome) and approval
+// requests and decisions, for auditing, pattern detection, replay and
+// policy simulation.
+func WithEventStore(s EventStore) Option {
+	return func(c *engineConfig) {
+		c.events = s
+	}
+}
+
+// WithPolicyVersions subscribes the engine to a policy version store, so
+// proposals applied through the evolution workflow, and their rollbacks,
+// take effect for runs started afterwards. Runs record the version they
+// executed under in Run.PolicyVersion.
+//
+// Sections a version leaves empty (eligibility, transitions, budgets,
+// rules) keep the values configured with the other options.
+func WithPolicyVersions(store PolicyVersionStore) Option {
+	return func(c *engineConfig) {
+		c.policyVersions = store
+	}
+}
+
+// WithSecretHandles lets tools accept secret handles, such as those
+// returned by the secrets pack, anywhere in their input. The default
+// middleware chain replaces each handle with its value right before the
+// tool runs, after the call has been recorded and approved, so the ledger,
+// events and approvers only see the handle, and replaces values the tool
+// echoes back with their handle. With custom middleware (WithMiddleware,
+// WithRateLimit, ...) the expansion is appended innermost to that chain.
+func WithSecretHandles(handles *secrets.HandleStore) Option {
+	return func(c *engineConfig) {
+		c.secretHandles = handles
+	}
+}
+
+// WithConstraints registers policy constraints evaluated before every tool
+// call and transition. Can be called multiple times.
+//
+// Example:
+//
+//	engine, _ := api.New(
+//	    api.WithPlanner(planner),
+//	    api.WithConstraints(
+//	        api.MaxToolCalls{Tool: "web_search", Max: 3},
+//	        api.RequireCallsBefore{State: api.StateAct, After: api.StateExplore, Calls: 2},
+//	    ),
+//	)
+func WithConstraints(constraints ...policy.Constraint) Option {
+	return func(c *engineConfig) {
+		c.constraints = append(c.constraints, constraints...)
+	}
+}
+
+// WithRules sets declarative policy rules. Deny rules deny tool calls and
+// transitions; require_approval rules route tool calls to the approver.
+func WithRules(rules *RuleSet) Option {
+	return func(c *engineConfig) {
+		c.rules = rules
+	}
+}
+
 // WithBudgets sets budget limits.
 func WithBudgets(budgets map[string]int) Option {
 	return func(c *engineConfig) {
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"context"
+
+	"github.com/felixgeelhaar/agent-go/domain/agent"
+	"github.com/felixgeelhaar/agent-go/domain/approval"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	infraApproval "github.com/felixgeelhaar/agent-go/infrastructure/approval"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/filesystem"
+	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
+)
+
+// Re-export approval inbox types for convenience.
+type (
+	// ApprovalTicket is a queued approval request.
+	ApprovalTicket = approval.Ticket
+
+	// ApprovalTicketStatus is the status of an approval ticket.
+	ApprovalTicketStatus = approval.Status
+
+	// ApprovalStore persists approval tickets.
+	ApprovalStore = approval.Store
+
+	// ApprovalListFilter filters approval ticket queries.
+	ApprovalListFilter = approval.ListFilter
+
+	// PendingApprovalError is returned when a tool call was queued for approval.
+	PendingApprovalError = approval.PendingError
+
+	// PendingApproval describes the tool call a paused run awaits approval for.
+	PendingApproval = agent.PendingApproval
+
+	// ApprovalInbox queues approval requests instead of blocking runs.
+	ApprovalInbox = infraApproval.Inbox
+
+	// ApprovalInboxConfig configures an approval inbox.
+	ApprovalInboxConfig = infraApproval.InboxConfig
+
+	// ApprovalDecision is the body of an approval webhook decision.
+	ApprovalDecision = infraApproval.Decision
+)
+
+// Re-export approval ticket statuses.
+const (
+	ApprovalTicketPending  = approval.StatusPending
+	ApprovalTicketApproved = approval.StatusApproved
+	ApprovalTicketDenied   = approval.StatusDenied
+	ApprovalTicketExpired  = approval.StatusExpired
+)
+
+// Approval inbox errors.
+var (
+	// ErrAwaitingApproval is returned when a run pauses for approval.
+	// Check run.PendingApproval for the tool call.
+	ErrAwaitingApproval = agent.ErrAwaitingApproval
+
+	// ErrNoPendingApproval is returned when resuming a run that is not
+	// awaiting approval.
+	ErrNoPendingApproval = agent.ErrNoPendingApproval
+
+	// ErrApprovalTicketNotFound indicates the approval ticket was not found.
+	ErrApprovalTicketNotFound = approval.ErrTicketNotFound
+
+	// ErrApprovalAlreadyDecided indicates the ticket is no longer pending.
+	ErrApprovalAlreadyDecided = approval.ErrAlreadyDecided
+
+	// ErrApprovalTicketExpired indicates the ticket expired before a decision.
+	ErrApprovalTicketExpired = approval.ErrTicketExpired
+)
+
+// NewApprovalInbox creates an approval inbox. Pass it to WithApprover to
+// pause runs for approval instead of blocking them.
+//
+// Example:
+//
+//	store, _ := api.NewFileApprovalStore("/var/lib/agent/approvals")
+//	inbox, _ := api.NewApprovalInbox(api.ApprovalInboxConfig{
+//	    Store:       store,
+//	    Approver:    slackApprover, // optional background approver
+//	    ExpireAfter: 24 * time.Hour,
+//	})
+//	engine, _ := api.New(api.WithPlanner(planner), api.WithApprover(inbox))
+//
+//	run, err := engine.Run(ctx, "Clean up old deployments")
+//	if errors.Is(err, api.ErrAwaitingApproval) {
+//	    // later, once run.PendingApproval.TicketID is decided:
+//	    run, err = engine.ResumeWithApproval(ctx, run)
+//	}
+func NewApprovalInbox(cfg ApprovalInboxConfig) (*ApprovalInbox, error) {
+	return infraApproval.NewInbox(cfg)
+}
+
+// NewApprovalStore creates a new in-memory approval store.
+func NewApprovalStore() approval.Store {
+	return memory.NewApprovalStore()
+}
+
+// NewFileApprovalStore creates an approval store that keeps one JSON file
+// per ticket in dir, so pending approvals survive restarts.
+func NewFileApprovalStore(dir string) (approval.Store, error) {
+	return filesystem.NewApprovalStore(dir)
+}
+
+// Re-export quorum approval types for convenience.
+type (
+	// Approver decides approval requests.
+	Approver = policy.Approver
+
+	// QuorumApprover requires N-of-M approval from named groups.
+	QuorumApprover = policy.QuorumApprover
+
+	// QuorumConfig configures a QuorumApprover.
+	QuorumConfig = policy.QuorumConfig
+
+	// ApprovalGroup is a named set of approvers with a required quorum.
+	ApprovalGroup = policy.ApprovalGroup
+
+	// ApprovalVote records one approver's decision in a quorum approval.
+	ApprovalVote = policy.ApprovalVote
+)
+
+// ErrInvalidQuorum indicates a quorum configuration that can never be satisfied.
+var ErrInvalidQuorum = policy.ErrInvalidQuorum
+
+// NewQuorumApprover creates an approver requiring N-of-M approval from each
+// group. The requester cannot approve their own request and an approver
+// counts toward at most one group; each vote is recorded in the run ledger.
+// Requests without a requester are denied, and approvals that modify the
+// input are not counted.
+//
+// Example:
+//
+//	approver, _ := api.NewQuorumApprover(api.QuorumConfig{
+//	    Groups: []api.ApprovalGroup{
+//	        {Name: "sre", Approvers: []api.Approver{aliceSlack, bobSlack, carolSlack}, Required: 2},
+//	        {Name: "security", Approvers: []api.Approver{securitySlack}},
+//	    },
+//	    Fallback:             slackApprover, // below critical risk
+//	    RequireJustification: true,
+//	})
+//	ctx = api.WithRequester(ctx, "dave")
+func NewQuorumApprover(cfg QuorumConfig) (*QuorumApprover, error) {
+	return policy.NewQuorumApprover(cfg)
+}
+
+// WithRequester returns a context identifying who started the run, so
+// approvers can prevent requesters from approving their own tool calls.
+func WithRequester(ctx context.Context, requester string) context.Context {
+	return policy.WithRequester(ctx, requester)
+}
@@ -23,8 +23,9 @@ func NewToolRegistry() *memory.ToolRegistry {
 
 // NewKnowledgeStore creates a new in-memory knowledge store for vector embeddings.
 // If dimension is 0, it will be auto-detected from the first vector stored.
-func NewKnowledgeStore(dimension int) *memory.KnowledgeStore {
-	return memory.NewKnowledgeStore(dimension)
+// Pass memory.WithHNSWIndex for approximate search over large stores.
+func NewKnowledgeStore(dimension int, opts ...memory.KnowledgeStoreOption) *memory.KnowledgeStore {
+	return memory.NewKnowledgeStore(dimension, opts...)
 }
 
 // NewMockPlanner creates a mock planner with predefined decisions.
@@ -68,6 +68,10 @@ type (
 	ConfigLoaderOption = infraconfig.LoaderOption
 	// JSONSchema represents a JSON Schema document.
 	JSONSchema = infraconfig.JSONSchema
+	// PolicyFixture is a set of test cases for declarative policy rules.
+	PolicyFixture = infraconfig.PolicyFixture
+	// PolicyTestResult is the outcome of one policy test case.
+	PolicyTestResult = infraconfig.PolicyTestResult
 )
 
 // Configuration format constants.
@@ -158,3 +162,14 @@ func ExpandEnv(input string) string {
 func ExpandEnvStrict(input string) (string, error) {
 	return infraconfig.ExpandEnvStrict(input)
 }
+
+// LoadPolicyFixture loads a policy rule fixture from a YAML or JSON file.
+func LoadPolicyFixture(path string) (*PolicyFixture, error) {
+	return infraconfig.LoadPolicyFixture(path)
+}
+
+// RunPolicyFixture evaluates a fixture's cases against rules, or against the
+// fixture's own rules when rules is nil.
+func RunPolicyFixture(rules *RuleSet, fixture *PolicyFixture) ([]PolicyTestResult, error) {
+	return infraconfig.RunPolicyFixture(rules, fixture)
+}
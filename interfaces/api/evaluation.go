@@ -0,0 +1,72 @@
+package api
+
+import (
+	"github.com/felixgeelhaar/agent-go/application"
+	"github.com/felixgeelhaar/agent-go/domain/policy"
+	infraProposal "github.com/felixgeelhaar/agent-go/infrastructure/proposal"
+)
+
+// Re-export policy evaluation types for convenience.
+type (
+	// EvaluationMode selects shadow or canary evaluation.
+	EvaluationMode = policy.EvaluationMode
+
+	// EvaluationConfig configures a shadow or canary evaluation.
+	EvaluationConfig = application.EvaluationConfig
+
+	// EvaluationReport summarizes a shadow or canary evaluation.
+	EvaluationReport = policy.EvaluationReport
+
+	// PolicyDisagreement records a decision the candidate policy would have
+	// treated differently from the active one.
+	PolicyDisagreement = policy.Disagreement
+
+	// PolicyVerdict is how a policy treats a decision.
+	PolicyVerdict = policy.Verdict
+
+	// RolloutCriteria decide whether an evaluation supports rolling out
+	// the candidate.
+	RolloutCriteria = policy.RolloutCriteria
+
+	// EvaluationOutcome is the result of a finished proposal evaluation.
+	EvaluationOutcome = application.EvaluationOutcome
+
+	// ProposalEvaluator evaluates proposals on live runs and rolls them out.
+	ProposalEvaluator = application.ProposalEvaluator
+)
+
+// Re-export evaluation mode constants.
+const (
+	EvaluationShadow = policy.EvaluationShadow
+	EvaluationCanary = policy.EvaluationCanary
+)
+
+// Policy evaluation errors.
+var (
+	// ErrEvaluationInProgress is returned when starting a second evaluation.
+	ErrEvaluationInProgress = application.ErrEvaluationInProgress
+
+	// ErrNoEvaluation is returned when no evaluation is in progress.
+	ErrNoEvaluation = application.ErrNoEvaluation
+
+	// ErrInvalidRolloutCriteria is returned when the criteria require no runs.
+	ErrInvalidRolloutCriteria = policy.ErrInvalidRolloutCriteria
+)
+
+// NewProposalEvaluator creates an evaluator that runs a proposal's
+// candidate policy version in shadow or canary mode on the engine, attaches
+// the report to the proposal as evidence, and applies the proposal once the
+// rollout criteria hold.
+//
+// Example:
+//
+//	evaluator := api.NewProposalEvaluator(engine, workflow)
+//	err := evaluator.Start(ctx, proposalID, api.EvaluationConfig{
+//	    Mode:          api.EvaluationCanary,
+//	    CanaryPercent: 10,
+//	}, api.RolloutCriteria{MinRuns: 50, MaxFailureRateIncrease: 0.02})
+//	// ... later, as runs complete
+//	outcome, err := evaluator.Check(ctx)
+func NewProposalEvaluator(engine *Engine, workflow *infraProposal.WorkflowService) *ProposalEvaluator {
+	return application.NewProposalEvaluator(engine.engine, workflow)
+}
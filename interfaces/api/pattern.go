@@ -58,6 +58,11 @@ func NewPatternStore() pattern.Store {
 	return memory.NewPatternStore()
 }
 
+// NewEventStore creates a new in-memory event store.
+func NewEventStore() *memory.EventStore {
+	return memory.NewEventStore()
+}
+
 // NewSequenceDetector creates a detector for tool sequence patterns.
 func NewSequenceDetector(eventStore EventStore, runStore RunStore) pattern.Detector {
 	return infraPattern.NewSequenceDetector(eventStore, runStore)
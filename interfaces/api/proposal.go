@@ -2,6 +2,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/felixgeelhaar/agent-go/domain/policy"
 	"github.com/felixgeelhaar/agent-go/domain/proposal"
 	infraProposal "github.com/felixgeelhaar/agent-go/infrastructure/proposal"
@@ -39,6 +41,18 @@ type (
 
 	// PolicyVersionStore stores policy versions.
 	PolicyVersionStore = policy.VersionStore
+
+	// PolicyVersionNotifier announces newly saved policy versions.
+	PolicyVersionNotifier = policy.VersionNotifier
+
+	// ToolSettings holds a tool's timeout, rate limit and circuit breaker.
+	ToolSettings = policy.ToolSettings
+
+	// ToolSettingsSnapshot holds a policy version's per-tool settings.
+	ToolSettingsSnapshot = policy.ToolSettingsSnapshot
+
+	// CircuitBreakerSettings configures a per-tool circuit breaker.
+	CircuitBreakerSettings = policy.CircuitBreakerSettings
 )
 
 // Re-export proposal status constants.
@@ -57,6 +71,11 @@ const (
 	ChangeTypeTransition  = proposal.ChangeTypeTransition
 	ChangeTypeBudget      = proposal.ChangeTypeBudget
 	ChangeTypeApproval    = proposal.ChangeTypeApproval
+
+	ChangeTypeToolTimeout       = proposal.ChangeTypeToolTimeout
+	ChangeTypeRateLimit         = proposal.ChangeTypeRateLimit
+	ChangeTypeApprovalExemption = proposal.ChangeTypeApprovalExemption
+	ChangeTypeCircuitBreaker    = proposal.ChangeTypeCircuitBreaker
 )
 
 // NewProposalStore creates a new in-memory proposal store.
@@ -110,3 +129,23 @@ func CreateBudgetChange(budgetName string, oldValue, newValue int, description s
 func CreateApprovalChange(toolName string, required bool, description string) (*proposal.PolicyChange, error) {
 	return infraProposal.CreateApprovalChange(toolName, required, description)
 }
+
+// CreateToolTimeoutChange creates a per-tool timeout policy change.
+func CreateToolTimeoutChange(toolName string, oldTimeout, newTimeout time.Duration, description string) (*proposal.PolicyChange, error) {
+	return infraProposal.CreateToolTimeoutChange(toolName, oldTimeout, newTimeout, description)
+}
+
+// CreateRateLimitChange creates a per-tool rate limit policy change.
+func CreateRateLimitChange(toolName string, oldLimit, newLimit int, description string) (*proposal.PolicyChange, error) {
+	return infraProposal.CreateRateLimitChange(toolName, oldLimit, newLimit, description)
+}
+
+// CreateApprovalExemptionChange creates an approval exemption policy change.
+func CreateApprovalExemptionChange(toolName string, exempt bool, description string) (*proposal.PolicyChange, error) {
+	return infraProposal.CreateApprovalExemptionChange(toolName, exempt, description)
+}
+
+// CreateCircuitBreakerChange creates a per-tool circuit breaker policy change.
+func CreateCircuitBreakerChange(toolName string, oldSettings, newSettings *CircuitBreakerSettings, description string) (*proposal.PolicyChange, error) {
+	return infraProposal.CreateCircuitBreakerChange(toolName, oldSettings, newSettings, description)
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"github.com/felixgeelhaar/agent-go/application"
+	"github.com/felixgeelhaar/agent-go/domain/event"
+)
+
+// Re-export replay and policy simulation types for convenience.
+type (
+	// Replay rebuilds and analyzes runs from their event history.
+	Replay = application.Replay
+
+	// SimulationConfig configures a counterfactual policy simulation.
+	SimulationConfig = application.SimulationConfig
+
+	// SimulationReport summarizes a counterfactual policy simulation.
+	SimulationReport = application.SimulationReport
+
+	// RunSimulation is the outcome of re-evaluating one recorded run.
+	RunSimulation = application.RunSimulation
+
+	// SimulationFinding is a recorded action the simulated policy would
+	// have treated differently.
+	SimulationFinding = application.SimulationFinding
+
+	// FindingKind classifies simulation findings.
+	FindingKind = application.FindingKind
+
+	// StoredEvent is a run event recorded in an EventStore.
+	StoredEvent = event.Event
+)
+
+// Re-export simulation finding kinds.
+const (
+	FindingToolIneligible    = application.FindingToolIneligible
+	FindingTransitionBlocked = application.FindingTransitionBlocked
+	FindingBudgetExhausted   = application.FindingBudgetExhausted
+	FindingApprovalRequired  = application.FindingApprovalRequired
+)
+
+// NewReplay creates a replay over an event store. Engines configured with
+// WithEventStore record the history it replays.
+//
+// Example:
+//
+//	replay := api.NewReplay(events)
+//	report, err := replay.Simulate(ctx, api.SimulationConfig{Version: candidate})
+//	for _, run := range report.Runs {
+//	    for _, f := range run.Findings {
+//	        fmt.Printf("%s: %s %s\n", run.RunID, f.Kind, f.Reason)
+//	    }
+//	}
+func NewReplay(eventStore event.Store) *Replay {
+	return application.NewReplay(eventStore)
+}
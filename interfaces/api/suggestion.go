@@ -3,6 +3,7 @@ package api
 
 import (
 	"github.com/felixgeelhaar/agent-go/domain/suggestion"
+	"github.com/felixgeelhaar/agent-go/domain/tool"
 	infraSuggestion "github.com/felixgeelhaar/agent-go/infrastructure/suggestion"
 	"github.com/felixgeelhaar/agent-go/infrastructure/storage/memory"
 )
@@ -39,13 +40,17 @@ type (
 
 // Re-export suggestion type constants.
 const (
-	SuggestionTypeAddEligibility    = suggestion.SuggestionTypeAddEligibility
-	SuggestionTypeRemoveEligibility = suggestion.SuggestionTypeRemoveEligibility
-	SuggestionTypeAddTransition     = suggestion.SuggestionTypeAddTransition
-	SuggestionTypeRemoveTransition  = suggestion.SuggestionTypeRemoveTransition
-	SuggestionTypeIncreaseBudget    = suggestion.SuggestionTypeIncreaseBudget
-	SuggestionTypeDecreaseBudget    = suggestion.SuggestionTypeDecreaseBudget
-	SuggestionTypeRequireApproval   = suggestion.SuggestionTypeRequireApproval
+	SuggestionTypeAddEligibility     = suggestion.SuggestionTypeAddEligibility
+	SuggestionTypeRemoveEligibility  = suggestion.SuggestionTypeRemoveEligibility
+	SuggestionTypeAddTransition      = suggestion.SuggestionTypeAddTransition
+	SuggestionTypeRemoveTransition   = suggestion.SuggestionTypeRemoveTransition
+	SuggestionTypeIncreaseBudget     = suggestion.SuggestionTypeIncreaseBudget
+	SuggestionTypeDecreaseBudget     = suggestion.SuggestionTypeDecreaseBudget
+	SuggestionTypeRequireApproval    = suggestion.SuggestionTypeRequireApproval
+	SuggestionTypeExemptApproval     = suggestion.SuggestionTypeExemptApproval
+	SuggestionTypeAdjustTimeout      = suggestion.SuggestionTypeAdjustTimeout
+	SuggestionTypeAddRateLimit       = suggestion.SuggestionTypeAddRateLimit
+	SuggestionTypeTuneCircuitBreaker = suggestion.SuggestionTypeTuneCircuitBreaker
 )
 
 // Re-export suggestion status constants.
@@ -78,6 +83,29 @@ func NewBudgetGenerator() suggestion.Generator {
 	return infraSuggestion.NewBudgetGenerator()
 }
 
+// NewLoopGenerator creates a generator for transition removals that break
+// state loops.
+func NewLoopGenerator() suggestion.Generator {
+	return infraSuggestion.NewLoopGenerator()
+}
+
+// NewPerformanceGenerator creates a generator for per-tool timeouts and
+// rate limits.
+func NewPerformanceGenerator() suggestion.Generator {
+	return infraSuggestion.NewPerformanceGenerator()
+}
+
+// NewApprovalExemptionGenerator creates a generator for approval exemptions
+// of consistently approved low-risk tools in the registry.
+func NewApprovalExemptionGenerator(registry tool.Registry) suggestion.Generator {
+	return infraSuggestion.NewApprovalExemptionGenerator(registry)
+}
+
+// NewCircuitBreakerGenerator creates a generator for per-tool circuit breakers.
+func NewCircuitBreakerGenerator() suggestion.Generator {
+	return infraSuggestion.NewCircuitBreakerGenerator()
+}
+
 // NewCompositeSuggestionGenerator creates a generator that combines multiple generators.
 func NewCompositeSuggestionGenerator(generators ...suggestion.Generator) suggestion.Generator {
 	return infraSuggestion.NewCompositeGenerator(generators...)
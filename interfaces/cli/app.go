@@ -53,6 +53,8 @@ Key principle: Trust is the product. Intelligence is constrained by design, not
 		app.newListPacksCmd(),
 		app.newInspectCmd(),
 		app.newExportSchemaCmd(),
+		app.newPolicyCmd(),
+		app.newApprovalsCmd(),
 	)
 
 	return app
@@ -3,10 +3,13 @@ package cli
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	api "github.com/felixgeelhaar/agent-go/interfaces/api"
 )
 
 func TestApp_Version(t *testing.T) {
@@ -353,3 +356,166 @@ version: "1.0"
 		t.Errorf("run JSON output missing 'state', got: %s", output)
 	}
 }
+
+func TestApp_PolicyTest(t *testing.T) {
+	tmpDir := t.TempDir()
+	fixture := `
+rules:
+  - name: no-prod-deletes
+    rule: 'tool.destructive && vars.env == "prod" => deny'
+cases:
+  - name: prod delete
+    state: act
+    tool: {name: delete_file, destructive: true}
+    vars: {env: prod}
+    expect: deny
+  - name: dev delete
+    state: act
+    tool: {name: delete_file, destructive: true}
+    vars: {env: dev}
+    expect: none
+`
+	fixturePath := filepath.Join(tmpDir, "policy_test.yaml")
+	if err := os.WriteFile(fixturePath, []byte(fixture), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	app := New().WithOutput(&stdout, &stderr)
+	if err := app.ExecuteWithArgs(context.Background(), []string{"policy", "test", "-v", fixturePath}); err != nil {
+		t.Fatalf("policy test failed: %v\n%s", err, stdout.String())
+	}
+	if !strings.Contains(stdout.String(), "PASS") || !strings.Contains(stdout.String(), "2 passed, 0 failed") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+
+	// Against a configuration without the rule, the prod case fails.
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("name: test-agent\nversion: \"1.0\"\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	stdout.Reset()
+	app = New().WithOutput(&stdout, &stderr)
+	err := app.ExecuteWithArgs(context.Background(), []string{"policy", "test", "-c", configPath, fixturePath})
+	if err == nil {
+		t.Fatal("expected policy test to fail")
+	}
+	if !strings.Contains(stdout.String(), "FAIL") || !strings.Contains(stdout.String(), "expected deny, got none") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestApp_Approvals(t *testing.T) {
+	dir := t.TempDir()
+	store, err := api.NewFileApprovalStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	inbox, err := api.NewApprovalInbox(api.ApprovalInboxConfig{Store: store})
+	if err != nil {
+		t.Fatalf("failed to create inbox: %v", err)
+	}
+	_, err = inbox.Approve(context.Background(), api.ApprovalRequest{RunID: "run-1", ToolName: "deploy", Reason: "ship it"})
+	var pending *api.PendingApprovalError
+	if !errors.As(err, &pending) {
+		t.Fatalf("expected a pending approval, got %v", err)
+	}
+	id := pending.Ticket.ID
+
+	var stdout, stderr bytes.Buffer
+	app := New().WithOutput(&stdout, &stderr)
+	if err := app.ExecuteWithArgs(context.Background(), []string{"approvals", "list", "--dir", dir}); err != nil {
+		t.Fatalf("approvals list failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), id) || !strings.Contains(stdout.String(), "reason: ship it") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	app = New().WithOutput(&stdout, &stderr)
+	args := []string{"approvals", "approve", id, "--dir", dir, "--approver", "alice", "--input", `{"env":"staging"}`}
+	if err := app.ExecuteWithArgs(context.Background(), args); err != nil {
+		t.Fatalf("approvals approve failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "approved by alice") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+	ticket, err := store.Get(context.Background(), id)
+	if err != nil || string(ticket.Response.ModifiedInput) != `{"env":"staging"}` {
+		t.Errorf("expected the approved input to be stored, got %+v (%v)", ticket, err)
+	}
+
+	app = New().WithOutput(&stdout, &stderr)
+	if err := app.ExecuteWithArgs(context.Background(), args); !errors.Is(err, api.ErrApprovalAlreadyDecided) {
+		t.Errorf("expected ErrApprovalAlreadyDecided, got %v", err)
+	}
+
+	stdout.Reset()
+	app = New().WithOutput(&stdout, &stderr)
+	if err := app.ExecuteWithArgs(context.Background(), []string{"approvals", "list", "--dir", dir}); err != nil {
+		t.Fatalf("approvals list failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "No approvals") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+}
+
+func TestApp_PolicySimulate(t *testing.T) {
+	dir := t.TempDir()
+	versions := filepath.Join(dir, "versions.json")
+	if err := os.WriteFile(versions, []byte(`[
+  {"version": 1, "eligibility": {"state_tools": {"explore": ["read_file", "delete_file"]}}},
+  {"version": 2, "eligibility": {"state_tools": {"explore": ["read_file"]}}, "approvals": {"required_tools": ["read_file"]}}
+]`), 0o600); err != nil {
+		t.Fatalf("failed to write versions: %v", err)
+	}
+	events := filepath.Join(dir, "events.jsonl")
+	if err := os.WriteFile(events, []byte(`
+{"run_id": "run-1", "type": "run.started", "sequence": 1, "payload": {"goal": "clean up", "policy_version": 1}}
+{"run_id": "run-1", "type": "state.transitioned", "sequence": 2, "payload": {"from_state": "intake", "to_state": "explore"}}
+{"run_id": "run-1", "type": "tool.called", "sequence": 3, "payload": {"tool_name": "delete_file", "input": {}, "state": "explore"}}
+{"run_id": "run-1", "type": "tool.succeeded", "sequence": 4, "payload": {"tool_name": "delete_file", "output": {}}}
+{"run_id": "run-1", "type": "run.completed", "sequence": 5, "payload": {}}
+{"run_id": "run-2", "type": "run.started", "sequence": 1, "payload": {"goal": "read", "policy_version": 1}}
+{"run_id": "run-2", "type": "state.transitioned", "sequence": 2, "payload": {"from_state": "intake", "to_state": "explore"}}
+{"run_id": "run-2", "type": "tool.called", "sequence": 3, "payload": {"tool_name": "read_file", "input": {}, "state": "explore"}}
+{"run_id": "run-2", "type": "tool.succeeded", "sequence": 4, "payload": {"tool_name": "read_file", "output": {}}}
+{"run_id": "run-2", "type": "run.completed", "sequence": 5, "payload": {}}
+`), 0o600); err != nil {
+		t.Fatalf("failed to write events: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	app := New().WithOutput(&stdout, &stderr)
+	args := []string{"policy", "simulate", "--version", "2", "--versions", versions, "--events", events}
+	if err := app.ExecuteWithArgs(context.Background(), args); err != nil {
+		t.Fatalf("policy simulate failed: %v", err)
+	}
+	output := stdout.String()
+	for _, want := range []string{
+		"run-1 (version 1, completed): would have been blocked",
+		"tool_ineligible",
+		"run-2 (version 1, completed): would have required approval",
+		"2 of 2 run(s) affected: 1 tool call(s) ineligible, 0 transition(s) blocked, 0 budget(s) exhausted, 1 approval(s) required",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+
+	stdout.Reset()
+	app = New().WithOutput(&stdout, &stderr)
+	args = []string{"policy", "simulate", "--version", "1", "--versions", versions, "--events", events, "--runs", "run-2", "--json"}
+	if err := app.ExecuteWithArgs(context.Background(), args); err != nil {
+		t.Fatalf("policy simulate failed: %v", err)
+	}
+	if !strings.Contains(stdout.String(), `"run_id": "run-2"`) || strings.Contains(stdout.String(), "run-1") {
+		t.Errorf("unexpected JSON report: %s", stdout.String())
+	}
+
+	app = New().WithOutput(&stdout, &stderr)
+	args = []string{"policy", "simulate", "--version", "7", "--versions", versions, "--events", events}
+	if err := app.ExecuteWithArgs(context.Background(), args); err == nil || !strings.Contains(err.Error(), "version 7 not found") {
+		t.Errorf("expected a missing version error, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	api "github.com/felixgeelhaar/agent-go/interfaces/api"
+)
+
+// approvalsOptions holds options shared by the approvals commands.
+type approvalsOptions struct {
+	dir      string
+	all      bool
+	approver string
+	reason   string
+	input    string
+}
+
+// newApprovalsCmd creates the approvals command group.
+func (a *App) newApprovalsCmd() *cobra.Command {
+	opts := &approvalsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "approvals",
+		Short: "List and decide queued tool call approvals",
+		Long: `Work with the approval inbox of agents that queue approvals in a
+file-based approval store. Deciding a ticket does not resume the run; the
+process owning the run resumes it with ResumeWithApproval.
+
+Examples:
+  # List pending approvals
+  agent approvals list --dir /var/lib/agent/approvals
+
+  # Approve or deny a ticket
+  agent approvals approve 3f2c... --dir /var/lib/agent/approvals --reason "change window"
+  agent approvals deny 3f2c... --dir /var/lib/agent/approvals --reason "not during freeze"
+
+  # Approve with narrower tool input
+  agent approvals approve 3f2c... --dir /var/lib/agent/approvals --input '{"replicas":2}'`,
+	}
+	cmd.PersistentFlags().StringVar(&opts.dir, "dir", "", "Approval store directory (required)")
+	_ = cmd.MarkPersistentFlagRequired("dir")
+
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List approval tickets",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return a.runApprovalsList(cmd.Context(), opts)
+		},
+	}
+	list.Flags().BoolVar(&opts.all, "all", false, "Include decided and expired tickets")
+
+	cmd.AddCommand(list, a.newApprovalDecisionCmd(opts, true), a.newApprovalDecisionCmd(opts, false))
+	return cmd
+}
+
+// newApprovalDecisionCmd creates the approve or deny command.
+func (a *App) newApprovalDecisionCmd(opts *approvalsOptions, approved bool) *cobra.Command {
+	use, short := "deny <ticket-id>", "Deny a pending approval"
+	if approved {
+		use, short = "approve <ticket-id>", "Approve a pending approval"
+	}
+	cmd := &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.runApprovalDecision(cmd.Context(), opts, args[0], approved)
+		},
+	}
+	cmd.Flags().StringVar(&opts.approver, "approver", "", "Name recorded as approver (default: $USER)")
+	cmd.Flags().StringVar(&opts.reason, "reason", "", "Reason recorded with the decision")
+	if approved {
+		cmd.Flags().StringVar(&opts.input, "input", "", "JSON tool input to execute instead of the requested input")
+	}
+	return cmd
+}
+
+func (a *App) openApprovalInbox(opts *approvalsOptions) (*api.ApprovalInbox, error) {
+	store, err := api.NewFileApprovalStore(opts.dir)
+	if err != nil {
+		return nil, err
+	}
+	return api.NewApprovalInbox(api.ApprovalInboxConfig{Store: store})
+}
+
+// runApprovalsList prints the tickets in the inbox.
+func (a *App) runApprovalsList(ctx context.Context, opts *approvalsOptions) error {
+	inbox, err := a.openApprovalInbox(opts)
+	if err != nil {
+		return err
+	}
+	filter := api.ApprovalListFilter{}
+	if !opts.all {
+		filter.Status = []api.ApprovalTicketStatus{api.ApprovalTicketPending}
+	}
+	tickets, err := inbox.List(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if len(tickets) == 0 {
+		_, _ = fmt.Fprintln(a.stdout, "No approvals")
+		return nil
+	}
+	for _, t := range tickets {
+		_, _ = fmt.Fprintf(a.stdout, "%s  %-8s  %s  run=%s  risk=%s  requested=%s",
+			t.ID, t.Status, t.Request.ToolName, t.Request.RunID, t.Request.RiskLevel,
+			t.CreatedAt.Format(time.RFC3339))
+		if !t.ExpiresAt.IsZero() {
+			_, _ = fmt.Fprintf(a.stdout, "  expires=%s", t.ExpiresAt.Format(time.RFC3339))
+		}
+		if t.EscalatedAt != nil {
+			_, _ = fmt.Fprintf(a.stdout, "  escalated")
+		}
+		_, _ = fmt.Fprintln(a.stdout)
+		if t.Request.Reason != "" {
+			_, _ = fmt.Fprintf(a.stdout, "    reason: %s\n", t.Request.Reason)
+		}
+		if len(t.Request.Input) > 0 {
+			_, _ = fmt.Fprintf(a.stdout, "    input: %s\n", t.Request.Input)
+		}
+	}
+	return nil
+}
+
+// runApprovalDecision records a decision on a ticket.
+func (a *App) runApprovalDecision(ctx context.Context, opts *approvalsOptions, id string, approved bool) error {
+	inbox, err := a.openApprovalInbox(opts)
+	if err != nil {
+		return err
+	}
+	approver := opts.approver
+	if approver == "" {
+		approver = os.Getenv("USER")
+	}
+	if approver == "" {
+		return fmt.Errorf("--approver is required")
+	}
+	resp := api.ApprovalResponse{
+		Approved:  approved,
+		Approver:  approver,
+		Reason:    opts.reason,
+		Timestamp: time.Now(),
+	}
+	if opts.input != "" {
+		if !json.Valid([]byte(opts.input)) {
+			return fmt.Errorf("--input is not valid JSON")
+		}
+		resp.ModifiedInput = json.RawMessage(opts.input)
+	}
+	ticket, err := inbox.Decide(ctx, id, resp)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(a.stdout, "Ticket %s %s by %s\n", ticket.ID, ticket.Status, approver)
+	return nil
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -204,6 +205,12 @@ func (a *App) printPolicySection(config *api.AgentConfig) {
 	_, _ = fmt.Fprintf(a.stdout, "  Approval:\n")
 	_, _ = fmt.Fprintf(a.stdout, "    Mode: %s\n", config.Policy.Approval.Mode)
 	_, _ = fmt.Fprintf(a.stdout, "    Require for Destructive: %v\n", config.Policy.Approval.RequireForDestructive)
+	if len(config.Policy.Approval.RequireForTools) > 0 {
+		_, _ = fmt.Fprintf(a.stdout, "    Require for Tools: %s\n", strings.Join(config.Policy.Approval.RequireForTools, ", "))
+	}
+	if len(config.Policy.Approval.ExemptTools) > 0 {
+		_, _ = fmt.Fprintf(a.stdout, "    Exempt Tools: %s\n", strings.Join(config.Policy.Approval.ExemptTools, ", "))
+	}
 
 	if config.Policy.RateLimit.Enabled {
 		_, _ = fmt.Fprintf(a.stdout, "  Rate Limiting:\n")
@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	api "github.com/felixgeelhaar/agent-go/interfaces/api"
+)
+
+// policyTestOptions holds options for the policy test command.
+type policyTestOptions struct {
+	configPath string
+	verbose    bool
+}
+
+// policySimulateOptions holds options for the policy simulate command.
+type policySimulateOptions struct {
+	version      int
+	versionsPath string
+	eventsPath   string
+	runs         []string
+	outputJSON   bool
+}
+
+// newPolicyCmd creates the policy command group.
+func (a *App) newPolicyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Work with policy rules and versions",
+	}
+	cmd.AddCommand(a.newPolicyTestCmd(), a.newPolicySimulateCmd())
+	return cmd
+}
+
+// newPolicyTestCmd creates the policy test command.
+func (a *App) newPolicyTestCmd() *cobra.Command {
+	opts := &policyTestOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "test <fixture>...",
+		Short: "Run policy rule fixtures",
+		Long: `Evaluate policy rules against fixture files and compare each decision
+with the expected effect.
+
+A fixture (YAML or JSON) lists cases describing a tool call or transition
+and the expected effect (allow, deny, require_approval or none). Rules are
+taken from the fixture itself, or from the agent configuration when -c is
+given.
+
+Examples:
+  # Test the rules embedded in a fixture
+  agent policy test policy_test.yaml
+
+  # Test the rules of an agent configuration
+  agent policy test -c config.yaml fixtures/*.yaml`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return a.runPolicyTests(opts, args)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.configPath, "config", "c", "", "Path to configuration file whose rules are tested")
+	cmd.Flags().BoolVarP(&opts.verbose, "verbose", "v", false, "Show passing cases")
+
+	return cmd
+}
+
+// runPolicyTests runs each fixture and reports failures.
+func (a *App) runPolicyTests(opts *policyTestOptions, fixtures []string) error {
+	var rules *api.RuleSet
+	if opts.configPath != "" {
+		config, err := api.NewConfigLoader().LoadFile(opts.configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration: %w", err)
+		}
+		result, err := api.NewConfigBuilder(config).Build()
+		if err != nil {
+			return fmt.Errorf("failed to build configuration: %w", err)
+		}
+		rules = result.Rules
+		if rules == nil {
+			rules = &api.RuleSet{}
+		}
+	}
+
+	passed, failed := 0, 0
+	for _, path := range fixtures {
+		fixture, err := api.LoadPolicyFixture(path)
+		if err != nil {
+			return err
+		}
+		results, err := api.RunPolicyFixture(rules, fixture)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, r := range results {
+			if r.Passed {
+				passed++
+				if opts.verbose {
+					_, _ = fmt.Fprintf(a.stdout, "PASS %s: %s\n", path, r.Case)
+				}
+				continue
+			}
+			failed++
+			_, _ = fmt.Fprintf(a.stdout, "FAIL %s: %s: %s\n", path, r.Case, r.Message)
+		}
+	}
+
+	_, _ = fmt.Fprintf(a.stdout, "%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d policy test(s) failed", failed)
+	}
+	return nil
+}
+
+// newPolicySimulateCmd creates the policy simulate command.
+func (a *App) newPolicySimulateCmd() *cobra.Command {
+	opts := &policySimulateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Replay recorded runs under a policy version",
+		Long: `Re-evaluate recorded runs under a different policy version and report
+which tool calls would have been ineligible, which transitions blocked,
+where budgets would have been exhausted and which calls would have
+required approval. Use it to validate a proposal before approving it.
+
+Runs are read from an export of the event store (JSON lines or a JSON
+array of events); policy versions from a JSON file holding a version or an
+array of versions. Constraints registered on an engine in code are not
+part of a policy version and are not simulated.
+
+Examples:
+  # Simulate version 3 over every recorded run
+  agent policy simulate --version 3 --versions versions.json --events events.jsonl
+
+  # Simulate selected runs and print the report as JSON
+  agent policy simulate --version 3 --versions versions.json --events events.jsonl \
+    --runs run-1,run-2 --json`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return a.runPolicySimulate(cmd.Context(), opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.version, "version", 0, "Policy version to simulate (required)")
+	cmd.Flags().StringVar(&opts.versionsPath, "versions", "", "Policy versions file (required)")
+	cmd.Flags().StringVar(&opts.eventsPath, "events", "", "Event store export (required)")
+	cmd.Flags().StringSliceVar(&opts.runs, "runs", nil, "Run IDs to simulate (default: all runs)")
+	cmd.Flags().BoolVar(&opts.outputJSON, "json", false, "Output the report as JSON")
+
+	_ = cmd.MarkFlagRequired("version")
+	_ = cmd.MarkFlagRequired("versions")
+	_ = cmd.MarkFlagRequired("events")
+
+	return cmd
+}
+
+// runPolicySimulate loads the version and events and prints the report.
+func (a *App) runPolicySimulate(ctx context.Context, opts *policySimulateOptions) error {
+	version, err := loadPolicyVersion(opts.versionsPath, opts.version)
+	if err != nil {
+		return err
+	}
+	store, err := loadEventExport(ctx, opts.eventsPath)
+	if err != nil {
+		return err
+	}
+
+	report, err := api.NewReplay(store).Simulate(ctx, api.SimulationConfig{
+		Version: version,
+		RunIDs:  opts.runs,
+	})
+	if err != nil {
+		return fmt.Errorf("simulation failed: %w", err)
+	}
+
+	if opts.outputJSON {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	a.printSimulation(report)
+	return nil
+}
+
+// printSimulation prints the simulation report as a diff per run.
+func (a *App) printSimulation(report *api.SimulationReport) {
+	_, _ = fmt.Fprintf(a.stdout, "Simulating policy version %d over %d run(s)\n", report.PolicyVersion, len(report.Runs))
+	_, _ = fmt.Fprintln(a.stdout, "Constraints registered on engines in code are not simulated.")
+	_, _ = fmt.Fprintln(a.stdout)
+	for _, run := range report.Runs {
+		verdict := "no change"
+		switch {
+		case run.Blocked():
+			verdict = "would have been blocked"
+		case len(run.Findings) > 0:
+			verdict = "would have required approval"
+		}
+		_, _ = fmt.Fprintf(a.stdout, "%s (version %d, %s): %s\n", run.RunID, run.PolicyVersion, run.Status, verdict)
+		for _, f := range run.Findings {
+			subject := f.ToolName
+			switch f.Kind {
+			case api.FindingTransitionBlocked:
+				subject = fmt.Sprintf("%s -> %s", f.State, f.ToState)
+			case api.FindingBudgetExhausted:
+				subject = f.Budget
+			}
+			_, _ = fmt.Fprintf(a.stdout, "  - #%d %-18s %s: %s\n", f.Sequence, f.Kind, subject, f.Reason)
+		}
+	}
+
+	_, _ = fmt.Fprintf(a.stdout, "\n%d of %d run(s) affected: %d tool call(s) ineligible, %d transition(s) blocked, %d budget(s) exhausted, %d approval(s) required\n",
+		report.Affected(), len(report.Runs),
+		report.Counts[api.FindingToolIneligible],
+		report.Counts[api.FindingTransitionBlocked],
+		report.Counts[api.FindingBudgetExhausted],
+		report.Counts[api.FindingApprovalRequired])
+}
+
+// loadPolicyVersion reads a policy version, or an array of versions, and
+// returns the requested one.
+func loadPolicyVersion(path string, number int) (*api.PolicyVersion, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is provided by the CLI user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy versions: %w", err)
+	}
+
+	var versions []*api.PolicyVersion
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &versions)
+	} else {
+		var v api.PolicyVersion
+		err = json.Unmarshal(data, &v)
+		versions = append(versions, &v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy versions: %w", err)
+	}
+
+	for _, v := range versions {
+		if v.Version == number {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("policy version %d not found in %s", number, path)
+}
+
+// loadEventExport reads exported events, as JSON lines or a JSON array,
+// into an in-memory event store, preserving each run's event order.
+func loadEventExport(ctx context.Context, path string) (api.EventStore, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is provided by the CLI user
+	if err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	var events []api.StoredEvent
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(data, &events); err != nil {
+			return nil, fmt.Errorf("failed to parse events: %w", err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+				continue
+			}
+			var evt api.StoredEvent
+			if err := json.Unmarshal(scanner.Bytes(), &evt); err != nil {
+				return nil, fmt.Errorf("failed to parse events: line %d: %w", line, err)
+			}
+			events = append(events, evt)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read events: %w", err)
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].RunID != events[j].RunID {
+			return events[i].RunID < events[j].RunID
+		}
+		return events[i].Sequence < events[j].Sequence
+	})
+	store := api.NewEventStore()
+	if err := store.Append(ctx, events...); err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+	return store, nil
+}
@@ -163,6 +163,14 @@ func (a *App) runAgent(ctx context.Context, opts *runOptions) error {
 		engineOpts = append(engineOpts, api.WithToolEligibility(result.Eligibility))
 	}
 
+	if result.Rules != nil {
+		engineOpts = append(engineOpts, api.WithRules(result.Rules))
+	}
+
+	if result.ApprovalPolicy != nil {
+		engineOpts = append(engineOpts, api.WithApprovalPolicy(*result.ApprovalPolicy))
+	}
+
 	if result.RateLimitConfig != nil && result.RateLimitConfig.Enabled {
 		engineOpts = append(engineOpts, api.WithRateLimit(
 			result.RateLimitConfig.Rate,
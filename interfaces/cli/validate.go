@@ -119,6 +119,10 @@ func (a *App) validateConfig(opts *validateOptions) error {
 		}
 	}
 
+	if len(config.Policy.Rules) > 0 {
+		_, _ = fmt.Fprintf(a.stdout, "  Policy rules: %d\n", len(config.Policy.Rules))
+	}
+
 	if config.Policy.RateLimit.Enabled {
 		_, _ = fmt.Fprintf(a.stdout, "  Rate limiting: enabled (rate=%d, burst=%d)\n",
 			config.Policy.RateLimit.Rate, config.Policy.RateLimit.Burst)